@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"expvar"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,10 +11,21 @@ import (
 	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditarchive"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditsink"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/challenge"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/config"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/dataexport"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/emailtemplate"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/notify"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/platform"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/webhook"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/pkg/logger"
 	"github.com/getsentry/sentry-go"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -110,16 +123,42 @@ func main() {
 	authConfig := auth.AuthConfig{
 		AllowPublicRegistration: true, // Default to true for now
 		DefaultAppURL:           os.Getenv("APP_URL"),
+		// DELETE /auth/account closes the account now; cmd/worker purges it after this
+		AccountDeletionCoolingOff: config.Load().AccountDeletionCoolingOff,
 	}
 	if authConfig.DefaultAppURL == "" {
 		authConfig.DefaultAppURL = "https://auth.laventecare.nl"
 	}
 
 	// Audit Service
-	auditLogger := audit.NewDBLogger(queries, log)
+	// Events are queued and written in batches off the request path; while the
+	// database is unreachable they are kept in AUDIT_SPOOL_PATH and replayed.
+	auditConfig := config.Load()
+	auditBackpressure, err := audit.ParseBackpressure(auditConfig.AuditBackpressure)
+	if err != nil {
+		log.Error("audit_backpressure_invalid", "error", err)
+		os.Exit(1)
+	}
+	auditWriter := audit.NewAsyncWriter(pool, audit.WriterConfig{
+		QueueSize:    auditConfig.AuditQueueSize,
+		BatchSize:    auditConfig.AuditBatchSize,
+		Backpressure: auditBackpressure,
+		SpoolPath:    auditConfig.AuditSpoolPath,
+	}, log)
+	// Queue depth, drops and spool size on /platform/v1/metrics
+	expvar.Publish("audit_writer", expvar.Func(func() interface{} { return auditWriter.Stats() }))
+	auditSinks := []audit.Sink{auditWriter}
+	if auditConfig.AuditStdout {
+		auditSinks = append(auditSinks, audit.NewJSONSink(os.Stdout))
+	}
+	auditLogger := audit.NewAuditService(log, auditSinks...)
 
 	authService := auth.NewAuthService(authConfig, pool, queries, hasher, tokenProvider, mfaService, auditLogger, emailSender)
 
+	// Platform Plane (/platform/v1): operators, tenant lifecycle, impersonation
+	// DELETE schedules offboarding; cmd/worker purges after TENANT_DELETION_GRACE_DAYS
+	platformService := platform.NewService(pool, hasher, mfaService, tokenProvider, auditLogger, config.Load().TenantDeletionGrace)
+
 	// IoT Service (Centralized Config)
 	iotConfig := auth.IoTConfig{
 		ConvexURL:       os.Getenv("CONVEX_WEBHOOK_URL"),
@@ -133,9 +172,109 @@ func main() {
 
 	iotService := auth.NewIoTService(queries, iotConfig)
 
+	// Rate Limit Backend
+	// Postgres shares counters across replicas; memory is only safe for a single instance.
+	rateLimitBackend := os.Getenv("RATE_LIMIT_BACKEND")
+	if rateLimitBackend == "" {
+		rateLimitBackend = "memory"
+		if env == "production" {
+			rateLimitBackend = "postgres"
+		}
+	}
+	var rateLimitStore ratelimit.Store
+	switch rateLimitBackend {
+	case "postgres":
+		rateLimitStore = ratelimit.NewPostgresStore(queries)
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore(time.Minute)
+	default:
+		log.Error("rate_limit_backend_invalid", "backend", rateLimitBackend)
+		os.Exit(1)
+	}
+	log.Info("rate_limit_backend", "backend", rateLimitBackend)
+
+	// Bot Challenge (PoW / CAPTCHA)
+	// Puzzles are stateless HMAC tokens: all replicas must share CHALLENGE_SECRET.
+	challengeSecret := []byte(os.Getenv("CHALLENGE_SECRET"))
+	if len(challengeSecret) == 0 {
+		log.Warn("challenge_secret_missing", "details", "using_ephemeral_secret")
+		challengeSecret = make([]byte, 32)
+		if _, err := rand.Read(challengeSecret); err != nil {
+			log.Error("challenge_secret_generate_failed", "error", err)
+			os.Exit(1)
+		}
+	}
+	var captchaVerifier challenge.CaptchaVerifier
+	if captchaSecret := os.Getenv("CAPTCHA_SECRET"); captchaSecret != "" {
+		captchaVerifier = challenge.NewSiteVerifyCaptcha(
+			os.Getenv("CAPTCHA_PROVIDER"),
+			os.Getenv("CAPTCHA_VERIFY_URL"),
+			captchaSecret,
+			os.Getenv("CAPTCHA_SITE_KEY"),
+		)
+		log.Info("captcha_verifier_configured", "provider", os.Getenv("CAPTCHA_PROVIDER"))
+	}
+	// Owners are emailed once per 15 minutes when the login rate limit or the
+	// challenge starts refusing sign-ins to their address
+	signInNotices := customMiddleware.NewSignInNotices(authService, rateLimitStore, 15*time.Minute)
+	challengeGate := customMiddleware.NewChallengeGate(
+		challenge.NewProofOfWork(challengeSecret, 2*time.Minute, rateLimitStore),
+		captchaVerifier,
+		challenge.NewFailureTracker(rateLimitStore, 15*time.Minute),
+		queries,
+		signInNotices,
+	)
+
+	// Tenant Resolver Chain
+	// Precedence of header, /t/{slug} path, custom domain, subdomain and token tid.
+	tenantSources, err := customMiddleware.ParseTenantSources(os.Getenv("TENANT_RESOLVER_ORDER"))
+	if err != nil {
+		log.Error("tenant_resolver_order_invalid", "error", err)
+		os.Exit(1)
+	}
+	tenantConflictMode := os.Getenv("TENANT_CONFLICT_MODE")
+	if tenantConflictMode != "" && tenantConflictMode != "reject" && tenantConflictMode != "first" {
+		log.Error("tenant_conflict_mode_invalid", "mode", tenantConflictMode)
+		os.Exit(1)
+	}
+	tenantResolver := customMiddleware.NewTenantResolver(customMiddleware.TenantResolverConfig{
+		Sources:         tenantSources,
+		BaseDomain:      os.Getenv("TENANT_BASE_DOMAIN"),
+		RejectConflicts: tenantConflictMode != "first",
+		CacheTTL:        5 * time.Minute,
+	}, queries, tokenProvider)
+	log.Info("tenant_resolver", "sources", tenantSources, "base_domain", os.Getenv("TENANT_BASE_DOMAIN"))
+
 	// 6. Setup HTTP Server
 	// PHASE 50 RLS: Pool is now passed to NewServer for RLS middleware integration
-	server := api.NewServer(pool, queries, authService, tokenProvider, iotService)
+	// Personal Data Exports: the worker builds archives in the DATA_EXPORT_DIR blob
+	// store, the API serves them through HMAC-signed links. Worker and API replicas
+	// must share that store (a common volume) and DATA_EXPORT_SECRET.
+	exportDir := config.Load().DataExportDir
+	exportStore := blobstore.NewFS(exportDir)
+	if err := blobstore.Probe(context.Background(), exportStore); err != nil {
+		log.Error("data_export_store_unusable", "dir", exportDir, "error", err)
+		os.Exit(1)
+	}
+	exportSecret := []byte(os.Getenv("DATA_EXPORT_SECRET"))
+	if len(exportSecret) == 0 {
+		log.Warn("data_export_secret_missing", "details", "using_ephemeral_secret")
+		exportSecret = make([]byte, 32)
+		if _, err := rand.Read(exportSecret); err != nil {
+			log.Error("data_export_secret_generate_failed", "error", err)
+			os.Exit(1)
+		}
+	}
+	dataExports := dataexport.NewService(pool, auditLogger, dataexport.NewSigner(exportSecret), exportStore)
+
+	siemSinks := auditsink.NewService(pool, auditLogger)
+	auditRetention := auditarchive.NewService(pool, auditLogger, auditarchive.Policy{
+		MinimumDays: auditConfig.AuditRetentionMinDays,
+		DefaultDays: auditConfig.AuditRetentionDefaultDays,
+	})
+	webhooks := webhook.NewService(pool, auditLogger)
+	emailTemplates := emailtemplate.NewService(pool, auditLogger)
+	server := api.NewServer(pool, queries, authService, tokenProvider, iotService, rateLimitStore, signInNotices, challengeGate, tenantResolver, platformService, dataExports, siemSinks, auditRetention, webhooks, emailTemplates)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -186,6 +325,11 @@ func main() {
 			}
 		}
 
+		// Flush queued audit events before the pool goes away; leftovers are spooled
+		if err := auditLogger.Close(ctx); err != nil {
+			log.Error("audit_writer_close_failed", "error", err)
+		}
+
 		// Explicitly close pool to ensure connection draining
 		pool.Close()
 		log.Info("database_pool_closed")
@@ -8,8 +8,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditarchive"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/config"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
@@ -23,6 +27,10 @@ func main() {
 		fmt.Println("Usage: control <command> [args]")
 		fmt.Println("Commands:")
 		fmt.Println("  create-tenant  Create a new tenant")
+		fmt.Println("  create-platform-admin  Create a platform operator (password + TOTP)")
+		fmt.Println("  disable-platform-admin Disable a platform operator")
+		fmt.Println("  audit-verify   Verify the audit log hash chains and checkpoints")
+		fmt.Println("  audit-restore  Load an archived audit log month into audit_logs_restored")
 		os.Exit(1)
 	}
 
@@ -39,11 +47,231 @@ func main() {
 		fixMembershipCmd()
 	case "reset-password":
 		resetPasswordCmd()
+	case "create-platform-admin":
+		createPlatformAdminCmd()
+	case "disable-platform-admin":
+		disablePlatformAdminCmd()
+	case "audit-verify":
+		auditVerifyCmd()
+	case "audit-restore":
+		auditRestoreCmd()
 	default:
 		log.Fatalf("Unknown command: %s", cmd)
 	}
 }
 
+// auditVerifyCmd walks the audit hash chains (all, or one tenant) and checks
+// them against the signed checkpoints. Exits 1 when anything does not match.
+func auditVerifyCmd() {
+	fs := flag.NewFlagSet("audit-verify", flag.ExitOnError)
+	tenant := fs.String("tenant", "", "Tenant ID (UUID); all chains when empty, 00000000-0000-0000-0000-000000000000 for platform events")
+	fs.Parse(os.Args[2:])
+
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is not set")
+	}
+	jwtPrivateKey := os.Getenv("JWT_PRIVATE_KEY")
+	if jwtPrivateKey == "" {
+		log.Fatal("JWT_PRIVATE_KEY environment variable is not set (needed to check checkpoint signatures)")
+	}
+
+	pool, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+	verifier := audit.NewVerifier(storage.New(pool), auth.NewJWTProvider(jwtPrivateKey))
+
+	var reports []audit.ChainReport
+	if *tenant != "" {
+		tenantUUID, err := uuid.Parse(*tenant)
+		if err != nil {
+			log.Fatalf("Invalid tenant ID: %v", err)
+		}
+		report, err := verifier.Verify(context.Background(), tenantUUID)
+		if err != nil {
+			log.Fatalf("❌ Verification failed: %v", err)
+		}
+		reports = append(reports, report)
+	} else {
+		reports, err = verifier.VerifyAll(context.Background())
+		if err != nil {
+			log.Fatalf("❌ Verification failed: %v", err)
+		}
+	}
+
+	broken := 0
+	for _, r := range reports {
+		status := "✅"
+		if !r.OK() {
+			status = "❌"
+			broken++
+		}
+		fmt.Printf("%s chain %s: %d rows (last seq %d), %d checkpoints, %d unchained legacy rows, %d archived rows\n",
+			status, r.ChainKey, r.Rows, r.LastSeq, r.Checkpoints, r.Unchained, r.Archived)
+		for _, p := range r.Problems {
+			fmt.Printf("   seq %d %s: %s\n", p.Seq, p.Kind, p.Detail)
+		}
+	}
+
+	if broken > 0 {
+		fmt.Printf("❌ %d of %d chains failed verification\n", broken, len(reports))
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %d chains verified\n", len(reports))
+}
+
+// auditRestoreCmd loads one archived month of a chain back into the database
+// for an investigation. Restored rows live in audit_logs_restored, apart from
+// the live log; running it again replaces the earlier restore.
+func auditRestoreCmd() {
+	fs := flag.NewFlagSet("audit-restore", flag.ExitOnError)
+	tenant := fs.String("tenant", "", "Tenant ID (UUID); 00000000-0000-0000-0000-000000000000 for platform events")
+	month := fs.String("month", "", "Archived month (YYYY-MM)")
+	fs.Parse(os.Args[2:])
+
+	if *tenant == "" || *month == "" {
+		fmt.Println("Error: --tenant and --month are required")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	tenantUUID, err := uuid.Parse(*tenant)
+	if err != nil {
+		log.Fatalf("Invalid tenant ID: %v", err)
+	}
+	monthStart, err := time.Parse("2006-01", *month)
+	if err != nil {
+		log.Fatalf("Invalid month (want YYYY-MM): %v", err)
+	}
+
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is not set")
+	}
+	pool, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+
+	report, err := auditarchive.Restore(context.Background(), pool, blobstore.NewFS(cfg.AuditArchiveDir), tenantUUID, monthStart)
+	if err != nil {
+		log.Fatalf("❌ Restore failed: %v", err)
+	}
+	fmt.Printf("✅ Restored %d rows of %s (%s) into audit_logs_restored, archive_id %s\n",
+		report.Rows, *month, report.Archive.ObjectKey, uuid.UUID(report.Archive.ID.Bytes))
+	if report.Rows != report.Archive.Rows {
+		fmt.Printf("⚠️  The archive record lists %d rows\n", report.Archive.Rows)
+	}
+	for _, seq := range report.Check.Modified {
+		fmt.Printf("   seq %d %s: content does not match its hash\n", seq, audit.ProblemModified)
+	}
+	for _, seq := range report.Check.BrokenLinks {
+		fmt.Printf("   seq %d %s: prev_hash does not match row %d\n", seq, audit.ProblemBrokenLink, seq-1)
+	}
+	if !report.Check.OK() {
+		os.Exit(1)
+	}
+}
+
+// createPlatformAdminCmd creates an operator for /platform/v1.
+// Password and TOTP secret are generated here and shown once; MFA cannot be skipped.
+func createPlatformAdminCmd() {
+	fs := flag.NewFlagSet("create-platform-admin", flag.ExitOnError)
+	email := fs.String("email", "", "Operator Email")
+	fs.Parse(os.Args[2:])
+
+	if *email == "" {
+		fmt.Println("Error: --email is required")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is not set")
+	}
+
+	pool, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+	queries := storage.New(pool)
+
+	// 1. Generate Password
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		log.Fatalf("Failed to generate random bytes: %v", err)
+	}
+	rawPassword := hex.EncodeToString(bytes)
+
+	hasher := auth.NewBcryptHasher()
+	passwordHash, err := hasher.Hash(rawPassword)
+	if err != nil {
+		log.Fatalf("Failed to hash password: %v", err)
+	}
+
+	// 2. Generate TOTP Secret
+	key, _, err := auth.NewMFAService("LaventeCare Platform").GenerateSecret(*email)
+	if err != nil {
+		log.Fatalf("Failed to generate TOTP secret: %v", err)
+	}
+
+	// 3. Create Operator
+	admin, err := queries.CreatePlatformAdmin(context.Background(), db.CreatePlatformAdminParams{
+		Email:        *email,
+		PasswordHash: passwordHash,
+		MfaSecret:    key.Secret(),
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to create platform admin: %v", err)
+	}
+
+	fmt.Printf("✅ Platform Admin Created!\n")
+	fmt.Printf("----------------------------------------------------------------\n")
+	fmt.Printf("ID:    %s\n", uuid.UUID(admin.ID.Bytes))
+	fmt.Printf("Email: %s\n", admin.Email)
+	fmt.Printf("----------------------------------------------------------------\n")
+	fmt.Printf("⚠️  CREDENTIALS (SAVE THESE NOW, THEY WILL NOT BE SHOWN AGAIN) ⚠️\n")
+	fmt.Printf("Password:    %s\n", rawPassword)
+	fmt.Printf("TOTP Secret: %s\n", key.Secret())
+	fmt.Printf("TOTP URL:    %s\n", key.URL())
+	fmt.Printf("----------------------------------------------------------------\n")
+}
+
+func disablePlatformAdminCmd() {
+	fs := flag.NewFlagSet("disable-platform-admin", flag.ExitOnError)
+	email := fs.String("email", "", "Operator Email")
+	fs.Parse(os.Args[2:])
+
+	if *email == "" {
+		fmt.Println("Error: --email is required")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is not set")
+	}
+
+	pool, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+
+	// Takes effect on the next request: PlatformAuth checks is_active every time
+	cmdTag, err := pool.Exec(context.Background(),
+		"UPDATE platform_admins SET is_active = FALSE WHERE email = $1", *email)
+	if err != nil {
+		log.Fatalf("❌ Failed to disable platform admin: %v", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		log.Fatalf("❌ No platform admin found with email: %s", *email)
+	}
+
+	fmt.Printf("✅ Platform Admin %s disabled\n", *email)
+}
+
 func resetPasswordCmd() {
 	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
 	email := fs.String("email", "", "User Email")
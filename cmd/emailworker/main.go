@@ -37,6 +37,7 @@ import (
 	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/config"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/emailtemplate"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/google/uuid"
@@ -200,8 +201,8 @@ func processEmail(ctx context.Context, pool *pgxpool.Pool, logger *slog.Logger,
 		keyVersion = 1
 	}
 
-	// 4. Create SMTP provider
-	provider, err := mailer.NewSMTPProvider(smtpConfig, keyVersion)
+	// 4. Create SMTP provider (renders the tenant's templates in the recipient's locale)
+	provider, err := mailer.NewSMTPProvider(smtpConfig, keyVersion, emailtemplate.NewRenderer(pool))
 	if err != nil {
 		markFailed(ctx, pool, id, "invalid SMTP config: "+err.Error())
 		return err
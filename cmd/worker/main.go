@@ -8,9 +8,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/accountdeletion"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditarchive"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditsink"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/config"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/dataexport"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/offboarding"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/webhook"
 )
 
 func main() {
@@ -27,23 +36,72 @@ func main() {
 	defer pool.Close()
 
 	queries := storage.New(pool)
-	logger.Info("🧹 Janitor Worker Started", "interval", "1h")
+	auditSinks := []audit.Sink{audit.NewDBSink(queries, logger)}
+	if cfg.AuditStdout {
+		auditSinks = append(auditSinks, audit.NewJSONSink(os.Stdout))
+	}
+	auditLogger := audit.NewAuditService(logger, auditSinks...)
+	offboarder := offboarding.NewRunner(pool, auditLogger, cfg.OffboardingExportDir, logger)
+	exportStore := blobstore.NewFS(cfg.DataExportDir)
+	if err := blobstore.Probe(context.Background(), exportStore); err != nil {
+		logger.Error("Data export store unusable", "dir", cfg.DataExportDir, "error", err)
+		os.Exit(1)
+	}
+	exporter := dataexport.NewRunner(pool, auditLogger, exportStore, logger)
+	accountDeleter := accountdeletion.NewRunner(pool, auditLogger, logger)
+	sinkRunner := auditsink.NewRunner(pool, logger)
+	webhookRunner := webhook.NewRunner(pool, logger)
+	archiver := auditarchive.NewRunner(pool, blobstore.NewFS(cfg.AuditArchiveDir), auditarchive.Policy{
+		MinimumDays: cfg.AuditRetentionMinDays,
+		DefaultDays: cfg.AuditRetentionDefaultDays,
+	}, auditLogger, logger)
+
+	// Audit checkpoints are signed with the API's JWT key; without it the chain
+	// is still written but not anchored.
+	var checkpointer *audit.Checkpointer
+	if key := os.Getenv("JWT_PRIVATE_KEY"); key != "" {
+		checkpointer = audit.NewCheckpointer(queries, auth.NewJWTProvider(key))
+	} else {
+		logger.Warn("JWT_PRIVATE_KEY not set: audit checkpoints disabled")
+	}
+	logger.Info("🧹 Janitor Worker Started", "interval", "1h", "data_exports_interval", "1m", "audit_sinks_interval", "10s", "webhooks_interval", "10s")
 
 	// 3. Scheduler (Elk uur)
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
+	// Data exports are user-facing: pick them up within a minute
+	exportTicker := time.NewTicker(1 * time.Minute)
+	defer exportTicker.Stop()
+
+	// SIEM streaming and webhooks: polled often, backoff is per sink (FailAuditSink)
+	// and per webhook delivery (MarkWebhookDeliveryFailed)
+	sinkTicker := time.NewTicker(10 * time.Second)
+	defer sinkTicker.Stop()
+
 	// 4. Graceful Shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
 	// Directe run bij opstarten (zodat je meteen resultaat ziet in dev)
-	runJanitor(context.Background(), queries, logger)
+	runJanitor(context.Background(), queries, offboarder, exporter, accountDeleter, cfg.EmailLogRetentionDays, logger)
+	runAuditCheckpoints(context.Background(), checkpointer, logger)
+	runAuditArchive(context.Background(), archiver, logger)
+	runDataExports(context.Background(), exporter, logger)
+	runAuditSinks(context.Background(), sinkRunner, logger)
+	runWebhooks(context.Background(), webhookRunner, logger)
 
 	for {
 		select {
 		case <-ticker.C:
-			runJanitor(context.Background(), queries, logger)
+			runJanitor(context.Background(), queries, offboarder, exporter, accountDeleter, cfg.EmailLogRetentionDays, logger)
+			runAuditCheckpoints(context.Background(), checkpointer, logger)
+			runAuditArchive(context.Background(), archiver, logger)
+		case <-exportTicker.C:
+			runDataExports(context.Background(), exporter, logger)
+		case <-sinkTicker.C:
+			runAuditSinks(context.Background(), sinkRunner, logger)
+			runWebhooks(context.Background(), webhookRunner, logger)
 		case <-quit:
 			logger.Info("🛑 Janitor shutting down...")
 			return
@@ -51,7 +109,7 @@ func main() {
 	}
 }
 
-func runJanitor(ctx context.Context, q *db.Queries, logger *slog.Logger) {
+func runJanitor(ctx context.Context, q *db.Queries, offboarder *offboarding.Runner, exporter *dataexport.Runner, accountDeleter *accountdeletion.Runner, emailLogDays int, logger *slog.Logger) {
 	logger.Info("Running cleanup cycle...")
 
 	// Refresh Tokens
@@ -70,6 +128,14 @@ func runJanitor(ctx context.Context, q *db.Queries, logger *slog.Logger) {
 		logger.Info("Cleaned invitations", "deleted", count)
 	}
 
+	// Signup Requests
+	count, err = q.CleanSignupRequests(ctx)
+	if err != nil {
+		logger.Error("Failed to clean signup_requests", "error", err)
+	} else if count > 0 {
+		logger.Info("Cleaned signup_requests", "deleted", count)
+	}
+
 	// Verification Tokens
 	count, err = q.CleanExpiredVerificationTokens(ctx)
 	if err != nil {
@@ -85,4 +151,129 @@ func runJanitor(ctx context.Context, q *db.Queries, logger *slog.Logger) {
 	} else if count > 0 {
 		logger.Info("Cleaned mfa_backup_codes", "deleted", count)
 	}
+
+	// Rate Limit Buckets
+	count, err = q.CleanExpiredRateLimitBuckets(ctx)
+	if err != nil {
+		logger.Error("Failed to clean rate_limit_buckets", "error", err)
+	} else if count > 0 {
+		logger.Info("Cleaned rate_limit_buckets", "deleted", count)
+	}
+
+	// Rate Limit Events
+	count, err = q.CleanOldRateLimitEvents(ctx)
+	if err != nil {
+		logger.Error("Failed to clean rate_limit_events", "error", err)
+	} else if count > 0 {
+		logger.Info("Cleaned rate_limit_events", "deleted", count)
+	}
+
+	// Email Logs (in batches; the first run can face years of history)
+	var emailLogs int64
+	for {
+		count, err = q.CleanOldEmailLogs(ctx, db.CleanOldEmailLogsParams{RetentionDays: int32(emailLogDays), BatchSize: 1000})
+		emailLogs += count
+		if err != nil || count < 1000 {
+			break
+		}
+	}
+	if err != nil {
+		logger.Error("Failed to clean email_logs", "error", err)
+	} else if emailLogs > 0 {
+		logger.Info("Cleaned email_logs", "deleted", emailLogs)
+	}
+
+	// Webhook Deliveries (the delivery log)
+	count, err = q.CleanOldWebhookDeliveries(ctx)
+	if err != nil {
+		logger.Error("Failed to clean webhook_deliveries", "error", err)
+	} else if count > 0 {
+		logger.Info("Cleaned webhook_deliveries", "deleted", count)
+	}
+
+	// Personal Data Exports past their download window
+	removed, err := exporter.CleanExpired(ctx)
+	if err != nil {
+		logger.Error("Failed to clean data exports", "error", err)
+	} else if removed > 0 {
+		logger.Info("Cleaned data exports", "deleted", removed)
+	}
+
+	// Self-service account deletions past their cooling-off period
+	purged, err := accountDeleter.RunDue(ctx)
+	if err != nil {
+		logger.Error("Failed to purge deleted accounts", "error", err)
+	} else if purged > 0 {
+		logger.Info("Purged deleted accounts", "deleted", purged)
+	}
+
+	// Tenant Offboarding (deletions past their grace period; resumes crashed runs)
+	done, err := offboarder.RunDue(ctx)
+	if err != nil {
+		logger.Error("Failed to run tenant offboarding", "error", err)
+	} else if done > 0 {
+		logger.Info("Offboarded tenants", "completed", done)
+	}
+}
+
+// runAuditCheckpoints signs the head of every audit chain that grew since the last run.
+func runAuditCheckpoints(ctx context.Context, checkpointer *audit.Checkpointer, logger *slog.Logger) {
+	if checkpointer == nil {
+		return
+	}
+	written, err := checkpointer.Run(ctx)
+	if err != nil {
+		logger.Error("Failed to write audit checkpoints", "error", err)
+	} else if written > 0 {
+		logger.Info("Wrote audit checkpoints", "count", written)
+	}
+}
+
+// runAuditArchive creates coming audit_logs partitions and archives and drops
+// the months past every tenant's retention. It runs after the checkpoints, so
+// an archived month's last rows are signed first.
+func runAuditArchive(ctx context.Context, archiver *auditarchive.Runner, logger *slog.Logger) {
+	dropped, err := archiver.Run(ctx)
+	if err != nil {
+		logger.Error("Failed to archive audit logs", "error", err)
+	} else if dropped > 0 {
+		logger.Info("Archived audit log partitions", "count", dropped)
+	}
+}
+
+func runDataExports(ctx context.Context, exporter *dataexport.Runner, logger *slog.Logger) {
+	built, err := exporter.RunPending(ctx)
+	if err != nil {
+		logger.Error("Failed to build data exports", "error", err)
+	} else if built > 0 {
+		logger.Info("Built data exports", "count", built)
+	}
+}
+
+// runAuditSinks delivers new audit events to the tenants' SIEM sinks.
+func runAuditSinks(ctx context.Context, runner *auditsink.Runner, logger *slog.Logger) {
+	delivered, err := runner.RunDue(ctx)
+	if err != nil {
+		logger.Error("Failed to run audit sinks", "error", err)
+	} else if delivered > 0 {
+		logger.Info("Delivered audit events to sinks", "count", delivered)
+	}
+}
+
+// runWebhooks queues new security events for the tenants' webhooks and sends
+// the deliveries that are due.
+func runWebhooks(ctx context.Context, runner *webhook.Runner, logger *slog.Logger) {
+	queued, err := runner.Enqueue(ctx)
+	if err != nil {
+		logger.Error("Failed to queue webhook deliveries", "error", err)
+	} else if queued > 0 {
+		logger.Info("Queued webhook deliveries", "count", queued)
+	}
+
+	delivered, err := runner.DeliverDue(ctx)
+	if err != nil {
+		logger.Error("Failed to send webhooks", "error", err)
+	} else if delivered > 0 {
+		logger.Info("Delivered webhooks", "count", delivered)
+	}
 }
@@ -0,0 +1,100 @@
+// Package accountdeletion purges accounts closed through DELETE /auth/account.
+//
+// The auth service schedules the deletion and closes the account; once the
+// cooling-off period is over the janitor worker deletes the user with its
+// memberships, sessions, MFA backup codes and pending email changes. Audit
+// entries are append-only and keep the user ID, which no longer resolves to
+// anyone and so remains only as a pseudonym.
+package accountdeletion
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Deletion statuses (account_deletions.status).
+const (
+	StatusScheduled = "scheduled"
+	StatusCancelled = "cancelled"
+	StatusCompleted = "completed"
+)
+
+type purgeFunc func(q *db.Queries, ctx context.Context, userID pgtype.UUID) (int64, error)
+
+// purges run in this order inside one transaction; the users row goes last.
+// Verification tokens and data exports follow the user by cascade.
+var purges = []struct {
+	table string
+	purge purgeFunc
+}{
+	{"memberships", (*db.Queries).PurgeUserMemberships},
+	{"refresh_tokens", (*db.Queries).PurgeUserRefreshTokens},
+	{"mfa_backup_codes", (*db.Queries).PurgeUserMfaBackupCodes},
+	{"email_change_requests", (*db.Queries).PurgeUserEmailChangeRequests},
+	{"users", (*db.Queries).PurgeUser},
+}
+
+// Runner purges accounts whose cooling-off period has passed. Run it from cmd/worker.
+type Runner struct {
+	pool   *pgxpool.Pool
+	audit  *audit.AuditService
+	logger *slog.Logger
+}
+
+func NewRunner(pool *pgxpool.Pool, audit *audit.AuditService, logger *slog.Logger) *Runner {
+	return &Runner{pool: pool, audit: audit, logger: logger}
+}
+
+// RunDue purges every due account and returns how many were deleted. Each
+// account is purged in a single transaction together with its status change,
+// so a crash leaves it either untouched or completely gone.
+func (r *Runner) RunDue(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		var deletion db.AccountDeletion
+		deleted := map[string]int64{}
+		err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			q := db.New(tx)
+			var err error
+			deletion, err = q.ClaimDueAccountDeletion(ctx)
+			if err != nil {
+				return err
+			}
+			for _, p := range purges {
+				n, err := p.purge(q, ctx, deletion.UserID)
+				if err != nil {
+					return err
+				}
+				deleted[p.table] = n
+			}
+			return q.CompleteAccountDeletion(ctx, deletion.ID)
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return purged, nil
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged++
+
+		// Tombstone: the pseudonymous user ID and what was removed, nothing else
+		r.audit.Log(ctx, audit.EventUserDeleted, audit.LogParams{
+			TargetID: deletion.UserID.Bytes,
+			TenantID: deletion.TenantID.Bytes,
+			Metadata: map[string]interface{}{
+				"deleted":      deleted,
+				"requested_at": deletion.RequestedAt.Time,
+			},
+		})
+		r.logger.Info("AccountDeletion: Account purged", "deletion_id", uuid.UUID(deletion.ID.Bytes), "tenant_id", uuid.UUID(deletion.TenantID.Bytes))
+	}
+}
@@ -0,0 +1,20 @@
+package accountdeletion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurges_CoverUserDataUsersLast(t *testing.T) {
+	tables := make([]string, len(purges))
+	for i, p := range purges {
+		tables[i] = p.table
+	}
+	require.NotEmpty(t, tables)
+	assert.Equal(t, "users", tables[len(tables)-1], "children must go before the user row")
+	for _, table := range []string{"memberships", "refresh_tokens", "mfa_backup_codes", "email_change_requests"} {
+		assert.Contains(t, tables, table)
+	}
+}
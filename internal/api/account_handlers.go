@@ -1,13 +1,15 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/mail"
+	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
 )
 
 // Request Email Change (Protected)
@@ -35,10 +37,12 @@ func (h *AuthHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if _, err := mail.ParseAddress(req.NewEmail); err != nil {
+	addr, err := mail.ParseAddress(req.NewEmail)
+	if err != nil {
 		http.Error(w, "Invalid email format", http.StatusBadRequest)
 		return
 	}
+	req.NewEmail = addr.Address // Drop any display name
 
 	// Tenant Context
 	tenantID, err := customMiddleware.GetTenantID(r.Context())
@@ -47,20 +51,27 @@ func (h *AuthHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	token, err := h.service.RequestEmailChange(r.Context(), userID, tenantID, req.NewEmail, req.Password)
-	if err != nil {
-		slog.Warn("RequestEmailChange failed", "user", userID, "error", err)
-		http.Error(w, "Request failed", http.StatusUnauthorized)
+	err = h.service.RequestEmailChange(r.Context(), userID, tenantID, req.NewEmail, req.Password)
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		slog.Warn("RequestEmailChange: Password incorrect", "user", userID)
+		http.Error(w, "Current password incorrect", http.StatusUnauthorized)
+		return
+	case errors.Is(err, auth.ErrEmailTaken):
+		http.Error(w, "Email address already in use", http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("RequestEmailChange failed", "user", userID, "error", err)
+		http.Error(w, "Request failed", http.StatusInternalServerError)
 		return
 	}
 
-	// Return token for MVP (simulate email)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+	// The token only travels by email: confirmation link to the new address,
+	// revert link to the current one
+	helpers.RespondJSON(w, http.StatusAccepted, map[string]string{"status": "confirmation_sent"})
 }
 
-// Confirm Email Change (Public - via link token, or Protected?)
-// Usually public link from email.
+// Confirm Email Change (Public - the link token from the new inbox is the credential)
 type ConfirmEmailChangeRequest struct {
 	Token string `json:"token"`
 }
@@ -72,14 +83,164 @@ func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant Context Required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ConfirmEmailChange(r.Context(), tenantID, req.Token); err != nil {
+		h.writeEmailChangeError(w, "ConfirmEmail", err)
+		return
+	}
+
+	// All sessions were revoked with the change
+	h.clearCookies(w)
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"status": "email_updated"})
+}
+
+// RevertEmailChange handles POST /auth/account/email/revert (Public - link sent to the old address)
+func (h *AuthHandler) RevertEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmEmailChangeRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant Context Required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevertEmailChange(r.Context(), tenantID, req.Token); err != nil {
+		h.writeEmailChangeError(w, "RevertEmail", err)
+		return
+	}
+
+	h.clearCookies(w)
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"status": "email_change_reverted"})
+}
+
+// Lock Account (Public - the "this wasn't me" link of a security notification)
+type LockAccountRequest struct {
+	Token string `json:"token"`
+}
+
+// LockAccount handles POST /auth/security/lock
+func (h *AuthHandler) LockAccount(w http.ResponseWriter, r *http.Request) {
+	var req LockAccountRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant Context Required", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.LockAccount(r.Context(), tenantID, req.Token)
+	switch {
+	case errors.Is(err, auth.ErrInvalidLockToken):
+		http.Error(w, "Invalid or expired link", http.StatusBadRequest)
+		return
+	case err != nil:
+		slog.Error("LockAccount: Failed", "error", err)
+		http.Error(w, "Lock failed", http.StatusInternalServerError)
+		return
+	}
+
+	// All sessions were revoked; a password reset unlocks the account
+	h.clearCookies(w)
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"status": "account_locked"})
+}
+
+func (h *AuthHandler) writeEmailChangeError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidEmailChangeToken):
+		http.Error(w, "Invalid or expired link", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrEmailTaken):
+		http.Error(w, "Email address already in use", http.StatusConflict)
+	default:
+		slog.Error(op+": Failed", "error", err)
+		http.Error(w, "Confirmation failed", http.StatusInternalServerError)
+	}
+}
+
+// Delete Account (Protected, re-authentication required)
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+	TOTPCode string `json:"totp_code"` // Required when MFA is enabled
+}
+
+// DeleteAccount handles DELETE /auth/account
+// Closes the account now and schedules the purge after the cooling-off period.
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID := customMiddleware.MustGetUserID(r.Context())
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	var req DeleteAccountRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Password == "" {
+		http.Error(w, "Password required", http.StatusBadRequest)
+		return
+	}
+
+	purgeAfter, err := h.service.ScheduleAccountDeletion(r.Context(), userID, tenantID, req.Password, req.TOTPCode)
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		slog.Warn("DeleteAccount: Password incorrect", "user", userID)
+		http.Error(w, "Current password incorrect", http.StatusUnauthorized)
+		return
+	case errors.Is(err, auth.ErrInvalidCode):
+		http.Error(w, "Invalid MFA code", http.StatusUnauthorized)
+		return
+	case errors.Is(err, auth.ErrAccountPendingDeletion):
+		http.Error(w, "Account is already scheduled for deletion", http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("DeleteAccount failed", "user", userID, "error", err)
+		http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		return
+	}
+
+	// All sessions are revoked; drop the cookies of this one as well
+	h.clearCookies(w)
+	helpers.RespondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":      "deletion_scheduled",
+		"purge_after": purgeAfter.UTC().Format(time.RFC3339),
+	})
+}
+
+// Cancel Account Deletion (Public - via the emailed link)
+type CancelAccountDeletionRequest struct {
+	Token string `json:"token"`
+}
+
+// CancelAccountDeletion handles POST /auth/account/cancel-deletion
+func (h *AuthHandler) CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	var req CancelAccountDeletionRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" {
+		http.Error(w, "Token required", http.StatusBadRequest)
+		return
+	}
 
-	if err := h.service.ConfirmEmailChange(r.Context(), req.Token); err != nil {
-		slog.Warn("ConfirmEmail failed", "error", err)
-		http.Error(w, "Confirmation failed", http.StatusBadRequest)
+	if err := h.service.CancelAccountDeletion(r.Context(), req.Token); err != nil {
+		if errors.Is(err, auth.ErrInvalidCancelToken) {
+			http.Error(w, "Invalid or expired link", http.StatusBadRequest)
+			return
+		}
+		slog.Error("CancelAccountDeletion failed", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"email_updated"}`))
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"status": "deletion_cancelled"})
 }
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnexpectedQuery = errors.New("unexpected query")
+
+// memberRolesDB answers GetMembership from a map and fails every other query,
+// so a handler that gets past its checks cannot change anything.
+type memberRolesDB map[uuid.UUID]string
+
+func (m memberRolesDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if !strings.Contains(sql, "name: GetMembership :one") {
+		return scanRow{err: errUnexpectedQuery}
+	}
+	role, ok := m[args[0].(pgtype.UUID).Bytes]
+	if !ok {
+		return scanRow{err: pgx.ErrNoRows}
+	}
+	return scanRow{value: role}
+}
+
+func (memberRolesDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errUnexpectedQuery
+}
+func (memberRolesDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errUnexpectedQuery
+}
+func (memberRolesDB) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, errUnexpectedQuery
+}
+
+type scanRow struct {
+	value string
+	err   error
+}
+
+func (r scanRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*string) = r.value
+	return nil
+}
+
+func TestMemberManagement_RefusesHigherPrivilegedTarget(t *testing.T) {
+	tenantID, actor, admin := uuid.New(), uuid.New(), uuid.New()
+	svc := auth.NewAuthService(auth.AuthConfig{}, nil, db.New(memberRolesDB{actor: "user-manager", admin: permissions.RoleAdmin}), nil, nil, nil, nil, nil)
+	h := &AuthHandler{service: svc}
+
+	// A custom role with users:manage, but none of the admin permissions
+	actorPerms := []string{permissions.UsersRead, permissions.UsersManage}
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), customMiddleware.TenantIDKey, tenantID)
+			ctx = context.WithValue(ctx, customMiddleware.UserIDKey, actor)
+			ctx = context.WithValue(ctx, customMiddleware.PermissionsKey, actorPerms)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Patch("/users/{userID}", h.UpdateRole)
+	r.Delete("/users/{userID}", h.RemoveUser)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/users/"+target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("demote admin", func(t *testing.T) {
+		rec := do(http.MethodPatch, admin.String(), `{"role":"viewer"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "permissions you do not hold")
+	})
+	t.Run("remove admin", func(t *testing.T) {
+		rec := do(http.MethodDelete, admin.String(), "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("not a member", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, uuid.NewString(), "").Code)
+	})
+
+	// An admin may manage the admin
+	require.NoError(t, svc.CheckMemberManageable(context.Background(), tenantID, admin, permissions.Builtin[permissions.RoleAdmin]))
+}
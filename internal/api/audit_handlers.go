@@ -1,129 +1,345 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// ListAuditLogs handles GET /admin/audit-logs
-// Returns paginated audit logs for the current tenant
-//
-// ✅ ADMIN ONLY: Protected by requireRBAC("admin") middleware
-// ✅ TENANT ISOLATED: Only shows logs for current tenant via RLS
-func (h *AuthHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
-	// 1. Get tenant from context
-	tenantID, err := customMiddleware.GetTenantID(r.Context())
-	if err != nil {
-		http.Error(w, "Tenant context required", http.StatusBadRequest)
-		return
+// auditLogResponse is one entry of the audit log API.
+type auditLogResponse struct {
+	ID        uuid.UUID       `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Action    string          `json:"action"`
+	Severity  audit.Severity  `json:"severity"`
+	ActorID   *uuid.UUID      `json:"actor_id"`
+	TargetID  *uuid.UUID      `json:"target_id"`
+	SessionID *uuid.UUID      `json:"session_id,omitempty"`
+	Metadata  json.RawMessage `json:"metadata"`
+	IPAddress *netip.Addr     `json:"ip_address"`
+	UserAgent *string         `json:"user_agent"`
+	RequestID *string         `json:"request_id"`
+}
+
+func newAuditLogResponses(logs []db.AuditLog) []auditLogResponse {
+	optionalUUID := func(u pgtype.UUID) *uuid.UUID {
+		if !u.Valid {
+			return nil
+		}
+		id := uuid.UUID(u.Bytes)
+		return &id
+	}
+	optionalText := func(t pgtype.Text) *string {
+		if !t.Valid {
+			return nil
+		}
+		return &t.String
 	}
 
-	// 2. Parse pagination parameters
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	resp := make([]auditLogResponse, len(logs))
+	for i, l := range logs {
+		metadata := json.RawMessage(l.Metadata)
+		if len(metadata) == 0 {
+			metadata = json.RawMessage("{}")
+		}
+		resp[i] = auditLogResponse{
+			ID:        l.ID.Bytes,
+			Timestamp: l.Timestamp.Time,
+			Action:    l.Action,
+			Severity:  audit.SeverityOf(l.Action),
+			ActorID:   optionalUUID(l.ActorID),
+			TargetID:  optionalUUID(l.TargetID),
+			SessionID: optionalUUID(l.SessionID),
+			Metadata:  metadata,
+			IPAddress: l.IpAddress,
+			UserAgent: optionalText(l.UserAgent),
+			RequestID: optionalText(l.RequestID),
+		}
 	}
+	return resp
+}
+
+// Audit log queries page newest first with an opaque keyset cursor on
+// (timestamp, id), so deep pages stay cheap and stable while rows are added.
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 100
+	maxAuditMetadataKeys = 10
+)
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 50 // Default limit
+// auditQuery holds the parsed query parameters of the audit log endpoints.
+type auditQuery struct {
+	ActionPattern pgtype.Text // LIKE pattern; "auth.*" matches the auth. prefix
+	ActorID       pgtype.UUID
+	TargetID      pgtype.UUID
+	Since         pgtype.Timestamptz // Inclusive
+	Until         pgtype.Timestamptz // Exclusive
+	IPRange       *netip.Prefix      // Single IP or CIDR
+	MetadataKeys  []string           // All must be present
+	CursorTs      pgtype.Timestamptz
+	CursorID      pgtype.UUID
+	Limit         int
+}
+
+// parseAuditQuery reads action, actor_id, target_id, since, until (RFC 3339),
+// ip, metadata_key (repeatable), cursor and limit.
+func parseAuditQuery(v url.Values) (auditQuery, error) {
+	q := auditQuery{Limit: defaultAuditPageSize}
+
+	if action := v.Get("action"); action != "" {
+		q.ActionPattern = pgtype.Text{String: actionPattern(action), Valid: true}
+	}
+	for name, dst := range map[string]*pgtype.UUID{"actor_id": &q.ActorID, "target_id": &q.TargetID} {
+		if raw := v.Get(name); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s", name)
+			}
+			*dst = pgtype.UUID{Bytes: id, Valid: true}
+		}
 	}
+	for name, dst := range map[string]*pgtype.Timestamptz{"since": &q.Since, "until": &q.Until} {
+		if raw := v.Get(name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s: use RFC 3339", name)
+			}
+			*dst = pgtype.Timestamptz{Time: t, Valid: true}
+		}
+	}
+	if raw := v.Get("ip"); raw != "" {
+		prefix, err := parseIPRange(raw)
+		if err != nil {
+			return q, errors.New("invalid ip: use an address or CIDR range")
+		}
+		q.IPRange = &prefix
+	}
+	for _, key := range v["metadata_key"] {
+		if key == "" {
+			return q, errors.New("invalid metadata_key")
+		}
+		q.MetadataKeys = append(q.MetadataKeys, key)
+	}
+	if len(q.MetadataKeys) > maxAuditMetadataKeys {
+		return q, fmt.Errorf("at most %d metadata_key values", maxAuditMetadataKeys)
+	}
+	if raw := v.Get("cursor"); raw != "" {
+		ts, id, err := decodeAuditCursor(raw)
+		if err != nil {
+			return q, errors.New("invalid cursor")
+		}
+		q.CursorTs = pgtype.Timestamptz{Time: ts, Valid: true}
+		q.CursorID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	if raw := v.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxAuditPageSize {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxAuditPageSize)
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
 
-	offset := (page - 1) * limit
+// actionPattern turns an action filter into a LIKE pattern: a trailing "*"
+// matches any suffix, everything else literally.
+func actionPattern(action string) string {
+	prefix, wildcard := strings.CutSuffix(action, "*")
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	if wildcard {
+		return escaped + "%"
+	}
+	return escaped
+}
 
-	// 3. Execute query
-	queries := db.New(h.Pool)
-	logs, err := queries.ListAuditLogs(r.Context(), db.ListAuditLogsParams{
-		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
-		Limit:    int32(limit),
-		Offset:   int32(offset),
-	})
+// parseIPRange accepts an address (matched exactly) or a CIDR range.
+func parseIPRange(raw string) (netip.Prefix, error) {
+	if strings.Contains(raw, "/") {
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(raw)
 	if err != nil {
-		slog.Error("ListAuditLogs: Query failed", "error", err)
-		http.Error(w, "Failed to fetch audit logs", http.StatusInternalServerError)
-		return
+		return netip.Prefix{}, err
 	}
+	addr = addr.Unmap() // Stored unmapped, see RequestMetadata
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func encodeAuditCursor(ts time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(ts.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
 
-	// 4. Get total count (optional - for pagination metadata)
-	totalCount, err := queries.CountAuditLogs(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+func decodeAuditCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		slog.Warn("ListAuditLogs: Count failed", "error", err)
-		totalCount = 0 // Non-critical, continue anyway
-	}
-
-	// 5. Return response with pagination metadata
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"logs": logs,
-		"pagination": map[string]interface{}{
-			"page":        page,
-			"limit":       limit,
-			"total_count": totalCount,
-			"total_pages": (totalCount + int64(limit) - 1) / int64(limit), // Ceiling division
-		},
-	})
+		return time.Time{}, uuid.Nil, err
+	}
+	tsPart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+	ts, err := time.Parse(time.RFC3339Nano, tsPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idPart)
+	return ts, id, err
 }
 
-// GetAuditLogsByUser handles GET /admin/audit-logs/user/{userID}
-// Returns audit logs filtered by specific user
+// auditPage trims the extra row fetched to detect a next page and returns the
+// cursor for it ("" on the last page).
+func auditPage(logs []db.AuditLog, limit int) ([]db.AuditLog, string) {
+	if len(logs) <= limit {
+		return logs, ""
+	}
+	logs = logs[:limit]
+	last := logs[limit-1]
+	return logs, encodeAuditCursor(last.Timestamp.Time, last.ID.Bytes)
+}
+
+// ListAuditLogs handles GET /admin/audit-logs
+// Returns audit logs for the current tenant, filtered and paged by parseAuditQuery.
 //
-// ✅ ADMIN ONLY: Protected by requireRBAC("admin") middleware
-func (h *AuthHandler) GetAuditLogsByUser(w http.ResponseWriter, r *http.Request) {
-	// 1. Get tenant from context
+// ✅ ADMIN ONLY: Protected by RequirePermission(audit:read)
+// ✅ TENANT ISOLATED: Always filtered on the current tenant
+func (h *AuthHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := customMiddleware.GetTenantID(r.Context())
 	if err != nil {
 		http.Error(w, "Tenant context required", http.StatusBadRequest)
 		return
 	}
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// 2. Get user ID from URL
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	logs, err := db.New(h.Pool).SearchAuditLogs(r.Context(), db.SearchAuditLogsParams{
+		TenantID:      pgtype.UUID{Bytes: tenantID, Valid: true},
+		ActionPattern: q.ActionPattern,
+		ActorID:       q.ActorID,
+		TargetID:      q.TargetID,
+		Since:         q.Since,
+		Until:         q.Until,
+		IpRange:       q.IPRange,
+		MetadataKeys:  q.MetadataKeys,
+		CursorTs:      q.CursorTs,
+		CursorID:      q.CursorID,
+		RowLimit:      int32(q.Limit + 1),
+	})
+	if err != nil {
+		slog.Error("ListAuditLogs: Query failed", "error", err)
+		http.Error(w, "Failed to fetch audit logs", http.StatusInternalServerError)
 		return
 	}
 
-	// Parse UUID (simple validation)
-	// For production, use proper UUID parsing
-	var userID pgtype.UUID
-	// userID parsing would go here - simplified for now
+	logs, next := auditPage(logs, q.Limit)
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"logs":       newAuditLogResponses(logs),
+		"pagination": auditPagination(q.Limit, next),
+	})
+}
 
-	// 3. Parse pagination
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+// ListAuditEvents handles GET /admin/audit-events: the event catalogue, so
+// admins know which actions to filter on and what metadata each carries.
+func (h *AuthHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{"events": audit.Catalogue})
+}
+
+// activityResponse is one entry of GET /auth/activity. Metadata is left out:
+// it may describe administrative details the user should not see.
+type activityResponse struct {
+	ID        uuid.UUID   `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Action    string      `json:"action"`
+	Self      bool        `json:"self"` // false: someone else (an admin) acted on the account
+	IPAddress *netip.Addr `json:"ip_address"`
+	UserAgent *string     `json:"user_agent"`
+}
+
+// ListMyActivity handles GET /auth/activity: the security events of the
+// current user in the current tenant. Supports action, since, until, cursor
+// and limit.
+func (h *AuthHandler) ListMyActivity(w http.ResponseWriter, r *http.Request) {
+	userID := customMiddleware.MustGetUserID(r.Context())
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	limit := 50
-	offset := (page - 1) * limit
 
-	// 4. Query logs by user
-	queries := db.New(h.Pool)
-	logs, err := queries.GetAuditLogsByUser(r.Context(), db.GetAuditLogsByUserParams{
-		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
-		ActorID:  userID,
-		Limit:    int32(limit),
-		Offset:   int32(offset),
+	logs, err := db.New(h.Pool).ListUserActivity(r.Context(), db.ListUserActivityParams{
+		TenantID:      pgtype.UUID{Bytes: tenantID, Valid: true},
+		UserID:        pgtype.UUID{Bytes: userID, Valid: true},
+		ActionPattern: q.ActionPattern,
+		Since:         q.Since,
+		Until:         q.Until,
+		CursorTs:      q.CursorTs,
+		CursorID:      q.CursorID,
+		RowLimit:      int32(q.Limit + 1),
 	})
 	if err != nil {
-		slog.Error("GetAuditLogsByUser: Query failed", "error", err)
-		http.Error(w, "Failed to fetch audit logs", http.StatusInternalServerError)
+		slog.Error("ListMyActivity: Query failed", "error", err)
+		http.Error(w, "Failed to fetch activity", http.StatusInternalServerError)
 		return
 	}
 
-	// 5. Return logs
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"logs": logs,
-		"pagination": map[string]interface{}{
-			"page":  page,
-			"limit": limit,
-		},
+	logs, next := auditPage(logs, q.Limit)
+	events := make([]activityResponse, len(logs))
+	for i, l := range logs {
+		events[i] = newActivityResponse(l, userID)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"events":     events,
+		"pagination": auditPagination(q.Limit, next),
 	})
 }
+
+// newActivityResponse maps an audit entry for userID. IP address and user agent
+// are only shown for the user's own actions: those of an admin stay private.
+func newActivityResponse(l db.AuditLog, userID uuid.UUID) activityResponse {
+	event := activityResponse{
+		ID:        l.ID.Bytes,
+		Timestamp: l.Timestamp.Time,
+		Action:    l.Action,
+		Self:      l.ActorID.Valid && uuid.UUID(l.ActorID.Bytes) == userID,
+	}
+	if event.Self {
+		event.IPAddress = l.IpAddress
+		if l.UserAgent.Valid {
+			event.UserAgent = &l.UserAgent.String
+		}
+	}
+	return event
+}
+
+func auditPagination(limit int, next string) map[string]interface{} {
+	pagination := map[string]interface{}{"limit": limit, "next_cursor": nil}
+	if next != "" {
+		pagination["next_cursor"] = next
+	}
+	return pagination
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuditQuery(t *testing.T) {
+	actor := uuid.New()
+	cursorID := uuid.New()
+	cursorTs := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+
+	q, err := parseAuditQuery(url.Values{
+		"action":       {"auth.*"},
+		"actor_id":     {actor.String()},
+		"since":        {"2026-03-01T00:00:00Z"},
+		"until":        {"2026-03-02T00:00:00+01:00"},
+		"ip":           {"::ffff:203.0.113.7"},
+		"metadata_key": {"method", "impersonated_by"},
+		"cursor":       {encodeAuditCursor(cursorTs, cursorID)},
+		"limit":        {"20"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, pgtype.Text{String: "auth.%", Valid: true}, q.ActionPattern)
+	assert.Equal(t, pgtype.UUID{Bytes: actor, Valid: true}, q.ActorID)
+	assert.False(t, q.TargetID.Valid)
+	assert.Equal(t, time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC), q.Until.Time.UTC())
+	assert.Equal(t, netip.MustParsePrefix("203.0.113.7/32"), *q.IPRange)
+	assert.Equal(t, []string{"method", "impersonated_by"}, q.MetadataKeys)
+	assert.True(t, cursorTs.Equal(q.CursorTs.Time))
+	assert.Equal(t, pgtype.UUID{Bytes: cursorID, Valid: true}, q.CursorID)
+	assert.Equal(t, 20, q.Limit)
+
+	q, err = parseAuditQuery(url.Values{"ip": {"10.1.2.3/8"}})
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), *q.IPRange)
+	assert.Equal(t, defaultAuditPageSize, q.Limit)
+
+	for name, v := range map[string]url.Values{
+		"actor":  {"actor_id": {"me"}},
+		"since":  {"since": {"yesterday"}},
+		"ip":     {"ip": {"10.0.0.300"}},
+		"cursor": {"cursor": {"bm90LWEtY3Vyc29y"}},
+		"limit":  {"limit": {"500"}},
+		"key":    {"metadata_key": {""}},
+	} {
+		_, err := parseAuditQuery(v)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestActionPattern(t *testing.T) {
+	assert.Equal(t, "auth.%", actionPattern("auth.*"))
+	assert.Equal(t, `user.email\_changed`, actionPattern("user.email_changed"), "exact match, _ is not a wildcard")
+	assert.Equal(t, `100\%%`, actionPattern("100%*"))
+	assert.Equal(t, "%", actionPattern("*"))
+}
+
+func TestAuditPage(t *testing.T) {
+	logs := make([]db.AuditLog, 3)
+	for i := range logs {
+		logs[i] = db.AuditLog{
+			ID:        pgtype.UUID{Bytes: uuid.New(), Valid: true},
+			Timestamp: pgtype.Timestamptz{Time: time.Now().Add(-time.Duration(i) * time.Minute), Valid: true},
+		}
+	}
+
+	page, next := auditPage(logs, 3)
+	assert.Len(t, page, 3)
+	assert.Empty(t, next, "last page")
+
+	page, next = auditPage(logs, 2)
+	require.Len(t, page, 2)
+	ts, id, err := decodeAuditCursor(next)
+	require.NoError(t, err)
+	assert.True(t, logs[1].Timestamp.Time.Equal(ts))
+	assert.Equal(t, uuid.UUID(logs[1].ID.Bytes), id)
+}
+
+func TestNewActivityResponse_HidesOthersClient(t *testing.T) {
+	user, admin := uuid.New(), uuid.New()
+	ip := netip.MustParseAddr("203.0.113.7")
+	entry := func(actor uuid.UUID) db.AuditLog {
+		return db.AuditLog{
+			ActorID:   pgtype.UUID{Bytes: actor, Valid: true},
+			IpAddress: &ip,
+			UserAgent: pgtype.Text{String: "Firefox/128.0", Valid: true},
+		}
+	}
+
+	own := newActivityResponse(entry(user), user)
+	assert.True(t, own.Self)
+	assert.Equal(t, &ip, own.IPAddress)
+	require.NotNil(t, own.UserAgent)
+	assert.Equal(t, "Firefox/128.0", *own.UserAgent)
+
+	byAdmin := newActivityResponse(entry(admin), user)
+	assert.False(t, byAdmin.Self)
+	assert.Nil(t, byAdmin.IPAddress)
+	assert.Nil(t, byAdmin.UserAgent)
+
+	system := newActivityResponse(db.AuditLog{IpAddress: &ip}, user)
+	assert.False(t, system.Self)
+	assert.Nil(t, system.IPAddress)
+}
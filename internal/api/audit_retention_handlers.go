@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditarchive"
+)
+
+type auditRetentionResponse struct {
+	RetentionDays       int  `json:"retention_days"`        // In effect
+	TenantRetentionDays *int `json:"tenant_retention_days"` // null: platform default
+	MinimumDays         int  `json:"minimum_days"`
+	DefaultDays         int  `json:"default_days"`
+	MaximumDays         int  `json:"maximum_days"`
+}
+
+func newAuditRetentionResponse(r auditarchive.Retention) auditRetentionResponse {
+	return auditRetentionResponse{
+		RetentionDays:       r.Days,
+		TenantRetentionDays: r.TenantDays,
+		MinimumDays:         r.MinimumDays,
+		DefaultDays:         r.DefaultDays,
+		MaximumDays:         auditarchive.MaxRetentionDays,
+	}
+}
+
+// GetAuditRetention handles GET /admin/audit-retention
+func (h *AuthHandler) GetAuditRetention(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	retention, err := h.AuditRetention.Get(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("GetAuditRetention: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to load audit retention", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newAuditRetentionResponse(retention))
+}
+
+// UpdateAuditRetention handles PUT /admin/audit-retention
+// {"retention_days": null} returns to the platform default.
+func (h *AuthHandler) UpdateAuditRetention(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+
+	var req struct {
+		RetentionDays *int `json:"retention_days"`
+	}
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	retention, err := h.AuditRetention.Set(r.Context(), tenantID, adminID, req.RetentionDays)
+	if errors.Is(err, auditarchive.ErrInvalidRetention) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("UpdateAuditRetention: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update audit retention", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newAuditRetentionResponse(retention))
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditsink"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// auditSinkResponse is a sink with its delivery status. The endpoint is shown
+// (admins configured it), the webhook secret never is.
+type auditSinkResponse struct {
+	ID                  uuid.UUID  `json:"id"`
+	Name                string     `json:"name"`
+	Kind                string     `json:"kind"`
+	Endpoint            string     `json:"endpoint"`
+	UseTLS              bool       `json:"use_tls"`
+	ActionFilter        string     `json:"action_filter,omitempty"`
+	Enabled             bool       `json:"enabled"`
+	LastSeq             int64      `json:"last_seq"`
+	Lag                 int64      `json:"lag"`
+	DeliveredCount      int64      `json:"delivered_count"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	LastDeliveryAt      *time.Time `json:"last_delivery_at"`
+	LastError           *string    `json:"last_error"`
+	LastErrorAt         *time.Time `json:"last_error_at"`
+	NextAttemptAt       time.Time  `json:"next_attempt_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	Secret              string     `json:"secret,omitempty"` // Webhooks, only in the create response
+}
+
+func newAuditSinkResponse(s auditsink.SinkStatus) auditSinkResponse {
+	resp := auditSinkResponse{
+		ID:                  s.ID.Bytes,
+		Name:                s.Name,
+		Kind:                s.Kind,
+		Endpoint:            s.Endpoint,
+		UseTLS:              s.UseTls,
+		ActionFilter:        s.ActionFilter.String,
+		Enabled:             s.Enabled,
+		LastSeq:             s.LastSeq,
+		Lag:                 s.Lag,
+		DeliveredCount:      s.DeliveredCount,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		NextAttemptAt:       s.NextAttemptAt.Time,
+		CreatedAt:           s.CreatedAt.Time,
+	}
+	if s.LastDeliveryAt.Valid {
+		resp.LastDeliveryAt = &s.LastDeliveryAt.Time
+	}
+	if s.LastError.Valid {
+		resp.LastError = &s.LastError.String
+	}
+	if s.LastErrorAt.Valid {
+		resp.LastErrorAt = &s.LastErrorAt.Time
+	}
+	return resp
+}
+
+// ListAuditSinks handles GET /admin/audit-sinks
+func (h *AuthHandler) ListAuditSinks(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	sinks, err := h.AuditSinks.List(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("ListAuditSinks: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to list audit sinks", http.StatusInternalServerError)
+		return
+	}
+	out := make([]auditSinkResponse, len(sinks))
+	for i, s := range sinks {
+		out[i] = newAuditSinkResponse(s)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{"sinks": out})
+}
+
+// CreateAuditSink handles POST /admin/audit-sinks
+// Webhook sinks get a signing secret that is returned only in this response.
+func (h *AuthHandler) CreateAuditSink(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+
+	var req struct {
+		Name         string `json:"name"`
+		Kind         string `json:"kind"`
+		Endpoint     string `json:"endpoint"`
+		UseTLS       *bool  `json:"use_tls"` // Syslog; default true
+		ActionFilter string `json:"action_filter"`
+	}
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	useTLS := req.UseTLS == nil || *req.UseTLS
+
+	sink, secret, err := h.AuditSinks.Create(r.Context(), tenantID, adminID, auditsink.CreateParams{
+		Name:         req.Name,
+		Kind:         req.Kind,
+		Endpoint:     req.Endpoint,
+		UseTLS:       useTLS,
+		ActionFilter: req.ActionFilter,
+	})
+	switch {
+	case errors.Is(err, auditsink.ErrInvalidSink):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, auditsink.ErrTooManySinks):
+		http.Error(w, fmt.Sprintf("At most %d audit sinks per tenant", auditsink.MaxSinksPerTenant), http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("CreateAuditSink: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to create audit sink", http.StatusInternalServerError)
+		return
+	}
+
+	resp := newAuditSinkResponse(auditsink.SinkStatus{AuditSink: sink})
+	resp.Secret = secret
+	helpers.RespondJSON(w, http.StatusCreated, resp)
+}
+
+// UpdateAuditSink handles PATCH /admin/audit-sinks/{sinkID}
+// Pauses or resumes delivery; the cursor is kept either way.
+func (h *AuthHandler) UpdateAuditSink(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	sinkID, err := uuid.Parse(chi.URLParam(r, "sinkID"))
+	if err != nil {
+		http.Error(w, "Invalid Sink ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := helpers.DecodeJSON(r, &req); err != nil || req.Enabled == nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sink, err := h.AuditSinks.SetEnabled(r.Context(), tenantID, adminID, sinkID, *req.Enabled)
+	switch {
+	case errors.Is(err, auditsink.ErrSinkNotFound):
+		http.Error(w, "Audit sink not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("UpdateAuditSink: Failed", "tenant_id", tenantID, "sink_id", sinkID, "error", err)
+		http.Error(w, "Failed to update audit sink", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newAuditSinkResponse(auditsink.SinkStatus{AuditSink: sink}))
+}
+
+// RetryAuditSink handles POST /admin/audit-sinks/{sinkID}/retry
+// Skips the remaining backoff; the worker picks the sink up on its next tick.
+func (h *AuthHandler) RetryAuditSink(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	sinkID, err := uuid.Parse(chi.URLParam(r, "sinkID"))
+	if err != nil {
+		http.Error(w, "Invalid Sink ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.AuditSinks.Retry(r.Context(), tenantID, sinkID)
+	switch {
+	case errors.Is(err, auditsink.ErrSinkNotFound):
+		http.Error(w, "Audit sink not found or disabled", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("RetryAuditSink: Failed", "tenant_id", tenantID, "sink_id", sinkID, "error", err)
+		http.Error(w, "Failed to retry audit sink", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// DeleteAuditSink handles DELETE /admin/audit-sinks/{sinkID}
+func (h *AuthHandler) DeleteAuditSink(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	sinkID, err := uuid.Parse(chi.URLParam(r, "sinkID"))
+	if err != nil {
+		http.Error(w, "Invalid Sink ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.AuditSinks.Delete(r.Context(), tenantID, adminID, sinkID)
+	switch {
+	case errors.Is(err, auditsink.ErrSinkNotFound):
+		http.Error(w, "Audit sink not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("DeleteAuditSink: Failed", "tenant_id", tenantID, "sink_id", sinkID, "error", err)
+		http.Error(w, "Failed to delete audit sink", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportAuditLogs handles GET /admin/audit-logs/export?format=ndjson|csv&since=&until=[&action=]
+// Streams the range oldest first as a download. since and until are required;
+// the other audit log filters do not apply.
+func (h *AuthHandler) ExportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !q.Since.Valid || !q.Until.Valid {
+		http.Error(w, "since and until are required", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = auditsink.FormatNDJSON
+	}
+	writer, err := auditsink.NewExportWriter(format, w)
+	if err != nil {
+		http.Error(w, "format must be ndjson or csv", http.StatusBadRequest)
+		return
+	}
+	params := auditsink.ExportParams{
+		Since:         q.Since.Time,
+		Until:         q.Until.Time,
+		ActionPattern: q.ActionPattern,
+		Format:        format,
+	}
+	if err := auditsink.ValidateRange(params.Since, params.Until); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s-%s-%s.%s", tenantID, params.Since.UTC().Format("20060102T150405Z"), params.Until.UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Type", auditsink.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Cache-Control", "no-store")
+
+	// Headers are sent with the first row: a later failure can only cut the
+	// download short, so it is logged.
+	rows, err := h.AuditSinks.Export(r.Context(), tenantID, adminID, params, writer)
+	if err != nil {
+		slog.Error("ExportAuditLogs: Failed", "tenant_id", tenantID, "rows_written", rows, "error", err)
+	}
+}
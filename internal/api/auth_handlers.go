@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
 	"github.com/google/uuid"
 )
 
@@ -25,11 +27,14 @@ type MeUser struct {
 	Email    string    `json:"email"`
 	FullName string    `json:"full_name"`
 	Role     string    `json:"role"`
+	// EmailVerified is only present when the tenant has an email verification policy.
+	EmailVerified *bool `json:"email_verified,omitempty"`
 }
 
 type MeTenant struct {
-	ID   uuid.UUID `json:"id"`
-	Slug string    `json:"slug"`
+	ID                uuid.UUID `json:"id"`
+	Slug              string    `json:"slug"`
+	EmailVerification string    `json:"email_verification"` // off, warn or enforce
 }
 
 // RegisterRequest defines the expected JSON body for registration.
@@ -95,18 +100,33 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		Token:    req.Token,
 	}
 
-	user, err := h.service.Register(r.Context(), input)
+	result, err := h.service.Register(r.Context(), input)
 	if err != nil {
+		// Signup rules are the tenant's published policy, so they are named
+		switch {
+		case errors.Is(err, auth.ErrPublicRegistrationDisabled), errors.Is(err, auth.ErrSignupClosed):
+			http.Error(w, "Registration is by invitation only", http.StatusForbidden)
+			return
+		case errors.Is(err, auth.ErrSignupDomainBlocked), errors.Is(err, auth.ErrSignupDomainNotAllowed):
+			http.Error(w, "Registration is not available for this email domain", http.StatusForbidden)
+			return
+		}
 		// Anti-Gravity Law 2: Silence is Golden. Log trace, return generic.
 		slog.Error("Register: Internal Error", "error", err)
 		http.Error(w, "Registration failed", http.StatusInternalServerError)
 		return
 	}
 
+	// Approval queue or domain confirmation: no account exists yet
+	if result.User == nil {
+		helpers.RespondJSON(w, http.StatusAccepted, map[string]string{"status": result.Status})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	// Don't return the full user model if it contains anything sensitive (though it shouldn't)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(result.User)
 }
 
 // LoginRequest defines the expected JSON body for login.
@@ -154,6 +174,20 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.service.Login(r.Context(), input)
 	if err != nil {
+		if errors.Is(err, auth.ErrTenantSuspended) {
+			http.Error(w, "Tenant is suspended", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, auth.ErrAccountPendingDeletion) {
+			// Only reachable with the correct password
+			http.Error(w, "Account is scheduled for deletion", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			// Tenant policy "enforce"; the service has re-sent the verification email
+			http.Error(w, "Email address not verified, check your inbox for the verification link", http.StatusForbidden)
+			return
+		}
 		// Law 2: Silence is Golden. Do not reveal if user exists or password is wrong.
 		// Note: h.service.Login already returns generic ErrInvalidCredentials, but we log here.
 		slog.Warn("Login: Failed Attempt", "email", req.Email, "error", err)
@@ -225,32 +259,7 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Set New Cookies
-	// CHIPS: Add Partitioned attribute
-	accessCookie := &http.Cookie{
-		Name:     "access_token",
-		Value:    result.AccessToken,
-		Path:     "/",
-		MaxAge:   900, // 15 min
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteNoneMode,
-	}
-	if v := accessCookie.String(); v != "" {
-		w.Header().Add("Set-Cookie", v+"; Partitioned")
-	}
-
-	refreshCookie := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    result.RefreshToken,
-		Path:     "/",
-		MaxAge:   604800, // 7 days
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteNoneMode,
-	}
-	if v := refreshCookie.String(); v != "" {
-		w.Header().Add("Set-Cookie", v+"; Partitioned")
-	}
+	h.setSessionCookies(w, result.AccessToken, result.RefreshToken)
 
 	// 5. Return Access Token (for memory client)
 	w.Header().Set("Content-Type", "application/json")
@@ -355,16 +364,23 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 3. Return Strict Response
+	policy := ctxInfo.TenantSettings.EmailVerificationPolicy()
+	var emailVerified *bool
+	if policy != domain.EmailVerificationOff {
+		emailVerified = &ctxInfo.IsEmailVerified
+	}
 	response := MeResponse{
 		User: MeUser{
-			ID:       uuid.UUID(ctxInfo.ID.Bytes),
-			Email:    ctxInfo.Email,
-			FullName: ctxInfo.FullName.String, // Handle pgtype.Text safely
-			Role:     ctxInfo.Role,
+			ID:            uuid.UUID(ctxInfo.ID.Bytes),
+			Email:         ctxInfo.Email,
+			FullName:      ctxInfo.FullName.String, // Handle pgtype.Text safely
+			Role:          ctxInfo.Role,
+			EmailVerified: emailVerified,
 		},
 		Tenant: MeTenant{
-			ID:   uuid.UUID(ctxInfo.TenantID.Bytes),
-			Slug: ctxInfo.TenantSlug,
+			ID:                uuid.UUID(ctxInfo.TenantID.Bytes),
+			Slug:              ctxInfo.TenantSlug,
+			EmailVerification: policy,
 		},
 	}
 
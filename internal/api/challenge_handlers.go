@@ -0,0 +1,108 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/challenge"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// UpdateChallengeSettingsRequest defines the request body for the bot challenge settings
+type UpdateChallengeSettingsRequest struct {
+	Mode       string `json:"mode"`
+	Threshold  int    `json:"threshold"`
+	Difficulty int    `json:"difficulty"`
+}
+
+// GetChallengeSettings handles GET /admin/security/challenge
+func (h *AuthHandler) GetChallengeSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	tenant, err := db.New(h.Pool).GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("GetChallengeSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve challenge settings", http.StatusInternalServerError)
+		return
+	}
+
+	settings := tenant.Settings.Challenge
+	if settings.Mode == "" {
+		settings.Mode = domain.ChallengeModeOff
+	}
+	helpers.RespondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateChallengeSettings handles PUT /admin/security/challenge
+// Enables/disables the adaptive proof-of-work or CAPTCHA challenge for
+// login, register and password-forgot.
+func (h *AuthHandler) UpdateChallengeSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateChallengeSettingsRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Law 1: Input is Toxic
+	switch req.Mode {
+	case domain.ChallengeModeOff, domain.ChallengeModePoW, domain.ChallengeModeCaptcha:
+	default:
+		http.Error(w, "Invalid mode: must be off, pow or captcha", http.StatusBadRequest)
+		return
+	}
+	if req.Threshold < 0 || req.Threshold > 100 {
+		http.Error(w, "Invalid threshold: must be between 0 (default) and 100", http.StatusBadRequest)
+		return
+	}
+	if req.Difficulty != 0 && (req.Difficulty < challenge.MinDifficulty || req.Difficulty > challenge.MaxDifficulty) {
+		http.Error(w, "Invalid difficulty", http.StatusBadRequest)
+		return
+	}
+
+	queries := db.New(h.Pool)
+	currentTenant, err := queries.GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("UpdateChallengeSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update challenge settings", http.StatusInternalServerError)
+		return
+	}
+
+	settings := currentTenant.Settings
+	settings.Challenge = domain.ChallengeSettings{
+		Mode:       req.Mode,
+		Threshold:  req.Threshold,
+		Difficulty: req.Difficulty,
+	}
+
+	// Update only settings, preserve other fields
+	_, err = queries.UpdateTenantConfig(r.Context(), db.UpdateTenantConfigParams{
+		ID:             currentTenant.ID,
+		AllowedOrigins: currentTenant.AllowedOrigins,
+		RedirectUrls:   currentTenant.RedirectUrls,
+		Branding:       currentTenant.Branding,
+		Settings:       settings,
+		AppUrl:         currentTenant.AppUrl,
+	})
+	if err != nil {
+		slog.Error("UpdateChallengeSettings: Database update failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update challenge settings", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Challenge settings updated", "tenant_id", tenantID, "mode", req.Mode)
+	helpers.RespondJSON(w, http.StatusOK, settings.Challenge)
+}
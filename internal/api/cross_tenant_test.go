@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// escalationTokens accepts "<user>|<tenant>|<role>|<perm,perm>" as a valid access token.
+type escalationTokens struct{}
+
+func (escalationTokens) GenerateAccessToken(userID, tenantID uuid.UUID, role string, perms []string, emailVerified *bool) (string, error) {
+	return userID.String() + "|" + tenantID.String() + "|" + role + "|" + strings.Join(perms, ","), nil
+}
+func (escalationTokens) GeneratePreAuthToken(userID uuid.UUID) (string, error) { return "", nil }
+func (escalationTokens) GetJWKS() (*auth.JWKS, error)                          { return &auth.JWKS{}, nil }
+func (escalationTokens) ValidateToken(token string) (*auth.Claims, error) {
+	parts := strings.Split(token, "|")
+	if len(parts) != 4 {
+		return nil, auth.ErrInvalidToken
+	}
+	perms := []string{}
+	if parts[3] != "" {
+		perms = strings.Split(parts[3], ",")
+	}
+	return &auth.Claims{UserID: uuid.MustParse(parts[0]), TenantID: uuid.MustParse(parts[1]), Role: parts[2], Permissions: perms, Scope: "access"}, nil
+}
+
+type escalationMemberships map[[2]uuid.UUID]string
+
+func (m escalationMemberships) GetMembership(ctx context.Context, arg db.GetMembershipParams) (string, error) {
+	role, ok := m[[2]uuid.UUID{arg.UserID.Bytes, arg.TenantID.Bytes}]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return role, nil
+}
+
+type escalationRoles map[string][]string
+
+func (m escalationRoles) GetTenantRoleByName(ctx context.Context, arg db.GetTenantRoleByNameParams) (db.TenantRole, error) {
+	perms, ok := m[arg.Name]
+	if !ok {
+		return db.TenantRole{}, pgx.ErrNoRows
+	}
+	return db.TenantRole{Name: arg.Name, Permissions: perms}, nil
+}
+
+// bindResolvedTenant stands in for TenantContext (which needs a database):
+// it copies the resolver result into the tenant context key.
+func bindResolvedTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if res, ok := customMiddleware.GetTenantResolution(r.Context()); ok {
+			r = r.WithContext(context.WithValue(r.Context(), customMiddleware.TenantIDKey, res.TenantID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TestAdminRoutes_CrossTenantEscalation sends an admin token issued for tenant A
+// to every admin route while targeting tenant B. Handlers are replaced by a
+// sentinel so only the authorization layers (AuthMiddleware + RequirePermission) decide.
+func TestAdminRoutes_CrossTenantEscalation(t *testing.T) {
+	tenantA, tenantB := uuid.New(), uuid.New()
+	attacker, viewerInB, supportInB := uuid.New(), uuid.New(), uuid.New()
+	memberships := escalationMemberships{
+		{attacker, tenantA}:   "admin",
+		{viewerInB, tenantA}:  "admin",
+		{viewerInB, tenantB}:  "viewer",
+		{supportInB, tenantA}: "admin",
+		{supportInB, tenantB}: "support",
+	}
+	roles := permissions.NewResolver(escalationRoles{"support": {permissions.UsersRead}})
+	tokens := escalationTokens{}
+
+	reached := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	// Enumerate the real admin route set.
+	routes := chi.NewRouter()
+	(&AuthHandler{}).adminRoutes(routes)
+
+	r := chi.NewRouter()
+	r.Use(customMiddleware.NewTenantResolver(customMiddleware.TenantResolverConfig{
+		Sources: []customMiddleware.TenantSource{customMiddleware.SourceHeader, customMiddleware.SourceToken},
+	}, nil, tokens).Middleware)
+	r.Use(bindResolvedTenant)
+	r.Route("/api/v1/admin", func(r chi.Router) {
+		r.Use(customMiddleware.AuthMiddleware(tokens, memberships, nil, nil, roles, nil))
+		require.NoError(t, chi.Walk(routes, func(method, route string, _ http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+			require.NotEmpty(t, middlewares, "%s %s has no permission guard", method, route)
+			r.With(middlewares...).Method(method, route, reached)
+			return nil
+		}))
+	})
+
+	do := func(method, route, token string, tenant uuid.UUID) int {
+		path := strings.NewReplacer("{userID}", uuid.NewString(), "{domainID}", uuid.NewString()).Replace(route)
+		req := httptest.NewRequest(method, "/api/v1/admin"+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Tenant-ID", tenant.String())
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	adminPerms := permissions.Builtin[permissions.RoleAdmin]
+	attackerToken, _ := tokens.GenerateAccessToken(attacker, tenantA, "admin", adminPerms, nil)
+	viewerToken, _ := tokens.GenerateAccessToken(viewerInB, tenantA, "admin", adminPerms, nil)
+	supportToken, _ := tokens.GenerateAccessToken(supportInB, tenantA, "admin", adminPerms, nil)
+
+	count := 0
+	require.NoError(t, chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		count++
+		name := method + " " + route
+
+		// Control: the same token is accepted in its own tenant
+		assert.Equal(t, http.StatusTeapot, do(method, route, attackerToken, tenantA), name)
+
+		// Admin of A, no membership in B
+		assert.Equal(t, http.StatusForbidden, do(method, route, attackerToken, tenantB), name)
+
+		// Admin of A, only a viewer in B: the admin role claim must not carry over
+		assert.Equal(t, http.StatusForbidden, do(method, route, viewerToken, tenantB), name)
+
+		// Admin of A, custom "support" role in B: only users:read carries over
+		want := http.StatusForbidden
+		if method == http.MethodGet && route == "/users" {
+			want = http.StatusTeapot
+		}
+		assert.Equal(t, want, do(method, route, supportToken, tenantB), name)
+		return nil
+	}))
+	assert.NotZero(t, count)
+}
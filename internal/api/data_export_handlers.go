@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/dataexport"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// dataExportResponse is one export as shown to the member or an admin.
+// download_url is a fresh signed link, only present while the archive is ready.
+type dataExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	OnBehalf    bool       `json:"requested_by_admin"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	SHA256      string     `json:"sha256,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+func (h *AuthHandler) newDataExportResponse(e db.DataExport) dataExportResponse {
+	resp := dataExportResponse{
+		ID:          e.ID.Bytes,
+		Status:      e.Status,
+		OnBehalf:    e.RequestedBy.Valid && e.RequestedBy.Bytes != e.UserID.Bytes,
+		CreatedAt:   e.CreatedAt.Time,
+		SizeBytes:   e.SizeBytes.Int64,
+		SHA256:      e.Sha256.String,
+		DownloadURL: h.DataExports.DownloadURL(e),
+	}
+	if e.CompletedAt.Valid {
+		resp.CompletedAt = &e.CompletedAt.Time
+	}
+	if e.ExpiresAt.Valid {
+		resp.ExpiresAt = &e.ExpiresAt.Time
+	}
+	return resp
+}
+
+// RequestDataExport handles POST /auth/account/export
+// Queues an export of the caller's own data; the worker builds it asynchronously.
+func (h *AuthHandler) RequestDataExport(w http.ResponseWriter, r *http.Request) {
+	userID := customMiddleware.MustGetUserID(r.Context())
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	h.requestDataExport(w, r, tenantID, userID, userID)
+}
+
+// ListDataExports handles GET /auth/account/exports
+func (h *AuthHandler) ListDataExports(w http.ResponseWriter, r *http.Request) {
+	userID := customMiddleware.MustGetUserID(r.Context())
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	h.listDataExports(w, r, tenantID, userID)
+}
+
+// AdminRequestDataExport handles POST /admin/users/{userID}/export
+// Lets an admin fulfil a data subject request for a member of their tenant.
+func (h *AuthHandler) AdminRequestDataExport(w http.ResponseWriter, r *http.Request) {
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	targetID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		http.Error(w, "Invalid User ID", http.StatusBadRequest)
+		return
+	}
+	h.requestDataExport(w, r, tenantID, targetID, adminID)
+}
+
+// AdminListDataExports handles GET /admin/users/{userID}/exports
+func (h *AuthHandler) AdminListDataExports(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	targetID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		http.Error(w, "Invalid User ID", http.StatusBadRequest)
+		return
+	}
+	h.listDataExports(w, r, tenantID, targetID)
+}
+
+func (h *AuthHandler) requestDataExport(w http.ResponseWriter, r *http.Request, tenantID, userID, requestedBy uuid.UUID) {
+	export, err := h.DataExports.Request(r.Context(), tenantID, userID, requestedBy)
+	switch {
+	case errors.Is(err, dataexport.ErrNotAMember):
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case errors.Is(err, dataexport.ErrExportInProgress):
+		http.Error(w, "An export is already in progress", http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("RequestDataExport: Failed", "tenant_id", tenantID, "user_id", userID, "error", err)
+		http.Error(w, "Failed to request export", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusAccepted, h.newDataExportResponse(export))
+}
+
+func (h *AuthHandler) listDataExports(w http.ResponseWriter, r *http.Request, tenantID, userID uuid.UUID) {
+	exports, err := h.DataExports.List(r.Context(), tenantID, userID)
+	if err != nil {
+		slog.Error("ListDataExports: Failed", "tenant_id", tenantID, "user_id", userID, "error", err)
+		http.Error(w, "Failed to list exports", http.StatusInternalServerError)
+		return
+	}
+	out := make([]dataExportResponse, len(exports))
+	for i, e := range exports {
+		out[i] = h.newDataExportResponse(e)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{"exports": out})
+}
+
+// DownloadDataExport handles GET /exports/{exportID}/download?expires=&signature=
+// Public: the signed link is the credential (see dataexport.Signer).
+func (h *AuthHandler) DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	exportID, err := uuid.Parse(chi.URLParam(r, "exportID"))
+	if err != nil {
+		http.Error(w, "Invalid download link", http.StatusNotFound)
+		return
+	}
+
+	f, export, err := h.DataExports.Open(r.Context(), exportID, r.URL.Query().Get("expires"), r.URL.Query().Get("signature"))
+	switch {
+	case errors.Is(err, dataexport.ErrInvalidLink):
+		slog.Warn("DownloadDataExport: Invalid signature", "export_id", exportID, "ip", helpers.GetRealIP(r))
+		http.Error(w, "Invalid download link", http.StatusNotFound)
+		return
+	case errors.Is(err, dataexport.ErrLinkExpired):
+		http.Error(w, "Download link expired", http.StatusGone)
+		return
+	case errors.Is(err, dataexport.ErrExportUnavailable):
+		http.Error(w, "Export no longer available", http.StatusGone)
+		return
+	case err != nil:
+		slog.Error("DownloadDataExport: Failed", "export_id", exportID, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="data-export-`+exportID.String()+`.zip"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(export.SizeBytes.Int64, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := io.Copy(w, f); err != nil {
+		slog.Warn("DownloadDataExport: Transfer interrupted", "export_id", exportID, "error", err)
+	}
+}
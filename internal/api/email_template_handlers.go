@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/emailtemplate"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/go-chi/chi/v5"
+)
+
+// emailTemplateError maps service errors to a response; it reports whether err was handled.
+func emailTemplateError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, emailtemplate.ErrUnknownTemplate):
+		http.Error(w, "Email template not found", http.StatusNotFound)
+	case errors.Is(err, emailtemplate.ErrUnknownLocale):
+		http.Error(w, "Invalid locale: must be nl or en", http.StatusBadRequest)
+	case errors.Is(err, emailtemplate.ErrInvalidTemplate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, emailtemplate.ErrOverrideNotFound):
+		http.Error(w, "Email template override not found", http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// ListEmailTemplates handles GET /admin/email-templates
+func (h *AuthHandler) ListEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	templates, err := h.EmailTemplates.List(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("ListEmailTemplates: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to list email templates", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"templates": templates,
+		"locales":   emailtemplate.Locales,
+	})
+}
+
+// GetEmailTemplate handles GET /admin/email-templates/{template}
+// Optional: ?locale=nl|en (default: the tenant's default locale)
+func (h *AuthHandler) GetEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	template := mailer.EmailTemplate(chi.URLParam(r, "template"))
+
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		var err error
+		if locale, err = h.EmailTemplates.DefaultLocale(r.Context(), tenantID); err != nil {
+			slog.Error("GetEmailTemplate: Failed to get tenant", "tenant_id", tenantID, "error", err)
+			http.Error(w, "Failed to get email template", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	detail, err := h.EmailTemplates.Get(r.Context(), tenantID, template, locale)
+	if emailTemplateError(w, err) {
+		return
+	}
+	if err != nil {
+		slog.Error("GetEmailTemplate: Failed", "tenant_id", tenantID, "template", template, "error", err)
+		http.Error(w, "Failed to get email template", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, detail)
+}
+
+// SaveEmailTemplate handles PUT /admin/email-templates/{template}/{locale}
+// The body replaces the override; omitted or empty parts keep the default.
+func (h *AuthHandler) SaveEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	template := mailer.EmailTemplate(chi.URLParam(r, "template"))
+	locale := chi.URLParam(r, "locale")
+
+	var req emailtemplate.Override
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	detail, err := h.EmailTemplates.Save(r.Context(), tenantID, adminID, template, locale, req)
+	if emailTemplateError(w, err) {
+		return
+	}
+	if err != nil {
+		slog.Error("SaveEmailTemplate: Failed", "tenant_id", tenantID, "template", template, "error", err)
+		http.Error(w, "Failed to save email template", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, detail)
+}
+
+// DeleteEmailTemplate handles DELETE /admin/email-templates/{template}/{locale}
+// Restores the default.
+func (h *AuthHandler) DeleteEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	template := mailer.EmailTemplate(chi.URLParam(r, "template"))
+	locale := chi.URLParam(r, "locale")
+
+	err := h.EmailTemplates.Delete(r.Context(), tenantID, adminID, template, locale)
+	if emailTemplateError(w, err) {
+		return
+	}
+	if err != nil {
+		slog.Error("DeleteEmailTemplate: Failed", "tenant_id", tenantID, "template", template, "error", err)
+		http.Error(w, "Failed to delete email template", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewEmailTemplate handles POST /admin/email-templates/{template}/preview
+// Renders with sample data and the tenant's branding. Draft parts in the body
+// are rendered instead of the saved ones, without saving them.
+func (h *AuthHandler) PreviewEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	template := mailer.EmailTemplate(chi.URLParam(r, "template"))
+
+	var req struct {
+		Locale string `json:"locale"`
+		emailtemplate.Override
+	}
+	if r.ContentLength != 0 {
+		if err := helpers.DecodeJSON(r, &req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	msg, err := h.EmailTemplates.Preview(r.Context(), tenantID, template, req.Locale, req.Override)
+	if emailTemplateError(w, err) {
+		return
+	}
+	if err != nil {
+		slog.Error("PreviewEmailTemplate: Failed", "tenant_id", tenantID, "template", template, "error", err)
+		http.Error(w, "Failed to preview email template", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{
+		"subject": msg.Subject,
+		"text":    msg.Text,
+		"html":    msg.HTML,
+	})
+}
+
+// EmailLocaleSettings is the request and response body of /admin/email-locale
+type EmailLocaleSettings struct {
+	DefaultLocale string `json:"default_locale"` // nl or en
+}
+
+// GetEmailLocale handles GET /admin/email-locale
+func (h *AuthHandler) GetEmailLocale(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	locale, err := h.EmailTemplates.DefaultLocale(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("GetEmailLocale: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve email locale", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, EmailLocaleSettings{DefaultLocale: locale})
+}
+
+// UpdateEmailLocale handles PUT /admin/email-locale
+// Members who chose a language in their profile keep receiving that one.
+func (h *AuthHandler) UpdateEmailLocale(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+
+	var req EmailLocaleSettings
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.EmailTemplates.SetDefaultLocale(r.Context(), tenantID, adminID, req.DefaultLocale)
+	if emailTemplateError(w, err) {
+		return
+	}
+	if err != nil {
+		slog.Error("UpdateEmailLocale: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update email locale", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Email locale updated", "tenant_id", tenantID, "locale", req.DefaultLocale)
+	helpers.RespondJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EmailVerificationSettings is the request and response body of /admin/security/email-verification
+type EmailVerificationSettings struct {
+	Policy string `json:"policy"` // off, warn or enforce
+}
+
+// GetEmailVerificationSettings handles GET /admin/security/email-verification
+func (h *AuthHandler) GetEmailVerificationSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	tenant, err := db.New(h.Pool).GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("GetEmailVerificationSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve email verification settings", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.RespondJSON(w, http.StatusOK, EmailVerificationSettings{Policy: tenant.Settings.EmailVerificationPolicy()})
+}
+
+// UpdateEmailVerificationSettings handles PUT /admin/security/email-verification
+// With "enforce", unverified members can no longer log in or refresh their
+// session; "warn" only exposes email_verified in tokens and /auth/me.
+func (h *AuthHandler) UpdateEmailVerificationSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	var req EmailVerificationSettings
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Law 1: Input is Toxic
+	switch req.Policy {
+	case domain.EmailVerificationOff, domain.EmailVerificationWarn, domain.EmailVerificationEnforce:
+	default:
+		http.Error(w, "Invalid policy: must be off, warn or enforce", http.StatusBadRequest)
+		return
+	}
+
+	queries := db.New(h.Pool)
+	currentTenant, err := queries.GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("UpdateEmailVerificationSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update email verification settings", http.StatusInternalServerError)
+		return
+	}
+
+	settings := currentTenant.Settings
+	settings.EmailVerification = req.Policy
+
+	// Update only settings, preserve other fields
+	_, err = queries.UpdateTenantConfig(r.Context(), db.UpdateTenantConfigParams{
+		ID:             currentTenant.ID,
+		AllowedOrigins: currentTenant.AllowedOrigins,
+		RedirectUrls:   currentTenant.RedirectUrls,
+		Branding:       currentTenant.Branding,
+		Settings:       settings,
+		AppUrl:         currentTenant.AppUrl,
+	})
+	if err != nil {
+		slog.Error("UpdateEmailVerificationSettings: Database update failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update email verification settings", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Email verification policy updated", "tenant_id", tenantID, "policy", req.Policy)
+	helpers.RespondJSON(w, http.StatusOK, EmailVerificationSettings{Policy: settings.EmailVerificationPolicy()})
+}
@@ -4,7 +4,13 @@ import (
 	"log/slog"
 	"net/http"
 
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditarchive"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditsink"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/dataexport"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/emailtemplate"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/webhook"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -13,6 +19,14 @@ type AuthHandler struct {
 	service *auth.AuthService
 	Pool    *pgxpool.Pool // For direct queries (mail config)
 	Logger  *slog.Logger
+
+	CORSCache      *customMiddleware.CORSPolicyCache // Optional: invalidated on CORS config writes
+	TenantResolver *customMiddleware.TenantResolver  // Optional: invalidated on custom domain writes
+	DataExports    *dataexport.Service               // Personal data exports (GDPR)
+	AuditSinks     *auditsink.Service                // SIEM sinks and bulk audit export
+	AuditRetention *auditarchive.Service             // Per-tenant audit log retention
+	Webhooks       *webhook.Service                  // Security event webhooks and their delivery log
+	EmailTemplates *emailtemplate.Service            // Per-tenant email template overrides and previews
 }
 
 func NewAuthHandler(service *auth.AuthService, pool *pgxpool.Pool, logger *slog.Logger) *AuthHandler {
@@ -51,3 +65,33 @@ func (h *AuthHandler) clearCookies(w http.ResponseWriter) {
 		w.Header().Add("Set-Cookie", v+"; Partitioned")
 	}
 }
+
+// setSessionCookies sets the access and refresh cookies of a new or rotated session.
+// CHIPS: Partitioned is appended by hand (Go < 1.23 workaround)
+func (h *AuthHandler) setSessionCookies(w http.ResponseWriter, accessToken, refreshToken string) {
+	accessCookie := &http.Cookie{
+		Name:     "access_token",
+		Value:    accessToken,
+		Path:     "/",
+		MaxAge:   900, // 15 min
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	}
+	if v := accessCookie.String(); v != "" {
+		w.Header().Add("Set-Cookie", v+"; Partitioned")
+	}
+
+	refreshCookie := &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     "/",
+		MaxAge:   604800, // 7 days
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	}
+	if v := refreshCookie.String(); v != "" {
+		w.Header().Add("Set-Cookie", v+"; Partitioned")
+	}
+}
@@ -1,20 +1,69 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// maxInvitationUpload bounds the CSV accepted by POST /admin/invitations/bulk.
+const maxInvitationUpload = 1 << 20
+
 // Admin Invite User (Protected + RBAC)
 type InviteRequest struct {
 	Email string `json:"email"`
 	Role  string `json:"role"`
 }
 
+// invitationResponse is one invitation in the admin list. The token is never shown.
+type invitationResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"`
+	InvitedBy  *uuid.UUID `json:"invited_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastSentAt time.Time  `json:"last_sent_at"`
+	SendCount  int32      `json:"send_count"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func newInvitationResponse(i db.Invitation, now time.Time) invitationResponse {
+	resp := invitationResponse{
+		ID:         i.ID.Bytes,
+		Email:      i.Email,
+		Role:       i.Role,
+		Status:     auth.InvitationStatus(i, now),
+		CreatedAt:  i.CreatedAt.Time,
+		ExpiresAt:  i.ExpiresAt.Time,
+		LastSentAt: i.LastSentAt.Time,
+		SendCount:  i.SendCount,
+	}
+	if i.InvitedBy.Valid {
+		id := uuid.UUID(i.InvitedBy.Bytes)
+		resp.InvitedBy = &id
+	}
+	if i.AcceptedAt.Valid {
+		resp.AcceptedAt = &i.AcceptedAt.Time
+	}
+	if i.RevokedAt.Valid {
+		resp.RevokedAt = &i.RevokedAt.Time
+	}
+	return resp
+}
+
 func (h *AuthHandler) InviteUser(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := customMiddleware.GetTenantID(r.Context())
 	if err != nil {
@@ -29,13 +78,221 @@ func (h *AuthHandler) InviteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.service.CreateInvitation(r.Context(), req.Email, tenantID, req.Role)
+	// Law 1: Input is Toxic. The role must exist and grant nothing the inviter lacks.
+	if !h.checkRoleAssignable(w, r, tenantID, req.Role) {
+		return
+	}
+
+	// The link goes to the invitee by email only; the inviter never sees the token
+	invite, _, err := h.service.CreateInvitation(r.Context(), req.Email, tenantID, req.Role, customMiddleware.MustGetUserID(r.Context()))
+	if err != nil {
+		writeInvitationError(w, "InviteUser", err)
+		return
+	}
+
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"invitation_id": uuid.UUID(invite.ID.Bytes),
+		"expires_at":    invite.ExpiresAt.Time,
+	})
+}
+
+// ListInvitations handles GET /admin/invitations?status=&page=&limit=
+func (h *AuthHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", auth.InvitationPending, auth.InvitationAccepted, auth.InvitationExpired, auth.InvitationRevoked:
+	default:
+		http.Error(w, "Invalid status: must be pending, accepted, expired or revoked", http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	invites, total, err := h.service.ListInvitations(r.Context(), tenantID, status, int32(limit), int32((page-1)*limit))
+	if err != nil {
+		slog.Error("ListInvitations: Query failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to list invitations", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	resp := make([]invitationResponse, len(invites))
+	for i, invite := range invites {
+		resp[i] = newInvitationResponse(invite, now)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"invitations": resp,
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// ResendInvitation handles POST /admin/invitations/{invitationID}/resend
+// A new token is mailed; the previous link stops working.
+func (h *AuthHandler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationID"))
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	invite, err := h.service.ResendInvitation(r.Context(), customMiddleware.MustGetTenantID(r.Context()), invitationID, customMiddleware.MustGetUserID(r.Context()))
+	if err != nil {
+		writeInvitationError(w, "ResendInvitation", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newInvitationResponse(invite, time.Now()))
+}
+
+// RevokeInvitation handles DELETE /admin/invitations/{invitationID}
+func (h *AuthHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationID"))
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeInvitation(r.Context(), customMiddleware.MustGetTenantID(r.Context()), invitationID, customMiddleware.MustGetUserID(r.Context())); err != nil {
+		writeInvitationError(w, "RevokeInvitation", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BulkInviteUsers handles POST /admin/invitations/bulk
+// multipart/form-data with a "file" field (CSV: email[,role]) and an optional
+// "role" field used for rows without one. Every row is processed on its own;
+// the response lists the outcome per line.
+func (h *AuthHandler) BulkInviteUsers(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInvitationUpload)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Upload a CSV file in the \"file\" field (max 1 MB)", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	rows, err := auth.ParseInvitationCSV(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(rows) == 0 {
+		http.Error(w, "The CSV file contains no invitations", http.StatusBadRequest)
+		return
+	}
+
+	actorPerms, _ := customMiddleware.GetPermissions(r.Context())
+	results := h.service.BulkInvite(r.Context(), tenantID, customMiddleware.MustGetUserID(r.Context()), actorPerms, r.FormValue("role"), rows)
+
+	invited := 0
+	for _, res := range results {
+		if res.Status == "invited" {
+			invited++
+		}
+	}
+	slog.Info("Bulk invite processed", "tenant_id", tenantID, "rows", len(rows), "invited", invited)
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"invited": invited,
+		"failed":  len(results) - invited,
+		"results": results,
+	})
+}
+
+// InvitationSettings is the request and response body of /admin/invitations/settings
+type InvitationSettings struct {
+	ExpiryDays int `json:"expiry_days"`
+}
+
+// GetInvitationSettings handles GET /admin/invitations/settings
+func (h *AuthHandler) GetInvitationSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	tenant, err := db.New(h.Pool).GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
 	if err != nil {
-		slog.Error("Invite failed", "error", err)
-		http.Error(w, "Failed to create invitation", http.StatusInternalServerError)
+		slog.Error("GetInvitationSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve invitation settings", http.StatusInternalServerError)
 		return
 	}
 
-	// Return token in response for MVP (normally sent via email)
-	json.NewEncoder(w).Encode(map[string]string{"token": token, "link": "/register?invite=" + token})
+	days := tenant.Settings.InvitationExpiryDays
+	if days == 0 {
+		days = int(auth.DefaultInvitationExpiry / (24 * time.Hour))
+	}
+	helpers.RespondJSON(w, http.StatusOK, InvitationSettings{ExpiryDays: days})
+}
+
+// UpdateInvitationSettings handles PUT /admin/invitations/settings
+// The expiry applies to invitations created or resent afterwards.
+func (h *AuthHandler) UpdateInvitationSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	var req InvitationSettings
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiryDays < 1 || req.ExpiryDays > 30 {
+		http.Error(w, "Invalid expiry_days: must be between 1 and 30", http.StatusBadRequest)
+		return
+	}
+
+	queries := db.New(h.Pool)
+	currentTenant, err := queries.GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("UpdateInvitationSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update invitation settings", http.StatusInternalServerError)
+		return
+	}
+
+	settings := currentTenant.Settings
+	settings.InvitationExpiryDays = req.ExpiryDays
+
+	// Update only settings, preserve other fields
+	_, err = queries.UpdateTenantConfig(r.Context(), db.UpdateTenantConfigParams{
+		ID:             currentTenant.ID,
+		AllowedOrigins: currentTenant.AllowedOrigins,
+		RedirectUrls:   currentTenant.RedirectUrls,
+		Branding:       currentTenant.Branding,
+		Settings:       settings,
+		AppUrl:         currentTenant.AppUrl,
+	})
+	if err != nil {
+		slog.Error("UpdateInvitationSettings: Database update failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update invitation settings", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.RespondJSON(w, http.StatusOK, InvitationSettings{ExpiryDays: settings.InvitationExpiryDays})
+}
+
+func writeInvitationError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidInviteEmail):
+		http.Error(w, "Invalid email address", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrAlreadyMember):
+		http.Error(w, "User is already a member of this tenant", http.StatusConflict)
+	case errors.Is(err, auth.ErrAlreadyInvited):
+		http.Error(w, "An open invitation already exists for this email; resend it instead", http.StatusConflict)
+	case errors.Is(err, auth.ErrInvitationNotFound):
+		http.Error(w, "Invitation not found or no longer open", http.StatusNotFound)
+	default:
+		slog.Error(op+": Failed", "error", err)
+		http.Error(w, "Failed to process invitation", http.StatusInternalServerError)
+	}
 }
@@ -2,11 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
 	"github.com/google/uuid"
 )
 
@@ -43,6 +45,14 @@ func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
 	result, err := h.service.VerifyLoginMFA(r.Context(), tokenString, req.Code, tenantID, ip, ua)
 	if err != nil {
 		slog.Warn("MFA Verify Failed", "user", req.UserID, "error", err)
+		if errors.Is(err, auth.ErrTenantSuspended) {
+			http.Error(w, "Tenant is suspended", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			http.Error(w, "Email address not verified", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Invalid code", http.StatusUnauthorized)
 		return
 	}
@@ -78,6 +88,14 @@ func (h *AuthHandler) VerifyBackupCode(w http.ResponseWriter, r *http.Request) {
 	result, err := h.service.VerifyLoginBackupCode(r.Context(), tokenString, req.Code, tenantID, ip, ua)
 	if err != nil {
 		slog.Warn("Backup Code Verify Failed", "user", req.UserID, "error", err)
+		if errors.Is(err, auth.ErrTenantSuspended) {
+			http.Error(w, "Tenant is suspended", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			http.Error(w, "Email address not verified", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Invalid code", http.StatusUnauthorized)
 		return
 	}
@@ -2,11 +2,20 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // extractJWT extracts JWT token from request using cookie-first strategy.
@@ -30,9 +39,43 @@ func extractJWT(r *http.Request) string {
 	return ""
 }
 
+// MembershipLookup returns a user's role in a tenant.
+// Used for cross-tenant access: a token issued for tenant A may act in tenant B
+// only when the user is also a member of B.
+type MembershipLookup interface {
+	GetMembership(ctx context.Context, arg db.GetMembershipParams) (string, error)
+}
+
+// DeletionLookup reports a user's pending account deletion (pgx.ErrNoRows when none).
+type DeletionLookup interface {
+	GetScheduledAccountDeletion(ctx context.Context, userID pgtype.UUID) (db.AccountDeletion, error)
+}
+
+// PermissionResolver resolves a role in a tenant to its permissions.
+// Implemented by *permissions.Resolver.
+type PermissionResolver interface {
+	Resolve(ctx context.Context, tenantID uuid.UUID, role string) ([]string, error)
+}
+
 // AuthMiddleware creates a handler that validates JWT tokens.
 // Supports both HttpOnly cookie-based auth (preferred) and Authorization header (legacy).
-func AuthMiddleware(provider auth.TokenProvider) func(http.Handler) http.Handler {
+//
+// Tenant binding is an authorization step, not a header check:
+//   - The request tenant (TenantResolver/TenantContext) must equal the token tid,
+//     OR the user must hold a membership in the request tenant. The role then comes
+//     from THAT membership, never from the token (which was issued for another tenant).
+//   - Permissions come from the token's perms claim. For cross-tenant access and
+//     tokens issued without the claim they are resolved from the role via roles.
+//   - Without a request tenant, the token tid is used and, when pool is set, the
+//     RLS transaction is opened here, so app.current_tenant always derives from the
+//     verified identity.
+//   - When tenants is set, a suspended tenant is refused at once: access tokens
+//     issued before the suspension stop working without waiting for their expiry.
+//   - When deletions is set, an account scheduled for deletion is refused the same
+//     way during its cooling-off period.
+//   - Under TenantResolverConfig.RejectConflicts an X-Tenant-ID header that names
+//     another tenant than the token tid is refused with 400.
+func AuthMiddleware(provider auth.TokenProvider, memberships MembershipLookup, tenants TenantSettingsProvider, deletions DeletionLookup, roles PermissionResolver, pool *pgxpool.Pool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// ✅ Extract token using cookie-first strategy
@@ -54,43 +97,143 @@ func AuthMiddleware(provider auth.TokenProvider) func(http.Handler) http.Handler
 				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 				return
 			}
+			// Pre-auth (MFA step) and platform operator tokens are not tenant sessions
+			if claims.Scope != "access" {
+				slog.Warn("AuthMiddleware: Wrong token scope", "scope", claims.Scope, "user_id", claims.UserID, "ip", r.RemoteAddr)
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			// Under RejectConflicts the header must name the session's tenant
+			if res, ok := GetTenantResolution(r.Context()); ok && res.headerConflict {
+				slog.Warn("AuthMiddleware: Header tenant differs from token", "token_tid", claims.TenantID, "user_id", claims.UserID, "ip", r.RemoteAddr)
+				http.Error(w, "Conflicting tenant context", http.StatusBadRequest)
+				return
+			}
 			// Log successful validation for debugging
 			slog.Info("AuthMiddleware: Token Validated", "user_id", claims.UserID, "scope", claims.Scope, "tid", claims.TenantID)
 
+			tenantID, role, perms := claims.TenantID, claims.Role, claims.Permissions
+
 			// Tenant Context Check
-			// If X-Tenant-ID header was provided (handled by previous TenantContext middleware),
-			// we MUST ensure the token grants access to THAT tenant.
+			// If a request tenant was resolved (header, path, domain, subdomain),
+			// we MUST ensure the identity grants access to THAT tenant.
 			// "Anti-Gravity Law: Strict Scoping"
 			ctxTenantID, err := GetTenantID(r.Context())
-			if err == nil {
-				// Header was present and valid.
-				if claims.TenantID != ctxTenantID {
-					slog.Warn("Tenant Mismatch", "token_tid", claims.TenantID, "header_tid", ctxTenantID)
+			hasRequestTenant := err == nil
+			if hasRequestTenant && ctxTenantID != claims.TenantID {
+				memberRole, err := crossTenantRole(r.Context(), memberships, claims.UserID, ctxTenantID)
+				if errors.Is(err, ErrNotAMember) {
+					slog.Warn("Tenant Mismatch", "token_tid", claims.TenantID, "request_tid", ctxTenantID, "user_id", claims.UserID)
 					http.Error(w, "Token does not match requested tenant context", http.StatusForbidden)
 					return
 				}
-			} else {
-				// No header provided? We can either:
-				// 1. Enforce specific tenant context from token (Inject token TID as the context TID)
-				// 2. Allow "Global" tokens (unlikely in this architecture).
-				// We'll adopt option 1 for safety.
-				ctx := context.WithValue(r.Context(), TenantIDKey, claims.TenantID)
-
-				// Re-inject Sentry tag since TenantContext middleware couldn't do it (was missing header)
-				SetSentryTenant(ctx, claims.TenantID.String(), "token-derived")
-				r = r.WithContext(ctx)
+				if err != nil {
+					slog.Error("AuthMiddleware: Membership lookup failed", "error", err, "user_id", claims.UserID, "tenant_id", ctxTenantID)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				slog.Info("AuthMiddleware: Cross-tenant access via membership", "user_id", claims.UserID, "token_tid", claims.TenantID, "request_tid", ctxTenantID, "role", memberRole)
+				tenantID, role, perms = ctxTenantID, memberRole, nil
 			}
 
-			// Inject User ID
-			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
-			ctx = context.WithValue(ctx, RoleKey, claims.Role) // Inject Role (Layer 2 Optimization)
-			SetSentryUser(ctx, claims.UserID.String(), claims.Role, r.RemoteAddr)
+			if tenants != nil {
+				tenant, err := tenants.GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+				if errors.Is(err, pgx.ErrNoRows) {
+					slog.Warn("AuthMiddleware: Token for unknown tenant", "user_id", claims.UserID, "tenant_id", tenantID)
+					http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+					return
+				}
+				if err != nil {
+					slog.Error("AuthMiddleware: Tenant lookup failed", "error", err, "tenant_id", tenantID)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				if !tenant.IsActive {
+					slog.Warn("AuthMiddleware: Tenant suspended", "user_id", claims.UserID, "tenant_id", tenantID)
+					http.Error(w, "Tenant is suspended", http.StatusForbidden)
+					return
+				}
+			}
+
+			if deletions != nil {
+				_, err := deletions.GetScheduledAccountDeletion(r.Context(), pgtype.UUID{Bytes: claims.UserID, Valid: true})
+				if err == nil {
+					slog.Warn("AuthMiddleware: Account pending deletion", "user_id", claims.UserID)
+					http.Error(w, "Account is scheduled for deletion", http.StatusForbidden)
+					return
+				}
+				if !errors.Is(err, pgx.ErrNoRows) {
+					slog.Error("AuthMiddleware: Deletion lookup failed", "error", err, "user_id", claims.UserID)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+			}
+
+			if perms == nil {
+				// Cross-tenant or legacy token: resolve from the verified role
+				perms, err = resolvePermissions(r.Context(), roles, tenantID, role)
+				if err != nil {
+					slog.Error("AuthMiddleware: Permission resolution failed", "error", err, "tenant_id", tenantID, "role", role)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+			}
+
+			// Inject verified Tenant, User ID and Role
+			ctx := context.WithValue(r.Context(), TenantIDKey, tenantID)
+			ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, RoleKey, role) // Inject Role (Layer 2 Optimization)
+			ctx = context.WithValue(ctx, PermissionsKey, perms)
+			ctx = requestmeta.WithTenant(requestmeta.WithActor(ctx, claims.UserID), tenantID)
+			SetSentryUser(ctx, claims.UserID.String(), role, r.RemoteAddr)
+			if claims.Actor != nil {
+				// Platform impersonation: every audit event names the operator
+				ctx = audit.WithImpersonator(ctx, claims.Actor.Subject)
+				ctx = context.WithValue(ctx, ImpersonatorKey, claims.Actor.Subject)
+				slog.Info("AuthMiddleware: Impersonated session", "user_id", claims.UserID, "platform_admin_id", claims.Actor.Subject, "tenant_id", tenantID)
+			}
+
+			if !hasRequestTenant {
+				// Re-inject Sentry tag since TenantContext middleware couldn't do it (no request tenant)
+				SetSentryTenant(ctx, tenantID.String(), "token-derived")
+
+				// PHASE 50 RLS: no transaction was opened upstream; bind RLS to the verified tenant.
+				if pool != nil && storage.GetTx(ctx) == nil {
+					serveInTenantTx(w, r.WithContext(ctx), pool, tenantID, next)
+					return
+				}
+			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// ErrNotAMember is returned when a user has no membership in the requested tenant.
+var ErrNotAMember = errors.New("user is not a member of the requested tenant")
+
+func resolvePermissions(ctx context.Context, roles PermissionResolver, tenantID uuid.UUID, role string) ([]string, error) {
+	if roles == nil {
+		return []string{}, nil
+	}
+	return roles.Resolve(ctx, tenantID, role)
+}
+
+// crossTenantRole looks up the user's role in tenantID.
+func crossTenantRole(ctx context.Context, memberships MembershipLookup, userID, tenantID uuid.UUID) (string, error) {
+	if memberships == nil {
+		return "", ErrNotAMember
+	}
+	role, err := memberships.GetMembership(ctx, db.GetMembershipParams{
+		UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotAMember
+	}
+	return role, err
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
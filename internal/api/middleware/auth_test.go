@@ -0,0 +1,183 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+// roleTokens accepts "<user>|<tenant>|<role>" as a valid bearer token (no perms claim).
+type roleTokens struct{ fakeTokens }
+
+func (roleTokens) ValidateToken(token string) (*auth.Claims, error) {
+	parts := strings.Split(token, "|")
+	if len(parts) != 3 {
+		return nil, auth.ErrInvalidToken
+	}
+	return &auth.Claims{UserID: uuid.MustParse(parts[0]), TenantID: uuid.MustParse(parts[1]), Role: parts[2], Scope: "access"}, nil
+}
+
+type fakeMemberships map[[2]uuid.UUID]string
+
+func (f fakeMemberships) GetMembership(ctx context.Context, arg db.GetMembershipParams) (string, error) {
+	role, ok := f[[2]uuid.UUID{arg.UserID.Bytes, arg.TenantID.Bytes}]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return role, nil
+}
+
+type fakeRoles map[string][]string
+
+func (f fakeRoles) GetTenantRoleByName(ctx context.Context, arg db.GetTenantRoleByNameParams) (db.TenantRole, error) {
+	perms, ok := f[arg.Name]
+	if !ok {
+		return db.TenantRole{}, pgx.ErrNoRows
+	}
+	return db.TenantRole{Name: arg.Name, Permissions: perms}, nil
+}
+
+func TestAuthMiddleware_TenantBinding(t *testing.T) {
+	userID := uuid.New()
+	token := userID.String() + "|" + tenantA.String() + "|admin"
+	memberships := fakeMemberships{{userID, tenantB}: "support"}
+	roles := permissions.NewResolver(fakeRoles{"support": {permissions.UsersRead}})
+
+	var gotTenant uuid.UUID
+	var gotRole string
+	var gotPerms []string
+	handler := customMiddleware.AuthMiddleware(roleTokens{}, memberships, nil, nil, roles, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTenant, _ = customMiddleware.GetTenantID(r.Context())
+		gotRole, _ = customMiddleware.GetRole(r.Context())
+		gotPerms, _ = customMiddleware.GetPermissions(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(requestTenant uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if requestTenant != uuid.Nil {
+			req = req.WithContext(context.WithValue(req.Context(), customMiddleware.TenantIDKey, requestTenant))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// No request tenant: token tid becomes the context; legacy token without perms is resolved from the role
+	assert.Equal(t, http.StatusOK, do(uuid.Nil))
+	assert.Equal(t, tenantA, gotTenant)
+	assert.Equal(t, "admin", gotRole)
+	assert.ElementsMatch(t, permissions.All(), gotPerms)
+
+	// Cross-tenant with membership: role and permissions come from the membership, not the token
+	assert.Equal(t, http.StatusOK, do(tenantB))
+	assert.Equal(t, tenantB, gotTenant)
+	assert.Equal(t, "support", gotRole)
+	assert.Equal(t, []string{permissions.UsersRead}, gotPerms)
+
+	// Cross-tenant without membership
+	assert.Equal(t, http.StatusForbidden, do(uuid.New()))
+}
+
+// fakeTenants maps tenant IDs to their is_active flag.
+type fakeTenants map[uuid.UUID]bool
+
+func (f fakeTenants) GetTenantByID(ctx context.Context, id pgtype.UUID) (db.Tenant, error) {
+	active, ok := f[id.Bytes]
+	if !ok {
+		return db.Tenant{}, pgx.ErrNoRows
+	}
+	return db.Tenant{ID: id, IsActive: active}, nil
+}
+
+func TestAuthMiddleware_SuspendedTenant(t *testing.T) {
+	userID := uuid.New()
+	suspended := uuid.New()
+	memberships := fakeMemberships{{userID, suspended}: "admin"}
+	tenants := fakeTenants{tenantA: true, suspended: false}
+	roles := permissions.NewResolver(fakeRoles{})
+	handler := customMiddleware.AuthMiddleware(roleTokens{}, memberships, tenants, nil, roles, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(tokenTenant, requestTenant uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+userID.String()+"|"+tokenTenant.String()+"|admin")
+		if requestTenant != uuid.Nil {
+			req = req.WithContext(context.WithValue(req.Context(), customMiddleware.TenantIDKey, requestTenant))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do(tenantA, uuid.Nil))
+	// A token issued before the suspension is refused straight away
+	assert.Equal(t, http.StatusForbidden, do(suspended, uuid.Nil))
+	assert.Equal(t, http.StatusForbidden, do(suspended, suspended))
+	// So is cross-tenant access into it through a membership
+	assert.Equal(t, http.StatusForbidden, do(tenantA, suspended))
+	// A tenant that no longer exists
+	assert.Equal(t, http.StatusUnauthorized, do(uuid.New(), uuid.Nil))
+}
+
+// fakeDeletions holds the users with a scheduled account deletion.
+type fakeDeletions map[uuid.UUID]bool
+
+func (f fakeDeletions) GetScheduledAccountDeletion(ctx context.Context, userID pgtype.UUID) (db.AccountDeletion, error) {
+	if !f[userID.Bytes] {
+		return db.AccountDeletion{}, pgx.ErrNoRows
+	}
+	return db.AccountDeletion{UserID: userID, Status: "scheduled"}, nil
+}
+
+func TestAuthMiddleware_PendingDeletion(t *testing.T) {
+	leaving, staying := uuid.New(), uuid.New()
+	handler := customMiddleware.AuthMiddleware(roleTokens{}, nil, nil, fakeDeletions{leaving: true}, permissions.NewResolver(fakeRoles{}), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(userID uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+userID.String()+"|"+tenantA.String()+"|admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do(staying))
+	// Access tokens issued before the deletion was scheduled stop working at once
+	assert.Equal(t, http.StatusForbidden, do(leaving))
+}
+
+func TestRequirePermission(t *testing.T) {
+	handler := customMiddleware.RequirePermission(permissions.UsersRead, permissions.AuditRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(perms []string) int {
+		ctx := context.WithValue(context.Background(), customMiddleware.UserIDKey, uuid.New())
+		if perms != nil {
+			ctx = context.WithValue(ctx, customMiddleware.PermissionsKey, perms)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do([]string{permissions.AuditRead, permissions.UsersRead, permissions.MailStats}))
+	assert.Equal(t, http.StatusForbidden, do([]string{permissions.UsersRead}))
+	assert.Equal(t, http.StatusForbidden, do(nil))
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/challenge"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultChallengeThreshold is used when a tenant enables challenges without a threshold.
+const DefaultChallengeThreshold = 5
+
+// TenantSettingsProvider loads the tenant row (settings JSONB) for the challenge gate.
+type TenantSettingsProvider interface {
+	GetTenantByID(ctx context.Context, id pgtype.UUID) (db.Tenant, error)
+}
+
+// ChallengeGate demands a proof-of-work or CAPTCHA answer on credential endpoints
+// once the per-IP or per-email failure count crosses the tenant threshold.
+// Below the threshold legitimate users never see a challenge.
+type ChallengeGate struct {
+	pow     *challenge.ProofOfWork
+	captcha challenge.CaptchaVerifier // nil = only proof-of-work available
+	tracker *challenge.FailureTracker
+	tenants TenantSettingsProvider
+	notices *SignInNotices // nil = owners are not told when their login gets challenged
+}
+
+func NewChallengeGate(pow *challenge.ProofOfWork, captcha challenge.CaptchaVerifier, tracker *challenge.FailureTracker, tenants TenantSettingsProvider, notices *SignInNotices) *ChallengeGate {
+	return &ChallengeGate{pow: pow, captcha: captcha, tracker: tracker, tenants: tenants, notices: notices}
+}
+
+// Protect returns middleware guarding one scope (see challenge.Scope*).
+// Requires TenantContext upstream; requests without a tenant are not challenged.
+func (g *ChallengeGate) Protect(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID, err := GetTenantID(r.Context())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenant, err := g.tenants.GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+			if err != nil {
+				// Fail open: the handler performs its own tenant validation.
+				slog.Warn("ChallengeGate: Tenant lookup failed", "tenant_id", tenantID, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			settings := tenant.Settings.Challenge
+			if !settings.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Same subject as the per-IP rate limit; "" when the request has no
+			// address at all, which then only counts per email
+			ip, _ := rateLimitSubject(r, ratelimit.KeyIP)
+			email := peekEmail(r)
+
+			if g.required(r.Context(), settings, ip, email) {
+				if err := g.verify(r, settings, scope, ip); err != nil {
+					code := challenge.CodeFailed
+					if errors.Is(err, errNoAnswer) {
+						code = challenge.CodeRequired
+					} else {
+						slog.Warn("ChallengeGate: Challenge rejected", "scope", scope, "tenant_id", tenantID, "error", err)
+					}
+					g.demand(w, settings, scope, ip, code)
+					return
+				}
+			}
+
+			rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			if isAuthFailure(rw.statusCode) || challenge.CountsEveryAttempt(scope) {
+				emailFailures := g.recordFailure(r.Context(), ip, email)
+				// The login of this address is challenged from now on: tell its owner
+				if scope == challenge.ScopeLogin && emailFailures == challengeThreshold(settings) {
+					g.notices.Send(r, email, auth.ThrottledByChallenge, g.tracker.ResetAt())
+				}
+			}
+		})
+	}
+}
+
+var errNoAnswer = errors.New("no challenge answer supplied")
+
+// required reports whether either the IP or the email crossed the threshold.
+func (g *ChallengeGate) required(ctx context.Context, settings domain.ChallengeSettings, ip, email string) bool {
+	threshold := challengeThreshold(settings)
+
+	if ip != "" {
+		ipFailures, err := g.tracker.Failures(ctx, ratelimit.KeyIP, ip)
+		if err != nil {
+			slog.Error("ChallengeGate: Failure counter unavailable", "error", err)
+			return false
+		}
+		if ipFailures >= threshold {
+			return true
+		}
+	}
+
+	if email == "" {
+		return false
+	}
+	emailFailures, err := g.tracker.Failures(ctx, ratelimit.KeyEmail, email)
+	if err != nil {
+		slog.Error("ChallengeGate: Failure counter unavailable", "error", err)
+		return false
+	}
+	return emailFailures >= threshold
+}
+
+// challengeThreshold is the tenant's failure threshold, or DefaultChallengeThreshold.
+func challengeThreshold(settings domain.ChallengeSettings) int {
+	if settings.Threshold <= 0 {
+		return DefaultChallengeThreshold
+	}
+	return settings.Threshold
+}
+
+func (g *ChallengeGate) verify(r *http.Request, settings domain.ChallengeSettings, scope, ip string) error {
+	if g.useCaptcha(settings) {
+		response := r.Header.Get(challenge.HeaderCaptchaResponse)
+		if response == "" {
+			return errNoAnswer
+		}
+		return g.captcha.Verify(r.Context(), response, ip)
+	}
+
+	token := r.Header.Get(challenge.HeaderToken)
+	if token == "" {
+		return errNoAnswer
+	}
+	return g.pow.Verify(r.Context(), token, r.Header.Get(challenge.HeaderSolution), scope, ip)
+}
+
+// demand answers 428 with a machine-readable description of the challenge.
+func (g *ChallengeGate) demand(w http.ResponseWriter, settings domain.ChallengeSettings, scope, ip, code string) {
+	req := challenge.Requirement{Scope: scope}
+
+	if g.useCaptcha(settings) {
+		req.Type = domain.ChallengeModeCaptcha
+		req.Provider = g.captcha.Provider()
+		req.SiteKey = g.captcha.SiteKey()
+	} else {
+		puzzle, err := g.pow.Issue(scope, ip, settings.Difficulty)
+		if err != nil {
+			slog.Error("ChallengeGate: Failed to issue puzzle", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		req.Type = domain.ChallengeModePoW
+		req.PoW = &puzzle
+	}
+
+	helpers.RespondJSON(w, http.StatusPreconditionRequired, challenge.Response{
+		Error:     code,
+		Message:   "Solve the challenge and retry the request",
+		Challenge: req,
+	})
+}
+
+// useCaptcha falls back to proof-of-work when a tenant selects CAPTCHA but no
+// verifier is configured on this deployment.
+func (g *ChallengeGate) useCaptcha(settings domain.ChallengeSettings) bool {
+	return settings.Mode == domain.ChallengeModeCaptcha && g.captcha != nil
+}
+
+// recordFailure counts the failure per IP and per email and returns the
+// email's new count (0 without an email or when the counter is unavailable).
+func (g *ChallengeGate) recordFailure(ctx context.Context, ip, email string) int {
+	if ip != "" {
+		if _, err := g.tracker.RecordFailure(ctx, ratelimit.KeyIP, ip); err != nil {
+			slog.Error("ChallengeGate: Failed to record failure", "error", err)
+		}
+	}
+	if email == "" {
+		return 0
+	}
+	failures, err := g.tracker.RecordFailure(ctx, ratelimit.KeyEmail, email)
+	if err != nil {
+		slog.Error("ChallengeGate: Failed to record failure", "error", err)
+		return 0
+	}
+	return failures
+}
+
+// isAuthFailure classifies handler responses that indicate a failed credential attempt.
+// 428/429 are our own gates and are not counted twice.
+func isAuthFailure(status int) bool {
+	return status >= 400 && status < 500 &&
+		status != http.StatusPreconditionRequired && status != http.StatusTooManyRequests
+}
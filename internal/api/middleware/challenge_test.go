@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/challenge"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+// challengeTenant is a tenant that demands proof-of-work after one failure.
+type challengeTenant struct{}
+
+func (challengeTenant) GetTenantByID(ctx context.Context, id pgtype.UUID) (db.Tenant, error) {
+	return db.Tenant{ID: id, Settings: domain.TenantSettings{
+		Challenge: domain.ChallengeSettings{Mode: domain.ChallengeModePoW, Threshold: 1},
+	}}, nil
+}
+
+func TestChallengeGate_CountsPerIP(t *testing.T) {
+	store := ratelimit.NewMemoryStore(0)
+	gate := customMiddleware.NewChallengeGate(
+		challenge.NewProofOfWork([]byte("test-secret"), time.Minute, store),
+		nil,
+		challenge.NewFailureTracker(store, time.Minute),
+		challengeTenant{},
+		nil,
+	)
+	handler := gate.Protect(challenge.ScopeLogin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	}))
+	tenantID := uuid.New()
+
+	login := func(remoteAddr, email string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"`+email+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		req = req.WithContext(context.WithValue(req.Context(), customMiddleware.TenantIDKey, tenantID))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("203.0.113.7:5555", "a@example.com"))
+	assert.Equal(t, http.StatusPreconditionRequired, login("203.0.113.7:5555", "b@example.com"), "same IP")
+	assert.Equal(t, http.StatusUnauthorized, login("198.51.100.1:5555", "c@example.com"), "other IP")
+
+	// Requests without any address do not share one bucket
+	assert.Equal(t, http.StatusUnauthorized, login("", "d@example.com"))
+	assert.Equal(t, http.StatusUnauthorized, login("", "e@example.com"))
+	assert.Equal(t, http.StatusPreconditionRequired, login("", "d@example.com"), "still counted per email")
+}
@@ -16,6 +16,12 @@ const (
 	UserIDKey   contextKey = "user_id"
 	TenantIDKey contextKey = "tenant_id"
 	RoleKey     contextKey = "user_role"
+	// PermissionsKey holds the verified permissions ([]string) of the user in the request tenant.
+	PermissionsKey contextKey = "user_permissions"
+	// ImpersonatorKey holds the platform operator ID (uuid.UUID) on impersonated sessions.
+	ImpersonatorKey contextKey = "impersonator_id"
+	// PlatformAdminIDKey holds the authenticated platform operator on /platform/v1 routes.
+	PlatformAdminIDKey contextKey = "platform_admin_id"
 )
 
 // GetUserID safely extracts the user ID from context.
@@ -60,6 +66,34 @@ func GetRole(ctx context.Context) (string, error) {
 	return role, nil
 }
 
+// GetPermissions safely extracts the user's permissions from context.
+// Returns an error if the value is missing or wrong type.
+func GetPermissions(ctx context.Context) ([]string, error) {
+	val := ctx.Value(PermissionsKey)
+	if val == nil {
+		return nil, fmt.Errorf("user_permissions not found in context")
+	}
+	perms, ok := val.([]string)
+	if !ok {
+		return nil, fmt.Errorf("user_permissions has wrong type: %T", val)
+	}
+	return perms, nil
+}
+
+// GetPlatformAdminID safely extracts the platform operator ID from context.
+// Returns an error if the value is missing or wrong type.
+func GetPlatformAdminID(ctx context.Context) (uuid.UUID, error) {
+	val := ctx.Value(PlatformAdminIDKey)
+	if val == nil {
+		return uuid.Nil, fmt.Errorf("platform_admin_id not found in context")
+	}
+	id, ok := val.(uuid.UUID)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("platform_admin_id has wrong type: %T", val)
+	}
+	return id, nil
+}
+
 // MustGetUserID extracts user ID and panics if not found.
 // Use only in contexts where UserID is guaranteed to be set by middleware.
 func MustGetUserID(ctx context.Context) uuid.UUID {
@@ -79,3 +113,13 @@ func MustGetTenantID(ctx context.Context) uuid.UUID {
 	}
 	return id
 }
+
+// MustGetPlatformAdminID extracts the platform operator ID and panics if not found.
+// Use only behind PlatformAuth.
+func MustGetPlatformAdminID(ctx context.Context) uuid.UUID {
+	id, err := GetPlatformAdminID(ctx)
+	if err != nil {
+		panic(fmt.Sprintf("CRITICAL: %v", err))
+	}
+	return id
+}
@@ -2,25 +2,173 @@ package middleware
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"log/slog"
 	"net/http"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// System defaults, used when a tenant does not override them.
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"}
+	defaultCORSHeaders = []string{
+		"Content-Type", "Authorization", "X-Tenant-ID", "X-Requested-With", "X-CSRF-Token",
+		"X-Challenge-Token", "X-Challenge-Solution", "X-Captcha-Response",
+	}
+	// Exposed so browser clients can honour rate limits
+	corsExposeHeaders = "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After"
+)
+
+const defaultCORSMaxAge = 600
+
 type CorsConfigProvider interface {
-	GetTenantConfig(ctx context.Context, id pgtype.UUID) (db.GetTenantConfigRow, error)
+	ListTenantCORSPolicies(ctx context.Context) ([]db.ListTenantCORSPoliciesRow, error)
+}
+
+// TenantCORSPolicy is the resolved CORS policy of one tenant.
+type TenantCORSPolicy struct {
+	TenantID       uuid.UUID
+	AllowedOrigins []string
+	AllowedHeaders []string
+	AllowedMethods []string
+	MaxAge         int
+}
+
+// AllowsOrigin checks the origin against the tenant's patterns.
+func (p TenantCORSPolicy) AllowsOrigin(origin string, allowLocalhost bool) bool {
+	for _, pattern := range p.AllowedOrigins {
+		if storage.MatchCORSOrigin(pattern, origin, allowLocalhost) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPreflight checks the requested method and headers of a preflight.
+func (p TenantCORSPolicy) allowsPreflight(method, requestHeaders string) bool {
+	if method != "" && !slices.ContainsFunc(p.AllowedMethods, func(m string) bool { return strings.EqualFold(m, method) }) {
+		return false
+	}
+	for _, h := range strings.Split(requestHeaders, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		if !slices.ContainsFunc(p.AllowedHeaders, func(a string) bool { return strings.EqualFold(a, h) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// CORSPolicyCache keeps every active tenant's CORS policy in memory.
+// Preflights carry no X-Tenant-ID, so the cache must be able to answer
+// "which tenant allows this origin?" without a DB round trip per request.
+// Entries expire after ttl (covers writes on other replicas); Invalidate
+// drops them immediately after a local UpdateCORSOrigins.
+type CORSPolicyCache struct {
+	provider CorsConfigProvider
+	ttl      time.Duration
+
+	mu       sync.RWMutex
+	policies map[uuid.UUID]TenantCORSPolicy
+	loadedAt time.Time
+}
+
+func NewCORSPolicyCache(provider CorsConfigProvider, ttl time.Duration) *CORSPolicyCache {
+	return &CORSPolicyCache{provider: provider, ttl: ttl}
+}
+
+// Invalidate forces a reload on the next lookup.
+func (c *CORSPolicyCache) Invalidate() {
+	c.mu.Lock()
+	c.policies = nil
+	c.mu.Unlock()
+}
+
+// Get returns the policy of one tenant.
+func (c *CORSPolicyCache) Get(ctx context.Context, tenantID uuid.UUID) (TenantCORSPolicy, bool, error) {
+	policies, err := c.snapshot(ctx)
+	if err != nil {
+		return TenantCORSPolicy{}, false, err
+	}
+	p, ok := policies[tenantID]
+	return p, ok, nil
+}
+
+// MatchOrigin returns the policies of all tenants that allow origin.
+func (c *CORSPolicyCache) MatchOrigin(ctx context.Context, origin string, allowLocalhost bool) ([]TenantCORSPolicy, error) {
+	policies, err := c.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []TenantCORSPolicy
+	for _, p := range policies {
+		if p.AllowsOrigin(origin, allowLocalhost) {
+			matches = append(matches, p)
+		}
+	}
+	return matches, nil
+}
+
+func (c *CORSPolicyCache) snapshot(ctx context.Context) (map[uuid.UUID]TenantCORSPolicy, error) {
+	c.mu.RLock()
+	policies, loadedAt := c.policies, c.loadedAt
+	c.mu.RUnlock()
+	if policies != nil && time.Since(loadedAt) < c.ttl {
+		return policies, nil
+	}
+
+	rows, err := c.provider.ListTenantCORSPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policies = make(map[uuid.UUID]TenantCORSPolicy, len(rows))
+	for _, row := range rows {
+		cors := row.Settings.CORS
+		p := TenantCORSPolicy{
+			TenantID:       uuid.UUID(row.ID.Bytes),
+			AllowedOrigins: row.AllowedOrigins,
+			AllowedHeaders: cors.AllowedHeaders,
+			AllowedMethods: cors.AllowedMethods,
+			MaxAge:         cors.MaxAge,
+		}
+		if len(p.AllowedHeaders) == 0 {
+			p.AllowedHeaders = defaultCORSHeaders
+		}
+		if len(p.AllowedMethods) == 0 {
+			p.AllowedMethods = defaultCORSMethods
+		}
+		if p.MaxAge == 0 {
+			p.MaxAge = defaultCORSMaxAge
+		}
+		policies[p.TenantID] = p
+	}
+
+	c.mu.Lock()
+	c.policies, c.loadedAt = policies, time.Now()
+	c.mu.Unlock()
+	return policies, nil
 }
 
 // DynamicCorsMiddleware enforces Tenant-specific CORS policies.
-// It assumes TenantContext middleware has already run and populated a possible TenantID.
-// For Preflight (OPTIONS), it reflects the Origin to allow the browser to send the actual request.
-func DynamicCorsMiddleware(q CorsConfigProvider) func(http.Handler) http.Handler {
+// It runs before TenantContext, so it uses the TenantResolver result (or
+// reads X-Tenant-ID itself when no resolver is installed).
+//
+//   - Preflight (OPTIONS): validated against the tenant that allows the Origin
+//     (browsers never send X-Tenant-ID on a preflight). Nothing is reflected blindly.
+//   - Actual request: the Origin must match the X-Tenant-ID tenant's patterns.
+//
+// allowLocalhost enables localhost origin patterns; it MUST be false in production.
+func DynamicCorsMiddleware(cache *CORSPolicyCache, allowLocalhost bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
@@ -29,92 +177,114 @@ func DynamicCorsMiddleware(q CorsConfigProvider) func(http.Handler) http.Handler
 				next.ServeHTTP(w, r)
 				return
 			}
+			w.Header().Add("Vary", "Origin")
 
-			// 0. SYSTEM OVERRIDE: Allow Localhost for Development
-			// This MUST bypass Tenant/DB checks to ensure developers are never blocked by CORS
-			isLocalDev := origin == "http://localhost:4321" || origin == "http://localhost:3000"
-			if isLocalDev {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID, X-Requested-With, X-CSRF-Token")
-
-				if r.Method == http.MethodOptions {
-					w.WriteHeader(http.StatusOK)
-					return
-				}
-
-				next.ServeHTTP(w, r)
+			requestMethod := r.Header.Get("Access-Control-Request-Method")
+			if r.Method == http.MethodOptions && requestMethod != "" {
+				handlePreflight(w, r, cache, origin, requestMethod, allowLocalhost)
 				return
 			}
 
-			// Preflight Handling independent of Tenant
-			if r.Method == http.MethodOptions {
-				w.Header().Set("Access-Control-Allow-Origin", origin) // Reflect
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID, X-Requested-With, X-CSRF-Token")
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.WriteHeader(http.StatusOK)
+			// Actual Request: Validate Origin against Tenant Config
+			tenantID, present, err := corsTenant(r)
+			if err != nil {
+				slog.Warn("CORS: Invalid X-Tenant-ID Header", "value", r.Header.Get("X-Tenant-ID"), "ip", r.RemoteAddr)
+				http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
 				return
 			}
-
-			// Actual Request: Validate Origin against Tenant Config
-			// FIX: TenantContext (Phase 50) runs AFTER this (Phase 99).
-			// We cannot rely on GetTenantID(ctx) being populated yet.
-			// We MUST extract the header manually here for the DB lookup.
-			tenantIDStr := r.Header.Get("X-Tenant-ID")
-
-			if tenantIDStr == "" {
+			if !present {
 				// No Tenant ID?
-				// If strictly following Anti-Gravity, we cannot validate origin without knowing the tenant.
+				// We cannot validate origin without knowing the tenant.
 				// We proceed without setting CORS headers (Browser blocks).
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Validate UUID syntax
-			tenantID, err := uuid.Parse(tenantIDStr)
-			if err != nil {
-				slog.Warn("CORS: Invalid X-Tenant-ID Header", "value", tenantIDStr, "ip", r.RemoteAddr)
-				http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
-				return
-			}
-
-			// Fetch Config
-			// Use r.Context() which might have timeout attached upstream?
-			// Use a detached context for DB check to avoid canceling on client disconnect during check? No, standard ctx is fine.
-			config, err := q.GetTenantConfig(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+			policy, found, err := cache.Get(r.Context(), tenantID)
 			if err != nil {
-				if errors.Is(err, sql.ErrNoRows) {
-					// Invalid Tenant ID was passed (though TenantContext validated UUID syntax)
-					slog.Warn("CORS: Tenant not found in DB", "tenant_id", tenantID)
-					http.Error(w, "Invalid Tenant", http.StatusForbidden)
-					return
-				}
 				slog.Error("CORS: DB Error", "err", err)
 				http.Error(w, "Internal Error", http.StatusInternalServerError)
 				return
 			}
+			if !found {
+				slog.Warn("CORS: Tenant not found in DB", "tenant_id", tenantID)
+				http.Error(w, "Invalid Tenant", http.StatusForbidden)
+				return
+			}
 
-			// Check Origin
-			// allowed_origins is TEXT[] -> []string
-			if slices.Contains(config.AllowedOrigins, origin) {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
-			} else {
-				// Anti-Gravity Law 1: Toxic Input.
-				// We do NOT set headers. Browser will block response reading.
-				// Optional: Log violation
+			if !policy.AllowsOrigin(origin, allowLocalhost) {
+				// Anti-Gravity Law 1: Toxic Input. Block instead of letting the browser discard it.
 				slog.Warn("CORS: Origin Rejected", "tenant_id", tenantID, "origin", origin)
-				// We do NOT stop execution here?
-				// Actually CORS spec says server *can* process process, but browser won't show result.
-				// But to save resources, we could abort.
-				// "Blokkeer (of negeer)" -> Let's Block with 403 to be "Anti-Gravity".
 				http.Error(w, "CORS Policy Violation", http.StatusForbidden)
 				return
 			}
 
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+func handlePreflight(w http.ResponseWriter, r *http.Request, cache *CORSPolicyCache, origin, method string, allowLocalhost bool) {
+	var candidates []TenantCORSPolicy
+
+	// Narrow to the tenant when it is known: resolved from the host or /t/{slug}
+	// path, or sent as X-Tenant-ID by non-browser clients.
+	if tenantID, present, err := corsTenant(r); err == nil && present {
+		policy, found, err := cache.Get(r.Context(), tenantID)
+		if err != nil {
+			slog.Error("CORS: DB Error", "err", err)
+			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		if found && policy.AllowsOrigin(origin, allowLocalhost) {
+			candidates = append(candidates, policy)
+		}
+	} else {
+		matches, err := cache.MatchOrigin(r.Context(), origin, allowLocalhost)
+		if err != nil {
+			slog.Error("CORS: DB Error", "err", err)
+			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		candidates = matches
+	}
+
+	requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+	for _, policy := range candidates {
+		if !policy.allowsPreflight(method, requestHeaders) {
+			continue
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	slog.Warn("CORS: Preflight Rejected", "origin", origin, "method", method, "headers", requestHeaders)
+	http.Error(w, "CORS Policy Violation", http.StatusForbidden)
+}
+
+// corsTenant returns the request tenant: the resolver result when the
+// TenantResolver ran, otherwise the raw X-Tenant-ID header.
+func corsTenant(r *http.Request) (uuid.UUID, bool, error) {
+	if resolverRan(r.Context()) {
+		res, ok := GetTenantResolution(r.Context())
+		return res.TenantID, ok, nil
+	}
+	value := r.Header.Get("X-Tenant-ID")
+	if value == "" {
+		return uuid.Nil, false, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return id, true, nil
+}
@@ -0,0 +1,113 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCorsProvider struct {
+	rows  []db.ListTenantCORSPoliciesRow
+	calls int
+}
+
+func (f *fakeCorsProvider) ListTenantCORSPolicies(ctx context.Context) ([]db.ListTenantCORSPoliciesRow, error) {
+	f.calls++
+	return f.rows, nil
+}
+
+func newCorsHandler(t *testing.T, tenantID uuid.UUID, allowLocalhost bool) (http.Handler, *fakeCorsProvider, *customMiddleware.CORSPolicyCache) {
+	t.Helper()
+	provider := &fakeCorsProvider{rows: []db.ListTenantCORSPoliciesRow{{
+		ID:             pgtype.UUID{Bytes: tenantID, Valid: true},
+		AllowedOrigins: []string{"https://*.example.com", "http://localhost:*"},
+		Settings: domain.TenantSettings{CORS: domain.CORSSettings{
+			AllowedHeaders: []string{"Content-Type", "X-Tenant-ID"},
+			AllowedMethods: []string{"GET", "POST"},
+			MaxAge:         120,
+		}},
+	}}}
+	cache := customMiddleware.NewCORSPolicyCache(provider, time.Minute)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	return customMiddleware.DynamicCorsMiddleware(cache, allowLocalhost)(next), provider, cache
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/auth/login", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	req.Header.Set("Access-Control-Request-Headers", headers)
+	return req
+}
+
+func TestCors_PreflightValidatedAgainstTenantPolicy(t *testing.T) {
+	handler, _, _ := newCorsHandler(t, uuid.New(), false)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, preflight("https://shop.example.com", "POST", "content-type,x-tenant-id"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://shop.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "120", rr.Header().Get("Access-Control-Max-Age"))
+
+	// Unknown origin is no longer reflected
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, preflight("https://evil.io", "POST", "content-type"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	// Method / header outside the tenant policy
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, preflight("https://shop.example.com", "DELETE", ""))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, preflight("https://shop.example.com", "POST", "x-custom"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestCors_LocalhostOnlyInDevelopment(t *testing.T) {
+	tenantID := uuid.New()
+	prod, _, _ := newCorsHandler(t, tenantID, false)
+	dev, _, _ := newCorsHandler(t, tenantID, true)
+
+	for _, tc := range []struct {
+		handler http.Handler
+		want    int
+	}{{prod, http.StatusForbidden}, {dev, http.StatusOK}} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil)
+		req.Header.Set("Origin", "http://localhost:4321")
+		req.Header.Set("X-Tenant-ID", tenantID.String())
+		rr := httptest.NewRecorder()
+		tc.handler.ServeHTTP(rr, req)
+		assert.Equal(t, tc.want, rr.Code)
+	}
+}
+
+func TestCors_CacheInvalidation(t *testing.T) {
+	tenantID := uuid.New()
+	handler, provider, cache := newCorsHandler(t, tenantID, false)
+
+	send := func() {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "https://shop.example.com")
+		req.Header.Set("X-Tenant-ID", tenantID.String())
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	send()
+	send()
+	assert.Equal(t, 1, provider.calls, "second request served from cache")
+
+	cache.Invalidate()
+	send()
+	assert.Equal(t, 2, provider.calls, "invalidation forces a reload")
+}
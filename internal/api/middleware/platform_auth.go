@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PlatformAdminLookup loads a platform operator. Implemented by *platform.Service,
+// which reads through storage.WithoutRLS.
+type PlatformAdminLookup interface {
+	GetPlatformAdmin(ctx context.Context, id pgtype.UUID) (db.PlatformAdmin, error)
+}
+
+// PlatformAuth guards the /platform/v1 plane.
+//
+// Only tokens with scope "platform" (issued by the platform login, which requires
+// password AND TOTP) are accepted. Tenant access tokens, impersonation tokens and
+// pre-auth tokens are rejected, and so are operators deactivated after their token
+// was issued. Bearer header only: no cookies, so no CSRF surface.
+func PlatformAuth(provider auth.TokenProvider, admins PlatformAdminLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if !strings.HasPrefix(authHeader, "Bearer ") {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := provider.ValidateToken(strings.TrimPrefix(authHeader, "Bearer "))
+			if err != nil || claims.Scope != "platform" {
+				slog.Warn("PlatformAuth: Rejected token", "error", err, "ip", r.RemoteAddr)
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			admin, err := admins.GetPlatformAdmin(r.Context(), pgtype.UUID{Bytes: claims.UserID, Valid: true})
+			if errors.Is(err, pgx.ErrNoRows) || (err == nil && !admin.IsActive) {
+				slog.Warn("PlatformAuth: Unknown or inactive operator", "platform_admin_id", claims.UserID, "ip", r.RemoteAddr)
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				slog.Error("PlatformAuth: Operator lookup failed", "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), PlatformAdminIDKey, claims.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
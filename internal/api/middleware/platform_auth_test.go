@@ -0,0 +1,97 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePlatformAdmins map[uuid.UUID]bool // id -> is_active
+
+func (f fakePlatformAdmins) GetPlatformAdmin(ctx context.Context, id pgtype.UUID) (db.PlatformAdmin, error) {
+	active, ok := f[id.Bytes]
+	if !ok {
+		return db.PlatformAdmin{}, pgx.ErrNoRows
+	}
+	return db.PlatformAdmin{ID: id, IsActive: active}, nil
+}
+
+func newTestJWTProvider(t *testing.T) *auth.JWTProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return auth.NewJWTProvider(string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})))
+}
+
+// TestPlatformPlane_TokenSeparation: platform tokens only open /platform/v1,
+// tenant tokens (including impersonation tokens) only open the tenant API.
+func TestPlatformPlane_TokenSeparation(t *testing.T) {
+	provider := newTestJWTProvider(t)
+	operator, disabledOperator := uuid.New(), uuid.New()
+	admins := fakePlatformAdmins{operator: true, disabledOperator: false}
+	userID, tenantID := uuid.New(), uuid.New()
+
+	platformToken, err := provider.GeneratePlatformToken(operator)
+	require.NoError(t, err)
+	disabledToken, err := provider.GeneratePlatformToken(disabledOperator)
+	require.NoError(t, err)
+	accessToken, err := provider.GenerateAccessToken(userID, tenantID, "admin", permissions.All(), nil)
+	require.NoError(t, err)
+	impersonationToken, err := provider.GenerateImpersonationToken(userID, tenantID, "admin", permissions.All(), operator)
+	require.NoError(t, err)
+	preAuthToken, err := provider.GeneratePreAuthToken(userID)
+	require.NoError(t, err)
+
+	var gotOperator, gotImpersonator uuid.UUID
+	var auditImpersonator uuid.UUID
+	platformAPI := customMiddleware.PlatformAuth(provider, admins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotOperator = customMiddleware.MustGetPlatformAdminID(r.Context())
+	}))
+	tenantAPI := customMiddleware.AuthMiddleware(provider, nil, nil, nil, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotImpersonator, _ = r.Context().Value(customMiddleware.ImpersonatorKey).(uuid.UUID)
+		auditImpersonator, _ = audit.ImpersonatorFrom(r.Context())
+	}))
+
+	do := func(h http.Handler, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Platform plane
+	assert.Equal(t, http.StatusOK, do(platformAPI, platformToken))
+	assert.Equal(t, operator, gotOperator)
+	assert.Equal(t, http.StatusUnauthorized, do(platformAPI, disabledToken))
+	assert.Equal(t, http.StatusUnauthorized, do(platformAPI, accessToken))
+	assert.Equal(t, http.StatusUnauthorized, do(platformAPI, impersonationToken))
+	assert.Equal(t, http.StatusUnauthorized, do(platformAPI, preAuthToken))
+
+	// Tenant plane
+	assert.Equal(t, http.StatusUnauthorized, do(tenantAPI, platformToken))
+	assert.Equal(t, http.StatusUnauthorized, do(tenantAPI, preAuthToken))
+	assert.Equal(t, http.StatusOK, do(tenantAPI, accessToken))
+	assert.Equal(t, uuid.Nil, gotImpersonator)
+
+	// Impersonation: the operator travels with the request into the audit log
+	assert.Equal(t, http.StatusOK, do(tenantAPI, impersonationToken))
+	assert.Equal(t, operator, gotImpersonator)
+	assert.Equal(t, operator, auditImpersonator)
+}
@@ -1,79 +1,180 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+	"github.com/google/uuid"
 )
 
-// IPRateLimiter holds the rate limiters for each visitor.
-type IPRateLimiter struct {
-	ips    sync.Map
-	mu     sync.Mutex
-	config LimiterConfig
+// maxPeekBody bounds how much of a request body we read to find the email
+// for email-keyed policies. Larger bodies are not auth payloads.
+const maxPeekBody = 64 << 10
+
+// RateLimiter enforces the declarative policy table per route group.
+// Counters live in a pluggable ratelimit.Store (memory or Postgres), so the
+// same budget holds across all replicas when the Postgres store is used.
+type RateLimiter struct {
+	limiter  *ratelimit.Limiter
+	recorder ratelimit.Recorder
+	notices  *SignInNotices
+	policies map[string][]ratelimit.Policy
 }
 
-type LimiterConfig struct {
-	RPS   rate.Limit
-	Burst int
+// NewRateLimiter creates a limiter. recorder may be nil (hits are then only
+// logged), notices too (owners of throttled addresses are then not emailed).
+func NewRateLimiter(store ratelimit.Store, recorder ratelimit.Recorder, notices *SignInNotices, policies map[string][]ratelimit.Policy) *RateLimiter {
+	return &RateLimiter{
+		limiter:  ratelimit.NewLimiter(store),
+		recorder: recorder,
+		notices:  notices,
+		policies: policies,
+	}
 }
 
-// NewIPRateLimiter creates a custom rate limiter.
-func NewIPRateLimiter(rps rate.Limit, burst int) *IPRateLimiter {
-	i := &IPRateLimiter{
-		config: LimiterConfig{
-			RPS:   rps,
-			Burst: burst,
-		},
+// Group returns middleware enforcing every policy registered for the named group.
+// Unknown groups panic at router construction time (programmer error).
+func (rl *RateLimiter) Group(name string) func(http.Handler) http.Handler {
+	policies, ok := rl.policies[name]
+	if !ok {
+		panic(fmt.Sprintf("ratelimit: unknown policy group %q", name))
 	}
 
-	// Background cleanup of old IPs could be added here to prevent memory leaks
-	go i.cleanupLoop()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range policies {
+				subject, ok := rateLimitSubject(r, p.Key)
+				if !ok {
+					// Key not available for this request (e.g. no email in body)
+					continue
+				}
+
+				decision, err := rl.limiter.Allow(r.Context(), p, subject)
+				if err != nil {
+					// Fail open: a broken counter store must not take the API down.
+					slog.Error("RateLimiter: store unavailable", "policy", p.Name, "error", err)
+					continue
+				}
+
+				setRateLimitHeaders(w, decision)
+
+				if !decision.Allowed {
+					if decision.FirstRejection() {
+						rl.recordHit(r, p, subject)
+						if p.NotifyOwner && p.Key == ratelimit.KeyEmail {
+							rl.notices.Send(r, subject, auth.ThrottledByRateLimit, decision.ResetAt)
+						}
+					}
+					slog.Warn("Rate Limit Exceeded", "policy", p.Name, "key_type", p.Key, "path", r.URL.Path)
+
+					retryAfter := int(math.Ceil(decision.RetryAfter(time.Now()).Seconds()))
+					w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+					http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+					return
+				}
+			}
 
-	return i
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-// GetLimiter returns the rate limiter for the provided IP address.
-func (i *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
-	limiter, exists := i.ips.Load(ip)
-	if !exists {
-		// Create new limiter
-		newLimiter := rate.NewLimiter(i.config.RPS, i.config.Burst)
-		i.ips.Store(ip, newLimiter)
-		return newLimiter
+// recordHit stores the rejection for the admin dashboard.
+// Runs detached from the request: a 429 response must not wait on the DB,
+// and TenantContext rolls back the request transaction anyway.
+func (rl *RateLimiter) recordHit(r *http.Request, p ratelimit.Policy, subject string) {
+	if rl.recorder == nil {
+		return
 	}
-	return limiter.(*rate.Limiter)
+
+	tenantID, _ := GetTenantID(r.Context())
+	hit := ratelimit.Hit{
+		Policy:      p.Name,
+		KeyType:     p.Key,
+		SubjectHash: ratelimit.HashSubject(subject),
+		TenantID:    tenantID,
+		IP:          helpers.GetRealIP(r),
+		Path:        r.URL.Path,
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := rl.recorder.RecordHit(ctx, hit); err != nil {
+			slog.Error("RateLimiter: failed to record hit", "policy", hit.Policy, "error", err)
+		}
+	}()
 }
 
-func (i *IPRateLimiter) cleanupLoop() {
-	for {
-		time.Sleep(10 * time.Minute)
-		// Simplistic cleanup: clear map. In production, use LRU or last-seen timestamp.
-		// For now, we trust the "Anti-Gravity" robustness of the server restarts.
-		// A full wipe is acceptable for a dev/staging MVP.
-		i.ips.Range(func(key, value interface{}) bool {
-			i.ips.Delete(key)
-			return true
-		})
+// rateLimitSubject resolves the value a policy counts against.
+func rateLimitSubject(r *http.Request, key ratelimit.KeyType) (string, bool) {
+	switch key {
+	case ratelimit.KeyIP:
+		ip := helpers.GetRealIP(r)
+		if ip == nil {
+			return r.RemoteAddr, r.RemoteAddr != ""
+		}
+		return ip.String(), true
+	case ratelimit.KeyEmail:
+		email := peekEmail(r)
+		return email, email != ""
+	case ratelimit.KeyTenant:
+		id, err := GetTenantID(r.Context())
+		return id.String(), err == nil && id != uuid.Nil
+	case ratelimit.KeyUser:
+		id, err := GetUserID(r.Context())
+		return id.String(), err == nil && id != uuid.Nil
 	}
+	return "", false
 }
 
-// RateLimitMiddleware enforces the rate limit per IP.
-func (i *IPRateLimiter) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
-		// If behind proxy (e.g. Nginx/Cloudflare), use X-Forwarded-For (handled by chi middleware.RealIP upstream)
+// peekEmail reads the "email" field from a JSON body and restores the body
+// so the handler can decode it normally.
+func peekEmail(r *http.Request) string {
+	if r.Body == nil || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+	if err != nil {
+		return ""
+	}
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
 
-		limiter := i.GetLimiter(ip)
-		if !limiter.Allow() {
-			slog.Warn("Rate Limit Exceeded", "ip", ip, "path", r.URL.Path)
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+// setRateLimitHeaders writes the IETF RateLimit-* headers.
+// When several policies apply (global + route group) the most restrictive one wins.
+func setRateLimitHeaders(w http.ResponseWriter, d ratelimit.Decision) {
+	h := w.Header()
+	if existing := h.Get("RateLimit-Remaining"); existing != "" && d.Allowed {
+		if current, err := strconv.Atoi(existing); err == nil && current <= d.Remaining {
 			return
 		}
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	reset := int(math.Ceil(d.RetryAfter(time.Now()).Seconds()))
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Policy.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(reset))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", d.Policy.Limit, int(d.Policy.Window.Seconds())))
 }
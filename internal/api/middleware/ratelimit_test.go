@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(policies ...ratelimit.Policy) *customMiddleware.RateLimiter {
+	return customMiddleware.NewRateLimiter(
+		ratelimit.NewMemoryStore(0),
+		nil,
+		nil,
+		map[string][]ratelimit.Policy{"test": policies},
+	)
+}
+
+func TestRateLimiter_IPPolicy_SetsHeadersAndBlocks(t *testing.T) {
+	limiter := newTestLimiter(ratelimit.Policy{Name: "test.ip", Key: ratelimit.KeyIP, Limit: 2, Window: time.Minute})
+	handler := limiter.Group("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.7:5555"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, "request %d should pass", i+1)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, wantRemaining, rr.Header().Get("RateLimit-Remaining"))
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+
+	// A different IP has its own budget
+	req = httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "198.51.100.1:5555"
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRateLimiter_EmailPolicy_PreservesBody(t *testing.T) {
+	limiter := newTestLimiter(ratelimit.Policy{Name: "test.email", Key: ratelimit.KeyEmail, Limit: 1, Window: time.Minute})
+
+	var seenBody string
+	handler := limiter.Group("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seenBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(email string) int {
+		body := `{"email":"` + email + `","password":"x"}`
+		req := httptest.NewRequest("POST", "/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("victim@example.com"))
+	assert.Contains(t, seenBody, `"password":"x"`, "handler must still see the full body")
+
+	// Same account (case-insensitive) from anywhere is blocked
+	assert.Equal(t, http.StatusTooManyRequests, send("Victim@Example.com"))
+	assert.Equal(t, http.StatusOK, send("other@example.com"))
+}
+
+func TestMemoryStore_SweepRemovesExpiredBuckets(t *testing.T) {
+	store := ratelimit.NewMemoryStore(0)
+	start := time.Now().Truncate(time.Minute)
+
+	_, err := store.Increment(t.Context(), "k", start, time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, store.Sweep(start.Add(30*time.Second)))
+	assert.Equal(t, 1, store.Sweep(start.Add(time.Minute)))
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxUserAgentLength caps what is copied from the User-Agent header into audit rows.
+const maxUserAgentLength = 512
+
+// RequestMetadata seeds requestmeta with the request ID, client IP and user agent.
+// Must run after chi's RequestID; TenantContext and AuthMiddleware add tenant and actor.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := requestmeta.Metadata{
+			RequestID: middleware.GetReqID(r.Context()),
+			UserAgent: r.UserAgent(),
+		}
+		if len(meta.UserAgent) > maxUserAgentLength {
+			meta.UserAgent = meta.UserAgent[:maxUserAgentLength]
+		}
+		// Postgres rejects invalid UTF-8 (also left by the cut above)
+		meta.UserAgent = strings.ToValidUTF8(meta.UserAgent, "")
+		if ip, ok := netip.AddrFromSlice(helpers.GetRealIP(r)); ok {
+			meta.IP = ip.Unmap()
+		}
+		next.ServeHTTP(w, r.WithContext(requestmeta.With(r.Context(), meta)))
+	})
+}
@@ -0,0 +1,45 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMetadata(t *testing.T) {
+	userID := uuid.New()
+	var got requestmeta.Metadata
+	var ok bool
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok = requestmeta.From(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	auth := customMiddleware.AuthMiddleware(roleTokens{}, nil, nil, nil, permissions.NewResolver(fakeRoles{}), nil)
+	handler := middleware.RequestID(customMiddleware.RequestMetadata(auth(inner)))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-For", "::ffff:203.0.113.7, 10.0.0.1")
+	req.Header.Set("X-Request-Id", "req-123")
+	req.Header.Set("User-Agent", "Mozilla/5.0 "+strings.Repeat("x", 600))
+	req.Header.Set("Authorization", "Bearer "+userID.String()+"|"+tenantA.String()+"|admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, ok)
+	assert.Equal(t, "req-123", got.RequestID)
+	assert.Equal(t, netip.MustParseAddr("203.0.113.7"), got.IP, "IPv4-mapped addresses are unmapped")
+	assert.Len(t, got.UserAgent, 512)
+	assert.Equal(t, userID, got.ActorID)
+	assert.Equal(t, tenantA, got.TenantID)
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
+	"github.com/google/uuid"
+)
+
+// SignInThrottleNotifier emails the owner of an address that sign-in is
+// refused for a while; *auth.AuthService satisfies it.
+type SignInThrottleNotifier interface {
+	NotifySignInThrottled(ctx context.Context, tenantID uuid.UUID, email, protection string, until time.Time)
+}
+
+// SignInNotices tells account owners when the login rate limit or the
+// challenge gate starts refusing sign-ins to their address. A marker in the
+// shared counter store caps it at one notice per address per window, whichever
+// protection fires first, so failed logins cannot be used to flood an inbox.
+type SignInNotices struct {
+	notifier SignInThrottleNotifier
+	store    ratelimit.Store
+	window   time.Duration
+	now      func() time.Time
+}
+
+func NewSignInNotices(notifier SignInThrottleNotifier, store ratelimit.Store, window time.Duration) *SignInNotices {
+	return &SignInNotices{notifier: notifier, store: store, window: window, now: time.Now}
+}
+
+// Send notifies the owner of email, unless a notice already went out this window.
+// Runs detached from the request: the rejected request's transaction rolls
+// back, and a 428/429 response must not wait on the mail outbox.
+func (n *SignInNotices) Send(r *http.Request, email, protection string, until time.Time) {
+	if n == nil || email == "" {
+		return
+	}
+	tenantID, err := GetTenantID(r.Context())
+	if err != nil || tenantID == uuid.Nil {
+		return
+	}
+
+	// Request metadata (IP, device) is carried over; the request transaction is not
+	ctx := context.Background()
+	if meta, ok := requestmeta.From(r.Context()); ok {
+		ctx = requestmeta.With(ctx, meta)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		key := "signin.notice:" + tenantID.String() + ":" + ratelimit.HashSubject(email)
+		sent, err := n.store.Increment(ctx, key, n.now().Truncate(n.window), n.window)
+		if err != nil {
+			slog.Error("SignInNotices: Counter unavailable", "error", err)
+			return
+		}
+		if sent > 1 {
+			return
+		}
+		n.notifier.NotifySignInThrottled(ctx, tenantID, email, protection, until)
+	}()
+}
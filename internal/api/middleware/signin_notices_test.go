@@ -0,0 +1,138 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/challenge"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier collects the notices SignInNotices hands out.
+type recordingNotifier struct {
+	mu      sync.Mutex
+	notices []string // "email/protection"
+	sent    chan struct{}
+}
+
+func newRecordingNotifier() *recordingNotifier {
+	return &recordingNotifier{sent: make(chan struct{}, 16)}
+}
+
+func (n *recordingNotifier) NotifySignInThrottled(_ context.Context, _ uuid.UUID, email, protection string, _ time.Time) {
+	n.mu.Lock()
+	n.notices = append(n.notices, email+"/"+protection)
+	n.mu.Unlock()
+	n.sent <- struct{}{}
+}
+
+// waitFor blocks until want notices arrived, then briefly checks no more follow.
+func (n *recordingNotifier) waitFor(t *testing.T, want int) []string {
+	t.Helper()
+	for range want {
+		select {
+		case <-n.sent:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d notices", want)
+		}
+	}
+	select {
+	case <-n.sent:
+		t.Fatal("unexpected extra notice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.notices...)
+}
+
+func loginRequest(tenantID uuid.UUID, email string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"`+email+`","password":"wrong"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "203.0.113.7:5555"
+	return req.WithContext(context.WithValue(req.Context(), customMiddleware.TenantIDKey, tenantID))
+}
+
+func TestSignInNotices_RateLimitNotifiesOncePerWindow(t *testing.T) {
+	store := ratelimit.NewMemoryStore(0)
+	notifier := newRecordingNotifier()
+	limiter := customMiddleware.NewRateLimiter(store, nil, customMiddleware.NewSignInNotices(notifier, store, time.Minute),
+		map[string][]ratelimit.Policy{"test": {
+			{Name: "test.email", Key: ratelimit.KeyEmail, Limit: 1, Window: time.Minute, NotifyOwner: true},
+		}})
+	handler := limiter.Group("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	tenantID := uuid.New()
+
+	for range 5 {
+		handler.ServeHTTP(httptest.NewRecorder(), loginRequest(tenantID, "victim@example.com"))
+	}
+
+	assert.Equal(t, []string{"victim@example.com/" + auth.ThrottledByRateLimit}, notifier.waitFor(t, 1))
+}
+
+func TestSignInNotices_PolicyWithoutNotifyOwnerStaysQuiet(t *testing.T) {
+	store := ratelimit.NewMemoryStore(0)
+	notifier := newRecordingNotifier()
+	limiter := customMiddleware.NewRateLimiter(store, nil, customMiddleware.NewSignInNotices(notifier, store, time.Minute),
+		map[string][]ratelimit.Policy{"test": {
+			{Name: "test.email", Key: ratelimit.KeyEmail, Limit: 1, Window: time.Minute},
+		}})
+	handler := limiter.Group("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), loginRequest(uuid.New(), "victim@example.com"))
+	}
+
+	assert.Empty(t, notifier.waitFor(t, 0))
+}
+
+func TestSignInNotices_ChallengeAndRateLimitShareTheCap(t *testing.T) {
+	store := ratelimit.NewMemoryStore(0)
+	notifier := newRecordingNotifier()
+	notices := customMiddleware.NewSignInNotices(notifier, store, time.Minute)
+	gate := customMiddleware.NewChallengeGate(
+		challenge.NewProofOfWork([]byte("test-secret"), time.Minute, store),
+		nil,
+		challenge.NewFailureTracker(store, time.Minute),
+		challengeTenant{},
+		notices,
+	)
+	limiter := customMiddleware.NewRateLimiter(store, nil, notices,
+		map[string][]ratelimit.Policy{"test": {
+			{Name: "test.email", Key: ratelimit.KeyEmail, Limit: 2, Window: time.Minute, NotifyOwner: true},
+		}})
+	handler := limiter.Group("test")(gate.Protect(challenge.ScopeLogin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	})))
+	tenantID := uuid.New()
+
+	login := func() int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, loginRequest(tenantID, "victim@example.com"))
+		return rr.Code
+	}
+
+	// The first failure crosses the challenge threshold of 1
+	require.Equal(t, http.StatusUnauthorized, login())
+	assert.Equal(t, []string{"victim@example.com/" + auth.ThrottledByChallenge}, notifier.waitFor(t, 1))
+
+	// The rate limit then rejects the address too, within the same window
+	require.Equal(t, http.StatusPreconditionRequired, login())
+	require.Equal(t, http.StatusTooManyRequests, login())
+	require.Equal(t, http.StatusTooManyRequests, login())
+	assert.Len(t, notifier.waitFor(t, 0), 1, "one notice per address per window")
+}
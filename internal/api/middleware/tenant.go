@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -22,25 +23,41 @@ import (
 //	r.Use(customMiddleware.TenantContext(pool))
 //
 // The X-Tenant-ID header is OPTIONAL by default, but if present, it MUST be valid.
+// When TenantResolver runs earlier in the chain, its resolution (domain,
+// subdomain, /t/{slug} path or token tid) is used instead of the raw header.
 // When set, all downstream database queries will respect RLS policies.
 func TenantContext(pool *pgxpool.Pool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			tenantIDStr := r.Header.Get("X-Tenant-ID")
-
-			// No tenant context? Continue without RLS enforcement.
-			// This allows public endpoints (health, login, register) to function.
-			if tenantIDStr == "" {
-				next.ServeHTTP(w, r)
-				return
-			}
+			var tenantUUID uuid.UUID
+			source := "header-provided"
+
+			if resolverRan(r.Context()) {
+				// TenantResolver already validated header, path, host and token sources.
+				res, ok := GetTenantResolution(r.Context())
+				if !ok {
+					next.ServeHTTP(w, r)
+					return
+				}
+				tenantUUID, source = res.TenantID, string(res.Source)
+			} else {
+				tenantIDStr := r.Header.Get("X-Tenant-ID")
+
+				// No tenant context? Continue without RLS enforcement.
+				// This allows public endpoints (health, login, register) to function.
+				if tenantIDStr == "" {
+					next.ServeHTTP(w, r)
+					return
+				}
 
-			// Anti-Gravity Law 1: Input is toxic. Validate strictly.
-			tenantUUID, err := uuid.Parse(tenantIDStr)
-			if err != nil {
-				slog.Warn("Invalid Tenant ID Header", "value", tenantIDStr, "ip", r.RemoteAddr)
-				http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
-				return
+				// Anti-Gravity Law 1: Input is toxic. Validate strictly.
+				parsed, err := uuid.Parse(tenantIDStr)
+				if err != nil {
+					slog.Warn("Invalid Tenant ID Header", "value", tenantIDStr, "ip", r.RemoteAddr)
+					http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
+					return
+				}
+				tenantUUID = parsed
 			}
 
 			// Law 2: Silence is Golden. We don't check if it exists in DB here (perf),
@@ -48,44 +65,51 @@ func TenantContext(pool *pgxpool.Pool) func(http.Handler) http.Handler {
 
 			// Inject into Context (for application logic)
 			ctx := context.WithValue(r.Context(), TenantIDKey, tenantUUID)
+			ctx = requestmeta.WithTenant(ctx, tenantUUID)
 
 			// Inject into Sentry (using our helper)
-			SetSentryTenant(ctx, tenantUUID.String(), "header-provided")
-
-			// PHASE 50 RLS: Set database session variable
-			// We use WithTenantContext to wrap the downstream handler execution
-			// in a transaction with SET LOCAL app.current_tenant.
-			//
-			// IMPORTANT: This means the ENTIRE request handler runs in ONE transaction.
-			// Handlers must be idempotent and handle rollbacks properly.
-			err = storage.WithTenantContext(ctx, pool, tenantUUID, func(tx pgx.Tx) error {
-				// Store the transaction in context for handlers to use
-				// Handlers can access via: storage.GetTx(ctx)
-				ctxWithTx := context.WithValue(ctx, storage.TxKey, tx)
-
-				// Create a custom ResponseWriter to capture errors
-				rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-
-				// Execute the downstream handler
-				next.ServeHTTP(rw, r.WithContext(ctxWithTx))
-
-				// If handler wrote an error status (4xx/5xx), rollback
-				if rw.statusCode >= 400 {
-					return http.ErrAbortHandler // Triggers rollback
-				}
+			SetSentryTenant(ctx, tenantUUID.String(), source)
 
-				return nil // Commit transaction
-			})
-
-			if err != nil && err != http.ErrAbortHandler {
-				// Transaction failed for reasons other than intentional abort
-				slog.Error("RLS transaction failed", "error", err, "tenant_id", tenantUUID)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
+			serveInTenantTx(w, r.WithContext(ctx), pool, tenantUUID, next)
 		})
 	}
 }
 
+// serveInTenantTx runs next inside a transaction with SET LOCAL app.current_tenant.
+// The request context must already carry TenantIDKey.
+func serveInTenantTx(w http.ResponseWriter, r *http.Request, pool *pgxpool.Pool, tenantID uuid.UUID, next http.Handler) {
+	// PHASE 50 RLS: Set database session variable
+	// We use WithTenantContext to wrap the downstream handler execution
+	// in a transaction with SET LOCAL app.current_tenant.
+	//
+	// IMPORTANT: This means the ENTIRE request handler runs in ONE transaction.
+	// Handlers must be idempotent and handle rollbacks properly.
+	err := storage.WithTenantContext(r.Context(), pool, tenantID, func(tx pgx.Tx) error {
+		// Store the transaction in context for handlers to use
+		// Handlers can access via: storage.GetTx(ctx)
+		ctxWithTx := context.WithValue(r.Context(), storage.TxKey, tx)
+
+		// Create a custom ResponseWriter to capture errors
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		// Execute the downstream handler
+		next.ServeHTTP(rw, r.WithContext(ctxWithTx))
+
+		// If handler wrote an error status (4xx/5xx), rollback
+		if rw.statusCode >= 400 {
+			return http.ErrAbortHandler // Triggers rollback
+		}
+
+		return nil // Commit transaction
+	})
+
+	if err != nil && err != http.ErrAbortHandler {
+		// Transaction failed for reasons other than intentional abort
+		slog.Error("RLS transaction failed", "error", err, "tenant_id", tenantID)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
 // responseWriter wraps http.ResponseWriter to capture status codes
 type responseWriter struct {
 	http.ResponseWriter
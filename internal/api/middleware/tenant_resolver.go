@@ -0,0 +1,393 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TenantSource identifies where a tenant was resolved from.
+type TenantSource string
+
+const (
+	SourceHeader    TenantSource = "header"    // X-Tenant-ID: <uuid>
+	SourcePath      TenantSource = "path"      // /t/{slug}/api/v1/...
+	SourceDomain    TenantSource = "domain"    // custom domain in tenant_domains
+	SourceSubdomain TenantSource = "subdomain" // {slug}.<BaseDomain>
+	SourceToken     TenantSource = "token"     // tid claim of a valid access token
+)
+
+// DefaultTenantSources is the default precedence (first wins when conflicts are allowed).
+var DefaultTenantSources = []TenantSource{SourceHeader, SourcePath, SourceDomain, SourceSubdomain, SourceToken}
+
+const tenantPathPrefix = "/t/"
+
+// Maximum cached hostname/slug lookups (negative results included) so random
+// Host headers cannot grow the cache without bound.
+const maxTenantLookupCache = 10000
+
+var (
+	ErrUnknownTenant  = errors.New("unknown tenant")
+	ErrTenantConflict = errors.New("conflicting tenant context")
+)
+
+// TenantLookup resolves hostnames and slugs to tenant IDs.
+type TenantLookup interface {
+	GetTenantIDByDomain(ctx context.Context, domain string) (pgtype.UUID, error)
+	GetTenantBySlug(ctx context.Context, slug string) (db.Tenant, error)
+}
+
+// TenantResolverConfig controls the resolver chain.
+type TenantResolverConfig struct {
+	// Sources in precedence order. Sources not listed are ignored.
+	Sources []TenantSource
+	// BaseDomain enables subdomain resolution: "acme.auth.example.com" -> slug "acme"
+	// when BaseDomain is "auth.example.com". Empty disables SourceSubdomain.
+	BaseDomain string
+	// RejectConflicts answers 400 when two routing sources (header, path,
+	// domain, subdomain) resolve to different tenants. On authenticated
+	// routes AuthMiddleware also answers 400 when the header names another
+	// tenant than the token's tid: a client acting in another tenant switches
+	// its session there first (POST /auth/tenants/switch). Public routes
+	// (login, refresh) ignore the token, so a stale cookie cannot block them.
+	// When false, the highest-precedence source wins. Either way a token tid
+	// that differs from a path, domain or subdomain tenant is not a conflict:
+	// AuthMiddleware authorizes it (or not) via a membership lookup.
+	RejectConflicts bool
+	// CacheTTL for domain/slug lookups.
+	CacheTTL time.Duration
+}
+
+// TenantResolution is the outcome of the resolver chain, stored in the request context.
+type TenantResolution struct {
+	TenantID uuid.UUID
+	Source   TenantSource
+
+	// headerConflict is set under RejectConflicts when X-Tenant-ID differs
+	// from the token's tid; AuthMiddleware rejects it on protected routes.
+	headerConflict bool
+}
+
+type resolutionKey struct{}
+
+// GetTenantResolution returns the resolver result. ok is false when the
+// resolver did not run or no source identified a tenant.
+func GetTenantResolution(ctx context.Context) (TenantResolution, bool) {
+	res, ok := ctx.Value(resolutionKey{}).(TenantResolution)
+	return res, ok && res.TenantID != uuid.Nil
+}
+
+// resolverRan reports whether ResolveTenant ran for this request, so
+// downstream middleware knows not to fall back to the raw header.
+func resolverRan(ctx context.Context) bool {
+	_, ok := ctx.Value(resolutionKey{}).(TenantResolution)
+	return ok
+}
+
+type lookupEntry struct {
+	tenantID  uuid.UUID // uuid.Nil = negative result
+	expiresAt time.Time
+}
+
+// TenantResolver identifies the tenant of a request from several sources.
+type TenantResolver struct {
+	cfg    TenantResolverConfig
+	lookup TenantLookup
+	tokens auth.TokenProvider
+
+	mu    sync.Mutex
+	cache map[string]lookupEntry
+}
+
+func NewTenantResolver(cfg TenantResolverConfig, lookup TenantLookup, tokens auth.TokenProvider) *TenantResolver {
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = DefaultTenantSources
+	}
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = 5 * time.Minute
+	}
+	cfg.BaseDomain = strings.ToLower(strings.TrimPrefix(cfg.BaseDomain, "."))
+	return &TenantResolver{cfg: cfg, lookup: lookup, tokens: tokens, cache: make(map[string]lookupEntry)}
+}
+
+// ParseTenantSources parses a comma separated precedence list ("header,path,token").
+func ParseTenantSources(s string) ([]TenantSource, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultTenantSources, nil
+	}
+	var sources []TenantSource
+	for _, part := range strings.Split(s, ",") {
+		src := TenantSource(strings.TrimSpace(strings.ToLower(part)))
+		switch src {
+		case SourceHeader, SourcePath, SourceDomain, SourceSubdomain, SourceToken:
+			sources = append(sources, src)
+		default:
+			return nil, fmt.Errorf("unknown tenant source %q", part)
+		}
+	}
+	return sources, nil
+}
+
+// Middleware runs the resolver chain, strips a /t/{slug} prefix so the router
+// sees the canonical path, and stores the TenantResolution in the context.
+// TenantContext and DynamicCorsMiddleware consume the resolution.
+func (tr *TenantResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, r, err := tr.Resolve(r)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUnknownTenant):
+				http.Error(w, "Unknown tenant", http.StatusNotFound)
+			case errors.Is(err, ErrTenantConflict):
+				slog.Warn("TenantResolver: Conflicting sources", "error", err, "ip", r.RemoteAddr)
+				http.Error(w, "Conflicting tenant context", http.StatusBadRequest)
+			case errors.Is(err, errInvalidTenantHeader):
+				slog.Warn("Invalid Tenant ID Header", "value", r.Header.Get("X-Tenant-ID"), "ip", r.RemoteAddr)
+				http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
+			default:
+				slog.Error("TenantResolver: Lookup failed", "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), resolutionKey{}, res)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+var errInvalidTenantHeader = errors.New("invalid X-Tenant-ID header")
+
+type candidate struct {
+	source   TenantSource
+	tenantID uuid.UUID
+}
+
+// Resolve evaluates every configured source. It returns the (possibly
+// path-rewritten) request so callers can continue with it.
+func (tr *TenantResolver) Resolve(r *http.Request) (TenantResolution, *http.Request, error) {
+	var candidates []candidate
+
+	for _, src := range tr.cfg.Sources {
+		var (
+			id  uuid.UUID
+			err error
+		)
+		switch src {
+		case SourceHeader:
+			id, err = tenantFromHeader(r)
+		case SourcePath:
+			var slug string
+			slug, r = stripTenantPath(r)
+			if slug != "" {
+				id, err = tr.resolveSlug(r.Context(), slug)
+			}
+		case SourceDomain:
+			if host := requestHost(r); host != "" && !tr.isBaseDomain(host) {
+				id, err = tr.resolveDomain(r.Context(), host)
+				if errors.Is(err, ErrUnknownTenant) {
+					// Hosts that are not custom domains (API hostname, IPs) are normal.
+					id, err = uuid.Nil, nil
+				}
+			}
+		case SourceSubdomain:
+			if slug := tr.subdomainSlug(requestHost(r)); slug != "" {
+				id, err = tr.resolveSlug(r.Context(), slug)
+			}
+		case SourceToken:
+			id = tr.tenantFromToken(r)
+		}
+		if err != nil {
+			return TenantResolution{}, r, err
+		}
+		if id != uuid.Nil {
+			candidates = append(candidates, candidate{source: src, tenantID: id})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return TenantResolution{}, r, nil
+	}
+
+	// Conflicts are checked between routing sources. A header that differs
+	// from the token is only flagged: the token may be a stale cookie on a
+	// public route, so AuthMiddleware decides. A token tid that differs from
+	// a host or path tenant is authorized there too ("Anti-Gravity Law: Strict Scoping").
+	headerConflict := false
+	if tr.cfg.RejectConflicts {
+		var routed, header, token *candidate
+		for i, c := range candidates {
+			switch c.source {
+			case SourceToken:
+				token = &candidates[i]
+				continue
+			case SourceHeader:
+				header = &candidates[i]
+			}
+			if routed == nil {
+				routed = &candidates[i]
+			} else if c.tenantID != routed.tenantID {
+				return TenantResolution{}, r, fmt.Errorf("%w: %s=%s %s=%s", ErrTenantConflict, routed.source, routed.tenantID, c.source, c.tenantID)
+			}
+		}
+		headerConflict = header != nil && token != nil && header.tenantID != token.tenantID
+	}
+
+	winner := candidates[0]
+
+	return TenantResolution{TenantID: winner.tenantID, Source: winner.source, headerConflict: headerConflict}, r, nil
+}
+
+func tenantFromHeader(r *http.Request) (uuid.UUID, error) {
+	value := r.Header.Get("X-Tenant-ID")
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	// Anti-Gravity Law 1: Input is toxic. Validate strictly.
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, errInvalidTenantHeader
+	}
+	return id, nil
+}
+
+// tenantFromToken reads the tid claim of a VALID token. Invalid or expired
+// tokens are ignored here; AuthMiddleware rejects them on protected routes.
+func (tr *TenantResolver) tenantFromToken(r *http.Request) uuid.UUID {
+	if tr.tokens == nil {
+		return uuid.Nil
+	}
+	tokenStr := extractJWT(r)
+	if tokenStr == "" {
+		return uuid.Nil
+	}
+	claims, err := tr.tokens.ValidateToken(tokenStr)
+	if err != nil {
+		return uuid.Nil
+	}
+	return claims.TenantID
+}
+
+// stripTenantPath removes a leading /t/{slug} and returns the slug.
+func stripTenantPath(r *http.Request) (string, *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, tenantPathPrefix)
+	if !ok {
+		return "", r
+	}
+	slug, remainder, _ := strings.Cut(rest, "/")
+	if slug == "" {
+		return "", r
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/" + remainder
+	r2.URL.RawPath = ""
+	if rctx := chi.RouteContext(r2.Context()); rctx != nil {
+		rctx.RoutePath = r2.URL.Path
+	}
+	return strings.ToLower(slug), r2
+}
+
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func (tr *TenantResolver) isBaseDomain(host string) bool {
+	return tr.cfg.BaseDomain != "" && (host == tr.cfg.BaseDomain || strings.HasSuffix(host, "."+tr.cfg.BaseDomain))
+}
+
+// subdomainSlug returns the single label in front of BaseDomain.
+func (tr *TenantResolver) subdomainSlug(host string) string {
+	if tr.cfg.BaseDomain == "" {
+		return ""
+	}
+	label, ok := strings.CutSuffix(host, "."+tr.cfg.BaseDomain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+func (tr *TenantResolver) resolveDomain(ctx context.Context, host string) (uuid.UUID, error) {
+	return tr.cached(ctx, "domain:"+host, func(ctx context.Context) (uuid.UUID, error) {
+		id, err := tr.lookup.GetTenantIDByDomain(ctx, host)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return uuid.UUID(id.Bytes), nil
+	})
+}
+
+func (tr *TenantResolver) resolveSlug(ctx context.Context, slug string) (uuid.UUID, error) {
+	return tr.cached(ctx, "slug:"+slug, func(ctx context.Context) (uuid.UUID, error) {
+		tenant, err := tr.lookup.GetTenantBySlug(ctx, slug)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if !tenant.IsActive {
+			return uuid.Nil, pgx.ErrNoRows
+		}
+		return uuid.UUID(tenant.ID.Bytes), nil
+	})
+}
+
+// cached memoises lookups, including "not found", for CacheTTL.
+func (tr *TenantResolver) cached(ctx context.Context, key string, load func(context.Context) (uuid.UUID, error)) (uuid.UUID, error) {
+	now := time.Now()
+
+	tr.mu.Lock()
+	entry, ok := tr.cache[key]
+	tr.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		if entry.tenantID == uuid.Nil {
+			return uuid.Nil, ErrUnknownTenant
+		}
+		return entry.tenantID, nil
+	}
+
+	id, err := load(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	tr.mu.Lock()
+	if len(tr.cache) >= maxTenantLookupCache {
+		clear(tr.cache)
+	}
+	tr.cache[key] = lookupEntry{tenantID: id, expiresAt: now.Add(tr.cfg.CacheTTL)}
+	tr.mu.Unlock()
+
+	if id == uuid.Nil {
+		return uuid.Nil, ErrUnknownTenant
+	}
+	return id, nil
+}
+
+// Invalidate drops cached lookups (after tenant_domains or slug changes).
+func (tr *TenantResolver) Invalidate() {
+	tr.mu.Lock()
+	clear(tr.cache)
+	tr.mu.Unlock()
+}
+
+// BaseDomain returns the configured subdomain-routing base domain ("" if disabled).
+func (tr *TenantResolver) BaseDomain() string {
+	return tr.cfg.BaseDomain
+}
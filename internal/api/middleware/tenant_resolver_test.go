@@ -0,0 +1,232 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTenantLookup struct {
+	domains map[string]uuid.UUID
+	slugs   map[string]uuid.UUID
+	calls   int
+}
+
+func (f *fakeTenantLookup) GetTenantIDByDomain(ctx context.Context, domain string) (pgtype.UUID, error) {
+	f.calls++
+	id, ok := f.domains[domain]
+	if !ok {
+		return pgtype.UUID{}, pgx.ErrNoRows
+	}
+	return pgtype.UUID{Bytes: id, Valid: true}, nil
+}
+
+func (f *fakeTenantLookup) GetTenantBySlug(ctx context.Context, slug string) (db.Tenant, error) {
+	f.calls++
+	id, ok := f.slugs[slug]
+	if !ok {
+		return db.Tenant{}, pgx.ErrNoRows
+	}
+	return db.Tenant{ID: pgtype.UUID{Bytes: id, Valid: true}, Slug: slug, IsActive: true}, nil
+}
+
+// fakeTokens accepts "tid:<uuid>" as a valid bearer token.
+type fakeTokens struct{}
+
+func (fakeTokens) GenerateAccessToken(userID, tenantID uuid.UUID, role string, perms []string, emailVerified *bool) (string, error) {
+	return "tid:" + tenantID.String(), nil
+}
+func (fakeTokens) GeneratePreAuthToken(userID uuid.UUID) (string, error) { return "", nil }
+func (fakeTokens) GetJWKS() (*auth.JWKS, error)                          { return &auth.JWKS{}, nil }
+func (fakeTokens) ValidateToken(token string) (*auth.Claims, error) {
+	id, err := uuid.Parse(token[len("tid:"):])
+	if err != nil {
+		return nil, auth.ErrInvalidToken
+	}
+	return &auth.Claims{TenantID: id, Scope: "access"}, nil
+}
+
+var (
+	tenantA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	tenantB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+)
+
+func newResolver(reject bool) (*customMiddleware.TenantResolver, *fakeTenantLookup) {
+	lookup := &fakeTenantLookup{
+		domains: map[string]uuid.UUID{"login.acme.nl": tenantA},
+		slugs:   map[string]uuid.UUID{"acme": tenantA, "beta": tenantB},
+	}
+	return customMiddleware.NewTenantResolver(customMiddleware.TenantResolverConfig{
+		BaseDomain:      "auth.example.com",
+		RejectConflicts: reject,
+	}, lookup, fakeTokens{}), lookup
+}
+
+// serve runs the resolver inside a chi router and reports the resolved tenant and routed path.
+func serve(t *testing.T, tr *customMiddleware.TenantResolver, req *http.Request) (*httptest.ResponseRecorder, customMiddleware.TenantResolution, string) {
+	t.Helper()
+	var (
+		got    customMiddleware.TenantResolution
+		routed string
+	)
+	r := chi.NewRouter()
+	r.Use(tr.Middleware)
+	r.Get("/api/v1/ping", func(w http.ResponseWriter, r *http.Request) {
+		got, _ = customMiddleware.GetTenantResolution(r.Context())
+		routed = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec, got, routed
+}
+
+func TestTenantResolver_Sources(t *testing.T) {
+	tr, _ := newResolver(true)
+
+	cases := []struct {
+		name   string
+		host   string
+		path   string
+		header string
+		token  string
+		want   customMiddleware.TenantSource
+	}{
+		{"header", "api.example.com", "/api/v1/ping", tenantA.String(), "", customMiddleware.SourceHeader},
+		{"path", "api.example.com", "/t/acme/api/v1/ping", "", "", customMiddleware.SourcePath},
+		{"custom domain", "login.acme.nl:443", "/api/v1/ping", "", "", customMiddleware.SourceDomain},
+		{"subdomain", "acme.auth.example.com", "/api/v1/ping", "", "", customMiddleware.SourceSubdomain},
+		{"token", "api.example.com", "/api/v1/ping", "", "tid:" + tenantA.String(), customMiddleware.SourceToken},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Host = tc.host
+			if tc.header != "" {
+				req.Header.Set("X-Tenant-ID", tc.header)
+			}
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			rec, res, routed := serve(t, tr, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, tenantA, res.TenantID)
+			assert.Equal(t, tc.want, res.Source)
+			assert.Equal(t, "/api/v1/ping", routed)
+		})
+	}
+}
+
+// serveWithAuth mounts a public /auth/login and a protected /me behind the resolver.
+func serveWithAuth(t *testing.T, tr *customMiddleware.TenantResolver, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := chi.NewRouter()
+	r.Use(tr.Middleware)
+	r.Post("/api/v1/auth/login", ok)
+	r.With(customMiddleware.AuthMiddleware(roleTokens{}, fakeMemberships{}, nil, nil, permissions.NewResolver(fakeRoles{}), nil)).Get("/api/v1/me", ok)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTenantResolver_HeaderMustMatchToken(t *testing.T) {
+	userID := uuid.New()
+	req := func(method, path string) *http.Request {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("X-Tenant-ID", tenantB.String())
+		r.Header.Set("Authorization", "Bearer "+userID.String()+"|"+tenantA.String()+"|admin")
+		return r
+	}
+
+	strict := customMiddleware.NewTenantResolver(customMiddleware.TenantResolverConfig{RejectConflicts: true}, &fakeTenantLookup{}, roleTokens{})
+	assert.Equal(t, http.StatusBadRequest, serveWithAuth(t, strict, req(http.MethodGet, "/api/v1/me")).Code)
+
+	// Lenient: no conflict; the header wins and the membership check decides
+	// once TenantContext has set the request tenant
+	lenient := customMiddleware.NewTenantResolver(customMiddleware.TenantResolverConfig{}, &fakeTenantLookup{}, roleTokens{})
+	assert.Equal(t, http.StatusOK, serveWithAuth(t, lenient, req(http.MethodGet, "/api/v1/me")).Code)
+}
+
+func TestTenantResolver_StaleCookieOnLogin(t *testing.T) {
+	// A leftover access_token cookie of tenant A must not block signing in to B
+	tr := customMiddleware.NewTenantResolver(customMiddleware.TenantResolverConfig{RejectConflicts: true}, &fakeTenantLookup{}, roleTokens{})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+	req.Header.Set("X-Tenant-ID", tenantB.String())
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: uuid.NewString() + "|" + tenantA.String() + "|admin"})
+
+	assert.Equal(t, http.StatusOK, serveWithAuth(t, tr, req).Code)
+}
+
+func TestTenantResolver_TokenIsNotARoutingConflict(t *testing.T) {
+	// A path tenant that differs from the token tid is not a conflict: the
+	// routed tenant wins and AuthMiddleware authorizes it (membership check).
+	tr, _ := newResolver(true)
+
+	req := httptest.NewRequest(http.MethodGet, "/t/beta/api/v1/ping", nil)
+	req.Header.Set("Authorization", "Bearer tid:"+tenantA.String())
+
+	rec, res, _ := serve(t, tr, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, tenantB, res.TenantID)
+	assert.Equal(t, customMiddleware.SourcePath, res.Source)
+}
+
+func TestTenantResolver_Conflicts(t *testing.T) {
+	req := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/t/beta/api/v1/ping", nil)
+		r.Host = "login.acme.nl"
+		return r
+	}
+
+	strict, _ := newResolver(true)
+	rec, _, _ := serve(t, strict, req())
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Precedence: path is listed before domain by default.
+	lenient, _ := newResolver(false)
+	rec, res, _ := serve(t, lenient, req())
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, tenantB, res.TenantID)
+	assert.Equal(t, customMiddleware.SourcePath, res.Source)
+}
+
+func TestTenantResolver_UnknownAndCached(t *testing.T) {
+	tr, lookup := newResolver(true)
+
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/t/ghost/api/v1/ping", nil)
+		rec, _, _ := serve(t, tr, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+	assert.Equal(t, 1, lookup.calls, "negative lookups are cached")
+
+	// Hosts without a custom domain are not an error.
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	req.Host = "api.example.com"
+	rec, res, _ := serve(t, tr, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, uuid.Nil, res.TenantID)
+}
+
+func TestParseTenantSources(t *testing.T) {
+	sources, err := customMiddleware.ParseTenantSources("token, header")
+	require.NoError(t, err)
+	assert.Equal(t, []customMiddleware.TenantSource{customMiddleware.SourceToken, customMiddleware.SourceHeader}, sources)
+
+	_, err = customMiddleware.ParseTenantSources("header,cookie")
+	assert.Error(t, err)
+}
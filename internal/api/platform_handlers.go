@@ -0,0 +1,393 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/platform"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// PlatformHandler serves the platform-operator plane (/platform/v1).
+// Tenant admins have no access here: see middleware.PlatformAuth.
+type PlatformHandler struct {
+	service *platform.Service
+}
+
+func NewPlatformHandler(service *platform.Service) *PlatformHandler {
+	return &PlatformHandler{service: service}
+}
+
+// PlatformLoginRequest requires the TOTP code up front: there is no MFA-optional path.
+type PlatformLoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	TOTPCode string `json:"totp_code"`
+}
+
+// Login handles POST /platform/v1/auth/login
+func (h *PlatformHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req PlatformLoginRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Email == "" || req.Password == "" || req.TOTPCode == "" {
+		http.Error(w, "email, password and totp_code are required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.service.Login(r.Context(), platform.LoginInput{
+		Email:    req.Email,
+		Password: req.Password,
+		TOTPCode: req.TOTPCode,
+		IP:       helpers.GetRealIP(r),
+	})
+	if err != nil {
+		slog.Warn("PlatformLogin: Failed Attempt", "email", req.Email, "ip", helpers.GetRealIP(r), "error", err)
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	// Bearer token in the body only: platform sessions never use cookies
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(auth.PlatformTokenDuration.Seconds()),
+	})
+}
+
+// ListTenants handles GET /platform/v1/tenants?page=&limit=
+func (h *PlatformHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	tenants, err := h.service.ListTenants(r.Context(), int32(limit), int32((page-1)*limit))
+	if err != nil {
+		slog.Error("PlatformListTenants: Failed", "error", err)
+		http.Error(w, "Failed to list tenants", http.StatusInternalServerError)
+		return
+	}
+
+	type tenantSummary struct {
+		ID             uuid.UUID `json:"id"`
+		Name           string    `json:"name"`
+		Slug           string    `json:"slug"`
+		AppURL         string    `json:"app_url"`
+		IsActive       bool      `json:"is_active"`
+		CreatedAt      time.Time `json:"created_at"`
+		MemberCount    int64     `json:"member_count"`
+		ActiveSessions int64     `json:"active_sessions"`
+	}
+	out := make([]tenantSummary, len(tenants))
+	for i, t := range tenants {
+		out[i] = tenantSummary{
+			ID:             t.ID.Bytes,
+			Name:           t.Name,
+			Slug:           t.Slug,
+			AppURL:         t.AppUrl,
+			IsActive:       t.IsActive,
+			CreatedAt:      t.CreatedAt.Time,
+			MemberCount:    t.MemberCount,
+			ActiveSessions: t.ActiveSessions,
+		}
+	}
+
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"tenants": out,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// PlatformCreateTenantRequest defines the payload for creating a new tenant.
+type PlatformCreateTenantRequest struct {
+	Name   string `json:"name"`
+	Slug   string `json:"slug"`
+	AppURL string `json:"app_url"`
+}
+
+// CreateTenant handles POST /platform/v1/tenants
+// The tenant secret key is returned ONCE in this response.
+func (h *PlatformHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	adminID := customMiddleware.MustGetPlatformAdminID(r.Context())
+
+	var req PlatformCreateTenantRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" || req.Slug == "" {
+		http.Error(w, "Name and Slug are required", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.service.CreateTenant(r.Context(), adminID, platform.CreateTenantInput{
+		Name:   req.Name,
+		Slug:   req.Slug,
+		AppURL: req.AppURL,
+	})
+	if err != nil {
+		writePlatformError(w, "PlatformCreateTenant", err)
+		return
+	}
+
+	helpers.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":         uuid.UUID(created.Tenant.ID.Bytes),
+		"name":       created.Tenant.Name,
+		"slug":       created.Tenant.Slug,
+		"public_key": uuid.UUID(created.Tenant.PublicKey.Bytes),
+		"secret_key": created.SecretKey,
+	})
+}
+
+// TenantActionRequest carries the reason (suspend, delete) and the confirmation slug (delete).
+type TenantActionRequest struct {
+	Reason      string `json:"reason"`
+	ConfirmSlug string `json:"confirm_slug"`
+}
+
+// SuspendTenant handles POST /platform/v1/tenants/{tenantID}/suspend
+func (h *PlatformHandler) SuspendTenant(w http.ResponseWriter, r *http.Request) {
+	adminID, tenantID, req, ok := platformTenantAction(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.SuspendTenant(r.Context(), adminID, tenantID, req.Reason); err != nil {
+		writePlatformError(w, "PlatformSuspendTenant", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"status": "suspended"})
+}
+
+// ResumeTenant handles POST /platform/v1/tenants/{tenantID}/resume
+func (h *PlatformHandler) ResumeTenant(w http.ResponseWriter, r *http.Request) {
+	adminID := customMiddleware.MustGetPlatformAdminID(r.Context())
+	tenantID, err := uuid.Parse(chi.URLParam(r, "tenantID"))
+	if err != nil {
+		http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.ResumeTenant(r.Context(), adminID, tenantID); err != nil {
+		writePlatformError(w, "PlatformResumeTenant", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"status": "active"})
+}
+
+// DeleteTenant handles DELETE /platform/v1/tenants/{tenantID}
+// Schedules the deletion: the tenant is suspended now and purged by the worker
+// after the grace period. The body must repeat the slug and give a reason.
+func (h *PlatformHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	adminID, tenantID, req, ok := platformTenantAction(w, r)
+	if !ok {
+		return
+	}
+	job, err := h.service.ScheduleTenantDeletion(r.Context(), adminID, tenantID, req.ConfirmSlug, req.Reason)
+	if err != nil {
+		writePlatformError(w, "PlatformDeleteTenant", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusAccepted, newTenantDeletionResponse(job))
+}
+
+// CancelTenantDeletion handles POST /platform/v1/tenants/{tenantID}/cancel-deletion
+// Only possible during the grace period. The tenant stays suspended.
+func (h *PlatformHandler) CancelTenantDeletion(w http.ResponseWriter, r *http.Request) {
+	adminID := customMiddleware.MustGetPlatformAdminID(r.Context())
+	tenantID, err := uuid.Parse(chi.URLParam(r, "tenantID"))
+	if err != nil {
+		http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.CancelTenantDeletion(r.Context(), adminID, tenantID); err != nil {
+		writePlatformError(w, "PlatformCancelTenantDeletion", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"status": "suspended"})
+}
+
+// GetTenantDeletion handles GET /platform/v1/tenants/{tenantID}/deletion
+func (h *PlatformHandler) GetTenantDeletion(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "tenantID"))
+	if err != nil {
+		http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
+		return
+	}
+	job, err := h.service.GetTenantDeletion(r.Context(), tenantID)
+	if err != nil {
+		writePlatformError(w, "PlatformGetTenantDeletion", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newTenantDeletionResponse(job))
+}
+
+// ListTenantDeletions handles GET /platform/v1/deletions
+func (h *PlatformHandler) ListTenantDeletions(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.service.ListTenantDeletions(r.Context(), 100)
+	if err != nil {
+		slog.Error("PlatformListTenantDeletions: Failed", "error", err)
+		http.Error(w, "Failed to list deletions", http.StatusInternalServerError)
+		return
+	}
+	out := make([]tenantDeletionResponse, len(jobs))
+	for i, job := range jobs {
+		out[i] = newTenantDeletionResponse(job)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{"deletions": out})
+}
+
+// tenantDeletionResponse exposes an offboarding job. The export path stays
+// server-side; operators get the checksum to match the archived file.
+type tenantDeletionResponse struct {
+	TenantID     uuid.UUID        `json:"tenant_id"`
+	Slug         string           `json:"slug"`
+	Name         string           `json:"name"`
+	Reason       string           `json:"reason"`
+	Status       string           `json:"status"`
+	Step         string           `json:"step,omitempty"`
+	Progress     map[string]int64 `json:"progress"`
+	ExportSHA256 string           `json:"export_sha256,omitempty"`
+	Attempts     int32            `json:"attempts"`
+	LastError    string           `json:"last_error,omitempty"`
+	RequestedAt  time.Time        `json:"requested_at"`
+	PurgeAfter   time.Time        `json:"purge_after"`
+	CompletedAt  *time.Time       `json:"completed_at,omitempty"`
+}
+
+func newTenantDeletionResponse(job db.TenantOffboarding) tenantDeletionResponse {
+	progress := map[string]int64{}
+	_ = json.Unmarshal(job.Progress, &progress)
+
+	resp := tenantDeletionResponse{
+		TenantID:     job.TenantID.Bytes,
+		Slug:         job.TenantSlug,
+		Name:         job.TenantName,
+		Reason:       job.Reason,
+		Status:       job.Status,
+		Step:         job.Step,
+		Progress:     progress,
+		ExportSHA256: job.ExportSha256.String,
+		Attempts:     job.Attempts,
+		LastError:    job.LastError.String,
+		RequestedAt:  job.RequestedAt.Time,
+		PurgeAfter:   job.PurgeAfter.Time,
+	}
+	if job.CompletedAt.Valid {
+		resp.CompletedAt = &job.CompletedAt.Time
+	}
+	return resp
+}
+
+// ImpersonateRequest names the member to act as and why (stored in the audit log).
+type ImpersonateRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	Reason string    `json:"reason"`
+}
+
+// Impersonate handles POST /platform/v1/tenants/{tenantID}/impersonate
+// Returns a 15 minute access token without refresh token.
+func (h *PlatformHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	adminID := customMiddleware.MustGetPlatformAdminID(r.Context())
+	tenantID, err := uuid.Parse(chi.URLParam(r, "tenantID"))
+	if err != nil {
+		http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil || req.UserID == uuid.Nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	imp, err := h.service.Impersonate(r.Context(), adminID, platform.ImpersonationInput{
+		TenantID: tenantID,
+		UserID:   req.UserID,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		writePlatformError(w, "PlatformImpersonate", err)
+		return
+	}
+
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": imp.AccessToken,
+		"token_type":   "Bearer",
+		"expires_at":   imp.ExpiresAt.UTC(),
+		"tenant_id":    tenantID,
+		"user_id":      req.UserID,
+		"role":         imp.Role,
+	})
+}
+
+// Stats handles GET /platform/v1/stats
+func (h *PlatformHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.Stats(r.Context())
+	if err != nil {
+		slog.Error("PlatformStats: Failed", "error", err)
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]int64{
+		"tenants_total":     stats.TenantsTotal,
+		"tenants_active":    stats.TenantsActive,
+		"users_total":       stats.UsersTotal,
+		"memberships_total": stats.MembershipsTotal,
+		"active_sessions":   stats.ActiveSessions,
+		"logins_24h":        stats.Logins24h,
+	})
+}
+
+// platformTenantAction extracts the operator, the {tenantID} param and an optional body.
+func platformTenantAction(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, TenantActionRequest, bool) {
+	var req TenantActionRequest
+	adminID := customMiddleware.MustGetPlatformAdminID(r.Context())
+	tenantID, err := uuid.Parse(chi.URLParam(r, "tenantID"))
+	if err != nil {
+		http.Error(w, "Invalid Tenant ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, req, false
+	}
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, req, false
+	}
+	return adminID, tenantID, req, true
+}
+
+func writePlatformError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, platform.ErrTenantNotFound):
+		http.Error(w, "Tenant not found", http.StatusNotFound)
+	case errors.Is(err, platform.ErrNotAMember):
+		http.Error(w, "User is not a member of this tenant", http.StatusNotFound)
+	case errors.Is(err, platform.ErrTenantExists):
+		http.Error(w, "Slug already taken", http.StatusConflict)
+	case errors.Is(err, platform.ErrNoDeletion):
+		http.Error(w, "Tenant has no scheduled deletion", http.StatusNotFound)
+	case errors.Is(err, platform.ErrDeletionScheduled), errors.Is(err, platform.ErrDeletionStarted):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, auth.ErrTenantSuspended):
+		http.Error(w, "Tenant is suspended", http.StatusConflict)
+	case errors.Is(err, platform.ErrSlugMismatch), errors.Is(err, platform.ErrReasonRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error(op+": Failed", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -9,13 +9,15 @@ import (
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/emailtemplate"
 )
 
 type UpdateProfileRequest struct {
-	FullName string `json:"full_name"`
+	FullName string  `json:"full_name"`
+	Locale   *string `json:"locale"` // Language of emails: nl, en, or "" for the tenant default
 }
 
-// UpdateProfile allows a user to change their display name.
+// UpdateProfile allows a user to change their display name and email language.
 func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	// 1. Context
 	userID, err := customMiddleware.GetUserID(r.Context())
@@ -35,6 +37,10 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Name too long", http.StatusBadRequest)
 		return
 	}
+	if req.Locale != nil && *req.Locale != "" && !emailtemplate.ValidLocale(*req.Locale) {
+		http.Error(w, "Invalid locale: must be nl or en", http.StatusBadRequest)
+		return
+	}
 
 	// 3. Action
 	if err := h.service.UpdateProfile(r.Context(), userID, req.FullName); err != nil {
@@ -42,6 +48,13 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 		return
 	}
+	if req.Locale != nil {
+		if err := h.service.UpdateLocale(r.Context(), userID, *req.Locale); err != nil {
+			slog.Error("UpdateProfile: Failed to update locale", "user", userID, "error", err)
+			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
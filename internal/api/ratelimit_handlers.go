@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListRateLimitHits handles GET /admin/rate-limits
+// Returns per-policy hit counts (24h) and the most recent limit hits for the tenant.
+//
+// ✅ ADMIN ONLY: Protected by requireRBAC("admin") middleware
+// ✅ PRIVACY: Subjects are SHA256 hashes, never raw emails
+func (h *AuthHandler) ListRateLimitHits(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+	queries := db.New(h.Pool)
+
+	stats, err := queries.GetRateLimitStats(r.Context(), tid)
+	if err != nil {
+		slog.Error("ListRateLimitHits: Stats query failed", "error", err)
+		http.Error(w, "Failed to fetch rate limit stats", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := queries.ListRateLimitEvents(r.Context(), db.ListRateLimitEventsParams{
+		TenantID: tid,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		slog.Error("ListRateLimitHits: Events query failed", "error", err)
+		http.Error(w, "Failed to fetch rate limit hits", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stats":  stats,
+		"events": events,
+	})
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// RoleRequest defines the request body for creating or updating a custom role
+type RoleRequest struct {
+	Name        string   `json:"name"` // Ignored on update (names are immutable)
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListPermissions handles GET /admin/permissions
+// Returns the permission catalogue for building role editors.
+func (h *AuthHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	helpers.RespondJSON(w, http.StatusOK, permissions.Catalogue)
+}
+
+// ListRoles handles GET /admin/roles
+func (h *AuthHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	roles, err := h.service.ListRoles(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("ListRoles: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve roles", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, roles)
+}
+
+// CreateRole handles POST /admin/roles
+func (h *AuthHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, actorPerms, ok := roleActor(w, r)
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.service.CreateRole(r.Context(), actorID, tenantID, actorPerms, auth.RoleInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		writeRoleError(w, "CreateRole", tenantID, err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusCreated, role)
+}
+
+// UpdateRoleDefinition handles PUT /admin/roles/{roleID}
+func (h *AuthHandler) UpdateRoleDefinition(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, actorPerms, ok := roleActor(w, r)
+	if !ok {
+		return
+	}
+
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleID"))
+	if err != nil {
+		http.Error(w, "Invalid Role ID", http.StatusBadRequest)
+		return
+	}
+
+	var req RoleRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.service.UpdateRoleDefinition(r.Context(), actorID, tenantID, roleID, actorPerms, auth.RoleInput{
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		writeRoleError(w, "UpdateRoleDefinition", tenantID, err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, role)
+}
+
+// DeleteRole handles DELETE /admin/roles/{roleID}
+func (h *AuthHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	tenantID, actorID, _, ok := roleActor(w, r)
+	if !ok {
+		return
+	}
+
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleID"))
+	if err != nil {
+		http.Error(w, "Invalid Role ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteRole(r.Context(), actorID, tenantID, roleID); err != nil {
+		writeRoleError(w, "DeleteRole", tenantID, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// roleActor extracts the tenant, caller and caller permissions (for escalation checks).
+func roleActor(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, []string, bool) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, nil, false
+	}
+	actorID, err := customMiddleware.GetUserID(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, nil, false
+	}
+	perms, _ := customMiddleware.GetPermissions(r.Context())
+	return tenantID, actorID, perms, true
+}
+
+func writeRoleError(w http.ResponseWriter, op string, tenantID uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, auth.ErrRoleNotFound):
+		http.Error(w, "Role not found", http.StatusNotFound)
+	case errors.Is(err, auth.ErrRoleExists):
+		http.Error(w, "Role already exists", http.StatusConflict)
+	case errors.Is(err, auth.ErrRoleInUse):
+		http.Error(w, "Role is still assigned to members", http.StatusConflict)
+	case errors.Is(err, auth.ErrPermissionEscalation):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, auth.ErrInvalidRoleName), errors.Is(err, permissions.ErrUnknownPermission):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error(op+": Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -1,12 +1,23 @@
 package api
 
 import (
+	"expvar"
 	"log/slog"
-	"net/http"
+	"os"
+	"time"
 
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditarchive"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditsink"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/challenge"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/dataexport"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/emailtemplate"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/platform"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/webhook"
 	sentryhttp "github.com/getsentry/sentry-go/http"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -22,12 +33,13 @@ type Server struct {
 	Logger *slog.Logger
 }
 
-func NewServer(pool *pgxpool.Pool, queries *db.Queries, authService *auth.AuthService, tokenProvider auth.TokenProvider, iotService *auth.IoTService) *Server {
+func NewServer(pool *pgxpool.Pool, queries *db.Queries, authService *auth.AuthService, tokenProvider auth.TokenProvider, iotService *auth.IoTService, rateLimitStore ratelimit.Store, signInNotices *customMiddleware.SignInNotices, challenges *customMiddleware.ChallengeGate, tenantResolver *customMiddleware.TenantResolver, platformService *platform.Service, dataExports *dataexport.Service, auditSinks *auditsink.Service, auditRetention *auditarchive.Service, webhooks *webhook.Service, emailTemplates *emailtemplate.Service) *Server {
 	r := chi.NewRouter()
 
 	// 1. Core Middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(customMiddleware.RequestMetadata) // Request ID, IP and user agent for audit and mail logs
 
 	// 2. Sentry Middleware (Must be before Panic Recovery to capture panics)
 	sentryHandler := sentryhttp.New(sentryhttp.Options{
@@ -39,13 +51,25 @@ func NewServer(pool *pgxpool.Pool, queries *db.Queries, authService *auth.AuthSe
 	r.Use(customMiddleware.RequestLogger) // Our custom slog logger
 	r.Use(customMiddleware.PanicRecovery) // Custom recovery with Sentry support
 
+	// Tenant Resolver: header, /t/{slug} path, custom domain, subdomain and token tid.
+	// Runs before CORS so preflights on tenant hosts are narrowed to that tenant,
+	// and strips the /t/{slug} prefix before routing.
+	r.Use(tenantResolver.Middleware)
+
 	// PHASE 99: CORS - Applied globally and EARLY to handle Preflight (OPTIONS)
 	// MUST be before Rate Limiter so 429 responses get CORS headers
-	r.Use(customMiddleware.DynamicCorsMiddleware(queries))
+	// Policies are cached in memory (preflights carry no tenant header); localhost
+	// origin patterns only match outside production.
+	env := os.Getenv("APP_ENV")
+	allowLocalhostOrigins := env == "" || env == "development"
+	corsPolicies := customMiddleware.NewCORSPolicyCache(queries, 5*time.Minute)
+	r.Use(customMiddleware.DynamicCorsMiddleware(corsPolicies, allowLocalhostOrigins))
 
 	// 4. Active Defense Middlewares
-	limiter := customMiddleware.NewIPRateLimiter(25, 50) // 25 RPS, Burst 50 (Increased for Dashboard/Convex)
-	r.Use(limiter.Middleware)
+	// Declarative per-group policies (see ratelimit.DefaultPolicies); counters are shared
+	// across replicas when the Postgres store is configured.
+	limits := customMiddleware.NewRateLimiter(rateLimitStore, ratelimit.NewDBRecorder(queries), signInNotices, ratelimit.DefaultPolicies)
+	r.Use(limits.Group(ratelimit.GroupGlobal))
 
 	// PHASE 50 RLS: TenantContext now requires pool for SET LOCAL transaction wrapping
 	r.Use(customMiddleware.TenantContext(pool))
@@ -53,11 +77,18 @@ func NewServer(pool *pgxpool.Pool, queries *db.Queries, authService *auth.AuthSe
 
 	// 5. Auth & RBAC Factories
 	// We create factories for use in specific routes
-	requireAuth := customMiddleware.AuthMiddleware(tokenProvider)
-	requireRBAC := customMiddleware.RBACMiddleware()
+	// Cross-tenant requests are authorized against memberships; RLS binds to the verified tenant.
+	requireAuth := customMiddleware.AuthMiddleware(tokenProvider, queries, queries, queries, permissions.NewResolver(queries), pool)
 
 	// Handlers
 	authHandler := NewAuthHandler(authService, pool, slog.Default())
+	authHandler.CORSCache = corsPolicies        // Invalidated by UpdateCORSOrigins
+	authHandler.TenantResolver = tenantResolver // Invalidated by custom domain writes
+	authHandler.DataExports = dataExports
+	authHandler.AuditSinks = auditSinks
+	authHandler.AuditRetention = auditRetention
+	authHandler.Webhooks = webhooks
+	authHandler.EmailTemplates = emailTemplates
 	iotHandler := NewIoTHandler(iotService)
 
 	// Initialize server early to use its methods
@@ -81,35 +112,47 @@ func NewServer(pool *pgxpool.Pool, queries *db.Queries, authService *auth.AuthSe
 	r.Route("/api/v1", func(r chi.Router) {
 
 		// Public Routes
-		r.Post("/auth/register", authHandler.Register)
-		r.Post("/auth/login", authHandler.Login)
+		// Adaptive challenge (PoW/CAPTCHA) runs after the rate limiter: tenants opt in,
+		// and it only kicks in once failures for the IP or email cross the threshold.
+		r.With(limits.Group(ratelimit.GroupRegister), challenges.Protect(challenge.ScopeRegister)).Post("/auth/register", authHandler.Register)
+		r.With(limits.Group(ratelimit.GroupLogin), challenges.Protect(challenge.ScopeLogin)).Post("/auth/login", authHandler.Login)
 		r.Post("/auth/logout", authHandler.Logout)
 		r.Post("/auth/refresh", authHandler.Refresh) // ✅ Token refresh endpoint
 
 		// Password Recovery (Public)
-		r.Post("/auth/password/forgot", authHandler.RequestPasswordReset)
-		r.Post("/auth/password/reset", authHandler.ResetPassword)
+		recoveryLimit := limits.Group(ratelimit.GroupRecovery)
+		r.With(recoveryLimit, challenges.Protect(challenge.ScopePasswordForgot)).Post("/auth/password/forgot", authHandler.RequestPasswordReset)
+		r.With(recoveryLimit).Post("/auth/password/reset", authHandler.ResetPassword)
 
 		// Email Verification (Public)
-		r.Post("/auth/email/resend", authHandler.ResendVerification)
-		r.Post("/auth/email/verify", authHandler.VerifyEmail)
+		r.With(recoveryLimit).Post("/auth/email/resend", authHandler.ResendVerification)
+		r.With(recoveryLimit).Post("/auth/email/verify", authHandler.VerifyEmail)
+		r.With(recoveryLimit).Post("/auth/account/cancel-deletion", authHandler.CancelAccountDeletion)
+		r.With(recoveryLimit).Post("/auth/account/email/confirm", authHandler.ConfirmEmailChange)
+		r.With(recoveryLimit).Post("/auth/account/email/revert", authHandler.RevertEmailChange)
+		r.With(recoveryLimit).Post("/auth/security/lock", authHandler.LockAccount) // "This wasn't me" link in security emails
 
 		// IoT Telemetry (Gatekeeper)
-		r.Post("/iot/telemetry", iotHandler.HandleTelemetry)
+		r.With(limits.Group(ratelimit.GroupIoT)).Post("/iot/telemetry", iotHandler.HandleTelemetry)
 
 		// MFA Verification (Public/Semi-Public)
-		r.Post("/auth/mfa/verify", authHandler.VerifyMFA)
-		r.Post("/auth/mfa/backup", authHandler.VerifyBackupCode)
+		mfaLimit := limits.Group(ratelimit.GroupMFA)
+		r.With(mfaLimit).Post("/auth/mfa/verify", authHandler.VerifyMFA)
+		r.With(mfaLimit).Post("/auth/mfa/backup", authHandler.VerifyBackupCode)
 
 		// Public Tenant Lookup (Phase 27)
 		publicHandler := NewPublicHandler(queries)
 		r.Get("/tenants/{slug}", publicHandler.GetTenantInfo)
 		r.Get("/showcase", publicHandler.GetShowcase)
 
+		// Personal Data Export download: the signed, short-lived link is the credential
+		r.With(limits.Group(ratelimit.GroupDataExport)).Get("/exports/{exportID}/download", authHandler.DownloadDataExport)
+
 		// Protected Routes
 		r.Group(func(r chi.Router) {
 			r.Use(requireAuth)
-			r.Use(customMiddleware.CSRFMiddleware) // Apply CSRF to authenticated routes only
+			r.Use(limits.Group(ratelimit.GroupSession)) // Per-user budget (needs UserID from requireAuth)
+			r.Use(customMiddleware.CSRFMiddleware)      // Apply CSRF to authenticated routes only
 
 			// Example: User Profile (Self)
 			r.Get("/auth/me", authHandler.Me)          // Updated to /auth/me for consistency with frontend
@@ -117,53 +160,165 @@ func NewServer(pool *pgxpool.Pool, queries *db.Queries, authService *auth.AuthSe
 
 			// Session Management (Phase 17)
 			r.Get("/auth/sessions", authHandler.GetSessions)
+			r.Get("/auth/activity", authHandler.ListMyActivity) // Own security events (audit log)
 			r.Delete("/auth/sessions/{id}", authHandler.RevokeSession)
 
+			// Tenant Memberships (accept invitations with an existing account, switch tenant)
+			r.Post("/auth/invitations/accept", authHandler.AcceptInvitation)
+			r.Get("/auth/tenants", authHandler.ListMyTenants)
+			r.Post("/auth/tenants/switch", authHandler.SwitchTenant)
+
 			// MFA Management (Phase 10 & 14)
 			r.Post("/auth/mfa/setup", authHandler.SetupMFA)
 			r.Post("/auth/mfa/activate", authHandler.ActivateMFA)
 
 			// Email Change (Phase 19)
 			r.Post("/auth/account/email/change", authHandler.RequestEmailChange)
-			r.Post("/auth/account/email/confirm", authHandler.ConfirmEmailChange)
+
+			// Personal Data Export (GDPR Art. 15/20), built by the worker
+			r.Post("/auth/account/export", authHandler.RequestDataExport)
+			r.Get("/auth/account/exports", authHandler.ListDataExports)
+
+			// Account Deletion (re-authenticated, purged after the cooling-off period)
+			r.Delete("/auth/account", authHandler.DeleteAccount)
 
 			// User Self-Service (Phase 26)
 			r.Patch("/auth/profile", authHandler.UpdateProfile)
 			r.Put("/auth/security/password", authHandler.ChangePassword)
 
-			// Example: Admin Only Action
+			// Tenant Administration: every route requires its own permission (see adminRoutes)
 			r.Route("/admin", func(r chi.Router) {
-				r.Use(requireRBAC("admin"))
-
-				r.Delete("/tenants", func(w http.ResponseWriter, r *http.Request) {
-					// This logic would delete the tenant in the current context
-					w.Write([]byte("Tenant Deleted"))
-				})
-				r.Post("/tenants", authHandler.CreateTenant) // ✅ Tenant Creation (Audit Form)
-
-				// User Management (Phase 25)
-				r.Get("/users", authHandler.ListUsers)
-				r.Patch("/users/{userID}", authHandler.UpdateRole)
-				r.Delete("/users/{userID}", authHandler.RemoveUser)
-
-				// Invite User (Phase 16)
-				r.Post("/users/invite", authHandler.InviteUser)
-
-				// Mail Configuration Management (Email Gateway)
-				r.Get("/mail-config", authHandler.GetMailConfig)
-				r.Post("/mail-config", authHandler.UpdateMailConfig)
-				r.Delete("/mail-config", authHandler.DeleteMailConfig)
-				r.Get("/email-stats", authHandler.GetEmailStats)
-
-				// CORS Management (Security)
-				r.Get("/cors-origins", authHandler.GetTenantConfig)
-				r.Put("/cors-origins", authHandler.UpdateCORSOrigins)
-
-				// Audit Logs (Compliance)
-				r.Get("/audit-logs", authHandler.ListAuditLogs)
+				r.Use(limits.Group(ratelimit.GroupAdmin))
+
+				authHandler.adminRoutes(r)
 			})
 		})
 	})
 
+	// Platform Plane: operators only (separate credentials + mandatory TOTP).
+	// Tenant access tokens, including impersonation tokens, are rejected here.
+	platformHandler := NewPlatformHandler(platformService)
+	r.Route("/platform/v1", func(r chi.Router) {
+		r.With(limits.Group(ratelimit.GroupPlatformLogin)).Post("/auth/login", platformHandler.Login)
+
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.PlatformAuth(tokenProvider, platformService))
+
+			r.Get("/stats", platformHandler.Stats)
+			// Process metrics of the replica serving the request (expvar, incl. audit_writer)
+			r.Get("/metrics", expvar.Handler().ServeHTTP)
+			r.Get("/tenants", platformHandler.ListTenants)
+			r.Post("/tenants", platformHandler.CreateTenant)
+			r.Delete("/tenants/{tenantID}", platformHandler.DeleteTenant)
+			r.Get("/tenants/{tenantID}/deletion", platformHandler.GetTenantDeletion)
+			r.Post("/tenants/{tenantID}/cancel-deletion", platformHandler.CancelTenantDeletion)
+			r.Get("/deletions", platformHandler.ListTenantDeletions)
+			r.Post("/tenants/{tenantID}/suspend", platformHandler.SuspendTenant)
+			r.Post("/tenants/{tenantID}/resume", platformHandler.ResumeTenant)
+			r.Post("/tenants/{tenantID}/impersonate", platformHandler.Impersonate)
+		})
+	})
+
 	return server
 }
+
+// adminRoutes registers the tenant admin endpoints, each guarded by a permission.
+// Kept separate so the cross-tenant escalation tests exercise the exact route set.
+func (h *AuthHandler) adminRoutes(r chi.Router) {
+	can := customMiddleware.RequirePermission
+
+	// Tenant creation and deletion live on the platform plane (/platform/v1), not here.
+
+	// User Management (Phase 25)
+	r.With(can(permissions.UsersRead)).Get("/users", h.ListUsers)
+	r.With(can(permissions.UsersManage)).Patch("/users/{userID}", h.UpdateRole)
+	r.With(can(permissions.UsersManage)).Delete("/users/{userID}", h.RemoveUser)
+	r.With(can(permissions.UsersExport)).Post("/users/{userID}/export", h.AdminRequestDataExport)
+	r.With(can(permissions.UsersExport)).Get("/users/{userID}/exports", h.AdminListDataExports)
+
+	// Invite User (Phase 16)
+	r.With(can(permissions.UsersInvite)).Post("/users/invite", h.InviteUser)
+	r.With(can(permissions.UsersInvite)).Get("/invitations", h.ListInvitations)
+	r.With(can(permissions.UsersInvite)).Post("/invitations/bulk", h.BulkInviteUsers)
+	r.With(can(permissions.UsersInvite)).Post("/invitations/{invitationID}/resend", h.ResendInvitation)
+	r.With(can(permissions.UsersInvite)).Delete("/invitations/{invitationID}", h.RevokeInvitation)
+	r.With(can(permissions.TenantsConfigure)).Get("/invitations/settings", h.GetInvitationSettings)
+	r.With(can(permissions.TenantsConfigure)).Put("/invitations/settings", h.UpdateInvitationSettings)
+
+	// Signup Rules (self-service signup, verified email domains, approval queue)
+	r.With(can(permissions.TenantsConfigure)).Get("/signup/settings", h.GetSignupSettings)
+	r.With(can(permissions.TenantsConfigure)).Put("/signup/settings", h.UpdateSignupSettings)
+	r.With(can(permissions.TenantsConfigure)).Get("/signup/domains", h.ListEmailDomains)
+	r.With(can(permissions.TenantsConfigure)).Post("/signup/domains", h.AddEmailDomain)
+	r.With(can(permissions.TenantsConfigure)).Post("/signup/domains/{domainID}/verify", h.VerifyEmailDomain)
+	r.With(can(permissions.TenantsConfigure)).Delete("/signup/domains/{domainID}", h.DeleteEmailDomain)
+	r.With(can(permissions.UsersInvite)).Get("/signup/requests", h.ListSignupRequests)
+	r.With(can(permissions.UsersInvite)).Post("/signup/requests/{requestID}/approve", h.ApproveSignupRequest)
+	r.With(can(permissions.UsersInvite)).Post("/signup/requests/{requestID}/reject", h.RejectSignupRequest)
+
+	// Roles & Permissions (Phase 31)
+	r.With(can(permissions.RolesManage)).Get("/permissions", h.ListPermissions)
+	r.With(can(permissions.RolesManage)).Get("/roles", h.ListRoles)
+	r.With(can(permissions.RolesManage)).Post("/roles", h.CreateRole)
+	r.With(can(permissions.RolesManage)).Put("/roles/{roleID}", h.UpdateRoleDefinition)
+	r.With(can(permissions.RolesManage)).Delete("/roles/{roleID}", h.DeleteRole)
+
+	// Mail Configuration Management (Email Gateway)
+	r.With(can(permissions.MailConfigure)).Get("/mail-config", h.GetMailConfig)
+	r.With(can(permissions.MailConfigure)).Post("/mail-config", h.UpdateMailConfig)
+	r.With(can(permissions.MailConfigure)).Delete("/mail-config", h.DeleteMailConfig)
+	r.With(can(permissions.MailStats)).Get("/email-stats", h.GetEmailStats)
+
+	// Email Templates (branded, per locale)
+	r.With(can(permissions.MailConfigure)).Get("/email-templates", h.ListEmailTemplates)
+	r.With(can(permissions.MailConfigure)).Get("/email-templates/{template}", h.GetEmailTemplate)
+	r.With(can(permissions.MailConfigure)).Post("/email-templates/{template}/preview", h.PreviewEmailTemplate)
+	r.With(can(permissions.MailConfigure)).Put("/email-templates/{template}/{locale}", h.SaveEmailTemplate)
+	r.With(can(permissions.MailConfigure)).Delete("/email-templates/{template}/{locale}", h.DeleteEmailTemplate)
+	r.With(can(permissions.MailConfigure)).Get("/email-locale", h.GetEmailLocale)
+	r.With(can(permissions.MailConfigure)).Put("/email-locale", h.UpdateEmailLocale)
+
+	// CORS Management (Security)
+	r.With(can(permissions.SecurityConfig)).Get("/cors-origins", h.GetTenantConfig)
+	r.With(can(permissions.SecurityConfig)).Put("/cors-origins", h.UpdateCORSOrigins)
+
+	// Audit Logs (Compliance)
+	r.With(can(permissions.AuditRead)).Get("/audit-logs", h.ListAuditLogs)
+	r.With(can(permissions.AuditRead)).Get("/audit-events", h.ListAuditEvents)
+	r.With(can(permissions.AuditExport)).Get("/audit-logs/export", h.ExportAuditLogs)
+	r.With(can(permissions.AuditRead)).Get("/audit-retention", h.GetAuditRetention)
+	r.With(can(permissions.AuditExport)).Put("/audit-retention", h.UpdateAuditRetention)
+
+	// Audit Sinks (SIEM streaming)
+	r.With(can(permissions.AuditExport)).Get("/audit-sinks", h.ListAuditSinks)
+	r.With(can(permissions.AuditExport)).Post("/audit-sinks", h.CreateAuditSink)
+	r.With(can(permissions.AuditExport)).Patch("/audit-sinks/{sinkID}", h.UpdateAuditSink)
+	r.With(can(permissions.AuditExport)).Post("/audit-sinks/{sinkID}/retry", h.RetryAuditSink)
+	r.With(can(permissions.AuditExport)).Delete("/audit-sinks/{sinkID}", h.DeleteAuditSink)
+
+	// Webhooks (security events for tenant applications)
+	r.With(can(permissions.WebhooksManage)).Get("/webhooks", h.ListWebhooks)
+	r.With(can(permissions.WebhooksManage)).Post("/webhooks", h.CreateWebhook)
+	r.With(can(permissions.WebhooksManage)).Patch("/webhooks/{webhookID}", h.UpdateWebhook)
+	r.With(can(permissions.WebhooksManage)).Delete("/webhooks/{webhookID}", h.DeleteWebhook)
+	r.With(can(permissions.WebhooksManage)).Post("/webhooks/{webhookID}/rotate-secret", h.RotateWebhookSecret)
+	r.With(can(permissions.WebhooksManage)).Get("/webhooks/{webhookID}/deliveries", h.ListWebhookDeliveries)
+	r.With(can(permissions.WebhooksManage)).Post("/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", h.RedeliverWebhook)
+
+	// Rate Limit Hits (Active Defense Dashboard)
+	r.With(can(permissions.SecurityRead)).Get("/rate-limits", h.ListRateLimitHits)
+
+	// Custom Domains (Tenant Resolver)
+	r.With(can(permissions.SecurityConfig)).Get("/domains", h.ListTenantDomains)
+	r.With(can(permissions.SecurityConfig)).Post("/domains", h.AddTenantDomain)
+	r.With(can(permissions.SecurityConfig)).Post("/domains/{domainID}/verify", h.VerifyTenantDomain)
+	r.With(can(permissions.SecurityConfig)).Delete("/domains/{domainID}", h.DeleteTenantDomain)
+
+	// Bot Challenge Settings (Active Defense)
+	r.With(can(permissions.SecurityConfig)).Get("/security/challenge", h.GetChallengeSettings)
+	r.With(can(permissions.SecurityConfig)).Put("/security/challenge", h.UpdateChallengeSettings)
+
+	// Email Verification Policy (off / warn / enforce)
+	r.With(can(permissions.SecurityConfig)).Get("/security/email-verification", h.GetEmailVerificationSettings)
+	r.With(can(permissions.SecurityConfig)).Put("/security/email-verification", h.UpdateEmailVerificationSettings)
+}
@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxSignupDomainRules bounds allowed_domains and blocked_domains each.
+const maxSignupDomainRules = 100
+
+// SignupSettings is the request and response body of /admin/signup/settings
+type SignupSettings struct {
+	Mode           string   `json:"mode"` // open, approval or closed
+	AllowedDomains []string `json:"allowed_domains"`
+	BlockedDomains []string `json:"blocked_domains"`
+	DefaultRole    string   `json:"default_role"`
+}
+
+func newSignupSettings(s domain.SignupSettings) SignupSettings {
+	resp := SignupSettings{
+		Mode:           s.EffectiveMode(),
+		AllowedDomains: s.AllowedDomains,
+		BlockedDomains: s.BlockedDomains,
+		DefaultRole:    s.Role(),
+	}
+	if resp.AllowedDomains == nil {
+		resp.AllowedDomains = []string{}
+	}
+	if resp.BlockedDomains == nil {
+		resp.BlockedDomains = []string{}
+	}
+	return resp
+}
+
+// AddEmailDomainRequest is the body of POST /admin/signup/domains
+type AddEmailDomainRequest struct {
+	Domain   string `json:"domain"`
+	AutoJoin *bool  `json:"auto_join"` // Defaults to true
+}
+
+// emailDomainResponse includes the TXT record the tenant must publish.
+type emailDomainResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Domain        string     `json:"domain"`
+	AutoJoin      bool       `json:"auto_join"`
+	Verified      bool       `json:"verified"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	TXTName       string     `json:"txt_name"`
+	TXTValue      string     `json:"txt_value"`
+}
+
+func newEmailDomainResponse(d db.TenantEmailDomain) emailDomainResponse {
+	name, value := auth.DomainVerificationRecord(d.Domain, d.VerificationToken)
+	resp := emailDomainResponse{
+		ID:        d.ID.Bytes,
+		Domain:    d.Domain,
+		AutoJoin:  d.AutoJoin,
+		Verified:  d.VerifiedAt.Valid,
+		CreatedAt: d.CreatedAt.Time,
+		TXTName:   name,
+		TXTValue:  value,
+	}
+	if d.VerifiedAt.Valid {
+		resp.VerifiedAt = &d.VerifiedAt.Time
+	}
+	if d.LastCheckedAt.Valid {
+		resp.LastCheckedAt = &d.LastCheckedAt.Time
+	}
+	return resp
+}
+
+// signupRequestResponse is one entry of the approval queue. The password hash never leaves the server.
+type signupRequestResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Email     string     `json:"email"`
+	FullName  string     `json:"full_name,omitempty"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	DecidedBy *uuid.UUID `json:"decided_by,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+}
+
+func newSignupRequestResponse(r db.SignupRequest) signupRequestResponse {
+	resp := signupRequestResponse{
+		ID:        r.ID.Bytes,
+		Email:     r.Email,
+		FullName:  r.FullName.String,
+		Status:    r.Status,
+		CreatedAt: r.CreatedAt.Time,
+	}
+	if r.DecidedAt.Valid {
+		resp.DecidedAt = &r.DecidedAt.Time
+	}
+	if r.DecidedBy.Valid {
+		id := uuid.UUID(r.DecidedBy.Bytes)
+		resp.DecidedBy = &id
+	}
+	if r.UserID.Valid {
+		id := uuid.UUID(r.UserID.Bytes)
+		resp.UserID = &id
+	}
+	return resp
+}
+
+// GetSignupSettings handles GET /admin/signup/settings
+func (h *AuthHandler) GetSignupSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	tenant, err := db.New(h.Pool).GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("GetSignupSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve signup settings", http.StatusInternalServerError)
+		return
+	}
+
+	helpers.RespondJSON(w, http.StatusOK, newSignupSettings(tenant.Settings.SignupRules()))
+}
+
+// UpdateSignupSettings handles PUT /admin/signup/settings
+// allow_registration is kept in step: false only when signup is closed.
+func (h *AuthHandler) UpdateSignupSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	var req SignupSettings
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Law 1: Input is Toxic
+	switch req.Mode {
+	case domain.SignupModeOpen, domain.SignupModeApproval, domain.SignupModeClosed:
+	default:
+		http.Error(w, "Invalid mode: must be open, approval or closed", http.StatusBadRequest)
+		return
+	}
+	allowed, ok := normalizeDomainRules(w, "allowed_domains", req.AllowedDomains)
+	if !ok {
+		return
+	}
+	blocked, ok := normalizeDomainRules(w, "blocked_domains", req.BlockedDomains)
+	if !ok {
+		return
+	}
+	if req.DefaultRole == "" {
+		req.DefaultRole = domain.DefaultSignupRole
+	}
+	if !h.checkRoleAssignable(w, r, tenantID, req.DefaultRole) {
+		return
+	}
+
+	queries := db.New(h.Pool)
+	currentTenant, err := queries.GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("UpdateSignupSettings: Failed to get tenant", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update signup settings", http.StatusInternalServerError)
+		return
+	}
+
+	settings := currentTenant.Settings
+	settings.Signup = domain.SignupSettings{
+		Mode:           req.Mode,
+		AllowedDomains: allowed,
+		BlockedDomains: blocked,
+		DefaultRole:    req.DefaultRole,
+	}
+	settings.AllowRegistration = req.Mode != domain.SignupModeClosed
+
+	// Update only settings, preserve other fields
+	_, err = queries.UpdateTenantConfig(r.Context(), db.UpdateTenantConfigParams{
+		ID:             currentTenant.ID,
+		AllowedOrigins: currentTenant.AllowedOrigins,
+		RedirectUrls:   currentTenant.RedirectUrls,
+		Branding:       currentTenant.Branding,
+		Settings:       settings,
+		AppUrl:         currentTenant.AppUrl,
+	})
+	if err != nil {
+		slog.Error("UpdateSignupSettings: Database update failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to update signup settings", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Signup rules updated", "tenant_id", tenantID, "mode", req.Mode, "allowed", len(allowed), "blocked", len(blocked))
+	helpers.RespondJSON(w, http.StatusOK, newSignupSettings(settings.SignupRules()))
+}
+
+// normalizeDomainRules validates and lower-cases a domain list, writing a 400 on failure.
+func normalizeDomainRules(w http.ResponseWriter, field string, domains []string) ([]string, bool) {
+	if len(domains) > maxSignupDomainRules {
+		http.Error(w, "Too many entries in "+field+": at most "+strconv.Itoa(maxSignupDomainRules), http.StatusBadRequest)
+		return nil, false
+	}
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		n, err := storage.ValidateTenantDomain(d, "")
+		if err != nil {
+			http.Error(w, field+": "+err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		normalized = append(normalized, n)
+	}
+	return normalized, true
+}
+
+// ListEmailDomains handles GET /admin/signup/domains
+func (h *AuthHandler) ListEmailDomains(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	domains, err := h.service.ListEmailDomains(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("ListEmailDomains: Query failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve email domains", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]emailDomainResponse, len(domains))
+	for i, d := range domains {
+		resp[i] = newEmailDomainResponse(d)
+	}
+	helpers.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AddEmailDomain handles POST /admin/signup/domains
+// The response carries the TXT record to publish before calling verify.
+func (h *AuthHandler) AddEmailDomain(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	var req AddEmailDomainRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	autoJoin := req.AutoJoin == nil || *req.AutoJoin
+
+	created, err := h.service.AddEmailDomain(r.Context(), tenantID, customMiddleware.MustGetUserID(r.Context()), req.Domain, autoJoin)
+	if err != nil {
+		writeSignupError(w, "AddEmailDomain", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusCreated, newEmailDomainResponse(created))
+}
+
+// VerifyEmailDomain handles POST /admin/signup/domains/{domainID}/verify
+func (h *AuthHandler) VerifyEmailDomain(w http.ResponseWriter, r *http.Request) {
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainID"))
+	if err != nil {
+		http.Error(w, "Invalid Domain ID", http.StatusBadRequest)
+		return
+	}
+
+	verified, err := h.service.VerifyEmailDomain(r.Context(), customMiddleware.MustGetTenantID(r.Context()), domainID, customMiddleware.MustGetUserID(r.Context()))
+	if err != nil {
+		writeSignupError(w, "VerifyEmailDomain", err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newEmailDomainResponse(verified))
+}
+
+// DeleteEmailDomain handles DELETE /admin/signup/domains/{domainID}
+func (h *AuthHandler) DeleteEmailDomain(w http.ResponseWriter, r *http.Request) {
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainID"))
+	if err != nil {
+		http.Error(w, "Invalid Domain ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteEmailDomain(r.Context(), customMiddleware.MustGetTenantID(r.Context()), domainID, customMiddleware.MustGetUserID(r.Context())); err != nil {
+		writeSignupError(w, "DeleteEmailDomain", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSignupRequests handles GET /admin/signup/requests?status=&page=&limit=
+func (h *AuthHandler) ListSignupRequests(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", auth.SignupRequestPending, auth.SignupRequestApproved, auth.SignupRequestRejected:
+	default:
+		http.Error(w, "Invalid status: must be pending, approved or rejected", http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	requests, total, err := h.service.ListSignupRequests(r.Context(), tenantID, status, int32(limit), int32((page-1)*limit))
+	if err != nil {
+		slog.Error("ListSignupRequests: Query failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to list signup requests", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]signupRequestResponse, len(requests))
+	for i, req := range requests {
+		resp[i] = newSignupRequestResponse(req)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"requests": resp,
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// ApproveSignupRequest handles POST /admin/signup/requests/{requestID}/approve
+func (h *AuthHandler) ApproveSignupRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideSignupRequest(w, r, "ApproveSignupRequest", h.service.ApproveSignupRequest)
+}
+
+// RejectSignupRequest handles POST /admin/signup/requests/{requestID}/reject
+func (h *AuthHandler) RejectSignupRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideSignupRequest(w, r, "RejectSignupRequest", h.service.RejectSignupRequest)
+}
+
+func (h *AuthHandler) decideSignupRequest(w http.ResponseWriter, r *http.Request, op string, decide func(ctx context.Context, tenantID, requestID, actorID uuid.UUID) (db.SignupRequest, error)) {
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestID"))
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	decided, err := decide(r.Context(), customMiddleware.MustGetTenantID(r.Context()), requestID, customMiddleware.MustGetUserID(r.Context()))
+	if err != nil {
+		writeSignupError(w, op, err)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newSignupRequestResponse(decided))
+}
+
+func writeSignupError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidEmailDomain):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, auth.ErrEmailDomainNotFound):
+		http.Error(w, "Email domain not found", http.StatusNotFound)
+	case errors.Is(err, auth.ErrEmailDomainExists):
+		http.Error(w, "Email domain already added", http.StatusConflict)
+	case errors.Is(err, auth.ErrEmailDomainTaken):
+		http.Error(w, "Email domain is verified by another tenant", http.StatusConflict)
+	case errors.Is(err, auth.ErrDomainTXTNotFound):
+		http.Error(w, "Verification TXT record not found; DNS changes can take a while to propagate", http.StatusUnprocessableEntity)
+	case errors.Is(err, auth.ErrSignupRequestNotFound):
+		http.Error(w, "Signup request not found or already decided", http.StatusNotFound)
+	case errors.Is(err, auth.ErrSignupEmailTaken):
+		http.Error(w, "An account with this email already exists in this tenant", http.StatusConflict)
+	default:
+		slog.Error(op+": Failed", "error", err)
+		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
@@ -12,15 +13,20 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// UpdateCORSOriginsRequest defines the request body for updating CORS origins
+// UpdateCORSOriginsRequest defines the request body for updating CORS origins.
+// Headers, methods and max-age are optional; omitted fields keep their current value.
 type UpdateCORSOriginsRequest struct {
-	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedOrigins []string  `json:"allowed_origins"`
+	AllowedHeaders *[]string `json:"allowed_headers,omitempty"`
+	AllowedMethods *[]string `json:"allowed_methods,omitempty"`
+	MaxAge         *int      `json:"max_age,omitempty"`
 }
 
-// UpdateCORSOrigins allows admins to update allowed CORS origins
+// UpdateCORSOrigins allows admins to update the tenant CORS policy
 // Requires admin role
 //
 // ✅ SECURE: Validates CORS origins to reject wildcard (*) and enforce HTTPS
+// ✅ SECURE: Invalidates the in-memory CORS policy cache after the write
 func (h *AuthHandler) UpdateCORSOrigins(w http.ResponseWriter, r *http.Request) {
 	// 1. Get current tenant from context
 	tenantID, err := customMiddleware.GetTenantID(r.Context())
@@ -60,13 +66,33 @@ func (h *AuthHandler) UpdateCORSOrigins(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// 5. Update only allowed_origins, preserve other fields
+	// 5. Merge optional preflight settings
+	settings := currentTenant.Settings
+	if req.AllowedHeaders != nil {
+		settings.CORS.AllowedHeaders = *req.AllowedHeaders
+	}
+	if req.AllowedMethods != nil {
+		methods := make([]string, len(*req.AllowedMethods))
+		for i, m := range *req.AllowedMethods {
+			methods[i] = strings.ToUpper(m)
+		}
+		settings.CORS.AllowedMethods = methods
+	}
+	if req.MaxAge != nil {
+		settings.CORS.MaxAge = *req.MaxAge
+	}
+	if err := storage.ValidateCORSPolicy(settings.CORS.AllowedHeaders, settings.CORS.AllowedMethods, settings.CORS.MaxAge); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 6. Update CORS fields, preserve other fields
 	params := db.UpdateTenantConfigParams{
 		ID:             pgtype.UUID{Bytes: tenantID, Valid: true},
 		AllowedOrigins: req.AllowedOrigins,
 		RedirectUrls:   currentTenant.RedirectUrls,
 		Branding:       currentTenant.Branding,
-		Settings:       currentTenant.Settings,
+		Settings:       settings,
 		AppUrl:         currentTenant.AppUrl,
 	}
 
@@ -80,12 +106,20 @@ func (h *AuthHandler) UpdateCORSOrigins(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// 6. Return updated origins
+	// Other replicas pick the change up when their cache TTL expires
+	if h.CORSCache != nil {
+		h.CORSCache.Invalidate()
+	}
+
+	// 7. Return updated policy
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"tenant_id":       tenantID,
 		"allowed_origins": updatedTenant.AllowedOrigins,
+		"allowed_headers": updatedTenant.Settings.CORS.AllowedHeaders,
+		"allowed_methods": updatedTenant.Settings.CORS.AllowedMethods,
+		"max_age":         updatedTenant.Settings.CORS.MaxAge,
 		"updated_at":      updatedTenant.UpdatedAt.Time,
 	})
 }
@@ -102,7 +136,7 @@ func (h *AuthHandler) GetTenantConfig(w http.ResponseWriter, r *http.Request) {
 
 	// 2. Fetch configuration using Pool
 	queries := db.New(h.Pool)
-	config, err := queries.GetTenantConfig(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	config, err := queries.GetTenantByID(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
 	if err != nil {
 		slog.Error("GetTenantConfig: Database query failed",
 			"tenant_id", tenantID,
@@ -117,6 +151,9 @@ func (h *AuthHandler) GetTenantConfig(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"allowed_origins": config.AllowedOrigins,
+		"allowed_headers": config.Settings.CORS.AllowedHeaders,
+		"allowed_methods": config.Settings.CORS.AllowedMethods,
+		"max_age":         config.Settings.CORS.MaxAge,
 		"app_url":         config.AppUrl,
 	})
 }
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AddTenantDomainRequest defines the request body for registering a custom domain
+type AddTenantDomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+// TenantDomainResponse is the admin view of a custom domain, including the
+// TXT record that proves control of it
+type TenantDomainResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Domain        string     `json:"domain"`
+	Verified      bool       `json:"verified"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	CreatedAt     string     `json:"created_at"`
+	TXTName       string     `json:"txt_name"`
+	TXTValue      string     `json:"txt_value"`
+}
+
+func toTenantDomainResponse(d db.TenantDomain) TenantDomainResponse {
+	name, value := auth.DomainVerificationRecord(d.Domain, d.VerificationToken)
+	resp := TenantDomainResponse{
+		ID:        uuid.UUID(d.ID.Bytes),
+		Domain:    d.Domain,
+		Verified:  d.VerifiedAt.Valid,
+		CreatedAt: d.CreatedAt.Time.Format(time.RFC3339),
+		TXTName:   name,
+		TXTValue:  value,
+	}
+	if d.VerifiedAt.Valid {
+		resp.VerifiedAt = &d.VerifiedAt.Time
+	}
+	if d.LastCheckedAt.Valid {
+		resp.LastCheckedAt = &d.LastCheckedAt.Time
+	}
+	return resp
+}
+
+// ListTenantDomains handles GET /admin/domains
+func (h *AuthHandler) ListTenantDomains(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	domains, err := db.New(h.Pool).ListTenantDomains(r.Context(), pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		slog.Error("ListTenantDomains: Database query failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to retrieve domains", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]TenantDomainResponse, len(domains))
+	for i, d := range domains {
+		resp[i] = toTenantDomainResponse(d)
+	}
+	helpers.RespondJSON(w, http.StatusOK, resp)
+}
+
+// AddTenantDomain handles POST /admin/domains
+// Claims a custom hostname for the current tenant. It only resolves to the
+// tenant after VerifyTenantDomain finds the TXT record.
+//
+// ✅ SECURE: A claim alone routes nothing, so a tenant cannot take over another's hostname
+func (h *AuthHandler) AddTenantDomain(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	var req AddTenantDomainRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	baseDomain := ""
+	if h.TenantResolver != nil {
+		baseDomain = h.TenantResolver.BaseDomain()
+	}
+	domain, err := storage.ValidateTenantDomain(req.Domain, baseDomain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.GenerateSecureToken(24)
+	if err != nil {
+		slog.Error("AddTenantDomain: Token generation failed", "error", err)
+		http.Error(w, "Failed to add domain", http.StatusInternalServerError)
+		return
+	}
+
+	created, err := db.New(h.Pool).CreateTenantDomain(r.Context(), db.CreateTenantDomainParams{
+		TenantID:          pgtype.UUID{Bytes: tenantID, Valid: true},
+		Domain:            domain,
+		VerificationToken: token,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			http.Error(w, "Domain already added", http.StatusConflict)
+			return
+		}
+		slog.Error("AddTenantDomain: Database insert failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to add domain", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Tenant domain added", "tenant_id", tenantID, "domain", domain)
+	helpers.RespondJSON(w, http.StatusCreated, toTenantDomainResponse(created))
+}
+
+// VerifyTenantDomain handles POST /admin/domains/{domainID}/verify
+func (h *AuthHandler) VerifyTenantDomain(w http.ResponseWriter, r *http.Request) {
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainID"))
+	if err != nil {
+		http.Error(w, "Invalid Domain ID", http.StatusBadRequest)
+		return
+	}
+
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	verified, err := h.service.VerifyTenantDomain(r.Context(), tenantID, domainID, customMiddleware.MustGetUserID(r.Context()))
+	switch {
+	case errors.Is(err, auth.ErrTenantDomainNotFound):
+		http.Error(w, "Domain not found", http.StatusNotFound)
+		return
+	case errors.Is(err, auth.ErrTenantDomainTaken):
+		http.Error(w, "Domain is verified by another tenant", http.StatusConflict)
+		return
+	case errors.Is(err, auth.ErrDomainTXTNotFound):
+		http.Error(w, "Verification TXT record not found; DNS changes can take a while to propagate", http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		slog.Error("VerifyTenantDomain: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to verify domain", http.StatusInternalServerError)
+		return
+	}
+
+	if h.TenantResolver != nil {
+		h.TenantResolver.Invalidate() // Drop cached negative lookups
+	}
+
+	helpers.RespondJSON(w, http.StatusOK, toTenantDomainResponse(verified))
+}
+
+// DeleteTenantDomain handles DELETE /admin/domains/{domainID}
+func (h *AuthHandler) DeleteTenantDomain(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := customMiddleware.GetTenantID(r.Context())
+	if err != nil {
+		http.Error(w, "Tenant context required", http.StatusBadRequest)
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainID"))
+	if err != nil {
+		http.Error(w, "Invalid Domain ID", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := db.New(h.Pool).DeleteTenantDomain(r.Context(), db.DeleteTenantDomainParams{
+		ID:       pgtype.UUID{Bytes: domainID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if err != nil {
+		slog.Error("DeleteTenantDomain: Database delete failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to delete domain", http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		http.Error(w, "Domain not found", http.StatusNotFound)
+		return
+	}
+
+	if h.TenantResolver != nil {
+		h.TenantResolver.Invalidate()
+	}
+
+	slog.Info("Tenant domain removed", "tenant_id", tenantID, "domain_id", domainID)
+	w.WriteHeader(http.StatusNoContent)
+}
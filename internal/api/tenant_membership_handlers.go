@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/google/uuid"
+)
+
+// AcceptInvitationRequest is the body of POST /auth/invitations/accept
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// SwitchTenantRequest is the body of POST /auth/tenants/switch
+type SwitchTenantRequest struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+}
+
+// userTenantResponse is one entry of GET /auth/tenants
+type userTenantResponse struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Slug     string    `json:"slug"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+	Current  bool      `json:"current"`
+}
+
+// AcceptInvitation handles POST /auth/invitations/accept
+// A signed-in user joins the invitation's tenant with their existing account;
+// POST /auth/tenants/switch then moves the session there.
+func (h *AuthHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req AcceptInvitationRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := customMiddleware.MustGetUserID(r.Context())
+	invite, err := h.service.AcceptInvitationAsMember(r.Context(), userID, customMiddleware.MustGetTenantID(r.Context()), req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvitationEmailMismatch):
+			http.Error(w, "This invitation was sent to a different email address", http.StatusForbidden)
+		case errors.Is(err, auth.ErrUserNotFound):
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		default:
+			writeInvitationError(w, "AcceptInvitation", err)
+		}
+		return
+	}
+
+	slog.Info("Invitation accepted by existing user", "user_id", userID, "tenant_id", uuid.UUID(invite.TenantID.Bytes))
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"tenant_id": uuid.UUID(invite.TenantID.Bytes),
+		"role":      invite.Role,
+	})
+}
+
+// ListMyTenants handles GET /auth/tenants
+func (h *AuthHandler) ListMyTenants(w http.ResponseWriter, r *http.Request) {
+	userID := customMiddleware.MustGetUserID(r.Context())
+	currentTenantID, _ := customMiddleware.GetTenantID(r.Context())
+
+	tenants, err := h.service.ListUserTenants(r.Context(), userID)
+	if err != nil {
+		slog.Error("ListMyTenants: Query failed", "user_id", userID, "error", err)
+		http.Error(w, "Failed to list tenants", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]userTenantResponse, len(tenants))
+	for i, t := range tenants {
+		resp[i] = userTenantResponse{
+			ID:       t.ID.Bytes,
+			Name:     t.Name,
+			Slug:     t.Slug,
+			Role:     t.Role,
+			JoinedAt: t.JoinedAt.Time,
+			Current:  uuid.UUID(t.ID.Bytes) == currentTenantID,
+		}
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{"tenants": resp})
+}
+
+// SwitchTenant handles POST /auth/tenants/switch
+// New session cookies are bound to the target tenant; the current session is revoked.
+func (h *AuthHandler) SwitchTenant(w http.ResponseWriter, r *http.Request) {
+	var req SwitchTenantRequest
+	if err := helpers.DecodeJSON(r, &req); err != nil || req.TenantID == uuid.Nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var refreshToken string
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		refreshToken = cookie.Value
+	}
+
+	userID := customMiddleware.MustGetUserID(r.Context())
+	currentTenantID, _ := customMiddleware.GetTenantID(r.Context())
+	result, err := h.service.SwitchTenant(r.Context(), userID, currentTenantID, req.TenantID, refreshToken, helpers.GetRealIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrNotTenantMember):
+			http.Error(w, "You are not a member of this tenant", http.StatusForbidden)
+		case errors.Is(err, auth.ErrTenantSuspended):
+			http.Error(w, "Tenant is suspended", http.StatusForbidden)
+		case errors.Is(err, auth.ErrEmailNotVerified):
+			http.Error(w, "Email address not verified", http.StatusForbidden)
+		case errors.Is(err, auth.ErrAccountPendingDeletion):
+			http.Error(w, "Account is scheduled for deletion", http.StatusForbidden)
+		default:
+			slog.Error("SwitchTenant: Failed", "user_id", userID, "tenant_id", req.TenantID, "error", err)
+			http.Error(w, "Failed to switch tenant", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.setSessionCookies(w, result.AccessToken, result.RefreshToken)
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"tenant_id": req.TenantID,
+	})
+}
@@ -2,11 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
 	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
 )
 
 // ResendVerificationRequest defines request body for resending verification email
@@ -78,6 +80,11 @@ func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 
 	// 3. Call service to verify email
 	if err := h.service.VerifyEmail(r.Context(), req.Token); err != nil {
+		if !errors.Is(err, auth.ErrInvalidVerificationToken) {
+			slog.Error("VerifyEmail: Failed", "error", err)
+			http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+			return
+		}
 		slog.Warn("VerifyEmail: Failed", "error", err)
 		http.Error(w, "Invalid or expired verification token", http.StatusUnauthorized)
 		return
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/helpers"
+	customMiddleware "github.com/Jeffreasy/LaventeCareAuthSystems/internal/api/middleware"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// webhookResponse is an endpoint as shown to admins; the secret only appears
+// in the create and rotate responses.
+type webhookResponse struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	EventTypes  []string  `json:"event_types"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Secret      string    `json:"secret,omitempty"`
+}
+
+func newWebhookResponse(e db.WebhookEndpoint) webhookResponse {
+	return webhookResponse{
+		ID:          e.ID.Bytes,
+		URL:         e.Url,
+		Description: e.Description.String,
+		EventTypes:  e.EventTypes,
+		Enabled:     e.Enabled,
+		CreatedAt:   e.CreatedAt.Time,
+		UpdatedAt:   e.UpdatedAt.Time,
+	}
+}
+
+// webhookDeliveryResponse is one entry of the delivery log. The payload is
+// included so admins can see exactly what was sent.
+type webhookDeliveryResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	MaxAttempts    int32           `json:"max_attempts"`
+	NextRetryAt    *time.Time      `json:"next_retry_at"`
+	ResponseStatus *int32          `json:"response_status"`
+	ResponseBody   *string         `json:"response_body"`
+	DurationMs     *int32          `json:"duration_ms"`
+	LastError      *string         `json:"last_error"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	RedeliveryOf   *uuid.UUID      `json:"redelivery_of,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func newWebhookDeliveryResponse(d db.WebhookDelivery) webhookDeliveryResponse {
+	resp := webhookDeliveryResponse{
+		ID:          d.ID.Bytes,
+		EventID:     d.EventID.Bytes,
+		EventType:   d.EventType,
+		Status:      d.Status,
+		Attempts:    d.AttemptCount,
+		MaxAttempts: d.MaxAttempts,
+		Payload:     d.Payload,
+		CreatedAt:   d.CreatedAt.Time,
+	}
+	if d.NextRetryAt.Valid && d.Status == webhook.StatusPending {
+		resp.NextRetryAt = &d.NextRetryAt.Time
+	}
+	if d.ResponseStatus.Valid {
+		resp.ResponseStatus = &d.ResponseStatus.Int32
+	}
+	if d.ResponseBody.Valid {
+		resp.ResponseBody = &d.ResponseBody.String
+	}
+	if d.DurationMs.Valid {
+		resp.DurationMs = &d.DurationMs.Int32
+	}
+	if d.LastError.Valid {
+		resp.LastError = &d.LastError.String
+	}
+	if d.LastAttemptAt.Valid {
+		resp.LastAttemptAt = &d.LastAttemptAt.Time
+	}
+	if d.DeliveredAt.Valid {
+		resp.DeliveredAt = &d.DeliveredAt.Time
+	}
+	if d.RedeliveryOf.Valid {
+		id := uuid.UUID(d.RedeliveryOf.Bytes)
+		resp.RedeliveryOf = &id
+	}
+	return resp
+}
+
+// ListWebhooks handles GET /admin/webhooks
+func (h *AuthHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	endpoints, err := h.Webhooks.List(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("ListWebhooks: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+	out := make([]webhookResponse, len(endpoints))
+	for i, e := range endpoints {
+		out[i] = newWebhookResponse(e)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{"webhooks": out})
+}
+
+// CreateWebhook handles POST /admin/webhooks
+// The signing secret is returned only in this response.
+func (h *AuthHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+
+	var req struct {
+		URL         string   `json:"url"`
+		Description string   `json:"description"`
+		EventTypes  []string `json:"event_types"`
+	}
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, secret, err := h.Webhooks.Create(r.Context(), tenantID, adminID, webhook.CreateParams{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+	})
+	switch {
+	case errors.Is(err, webhook.ErrInvalidEndpoint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, webhook.ErrTooManyEndpoints):
+		http.Error(w, fmt.Sprintf("At most %d webhooks per tenant", webhook.MaxEndpointsPerTenant), http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("CreateWebhook: Failed", "tenant_id", tenantID, "error", err)
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+
+	resp := newWebhookResponse(endpoint)
+	resp.Secret = secret
+	helpers.RespondJSON(w, http.StatusCreated, resp)
+}
+
+// UpdateWebhook handles PATCH /admin/webhooks/{webhookID}
+// Omitted fields are left unchanged.
+func (h *AuthHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	endpointID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		http.Error(w, "Invalid Webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		URL         *string  `json:"url"`
+		Description *string  `json:"description"`
+		EventTypes  []string `json:"event_types"`
+		Enabled     *bool    `json:"enabled"`
+	}
+	if err := helpers.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := h.Webhooks.Update(r.Context(), tenantID, adminID, endpointID, webhook.UpdateParams{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Enabled:     req.Enabled,
+	})
+	switch {
+	case errors.Is(err, webhook.ErrInvalidEndpoint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, webhook.ErrEndpointNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("UpdateWebhook: Failed", "tenant_id", tenantID, "webhook_id", endpointID, "error", err)
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, newWebhookResponse(endpoint))
+}
+
+// RotateWebhookSecret handles POST /admin/webhooks/{webhookID}/rotate-secret
+// The new secret takes effect at once and is returned only in this response.
+func (h *AuthHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	endpointID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		http.Error(w, "Invalid Webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	secret, err := h.Webhooks.RotateSecret(r.Context(), tenantID, adminID, endpointID)
+	switch {
+	case errors.Is(err, webhook.ErrEndpointNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("RotateWebhookSecret: Failed", "tenant_id", tenantID, "webhook_id", endpointID, "error", err)
+		http.Error(w, "Failed to rotate webhook secret", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]string{"secret": secret})
+}
+
+// DeleteWebhook handles DELETE /admin/webhooks/{webhookID}
+func (h *AuthHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	adminID := customMiddleware.MustGetUserID(r.Context())
+	endpointID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		http.Error(w, "Invalid Webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.Webhooks.Delete(r.Context(), tenantID, adminID, endpointID)
+	switch {
+	case errors.Is(err, webhook.ErrEndpointNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("DeleteWebhook: Failed", "tenant_id", tenantID, "webhook_id", endpointID, "error", err)
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET /admin/webhooks/{webhookID}/deliveries
+// Optional: ?status=pending|processing|delivered|failed&limit=N (max 100)
+func (h *AuthHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	endpointID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		http.Error(w, "Invalid Webhook ID", http.StatusBadRequest)
+		return
+	}
+	limit := webhook.MaxDeliveryPage
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := h.Webhooks.Deliveries(r.Context(), tenantID, endpointID, r.URL.Query().Get("status"), limit)
+	switch {
+	case errors.Is(err, webhook.ErrInvalidEndpoint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, webhook.ErrEndpointNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("ListWebhookDeliveries: Failed", "tenant_id", tenantID, "webhook_id", endpointID, "error", err)
+		http.Error(w, "Failed to list webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+	out := make([]webhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		out[i] = newWebhookDeliveryResponse(d)
+	}
+	helpers.RespondJSON(w, http.StatusOK, map[string]interface{}{"deliveries": out})
+}
+
+// RedeliverWebhook handles POST /admin/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver
+// Queues the same payload again; the worker sends it on its next tick.
+func (h *AuthHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID := customMiddleware.MustGetTenantID(r.Context())
+	endpointID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		http.Error(w, "Invalid Webhook ID", http.StatusBadRequest)
+		return
+	}
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		http.Error(w, "Invalid Delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.Webhooks.Redeliver(r.Context(), tenantID, endpointID, deliveryID)
+	switch {
+	case errors.Is(err, webhook.ErrDeliveryNotFound):
+		http.Error(w, "Webhook delivery not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("RedeliverWebhook: Failed", "tenant_id", tenantID, "delivery_id", deliveryID, "error", err)
+		http.Error(w, "Failed to redeliver webhook", http.StatusInternalServerError)
+		return
+	}
+	helpers.RespondJSON(w, http.StatusAccepted, newWebhookDeliveryResponse(delivery))
+}
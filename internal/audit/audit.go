@@ -2,76 +2,67 @@ package audit
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
-	"os"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// EventType defines the category of the audit log.
-type EventType string
-
-const (
-	EventLoginSuccess  EventType = "LOGIN_SUCCESS"
-	EventLoginFailed   EventType = "LOGIN_FAILED"
-	EventLogout        EventType = "LOGOUT"
-	EventPasswordReset EventType = "PASSWORD_RESET"
-	EventTenantSwitch  EventType = "TENANT_SWITCH"
-	EventDataAccess    EventType = "DATA_ACCESS" // High volume, critical for compliance
-	EventConfigChange  EventType = "CONFIG_CHANGE"
-)
-
-// AuditLogger defines the contract for immutable logging.
-type AuditLogger interface {
-	Log(ctx context.Context, actorID uuid.UUID, action EventType, resource string, metadata map[string]string)
-}
-
-// JSONAuditLogger writes structured logs to stdout, but with a specific "audit" key
+// JSONSink writes structured logs to stdout, but with a specific "audit" key
 // that can be filtered by log aggregators (Datadog, Splunk, Sentry) to go to a separate index.
-type JSONAuditLogger struct {
+type JSONSink struct {
 	logger *slog.Logger
-	mu     sync.Mutex
 }
 
-func NewJSONAuditLogger() *JSONAuditLogger {
+// NewJSONSink writes to w (os.Stdout in production).
+func NewJSONSink(w io.Writer) *JSONSink {
 	// We use a separate handler/logger instance to ensure consistent formatting
 	// independent of the main app logger.
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	})
-	return &JSONAuditLogger{
+	return &JSONSink{
 		logger: slog.New(handler),
 	}
 }
 
-func (l *JSONAuditLogger) Log(ctx context.Context, actorID uuid.UUID, action EventType, resource string, metadata map[string]string) {
+func (s *JSONSink) Write(ctx context.Context, entry Entry) {
 	// Anti-Gravity: "Compliance is Mandatory".
 	// We ensure timestamps are UTC and format is strict.
-
 	fields := []interface{}{
 		slog.String("log_type", "AUDIT_TRAIL"), // Marker for aggregators
-		slog.String("actor_id", actorID.String()),
-		slog.String("action", string(action)),
-		slog.String("resource", resource),
-		slog.Time("timestamp_utc", time.Now().UTC()),
+		slog.String("event_id", entry.ID.String()),
+		slog.String("action", entry.Action),
+		slog.String("severity", string(entry.Severity)),
+		slog.Time("timestamp_utc", entry.Timestamp.UTC().Truncate(time.Microsecond)),
 	}
-
-	// Flatten metadata
-	for k, v := range metadata {
-		fields = append(fields, slog.String("meta_"+k, v))
+	for _, id := range []struct {
+		key string
+		id  uuid.UUID
+	}{
+		{"actor_id", entry.ActorID},
+		{"tenant_id", entry.TenantID},
+		{"target_id", entry.TargetID},
+		{"session_id", entry.SessionID},
+	} {
+		if id.id != uuid.Nil {
+			fields = append(fields, slog.String(id.key, id.id.String()))
+		}
+	}
+	if len(entry.Metadata) > 0 && string(entry.Metadata) != "null" {
+		fields = append(fields, slog.Any("metadata", json.RawMessage(entry.Metadata)))
 	}
 
-	// Extract generic context info if available (IP, UserAgent)
-	// (Assuming middleware populates this, or we rely on metadata arg)
-
-	l.logger.InfoContext(ctx, "audit_event", fields...)
-}
-
-// MockAuditLogger for testing
-type MockAuditLogger struct{}
+	// Request context (IP, UserAgent, RequestID) as captured by the middleware
+	if entry.RequestID != "" {
+		fields = append(fields,
+			slog.String("request_id", entry.RequestID),
+			slog.String("ip", entry.IP.String()),
+			slog.String("user_agent", entry.UserAgent),
+		)
+	}
 
-func (m *MockAuditLogger) Log(ctx context.Context, actorID uuid.UUID, action EventType, resource string, metadata map[string]string) {
-	// No-op
+	s.logger.InfoContext(ctx, "audit_event", fields...)
 }
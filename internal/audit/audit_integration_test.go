@@ -30,7 +30,7 @@ func SetupServices(t *testing.T) (*pgxpool.Pool, *auth.AuthService, *db.Queries)
 
 	queries := db.New(pool)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	auditLogger := audit.NewDBLogger(queries, logger)
+	auditLogger := audit.NewAuditService(logger, audit.NewDBSink(queries, logger))
 
 	// Mock or real deps
 	hasher := auth.NewBcryptHasher()
@@ -0,0 +1,213 @@
+package audit
+
+import "slices"
+
+// Event is the name of an audit event ("<resource>.<action>"). Names are stored
+// in audit_logs.action and streamed to tenant SIEMs, so NEVER rename one; add a
+// new event instead.
+type Event string
+
+// Severity ranks events for SIEMs and alerting (maps onto syslog severities).
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"     // Routine activity
+	SeverityNotice   Severity = "notice"   // Configuration and account changes
+	SeverityWarning  Severity = "warning"  // Failures and weakened protection
+	SeverityCritical Severity = "critical" // Platform operators acting on tenants, data removal
+)
+
+// EventDef registers an event: its severity and the metadata keys every
+// emitter must set.
+type EventDef struct {
+	Name        Event    `json:"name"`
+	Severity    Severity `json:"severity"`
+	Required    []string `json:"required_metadata,omitempty"`
+	Description string   `json:"description"`
+}
+
+// Authentication and sessions
+const (
+	EventLoginSuccess Event = "auth.login.success"
+	EventLogout       Event = "auth.logout"
+	EventTenantSwitch Event = "auth.tenant_switch"
+	EventLoginLockout Event = "auth.lockout"
+)
+
+// Accounts
+const (
+	EventUserCreatedPublic    Event = "user.create.public"
+	EventUserCreatedInvite    Event = "user.create.invite"
+	EventUserCreatedDomain    Event = "user.create.domain"
+	EventSignupRequested      Event = "user.signup.requested"
+	EventSignupApproved       Event = "user.signup.approved"
+	EventSignupRejected       Event = "user.signup.rejected"
+	EventPasswordChanged      Event = "user.password_change"
+	EventMFAEnabled           Event = "user.mfa.enabled"
+	EventAccountLockedByOwner Event = "user.locked_by_owner"
+	EventEmailChangeRequested Event = "user.email_change.requested"
+	EventEmailChangeConfirmed Event = "user.email_change.confirmed"
+	EventEmailChangeReverted  Event = "user.email_change.reverted"
+	EventDataExportRequested  Event = "user.data_export.requested"
+	EventDataExportReady      Event = "user.data_export.ready"
+	EventDataExportDownloaded Event = "user.data_export.downloaded"
+	EventDeletionScheduled    Event = "user.deletion_scheduled"
+	EventDeletionCancelled    Event = "user.deletion_cancelled"
+	EventUserDeleted          Event = "user.deleted"
+)
+
+// Tenant administration
+const (
+	EventInvitationCreated    Event = "invitation.created"
+	EventInvitationResent     Event = "invitation.resent"
+	EventInvitationRevoked    Event = "invitation.revoked"
+	EventInvitationAccepted   Event = "invitation.accepted"
+	EventRoleCreated          Event = "role.create"
+	EventRoleUpdated          Event = "role.update"
+	EventRoleDeleted          Event = "role.delete"
+	EventMemberRoleChanged    Event = "member.role_changed"
+	EventMemberRemoved        Event = "member.removed"
+	EventEmailDomainAdded     Event = "tenant.email_domain.added"
+	EventEmailDomainVerified  Event = "tenant.email_domain.verified"
+	EventEmailDomainRemoved   Event = "tenant.email_domain.removed"
+	EventTenantDomainVerified Event = "tenant.domain.verified"
+	EventTenantBootstrap      Event = "tenant.bootstrap"
+	EventTenantOffboarded     Event = "tenant.offboarded"
+	EventAuditSinkCreated     Event = "audit_sink.created"
+	EventAuditSinkEnabled     Event = "audit_sink.enabled"
+	EventAuditSinkDisabled    Event = "audit_sink.disabled"
+	EventAuditSinkDeleted     Event = "audit_sink.deleted"
+	EventAuditLogExported     Event = "audit_log.exported"
+	EventAuditRetentionSet    Event = "audit_log.retention_changed"
+	EventAuditLogArchived     Event = "audit_log.archived"
+	EventWebhookCreated       Event = "webhook.created"
+	EventWebhookUpdated       Event = "webhook.updated"
+	EventWebhookRotated       Event = "webhook.secret_rotated"
+	EventWebhookDeleted       Event = "webhook.deleted"
+	EventEmailTemplateUpdated Event = "email_template.updated"
+	EventEmailTemplateReset   Event = "email_template.reset"
+	EventEmailLocaleChanged   Event = "tenant.email_locale_changed"
+)
+
+// Platform plane
+const (
+	EventPlatformLoginSuccess      Event = "platform.login.success"
+	EventPlatformLoginFailed       Event = "platform.login.failed"
+	EventPlatformTenantCreated     Event = "platform.tenant.create"
+	EventPlatformTenantSuspended   Event = "platform.tenant.suspend"
+	EventPlatformTenantResumed     Event = "platform.tenant.resume"
+	EventPlatformDeletionScheduled Event = "platform.tenant.delete_scheduled"
+	EventPlatformDeletionCancelled Event = "platform.tenant.delete_cancelled"
+	EventPlatformImpersonate       Event = "platform.impersonate"
+)
+
+// Catalogue lists every event the system records. AuditService.Log reports
+// events missing from it, and TestEmittedEventsAreRegistered fails the build
+// for code that emits one.
+var Catalogue = []EventDef{
+	{EventLoginSuccess, SeverityInfo, []string{"method"}, "Signed in (password, MFA or passkey)"},
+	{EventLogout, SeverityInfo, []string{"method"}, "Signed out or session revoked"},
+	{EventTenantSwitch, SeverityInfo, []string{"from_tenant_id"}, "Switched the session to another tenant"},
+	{EventLoginLockout, SeverityWarning, []string{"protection", "until"}, "Sign-in refused for a while after too many failed attempts (rate limit or challenge)"},
+
+	{EventUserCreatedPublic, SeverityInfo, []string{"method"}, "Account created through open registration"},
+	{EventUserCreatedInvite, SeverityInfo, []string{"method"}, "Account created from an invitation"},
+	{EventUserCreatedDomain, SeverityInfo, []string{"method", "email_domain"}, "Account created on a verified auto-join domain"},
+	{EventSignupRequested, SeverityInfo, []string{"method"}, "Registration queued for approval or domain confirmation"},
+	{EventSignupApproved, SeverityNotice, []string{"signup_request_id"}, "Admin approved a registration"},
+	{EventSignupRejected, SeverityNotice, []string{"signup_request_id"}, "Admin rejected a registration"},
+	{EventPasswordChanged, SeverityNotice, []string{"revoked_all_sessions"}, "Password changed"},
+	{EventMFAEnabled, SeverityNotice, []string{"backup_codes"}, "Two-factor authentication turned on"},
+	{EventAccountLockedByOwner, SeverityCritical, []string{"revoked_all_sessions"}, "Member reported a change as not theirs; account locked until a password reset"},
+	{EventEmailChangeRequested, SeverityInfo, []string{"change_id"}, "Email change requested"},
+	{EventEmailChangeConfirmed, SeverityNotice, []string{"change_id"}, "Email change confirmed"},
+	{EventEmailChangeReverted, SeverityWarning, []string{"change_id"}, "Email change reverted from the old address"},
+	{EventDataExportRequested, SeverityNotice, []string{"export_id"}, "Personal data export requested"},
+	{EventDataExportReady, SeverityInfo, []string{"export_id"}, "Personal data export built"},
+	{EventDataExportDownloaded, SeverityNotice, []string{"export_id"}, "Personal data export downloaded"},
+	{EventDeletionScheduled, SeverityNotice, []string{"purge_after"}, "Account deletion scheduled"},
+	{EventDeletionCancelled, SeverityInfo, nil, "Account deletion cancelled"},
+	{EventUserDeleted, SeverityWarning, []string{"deleted"}, "Account purged after its cooling-off period"},
+
+	{EventInvitationCreated, SeverityNotice, []string{"invitation_id"}, "Invitation sent"},
+	{EventInvitationResent, SeverityInfo, []string{"invitation_id"}, "Invitation sent again"},
+	{EventInvitationRevoked, SeverityNotice, []string{"invitation_id"}, "Invitation revoked"},
+	{EventInvitationAccepted, SeverityNotice, []string{"invitation_id"}, "Invitation accepted"},
+	{EventRoleCreated, SeverityNotice, []string{"name", "permissions"}, "Custom role created"},
+	{EventRoleUpdated, SeverityNotice, []string{"name", "permissions"}, "Custom role changed"},
+	{EventRoleDeleted, SeverityNotice, nil, "Custom role deleted"},
+	{EventMemberRoleChanged, SeverityNotice, []string{"role"}, "Member given another role"},
+	{EventMemberRemoved, SeverityWarning, nil, "Member removed from the tenant"},
+	{EventEmailDomainAdded, SeverityNotice, []string{"domain"}, "Email domain claimed"},
+	{EventEmailDomainVerified, SeverityNotice, []string{"domain"}, "Email domain verified"},
+	{EventEmailDomainRemoved, SeverityNotice, []string{"domain_id"}, "Email domain removed"},
+	{EventTenantDomainVerified, SeverityNotice, []string{"domain"}, "Custom hostname verified"},
+	{EventTenantBootstrap, SeverityNotice, []string{"method", "slug"}, "Tenant created by the bootstrap script"},
+	{EventTenantOffboarded, SeverityCritical, []string{"slug", "deleted"}, "Tenant data deleted after its grace period"},
+	{EventAuditSinkCreated, SeverityNotice, []string{"name", "kind", "endpoint"}, "SIEM sink added"},
+	{EventAuditSinkEnabled, SeverityNotice, []string{"name"}, "SIEM sink resumed"},
+	{EventAuditSinkDisabled, SeverityWarning, []string{"name"}, "SIEM sink paused"},
+	{EventAuditSinkDeleted, SeverityWarning, nil, "SIEM sink removed"},
+	{EventAuditLogExported, SeverityNotice, []string{"format", "since", "until", "rows"}, "Audit log exported"},
+	{EventAuditRetentionSet, SeverityWarning, []string{"retention_days", "previous_days"}, "Audit log retention changed"},
+	{EventAuditLogArchived, SeverityNotice, []string{"month", "rows", "sha256"}, "Audit log month archived and removed from the database"},
+	{EventWebhookCreated, SeverityNotice, []string{"url", "event_types"}, "Webhook endpoint added"},
+	{EventWebhookUpdated, SeverityNotice, []string{"url", "event_types", "enabled"}, "Webhook endpoint changed"},
+	{EventWebhookRotated, SeverityNotice, nil, "Webhook signing secret replaced"},
+	{EventWebhookDeleted, SeverityWarning, nil, "Webhook endpoint removed"},
+	{EventEmailTemplateUpdated, SeverityNotice, []string{"template", "locale", "parts"}, "Email template override saved"},
+	{EventEmailTemplateReset, SeverityNotice, []string{"template", "locale"}, "Email template override removed; the default applies again"},
+	{EventEmailLocaleChanged, SeverityNotice, []string{"locale", "previous_locale"}, "Default email language changed"},
+
+	{EventPlatformLoginSuccess, SeverityNotice, []string{"platform_admin_id"}, "Platform operator signed in"},
+	{EventPlatformLoginFailed, SeverityWarning, []string{"email"}, "Platform sign-in failed"},
+	{EventPlatformTenantCreated, SeverityNotice, []string{"platform_admin_id", "slug"}, "Tenant created"},
+	{EventPlatformTenantSuspended, SeverityWarning, []string{"platform_admin_id", "reason"}, "Tenant suspended"},
+	{EventPlatformTenantResumed, SeverityNotice, []string{"platform_admin_id"}, "Tenant resumed"},
+	{EventPlatformDeletionScheduled, SeverityCritical, []string{"platform_admin_id", "reason", "purge_after"}, "Tenant deletion scheduled"},
+	{EventPlatformDeletionCancelled, SeverityNotice, []string{"platform_admin_id"}, "Tenant deletion cancelled"},
+	{EventPlatformImpersonate, SeverityCritical, []string{"platform_admin_id", "reason"}, "Platform operator impersonated a member"},
+}
+
+// catalogueIndex is Catalogue by name.
+var catalogueIndex = func() map[Event]EventDef {
+	index := make(map[Event]EventDef, len(Catalogue))
+	for _, def := range Catalogue {
+		index[def.Name] = def
+	}
+	return index
+}()
+
+// Lookup returns the registration of an event.
+func Lookup(name Event) (EventDef, bool) {
+	def, ok := catalogueIndex[name]
+	return def, ok
+}
+
+// SeverityOf returns the severity of a stored action; SeverityInfo for actions
+// that are not registered (rows from before the catalogue).
+func SeverityOf(action string) Severity {
+	if def, ok := Lookup(Event(action)); ok {
+		return def.Severity
+	}
+	return SeverityInfo
+}
+
+// missing returns the required keys absent from metadata.
+func (d EventDef) missing(metadata map[string]interface{}) []string {
+	var keys []string
+	for _, key := range d.Required {
+		if _, ok := metadata[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Severities lists the valid severities, lowest first.
+var Severities = []Severity{SeverityInfo, SeverityNotice, SeverityWarning, SeverityCritical}
+
+// Valid reports whether s is a known severity.
+func (s Severity) Valid() bool {
+	return slices.Contains(Severities, s)
+}
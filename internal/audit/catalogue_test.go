@@ -0,0 +1,240 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps the entries it receives.
+type recordingSink struct {
+	entries []audit.Entry
+}
+
+func (s *recordingSink) Write(ctx context.Context, entry audit.Entry) {
+	s.entries = append(s.entries, entry)
+}
+
+// eventConstants parses catalogue.go: constant name -> event name.
+func eventConstants(t *testing.T) map[string]audit.Event {
+	file, err := parser.ParseFile(token.NewFileSet(), "catalogue.go", nil, 0)
+	require.NoError(t, err)
+	consts := make(map[string]audit.Event)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if typ, ok := vs.Type.(*ast.Ident); !ok || typ.Name != "Event" {
+				continue
+			}
+			value, err := strconv.Unquote(vs.Values[0].(*ast.BasicLit).Value)
+			require.NoError(t, err)
+			consts[vs.Names[0].Name] = audit.Event(value)
+		}
+	}
+	return consts
+}
+
+func TestCatalogue(t *testing.T) {
+	seen := make(map[audit.Event]bool)
+	for _, def := range audit.Catalogue {
+		assert.False(t, seen[def.Name], "%s is registered twice", def.Name)
+		seen[def.Name] = true
+		assert.True(t, def.Severity.Valid(), "%s: severity %q", def.Name, def.Severity)
+		assert.NotEmpty(t, def.Description, def.Name)
+		assert.Regexp(t, `^[a-z_]+(\.[a-z_]+)+$`, string(def.Name))
+	}
+	for name, event := range eventConstants(t) {
+		_, ok := audit.Lookup(event)
+		assert.True(t, ok, "%s (%s) is declared but missing from Catalogue", name, event)
+	}
+}
+
+// TestEmittedEventsAreRegistered scans the module for audit calls
+// (x.Log(ctx, event, audit.LogParams{...})) and requires every event to be a
+// registered catalogue constant, or a variable only ever assigned one.
+func TestEmittedEventsAreRegistered(t *testing.T) {
+	consts := eventConstants(t)
+	root, err := filepath.Abs("../..")
+	require.NoError(t, err)
+
+	calls := 0
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		inAudit := file.Name.Name == "audit"
+
+		// isConstant reports whether expr names a registered event constant.
+		isConstant := func(expr ast.Expr) bool {
+			switch e := expr.(type) {
+			case *ast.SelectorExpr:
+				pkg, ok := e.X.(*ast.Ident)
+				_, registered := consts[e.Sel.Name]
+				return ok && pkg.Name == "audit" && registered
+			case *ast.Ident:
+				_, registered := consts[e.Name]
+				return inAudit && registered
+			}
+			return false
+		}
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || !isAuditCall(call) {
+					return true
+				}
+				calls++
+				event := call.Args[1]
+				where := fset.Position(event.Pos()).String()
+				if isConstant(event) {
+					return true
+				}
+				if ident, ok := event.(*ast.Ident); ok {
+					values := assignments(fn.Body, ident.Name)
+					assert.NotEmpty(t, values, "%s: %s is not assigned in this function", where, ident.Name)
+					for _, v := range values {
+						assert.True(t, isConstant(v), "%s: %s is assigned something other than a registered audit.Event constant", where, ident.Name)
+					}
+					return true
+				}
+				t.Errorf("%s: audit event must be a registered audit.Event constant, got %s", where, render(fset, event))
+				return true
+			})
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Greater(t, calls, 30, "the scan should find the audit calls of the module")
+}
+
+// isAuditCall matches x.Log(ctx, event, audit.LogParams{...}).
+func isAuditCall(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Log" || len(call.Args) != 3 {
+		return false
+	}
+	lit, ok := call.Args[2].(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	switch typ := lit.Type.(type) {
+	case *ast.SelectorExpr:
+		return typ.Sel.Name == "LogParams"
+	case *ast.Ident:
+		return typ.Name == "LogParams"
+	}
+	return false
+}
+
+// assignments returns every value assigned to name within body.
+func assignments(body *ast.BlockStmt, name string) []ast.Expr {
+	var values []ast.Expr
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range s.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && id.Name == name && i < len(s.Rhs) {
+					values = append(values, s.Rhs[i])
+				}
+			}
+		case *ast.ValueSpec:
+			for i, id := range s.Names {
+				if id.Name == name && i < len(s.Values) {
+					values = append(values, s.Values[i])
+				}
+			}
+		}
+		return true
+	})
+	return values
+}
+
+func render(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = ast.Fprint(&buf, fset, expr, nil)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func TestAuditService_FanOut(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	first, second := &recordingSink{}, &recordingSink{}
+	svc := audit.NewAuditService(logger, first, second)
+
+	actor := uuid.New()
+	svc.Log(context.Background(), audit.EventPlatformImpersonate, audit.LogParams{
+		ActorID:  actor,
+		Metadata: map[string]interface{}{"platform_admin_id": actor.String(), "reason": "ticket 42"},
+	})
+	require.Len(t, first.entries, 1)
+	require.Len(t, second.entries, 1)
+	assert.Equal(t, first.entries[0].ID, second.entries[0].ID, "one record, shared by every sink")
+	assert.Equal(t, audit.SeverityCritical, first.entries[0].Severity)
+	assert.Equal(t, "platform.impersonate", first.entries[0].Action)
+	assert.Empty(t, logs.String())
+
+	// Missing required metadata is reported, the event still recorded
+	svc.Log(context.Background(), audit.EventPlatformImpersonate, audit.LogParams{})
+	assert.Len(t, first.entries, 2)
+	assert.Contains(t, logs.String(), "audit_metadata_missing")
+
+	// So is an unregistered event; TestEmittedEventsAreRegistered keeps them out of the code
+	logs.Reset()
+	svc.Log(context.Background(), audit.Event("made.up"), audit.LogParams{})
+	assert.Len(t, first.entries, 3)
+	assert.Equal(t, audit.SeverityWarning, first.entries[2].Severity)
+	assert.Contains(t, logs.String(), "audit_event_unregistered")
+}
+
+func TestJSONSink(t *testing.T) {
+	var out bytes.Buffer
+	svc := audit.NewAuditService(slog.Default(), audit.NewJSONSink(&out))
+	tenant := uuid.New()
+	svc.Log(context.Background(), audit.EventRoleCreated, audit.LogParams{
+		TenantID: tenant,
+		Metadata: map[string]interface{}{"name": "auditor", "permissions": []string{"audit:read"}},
+	})
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "AUDIT_TRAIL", line["log_type"])
+	assert.Equal(t, "role.create", line["action"])
+	assert.Equal(t, "notice", line["severity"])
+	assert.Equal(t, tenant.String(), line["tenant_id"])
+	assert.NotContains(t, line, "actor_id", "nil IDs are left out")
+	assert.Equal(t, map[string]interface{}{"name": "auditor", "permissions": []interface{}{"audit:read"}}, line["metadata"])
+}
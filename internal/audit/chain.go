@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+)
+
+// Hash chain (migration 025)
+//
+// Every audit row carries seq, prev_hash and row_hash within the chain of its
+// tenant (the chain key; platform events without tenant share the uuid.Nil
+// chain): row_hash = SHA-256(prev_hash || seq as big-endian int64 || payload).
+// The first row links to 32 zero bytes. Editing a row changes its payload,
+// deleting one leaves a gap in seq; both are found by Verifier.
+
+// chainPayloadVersion prefixes each payload so the encoding can evolve.
+const chainPayloadVersion = "laventecare.audit.v1"
+
+// genesisHash is prev_hash of the first row of every chain.
+var genesisHash = make([]byte, sha256.Size)
+
+// ChainRecord is the hashed content of an audit row. It is also the line
+// format of the AsyncWriter spool.
+type ChainRecord struct {
+	ID        uuid.UUID       `json:"id"`
+	Timestamp time.Time       `json:"timestamp"` // Microsecond precision, as stored by Postgres
+	TenantID  uuid.UUID       `json:"tenant_id"`
+	ActorID   uuid.UUID       `json:"actor_id"`
+	SessionID uuid.UUID       `json:"session_id"`
+	TargetID  uuid.UUID       `json:"target_id"`
+	Action    string          `json:"action"`
+	Metadata  json.RawMessage `json:"metadata"`
+	IP        netip.Addr      `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+}
+
+// ipAddress returns the IP as a nullable INET value.
+func (r ChainRecord) ipAddress() *netip.Addr {
+	if !r.IP.IsValid() {
+		return nil
+	}
+	ip := r.IP
+	return &ip
+}
+
+// ChainRecordFromRow rebuilds the hashed content of a stored row.
+func ChainRecordFromRow(row db.AuditLog) ChainRecord {
+	r := ChainRecord{
+		ID:        row.ID.Bytes,
+		Timestamp: row.Timestamp.Time,
+		TenantID:  row.TenantID.Bytes,
+		ActorID:   row.ActorID.Bytes,
+		SessionID: row.SessionID.Bytes,
+		TargetID:  row.TargetID.Bytes,
+		Action:    row.Action,
+		Metadata:  row.Metadata,
+		UserAgent: row.UserAgent.String,
+		RequestID: row.RequestID.String,
+	}
+	if row.IpAddress != nil {
+		r.IP = *row.IpAddress
+	}
+	return r
+}
+
+// Payload returns the canonical encoding of r: length-prefixed fields, so no
+// value can run into the next one. Metadata is re-encoded because JSONB does
+// not keep key order or whitespace.
+func (r ChainRecord) Payload() ([]byte, error) {
+	metadata, err := canonicalJSON(r.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	var ip string
+	if r.IP.IsValid() {
+		ip = r.IP.Unmap().String()
+	}
+
+	var buf bytes.Buffer
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(r.Timestamp.UnixMicro()))
+	for _, field := range [][]byte{
+		[]byte(chainPayloadVersion),
+		r.ID[:],
+		ts[:],
+		r.TenantID[:],
+		r.ActorID[:],
+		r.SessionID[:],
+		r.TargetID[:],
+		[]byte(r.Action),
+		metadata,
+		[]byte(ip),
+		[]byte(r.UserAgent),
+		[]byte(r.RequestID),
+	} {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(field)))
+		buf.Write(n[:])
+		buf.Write(field)
+	}
+	return buf.Bytes(), nil
+}
+
+// RowHash links payload at position seq to the previous row's hash.
+// Must match the sha256 expression in the CreateAuditLog query.
+func RowHash(prevHash []byte, seq int64, payload []byte) []byte {
+	h := sha256.New()
+	h.Write(prevHash)
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(seq))
+	h.Write(n[:])
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// canonicalJSON re-encodes raw with sorted keys and no insignificant whitespace.
+// Missing metadata encodes as null.
+func canonicalJSON(raw []byte) ([]byte, error) {
+	if len(raw) == 0 {
+		return []byte("null"), nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
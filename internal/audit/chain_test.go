@@ -0,0 +1,259 @@
+package audit_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/netip"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memChain is an in-memory audit.ChainStore holding the rows of one chain.
+type memChain struct {
+	key         uuid.UUID
+	rows        []db.AuditLog
+	head        *db.AuditChainHead
+	checkpoints []db.AuditCheckpoint
+	archives    []db.AuditArchive
+}
+
+// append mirrors the CreateAuditLog query.
+func (m *memChain) append(t *testing.T, record audit.ChainRecord) {
+	payload, err := record.Payload()
+	require.NoError(t, err)
+	seq, prev := int64(1), make([]byte, 32)
+	if m.head != nil {
+		seq, prev = m.head.LastSeq+1, m.head.LastHash
+	}
+	hash := audit.RowHash(prev, seq, payload)
+	ip := record.IP
+	m.rows = append(m.rows, db.AuditLog{
+		ID:        pgtype.UUID{Bytes: record.ID, Valid: true},
+		Timestamp: pgtype.Timestamptz{Time: record.Timestamp, Valid: true},
+		ActorID:   pgtype.UUID{Bytes: record.ActorID, Valid: record.ActorID != uuid.Nil},
+		TenantID:  pgtype.UUID{Bytes: record.TenantID, Valid: record.TenantID != uuid.Nil},
+		Action:    record.Action,
+		Metadata:  record.Metadata,
+		IpAddress: &ip,
+		UserAgent: pgtype.Text{String: record.UserAgent, Valid: record.UserAgent != ""},
+		Seq:       pgtype.Int8{Int64: seq, Valid: true},
+		PrevHash:  prev,
+		RowHash:   hash,
+	})
+	m.head = &db.AuditChainHead{ChainKey: pgtype.UUID{Bytes: m.key, Valid: true}, LastSeq: seq, PrevHash: prev, LastHash: hash}
+}
+
+func (m *memChain) ListAuditChainHeads(ctx context.Context) ([]db.AuditChainHead, error) {
+	if m.head == nil {
+		return nil, nil
+	}
+	return []db.AuditChainHead{*m.head}, nil
+}
+
+func (m *memChain) GetAuditChainHead(ctx context.Context, chainKey pgtype.UUID) (db.AuditChainHead, error) {
+	if m.head == nil || chainKey.Bytes != m.key {
+		return db.AuditChainHead{}, pgx.ErrNoRows
+	}
+	return *m.head, nil
+}
+
+func (m *memChain) ListAuditChainHeadsToCheckpoint(ctx context.Context) ([]db.AuditChainHead, error) {
+	if m.head == nil || (len(m.checkpoints) > 0 && m.checkpoints[len(m.checkpoints)-1].Seq >= m.head.LastSeq) {
+		return nil, nil
+	}
+	return []db.AuditChainHead{*m.head}, nil
+}
+
+func (m *memChain) ListAuditChainRows(ctx context.Context, arg db.ListAuditChainRowsParams) ([]db.AuditLog, error) {
+	sort.Slice(m.rows, func(i, j int) bool { return m.rows[i].Seq.Int64 < m.rows[j].Seq.Int64 })
+	var page []db.AuditLog
+	for _, r := range m.rows {
+		if r.Seq.Int64 > arg.AfterSeq && len(page) < int(arg.RowLimit) {
+			page = append(page, r)
+		}
+	}
+	return page, nil
+}
+
+func (m *memChain) CountUnchainedAuditLogs(ctx context.Context, chainKey pgtype.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (m *memChain) CreateAuditCheckpoint(ctx context.Context, arg db.CreateAuditCheckpointParams) error {
+	m.checkpoints = append(m.checkpoints, db.AuditCheckpoint{ChainKey: arg.ChainKey, Seq: arg.Seq, RowHash: arg.RowHash, Signature: arg.Signature})
+	return nil
+}
+
+func (m *memChain) ListAuditCheckpoints(ctx context.Context, chainKey pgtype.UUID) ([]db.AuditCheckpoint, error) {
+	return m.checkpoints, nil
+}
+
+func (m *memChain) ListDroppedAuditArchives(ctx context.Context, chainKey pgtype.UUID) ([]db.AuditArchive, error) {
+	return m.archives, nil
+}
+
+// archive mirrors the worker: the first n rows move to a dropped archive.
+func (m *memChain) archive(n int) {
+	last := m.rows[n-1]
+	m.archives = append(m.archives, db.AuditArchive{
+		ChainKey:    pgtype.UUID{Bytes: m.key, Valid: true},
+		Rows:        int64(n),
+		ChainedRows: int64(n),
+		FirstSeq:    m.rows[0].Seq,
+		LastSeq:     last.Seq,
+		LastHash:    last.RowHash,
+	})
+	m.rows = m.rows[n:]
+}
+
+func newSigner(t *testing.T) *auth.JWTProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return auth.NewJWTProvider(string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})))
+}
+
+func record(tenantID uuid.UUID, i int) audit.ChainRecord {
+	return audit.ChainRecord{
+		ID:        uuid.New(),
+		Timestamp: time.Date(2026, 3, 1, 12, 0, i, 123456000, time.UTC),
+		TenantID:  tenantID,
+		ActorID:   uuid.New(),
+		Action:    "auth.login",
+		Metadata:  []byte(fmt.Sprintf(`{"attempt":%d,"method":"password"}`, i)),
+		IP:        netip.MustParseAddr("203.0.113.7"),
+		UserAgent: "Mozilla/5.0",
+	}
+}
+
+func problemKinds(r audit.ChainReport) []string {
+	kinds := make([]string, len(r.Problems))
+	for i, p := range r.Problems {
+		kinds[i] = p.Kind
+	}
+	return kinds
+}
+
+func TestChainPayload_JSONBRoundTrip(t *testing.T) {
+	r := record(uuid.New(), 1)
+	want, err := r.Payload()
+	require.NoError(t, err)
+
+	// JSONB reorders keys and reformats whitespace
+	r.Metadata = []byte(`{"method": "password", "attempt": 1}`)
+	got, err := r.Payload()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	r.Action = "auth.logout"
+	changed, err := r.Payload()
+	require.NoError(t, err)
+	assert.NotEqual(t, want, changed)
+}
+
+func TestVerifier(t *testing.T) {
+	signer := newSigner(t)
+	tenantID := uuid.New()
+
+	build := func() *memChain {
+		chain := &memChain{key: tenantID}
+		for i := 1; i <= 5; i++ {
+			chain.append(t, record(tenantID, i))
+		}
+		written, err := audit.NewCheckpointer(chain, signer).Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, written)
+		return chain
+	}
+	verify := func(chain *memChain) audit.ChainReport {
+		report, err := audit.NewVerifier(chain, signer).Verify(context.Background(), tenantID)
+		require.NoError(t, err)
+		return report
+	}
+
+	t.Run("intact", func(t *testing.T) {
+		chain := build()
+		chain.append(t, record(tenantID, 6)) // Past the checkpoint
+		report := verify(chain)
+		assert.True(t, report.OK(), report.Problems)
+		assert.Equal(t, int64(6), report.Rows)
+		assert.Equal(t, 1, report.Checkpoints)
+	})
+
+	t.Run("edited row", func(t *testing.T) {
+		chain := build()
+		chain.rows[2].Metadata = []byte(`{"attempt":99,"method":"password"}`)
+		assert.Equal(t, []string{audit.ProblemModified}, problemKinds(verify(chain)))
+	})
+
+	t.Run("deleted row", func(t *testing.T) {
+		chain := build()
+		chain.rows = append(chain.rows[:1], chain.rows[2:]...)
+		report := verify(chain)
+		assert.Equal(t, []string{audit.ProblemGap}, problemKinds(report))
+		assert.Equal(t, int64(2), report.Problems[0].Seq)
+	})
+
+	t.Run("deleted tail", func(t *testing.T) {
+		chain := build()
+		chain.rows = chain.rows[:4]
+		assert.Contains(t, problemKinds(verify(chain)), audit.ProblemTruncated)
+	})
+
+	t.Run("archived start", func(t *testing.T) {
+		chain := build()
+		chain.archive(3)
+		report := verify(chain)
+		assert.True(t, report.OK(), report.Problems)
+		assert.Equal(t, int64(2), report.Rows)
+		assert.Equal(t, int64(3), report.Archived)
+
+		// A row deleted next to the archive does not pass as archived
+		chain.rows = chain.rows[1:]
+		report = verify(chain)
+		assert.Equal(t, []string{audit.ProblemGap}, problemKinds(report))
+		assert.Equal(t, int64(4), report.Problems[0].Seq)
+	})
+
+	t.Run("rewritten chain", func(t *testing.T) {
+		// Someone with database access edits row 2 and recomputes every hash after it
+		chain := build()
+		records := make([]audit.ChainRecord, 0, 5)
+		for _, row := range chain.rows {
+			records = append(records, audit.ChainRecord{
+				ID: row.ID.Bytes, Timestamp: row.Timestamp.Time, TenantID: row.TenantID.Bytes, ActorID: row.ActorID.Bytes,
+				Action: row.Action, Metadata: row.Metadata, IP: *row.IpAddress, UserAgent: row.UserAgent.String,
+			})
+		}
+		records[1].Action = "auth.logout"
+		chain.rows, chain.head = nil, nil
+		for _, r := range records {
+			chain.append(t, r)
+		}
+		assert.Equal(t, []string{audit.ProblemCheckpointMismatch}, problemKinds(verify(chain)))
+	})
+
+	t.Run("forged checkpoint", func(t *testing.T) {
+		chain := build()
+		// Same statement, signed with another key
+		var claims audit.CheckpointClaims
+		require.NoError(t, signer.ParseClaims(chain.checkpoints[0].Signature, &claims))
+		forged, err := newSigner(t).SignClaims(claims)
+		require.NoError(t, err)
+		chain.checkpoints[0].Signature = forged
+		assert.Equal(t, []string{audit.ProblemCheckpointInvalid}, problemKinds(verify(chain)))
+	})
+}
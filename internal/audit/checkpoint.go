@@ -0,0 +1,356 @@
+package audit
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// checkpointIssuer marks checkpoint tokens, so an access token can never pass as one.
+const checkpointIssuer = "laventecare-audit-checkpoint"
+
+// verifyPageSize is the number of chain rows read per query while verifying.
+const verifyPageSize = 1000
+
+// Signer signs and verifies checkpoints; *auth.JWTProvider implements it with
+// the JWT signing key.
+type Signer interface {
+	SignClaims(claims jwt.Claims) (string, error)
+	ParseClaims(token string, claims jwt.Claims) error
+}
+
+// ChainStore is the database access of Checkpointer and Verifier (*db.Queries).
+type ChainStore interface {
+	ListAuditChainHeads(ctx context.Context) ([]db.AuditChainHead, error)
+	GetAuditChainHead(ctx context.Context, chainKey pgtype.UUID) (db.AuditChainHead, error)
+	ListAuditChainHeadsToCheckpoint(ctx context.Context) ([]db.AuditChainHead, error)
+	ListAuditChainRows(ctx context.Context, arg db.ListAuditChainRowsParams) ([]db.AuditLog, error)
+	CountUnchainedAuditLogs(ctx context.Context, chainKey pgtype.UUID) (int64, error)
+	CreateAuditCheckpoint(ctx context.Context, arg db.CreateAuditCheckpointParams) error
+	ListAuditCheckpoints(ctx context.Context, chainKey pgtype.UUID) ([]db.AuditCheckpoint, error)
+	ListDroppedAuditArchives(ctx context.Context, chainKey pgtype.UUID) ([]db.AuditArchive, error)
+}
+
+// CheckpointClaims is the signed statement "chain X had hash H at seq N".
+type CheckpointClaims struct {
+	ChainKey uuid.UUID `json:"chain"`
+	Seq      int64     `json:"seq"`
+	Hash     string    `json:"hash"` // Hex row_hash
+	jwt.RegisteredClaims
+}
+
+// Checkpointer signs the head of every chain that grew since its last checkpoint.
+// Run by the janitor in cmd/worker.
+type Checkpointer struct {
+	store  ChainStore
+	signer Signer
+}
+
+func NewCheckpointer(store ChainStore, signer Signer) *Checkpointer {
+	return &Checkpointer{store: store, signer: signer}
+}
+
+// Run writes one checkpoint per grown chain and returns how many were written.
+func (c *Checkpointer) Run(ctx context.Context) (int, error) {
+	heads, err := c.store.ListAuditChainHeadsToCheckpoint(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list chain heads: %w", err)
+	}
+
+	written := 0
+	for _, head := range heads {
+		signature, err := c.signer.SignClaims(CheckpointClaims{
+			ChainKey: head.ChainKey.Bytes,
+			Seq:      head.LastSeq,
+			Hash:     hex.EncodeToString(head.LastHash),
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:   checkpointIssuer,
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+			},
+		})
+		if err != nil {
+			return written, fmt.Errorf("sign checkpoint: %w", err)
+		}
+		if err := c.store.CreateAuditCheckpoint(ctx, db.CreateAuditCheckpointParams{
+			ChainKey:  head.ChainKey,
+			Seq:       head.LastSeq,
+			RowHash:   head.LastHash,
+			Signature: signature,
+		}); err != nil {
+			return written, fmt.Errorf("store checkpoint: %w", err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+// Kinds of ChainProblem.
+const (
+	ProblemGap                = "gap"                 // Rows missing inside the chain
+	ProblemBrokenLink         = "broken_link"         // prev_hash differs from the previous row_hash
+	ProblemModified           = "modified"            // row_hash does not match the row content
+	ProblemTruncated          = "truncated"           // Rows missing at the end of the chain
+	ProblemCheckpointInvalid  = "checkpoint_invalid"  // Bad signature, or signed values differ from the row
+	ProblemCheckpointMismatch = "checkpoint_mismatch" // The chain no longer has the signed hash
+)
+
+// ChainProblem is one finding of Verifier.
+type ChainProblem struct {
+	Seq    int64
+	Kind   string
+	Detail string
+}
+
+// ChainReport is the outcome of verifying one chain.
+type ChainReport struct {
+	ChainKey    uuid.UUID // Tenant ID; uuid.Nil for platform events
+	Rows        int64     // Chained rows read
+	Unchained   int64     // Rows from before migration 025, not covered
+	Archived    int64     // Chained rows moved to archives and dropped; see auditarchive
+	LastSeq     int64
+	Checkpoints int
+	Problems    []ChainProblem
+}
+
+// OK reports whether the chain is intact.
+func (r ChainReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *ChainReport) add(seq int64, kind, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, ChainProblem{Seq: seq, Kind: kind, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Verifier walks hash chains and checks them against their signed checkpoints.
+type Verifier struct {
+	store  ChainStore
+	signer Signer
+}
+
+func NewVerifier(store ChainStore, signer Signer) *Verifier {
+	return &Verifier{store: store, signer: signer}
+}
+
+// VerifyAll verifies every chain.
+func (v *Verifier) VerifyAll(ctx context.Context) ([]ChainReport, error) {
+	heads, err := v.store.ListAuditChainHeads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list chain heads: %w", err)
+	}
+	reports := make([]ChainReport, 0, len(heads))
+	for _, head := range heads {
+		report, err := v.Verify(ctx, head.ChainKey.Bytes)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// Verify verifies the chain of one tenant (uuid.Nil: platform events).
+func (v *Verifier) Verify(ctx context.Context, chainKey uuid.UUID) (ChainReport, error) {
+	key := pgtype.UUID{Bytes: chainKey, Valid: true}
+	report := ChainReport{ChainKey: chainKey}
+	var err error
+	if report.Unchained, err = v.store.CountUnchainedAuditLogs(ctx, key); err != nil {
+		return report, fmt.Errorf("count unchained rows: %w", err)
+	}
+
+	// Checkpoints before the head: every checkpoint read is then at or below
+	// the head, unless the head was rolled back.
+	checkpoints, err := v.store.ListAuditCheckpoints(ctx, key)
+	if err != nil {
+		return report, fmt.Errorf("list checkpoints: %w", err)
+	}
+	head, err := v.store.GetAuditChainHead(ctx, key)
+	if errors.Is(err, pgx.ErrNoRows) && len(checkpoints) == 0 {
+		return report, nil // No chained rows yet
+	}
+	headMissing := errors.Is(err, pgx.ErrNoRows)
+	if err != nil && !headMissing {
+		return report, fmt.Errorf("get chain head: %w", err)
+	}
+	if headMissing {
+		// Checkpoints without head: walk whatever rows are left
+		report.add(0, ProblemTruncated, "chain head is missing")
+		head.LastSeq = math.MaxInt64
+	}
+
+	report.Checkpoints = len(checkpoints)
+	signed := make(map[int64][]byte, len(checkpoints)) // seq -> hash stated by a valid signature
+	for _, cp := range checkpoints {
+		var claims CheckpointClaims
+		if err := v.signer.ParseClaims(cp.Signature, &claims); err != nil || claims.Issuer != checkpointIssuer {
+			report.add(cp.Seq, ProblemCheckpointInvalid, "signature does not verify")
+			continue
+		}
+		hash, err := hex.DecodeString(claims.Hash)
+		if err != nil || claims.ChainKey != chainKey || claims.Seq != cp.Seq || !bytes.Equal(hash, cp.RowHash) {
+			report.add(cp.Seq, ProblemCheckpointInvalid, "signed values differ from the stored checkpoint")
+			continue
+		}
+		signed[cp.Seq] = hash
+	}
+
+	dropped, err := v.store.ListDroppedAuditArchives(ctx, key)
+	if err != nil {
+		return report, fmt.Errorf("list archives: %w", err)
+	}
+	archived := newArchivedSpans(dropped)
+
+	// Rows appended after the head was read are left for the next run
+	expected, prev := int64(1), genesisHash
+walk:
+	for {
+		rows, err := v.store.ListAuditChainRows(ctx, db.ListAuditChainRowsParams{
+			ChainKey: key,
+			AfterSeq: expected - 1,
+			RowLimit: verifyPageSize,
+		})
+		if err != nil {
+			return report, fmt.Errorf("list chain rows: %w", err)
+		}
+		for _, row := range rows {
+			seq := row.Seq.Int64
+			if seq > head.LastSeq {
+				break walk
+			}
+			report.Rows++
+			if seq > expected {
+				n, holes := archived.cover(expected, seq-1)
+				report.Archived += n
+				for _, h := range holes {
+					report.add(h[0], ProblemGap, "rows %d-%d are missing", h[0], h[1])
+				}
+				if last, ok := archived.boundary[seq-1]; ok && !bytes.Equal(row.PrevHash, last) {
+					report.add(seq, ProblemBrokenLink, "prev_hash does not match archived row %d", seq-1)
+				}
+			} else if !bytes.Equal(row.PrevHash, prev) {
+				report.add(seq, ProblemBrokenLink, "prev_hash does not match row %d", seq-1)
+			}
+
+			payload, err := ChainRecordFromRow(row).Payload()
+			if err != nil || !bytes.Equal(RowHash(row.PrevHash, seq, payload), row.RowHash) {
+				report.add(seq, ProblemModified, "content of row %s does not match its hash", uuid.UUID(row.ID.Bytes))
+			}
+			if hash, ok := signed[seq]; ok && !bytes.Equal(hash, row.RowHash) {
+				report.add(seq, ProblemCheckpointMismatch, "row hash differs from the checkpoint")
+			}
+			delete(signed, seq)
+
+			expected, prev = seq+1, row.RowHash
+			report.LastSeq = seq
+		}
+		if len(rows) < verifyPageSize {
+			break
+		}
+	}
+
+	switch {
+	case headMissing:
+	case report.LastSeq < head.LastSeq:
+		// The newest rows can only be archived when the chain stopped growing
+		n, holes := archived.cover(report.LastSeq+1, head.LastSeq)
+		report.Archived += n
+		for _, h := range holes {
+			report.add(h[0], ProblemTruncated, "rows %d-%d are missing", h[0], h[1])
+		}
+		if last, ok := archived.boundary[head.LastSeq]; ok && len(holes) == 0 && !bytes.Equal(last, head.LastHash) {
+			report.add(head.LastSeq, ProblemModified, "archived last row hash differs from the chain head")
+		}
+	case !bytes.Equal(prev, head.LastHash):
+		report.add(report.LastSeq, ProblemModified, "last row hash differs from the chain head")
+	}
+	if report.Archived != archived.rows && !headMissing {
+		// Rows deleted inside an archived range would otherwise pass as archived
+		report.add(0, ProblemGap, "archives hold %d chained rows, %d are missing", archived.rows, report.Archived)
+	}
+	missing := make([]int64, 0, len(signed))
+	for seq := range signed {
+		missing = append(missing, seq)
+	}
+	slices.Sort(missing)
+	for _, seq := range missing {
+		if !archived.contains(seq) {
+			report.add(seq, ProblemCheckpointMismatch, "checkpointed row is missing")
+		} else if last, ok := archived.boundary[seq]; ok && !bytes.Equal(last, signed[seq]) {
+			// Only an archive boundary still has a hash to compare with
+			report.add(seq, ProblemCheckpointMismatch, "archived row hash differs from the checkpoint")
+		}
+	}
+	return report, nil
+}
+
+// archivedSpans are the seq ranges of a chain's dropped archives.
+type archivedSpans struct {
+	spans    [][2]int64       // Merged, ascending
+	boundary map[int64][]byte // last_seq -> last_hash of each archive
+	rows     int64            // Chained rows in all archives
+}
+
+func newArchivedSpans(archives []db.AuditArchive) archivedSpans {
+	a := archivedSpans{boundary: make(map[int64][]byte, len(archives))}
+	for _, archive := range archives {
+		if !archive.FirstSeq.Valid {
+			continue // Only unchained rows
+		}
+		a.rows += archive.ChainedRows
+		a.boundary[archive.LastSeq.Int64] = archive.LastHash
+		a.spans = append(a.spans, [2]int64{archive.FirstSeq.Int64, archive.LastSeq.Int64})
+	}
+	slices.SortFunc(a.spans, func(x, y [2]int64) int { return cmp.Compare(x[0], y[0]) })
+	merged := a.spans[:0]
+	for _, s := range a.spans {
+		if n := len(merged); n > 0 && s[0] <= merged[n-1][1]+1 {
+			merged[n-1][1] = max(merged[n-1][1], s[1])
+			continue
+		}
+		merged = append(merged, s)
+	}
+	a.spans = merged
+	return a
+}
+
+func (a archivedSpans) contains(seq int64) bool {
+	for _, s := range a.spans {
+		if seq >= s[0] && seq <= s[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// cover splits the missing rows from-to into the number inside archives and
+// the ranges outside them.
+func (a archivedSpans) cover(from, to int64) (int64, [][2]int64) {
+	var covered int64
+	var holes [][2]int64
+	for _, s := range a.spans {
+		if s[1] < from || s[0] > to {
+			continue
+		}
+		if s[0] > from {
+			holes = append(holes, [2]int64{from, s[0] - 1})
+		}
+		end := min(s[1], to)
+		covered += end - max(s[0], from) + 1
+		from = end + 1
+	}
+	if from <= to {
+		holes = append(holes, [2]int64{from, to})
+	}
+	return covered, holes
+}
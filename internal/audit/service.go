@@ -3,16 +3,41 @@ package audit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"time"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// AuditService defines the interface for recording security events.
-type AuditService interface {
-	Log(ctx context.Context, action string, params LogParams)
+// AuditService records security events. It is the single entry point for
+// audit events: Log checks the event against the Catalogue, builds the record
+// once and hands it to every sink (database, JSON stdout, ...). Tenant SIEM
+// streams (package auditsink) read the database sink's hash chain, so they see
+// exactly what was stored.
+type AuditService struct {
+	sinks  []Sink
+	logger *slog.Logger
+}
+
+// NewAuditService fans out to sinks in order.
+func NewAuditService(logger *slog.Logger, sinks ...Sink) *AuditService {
+	return &AuditService{sinks: sinks, logger: logger}
+}
+
+// Sink receives every recorded event. Write must not block on slow I/O for
+// long: it runs on the caller's request path.
+type Sink interface {
+	Write(ctx context.Context, entry Entry)
+}
+
+// Entry is a recorded event as handed to sinks.
+type Entry struct {
+	ChainRecord
+	Severity Severity
 }
 
 // LogParams encapsulates optional fields for an audit log.
@@ -24,69 +49,149 @@ type LogParams struct {
 	Metadata  map[string]interface{}
 }
 
-// DBLogger implements AuditService using the PostgreSQL database.
-type DBLogger struct {
+// Log records an event. Unregistered events and missing required metadata are
+// reported but still recorded: an audit trail with a flaw beats none.
+func (s *AuditService) Log(ctx context.Context, event Event, params LogParams) {
+	def, ok := Lookup(event)
+	if !ok {
+		s.logger.Error("audit_event_unregistered", "action", string(event))
+		def = EventDef{Name: event, Severity: SeverityWarning}
+	} else if missing := def.missing(params.Metadata); len(missing) > 0 {
+		s.logger.Warn("audit_metadata_missing", "action", string(event), "keys", missing)
+	}
+
+	entry := Entry{ChainRecord: newRecord(ctx, string(event), params, s.logger), Severity: def.Severity}
+	for _, sink := range s.sinks {
+		sink.Write(ctx, entry)
+	}
+}
+
+// Close flushes the sinks that buffer (AsyncWriter). Call it on shutdown.
+func (s *AuditService) Close(ctx context.Context) error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if c, ok := sink.(interface{ Close(context.Context) error }); ok {
+			errs = append(errs, c.Close(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type impersonatorKey struct{}
+
+// WithImpersonator marks ctx as acting on behalf of a platform operator.
+// Set by AuthMiddleware for tokens carrying an act claim.
+func WithImpersonator(ctx context.Context, platformAdminID uuid.UUID) context.Context {
+	return context.WithValue(ctx, impersonatorKey{}, platformAdminID)
+}
+
+// ImpersonatorFrom returns the platform operator behind an impersonated request.
+func ImpersonatorFrom(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(impersonatorKey{}).(uuid.UUID)
+	return id, ok
+}
+
+// DBSink writes each event synchronously, one insert per event. The API uses
+// AsyncWriter instead; DBSink serves the worker and tools, where latency does
+// not matter and there is no shutdown flush.
+type DBSink struct {
 	queries *db.Queries
 	logger  *slog.Logger
 }
 
-func NewDBLogger(queries *db.Queries, logger *slog.Logger) *DBLogger {
-	return &DBLogger{
+func NewDBSink(queries *db.Queries, logger *slog.Logger) *DBSink {
+	return &DBSink{
 		queries: queries,
 		logger:  logger,
 	}
 }
 
-// Log records an event.
-// Design Decision: We execute purely synchronously for CRITICAL events for now (MVP).
-// In high-scale, this should push to a channel/queue.
-func (s *DBLogger) Log(ctx context.Context, action string, params LogParams) {
-	// 1. Extract Request Context (IP, UserAgent, RequestID)
-	// This relies on Middleware populating the context?
-	// Or we require params to pass them?
-	// Decision: Extract from Context if available, else generic.
-	// But `audit` package shouldn't depend on `middleware` package to avoid cycles.
-	// We will rely on simple context keys or generic value extraction if keys were exported in a shared `pkg/context` or similar.
-	// For now, we will assume params are passed or extracted here if we decide to move keys to shared pkg.
-	// Actually, `middleware.GetIP(ctx)` would be circular if middleware imports audit.
-	// Let's keep it simple: We won't deep-inspect context here. We assume context has what we need OR we just log what we have.
-	// Wait, we need IP/UA/ReqID.
-	// We'll define keys in specific package or just assume they are not here for MVP unless added to params.
-	// Refined: We will add IP/UA/ReqID to LogParams for explicit passing to avoid "Magic Context".
+func (s *DBSink) Write(ctx context.Context, entry Entry) {
+	if err := insertRecord(ctx, s.queries, entry.ChainRecord); err != nil {
+		// Fallback: Log to Stdout so we don't lose the event entirely
+		s.logger.Error("audit_db_insert_failed",
+			"action", entry.Action,
+			"error", err,
+			"actor", entry.ActorID,
+			"request_id", entry.RequestID,
+		)
+	}
+}
 
-	metadataBytes, err := json.Marshal(params.Metadata)
-	if err != nil {
-		s.logger.Error("audit_metadata_marshal_failed", "error", err)
-		metadataBytes = []byte("{}")
+// newRecord builds the row for an event.
+//
+// Request ID, IP and user agent come from requestmeta; actor and tenant fall
+// back to the authenticated request when the caller leaves them empty. ID and
+// timestamp are chosen here because they are part of the chained hash.
+func newRecord(ctx context.Context, action string, params LogParams, logger *slog.Logger) ChainRecord {
+	meta, _ := requestmeta.From(ctx)
+	if params.ActorID == uuid.Nil {
+		params.ActorID = meta.ActorID
+	}
+	if params.TenantID == uuid.Nil {
+		params.TenantID = meta.TenantID
 	}
 
-	// Helper to convert UUID to pgtype or Null
-	toUUID := func(u uuid.UUID) pgtype.UUID {
-		return pgtype.UUID{Bytes: u, Valid: u != uuid.Nil}
+	// Impersonated sessions: record the platform operator behind the actor.
+	if impersonator, ok := ImpersonatorFrom(ctx); ok {
+		metadata := make(map[string]interface{}, len(params.Metadata)+1)
+		for k, v := range params.Metadata {
+			metadata[k] = v
+		}
+		metadata["impersonated_by"] = impersonator.String()
+		params.Metadata = metadata
 	}
 
-	// Get IP/Info from context?
-	// We'll implement a `ExtractContextInfo` helper if we move keys to `pkg`.
-	// For now, we omit IP/UA unless passed.
+	metadataBytes, err := json.Marshal(params.Metadata)
+	if err != nil {
+		logger.Error("audit_metadata_marshal_failed", "error", err)
+		metadataBytes = []byte("{}")
+	}
 
-	err = s.queries.CreateAuditLog(ctx, db.CreateAuditLogParams{
-		ActorID:   toUUID(params.ActorID),
-		SessionID: toUUID(params.SessionID),
-		TenantID:  toUUID(params.TenantID),
+	return ChainRecord{
+		ID:        uuid.New(),
+		Timestamp: time.Now().UTC().Truncate(time.Microsecond),
+		TenantID:  params.TenantID,
+		ActorID:   params.ActorID,
+		SessionID: params.SessionID,
+		TargetID:  params.TargetID,
 		Action:    action,
-		TargetID:  toUUID(params.TargetID),
 		Metadata:  metadataBytes,
-		// IpAddress: ... (Need to add to Params or Context)
-		// UserAgent: ...
-		// RequestId: ...
-	})
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		RequestID: meta.RequestID,
+	}
+}
 
+// insertRecord appends one record to its tenant's hash chain (see chain.go).
+func insertRecord(ctx context.Context, q *db.Queries, record ChainRecord) error {
+	payload, err := record.Payload()
 	if err != nil {
-		// Fallback: Log to Stdout so we don't lose the event entirely
-		s.logger.Error("audit_db_insert_failed",
-			"action", action,
-			"error", err,
-			"actor", params.ActorID,
-		)
+		return err
 	}
+	return q.CreateAuditLog(ctx, db.CreateAuditLogParams{
+		ChainKey:  pgtype.UUID{Bytes: record.TenantID, Valid: true},
+		Payload:   payload,
+		ID:        toUUID(record.ID),
+		Timestamp: pgtype.Timestamptz{Time: record.Timestamp, Valid: true},
+		ActorID:   toUUID(record.ActorID),
+		SessionID: toUUID(record.SessionID),
+		TenantID:  toUUID(record.TenantID),
+		Action:    record.Action,
+		TargetID:  toUUID(record.TargetID),
+		Metadata:  record.Metadata,
+		IpAddress: record.ipAddress(),
+		UserAgent: toText(record.UserAgent),
+		RequestID: toText(record.RequestID),
+	})
+}
+
+// toUUID converts a UUID to pgtype, with uuid.Nil as NULL.
+func toUUID(u uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: u, Valid: u != uuid.Nil}
+}
+
+// toText converts a string to pgtype, with "" as NULL.
+func toText(v string) pgtype.Text {
+	return pgtype.Text{String: v, Valid: v != ""}
 }
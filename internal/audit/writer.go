@@ -0,0 +1,642 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Asynchronous audit pipeline
+//
+// AsyncWriter takes audit writes off the request path. AuditService.Log builds
+// the record (ID, timestamp and request metadata are fixed at that moment) and
+// Write queues it; a single goroutine writes the queue in batches with COPY. The hash chain is
+// kept by locking each tenant's chain head in the batch transaction and
+// computing seq and hashes here, exactly as CreateAuditLog does in SQL.
+//
+// Events that cannot be queued (per Backpressure) or written go to an
+// append-only NDJSON spool on local disk, which is replayed when the database
+// is back. Replayed events keep their original ID and timestamp but are
+// chained after the events written in the meantime.
+//
+// A failed batch is retried record by record, so one record the database
+// rejects cannot hold back the others. Rejected records are moved to a
+// quarantine file next to the spool and left for an operator.
+
+// Backpressure decides what Log does when the queue is full.
+type Backpressure string
+
+const (
+	BackpressureBlock Backpressure = "block" // Wait up to EnqueueTimeout for room, then spool
+	BackpressureSpool Backpressure = "spool" // Spool at once
+	BackpressureDrop  Backpressure = "drop"  // Drop the event (counted and logged)
+)
+
+// ParseBackpressure parses AUDIT_BACKPRESSURE; empty means BackpressureBlock.
+func ParseBackpressure(s string) (Backpressure, error) {
+	switch b := Backpressure(s); b {
+	case "":
+		return BackpressureBlock, nil
+	case BackpressureBlock, BackpressureSpool, BackpressureDrop:
+		return b, nil
+	}
+	return "", fmt.Errorf("unknown audit backpressure policy %q", s)
+}
+
+// writeTimeout bounds one batch transaction.
+const writeTimeout = 10 * time.Second
+
+// maxSpoolLine is the longest spool line replay accepts.
+const maxSpoolLine = 1 << 20
+
+// errBadRecord marks a record that cannot be written however often it is retried.
+var errBadRecord = errors.New("audit record cannot be written")
+
+// WriterConfig tunes AsyncWriter; zero values take the defaults.
+type WriterConfig struct {
+	QueueSize      int           // Events buffered in memory (default 4096)
+	BatchSize      int           // Events per COPY (default 500)
+	FlushInterval  time.Duration // Longest wait for a partial batch (default 250ms)
+	Backpressure   Backpressure  // Default BackpressureBlock
+	EnqueueTimeout time.Duration // BackpressureBlock only (default 100ms)
+	SpoolPath      string        // NDJSON spool; empty disables it and events are dropped instead
+	ReplayInterval time.Duration // How often the spool is retried (default 30s)
+}
+
+func (c WriterConfig) withDefaults() WriterConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = 4096
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 500
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 250 * time.Millisecond
+	}
+	if c.Backpressure == "" {
+		c.Backpressure = BackpressureBlock
+	}
+	if c.EnqueueTimeout <= 0 {
+		c.EnqueueTimeout = 100 * time.Millisecond
+	}
+	if c.ReplayInterval <= 0 {
+		c.ReplayInterval = 30 * time.Second
+	}
+	return c
+}
+
+// WriterStats are the pipeline metrics of one process.
+type WriterStats struct {
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Written       uint64 `json:"written"`        // Events written from the queue
+	Spooled       uint64 `json:"spooled"`        // Events written to the spool
+	Replayed      uint64 `json:"replayed"`       // Spooled events written to the database
+	Dropped       uint64 `json:"dropped"`        // Events lost (only in the process log)
+	Quarantined   uint64 `json:"quarantined"`    // Events the database rejected, kept in the quarantine file
+	FailedBatches uint64 `json:"failed_batches"` // Batch transactions that failed
+	SpoolBytes    int64  `json:"spool_bytes"`    // Spool waiting for replay
+}
+
+// batchStore writes batches of records; pgBatchStore outside tests.
+type batchStore interface {
+	insert(ctx context.Context, records []ChainRecord) error
+	existing(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+// AsyncWriter is the database Sink of the API: a bounded queue, batched inserts
+// and a durable spool. Close must be called on shutdown.
+type AsyncWriter struct {
+	store  batchStore
+	cfg    WriterConfig
+	logger *slog.Logger
+	queue  chan ChainRecord
+
+	mu      sync.RWMutex // closed vs. sends on queue
+	closed  bool
+	spoolMu sync.Mutex // Spool file and its .replay and .quarantine siblings
+
+	ctx    context.Context // Cancelled when Close runs out of time
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	written       atomic.Uint64
+	spooled       atomic.Uint64
+	replayed      atomic.Uint64
+	dropped       atomic.Uint64
+	quarantined   atomic.Uint64
+	failedBatches atomic.Uint64
+}
+
+func NewAsyncWriter(pool *pgxpool.Pool, cfg WriterConfig, logger *slog.Logger) *AsyncWriter {
+	return newAsyncWriter(&pgBatchStore{pool: pool}, cfg, logger)
+}
+
+func newAsyncWriter(store batchStore, cfg WriterConfig, logger *slog.Logger) *AsyncWriter {
+	cfg = cfg.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &AsyncWriter{
+		store:  store,
+		cfg:    cfg,
+		logger: logger,
+		queue:  make(chan ChainRecord, cfg.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues an event. It never waits on the database; with BackpressureBlock
+// it waits at most EnqueueTimeout for room in the queue.
+func (w *AsyncWriter) Write(ctx context.Context, entry Entry) {
+	w.enqueue(entry.ChainRecord)
+}
+
+func (w *AsyncWriter) enqueue(record ChainRecord) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.overflow("writer_closed", record)
+		return
+	}
+
+	select {
+	case w.queue <- record:
+		return
+	default:
+	}
+	switch w.cfg.Backpressure {
+	case BackpressureDrop:
+		w.drop("queue_full", record)
+		return
+	case BackpressureBlock:
+		timer := time.NewTimer(w.cfg.EnqueueTimeout)
+		defer timer.Stop()
+		select {
+		case w.queue <- record:
+			return
+		case <-timer.C:
+		}
+	}
+	w.overflow("queue_full", record)
+}
+
+// Close stops accepting events and writes what is queued. Whatever is still
+// unwritten when ctx ends goes to the spool. Events written after Close are
+// spooled directly.
+func (w *AsyncWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.cancel() // Remaining batches fail at once and are spooled
+		<-w.done
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current metrics.
+func (w *AsyncWriter) Stats() WriterStats {
+	stats := WriterStats{
+		QueueDepth:    len(w.queue),
+		QueueCapacity: cap(w.queue),
+		Written:       w.written.Load(),
+		Spooled:       w.spooled.Load(),
+		Replayed:      w.replayed.Load(),
+		Dropped:       w.dropped.Load(),
+		Quarantined:   w.quarantined.Load(),
+		FailedBatches: w.failedBatches.Load(),
+	}
+	if w.cfg.SpoolPath != "" {
+		for _, path := range []string{w.cfg.SpoolPath, w.replayPath()} {
+			if info, err := os.Stat(path); err == nil {
+				stats.SpoolBytes += info.Size()
+			}
+		}
+	}
+	return stats
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	flushTicker := time.NewTicker(w.cfg.FlushInterval)
+	defer flushTicker.Stop()
+	replayTicker := time.NewTicker(w.cfg.ReplayInterval)
+	defer replayTicker.Stop()
+
+	batch := make([]ChainRecord, 0, w.cfg.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			w.writeBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case record, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-flushTicker.C:
+			flush()
+		case <-replayTicker.C:
+			flush()
+			w.replay()
+		}
+	}
+}
+
+func (w *AsyncWriter) writeBatch(batch []ChainRecord) {
+	ctx, cancel := context.WithTimeout(w.ctx, writeTimeout)
+	defer cancel()
+	err := w.store.insert(ctx, batch)
+	if err == nil {
+		w.written.Add(uint64(len(batch)))
+		return
+	}
+	w.failedBatches.Add(1)
+	w.logger.Error("audit_batch_insert_failed", "events", len(batch), "error", err)
+
+	n, pending, err := w.insertEach(batch)
+	w.written.Add(uint64(n))
+	if err != nil {
+		w.overflow("insert_failed", pending...)
+	}
+}
+
+// insertEach writes records one at a time after their batch failed.
+// Records the database rejects are quarantined. At the first other error
+// (the database is gone) it stops and returns the records not yet written.
+func (w *AsyncWriter) insertEach(records []ChainRecord) (int, []ChainRecord, error) {
+	written := 0
+	for i, r := range records {
+		ctx, cancel := context.WithTimeout(w.ctx, writeTimeout)
+		err := w.store.insert(ctx, []ChainRecord{r})
+		cancel()
+		switch {
+		case err == nil:
+			written++
+		case recordRejected(err):
+			w.quarantine(r, err)
+		default:
+			return written, records[i:], err
+		}
+	}
+	return written, nil, nil
+}
+
+// recordRejected reports whether err is about the record itself: its payload
+// cannot be hashed, or Postgres refused its data (SQLSTATE class 22 or 23).
+// Anything else may succeed on a later attempt.
+func recordRejected(err error) bool {
+	if errors.Is(err, errBadRecord) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23"))
+}
+
+// quarantinePath holds records the database rejected.
+func (w *AsyncWriter) quarantinePath() string {
+	return w.cfg.SpoolPath + ".quarantine"
+}
+
+// quarantine sets a rejected record aside; without a spool it is dropped.
+func (w *AsyncWriter) quarantine(record ChainRecord, cause error) {
+	if w.cfg.SpoolPath == "" {
+		w.drop("rejected", record)
+		return
+	}
+	if err := w.appendRecords(w.quarantinePath(), []ChainRecord{record}); err != nil {
+		w.logger.Error("audit_quarantine_write_failed", "error", err)
+		w.drop("rejected", record)
+		return
+	}
+	w.quarantined.Add(1)
+	w.logger.Error("audit_event_quarantined",
+		"id", record.ID,
+		"action", record.Action,
+		"tenant", record.TenantID,
+		"error", cause,
+	)
+}
+
+// overflow spools records that could not be queued or written; without a
+// usable spool they are dropped.
+func (w *AsyncWriter) overflow(reason string, records ...ChainRecord) {
+	if w.cfg.SpoolPath != "" {
+		err := w.spool(records)
+		if err == nil {
+			w.spooled.Add(uint64(len(records)))
+			return
+		}
+		w.logger.Error("audit_spool_write_failed", "error", err)
+	}
+	w.drop(reason, records...)
+}
+
+func (w *AsyncWriter) drop(reason string, records ...ChainRecord) {
+	w.dropped.Add(uint64(len(records)))
+	for _, r := range records {
+		// Last resort, like DBLogger: the event survives in the process log
+		w.logger.Error("audit_event_dropped",
+			"reason", reason,
+			"action", r.Action,
+			"actor", r.ActorID,
+			"tenant", r.TenantID,
+			"request_id", r.RequestID,
+		)
+	}
+}
+
+// spool appends records to the spool file and syncs it to disk.
+func (w *AsyncWriter) spool(records []ChainRecord) error {
+	return w.appendRecords(w.cfg.SpoolPath, records)
+}
+
+// appendRecords appends records to the NDJSON file at path and syncs it.
+func (w *AsyncWriter) appendRecords(path string, records []ChainRecord) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	w.spoolMu.Lock()
+	defer w.spoolMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replayPath holds the spool while it is being replayed.
+func (w *AsyncWriter) replayPath() string {
+	return w.cfg.SpoolPath + ".replay"
+}
+
+// replay writes the spool to the database. The spool is moved aside first, so
+// events spooled meanwhile land in a fresh file; batches that fail again are
+// appended back to it, rejected records are quarantined. A .replay file left
+// by a crash is replayed before the spool is touched; rows it already wrote are
+// skipped by ID.
+func (w *AsyncWriter) replay() {
+	if w.cfg.SpoolPath == "" {
+		return
+	}
+	pending := w.replayPath()
+
+	w.spoolMu.Lock()
+	if _, err := os.Stat(pending); errors.Is(err, fs.ErrNotExist) {
+		err = os.Rename(w.cfg.SpoolPath, pending)
+		if err != nil {
+			w.spoolMu.Unlock()
+			if !errors.Is(err, fs.ErrNotExist) {
+				w.logger.Error("audit_spool_rotate_failed", "error", err)
+			}
+			return // Nothing spooled
+		}
+	}
+	w.spoolMu.Unlock()
+
+	f, err := os.Open(pending)
+	if err != nil {
+		w.logger.Error("audit_spool_open_failed", "error", err)
+		return
+	}
+	defer f.Close()
+
+	var (
+		batch     []ChainRecord
+		replayed  int
+		failed    error
+		keepSpool bool // Some records are in neither the database nor the new spool
+	)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if failed == nil {
+			n, pending, err := w.replayBatch(batch)
+			replayed += n
+			if err == nil {
+				batch = batch[:0]
+				return
+			}
+			failed = err
+			batch = pending
+		}
+		if err := w.spool(batch); err != nil {
+			w.logger.Error("audit_spool_write_failed", "error", err)
+			keepSpool = true
+		}
+		batch = batch[:0]
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxSpoolLine)
+	for scanner.Scan() {
+		var record ChainRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A torn last line from a crash mid-write
+			w.dropped.Add(1)
+			w.logger.Error("audit_spool_line_corrupt", "error", err)
+			continue
+		}
+		batch = append(batch, record)
+		if len(batch) >= w.cfg.BatchSize {
+			flush()
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		w.logger.Error("audit_spool_read_failed", "error", err)
+		keepSpool = true
+	}
+
+	w.replayed.Add(uint64(replayed))
+	if failed != nil {
+		w.logger.Warn("audit_spool_replay_failed", "replayed", replayed, "error", failed)
+	} else if replayed > 0 {
+		w.logger.Info("audit_spool_replayed", "events", replayed)
+	}
+	if !keepSpool {
+		if err := os.Remove(pending); err != nil {
+			w.logger.Error("audit_spool_remove_failed", "error", err)
+		}
+	}
+}
+
+// replayBatch writes the records of batch that are not in the database yet.
+// On error it also returns the records that still have to be spooled.
+func (w *AsyncWriter) replayBatch(batch []ChainRecord) (int, []ChainRecord, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, writeTimeout)
+	defer cancel()
+
+	ids := make([]uuid.UUID, len(batch))
+	for i, r := range batch {
+		ids[i] = r.ID
+	}
+	existing, err := w.store.existing(ctx, ids)
+	if err != nil {
+		return 0, batch, err
+	}
+	fresh := make([]ChainRecord, 0, len(batch))
+	for _, r := range batch {
+		if !existing[r.ID] {
+			fresh = append(fresh, r)
+		}
+	}
+	if len(fresh) == 0 {
+		return 0, nil, nil
+	}
+	if err := w.store.insert(ctx, fresh); err != nil {
+		w.logger.Warn("audit_spool_batch_failed", "events", len(fresh), "error", err)
+		return w.insertEach(fresh)
+	}
+	return len(fresh), nil, nil
+}
+
+// chainRows assigns seq, prev_hash and row_hash to records, continuing the
+// chain from head. It returns the rows and the new head.
+func chainRows(head db.AuditChainHead, records []ChainRecord) ([]db.CreateAuditLogsParams, db.AdvanceAuditChainHeadParams, error) {
+	rows := make([]db.CreateAuditLogsParams, 0, len(records))
+	seq, prev := head.LastSeq, head.LastHash
+	next := db.AdvanceAuditChainHeadParams{ChainKey: head.ChainKey}
+	for _, r := range records {
+		payload, err := r.Payload()
+		if err != nil {
+			return nil, next, fmt.Errorf("%w: payload of %s: %w", errBadRecord, r.ID, err)
+		}
+		seq++
+		hash := RowHash(prev, seq, payload)
+		rows = append(rows, db.CreateAuditLogsParams{
+			ID:        toUUID(r.ID),
+			Timestamp: pgtype.Timestamptz{Time: r.Timestamp, Valid: true},
+			ActorID:   toUUID(r.ActorID),
+			SessionID: toUUID(r.SessionID),
+			TenantID:  toUUID(r.TenantID),
+			Action:    r.Action,
+			TargetID:  toUUID(r.TargetID),
+			Metadata:  r.Metadata,
+			IpAddress: r.ipAddress(),
+			UserAgent: toText(r.UserAgent),
+			RequestID: toText(r.RequestID),
+			Seq:       pgtype.Int8{Int64: seq, Valid: true},
+			PrevHash:  prev,
+			RowHash:   hash,
+		})
+		next.PrevHash, prev = prev, hash
+	}
+	next.LastSeq, next.LastHash = seq, prev
+	return rows, next, nil
+}
+
+// pgBatchStore writes batches to Postgres.
+type pgBatchStore struct {
+	pool *pgxpool.Pool
+}
+
+// insert writes records in one transaction: per chain the head is locked, the
+// rows are chained onto it and everything is copied in at the end. Heads are
+// locked in key order, so concurrent writers (other replicas) cannot deadlock.
+// COPY requires that RLS does not apply to the connection role (audit_logs
+// is not FORCE ROW LEVEL SECURITY), like all audit writes.
+func (s *pgBatchStore) insert(ctx context.Context, records []ChainRecord) error {
+	chains := make(map[uuid.UUID][]ChainRecord)
+	for _, r := range records {
+		chains[r.TenantID] = append(chains[r.TenantID], r)
+	}
+	keys := make([]uuid.UUID, 0, len(chains))
+	for key := range chains {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+
+	return storage.WithoutRLS(ctx, s.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		rows := make([]db.CreateAuditLogsParams, 0, len(records))
+		for _, key := range keys {
+			head, err := q.LockAuditChainHead(ctx, pgtype.UUID{Bytes: key, Valid: true})
+			if err != nil {
+				return fmt.Errorf("lock chain head: %w", err)
+			}
+			chained, next, err := chainRows(head, chains[key])
+			if err != nil {
+				return err
+			}
+			if err := q.AdvanceAuditChainHead(ctx, next); err != nil {
+				return fmt.Errorf("advance chain head: %w", err)
+			}
+			rows = append(rows, chained...)
+		}
+		if _, err := q.CreateAuditLogs(ctx, rows); err != nil {
+			return fmt.Errorf("copy audit rows: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *pgBatchStore) existing(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	params := make([]pgtype.UUID, len(ids))
+	for i, id := range ids {
+		params[i] = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	found, err := db.New(s.pool).ListAuditLogIDs(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		existing[id.Bytes] = true
+	}
+	return existing, nil
+}
@@ -0,0 +1,250 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBatchStore records inserted batches; err makes inserts fail, a batch
+// holding a rejected ID fails like a bad column value and gate, when set, holds
+// every insert until it is closed.
+type fakeBatchStore struct {
+	mu       sync.Mutex
+	rows     []ChainRecord
+	err      error
+	rejected map[uuid.UUID]bool
+	entered  chan struct{}
+	gate     chan struct{}
+}
+
+func (s *fakeBatchStore) insert(ctx context.Context, records []ChainRecord) error {
+	if s.gate != nil {
+		s.entered <- struct{}{}
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	for _, r := range records {
+		if s.rejected[r.ID] {
+			return &pgconn.PgError{Code: "22P02", Message: "invalid input syntax"}
+		}
+	}
+	s.rows = append(s.rows, records...)
+	return nil
+}
+
+func (s *fakeBatchStore) existing(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	existing := make(map[uuid.UUID]bool)
+	for _, r := range s.rows {
+		existing[r.ID] = true
+	}
+	return existing, nil
+}
+
+func (s *fakeBatchStore) inserted() []ChainRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ChainRecord(nil), s.rows...)
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// logTo records an event through an AuditService writing to sink only.
+func logTo(sink Sink, event Event, params LogParams) {
+	NewAuditService(discardLogger(), sink).Log(context.Background(), event, params)
+}
+
+func TestChainRows(t *testing.T) {
+	tenantID := uuid.New()
+	records := make([]ChainRecord, 4)
+	for i := range records {
+		records[i] = ChainRecord{ID: uuid.New(), Timestamp: time.Now().UTC().Truncate(time.Microsecond), TenantID: tenantID, Action: "auth.login", Metadata: []byte(`{}`)}
+	}
+	key := pgtype.UUID{Bytes: tenantID, Valid: true}
+
+	// A new chain (LockAuditChainHead created it at seq 0), then a second batch on top
+	first, head, err := chainRows(db.AuditChainHead{ChainKey: key, LastHash: genesisHash, PrevHash: genesisHash}, records[:2])
+	require.NoError(t, err)
+	second, head, err := chainRows(db.AuditChainHead{ChainKey: key, LastSeq: head.LastSeq, PrevHash: head.PrevHash, LastHash: head.LastHash}, records[2:])
+	require.NoError(t, err)
+
+	prev := genesisHash
+	for i, row := range append(first, second...) {
+		payload, err := records[i].Payload()
+		require.NoError(t, err)
+		assert.Equal(t, int64(i+1), row.Seq.Int64)
+		assert.Equal(t, prev, row.PrevHash)
+		assert.Equal(t, RowHash(prev, int64(i+1), payload), row.RowHash)
+		prev = row.RowHash
+	}
+	assert.Equal(t, int64(4), head.LastSeq)
+	assert.Equal(t, prev, head.LastHash)
+	assert.Equal(t, second[0].RowHash, head.PrevHash)
+}
+
+func TestAsyncWriter_FlushesOnClose(t *testing.T) {
+	store := &fakeBatchStore{}
+	w := newAsyncWriter(store, WriterConfig{BatchSize: 3, FlushInterval: time.Hour}, discardLogger())
+	for i := 0; i < 7; i++ {
+		logTo(w, EventLoginSuccess, LogParams{TenantID: uuid.New()})
+	}
+	require.NoError(t, w.Close(context.Background()))
+
+	assert.Len(t, store.inserted(), 7)
+	stats := w.Stats()
+	assert.Equal(t, uint64(7), stats.Written)
+	assert.Zero(t, stats.Dropped)
+	assert.Zero(t, stats.QueueDepth)
+}
+
+func TestAsyncWriter_SpoolAndReplay(t *testing.T) {
+	spool := filepath.Join(t.TempDir(), "audit", "spool.ndjson")
+	store := &fakeBatchStore{err: errors.New("connection refused")}
+	w := newAsyncWriter(store, WriterConfig{SpoolPath: spool, ReplayInterval: time.Hour}, discardLogger())
+	for i := 0; i < 5; i++ {
+		logTo(w, EventLoginSuccess, LogParams{Metadata: map[string]interface{}{"attempt": i}})
+	}
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, uint64(5), w.Stats().Spooled)
+	assert.Positive(t, w.Stats().SpoolBytes)
+
+	// Replay while the database is still down keeps everything
+	w.replay()
+	assert.Empty(t, store.inserted())
+	assert.Positive(t, w.Stats().SpoolBytes)
+
+	store.err = nil
+	w.replay()
+	all := store.inserted()
+	require.Len(t, all, 5)
+	assert.JSONEq(t, `{"attempt":0}`, string(all[0].Metadata))
+	assert.Zero(t, w.Stats().SpoolBytes)
+
+	// A crash mid-replay leaves rows that were already written, and a torn line
+	store.rows = all[:2]
+	require.NoError(t, w.spool(all))
+	f, err := os.OpenFile(spool, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id":"`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	w.replay()
+	assert.Len(t, store.inserted(), 5, "rows already written are skipped")
+	assert.Equal(t, uint64(8), w.Stats().Replayed)
+	assert.Equal(t, uint64(1), w.Stats().Dropped, "the torn line")
+	assert.Zero(t, w.Stats().SpoolBytes)
+}
+
+func TestAsyncWriter_QuarantinesRejectedRecords(t *testing.T) {
+	spool := filepath.Join(t.TempDir(), "spool.ndjson")
+	store := &fakeBatchStore{err: errors.New("connection refused")}
+	w := newAsyncWriter(store, WriterConfig{SpoolPath: spool, ReplayInterval: time.Hour}, discardLogger())
+	for i := 0; i < 5; i++ {
+		logTo(w, EventLoginSuccess, LogParams{Metadata: map[string]interface{}{"attempt": i}})
+	}
+	require.NoError(t, w.Close(context.Background()))
+	data, err := os.ReadFile(spool)
+	require.NoError(t, err)
+	var bad ChainRecord
+	require.NoError(t, json.Unmarshal(bytes.SplitN(data, []byte("\n"), 3)[1], &bad))
+
+	// The database is back, but refuses the second record
+	store.err = nil
+	store.rejected = map[uuid.UUID]bool{bad.ID: true}
+	w.replay()
+	assert.Len(t, store.inserted(), 4, "the rest of the batch is written")
+	assert.NotContains(t, store.inserted(), bad)
+	stats := w.Stats()
+	assert.Equal(t, uint64(4), stats.Replayed)
+	assert.Equal(t, uint64(1), stats.Quarantined)
+	assert.Zero(t, stats.SpoolBytes, "replay is not blocked by the bad record")
+
+	quarantined, err := os.ReadFile(spool + ".quarantine")
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(quarantined, []byte("\n")))
+	assert.Contains(t, string(quarantined), bad.ID.String())
+
+	// The live path falls back the same way
+	w = newAsyncWriter(store, WriterConfig{BatchSize: 2, FlushInterval: time.Hour, SpoolPath: spool}, discardLogger())
+	w.writeBatch([]ChainRecord{bad, {ID: uuid.New(), Action: "auth.login", Metadata: []byte(`{}`)}})
+	require.NoError(t, w.Close(context.Background()))
+	assert.Len(t, store.inserted(), 5)
+	assert.Equal(t, uint64(1), w.Stats().Written)
+	assert.Equal(t, uint64(1), w.Stats().Quarantined)
+	assert.Zero(t, w.Stats().Spooled)
+}
+
+func TestAsyncWriter_Backpressure(t *testing.T) {
+	store := &fakeBatchStore{entered: make(chan struct{}), gate: make(chan struct{})}
+	w := newAsyncWriter(store, WriterConfig{QueueSize: 1, BatchSize: 1, Backpressure: BackpressureDrop}, discardLogger())
+
+	logTo(w, EventLoginSuccess, LogParams{})
+	<-store.entered // The writer is stuck on the first batch
+	logTo(w, EventLoginSuccess, LogParams{})
+	logTo(w, EventLoginSuccess, LogParams{})
+
+	stats := w.Stats()
+	assert.Equal(t, 1, stats.QueueDepth)
+	assert.Equal(t, uint64(1), stats.Dropped)
+
+	go func() {
+		for range store.entered {
+		}
+	}()
+	close(store.gate)
+	require.NoError(t, w.Close(context.Background()))
+	close(store.entered)
+	assert.Len(t, store.inserted(), 2)
+}
+
+func TestAsyncWriter_CloseTimeoutSpools(t *testing.T) {
+	spool := filepath.Join(t.TempDir(), "spool.ndjson")
+	store := &fakeBatchStore{entered: make(chan struct{}, 1), gate: make(chan struct{})}
+	w := newAsyncWriter(store, WriterConfig{BatchSize: 1, SpoolPath: spool}, discardLogger())
+	logTo(w, EventLoginSuccess, LogParams{})
+	<-store.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		store.mu.Lock()
+		store.err = context.Canceled // The cancelled transaction
+		store.mu.Unlock()
+		close(store.gate)
+	}()
+	assert.ErrorIs(t, w.Close(ctx), context.DeadlineExceeded)
+
+	logTo(w, EventLogout, LogParams{})
+	data, err := os.ReadFile(spool)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte("\n")))
+	assert.Equal(t, uint64(2), w.Stats().Spooled)
+}
@@ -0,0 +1,179 @@
+// Package auditarchive enforces audit log retention.
+//
+// audit_logs is partitioned by UTC month (migration 028). Tenants choose how
+// long their events stay in the database (Service), never below the platform
+// minimum (Policy). Once every chain with rows in a month is past its
+// retention, the worker (Runner) writes the month to the blob store as one
+// gzipped NDJSON file per chain, checks nothing was added in the meantime and
+// drops the partition. Restore loads an archive back into audit_logs_restored
+// for an investigation, after checking its checksum and hash chain.
+package auditarchive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+)
+
+// Row is one line of an archive: the hashed content of the row plus its chain
+// columns, enough to restore the row exactly and re-check its hash.
+type Row struct {
+	audit.ChainRecord
+	Seq      int64  `json:"seq,omitempty"` // 0: unchained row from before migration 025
+	PrevHash []byte `json:"prev_hash,omitempty"`
+	RowHash  []byte `json:"row_hash,omitempty"`
+}
+
+// NewRow converts a stored row.
+func NewRow(row db.AuditLog) Row {
+	return Row{
+		ChainRecord: audit.ChainRecordFromRow(row),
+		Seq:         row.Seq.Int64,
+		PrevHash:    row.PrevHash,
+		RowHash:     row.RowHash,
+	}
+}
+
+// ObjectKey is the blob store key of a chain's archive of one month.
+func ObjectKey(chainKey uuid.UUID, month time.Time) string {
+	return fmt.Sprintf("audit-logs/%s/%s.ndjson.gz", chainKey, month.Format("2006-01"))
+}
+
+// MonthOf returns the first instant of the UTC month containing t.
+func MonthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Summary describes a written archive, as recorded in audit_archives.
+type Summary struct {
+	Rows        int64
+	ChainedRows int64
+	FirstSeq    int64 // 0 when no row is chained
+	LastSeq     int64
+	LastHash    []byte
+	SHA256      string // Of the compressed bytes
+	Size        int64
+}
+
+// Writer encodes rows as gzipped NDJSON. Rows must come in archive order:
+// unchained rows first, then ascending seq (ListAuditLogsForArchive).
+type Writer struct {
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	hash    hash.Hash
+	counter *countingWriter
+	summary Summary
+}
+
+func NewWriter(w io.Writer) *Writer {
+	h := sha256.New()
+	counter := &countingWriter{}
+	gz := gzip.NewWriter(io.MultiWriter(w, h, counter))
+	return &Writer{gz: gz, enc: json.NewEncoder(gz), hash: h, counter: counter}
+}
+
+func (w *Writer) Write(row Row) error {
+	if err := w.enc.Encode(row); err != nil {
+		return err
+	}
+	w.summary.Rows++
+	if row.Seq > 0 {
+		if w.summary.ChainedRows == 0 {
+			w.summary.FirstSeq = row.Seq
+		}
+		w.summary.ChainedRows++
+		w.summary.LastSeq, w.summary.LastHash = row.Seq, row.RowHash
+	}
+	return nil
+}
+
+// Close flushes the gzip stream and returns the summary. It does not close the
+// underlying writer.
+func (w *Writer) Close() (Summary, error) {
+	if err := w.gz.Close(); err != nil {
+		return Summary{}, err
+	}
+	w.summary.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	w.summary.Size = w.counter.n
+	return w.summary, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// maxLineSize bounds one archived row; metadata is limited well below it.
+const maxLineSize = 4 << 20
+
+// Read decodes an archive and calls fn for every row in file order.
+func Read(r io.Reader, fn func(Row) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var row Row
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ChainCheck verifies the rows of an archive as they are read: every chained
+// row must match its hash, and consecutive rows must link. Gaps are expected
+// (rows around a month boundary can sit in the neighbouring month).
+type ChainCheck struct {
+	Modified    []int64 // seq of rows whose content does not match row_hash
+	BrokenLinks []int64 // seq of rows whose prev_hash differs from the previous row
+	lastSeq     int64
+	lastHash    []byte
+}
+
+func (c *ChainCheck) Add(row Row) {
+	if row.Seq == 0 {
+		return
+	}
+	payload, err := row.Payload()
+	if err != nil || !bytes.Equal(audit.RowHash(row.PrevHash, row.Seq, payload), row.RowHash) {
+		c.Modified = append(c.Modified, row.Seq)
+	}
+	if c.lastSeq > 0 && row.Seq == c.lastSeq+1 && !bytes.Equal(row.PrevHash, c.lastHash) {
+		c.BrokenLinks = append(c.BrokenLinks, row.Seq)
+	}
+	c.lastSeq, c.lastHash = row.Seq, row.RowHash
+}
+
+// OK reports whether no problem was found.
+func (c *ChainCheck) OK() bool {
+	return len(c.Modified) == 0 && len(c.BrokenLinks) == 0
+}
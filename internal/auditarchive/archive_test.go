@@ -0,0 +1,105 @@
+package auditarchive_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditarchive"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chainRows builds n linked rows, as CreateAuditLog would store them.
+func chainRows(t *testing.T, tenantID uuid.UUID, n int) []auditarchive.Row {
+	rows := make([]auditarchive.Row, 0, n)
+	prev := make([]byte, 32)
+	for i := 1; i <= n; i++ {
+		record := audit.ChainRecord{
+			ID:        uuid.New(),
+			Timestamp: time.Date(2025, 1, i, 9, 30, 0, 0, time.UTC),
+			TenantID:  tenantID,
+			ActorID:   uuid.New(),
+			Action:    "auth.login.success",
+			Metadata:  []byte(fmt.Sprintf(`{"attempt":%d}`, i)),
+			IP:        netip.MustParseAddr("198.51.100.4"),
+			UserAgent: "Mozilla/5.0",
+		}
+		payload, err := record.Payload()
+		require.NoError(t, err)
+		hash := audit.RowHash(prev, int64(i), payload)
+		rows = append(rows, auditarchive.Row{ChainRecord: record, Seq: int64(i), PrevHash: prev, RowHash: hash})
+		prev = hash
+	}
+	return rows
+}
+
+func writeArchive(t *testing.T, rows []auditarchive.Row) ([]byte, auditarchive.Summary) {
+	var buf bytes.Buffer
+	w := auditarchive.NewWriter(&buf)
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	summary, err := w.Close()
+	require.NoError(t, err)
+	return buf.Bytes(), summary
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	rows := chainRows(t, uuid.New(), 4)
+	legacy := auditarchive.Row{ChainRecord: audit.ChainRecord{ID: uuid.New(), Action: "user.login", Timestamp: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	data, summary := writeArchive(t, append([]auditarchive.Row{legacy}, rows...))
+
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), summary.SHA256)
+	assert.Equal(t, int64(len(data)), summary.Size)
+	assert.Equal(t, int64(5), summary.Rows)
+	assert.Equal(t, int64(4), summary.ChainedRows)
+	assert.Equal(t, int64(1), summary.FirstSeq)
+	assert.Equal(t, int64(4), summary.LastSeq)
+	assert.Equal(t, rows[3].RowHash, summary.LastHash)
+
+	var read []auditarchive.Row
+	var check auditarchive.ChainCheck
+	require.NoError(t, auditarchive.Read(bytes.NewReader(data), func(row auditarchive.Row) error {
+		check.Add(row)
+		read = append(read, row)
+		return nil
+	}))
+	require.Len(t, read, 5)
+	assert.Equal(t, rows[2].ID, read[3].ID)
+	assert.Equal(t, rows[2].IP, read[3].IP)
+	assert.True(t, check.OK())
+}
+
+func TestChainCheck_DetectsEdits(t *testing.T) {
+	rows := chainRows(t, uuid.New(), 4)
+	rows[1].Metadata = []byte(`{"attempt":99}`)
+	rows[3].PrevHash = rows[1].RowHash // Re-linked past row 3
+
+	var check auditarchive.ChainCheck
+	for _, row := range rows {
+		check.Add(row)
+	}
+	assert.False(t, check.OK())
+	assert.Equal(t, []int64{2, 4}, check.Modified)
+	assert.Equal(t, []int64{4}, check.BrokenLinks)
+}
+
+func TestPolicy_Effective(t *testing.T) {
+	policy := auditarchive.Policy{MinimumDays: 365, DefaultDays: 730}
+	assert.Equal(t, 730, policy.Effective(0))     // No choice
+	assert.Equal(t, 1000, policy.Effective(1000)) // Longer than the default
+	assert.Equal(t, 365, policy.Effective(200))   // Chosen before the minimum was raised
+}
+
+func TestObjectKey(t *testing.T) {
+	month := auditarchive.MonthOf(time.Date(2025, 3, 31, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*3600))) // 01:30 UTC
+	assert.Equal(t, "audit-logs/00000000-0000-0000-0000-000000000000/2025-04.ndjson.gz", auditarchive.ObjectKey(uuid.Nil, month))
+}
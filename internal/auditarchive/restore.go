@@ -0,0 +1,136 @@
+package auditarchive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrArchiveNotFound = errors.New("no archive for this chain and month")
+	ErrChecksum        = errors.New("archive checksum does not match")
+)
+
+// restoreBatchSize is the number of rows copied per COPY statement.
+const restoreBatchSize = 1000
+
+// RestoreReport describes a restored archive.
+type RestoreReport struct {
+	Archive db.AuditArchive
+	Rows    int64
+	Check   ChainCheck
+}
+
+// Restore loads a chain's archive of month into audit_logs_restored, replacing
+// an earlier restore of the same archive. The file must match the checksum
+// recorded when it was written; hash chain problems are reported, not fatal,
+// since an investigation may be about exactly those rows.
+func Restore(ctx context.Context, pool *pgxpool.Pool, store blobstore.Store, chainKey uuid.UUID, month time.Time) (RestoreReport, error) {
+	var report RestoreReport
+	err := storage.WithoutRLS(ctx, pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		archive, err := q.GetAuditArchive(ctx, db.GetAuditArchiveParams{
+			ChainKey: pgtype.UUID{Bytes: chainKey, Valid: true},
+			Month:    pgtype.Date{Time: MonthOf(month), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrArchiveNotFound
+		}
+		if err != nil {
+			return err
+		}
+		report.Archive = archive
+
+		// The whole file is checked before a row is used
+		data, err := readVerified(ctx, store, archive)
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.DeleteRestoredAuditLogs(ctx, archive.ID); err != nil {
+			return err
+		}
+		batch := make([]db.CreateRestoredAuditLogsParams, 0, restoreBatchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			n, err := q.CreateRestoredAuditLogs(ctx, batch)
+			report.Rows += n
+			batch = batch[:0]
+			return err
+		}
+		err = Read(bytes.NewReader(data), func(row Row) error {
+			report.Check.Add(row)
+			batch = append(batch, restoredParams(archive.ID, row))
+			if len(batch) == restoreBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return flush()
+	})
+	return report, err
+}
+
+func readVerified(ctx context.Context, store blobstore.Store, archive db.AuditArchive) ([]byte, error) {
+	rc, err := store.Open(ctx, archive.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != archive.Sha256 {
+		return nil, fmt.Errorf("%w: %s", ErrChecksum, archive.ObjectKey)
+	}
+	return data, nil
+}
+
+func restoredParams(archiveID pgtype.UUID, row Row) db.CreateRestoredAuditLogsParams {
+	optional := func(id uuid.UUID) pgtype.UUID {
+		return pgtype.UUID{Bytes: id, Valid: id != uuid.Nil}
+	}
+	p := db.CreateRestoredAuditLogsParams{
+		ArchiveID: archiveID,
+		ID:        pgtype.UUID{Bytes: row.ID, Valid: true},
+		Timestamp: pgtype.Timestamptz{Time: row.Timestamp, Valid: true},
+		ActorID:   optional(row.ActorID),
+		SessionID: optional(row.SessionID),
+		TenantID:  optional(row.TenantID),
+		Action:    row.Action,
+		TargetID:  optional(row.TargetID),
+		UserAgent: pgtype.Text{String: row.UserAgent, Valid: row.UserAgent != ""},
+		RequestID: pgtype.Text{String: row.RequestID, Valid: row.RequestID != ""},
+		Seq:       pgtype.Int8{Int64: row.Seq, Valid: row.Seq > 0},
+		PrevHash:  row.PrevHash,
+		RowHash:   row.RowHash,
+	}
+	if len(row.Metadata) > 0 && string(row.Metadata) != "null" {
+		p.Metadata = row.Metadata
+	}
+	if row.IP.IsValid() {
+		ip := row.IP
+		p.IpAddress = &ip
+	}
+	return p
+}
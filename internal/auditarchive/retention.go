@@ -0,0 +1,133 @@
+package auditarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MaxRetentionDays caps what a tenant can choose (10 years).
+const MaxRetentionDays = 3650
+
+var ErrInvalidRetention = errors.New("invalid retention")
+
+// Policy is the platform side of retention.
+type Policy struct {
+	MinimumDays int // Compliance floor for every tenant (AUDIT_RETENTION_MIN_DAYS)
+	DefaultDays int // Tenants without a choice, and platform events (AUDIT_RETENTION_DEFAULT_DAYS)
+}
+
+// Effective returns the days a chain is kept: the tenant's choice (0: none)
+// or the default, raised to the minimum. Raising the minimum later applies to
+// existing choices too.
+func (p Policy) Effective(tenantDays int) int {
+	days := tenantDays
+	if days <= 0 {
+		days = p.DefaultDays
+	}
+	return max(days, p.MinimumDays)
+}
+
+// Retention is a tenant's current setting.
+type Retention struct {
+	Days        int  // Effective
+	TenantDays  *int // The tenant's choice; nil uses the default
+	MinimumDays int
+	DefaultDays int
+}
+
+// Service reads and changes a tenant's retention for the API.
+type Service struct {
+	pool   *pgxpool.Pool
+	audit  *audit.AuditService
+	policy Policy
+}
+
+func NewService(pool *pgxpool.Pool, audit *audit.AuditService, policy Policy) *Service {
+	return &Service{pool: pool, audit: audit, policy: policy}
+}
+
+func (s *Service) retention(policy db.AuditRetentionPolicy, found bool) Retention {
+	r := Retention{MinimumDays: s.policy.MinimumDays, DefaultDays: s.policy.DefaultDays}
+	if found {
+		days := int(policy.RetentionDays)
+		r.TenantDays = &days
+		r.Days = s.policy.Effective(days)
+	} else {
+		r.Days = s.policy.Effective(0)
+	}
+	return r
+}
+
+func (s *Service) get(ctx context.Context, q *db.Queries, tenantID uuid.UUID) (Retention, error) {
+	policy, err := q.GetAuditRetentionPolicy(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.retention(policy, false), nil
+	}
+	if err != nil {
+		return Retention{}, err
+	}
+	return s.retention(policy, true), nil
+}
+
+// Get returns the tenant's retention.
+func (s *Service) Get(ctx context.Context, tenantID uuid.UUID) (Retention, error) {
+	var r Retention
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		r, err = s.get(ctx, q, tenantID)
+		return err
+	})
+	return r, err
+}
+
+// Set stores the tenant's choice; nil returns to the platform default.
+// Shortening retention makes older months eligible for archival at the next
+// worker run, so the change is audited as a warning.
+func (s *Service) Set(ctx context.Context, tenantID, actorID uuid.UUID, days *int) (Retention, error) {
+	if days != nil && (*days < s.policy.MinimumDays || *days > MaxRetentionDays) {
+		return Retention{}, fmt.Errorf("%w: retention_days must be between %d and %d", ErrInvalidRetention, s.policy.MinimumDays, MaxRetentionDays)
+	}
+
+	var before, after Retention
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		if before, err = s.get(ctx, q, tenantID); err != nil {
+			return err
+		}
+		if days == nil {
+			_, err = q.DeleteAuditRetentionPolicy(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+			after = s.retention(db.AuditRetentionPolicy{}, false)
+			return err
+		}
+		policy, err := q.UpsertAuditRetentionPolicy(ctx, db.UpsertAuditRetentionPolicyParams{
+			TenantID:      pgtype.UUID{Bytes: tenantID, Valid: true},
+			RetentionDays: int32(*days),
+			UpdatedBy:     pgtype.UUID{Bytes: actorID, Valid: true},
+		})
+		after = s.retention(policy, true)
+		return err
+	})
+	if err != nil {
+		return Retention{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventAuditRetentionSet, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"retention_days":        after.Days,
+			"previous_days":         before.Days,
+			"tenant_retention_days": after.TenantDays,
+		},
+	})
+	return after, nil
+}
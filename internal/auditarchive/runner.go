@@ -0,0 +1,287 @@
+package auditarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// monthsAhead are created in advance, so inserts never wait for the worker.
+	monthsAhead = 3
+	// maxMonthsPerRun bounds one run; a backlog (first run on years of
+	// history) is worked off over the following runs.
+	maxMonthsPerRun = 3
+	// archivePageSize is the number of rows read per query while archiving.
+	archivePageSize = 1000
+)
+
+// errMonthChanged aborts a drop when rows were added after the archive was written.
+var errMonthChanged = errors.New("rows changed since the archive was written")
+
+// Runner creates partitions and archives and drops expired months. Run it from
+// the janitor worker.
+type Runner struct {
+	pool   *pgxpool.Pool
+	store  blobstore.Store
+	policy Policy
+	audit  *audit.AuditService
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewRunner(pool *pgxpool.Pool, store blobstore.Store, policy Policy, audit *audit.AuditService, logger *slog.Logger) *Runner {
+	return &Runner{pool: pool, store: store, policy: policy, audit: audit, logger: logger, now: time.Now}
+}
+
+// Run creates the coming partitions, then archives and drops due months,
+// oldest first. Returns the number of partitions dropped.
+func (r *Runner) Run(ctx context.Context) (int, error) {
+	if err := r.ensurePartitions(ctx); err != nil {
+		return 0, fmt.Errorf("create partitions: %w", err)
+	}
+
+	var partitions []string
+	tenantDays := map[uuid.UUID]int{}
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		var err error
+		if partitions, err = q.ListAuditLogPartitions(ctx); err != nil {
+			return err
+		}
+		policies, err := q.ListAuditRetentionPolicies(ctx)
+		for _, p := range policies {
+			tenantDays[p.TenantID.Bytes] = int(p.RetentionDays)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+	for _, name := range partitions {
+		month, err := time.Parse("audit_logs_2006_01", name)
+		if err != nil {
+			continue
+		}
+		// Partitions are sorted: once the minimum keeps a month, it keeps all later ones
+		if !r.expired(month, r.policy.MinimumDays) || dropped == maxMonthsPerRun {
+			break
+		}
+		done, err := r.archiveMonth(ctx, name, month, tenantDays)
+		if err != nil {
+			return dropped, fmt.Errorf("archive %s: %w", name, err)
+		}
+		if done {
+			dropped++
+		}
+	}
+	return dropped, nil
+}
+
+// expired reports whether all of month is older than days.
+func (r *Runner) expired(month time.Time, days int) bool {
+	return !month.AddDate(0, 1, days).After(r.now())
+}
+
+// ensurePartitions creates this month's partition and the next ones.
+func (r *Runner) ensurePartitions(ctx context.Context) error {
+	return storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		current := MonthOf(r.now())
+		for i := 0; i <= monthsAhead; i++ {
+			if _, err := q.CreateAuditLogPartition(ctx, pgtype.Date{Time: current.AddDate(0, i, 0), Valid: true}); err != nil {
+				return err
+			}
+		}
+		stray, err := q.CountDefaultPartitionAuditLogs(ctx)
+		if err == nil && stray > 0 {
+			// Events of a month that was already dropped: kept, but never archived
+			r.logger.Warn("AuditArchive: Rows in the default partition", "rows", stray)
+		}
+		return err
+	})
+}
+
+// archiveMonth archives every chain of the month and drops the partition. It
+// returns false when a chain in it is still within its retention.
+func (r *Runner) archiveMonth(ctx context.Context, partition string, month time.Time, tenantDays map[uuid.UUID]int) (bool, error) {
+	since, until := monthRange(month)
+	var chains []pgtype.UUID
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		chains, err = db.New(tx).ListAuditLogChainKeys(ctx, db.ListAuditLogChainKeysParams{Since: since, Until: until})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, chain := range chains {
+		// Platform events (nil chain) and tenants without a choice use the default
+		if !r.expired(month, r.policy.Effective(tenantDays[chain.Bytes])) {
+			return false, nil
+		}
+	}
+
+	archives := make([]db.AuditArchive, 0, len(chains))
+	for _, chain := range chains {
+		archive, err := r.archiveChain(ctx, chain.Bytes, month)
+		if err != nil {
+			return false, fmt.Errorf("chain %s: %w", uuid.UUID(chain.Bytes), err)
+		}
+		archives = append(archives, archive)
+	}
+
+	err = r.drop(ctx, partition, month, archives)
+	if errors.Is(err, errMonthChanged) {
+		// Late rows (spool replay): the next run archives the month again
+		r.logger.Warn("AuditArchive: Month changed while archiving, retrying next run", "partition", partition)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("drop: %w", err)
+	}
+
+	for _, a := range archives {
+		chainKey := uuid.UUID(a.ChainKey.Bytes)
+		r.audit.Log(ctx, audit.EventAuditLogArchived, audit.LogParams{
+			TenantID: chainKey, // uuid.Nil: the platform chain
+			Metadata: map[string]interface{}{
+				"month":      month.Format("2006-01"),
+				"rows":       a.Rows,
+				"first_seq":  a.FirstSeq.Int64,
+				"last_seq":   a.LastSeq.Int64,
+				"object_key": a.ObjectKey,
+				"sha256":     a.Sha256,
+			},
+		})
+	}
+	r.logger.Info("AuditArchive: Month archived", "partition", partition, "chains", len(archives))
+	return true, nil
+}
+
+// archiveChain streams one chain's rows of the month to the blob store and
+// records the archive. Re-running it (crash before the drop) overwrites both.
+func (r *Runner) archiveChain(ctx context.Context, chainKey uuid.UUID, month time.Time) (db.AuditArchive, error) {
+	key := ObjectKey(chainKey, month)
+	pr, pw := io.Pipe()
+	var summary Summary
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		summary, err = r.writeChain(ctx, pw, chainKey, month)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	_, putErr := r.store.Put(ctx, key, pr)
+	pr.CloseWithError(putErr) // Unblocks the writer when Put gave up early
+	if err := <-done; err != nil {
+		return db.AuditArchive{}, fmt.Errorf("read rows: %w", err)
+	}
+	if putErr != nil {
+		return db.AuditArchive{}, fmt.Errorf("store %s: %w", key, putErr)
+	}
+
+	var archive db.AuditArchive
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		archive, err = db.New(tx).UpsertAuditArchive(ctx, db.UpsertAuditArchiveParams{
+			ChainKey:    pgtype.UUID{Bytes: chainKey, Valid: true},
+			Month:       pgtype.Date{Time: month, Valid: true},
+			ObjectKey:   key,
+			Rows:        summary.Rows,
+			ChainedRows: summary.ChainedRows,
+			FirstSeq:    pgtype.Int8{Int64: summary.FirstSeq, Valid: summary.ChainedRows > 0},
+			LastSeq:     pgtype.Int8{Int64: summary.LastSeq, Valid: summary.ChainedRows > 0},
+			LastHash:    summary.LastHash,
+			Sha256:      summary.SHA256,
+			SizeBytes:   summary.Size,
+		})
+		return err
+	})
+	return archive, err
+}
+
+func (r *Runner) writeChain(ctx context.Context, w io.Writer, chainKey uuid.UUID, month time.Time) (Summary, error) {
+	since, until := monthRange(month)
+	aw := NewWriter(w)
+	arg := db.ListAuditLogsForArchiveParams{
+		ChainKey: pgtype.UUID{Bytes: chainKey, Valid: true},
+		Since:    since,
+		Until:    until,
+		AfterSeq: -1,
+		RowLimit: archivePageSize,
+	}
+	for {
+		var rows []db.AuditLog
+		err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			var err error
+			rows, err = db.New(tx).ListAuditLogsForArchive(ctx, arg)
+			return err
+		})
+		if err != nil {
+			return Summary{}, err
+		}
+		for _, row := range rows {
+			if err := aw.Write(NewRow(row)); err != nil {
+				return Summary{}, err
+			}
+		}
+		if len(rows) < archivePageSize {
+			return aw.Close()
+		}
+		last := rows[len(rows)-1]
+		arg.AfterSeq, arg.AfterID = last.Seq.Int64, last.ID
+	}
+}
+
+// drop removes the partition once its rows match the archives. The partition
+// is locked first, so nothing can be added between the check and the drop.
+func (r *Runner) drop(ctx context.Context, partition string, month time.Time, archives []db.AuditArchive) error {
+	since, until := monthRange(month)
+	table := pgx.Identifier{partition}.Sanitize()
+	return storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "LOCK TABLE "+table+" IN ACCESS EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+		q := db.New(tx)
+		chains, err := q.ListAuditLogChainKeys(ctx, db.ListAuditLogChainKeysParams{Since: since, Until: until})
+		if err != nil {
+			return err
+		}
+		if len(chains) != len(archives) {
+			return errMonthChanged
+		}
+		for _, a := range archives {
+			count, err := q.CountAuditLogsInRange(ctx, db.CountAuditLogsInRangeParams{ChainKey: a.ChainKey, Since: since, Until: until})
+			if err != nil {
+				return err
+			}
+			if count != a.Rows {
+				return errMonthChanged
+			}
+		}
+		if _, err := tx.Exec(ctx, "DROP TABLE "+table); err != nil {
+			return err
+		}
+		return q.MarkAuditArchivesDropped(ctx, pgtype.Date{Time: month, Valid: true})
+	})
+}
+
+func monthRange(month time.Time) (pgtype.Timestamptz, pgtype.Timestamptz) {
+	return pgtype.Timestamptz{Time: month, Valid: true}, pgtype.Timestamptz{Time: month.AddDate(0, 1, 0), Valid: true}
+}
@@ -0,0 +1,153 @@
+// Package auditsink delivers a tenant's audit events to its SIEM.
+//
+// Tenant admins configure sinks (Service): RFC 5424 syslog over TCP or TLS, or
+// an HTTPS webhook signed with a per-sink secret. The worker (Runner) reads each
+// tenant's hash chain from the sink's cursor (audit_sinks.last_seq) and moves
+// the cursor only after a delivery succeeds, so events are delivered at least
+// once and in chain order. Older history is available through the bulk export
+// (ExportWriter).
+package auditsink
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+)
+
+// Sink kinds (audit_sinks.kind).
+const (
+	KindSyslog  = "syslog"
+	KindWebhook = "webhook"
+)
+
+const (
+	// MaxSinksPerTenant bounds the fan-out of one tenant's events.
+	MaxSinksPerTenant = 5
+	// deliveryTimeout bounds one delivery (connect, write, response).
+	deliveryTimeout = 10 * time.Second
+)
+
+var (
+	ErrInvalidSink   = errors.New("invalid audit sink")
+	ErrTooManySinks  = errors.New("tenant has the maximum number of audit sinks")
+	ErrSinkNotFound  = errors.New("audit sink not found")
+	ErrBlockedTarget = errors.New("security violation: connection to private network blocked")
+	ErrInvalidRange  = errors.New("invalid export range")
+)
+
+// Event is one audit log row as sent to a sink and written by the export.
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Seq       int64           `json:"seq,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+	TenantID  uuid.UUID       `json:"tenant_id"`
+	Action    string          `json:"action"`
+	Severity  audit.Severity  `json:"severity"` // From the event catalogue
+	ActorID   *uuid.UUID      `json:"actor_id,omitempty"`
+	TargetID  *uuid.UUID      `json:"target_id,omitempty"`
+	SessionID *uuid.UUID      `json:"session_id,omitempty"`
+	Metadata  json.RawMessage `json:"metadata,omitempty"`
+	IPAddress *netip.Addr     `json:"ip_address,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	RowHash   string          `json:"row_hash,omitempty"` // Hex; lets the SIEM follow the hash chain
+}
+
+func NewEvent(row db.AuditLog) Event {
+	e := Event{
+		ID:        row.ID.Bytes,
+		Seq:       row.Seq.Int64,
+		Timestamp: row.Timestamp.Time.UTC(),
+		TenantID:  row.TenantID.Bytes,
+		Action:    row.Action,
+		Severity:  audit.SeverityOf(row.Action),
+		Metadata:  json.RawMessage(row.Metadata),
+		IPAddress: row.IpAddress,
+		UserAgent: row.UserAgent.String,
+		RequestID: row.RequestID.String,
+	}
+	if row.ActorID.Valid {
+		id := uuid.UUID(row.ActorID.Bytes)
+		e.ActorID = &id
+	}
+	if row.TargetID.Valid {
+		id := uuid.UUID(row.TargetID.Bytes)
+		e.TargetID = &id
+	}
+	if row.SessionID.Valid {
+		id := uuid.UUID(row.SessionID.Bytes)
+		e.SessionID = &id
+	}
+	if len(row.Metadata) == 0 {
+		e.Metadata = nil
+	}
+	if len(row.RowHash) > 0 {
+		e.RowHash = hex.EncodeToString(row.RowHash)
+	}
+	return e
+}
+
+// Sink delivers a batch of events. A nil error means the whole batch was accepted.
+type Sink interface {
+	Deliver(ctx context.Context, events []Event) error
+}
+
+// matchAction reports whether action passes a sink's filter: empty matches
+// everything, a trailing '*' matches a prefix, anything else the exact action.
+func matchAction(filter, action string) bool {
+	if filter == "" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(filter, "*"); ok {
+		return strings.HasPrefix(action, prefix)
+	}
+	return filter == action
+}
+
+// guardedDialer refuses private, loopback and link-local addresses. The check
+// runs on the resolved IP at connect time, so DNS rebinding after the sink was
+// validated cannot redirect deliveries into the internal network.
+func guardedDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || mailer.ValidatePublicIP(ip) != nil {
+				return ErrBlockedTarget
+			}
+			return nil
+		},
+	}
+}
+
+// endpointHost checks the shape of an endpoint and returns the host to resolve.
+func endpointHost(kind, endpoint string) (string, error) {
+	switch kind {
+	case KindSyslog:
+		return syslogHost(endpoint)
+	case KindWebhook:
+		u, err := webhookURL(endpoint)
+		if err != nil {
+			return "", err
+		}
+		return u.Hostname(), nil
+	default:
+		return "", fmt.Errorf("%w: kind must be %q or %q", ErrInvalidSink, KindSyslog, KindWebhook)
+	}
+}
@@ -0,0 +1,235 @@
+package auditsink_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditsink"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func events(n int) []auditsink.Event {
+	tenantID, actorID := uuid.New(), uuid.New()
+	ip := netip.MustParseAddr("203.0.113.7")
+	out := make([]auditsink.Event, n)
+	for i := range out {
+		out[i] = auditsink.NewEvent(db.AuditLog{
+			ID:        pgtype.UUID{Bytes: uuid.New(), Valid: true},
+			Timestamp: pgtype.Timestamptz{Time: time.Date(2026, 3, 1, 12, 0, i, 123456000, time.UTC), Valid: true},
+			TenantID:  pgtype.UUID{Bytes: tenantID, Valid: true},
+			ActorID:   pgtype.UUID{Bytes: actorID, Valid: true},
+			Action:    "auth.login.success",
+			Metadata:  []byte(`{"method":"password"}`),
+			IpAddress: &ip,
+			UserAgent: pgtype.Text{String: "Mozilla/5.0", Valid: true},
+			Seq:       pgtype.Int8{Int64: int64(i + 1), Valid: true},
+			RowHash:   []byte{0xab, 0xcd},
+		})
+	}
+	return out
+}
+
+// readFrames reads octet-counted syslog frames (RFC 6587) until EOF.
+func readFrames(t *testing.T, r io.Reader) []string {
+	br := bufio.NewReader(r)
+	var frames []string
+	for {
+		length, err := br.ReadString(' ')
+		if err == io.EOF {
+			return frames
+		}
+		require.NoError(t, err)
+		n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		require.NoError(t, err)
+		frame := make([]byte, n)
+		_, err = io.ReadFull(br, frame)
+		require.NoError(t, err)
+		frames = append(frames, string(frame))
+	}
+}
+
+// serveOnce accepts one connection and returns the frames written to it.
+func serveOnce(t *testing.T, ln net.Listener) <-chan []string {
+	out := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(out)
+			return
+		}
+		defer conn.Close()
+		out <- readFrames(t, conn)
+	}()
+	return out
+}
+
+// localDialer skips the private network guard, which would refuse 127.0.0.1.
+var localDialer = &net.Dialer{Timeout: time.Second}
+
+func TestSyslogSink_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	received := serveOnce(t, ln)
+
+	batch := events(3)
+	sink := &auditsink.SyslogSink{Addr: ln.Addr().String(), Hostname: "auth-1", Dialer: localDialer}
+	require.NoError(t, sink.Deliver(context.Background(), batch))
+
+	frames := <-received
+	require.Len(t, frames, 3)
+	header, body, ok := strings.Cut(frames[0], "\xEF\xBB\xBF")
+	require.True(t, ok, "MSG starts with a BOM")
+	assert.Equal(t, "<110>1 2026-03-01T12:00:00.123456Z auth-1 laventecare - auth.login.success - ", header)
+
+	var got auditsink.Event
+	require.NoError(t, json.Unmarshal([]byte(body), &got))
+	assert.Equal(t, batch[0].ID, got.ID)
+	assert.Equal(t, int64(1), got.Seq)
+	assert.Equal(t, "abcd", got.RowHash)
+	assert.JSONEq(t, `{"method":"password"}`, string(got.Metadata))
+	assert.Equal(t, audit.SeverityInfo, got.Severity)
+
+	// The PRI carries the catalogue severity: warning is 13*8+4
+	warning := events(1)
+	warning[0].Action, warning[0].Severity = "audit_sink.disabled", audit.SeverityWarning
+	received = serveOnce(t, ln)
+	require.NoError(t, sink.Deliver(context.Background(), warning))
+	frames = <-received
+	require.Len(t, frames, 1)
+	assert.True(t, strings.HasPrefix(frames[0], "<108>1 "), frames[0])
+}
+
+func TestSyslogSink_TLS(t *testing.T) {
+	// httptest provides a certificate for 127.0.0.1 and a client trusting it
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	require.NoError(t, err)
+	defer ln.Close()
+	received := serveOnce(t, ln)
+
+	clientTLS := srv.Client().Transport.(*http.Transport).TLSClientConfig
+	sink := &auditsink.SyslogSink{Addr: ln.Addr().String(), TLS: true, Dialer: localDialer, TLSConfig: clientTLS}
+	require.NoError(t, sink.Deliver(context.Background(), events(2)))
+	frames := <-received
+	require.Len(t, frames, 2)
+	assert.True(t, strings.HasPrefix(frames[1], "<110>1 2026-03-01T12:00:01.123456Z - laventecare - auth.login.success - "))
+
+	// Without the test CA the certificate is rejected
+	untrusted := &auditsink.SyslogSink{Addr: ln.Addr().String(), TLS: true, Dialer: localDialer}
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	assert.Error(t, untrusted.Deliver(context.Background(), events(1)))
+}
+
+func TestSink_RefusesPrivateNetworks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	// The default dialer checks the resolved address, whatever the endpoint says
+	err = (&auditsink.SyslogSink{Addr: ln.Addr().String()}).Deliver(context.Background(), events(1))
+	assert.ErrorIs(t, err, auditsink.ErrBlockedTarget)
+
+	err = (&auditsink.WebhookSink{URL: "https://" + ln.Addr().String() + "/hook"}).Deliver(context.Background(), events(1))
+	assert.ErrorIs(t, err, auditsink.ErrBlockedTarget)
+}
+
+func TestWebhookSink(t *testing.T) {
+	secret := []byte("s3cret")
+	var gotBody []byte
+	var gotTimestamp, gotSignature string
+	status := http.StatusNoContent
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotTimestamp = r.Header.Get(auditsink.HeaderTimestamp)
+		gotSignature = r.Header.Get(auditsink.HeaderSignature)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := &auditsink.WebhookSink{
+		URL:       srv.URL + "/siem",
+		Secret:    secret,
+		Dialer:    localDialer,
+		TLSConfig: srv.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+	batch := events(2)
+	require.NoError(t, sink.Deliver(context.Background(), batch))
+
+	// What a receiver does: recompute the signature over timestamp and raw body
+	assert.Equal(t, auditsink.Sign(secret, gotTimestamp, gotBody), gotSignature)
+	assert.True(t, strings.HasPrefix(gotSignature, "v1="))
+	ts, err := strconv.ParseInt(gotTimestamp, 10, 64)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), time.Unix(ts, 0), 5*time.Second)
+	assert.NotEqual(t, auditsink.Sign([]byte("other"), gotTimestamp, gotBody), gotSignature)
+
+	var payload struct {
+		Events []auditsink.Event `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(gotBody, &payload))
+	require.Len(t, payload.Events, 2)
+	assert.Equal(t, batch[1].ID, payload.Events[1].ID)
+
+	// Non-2xx (redirects included) fails the batch, so the cursor stays put
+	for _, code := range []int{http.StatusInternalServerError, http.StatusFound} {
+		status = code
+		assert.Error(t, sink.Deliver(context.Background(), batch), code)
+	}
+}
+
+func TestExportWriter(t *testing.T) {
+	batch := events(2)
+	batch[1].UserAgent = "=HYPERLINK(\"http://evil\")"
+
+	var buf bytes.Buffer
+	w, err := auditsink.NewExportWriter(auditsink.FormatCSV, &buf)
+	require.NoError(t, err)
+	for _, e := range batch {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Flush())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "id,seq,timestamp,action,"))
+	assert.Contains(t, lines[2], `'=HYPERLINK`, "formulas are neutralised")
+
+	buf.Reset()
+	w, err = auditsink.NewExportWriter(auditsink.FormatNDJSON, &buf)
+	require.NoError(t, err)
+	for _, e := range batch {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Flush())
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var got auditsink.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+	assert.Equal(t, batch[0].ID, got.ID)
+
+	_, err = auditsink.NewExportWriter("xlsx", &buf)
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package auditsink
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats.
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// MaxExportRange bounds the time range of one bulk export.
+const MaxExportRange = 366 * 24 * time.Hour
+
+// csvHeader is the column order of CSV exports.
+var csvHeader = []string{"id", "seq", "timestamp", "action", "severity", "actor_id", "target_id", "session_id", "ip_address", "user_agent", "request_id", "metadata", "row_hash"}
+
+// ExportWriter streams events in one of the export formats.
+type ExportWriter interface {
+	Write(e Event) error
+	// Flush writes buffered output; call it once after the last event.
+	Flush() error
+}
+
+// NewExportWriter returns a writer for format (FormatNDJSON or FormatCSV).
+func NewExportWriter(format string, w io.Writer) (ExportWriter, error) {
+	switch format {
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType returns the media type of an export format.
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder // Encode appends the newline
+}
+
+func (n *ndjsonWriter) Write(e Event) error { return n.enc.Encode(e) }
+func (n *ndjsonWriter) Flush() error        { return n.w.Flush() }
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(e Event) error {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	optional := func(v fmt.Stringer, ok bool) string {
+		if !ok {
+			return ""
+		}
+		return v.String()
+	}
+	var seq string
+	if e.Seq > 0 {
+		seq = strconv.FormatInt(e.Seq, 10)
+	}
+	return c.w.Write([]string{
+		e.ID.String(),
+		seq,
+		e.Timestamp.Format(time.RFC3339Nano),
+		csvCell(e.Action),
+		string(e.Severity),
+		optional(e.ActorID, e.ActorID != nil),
+		optional(e.TargetID, e.TargetID != nil),
+		optional(e.SessionID, e.SessionID != nil),
+		optional(e.IPAddress, e.IPAddress != nil),
+		csvCell(e.UserAgent),
+		csvCell(e.RequestID),
+		csvCell(string(e.Metadata)),
+		e.RowHash,
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvCell neutralises spreadsheet formulas: user agents and metadata are user
+// controlled, and a cell starting with = + - @ (or a tab/CR) is evaluated by Excel.
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
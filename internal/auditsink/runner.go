@@ -0,0 +1,155 @@
+package auditsink
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/crypto"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// claimLimit is the number of sinks leased per run.
+	claimLimit = 20
+	// batchSize is the number of chain rows read (and at most delivered) at once.
+	batchSize = 500
+	// maxBatchesPerRun keeps one busy sink from holding its lease too long; the
+	// rest is delivered on the next tick.
+	maxBatchesPerRun = 20
+)
+
+// Runner delivers pending events to every due sink. Run it from cmd/worker.
+type Runner struct {
+	pool     *pgxpool.Pool
+	logger   *slog.Logger
+	hostname string
+}
+
+func NewRunner(pool *pgxpool.Pool, logger *slog.Logger) *Runner {
+	hostname, _ := os.Hostname()
+	return &Runner{pool: pool, logger: logger, hostname: hostname}
+}
+
+// RunDue leases due sinks and delivers their backlog. It returns the number of
+// events delivered. A failing sink is backed off (FailAuditSink) without
+// affecting the others.
+func (r *Runner) RunDue(ctx context.Context) (int64, error) {
+	var sinks []db.AuditSink
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		sinks, err = db.New(tx).ClaimDueAuditSinks(ctx, claimLimit)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("claim audit sinks: %w", err)
+	}
+
+	var total int64
+	for _, sink := range sinks {
+		delivered, err := r.drain(ctx, sink)
+		total += delivered
+		if err == nil {
+			continue
+		}
+		r.logger.Warn("AuditSink: Delivery failed",
+			"sink_id", uuid.UUID(sink.ID.Bytes),
+			"tenant_id", uuid.UUID(sink.TenantID.Bytes),
+			"kind", sink.Kind,
+			"failures", sink.ConsecutiveFailures+1,
+			"error", err,
+		)
+		failErr := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			return db.New(tx).FailAuditSink(ctx, db.FailAuditSinkParams{
+				ID:        sink.ID,
+				LastError: pgtype.Text{String: err.Error(), Valid: true},
+			})
+		})
+		if failErr != nil {
+			return total, failErr
+		}
+	}
+	return total, nil
+}
+
+// drain delivers batches from the sink's cursor until it is caught up, moving
+// the cursor after every accepted batch. Rows that do not pass the action
+// filter move the cursor too.
+func (r *Runner) drain(ctx context.Context, sink db.AuditSink) (int64, error) {
+	target, err := r.sink(sink)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int64
+	cursor := sink.LastSeq
+	for i := 0; i < maxBatchesPerRun; i++ {
+		var rows []db.AuditLog
+		err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			var err error
+			rows, err = db.New(tx).ListAuditChainRows(ctx, db.ListAuditChainRowsParams{
+				ChainKey: sink.TenantID,
+				AfterSeq: cursor,
+				RowLimit: batchSize,
+			})
+			return err
+		})
+		if err != nil {
+			return delivered, fmt.Errorf("read audit chain: %w", err)
+		}
+
+		events := make([]Event, 0, len(rows))
+		for _, row := range rows {
+			if matchAction(sink.ActionFilter.String, row.Action) {
+				events = append(events, NewEvent(row))
+			}
+		}
+		if len(events) > 0 {
+			if err := target.Deliver(ctx, events); err != nil {
+				return delivered, err
+			}
+		}
+		if len(rows) > 0 {
+			cursor = rows[len(rows)-1].Seq.Int64
+		}
+
+		// Also releases the lease when there was nothing to send
+		err = storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			return db.New(tx).AdvanceAuditSink(ctx, db.AdvanceAuditSinkParams{
+				ID:             sink.ID,
+				LastSeq:        cursor,
+				DeliveredCount: int64(len(events)),
+			})
+		})
+		if err != nil {
+			return delivered, fmt.Errorf("advance cursor: %w", err)
+		}
+		delivered += int64(len(events))
+		if len(rows) < batchSize {
+			break
+		}
+	}
+	return delivered, nil
+}
+
+// sink builds the delivery target of a configured sink.
+func (r *Runner) sink(sink db.AuditSink) (Sink, error) {
+	switch sink.Kind {
+	case KindSyslog:
+		return &SyslogSink{Addr: sink.Endpoint, TLS: sink.UseTls, Hostname: r.hostname}, nil
+	case KindWebhook:
+		secret, err := crypto.DecryptTenantSecret(sink.SecretEncrypted.String)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt webhook secret: %w", err)
+		}
+		return &WebhookSink{URL: sink.Endpoint, Secret: []byte(secret)}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidSink, sink.Kind)
+	}
+}
@@ -0,0 +1,293 @@
+package auditsink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/crypto"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// exportPageSize is the number of rows read per query while exporting.
+const exportPageSize = 1000
+
+// Service manages a tenant's sinks and runs bulk exports for the API.
+type Service struct {
+	pool  *pgxpool.Pool
+	audit *audit.AuditService
+}
+
+func NewService(pool *pgxpool.Pool, audit *audit.AuditService) *Service {
+	return &Service{pool: pool, audit: audit}
+}
+
+// CreateParams configures a new sink.
+type CreateParams struct {
+	Name         string
+	Kind         string
+	Endpoint     string
+	UseTLS       bool   // Syslog only
+	ActionFilter string // Optional: exact action or prefix with trailing '*'
+}
+
+// Create adds a sink that starts at the current end of the tenant's chain. For
+// webhooks it also returns the signing secret; it is stored encrypted and never
+// shown again.
+func (s *Service) Create(ctx context.Context, tenantID, actorID uuid.UUID, p CreateParams) (db.AuditSink, string, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Endpoint = strings.TrimSpace(p.Endpoint)
+	p.ActionFilter = strings.TrimSpace(p.ActionFilter)
+	if p.Name == "" || len(p.Name) > 100 {
+		return db.AuditSink{}, "", fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidSink)
+	}
+	if len(p.ActionFilter) > 255 {
+		return db.AuditSink{}, "", fmt.Errorf("%w: action filter is too long", ErrInvalidSink)
+	}
+	host, err := endpointHost(p.Kind, p.Endpoint)
+	if err != nil {
+		return db.AuditSink{}, "", err
+	}
+	// Fail early on internal targets; the worker checks again at connect time
+	if err := mailer.ValidateSMTPHost(host); err != nil {
+		return db.AuditSink{}, "", fmt.Errorf("%w: %v", ErrInvalidSink, err)
+	}
+
+	var secret string
+	var secretEncrypted pgtype.Text
+	if p.Kind == KindWebhook {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return db.AuditSink{}, "", err
+		}
+		secret = hex.EncodeToString(raw)
+		encrypted, err := crypto.EncryptTenantSecret(secret)
+		if err != nil {
+			return db.AuditSink{}, "", fmt.Errorf("encrypt webhook secret: %w", err)
+		}
+		secretEncrypted = pgtype.Text{String: encrypted, Valid: true}
+		p.UseTLS = true // Always HTTPS
+	}
+
+	var sink db.AuditSink
+	err = storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+		count, err := q.CountAuditSinks(ctx, tid)
+		if err != nil {
+			return err
+		}
+		if count >= MaxSinksPerTenant {
+			return ErrTooManySinks
+		}
+		sink, err = q.CreateAuditSink(ctx, db.CreateAuditSinkParams{
+			TenantID:        tid,
+			Name:            p.Name,
+			Kind:            p.Kind,
+			Endpoint:        p.Endpoint,
+			UseTls:          p.UseTLS,
+			SecretEncrypted: secretEncrypted,
+			ActionFilter:    pgtype.Text{String: p.ActionFilter, Valid: p.ActionFilter != ""},
+			CreatedBy:       pgtype.UUID{Bytes: actorID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return db.AuditSink{}, "", err
+	}
+
+	s.audit.Log(ctx, audit.EventAuditSinkCreated, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		TargetID: sink.ID.Bytes,
+		Metadata: map[string]interface{}{
+			"name":     sink.Name,
+			"kind":     sink.Kind,
+			"endpoint": sink.Endpoint,
+		},
+	})
+	return sink, secret, nil
+}
+
+// SinkStatus is a sink with its delivery lag.
+type SinkStatus struct {
+	db.AuditSink
+	Lag int64 // Chain rows not yet past the cursor
+}
+
+// List returns the tenant's sinks with their lag behind the chain head.
+func (s *Service) List(ctx context.Context, tenantID uuid.UUID) ([]SinkStatus, error) {
+	var result []SinkStatus
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+		sinks, err := q.ListAuditSinks(ctx, tid)
+		if err != nil {
+			return err
+		}
+		var headSeq int64
+		head, err := q.GetAuditChainHead(ctx, tid)
+		if err == nil {
+			headSeq = head.LastSeq
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		result = make([]SinkStatus, len(sinks))
+		for i, sink := range sinks {
+			result[i] = SinkStatus{AuditSink: sink, Lag: max(headSeq-sink.LastSeq, 0)}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// SetEnabled pauses or resumes a sink. A paused sink keeps its cursor, so
+// resuming delivers everything that happened in between.
+func (s *Service) SetEnabled(ctx context.Context, tenantID, actorID, sinkID uuid.UUID, enabled bool) (db.AuditSink, error) {
+	var sink db.AuditSink
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		sink, err = q.SetAuditSinkEnabled(ctx, db.SetAuditSinkEnabledParams{
+			ID:       pgtype.UUID{Bytes: sinkID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+			Enabled:  enabled,
+		})
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.AuditSink{}, ErrSinkNotFound
+	}
+	if err != nil {
+		return db.AuditSink{}, err
+	}
+
+	event := audit.EventAuditSinkDisabled
+	if enabled {
+		event = audit.EventAuditSinkEnabled
+	}
+	s.audit.Log(ctx, event, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		TargetID: sinkID,
+		Metadata: map[string]interface{}{"name": sink.Name},
+	})
+	return sink, nil
+}
+
+// Retry makes a backed-off sink due now.
+func (s *Service) Retry(ctx context.Context, tenantID, sinkID uuid.UUID) error {
+	return storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		n, err := q.RetryAuditSink(ctx, db.RetryAuditSinkParams{
+			ID:       pgtype.UUID{Bytes: sinkID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		})
+		if err == nil && n == 0 {
+			return ErrSinkNotFound
+		}
+		return err
+	})
+}
+
+// Delete removes a sink and its cursor.
+func (s *Service) Delete(ctx context.Context, tenantID, actorID, sinkID uuid.UUID) error {
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		n, err := q.DeleteAuditSink(ctx, db.DeleteAuditSinkParams{
+			ID:       pgtype.UUID{Bytes: sinkID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		})
+		if err == nil && n == 0 {
+			return ErrSinkNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventAuditSinkDeleted, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		TargetID: sinkID,
+	})
+	return nil
+}
+
+// ExportParams selects the rows of a bulk export.
+type ExportParams struct {
+	Since         time.Time
+	Until         time.Time
+	ActionPattern pgtype.Text // LIKE pattern, as built by the audit log API
+	Format        string
+}
+
+// ValidateRange checks the time range of an export.
+func ValidateRange(since, until time.Time) error {
+	if !until.After(since) || until.Sub(since) > MaxExportRange {
+		return fmt.Errorf("%w: until must be after since, at most %d days apart", ErrInvalidRange, int(MaxExportRange.Hours()/24))
+	}
+	return nil
+}
+
+// Export streams the tenant's audit log in [Since, Until), oldest first, to w
+// and returns the number of rows written. Rows are read in pages, so the export
+// never holds the whole range in memory.
+func (s *Service) Export(ctx context.Context, tenantID, actorID uuid.UUID, p ExportParams, w ExportWriter) (int64, error) {
+	if err := ValidateRange(p.Since, p.Until); err != nil {
+		return 0, err
+	}
+
+	var written int64
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		arg := db.ExportAuditLogsParams{
+			TenantID:      pgtype.UUID{Bytes: tenantID, Valid: true},
+			Since:         pgtype.Timestamptz{Time: p.Since, Valid: true},
+			Until:         pgtype.Timestamptz{Time: p.Until, Valid: true},
+			ActionPattern: p.ActionPattern,
+			RowLimit:      exportPageSize,
+		}
+		for {
+			rows, err := q.ExportAuditLogs(ctx, arg)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				if err := w.Write(NewEvent(row)); err != nil {
+					return err
+				}
+				written++
+			}
+			if len(rows) < exportPageSize {
+				return nil
+			}
+			last := rows[len(rows)-1]
+			arg.CursorTs, arg.CursorID = last.Timestamp, last.ID
+		}
+	})
+	if err != nil {
+		return written, err
+	}
+	if err := w.Flush(); err != nil {
+		return written, err
+	}
+
+	s.audit.Log(ctx, audit.EventAuditLogExported, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"format": p.Format,
+			"since":  p.Since.UTC().Format(time.RFC3339),
+			"until":  p.Until.UTC().Format(time.RFC3339),
+			"rows":   written,
+		},
+	})
+	return written, nil
+}
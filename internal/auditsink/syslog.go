@@ -0,0 +1,151 @@
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+)
+
+const (
+	// syslogFacility is facility 13 (log audit); PRI is facility*8 + severity.
+	syslogFacility = 13
+	syslogAppName  = "laventecare"
+	// syslogMaxMsgID is the RFC 5424 limit on MSGID.
+	syslogMaxMsgID = 32
+)
+
+// syslogSeverity maps catalogue severities onto RFC 5424 severities.
+var syslogSeverity = map[audit.Severity]int{
+	audit.SeverityInfo:     6,
+	audit.SeverityNotice:   5,
+	audit.SeverityWarning:  4,
+	audit.SeverityCritical: 2,
+}
+
+// utf8BOM marks the MSG part as UTF-8 (RFC 5424 section 6.4).
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// SyslogSink sends every event as an RFC 5424 message with octet-counting
+// framing (RFC 6587 over TCP, RFC 5425 over TLS). The MSG is the event as JSON.
+// Syslog has no acknowledgement: a batch counts as delivered once all of it was
+// written to the connection.
+type SyslogSink struct {
+	Addr      string // host:port
+	TLS       bool
+	Hostname  string      // HOSTNAME field; "-" when empty
+	Dialer    *net.Dialer // nil: refuse private networks (guardedDialer)
+	TLSConfig *tls.Config // Base TLS config; nil uses the system roots
+}
+
+func (s *SyslogSink) Deliver(ctx context.Context, events []Event) error {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("syslog connect: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var buf bytes.Buffer
+	for _, e := range events {
+		msg, err := formatSyslog(e, s.Hostname)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(strconv.Itoa(len(msg)))
+		buf.WriteByte(' ')
+		buf.Write(msg)
+	}
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("syslog write: %w", err)
+	}
+	return nil
+}
+
+func (s *SyslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := s.Dialer
+	if dialer == nil {
+		dialer = guardedDialer()
+	}
+	if !s.TLS {
+		return dialer.DialContext(ctx, "tcp", s.Addr)
+	}
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{}
+	if s.TLSConfig != nil {
+		cfg = s.TLSConfig.Clone()
+	}
+	cfg.ServerName = host
+	cfg.MinVersion = tls.VersionTLS12
+	return (&tls.Dialer{NetDialer: dialer, Config: cfg}).DialContext(ctx, "tcp", s.Addr)
+}
+
+// formatSyslog renders one RFC 5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA BOM MSG
+func formatSyslog(e Event, hostname string) ([]byte, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode event: %w", err)
+	}
+	severity, ok := syslogSeverity[e.Severity]
+	if !ok {
+		severity = syslogSeverity[audit.SeverityInfo]
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "<%d>1 %s %s %s - %s - ",
+		syslogFacility*8+severity,
+		e.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(hostname, 255),
+		syslogAppName,
+		headerField(e.Action, syslogMaxMsgID),
+	)
+	msg.Write(utf8BOM)
+	msg.Write(body)
+	return msg.Bytes(), nil
+}
+
+// headerField makes a header field valid: printable US-ASCII without spaces,
+// at most max characters, "-" when empty.
+func headerField(v string, max int) string {
+	out := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(out) < max; i++ {
+		c := v[i]
+		if c < 33 || c > 126 {
+			c = '_'
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		return "-"
+	}
+	return string(out)
+}
+
+// syslogHost validates a syslog endpoint (host:port) and returns the host.
+func syslogHost(endpoint string) (string, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil || host == "" {
+		return "", fmt.Errorf("%w: syslog endpoint must be host:port", ErrInvalidSink)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("%w: invalid syslog port", ErrInvalidSink)
+	}
+	return host, nil
+}
+
+// Compile-time check
+var _ Sink = (*SyslogSink)(nil)
@@ -0,0 +1,111 @@
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Webhook request headers. The signature covers "<timestamp>.<body>", so a
+// receiver can reject replays by checking the timestamp.
+const (
+	HeaderTimestamp = "X-LaventeCare-Timestamp"
+	HeaderSignature = "X-LaventeCare-Signature"
+)
+
+// WebhookSink POSTs a batch as {"events": [...]} to an HTTPS URL. Any 2xx
+// response acknowledges the whole batch; redirects are not followed.
+type WebhookSink struct {
+	URL       string
+	Secret    []byte
+	Dialer    *net.Dialer // nil: refuse private networks (guardedDialer)
+	TLSConfig *tls.Config // Base TLS config; nil uses the system roots
+}
+
+// Sign returns the X-LaventeCare-Signature value for a request.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookSink) Deliver(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(struct {
+		Events []Event `json:"events"`
+	}{events})
+	if err != nil {
+		return fmt.Errorf("encode events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LaventeCare-AuditSink/1")
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(s.Secret, ts, body))
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSink) client() *http.Client {
+	dialer := s.Dialer
+	if dialer == nil {
+		dialer = guardedDialer()
+	}
+	cfg := &tls.Config{}
+	if s.TLSConfig != nil {
+		cfg = s.TLSConfig.Clone()
+	}
+	cfg.MinVersion = tls.VersionTLS12
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:     dialer.DialContext,
+			TLSClientConfig: cfg,
+			Proxy:           nil, // A proxy would bypass the dial-time address check
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookURL validates a webhook endpoint: an absolute https URL without credentials.
+func webhookURL(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: webhook endpoint must be an https URL", ErrInvalidSink)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: webhook endpoint must not contain credentials", ErrInvalidSink)
+	}
+	return u, nil
+}
+
+// Compile-time check
+var _ Sink = (*WebhookSink)(nil)
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultAccountDeletionCoolingOff applies when AuthConfig leaves it unset.
+const DefaultAccountDeletionCoolingOff = 14 * 24 * time.Hour
+
+var (
+	ErrAccountPendingDeletion = errors.New("account is scheduled for deletion")
+	ErrInvalidCancelToken     = errors.New("invalid or expired cancellation link")
+)
+
+// ScheduleAccountDeletion closes the caller's account after re-authentication
+// (password, plus a TOTP code when MFA is enabled). All sessions are revoked
+// now and AuthMiddleware refuses access tokens that were already issued;
+// cmd/worker purges the account once the cooling-off period has passed.
+// Returns the moment of the purge.
+func (s *AuthService) ScheduleAccountDeletion(ctx context.Context, userID, tenantID uuid.UUID, password, totpCode string) (time.Time, error) {
+	q := s.txQueries(ctx)
+
+	// 1. Re-authenticate: a stolen access token alone must not delete an account
+	user, err := q.GetUserInTenant(ctx, db.GetUserInTenantParams{
+		ID:       pgtype.UUID{Bytes: userID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if err != nil {
+		return time.Time{}, ErrUserNotFound
+	}
+	if !user.PasswordHash.Valid {
+		return time.Time{}, ErrInvalidCredentials
+	}
+	if err := s.passwordHasher.Compare(user.PasswordHash.String, password); err != nil {
+		return time.Time{}, ErrInvalidCredentials
+	}
+	if user.MfaEnabled && (!user.MfaSecret.Valid || !s.mfaService.ValidateCode(totpCode, user.MfaSecret.String)) {
+		return time.Time{}, ErrInvalidCode
+	}
+
+	// 2. Schedule; the raw cancel token only travels in the email
+	token, err := GenerateSecureToken(32)
+	if err != nil {
+		return time.Time{}, err
+	}
+	coolingOff := s.config.AccountDeletionCoolingOff
+	if coolingOff <= 0 {
+		coolingOff = DefaultAccountDeletionCoolingOff
+	}
+	deletion, err := q.ScheduleAccountDeletion(ctx, db.ScheduleAccountDeletionParams{
+		TenantID:        pgtype.UUID{Bytes: tenantID, Valid: true},
+		UserID:          user.ID,
+		CancelTokenHash: hashToken(token),
+		PurgeAfter:      pgtype.Timestamptz{Time: time.Now().Add(coolingOff), Valid: true},
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return time.Time{}, ErrAccountPendingDeletion
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// 3. Close the account now: every device is signed out, Login refuses it
+	if err := q.RevokeAllSessions(ctx, user.ID); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := s.mail.SendAccountDeletion(ctx, user.Email, token, s.appURL(ctx, tenantID), deletion.PurgeAfter.Time); err != nil {
+		return time.Time{}, err
+	}
+
+	// AUDIT LOG: IDs only; the user ID becomes a pseudonym once the row is purged
+	s.audit.Log(ctx, audit.EventDeletionScheduled, audit.LogParams{
+		ActorID:  userID,
+		TargetID: userID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"purge_after":          deletion.PurgeAfter.Time,
+			"revoked_all_sessions": true,
+		},
+	})
+
+	return deletion.PurgeAfter.Time, nil
+}
+
+// CancelAccountDeletion reopens an account through the emailed link. Sessions
+// are not restored: the member simply logs in again.
+func (s *AuthService) CancelAccountDeletion(ctx context.Context, rawToken string) error {
+	deletion, err := s.txQueries(ctx).CancelAccountDeletion(ctx, hashToken(rawToken))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInvalidCancelToken
+	}
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventDeletionCancelled, audit.LogParams{
+		ActorID:  deletion.UserID.Bytes,
+		TargetID: deletion.UserID.Bytes,
+		TenantID: deletion.TenantID.Bytes,
+	})
+	return nil
+}
+
+// ensureNotPendingDeletion refuses sessions for accounts in their cooling-off period.
+func (s *AuthService) ensureNotPendingDeletion(ctx context.Context, userID pgtype.UUID) error {
+	_, err := s.txQueries(ctx).GetScheduledAccountDeletion(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrAccountPendingDeletion
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/notify"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// lockTokenType marks the verification_tokens row behind a "this wasn't me" link.
+	lockTokenType = "account_lock"
+	// lockTokenTTL is how long a security notification can lock the account.
+	lockTokenTTL = 7 * 24 * time.Hour
+)
+
+// Protections that refuse sign-in to an address for a while (metadata of auth.lockout).
+const (
+	ThrottledByRateLimit = "rate_limit"
+	ThrottledByChallenge = "challenge"
+)
+
+var ErrInvalidLockToken = errors.New("invalid or expired account lock link")
+
+// accountLocked reports whether sign-in is refused because the owner used a
+// "this wasn't me" link: locked_until is infinity then, until a password reset.
+func accountLocked(user db.User, now time.Time) bool {
+	if !user.LockedUntil.Valid {
+		return false
+	}
+	return user.LockedUntil.InfinityModifier == pgtype.Infinity || user.LockedUntil.Time.After(now)
+}
+
+// NotifySignInThrottled tells the owner of email that the login rate limit or
+// the challenge gate refuses sign-ins to their address until until. Unknown
+// addresses get nothing. Callers send at most one notice per address per
+// window (see middleware.SignInNotices).
+//
+// The request behind it was already rejected and its transaction rolls back,
+// so the lock token is written through the pool: the notice reports that
+// rejection and has to outlive it. ctx must not carry the request transaction.
+func (s *AuthService) NotifySignInThrottled(ctx context.Context, tenantID uuid.UUID, email, protection string, until time.Time) {
+	user, err := s.queries.GetUserByEmail(ctx, db.GetUserByEmailParams{
+		Email:    email,
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.Error("SignInThrottled: User lookup failed", "tenant_id", tenantID, "error", err)
+		}
+		return
+	}
+
+	s.audit.Log(ctx, audit.EventLoginLockout, audit.LogParams{
+		TargetID: user.ID.Bytes,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"protection": protection,
+			"until":      until.UTC().Format(time.RFC3339),
+		},
+	})
+	s.notifySecurityEvent(ctx, s.queries, user, func(notice notify.SecurityNotice) error {
+		return s.mail.SendAccountLocked(ctx, user.Email, notice, until)
+	})
+}
+
+// notifySecurityEvent queues a security notification to user; q stores the
+// token of its lock link. The change it reports has already been made, so a
+// failure is logged rather than returned.
+func (s *AuthService) notifySecurityEvent(ctx context.Context, q *db.Queries, user db.User, send func(notify.SecurityNotice) error) {
+	notice, err := s.securityNotice(ctx, q, user)
+	if err == nil {
+		err = send(notice)
+	}
+	if err != nil {
+		slog.Error("SecurityNotice: Failed to queue", "user_id", uuid.UUID(user.ID.Bytes), "error", err)
+	}
+}
+
+// securityNotice describes the current request and issues the token of its
+// "this wasn't me" link. Only the hash is stored, like reset tokens.
+func (s *AuthService) securityNotice(ctx context.Context, q *db.Queries, user db.User) (notify.SecurityNotice, error) {
+	token, err := GenerateSecureToken(32)
+	if err != nil {
+		return notify.SecurityNotice{}, err
+	}
+	now := time.Now()
+	_, err = q.CreateVerificationToken(ctx, db.CreateVerificationTokenParams{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		Type:      lockTokenType,
+		TenantID:  user.TenantID,
+		ExpiresAt: pgtype.Timestamptz{Time: now.Add(lockTokenTTL), Valid: true},
+	})
+	if err != nil {
+		return notify.SecurityNotice{}, err
+	}
+
+	notice := notify.SecurityNotice{
+		Time:      now,
+		LockToken: token,
+		AppURL:    s.appURL(ctx, user.TenantID.Bytes),
+	}
+	if meta, ok := requestmeta.From(ctx); ok {
+		if meta.IP.IsValid() {
+			notice.IP = meta.IP.String()
+		}
+		notice.Device = describeDevice(meta.UserAgent)
+	}
+	return notice, nil
+}
+
+// LockAccount handles the "this wasn't me" link of a security notification:
+// the account is locked until a password reset and every session is revoked.
+func (s *AuthService) LockAccount(ctx context.Context, tenantID uuid.UUID, rawToken string) error {
+	var token db.VerificationToken
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		token, err = q.GetVerificationToken(ctx, hashToken(rawToken))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidLockToken
+		}
+		if err != nil {
+			return err
+		}
+		// Reset and verification tokens share the table: only a lock token counts,
+		// and only within the tenant the user belongs to
+		if token.Type != lockTokenType || uuid.UUID(token.TenantID.Bytes) != tenantID || time.Now().After(token.ExpiresAt.Time) {
+			return ErrInvalidLockToken
+		}
+
+		if err := q.LockUserAccount(ctx, db.LockUserAccountParams{
+			ID:          token.UserID,
+			LockedUntil: pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true},
+		}); err != nil {
+			return err
+		}
+		if err := q.RevokeAllSessions(ctx, token.UserID); err != nil {
+			return err
+		}
+		return q.DeleteVerificationToken(ctx, token.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventAccountLockedByOwner, audit.LogParams{
+		ActorID:  token.UserID.Bytes,
+		TargetID: token.UserID.Bytes,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"revoked_all_sessions": true,
+		},
+	})
+	return nil
+}
+
+// describeDevice turns a user agent into "Browser on OS" for security
+// notifications. Unrecognised agents give "": the raw header is attacker
+// controlled and does not belong in an email.
+func describeDevice(userAgent string) string {
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"}, // Before Chrome and Safari, which Edge also claims
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	})
+	platform := firstMatch(userAgent, [][2]string{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"}, // Before Mac OS X, which iOS agents also contain
+		{"iPad", "iPadOS"},
+		{"Android", "Android"}, // Before Linux
+		{"CrOS", "ChromeOS"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	})
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	default:
+		return platform
+	}
+}
+
+// firstMatch returns the name paired with the first token found in s.
+func firstMatch(s string, tokens [][2]string) string {
+	for _, t := range tokens {
+		if strings.Contains(s, t[0]) {
+			return t[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0", "Firefox on Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15", "Safari on macOS"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/126.0 Mobile/15E148 Safari/604.1", "Chrome on iOS"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (X11; Linux x86_64)", "Linux"},
+		{"curl/8.7.1", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, describeDevice(tt.userAgent), tt.userAgent)
+	}
+}
+
+func TestAccountLocked(t *testing.T) {
+	now := time.Now()
+	at := func(ts time.Time) db.User {
+		return db.User{LockedUntil: pgtype.Timestamptz{Time: ts, Valid: true}}
+	}
+	assert.False(t, accountLocked(db.User{}, now))
+	assert.False(t, accountLocked(at(now.Add(-time.Minute)), now))
+	assert.True(t, accountLocked(at(now.Add(time.Minute)), now))
+	assert.True(t, accountLocked(db.User{LockedUntil: pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true}}, now))
+}
@@ -0,0 +1,186 @@
+package auth_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userEmail(t *testing.T, pool *pgxpool.Pool, userID uuid.UUID) string {
+	t.Helper()
+	var email string
+	require.NoError(t, pool.QueryRow(context.Background(), `SELECT email FROM users WHERE id = $1`, userID).Scan(&email))
+	return email
+}
+
+func TestEmailChange_ConfirmThenRevert(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	userID := createTestUser(t, pool, tenantID, "old@example.com", "Password-123")
+
+	require.NoError(t, svc.RequestEmailChange(ctx, userID, tenantID, "new@example.com", "Password-123"))
+	token := mail.token(t, "email_change", "new@example.com")
+	revertToken := mail.token(t, "email_change_notice", "old@example.com")
+
+	// Only within the user's own tenant
+	assert.ErrorIs(t, svc.ConfirmEmailChange(ctx, createTestTenant(t, pool), token), auth.ErrInvalidEmailChangeToken)
+
+	require.NoError(t, svc.ConfirmEmailChange(ctx, tenantID, token))
+	assert.Equal(t, "new@example.com", userEmail(t, pool, userID))
+	assert.ErrorIs(t, svc.ConfirmEmailChange(ctx, tenantID, token), auth.ErrInvalidEmailChangeToken, "single use")
+
+	require.NoError(t, svc.RevertEmailChange(ctx, tenantID, revertToken))
+	assert.Equal(t, "old@example.com", userEmail(t, pool, userID))
+	assert.ErrorIs(t, svc.RevertEmailChange(ctx, tenantID, revertToken), auth.ErrInvalidEmailChangeToken, "single use")
+}
+
+func TestEmailChange_ConfirmAfterRevert(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	userID := createTestUser(t, pool, tenantID, "old@example.com", "Password-123")
+
+	require.NoError(t, svc.RequestEmailChange(ctx, userID, tenantID, "new@example.com", "Password-123"))
+	require.NoError(t, svc.RevertEmailChange(ctx, tenantID, mail.token(t, "email_change_notice", "old@example.com")))
+
+	// The cancelled request can no longer be confirmed
+	assert.ErrorIs(t, svc.ConfirmEmailChange(ctx, tenantID, mail.token(t, "email_change", "new@example.com")), auth.ErrInvalidEmailChangeToken)
+	assert.Equal(t, "old@example.com", userEmail(t, pool, userID))
+}
+
+func TestEmailChange_ExpiredLinks(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	userID := createTestUser(t, pool, tenantID, "old@example.com", "Password-123")
+
+	require.NoError(t, svc.RequestEmailChange(ctx, userID, tenantID, "new@example.com", "Password-123"))
+	token := mail.token(t, "email_change", "new@example.com")
+	revertToken := mail.token(t, "email_change_notice", "old@example.com")
+
+	t.Run("ConfirmAfterExpiry", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE email_change_requests SET expires_at = NOW() - INTERVAL '1 minute' WHERE user_id = $1`, userID)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, svc.ConfirmEmailChange(ctx, tenantID, token), auth.ErrInvalidEmailChangeToken)
+		assert.Equal(t, "old@example.com", userEmail(t, pool, userID))
+	})
+
+	t.Run("RevertAfterExpiry", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE email_change_requests SET expires_at = NOW() + INTERVAL '1 hour' WHERE user_id = $1`, userID)
+		require.NoError(t, err)
+		require.NoError(t, svc.ConfirmEmailChange(ctx, tenantID, token))
+		_, err = pool.Exec(ctx, `UPDATE email_change_requests SET revert_expires_at = NOW() - INTERVAL '1 minute' WHERE user_id = $1`, userID)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, svc.RevertEmailChange(ctx, tenantID, revertToken), auth.ErrInvalidEmailChangeToken)
+		assert.Equal(t, "new@example.com", userEmail(t, pool, userID))
+	})
+}
+
+func TestEmailChange_DuplicateEmail(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	userID := createTestUser(t, pool, tenantID, "old@example.com", "Password-123")
+	createTestUser(t, pool, tenantID, "taken@example.com", "Password-123")
+
+	t.Run("TakenAtRequest", func(t *testing.T) {
+		assert.ErrorIs(t, svc.RequestEmailChange(ctx, userID, tenantID, "taken@example.com", "Password-123"), auth.ErrEmailTaken)
+		assert.ErrorIs(t, svc.RequestEmailChange(ctx, userID, tenantID, "OLD@example.com", "Password-123"), auth.ErrEmailTaken)
+	})
+
+	t.Run("UniquePerTenant", func(t *testing.T) {
+		otherTenant := createTestTenant(t, pool)
+		createTestUser(t, pool, otherTenant, "elsewhere@example.com", "Password-123")
+
+		assert.NoError(t, svc.RequestEmailChange(ctx, userID, tenantID, "elsewhere@example.com", "Password-123"))
+	})
+
+	t.Run("TakenBeforeConfirm", func(t *testing.T) {
+		require.NoError(t, svc.RequestEmailChange(ctx, userID, tenantID, "late@example.com", "Password-123"))
+		token := mail.token(t, "email_change", "late@example.com")
+		otherID := createTestUser(t, pool, tenantID, "late@example.com", "Password-123")
+
+		// The unique constraint (23505) is reported as taken and nothing is committed
+		assert.ErrorIs(t, svc.ConfirmEmailChange(ctx, tenantID, token), auth.ErrEmailTaken)
+		assert.Equal(t, "old@example.com", userEmail(t, pool, userID))
+
+		// Once the address is free again the same link still works
+		_, err := pool.Exec(ctx, `UPDATE users SET email = 'moved@example.com' WHERE id = $1`, otherID)
+		require.NoError(t, err)
+		assert.NoError(t, svc.ConfirmEmailChange(ctx, tenantID, token))
+	})
+}
+
+// Confirm and revert lock the request row, so whichever runs first, the
+// account ends up on the old address.
+func TestEmailChange_ConcurrentConfirmAndRevert(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+
+	for i := range 10 {
+		oldEmail := uuid.NewString() + "@example.com"
+		newEmail := uuid.NewString() + "@example.com"
+		userID := createTestUser(t, pool, tenantID, oldEmail, "Password-123")
+		require.NoError(t, svc.RequestEmailChange(ctx, userID, tenantID, newEmail, "Password-123"))
+		token := mail.token(t, "email_change", newEmail)
+		revertToken := mail.token(t, "email_change_notice", oldEmail)
+
+		var wg sync.WaitGroup
+		var confirmErr, revertErr error
+		wg.Add(2)
+		go func() { defer wg.Done(); confirmErr = svc.ConfirmEmailChange(ctx, tenantID, token) }()
+		go func() { defer wg.Done(); revertErr = svc.RevertEmailChange(ctx, tenantID, revertToken) }()
+		wg.Wait()
+
+		require.NoError(t, revertErr, "round %d", i)
+		if confirmErr != nil {
+			assert.ErrorIs(t, confirmErr, auth.ErrInvalidEmailChangeToken, "round %d", i)
+		}
+		assert.Equal(t, oldEmail, userEmail(t, pool, userID), "round %d", i)
+	}
+}
+
+// A member of a joined tenant changes the address of their home account; the
+// links carry the joined tenant and must work there.
+func TestEmailChange_FromJoinedTenant(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	homeTenant := createTestTenant(t, pool)
+	joinedTenant := createTestTenant(t, pool)
+	userID := createTestUser(t, pool, homeTenant, "old@example.com", "Password-123")
+	createTestUser(t, pool, homeTenant, "taken@example.com", "Password-123")
+	createTestUser(t, pool, joinedTenant, "new@example.com", "Password-123")
+
+	_, invite, err := svc.CreateInvitation(ctx, "old@example.com", joinedTenant, "user", uuid.Nil)
+	require.NoError(t, err)
+	_, err = svc.AcceptInvitationAsMember(ctx, userID, homeTenant, invite)
+	require.NoError(t, err)
+
+	// Uniqueness is checked in the home tenant, not the session tenant
+	assert.ErrorIs(t, svc.RequestEmailChange(ctx, userID, joinedTenant, "taken@example.com", "Password-123"), auth.ErrEmailTaken)
+	require.NoError(t, svc.RequestEmailChange(ctx, userID, joinedTenant, "new@example.com", "Password-123"))
+	token := mail.token(t, "email_change", "new@example.com")
+	revertToken := mail.token(t, "email_change_notice", "old@example.com")
+
+	require.NoError(t, svc.ConfirmEmailChange(ctx, joinedTenant, token))
+	assert.Equal(t, "new@example.com", userEmail(t, pool, userID))
+
+	require.NoError(t, svc.RevertEmailChange(ctx, joinedTenant, revertToken))
+	assert.Equal(t, "old@example.com", userEmail(t, pool, userID))
+}
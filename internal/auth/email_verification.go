@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrEmailNotVerified = errors.New("email address is not verified")
+
+// verificationResendInterval throttles the automatic re-send on refused logins,
+// so hammering the login form does not flood the member's inbox.
+const verificationResendInterval = 5 * time.Minute
+
+// emailVerificationPolicy loads the tenant's policy; tokens without a tenant get "off".
+func (s *AuthService) emailVerificationPolicy(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	if tenantID == uuid.Nil {
+		return domain.EmailVerificationOff, nil
+	}
+	tenant, err := s.txQueries(ctx).GetTenantByID(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		return "", err
+	}
+	return tenant.Settings.EmailVerificationPolicy(), nil
+}
+
+// emailVerifiedClaim is the email_verified claim for the access token: only
+// present when the tenant has a policy, so "off" tenants see no change.
+func emailVerifiedClaim(policy string, user db.User) *bool {
+	if policy == domain.EmailVerificationOff {
+		return nil
+	}
+	verified := user.IsEmailVerified
+	return &verified
+}
+
+// refuseUnverifiedLogin enforces the "enforce" policy after a correct password.
+// The verification mail is re-sent so the member has a way forward; failures
+// there are logged, the login is refused either way.
+func (s *AuthService) refuseUnverifiedLogin(ctx context.Context, tenant db.Tenant, user db.User) error {
+	if user.IsEmailVerified || tenant.Settings.EmailVerificationPolicy() != domain.EmailVerificationEnforce {
+		return nil
+	}
+
+	recent, err := s.txQueries(ctx).CountRecentVerificationTokens(ctx, db.CountRecentVerificationTokensParams{
+		UserID:    user.ID,
+		Type:      "email_verify",
+		CreatedAt: pgtype.Timestamptz{Time: time.Now().Add(-verificationResendInterval), Valid: true},
+	})
+	switch {
+	case err != nil:
+		slog.Warn("Login: Verification throttle lookup failed", "user_id", uuid.UUID(user.ID.Bytes), "error", err)
+	case recent == 0:
+		if err := s.RequestEmailVerification(ctx, user.Email, uuid.UUID(tenant.ID.Bytes)); err != nil {
+			slog.Warn("Login: Verification re-send failed", "user_id", uuid.UUID(user.ID.Bytes), "error", err)
+		}
+	}
+	return ErrEmailNotVerified
+}
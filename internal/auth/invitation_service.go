@@ -2,53 +2,353 @@ package auth
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// CreateInvitation generates a secure invite link for a new user.
-func (s *AuthService) CreateInvitation(ctx context.Context, email string, tenantID uuid.UUID, role string) (string, error) {
-	// Generate Token
+// DefaultInvitationExpiry applies when the tenant has no invitation_expiry_days.
+const DefaultInvitationExpiry = 7 * 24 * time.Hour
+
+// MaxBulkInvitations caps the rows of one CSV upload.
+const MaxBulkInvitations = 500
+
+// Invitation statuses as listed by GET /admin/invitations.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+var (
+	ErrInvitationNotFound      = errors.New("invitation not found or no longer open")
+	ErrAlreadyInvited          = errors.New("an open invitation already exists for this email")
+	ErrAlreadyMember           = errors.New("user is already a member of this tenant")
+	ErrInvalidInviteEmail      = errors.New("invalid email address")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+	ErrTooManyInvitations      = fmt.Errorf("too many rows: at most %d invitations per upload", MaxBulkInvitations)
+)
+
+// InvitationStatus derives the status shown to admins. Keep it in sync with
+// the CASE in the ListInvitations and CountInvitations queries.
+func InvitationStatus(invite db.Invitation, now time.Time) string {
+	switch {
+	case invite.RevokedAt.Valid:
+		return InvitationRevoked
+	case invite.Accepted:
+		return InvitationAccepted
+	case !invite.ExpiresAt.Time.After(now):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+// CreateInvitation records an invitation and mails the link. It returns the
+// invitation and the raw token (the link carries it, the database only the hash).
+func (s *AuthService) CreateInvitation(ctx context.Context, email string, tenantID uuid.UUID, role string, invitedBy uuid.UUID) (db.Invitation, string, error) {
+	if err := validateInviteEmail(email); err != nil {
+		return db.Invitation{}, "", err
+	}
 	token, err := GenerateSecureToken(32)
 	if err != nil {
-		return "", err
+		return db.Invitation{}, "", err
 	}
-	hash := hashToken(token)
+	expiry, err := s.invitationExpiry(ctx, tenantID)
+	if err != nil {
+		return db.Invitation{}, "", err
+	}
+	inviteURL := s.invitationURL(ctx, tenantID, token)
 
-	// DB Operation with RLS
-	// Note: We use WithRLS even though Invitations RLS is disabled in Migration 006 for SELECT.
-	// But for INSERT, RLS typically applies if not bypassed.
-	// Actually we disabled it for invitations temporarily in 006. But let's future-proof it.
+	// The mail is queued inside the transaction: if it fails, no unusable
+	// invitation is left behind to block a retry.
+	var invite db.Invitation
 	err = s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
-		_, err := q.CreateInvitation(ctx, db.CreateInvitationParams{
+		member, err := q.IsTenantMemberByEmail(ctx, db.IsTenantMemberByEmailParams{
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+			Email:    email,
+		})
+		if err != nil {
+			return err
+		}
+		if member {
+			return ErrAlreadyMember
+		}
+
+		invite, err = q.CreateInvitation(ctx, db.CreateInvitationParams{
 			Email:     email,
-			TokenHash: hash,
+			TokenHash: hashToken(token),
 			TenantID:  pgtype.UUID{Bytes: tenantID, Valid: true},
 			Role:      role,
-			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(7 * 24 * time.Hour), Valid: true},
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(expiry), Valid: true},
+			InvitedBy: pgtype.UUID{Bytes: invitedBy, Valid: invitedBy != uuid.Nil},
 		})
-		return err
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrAlreadyInvited
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.mail.SendInvitation(ctx, email, inviteURL); err != nil {
+			return fmt.Errorf("failed to send invite email: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return db.Invitation{}, "", err
+	}
+
+	s.audit.Log(ctx, audit.EventInvitationCreated, audit.LogParams{
+		ActorID:  invitedBy,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"invitation_id": uuid.UUID(invite.ID.Bytes),
+			"role":          role,
+			"expires_at":    invite.ExpiresAt.Time,
+		},
+	})
+
+	return invite, token, nil
+}
+
+// ListInvitations returns a page of the tenant's invitations, newest first,
+// and the total for the status filter ("" lists all).
+func (s *AuthService) ListInvitations(ctx context.Context, tenantID uuid.UUID, status string, limit, offset int32) ([]db.Invitation, int64, error) {
+	q := s.txQueries(ctx)
+	invites, err := q.ListInvitations(ctx, db.ListInvitationsParams{
+		TenantID:   pgtype.UUID{Bytes: tenantID, Valid: true},
+		Status:     status,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := q.CountInvitations(ctx, db.CountInvitationsParams{
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		Status:   status,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return invites, total, nil
+}
+
+// ResendInvitation mails a fresh link for a pending or expired invitation.
+// The token is rotated, so earlier links stop working, and the expiry restarts.
+func (s *AuthService) ResendInvitation(ctx context.Context, tenantID, invitationID, actorID uuid.UUID) (db.Invitation, error) {
+	token, err := GenerateSecureToken(32)
+	if err != nil {
+		return db.Invitation{}, err
+	}
+	expiry, err := s.invitationExpiry(ctx, tenantID)
+	if err != nil {
+		return db.Invitation{}, err
+	}
+	inviteURL := s.invitationURL(ctx, tenantID, token)
+
+	var invite db.Invitation
+	err = s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
+		var err error
+		invite, err = q.RotateInvitationToken(ctx, db.RotateInvitationTokenParams{
+			ID:        pgtype.UUID{Bytes: invitationID, Valid: true},
+			TenantID:  pgtype.UUID{Bytes: tenantID, Valid: true},
+			TokenHash: hashToken(token),
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(expiry), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.mail.SendInvitation(ctx, invite.Email, inviteURL); err != nil {
+			return fmt.Errorf("failed to send invite email: %w", err)
+		}
+		return nil
 	})
 	if err != nil {
-		return "", err
+		return db.Invitation{}, err
 	}
 
-	// In real app: Send Email with link /register?invite=token
-	inviteURL := fmt.Sprintf("https://auth.lavente.care/register?invite=%s", token)
-	if err := s.mail.SendInvitation(ctx, email, inviteURL); err != nil {
-		// Log error but don't fail the transaction?
-		// "Velvet Rope" says we must communicate. If mail fails, the invite is useless.
-		// So we should probably delete it or return error.
-		// For MVP, just return error.
-		return "", fmt.Errorf("failed to send invite email: %w", err)
+	s.audit.Log(ctx, audit.EventInvitationResent, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"invitation_id": invitationID,
+			"send_count":    invite.SendCount,
+			"expires_at":    invite.ExpiresAt.Time,
+		},
+	})
+	return invite, nil
+}
+
+// RevokeInvitation invalidates an open invitation. The row stays as history.
+func (s *AuthService) RevokeInvitation(ctx context.Context, tenantID, invitationID, actorID uuid.UUID) error {
+	_, err := s.txQueries(ctx).RevokeInvitation(ctx, db.RevokeInvitationParams{
+		ID:       pgtype.UUID{Bytes: invitationID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInvitationNotFound
 	}
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventInvitationRevoked, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"invitation_id": invitationID,
+		},
+	})
+	return nil
+}
 
-	return token, nil
+// InvitationRow is one line of a bulk invite upload.
+type InvitationRow struct {
+	Line  int
+	Email string
+	Role  string // Empty: the upload's default role
+}
+
+// InvitationResult reports the outcome of one InvitationRow.
+type InvitationResult struct {
+	Line         int        `json:"line"`
+	Email        string     `json:"email"`
+	Status       string     `json:"status"` // "invited" or "failed"
+	InvitationID *uuid.UUID `json:"invitation_id,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// ParseInvitationCSV reads "email[,role]" lines. A first line starting with
+// "email" is treated as a header; blank lines are skipped.
+func ParseInvitationCSV(r io.Reader) ([]InvitationRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []InvitationRow
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		email := strings.TrimSpace(record[0])
+		if first && strings.EqualFold(email, "email") {
+			continue
+		}
+		if email == "" && len(record) == 1 {
+			continue
+		}
+		row := InvitationRow{Line: line, Email: email}
+		if len(record) > 1 {
+			row.Role = strings.TrimSpace(record[1])
+		}
+		if len(rows) == MaxBulkInvitations {
+			return nil, ErrTooManyInvitations
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// BulkInvite invites every row independently: one bad row does not stop the
+// others. Each role is checked against actorPerms like a single invite.
+func (s *AuthService) BulkInvite(ctx context.Context, tenantID, invitedBy uuid.UUID, actorPerms []string, defaultRole string, rows []InvitationRow) []InvitationResult {
+	roleChecks := map[string]error{}
+	results := make([]InvitationResult, len(rows))
+	for i, row := range rows {
+		result := InvitationResult{Line: row.Line, Email: row.Email, Status: "failed"}
+		role := row.Role
+		if role == "" {
+			role = defaultRole
+		}
+
+		err, checked := roleChecks[role]
+		if !checked {
+			err = s.CheckRoleAssignable(ctx, tenantID, role, actorPerms)
+			roleChecks[role] = err
+		}
+		if err == nil {
+			var invite db.Invitation
+			invite, _, err = s.CreateInvitation(ctx, row.Email, tenantID, role, invitedBy)
+			if err == nil {
+				id := uuid.UUID(invite.ID.Bytes)
+				result.Status, result.InvitationID = "invited", &id
+			}
+		}
+
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrAlreadyInvited):
+			// Point the admin at the open invitation so it can be resent instead
+			open, lookupErr := s.txQueries(ctx).GetOpenInvitationByEmail(ctx, db.GetOpenInvitationByEmailParams{
+				TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+				Email:    row.Email,
+			})
+			if lookupErr == nil {
+				id := uuid.UUID(open.ID.Bytes)
+				result.InvitationID = &id
+			}
+			result.Error = err.Error()
+		case errors.Is(err, ErrInvalidInviteEmail), errors.Is(err, ErrAlreadyMember),
+			errors.Is(err, ErrRoleNotFound), errors.Is(err, ErrPermissionEscalation):
+			result.Error = err.Error()
+		default:
+			slog.Error("BulkInvite: Row failed", "tenant_id", tenantID, "line", row.Line, "error", err)
+			result.Error = "internal error"
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// validateInviteEmail accepts a bare address only (no display name).
+func validateInviteEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidInviteEmail
+	}
+	return nil
+}
+
+// invitationExpiry is the tenant's configured link lifetime.
+func (s *AuthService) invitationExpiry(ctx context.Context, tenantID uuid.UUID) (time.Duration, error) {
+	tenant, err := s.txQueries(ctx).GetTenantByID(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load tenant: %w", err)
+	}
+	if days := tenant.Settings.InvitationExpiryDays; days > 0 {
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return DefaultInvitationExpiry, nil
+}
+
+// invitationURL is the registration link on the tenant's own app.
+func (s *AuthService) invitationURL(ctx context.Context, tenantID uuid.UUID, token string) string {
+	return s.appURL(ctx, tenantID) + "/register?invite=" + url.QueryEscape(token)
 }
 
 // ValidateInvitation checks if a token is valid and returns the invite details.
@@ -61,42 +361,77 @@ func (s *AuthService) ValidateInvitation(ctx context.Context, token string) (*db
 	return &invite, nil
 }
 
-// RegisterWithInvite registers a user and automatically adds them to the tenant defined in the invite.
-func (s *AuthService) RegisterWithInvite(ctx context.Context, input RegisterInput, inviteToken string) (*db.User, error) {
-	// 1. Validate Invite
-	invite, err := s.ValidateInvitation(ctx, inviteToken)
+// AcceptInvitationAsMember lets a signed-in user join the invitation's tenant
+// with the account they already have: only a membership is created, so the
+// (email, tenant_id) uniqueness of users never comes into play. The invitation
+// must be addressed to the user's own email. Returns the claimed invitation.
+func (s *AuthService) AcceptInvitationAsMember(ctx context.Context, userID, tenantID uuid.UUID, rawToken string) (db.Invitation, error) {
+	user, err := s.txQueries(ctx).GetUserInTenant(ctx, db.GetUserInTenantParams{
+		ID:       pgtype.UUID{Bytes: userID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
 	if err != nil {
-		return nil, err
+		return db.Invitation{}, ErrUserNotFound
 	}
 
-	if invite.Email != input.Email {
-		return nil, errors.New("email does not match invitation")
+	hash := hashToken(rawToken)
+	invite, err := s.queries.GetInvitationByHash(ctx, hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Invitation{}, ErrInvitationNotFound
 	}
-
-	// 2. Register User (Reuse existing logic or call internal helper)
-	user, err := s.Register(ctx, input) // This creates user + default tenant.
-	// Issue: Register creates a default tenant. For invitee, we want to join EXISTING tenant.
-	// Refactor: We need `Register` to accept `SkipDefaultTenantCreation`.
-	// For MVP: let them create a personal tenant (default) AND join the invited tenant.
 	if err != nil {
-		return nil, err
+		return db.Invitation{}, err
+	}
+	if !strings.EqualFold(invite.Email, user.Email) {
+		return db.Invitation{}, ErrInvitationEmailMismatch
 	}
 
-	// 3. Add to Invited Tenant
-	_, err = s.queries.CreateMembership(ctx, db.CreateMembershipParams{
-		UserID:   user.ID,
-		TenantID: invite.TenantID,
-		Role:     invite.Role,
+	// Claim and membership commit together: a duplicate membership rolls the
+	// claim back, and a concurrent accept finds nothing left to claim.
+	err = s.WithRLS(ctx, invite.TenantID.Bytes, func(q *db.Queries) error {
+		claimed, err := q.ClaimInvitation(ctx, hash)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		invite = claimed
+
+		_, err = q.CreateMembership(ctx, db.CreateMembershipParams{
+			UserID:   user.ID,
+			TenantID: invite.TenantID,
+			Role:     invite.Role,
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrAlreadyMember
+		}
+		if err != nil {
+			return err
+		}
+
+		// The link reached this address, which proves ownership as well
+		if !user.IsEmailVerified {
+			if _, err := q.VerifyUserEmail(ctx, user.ID); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
-		// Rollback user creation? In simplified service, we can't easily.
-		// Anti-Gravity: Transactions required here.
-		// For now, logging error.
-		return nil, fmt.Errorf("failed to add membership: %w", err)
+		return db.Invitation{}, err
 	}
 
-	// 4. Mark Invite Accepted
-	s.queries.AcceptInvitation(ctx, invite.ID)
-
-	return user, nil
+	s.audit.Log(ctx, audit.EventInvitationAccepted, audit.LogParams{
+		ActorID:  userID,
+		TargetID: userID,
+		TenantID: invite.TenantID.Bytes,
+		Metadata: map[string]interface{}{
+			"invitation_id": uuid.UUID(invite.ID.Bytes),
+			"role":          invite.Role,
+			"existing_user": true,
+		},
+	})
+	return invite, nil
 }
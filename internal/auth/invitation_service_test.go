@@ -0,0 +1,45 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInvitationCSV(t *testing.T) {
+	rows, err := auth.ParseInvitationCSV(strings.NewReader("Email,Role\njan@example.com, editor\n\n  piet@example.com\n\"a,b\",viewer\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []auth.InvitationRow{
+		{Line: 2, Email: "jan@example.com", Role: "editor"},
+		{Line: 4, Email: "piet@example.com"},
+		{Line: 5, Email: "a,b", Role: "viewer"},
+	}, rows)
+
+	// No header: the first line is an invitation too
+	rows, err = auth.ParseInvitationCSV(strings.NewReader("jan@example.com\n"))
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+
+	_, err = auth.ParseInvitationCSV(strings.NewReader("\"unterminated\n"))
+	assert.Error(t, err)
+
+	_, err = auth.ParseInvitationCSV(strings.NewReader(strings.Repeat("x@example.com\n", auth.MaxBulkInvitations+1)))
+	assert.ErrorIs(t, err, auth.ErrTooManyInvitations)
+}
+
+func TestInvitationStatus(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) pgtype.Timestamptz { return pgtype.Timestamptz{Time: now.Add(d), Valid: true} }
+
+	assert.Equal(t, auth.InvitationPending, auth.InvitationStatus(db.Invitation{ExpiresAt: at(time.Hour)}, now))
+	assert.Equal(t, auth.InvitationExpired, auth.InvitationStatus(db.Invitation{ExpiresAt: at(-time.Hour)}, now))
+	// Accepted and revoked win over expiry
+	assert.Equal(t, auth.InvitationAccepted, auth.InvitationStatus(db.Invitation{ExpiresAt: at(-time.Hour), Accepted: true}, now))
+	assert.Equal(t, auth.InvitationRevoked, auth.InvitationStatus(db.Invitation{ExpiresAt: at(-time.Hour), RevokedAt: at(-2 * time.Hour)}, now))
+}
@@ -40,13 +40,17 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginResult
 
 	// 1.5 Validate Tenant Exists (Prevent FK Violations)
 	// Phase 35 Hardening: Ensure the tenant ID is valid before lookup
-	_, err := s.txQueries(ctx).GetTenantByID(ctx, pgtype.UUID{Bytes: input.TenantID, Valid: true})
+	tenant, err := s.txQueries(ctx).GetTenantByID(ctx, pgtype.UUID{Bytes: input.TenantID, Valid: true})
 	if err != nil {
 		// Log internal warning for debugging
 		// But return generic error or ErrTenantRequired to client
 		slog.Warn("GetTenantByID Failed", "tenantID", input.TenantID, "error", err)
 		return nil, ErrTenantRequired
 	}
+	// Suspended on the platform plane: nobody logs in, not even with valid credentials
+	if !tenant.IsActive {
+		return nil, ErrTenantSuspended
+	}
 
 	user, err := s.txQueries(ctx).GetUserByEmail(ctx, db.GetUserByEmailParams{
 		Email:    input.Email,
@@ -62,10 +66,26 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginResult
 		return nil, ErrInvalidCredentials // User has no password (maybe social login only)
 	}
 
+	// Locked accounts get the generic error too: the owner learns why by email
+	if accountLocked(user, time.Now()) {
+		slog.Info("Login: Account locked", "user_id", uuid.UUID(user.ID.Bytes))
+		return nil, ErrInvalidCredentials
+	}
+
 	if err := s.passwordHasher.Compare(user.PasswordHash.String, input.Password); err != nil {
 		return nil, ErrInvalidCredentials
 	}
 
+	// Closed via DELETE /auth/account: only the emailed cancel link reopens it
+	if err := s.ensureNotPendingDeletion(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	// Tenant policy "enforce": checked before MFA so no pre-auth token is handed out
+	if err := s.refuseUnverifiedLogin(ctx, tenant, user); err != nil {
+		return nil, err
+	}
+
 	// 2.5 Check MFA
 	if user.MfaEnabled {
 		// Generate Pre-Auth Token (Phase 35 Hardening)
@@ -88,7 +108,7 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginResult
 		return nil, fmt.Errorf("failed to resolve tenant context: %w", err)
 	}
 
-	accessToken, err := s.tokenProvider.GenerateAccessToken(uuid.UUID(user.ID.Bytes), tenantID, role)
+	accessToken, err := s.issueAccessToken(ctx, user, tenantID, role)
 	if err != nil {
 		return nil, fmt.Errorf("token generation failed: %w", err)
 	}
@@ -120,7 +140,7 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginResult
 	}
 
 	// AUDIT LOG: SUCCESS
-	s.audit.Log(ctx, "auth.login.success", audit.LogParams{
+	s.audit.Log(ctx, audit.EventLoginSuccess, audit.LogParams{
 		ActorID:  user.ID.Bytes,
 		TargetID: user.ID.Bytes,
 		TenantID: tenantID, // Might be Nil if no default tenant
@@ -176,8 +196,11 @@ func (s *AuthService) VerifyLoginBackupCode(ctx context.Context, preAuthToken st
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve tenant context: %w", err)
 	}
+	if err := s.ensureTenantActive(ctx, tenantID); err != nil {
+		return nil, err
+	}
 
-	accessToken, err := s.tokenProvider.GenerateAccessToken(uuid.UUID(user.ID.Bytes), tenantID, role)
+	accessToken, err := s.issueAccessToken(ctx, user, tenantID, role)
 	if err != nil {
 		return nil, err
 	}
@@ -205,7 +228,7 @@ func (s *AuthService) VerifyLoginBackupCode(ctx context.Context, preAuthToken st
 	}
 
 	// AUDIT LOG
-	s.audit.Log(ctx, "auth.login.success", audit.LogParams{
+	s.audit.Log(ctx, audit.EventLoginSuccess, audit.LogParams{
 		ActorID:  user.ID.Bytes,
 		TargetID: user.ID.Bytes,
 		TenantID: tenantID,
@@ -257,8 +280,11 @@ func (s *AuthService) VerifyLoginMFA(ctx context.Context, preAuthToken string, c
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve tenant context: %w", err)
 	}
+	if err := s.ensureTenantActive(ctx, tenantID); err != nil {
+		return nil, err
+	}
 
-	accessToken, err := s.tokenProvider.GenerateAccessToken(uuid.UUID(user.ID.Bytes), tenantID, role)
+	accessToken, err := s.issueAccessToken(ctx, user, tenantID, role)
 	if err != nil {
 		return nil, fmt.Errorf("token generation failed: %w", err)
 	}
@@ -287,7 +313,7 @@ func (s *AuthService) VerifyLoginMFA(ctx context.Context, preAuthToken string, c
 	}
 
 	// AUDIT LOG
-	s.audit.Log(ctx, "auth.login.success", audit.LogParams{
+	s.audit.Log(ctx, audit.EventLoginSuccess, audit.LogParams{
 		ActorID:  user.ID.Bytes,
 		TargetID: user.ID.Bytes,
 		TenantID: tenantID,
@@ -3,6 +3,8 @@ package auth
 import (
 	"context"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/notify"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -61,11 +63,22 @@ func (s *AuthService) ActivateMFA(ctx context.Context, userID uuid.UUID, secret
 	}
 
 	// 3. Enable User MFA in DB
-	// 3. Enable User MFA in DB
-	_, err := s.queries.UpdateUserMFA(ctx, db.UpdateUserMFAParams{
+	user, err := s.queries.UpdateUserMFA(ctx, db.UpdateUserMFAParams{
 		ID:         pgtype.UUID{Bytes: userID, Valid: true},
 		MfaSecret:  pgtype.Text{String: secret, Valid: true},
 		MfaEnabled: true,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventMFAEnabled, audit.LogParams{
+		ActorID:  userID,
+		TargetID: userID,
+		Metadata: map[string]interface{}{"backup_codes": len(backupCodes)},
+	})
+	s.notifySecurityEvent(ctx, s.txQueries(ctx), user, func(notice notify.SecurityNotice) error {
+		return s.mail.SendMFAEnabled(ctx, user.Email, notice)
+	})
+	return nil
 }
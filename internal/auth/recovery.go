@@ -9,13 +9,17 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/notify"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var (
-	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
 )
 
 // GenerateSecureToken creates a random string for reference tokens.
@@ -87,38 +91,61 @@ func (s *AuthService) RequestPasswordReset(ctx context.Context, email string, te
 	return s.mail.SendPasswordReset(ctx, email, token, appURL)
 }
 
-// ResetPassword completes the flow.
+// ResetPassword completes the flow. Token lookup, password update, token
+// consumption and lifting a lock commit together; the notice follows the commit.
+// The link carries no tenant, hence WithoutRLS.
 func (s *AuthService) ResetPassword(ctx context.Context, rawToken string, newPassword string) error {
 	hashedToken := hashToken(rawToken) // Deterministic
 
-	// 2. Lookup
-	storedToken, err := s.queries.GetVerificationToken(ctx, hashedToken)
-	if err != nil {
-		return ErrInvalidResetToken
-	}
+	var user db.User
+	var expired pgtype.UUID
+	err := storage.WithoutRLS(ctx, s.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
 
-	// 3. Check Expiry
-	if time.Now().After(storedToken.ExpiresAt.Time) {
-		s.queries.DeleteVerificationToken(ctx, storedToken.ID) // Cleanup
-		return ErrInvalidResetToken
-	}
+		// 1. Lookup
+		storedToken, err := q.GetVerificationToken(ctx, hashedToken)
+		if err != nil || storedToken.Type != "password_reset" {
+			return ErrInvalidResetToken // Verification and lock tokens share the table
+		}
 
-	// 4. Update Password
-	newHash, err := s.passwordHasher.Hash(newPassword)
-	if err != nil {
-		return err
-	}
+		// 2. Check Expiry
+		if time.Now().After(storedToken.ExpiresAt.Time) {
+			expired = storedToken.ID
+			return ErrInvalidResetToken
+		}
 
-	_, err = s.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
-		ID:           storedToken.UserID,
-		PasswordHash: pgtype.Text{String: newHash, Valid: true},
+		// 3. Update Password
+		newHash, err := s.passwordHasher.Hash(newPassword)
+		if err != nil {
+			return err
+		}
+		user, err = q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:           storedToken.UserID,
+			PasswordHash: pgtype.Text{String: newHash, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// 4. Consume Token (One-Time Use)
+		if err := q.DeleteVerificationToken(ctx, storedToken.ID); err != nil {
+			return err
+		}
+
+		// 5. Lift a "this wasn't me" lock: the reset link proves control of the inbox
+		return q.ResetLoginAttempts(ctx, user.ID)
 	})
+	if expired.Valid {
+		s.queries.DeleteVerificationToken(ctx, expired) // Cleanup
+	}
 	if err != nil {
 		return err
 	}
 
-	// 5. Consume Token (One-Time Use)
-	return s.queries.DeleteVerificationToken(ctx, storedToken.ID)
+	s.notifySecurityEvent(ctx, s.txQueries(ctx), user, func(notice notify.SecurityNotice) error {
+		return s.mail.SendPasswordChanged(ctx, user.Email, notice)
+	})
+	return nil
 }
 
 // RequestEmailVerification initiates the email verification flow.
@@ -172,13 +199,20 @@ func (s *AuthService) VerifyEmail(ctx context.Context, rawToken string) error {
 	hashedToken := hashToken(rawToken)
 
 	storedToken, err := s.queries.GetVerificationToken(ctx, hashedToken)
+	if err == nil && storedToken.Type != "email_verify" {
+		return ErrInvalidVerificationToken // Reset and lock tokens share the table
+	}
 	if err != nil {
-		return ErrInvalidResetToken // Reuse error or create specific ErrInvalidVerificationToken
+		// Domain auto-join signups are confirmed through the same link
+		if handled, err := s.confirmDomainSignup(ctx, hashedToken); handled {
+			return err
+		}
+		return ErrInvalidVerificationToken
 	}
 
 	if time.Now().After(storedToken.ExpiresAt.Time) {
 		s.queries.DeleteVerificationToken(ctx, storedToken.ID)
-		return ErrInvalidResetToken
+		return ErrInvalidVerificationToken
 	}
 
 	// Verify User
@@ -20,8 +20,9 @@ type RegisterInput struct {
 	Token    string // Invitation Token (Optional if Public Reg allowed)
 }
 
-// Register creates a new user and returns the user model.
-func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.User, error) {
+// Register creates a new user, or, depending on the tenant's signup rules,
+// queues the signup for approval or mails a domain confirmation link.
+func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*RegisterResult, error) {
 	// 1. Hash Password FIRST (shared step)
 	hashedPassword, err := s.passwordHasher.Hash(input.Password)
 	if err != nil {
@@ -46,7 +47,9 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.Us
 		}
 
 		// 3. Atomically Create User + Membership + Delete Invite
-		// This uses the explicit transaction query we added.
+		// This uses the explicit transaction query we added. The user starts
+		// verified: the emailed invite token already proves the address, so the
+		// tenant's email verification policy never blocks invited members.
 		result, err := s.queries.CreateUserFromInvitation(ctx, db.CreateUserFromInvitationParams{
 			Email:        input.Email,
 			PasswordHash: hashText,
@@ -68,7 +71,7 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.Us
 		}
 
 		// AUDIT LOG
-		s.audit.Log(ctx, "user.create.invite", audit.LogParams{
+		s.audit.Log(ctx, audit.EventUserCreatedInvite, audit.LogParams{
 			ActorID:  user.ID.Bytes,
 			TargetID: user.ID.Bytes,
 			TenantID: invite.TenantID.Bytes,
@@ -78,18 +81,33 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.Us
 			},
 		})
 
-		return user, nil
+		return &RegisterResult{Status: SignupCreated, User: user}, nil
 	}
 
 	// FLOW B: PUBLIC REGISTRATION
-	// 1. Check Config
+	// 1. Check Config (platform-wide switch)
 	if !s.config.AllowPublicRegistration {
 		return nil, ErrPublicRegistrationDisabled
 	}
+	if input.TenantID == uuid.Nil {
+		return nil, ErrTenantRequired
+	}
+
+	// 1b. Tenant signup rules: blocked/allowed domains, approval queue, domain auto-join
+	status, rules, err := s.signupDecision(ctx, input.TenantID, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case SignupPendingApproval:
+		return s.queueSignupRequest(ctx, input, hashedPassword)
+	case SignupConfirmationSent:
+		return s.sendDomainSignupConfirmation(ctx, input, hashedPassword)
+	}
 
 	// 2. Prepare DB Params
 	fullNameText := pgtype.Text{String: input.FullName, Valid: input.FullName != ""}
-	defaultTenantUUID := pgtype.UUID{Bytes: input.TenantID, Valid: input.TenantID != uuid.Nil}
+	defaultTenantUUID := pgtype.UUID{Bytes: input.TenantID, Valid: true}
 
 	// 3. Create User + Membership Atomically (FIXED: was TODO service.go:175)
 	// Previously: CreateUser and CreateMembership were separate → orphan users possible
@@ -102,7 +120,7 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.Us
 		TenantID:     defaultTenantUUID,
 		MfaSecret:    pgtype.Text{Valid: false},
 		MfaEnabled:   false,
-		Role:         "user", // Default Role for Public Registration
+		Role:         rules.Role(), // Tenant's signup role ("user" unless configured)
 	})
 
 	if err != nil {
@@ -110,7 +128,7 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.Us
 	}
 
 	// AUDIT LOG
-	s.audit.Log(ctx, "user.create.public", audit.LogParams{
+	s.audit.Log(ctx, audit.EventUserCreatedPublic, audit.LogParams{
 		ActorID:  user.ID.Bytes,
 		TargetID: user.ID.Bytes,
 		TenantID: defaultTenantUUID.Bytes,
@@ -120,7 +138,7 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.Us
 	})
 
 	// Convert to db.User for return type compatibility
-	return &db.User{
+	return &RegisterResult{Status: SignupCreated, User: &db.User{
 		ID:                  user.ID,
 		Email:               user.Email,
 		PasswordHash:        user.PasswordHash,
@@ -133,5 +151,5 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*db.Us
 		LockedUntil:         user.LockedUntil,
 		CreatedAt:           user.CreatedAt,
 		UpdatedAt:           user.UpdatedAt,
-	}, nil
+	}}, nil
 }
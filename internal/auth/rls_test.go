@@ -33,7 +33,7 @@ func TestRLS_Enforcement(t *testing.T) {
 	queries := db.New(pool)
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	auditLogger := audit.NewDBLogger(queries, logger)
+	auditLogger := audit.NewAuditService(logger, audit.NewDBSink(queries, logger))
 
 	svc := auth.NewAuthService(auth.AuthConfig{}, pool, queries, nil, nil, nil, auditLogger, nil)
 
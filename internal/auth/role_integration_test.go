@@ -0,0 +1,49 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateRoleDefinition_RequiresCurrentPermissions(t *testing.T) {
+	pool := integrationPool(t)
+	svc, _ := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+
+	broad, err := svc.CreateRole(ctx, uuid.Nil, tenantID, permissions.All(), auth.RoleInput{
+		Name:        "auditor",
+		Permissions: []string{permissions.UsersRead, permissions.AuditRead},
+	})
+	require.NoError(t, err)
+	roleID := uuid.MustParse(broad.ID)
+
+	// A roles:manage holder without audit:read cannot strip it from the role
+	manager := []string{permissions.RolesManage, permissions.UsersRead}
+	_, err = svc.UpdateRoleDefinition(ctx, uuid.Nil, tenantID, roleID, manager, auth.RoleInput{
+		Permissions: []string{permissions.UsersRead},
+	})
+	assert.ErrorIs(t, err, auth.ErrPermissionEscalation)
+
+	roles, err := svc.ListRoles(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Contains(t, roles, auth.RoleDefinition{
+		ID: broad.ID, Name: "auditor", Permissions: []string{permissions.AuditRead, permissions.UsersRead},
+	})
+
+	// Holding both the current and the new permissions is enough
+	updated, err := svc.UpdateRoleDefinition(ctx, uuid.Nil, tenantID, roleID, append(manager, permissions.AuditRead), auth.RoleInput{
+		Permissions: []string{permissions.UsersRead},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{permissions.UsersRead}, updated.Permissions)
+
+	_, err = svc.UpdateRoleDefinition(ctx, uuid.Nil, tenantID, uuid.New(), permissions.All(), auth.RoleInput{})
+	assert.ErrorIs(t, err, auth.ErrRoleNotFound)
+}
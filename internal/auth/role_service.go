@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleExists           = errors.New("role already exists")
+	ErrRoleInUse            = errors.New("role is assigned to members")
+	ErrInvalidRoleName      = errors.New("invalid role name: use 2-50 lowercase letters, digits, '-' or '_'")
+	ErrPermissionEscalation = errors.New("cannot grant permissions you do not hold")
+)
+
+// Mirrors the tenant_roles_name_format constraint (migration 017)
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// RoleDefinition is a built-in or tenant-defined role.
+type RoleDefinition struct {
+	ID          string   `json:"id,omitempty"` // Empty for built-in roles
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Builtin     bool     `json:"builtin"`
+}
+
+// RoleInput is the payload for creating or updating a custom role.
+type RoleInput struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// ListRoles returns the built-in roles followed by the tenant's custom roles.
+func (s *AuthService) ListRoles(ctx context.Context, tenantID uuid.UUID) ([]RoleDefinition, error) {
+	roles := []RoleDefinition{}
+	for _, name := range []string{permissions.RoleAdmin, permissions.RoleEditor, permissions.RoleViewer} {
+		roles = append(roles, RoleDefinition{Name: name, Permissions: permissions.Builtin[name], Builtin: true})
+	}
+
+	err := s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
+		custom, err := q.ListTenantRoles(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		if err != nil {
+			return err
+		}
+		for _, r := range custom {
+			roles = append(roles, toRoleDefinition(r))
+		}
+		return nil
+	})
+	return roles, err
+}
+
+// CreateRole defines a custom role. actorPerms are the caller's own permissions:
+// nobody can mint a role more powerful than themselves.
+func (s *AuthService) CreateRole(ctx context.Context, actorID, tenantID uuid.UUID, actorPerms []string, input RoleInput) (*RoleDefinition, error) {
+	if !roleNamePattern.MatchString(input.Name) {
+		return nil, ErrInvalidRoleName
+	}
+	if permissions.IsBuiltin(input.Name) || input.Name == "user" {
+		return nil, ErrRoleExists
+	}
+	perms, err := checkGrantable(input.Permissions, actorPerms)
+	if err != nil {
+		return nil, err
+	}
+
+	var created db.TenantRole
+	err = s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
+		created, err = q.CreateTenantRole(ctx, db.CreateTenantRoleParams{
+			TenantID:    pgtype.UUID{Bytes: tenantID, Valid: true},
+			Name:        input.Name,
+			Description: input.Description,
+			Permissions: perms,
+		})
+		return err
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrRoleExists
+		}
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	s.audit.Log(ctx, audit.EventRoleCreated, audit.LogParams{
+		ActorID:  actorID,
+		TargetID: created.ID.Bytes,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"name":        created.Name,
+			"permissions": created.Permissions,
+		},
+	})
+
+	role := toRoleDefinition(created)
+	return &role, nil
+}
+
+// UpdateRoleDefinition replaces the description and permissions of a custom role.
+// Members holding the role get the new permissions on their next token refresh.
+// The actor must hold both the current and the new permissions: otherwise they
+// could strip a role broader than their own.
+func (s *AuthService) UpdateRoleDefinition(ctx context.Context, actorID, tenantID, roleID uuid.UUID, actorPerms []string, input RoleInput) (*RoleDefinition, error) {
+	perms, err := checkGrantable(input.Permissions, actorPerms)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated db.TenantRole
+	err = s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
+		current, err := q.GetTenantRoleForUpdate(ctx, db.GetTenantRoleForUpdateParams{
+			ID:       pgtype.UUID{Bytes: roleID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if !permissions.HasAll(actorPerms, current.Permissions...) {
+			return ErrPermissionEscalation
+		}
+
+		updated, err = q.UpdateTenantRole(ctx, db.UpdateTenantRoleParams{
+			ID:          pgtype.UUID{Bytes: roleID, Valid: true},
+			TenantID:    pgtype.UUID{Bytes: tenantID, Valid: true},
+			Description: input.Description,
+			Permissions: perms,
+		})
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	s.audit.Log(ctx, audit.EventRoleUpdated, audit.LogParams{
+		ActorID:  actorID,
+		TargetID: roleID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"name":        updated.Name,
+			"permissions": updated.Permissions,
+		},
+	})
+
+	role := toRoleDefinition(updated)
+	return &role, nil
+}
+
+// DeleteRole removes a custom role that is no longer assigned to any member.
+func (s *AuthService) DeleteRole(ctx context.Context, actorID, tenantID, roleID uuid.UUID) error {
+	err := s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
+		roles, err := q.ListTenantRoles(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		if err != nil {
+			return err
+		}
+		idx := slices.IndexFunc(roles, func(r db.TenantRole) bool { return r.ID.Bytes == roleID })
+		if idx < 0 {
+			return ErrRoleNotFound
+		}
+
+		inUse, err := q.CountMembersWithRole(ctx, db.CountMembersWithRoleParams{
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+			Role:     roles[idx].Name,
+		})
+		if err != nil {
+			return err
+		}
+		if inUse > 0 {
+			return ErrRoleInUse
+		}
+
+		_, err = q.DeleteTenantRole(ctx, db.DeleteTenantRoleParams{
+			ID:       pgtype.UUID{Bytes: roleID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventRoleDeleted, audit.LogParams{
+		ActorID:  actorID,
+		TargetID: roleID,
+		TenantID: tenantID,
+	})
+	return nil
+}
+
+// CheckRoleAssignable verifies that role exists in the tenant and grants nothing
+// beyond actorPerms. Used when changing a member's role or inviting a user.
+func (s *AuthService) CheckRoleAssignable(ctx context.Context, tenantID uuid.UUID, role string, actorPerms []string) error {
+	resolver := permissions.NewResolver(s.txQueries(ctx))
+	exists, err := resolver.Exists(ctx, tenantID, role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRoleNotFound
+	}
+	perms, err := resolver.Resolve(ctx, tenantID, role)
+	if err != nil {
+		return err
+	}
+	if !permissions.HasAll(actorPerms, perms...) {
+		return ErrPermissionEscalation
+	}
+	return nil
+}
+
+// CheckMemberManageable verifies that the member's current role grants nothing
+// beyond actorPerms, so a narrower role cannot demote or remove a broader one.
+func (s *AuthService) CheckMemberManageable(ctx context.Context, tenantID, userID uuid.UUID, actorPerms []string) error {
+	q := s.txQueries(ctx)
+	role, err := q.GetMembership(ctx, db.GetMembershipParams{
+		UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotTenantMember
+	}
+	if err != nil {
+		return err
+	}
+	perms, err := permissions.NewResolver(q).Resolve(ctx, tenantID, role)
+	if err != nil {
+		return err
+	}
+	if !permissions.HasAll(actorPerms, perms...) {
+		return ErrPermissionEscalation
+	}
+	return nil
+}
+
+func checkGrantable(requested, actorPerms []string) ([]string, error) {
+	perms, err := permissions.Validate(requested)
+	if err != nil {
+		return nil, err
+	}
+	if !permissions.HasAll(actorPerms, perms...) {
+		return nil, ErrPermissionEscalation
+	}
+	return perms, nil
+}
+
+func toRoleDefinition(r db.TenantRole) RoleDefinition {
+	return RoleDefinition{
+		ID:          uuid.UUID(r.ID.Bytes).String(),
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Permissions,
+	}
+}
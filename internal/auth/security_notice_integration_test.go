@@ -0,0 +1,176 @@
+package auth_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityNotices_QueuedAfterEachChange(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	const email = "notices@example.com"
+	userID := createTestUser(t, pool, tenantID, email, "Old-Password-123")
+
+	t.Run("ChangePassword", func(t *testing.T) {
+		require.NoError(t, svc.ChangePassword(ctx, userID, tenantID, "Old-Password-123", "New-Password-456"))
+
+		notices := mail.sentNotices("password_changed", email)
+		require.Len(t, notices, 1)
+		assert.NotEmpty(t, notices[0].LockToken)
+		assert.Equal(t, "https://app.example.test", notices[0].AppURL)
+	})
+
+	t.Run("ResetPassword", func(t *testing.T) {
+		require.NoError(t, svc.RequestPasswordReset(ctx, email, tenantID))
+		require.NoError(t, svc.ResetPassword(ctx, mail.token(t, "password_reset", email), "Reset-Password-789"))
+
+		assert.Len(t, mail.sentNotices("password_changed", email), 2)
+	})
+
+	t.Run("ActivateMFA", func(t *testing.T) {
+		key, err := totp.Generate(totp.GenerateOpts{Issuer: "LaventeCare Test", AccountName: email})
+		require.NoError(t, err)
+		code, err := totp.GenerateCode(key.Secret(), time.Now())
+		require.NoError(t, err)
+
+		require.NoError(t, svc.ActivateMFA(ctx, userID, key.Secret(), code, []string{"backup-1", "backup-2"}))
+
+		notices := mail.sentNotices("mfa_enabled", email)
+		require.Len(t, notices, 1)
+		assert.NotEmpty(t, notices[0].LockToken)
+	})
+
+	t.Run("NoNoticeWhenTheChangeFails", func(t *testing.T) {
+		assert.ErrorIs(t, svc.ChangePassword(ctx, userID, tenantID, "wrong", "Whatever-000"), auth.ErrInvalidCredentials)
+		assert.Len(t, mail.sentNotices("password_changed", email), 2)
+	})
+}
+
+// Reset, verification and lock tokens share verification_tokens; each flow
+// only accepts its own type and leaves the others unused.
+func TestVerificationTokens_OnlyWorkInTheirOwnFlow(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	const email = "tokens@example.com"
+	userID := createTestUser(t, pool, tenantID, email, "Password-123")
+	_, err := pool.Exec(ctx, `UPDATE users SET is_email_verified = FALSE WHERE id = $1`, userID)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RequestPasswordReset(ctx, email, tenantID))
+	resetToken := mail.token(t, "password_reset", email)
+	require.NoError(t, svc.RequestEmailVerification(ctx, email, tenantID))
+	verifyToken := mail.token(t, "email_verification", email)
+	require.NoError(t, svc.ChangePassword(ctx, userID, tenantID, "Password-123", "Password-456"))
+	lockToken := mail.sentNotices("password_changed", email)[0].LockToken
+
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, resetToken), auth.ErrInvalidVerificationToken)
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, lockToken), auth.ErrInvalidVerificationToken)
+	assert.ErrorIs(t, svc.ResetPassword(ctx, verifyToken, "Password-789"), auth.ErrInvalidResetToken)
+	assert.ErrorIs(t, svc.ResetPassword(ctx, lockToken, "Password-789"), auth.ErrInvalidResetToken)
+	assert.ErrorIs(t, svc.LockAccount(ctx, tenantID, resetToken), auth.ErrInvalidLockToken)
+	assert.ErrorIs(t, svc.LockAccount(ctx, tenantID, verifyToken), auth.ErrInvalidLockToken)
+
+	// None of the refused attempts consumed a token
+	require.NoError(t, svc.VerifyEmail(ctx, verifyToken))
+	require.NoError(t, svc.ResetPassword(ctx, resetToken, "Password-789"))
+	require.NoError(t, svc.LockAccount(ctx, tenantID, lockToken))
+}
+
+func TestLockAccount_LocksUntilPasswordReset(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	const email = "lock@example.com"
+	userID := createTestUser(t, pool, tenantID, email, "Password-123")
+
+	session, err := svc.Login(ctx, auth.LoginInput{Email: email, Password: "Password-123", TenantID: tenantID})
+	require.NoError(t, err)
+	require.NoError(t, svc.ChangePassword(ctx, userID, tenantID, "Password-123", "Password-456"))
+	lockToken := mail.sentNotices("password_changed", email)[0].LockToken
+
+	// Only within the user's own tenant
+	assert.ErrorIs(t, svc.LockAccount(ctx, createTestTenant(t, pool), lockToken), auth.ErrInvalidLockToken)
+
+	require.NoError(t, svc.LockAccount(ctx, tenantID, lockToken))
+	assert.ErrorIs(t, svc.LockAccount(ctx, tenantID, lockToken), auth.ErrInvalidLockToken, "single use")
+
+	// Even the right password is refused, and existing sessions are gone
+	_, err = svc.Login(ctx, auth.LoginInput{Email: email, Password: "Password-456", TenantID: tenantID})
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	_, err = svc.RefreshSession(ctx, session.RefreshToken, nil, "")
+	assert.Error(t, err)
+
+	// A password reset proves control of the inbox and lifts the lock
+	require.NoError(t, svc.RequestPasswordReset(ctx, email, tenantID))
+	require.NoError(t, svc.ResetPassword(ctx, mail.token(t, "password_reset", email), "Password-789"))
+	_, err = svc.Login(ctx, auth.LoginInput{Email: email, Password: "Password-789", TenantID: tenantID})
+	assert.NoError(t, err)
+}
+
+// Password update, token consumption and lifting the lock commit together:
+// concurrent redemptions of one reset link change the password once.
+func TestResetPassword_ConcurrentRedemption(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	const email = "reset-race@example.com"
+	createTestUser(t, pool, tenantID, email, "Password-123")
+
+	require.NoError(t, svc.RequestPasswordReset(ctx, email, tenantID))
+	token := mail.token(t, "password_reset", email)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = svc.ResetPassword(ctx, token, "Password-reset-"+string(rune('a'+i)))
+		}()
+	}
+	wg.Wait()
+
+	reset := 0
+	for _, err := range errs {
+		if err == nil {
+			reset++
+		} else {
+			assert.ErrorIs(t, err, auth.ErrInvalidResetToken)
+		}
+	}
+	assert.Equal(t, 1, reset)
+	assert.Len(t, mail.sentNotices("password_changed", email), 1)
+}
+
+func TestNotifySignInThrottled(t *testing.T) {
+	pool := integrationPool(t)
+	svc, mail := newIntegrationService(t, pool)
+	ctx := context.Background()
+	tenantID := createTestTenant(t, pool)
+	const email = "throttled@example.com"
+	createTestUser(t, pool, tenantID, email, "Password-123")
+	until := time.Now().Add(15 * time.Minute)
+
+	svc.NotifySignInThrottled(ctx, tenantID, email, auth.ThrottledByRateLimit, until)
+	svc.NotifySignInThrottled(ctx, tenantID, "unknown@example.com", auth.ThrottledByRateLimit, until)
+
+	assert.Len(t, mail.sentNotices("account_locked", email), 1)
+	assert.Empty(t, mail.sentNotices("account_locked", "unknown@example.com"), "unknown addresses get nothing")
+
+	// Throttling is not a lock: the right password still works
+	_, err := svc.Login(ctx, auth.LoginInput{Email: email, Password: "Password-123", TenantID: tenantID})
+	assert.NoError(t, err)
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/notify"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -19,12 +23,18 @@ var (
 	ErrInvalidCredentials         = errors.New("invalid email or password")
 	ErrTenantRequired             = errors.New("tenant id is required")
 	ErrPublicRegistrationDisabled = errors.New("public registration is disabled")
+	ErrTenantSuspended            = errors.New("tenant is suspended")
+	ErrNotTenantMember            = errors.New("user is not a member of this tenant")
 )
 
 // AuthConfig holds configuration for the auth service.
 type AuthConfig struct {
 	AllowPublicRegistration bool
 	DefaultAppURL           string // Fallback URL for email links when tenant has no custom app_url
+	// Time between DELETE /auth/account and the purge (DefaultAccountDeletionCoolingOff if zero)
+	AccountDeletionCoolingOff time.Duration
+	// DNS lookups for email domain verification (net.DefaultResolver if nil)
+	TXTResolver TXTResolver
 }
 
 // AuthService orchestrates the authentication flow.
@@ -36,7 +46,7 @@ type AuthService struct {
 	passwordHasher PasswordHasher
 	tokenProvider  TokenProvider
 	mfaService     *MFAService
-	audit          audit.AuditService // NEW
+	audit          *audit.AuditService // NEW
 	mail           notify.EmailSender
 }
 
@@ -47,7 +57,7 @@ func NewAuthService(
 	hasher PasswordHasher,
 	tokenProvider TokenProvider,
 	mfa *MFAService,
-	audit audit.AuditService, // NEW
+	audit *audit.AuditService, // NEW
 	mail notify.EmailSender,
 ) *AuthService {
 	return &AuthService{
@@ -89,30 +99,62 @@ func (s *AuthService) resolveTenantAndRole(ctx context.Context, user db.User) (u
 	return tenantID, role, nil
 }
 
-// WithRLS executes a function within a transaction that has the RLS context set.
-func (s *AuthService) WithRLS(ctx context.Context, tenantID uuid.UUID, fn func(q *db.Queries) error) error {
-	// 1. Begin Transaction
-	tx, err := s.pool.Begin(ctx)
+// ensureTenantActive refuses sessions for tenants suspended on the platform plane.
+func (s *AuthService) ensureTenantActive(ctx context.Context, tenantID uuid.UUID) error {
+	if tenantID == uuid.Nil {
+		return nil
+	}
+	tenant, err := s.txQueries(ctx).GetTenantByID(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to load tenant: %w", err)
+	}
+	if !tenant.IsActive {
+		return ErrTenantSuspended
 	}
-	defer tx.Rollback(ctx)
+	return nil
+}
 
-	// 2. Set RLS Context Variable (Postgres Session Local)
-	// We use set_config with 'is_local' = true, so it applies only to this transaction.
-	_, err = tx.Exec(ctx, "SELECT set_config('app.current_tenant', $1, true)", tenantID.String())
+// issueAccessToken resolves the role's permissions (built-in or tenant-defined)
+// and signs them into the access token, so RequirePermission needs no DB lookup.
+// The tenant's email verification policy is applied here too, so refresh and
+// the MFA steps cannot mint tokens that Login would refuse.
+func (s *AuthService) issueAccessToken(ctx context.Context, user db.User, tenantID uuid.UUID, role string) (string, error) {
+	perms := []string{}
+	if tenantID != uuid.Nil {
+		resolved, err := permissions.NewResolver(s.txQueries(ctx)).Resolve(ctx, tenantID, role)
+		if err != nil {
+			return "", err
+		}
+		perms = resolved
+	}
+	policy, err := s.emailVerificationPolicy(ctx, tenantID)
 	if err != nil {
-		return fmt.Errorf("failed to set rls context: %w", err)
+		return "", err
 	}
-
-	// 3. Execute Logic with Tx-wrapped Queries
-	q := s.queries.WithTx(tx)
-	if err := fn(q); err != nil {
-		return err
+	if policy == domain.EmailVerificationEnforce && !user.IsEmailVerified {
+		return "", ErrEmailNotVerified
 	}
+	return s.tokenProvider.GenerateAccessToken(uuid.UUID(user.ID.Bytes), tenantID, role, perms, emailVerifiedClaim(policy, user))
+}
 
-	// 4. Commit
-	return tx.Commit(ctx)
+// WithRLS executes a function within a transaction that has the RLS context set.
+func (s *AuthService) WithRLS(ctx context.Context, tenantID uuid.UUID, fn func(q *db.Queries) error) error {
+	return storage.WithTenantContext(ctx, s.pool, tenantID, func(tx pgx.Tx) error {
+		return fn(s.queries.WithTx(tx))
+	})
+}
+
+// appURL is the base URL for links in emails: the tenant's app_url, else the configured default.
+func (s *AuthService) appURL(ctx context.Context, tenantID uuid.UUID) string {
+	appURL := s.config.DefaultAppURL
+	if appURL == "" {
+		appURL = "https://auth.laventecare.nl"
+	}
+	tenantConfig, err := s.txQueries(ctx).GetTenantConfig(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err == nil && tenantConfig.AppUrl != "" {
+		appURL = tenantConfig.AppUrl
+	}
+	return appURL
 }
 
 // GetJWKS returns the JSON Web Key Set for the OIDC provider.
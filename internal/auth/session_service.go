@@ -8,8 +8,10 @@ import (
 	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -23,7 +25,7 @@ func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	token, err := s.queries.GetRefreshToken(ctx, hashed)
 	if err == nil {
 		// Found token, log the event
-		s.audit.Log(ctx, "auth.logout", audit.LogParams{
+		s.audit.Log(ctx, audit.EventLogout, audit.LogParams{
 			ActorID:  token.UserID.Bytes,
 			TargetID: token.UserID.Bytes,
 			TenantID: token.TenantID.Bytes,
@@ -106,6 +108,10 @@ func (s *AuthService) RefreshSession(ctx context.Context, refreshToken string, i
 		ID:       token.UserID,
 		TenantID: token.TenantID,
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Not the home tenant: the session was switched to a tenant the user joined
+		return s.refreshMemberSession(ctx, token, newRawToken)
+	}
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
@@ -114,8 +120,11 @@ func (s *AuthService) RefreshSession(ctx context.Context, refreshToken string, i
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve tenant context: %w", err)
 	}
+	if err := s.ensureTenantActive(ctx, tenantID); err != nil {
+		return nil, err
+	}
 
-	accessToken, err := s.tokenProvider.GenerateAccessToken(uuid.UUID(user.ID.Bytes), tenantID, role)
+	accessToken, err := s.issueAccessToken(ctx, user, tenantID, role)
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +136,136 @@ func (s *AuthService) RefreshSession(ctx context.Context, refreshToken string, i
 	}, nil
 }
 
+// refreshMemberSession issues the access token of a rotated session that is
+// bound to a tenant other than the user's home tenant.
+func (s *AuthService) refreshMemberSession(ctx context.Context, token db.RefreshToken, newRawToken string) (*LoginResult, error) {
+	var result *LoginResult
+	err := s.withSessionTenant(ctx, token.TenantID.Bytes, func(ctx context.Context) error {
+		user, accessToken, err := s.memberAccessToken(ctx, token.UserID.Bytes, token.TenantID.Bytes)
+		if err != nil {
+			return err
+		}
+		result = &LoginResult{AccessToken: accessToken, RefreshToken: newRawToken, User: user}
+		return nil
+	})
+	return result, err
+}
+
+// ListUserTenants returns every active tenant the user is a member of.
+// Memberships of other tenants are invisible under the request's RLS
+// context, so this reads outside of it, filtered on the user alone.
+func (s *AuthService) ListUserTenants(ctx context.Context, userID uuid.UUID) ([]db.ListUserTenantsRow, error) {
+	var tenants []db.ListUserTenantsRow
+	err := storage.WithoutRLS(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		tenants, err = db.New(tx).ListUserTenants(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+		return err
+	})
+	return tenants, err
+}
+
+// SwitchTenant moves the caller's session to another tenant they are a member
+// of. A new refresh token family is bound to that tenant and the current
+// family is revoked, so the previous tenant's session cannot be refreshed.
+func (s *AuthService) SwitchTenant(ctx context.Context, userID, fromTenantID, toTenantID uuid.UUID, refreshToken string, ip net.IP, userAgent string) (*LoginResult, error) {
+	newRawToken, err := GenerateSecureToken(64)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *LoginResult
+	err = s.withSessionTenant(ctx, toTenantID, func(ctx context.Context) error {
+		user, accessToken, err := s.memberAccessToken(ctx, userID, toTenantID)
+		if err != nil {
+			return err
+		}
+		_, err = s.txQueries(ctx).CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+			UserID:        user.ID,
+			TokenHash:     hashToken(newRawToken),
+			ParentTokenID: pgtype.UUID{Valid: false},                   // Root of family
+			FamilyID:      pgtype.UUID{Bytes: uuid.New(), Valid: true}, // New Family
+			TenantID:      pgtype.UUID{Bytes: toTenantID, Valid: true},
+			IpAddress:     ip,
+			UserAgent:     pgtype.Text{String: userAgent, Valid: true},
+			ExpiresAt:     pgtype.Timestamptz{Time: time.Now().Add(7 * 24 * time.Hour), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store session: %w", err)
+		}
+		result = &LoginResult{AccessToken: accessToken, RefreshToken: newRawToken, User: user}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Retire the session we came from, but only if it is the caller's own
+	if refreshToken != "" {
+		hashed := hashToken(refreshToken)
+		if old, err := s.queries.GetRefreshToken(ctx, hashed); err == nil && uuid.UUID(old.UserID.Bytes) == userID {
+			if err := s.queries.RevokeTokenFamily(ctx, hashed); err != nil {
+				return nil, fmt.Errorf("failed to revoke previous session: %w", err)
+			}
+		}
+	}
+
+	s.audit.Log(ctx, audit.EventTenantSwitch, audit.LogParams{
+		ActorID:  userID,
+		TargetID: userID,
+		TenantID: toTenantID,
+		Metadata: map[string]interface{}{
+			"from_tenant_id": fromTenantID,
+			"ip":             ip.String(),
+		},
+	})
+	return result, nil
+}
+
+// memberAccessToken signs an access token for tenantID with the role of the
+// user's membership there. ctx must carry that tenant's transaction.
+func (s *AuthService) memberAccessToken(ctx context.Context, userID, tenantID uuid.UUID) (db.User, string, error) {
+	q := s.txQueries(ctx)
+	user, err := q.GetUserInTenant(ctx, db.GetUserInTenantParams{
+		ID:       pgtype.UUID{Bytes: userID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.User{}, "", ErrNotTenantMember
+	}
+	if err != nil {
+		return db.User{}, "", err
+	}
+	role, err := q.GetMembership(ctx, db.GetMembershipParams{
+		UserID:   user.ID,
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.User{}, "", ErrNotTenantMember
+	}
+	if err != nil {
+		return db.User{}, "", err
+	}
+	if err := s.ensureTenantActive(ctx, tenantID); err != nil {
+		return db.User{}, "", err
+	}
+	if err := s.ensureNotPendingDeletion(ctx, user.ID); err != nil {
+		return db.User{}, "", err
+	}
+	accessToken, err := s.issueAccessToken(ctx, user, tenantID, role)
+	if err != nil {
+		return db.User{}, "", err
+	}
+	return user, accessToken, nil
+}
+
+// withSessionTenant runs fn in a transaction bound to the tenant a session is
+// issued for, which need not be the tenant of the current request.
+func (s *AuthService) withSessionTenant(ctx context.Context, tenantID uuid.UUID, fn func(ctx context.Context) error) error {
+	return storage.WithTenantContext(ctx, s.pool, tenantID, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, storage.TxKey, tx))
+	})
+}
+
 func (s *AuthService) GetSessions(ctx context.Context, userID uuid.UUID) ([]db.RefreshToken, error) {
 	return s.queries.GetSessionsByUser(ctx, pgtype.UUID{Bytes: userID, Valid: true})
 }
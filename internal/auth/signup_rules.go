@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+)
+
+// Outcomes of a registration (RegisterResult.Status).
+const (
+	SignupCreated          = "created"           // Account exists, the user can log in
+	SignupPendingApproval  = "pending_approval"  // Waiting in the admin approval queue
+	SignupConfirmationSent = "confirmation_sent" // Verified domain: the emailed link creates the account
+)
+
+var (
+	ErrSignupClosed           = errors.New("self-service signup is closed for this tenant")
+	ErrSignupDomainBlocked    = errors.New("signups from this email domain are not accepted")
+	ErrSignupDomainNotAllowed = errors.New("signups are limited to specific email domains")
+)
+
+// TXTResolver looks up DNS TXT records; *net.Resolver satisfies it.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Tenants prove an email domain by publishing
+//
+//	_laventecare-verification.acme.com. TXT "laventecare-verification=<token>"
+const (
+	domainVerificationLabel  = "_laventecare-verification"
+	domainVerificationPrefix = "laventecare-verification="
+)
+
+// DomainVerificationRecord returns the TXT record name and value the tenant must publish.
+func DomainVerificationRecord(emailDomain, token string) (name, value string) {
+	return domainVerificationLabel + "." + emailDomain, domainVerificationPrefix + token
+}
+
+// checkDomainTXT reports whether the verification record for token is published.
+// A missing name is a negative answer, not an error.
+func checkDomainTXT(ctx context.Context, resolver TXTResolver, emailDomain, token string) (bool, error) {
+	name, want := DomainVerificationRecord(emailDomain, token)
+	records, err := resolver.LookupTXT(ctx, name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EmailDomain returns the lower-cased domain part of an address.
+func EmailDomain(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(email[at+1:], "."))
+}
+
+// domainMatches reports whether emailDomain is one of rules or a subdomain of one.
+func domainMatches(emailDomain string, rules []string) bool {
+	for _, rule := range rules {
+		if emailDomain == rule || strings.HasSuffix(emailDomain, "."+rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// decideSignup applies the tenant's rules to a signup from emailDomain.
+// autoJoin reports whether that domain is a verified auto-join domain of the
+// tenant; in open mode everyone is created directly, so it only matters when
+// signup is otherwise restricted.
+func decideSignup(rules domain.SignupSettings, emailDomain string, autoJoin bool) (string, error) {
+	if domainMatches(emailDomain, rules.BlockedDomains) {
+		return "", ErrSignupDomainBlocked
+	}
+	if len(rules.AllowedDomains) > 0 && !autoJoin && !domainMatches(emailDomain, rules.AllowedDomains) {
+		return "", ErrSignupDomainNotAllowed
+	}
+
+	mode := rules.EffectiveMode()
+	switch {
+	case mode == domain.SignupModeOpen:
+		return SignupCreated, nil
+	case autoJoin:
+		return SignupConfirmationSent, nil
+	case mode == domain.SignupModeApproval:
+		return SignupPendingApproval, nil
+	default:
+		return "", ErrSignupClosed
+	}
+}
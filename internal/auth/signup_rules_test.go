@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecideSignup(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    domain.SignupSettings
+		domain   string
+		autoJoin bool
+		want     string
+		wantErr  error
+	}{
+		{"empty mode is open", domain.SignupSettings{}, "example.com", false, SignupCreated, nil},
+		{"open ignores auto-join", domain.SignupSettings{Mode: domain.SignupModeOpen}, "acme.com", true, SignupCreated, nil},
+		{"approval queues", domain.SignupSettings{Mode: domain.SignupModeApproval}, "example.com", false, SignupPendingApproval, nil},
+		{"closed rejects", domain.SignupSettings{Mode: domain.SignupModeClosed}, "example.com", false, "", ErrSignupClosed},
+		{"auto-join bypasses closed", domain.SignupSettings{Mode: domain.SignupModeClosed}, "acme.com", true, SignupConfirmationSent, nil},
+		{"auto-join bypasses approval", domain.SignupSettings{Mode: domain.SignupModeApproval}, "acme.com", true, SignupConfirmationSent, nil},
+		{"blocked subdomain", domain.SignupSettings{BlockedDomains: []string{"spam.io"}}, "mail.spam.io", false, "", ErrSignupDomainBlocked},
+		{"blocked beats auto-join", domain.SignupSettings{Mode: domain.SignupModeClosed, BlockedDomains: []string{"acme.com"}}, "acme.com", true, "", ErrSignupDomainBlocked},
+		{"allow-list miss", domain.SignupSettings{AllowedDomains: []string{"acme.com"}}, "example.com", false, "", ErrSignupDomainNotAllowed},
+		{"allow-list hit", domain.SignupSettings{AllowedDomains: []string{"acme.com"}}, "eu.acme.com", false, SignupCreated, nil},
+		{"suffix is not a subdomain", domain.SignupSettings{AllowedDomains: []string{"acme.com"}}, "notacme.com", false, "", ErrSignupDomainNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decideSignup(tt.rules, tt.domain, tt.autoJoin)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSignupRules_LegacySettings(t *testing.T) {
+	tests := []struct {
+		blob string
+		want string
+	}{
+		{`{"allow_registration": false}`, domain.SignupModeClosed},
+		{`{"allow_registration": true}`, domain.SignupModeOpen},
+		{`{}`, domain.SignupModeClosed},
+		{`{"allow_registration": false, "signup": {"mode": "approval"}}`, domain.SignupModeApproval},
+	}
+	for _, tt := range tests {
+		var settings domain.TenantSettings
+		require.NoError(t, json.Unmarshal([]byte(tt.blob), &settings))
+		assert.Equal(t, tt.want, settings.SignupRules().EffectiveMode(), tt.blob)
+	}
+
+	var legacy domain.TenantSettings
+	require.NoError(t, json.Unmarshal([]byte(`{"allow_registration": false}`), &legacy))
+	_, err := decideSignup(legacy.SignupRules(), "example.com", false)
+	assert.ErrorIs(t, err, ErrSignupClosed)
+}
+
+func TestEmailDomain(t *testing.T) {
+	assert.Equal(t, "acme.com", EmailDomain("Jan@ACME.com"))
+	assert.Equal(t, "acme.com", EmailDomain("\"a@b\"@acme.com."))
+	assert.Equal(t, "", EmailDomain("not-an-email"))
+}
+
+type fakeTXTResolver map[string][]string
+
+func (f fakeTXTResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	records, ok := f[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+type failingTXTResolver struct{}
+
+func (failingTXTResolver) LookupTXT(context.Context, string) ([]string, error) {
+	return nil, errors.New("dns timeout")
+}
+
+func TestCheckDomainTXT(t *testing.T) {
+	name, value := DomainVerificationRecord("acme.com", "tok123")
+	assert.Equal(t, "_laventecare-verification.acme.com", name)
+	assert.Equal(t, "laventecare-verification=tok123", value)
+
+	resolver := fakeTXTResolver{name: {"v=spf1 -all", " " + value + " "}}
+	ok, err := checkDomainTXT(context.Background(), resolver, "acme.com", "tok123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = checkDomainTXT(context.Background(), resolver, "acme.com", "other")
+	require.NoError(t, err)
+	assert.False(t, ok, "a different token must not verify")
+
+	ok, err = checkDomainTXT(context.Background(), resolver, "example.com", "tok123")
+	require.NoError(t, err, "NXDOMAIN is a negative answer")
+	assert.False(t, ok)
+
+	_, err = checkDomainTXT(context.Background(), failingTXTResolver{}, "acme.com", "tok123")
+	assert.Error(t, err)
+}
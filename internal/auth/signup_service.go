@@ -0,0 +1,425 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// domainSignupLinkExpiry is how long the confirmation link of a domain auto-join stays valid.
+const domainSignupLinkExpiry = 24 * time.Hour
+
+// Signup request statuses as listed by GET /admin/signup/requests.
+const (
+	SignupRequestPending  = "pending"
+	SignupRequestApproved = "approved"
+	SignupRequestRejected = "rejected"
+)
+
+var (
+	ErrSignupRequestNotFound = errors.New("signup request not found or already decided")
+	ErrSignupEmailTaken      = errors.New("an account with this email already exists in this tenant")
+	ErrInvalidEmailDomain    = errors.New("invalid email domain")
+	ErrEmailDomainNotFound   = errors.New("email domain not found")
+	ErrEmailDomainExists     = errors.New("email domain already added")
+	ErrEmailDomainTaken      = errors.New("email domain is verified by another tenant")
+	ErrDomainTXTNotFound     = errors.New("verification TXT record not found")
+)
+
+// RegisterResult tells the caller what a registration led to. User is only
+// set when the account was created (Status SignupCreated).
+type RegisterResult struct {
+	Status string
+	User   *db.User
+}
+
+// signupDecision loads the tenant's rules and decides how a self-service
+// signup for email proceeds.
+func (s *AuthService) signupDecision(ctx context.Context, tenantID uuid.UUID, email string) (string, domain.SignupSettings, error) {
+	q := s.txQueries(ctx)
+	tenant, err := q.GetTenantByID(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+	if err != nil {
+		return "", domain.SignupSettings{}, fmt.Errorf("failed to load tenant: %w", err)
+	}
+	rules := tenant.Settings.SignupRules()
+
+	emailDomain := EmailDomain(email)
+	_, err = q.GetAutoJoinDomain(ctx, db.GetAutoJoinDomainParams{
+		TenantID: tenant.ID,
+		Domain:   emailDomain,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", rules, err
+	}
+
+	status, err := decideSignup(rules, emailDomain, err == nil)
+	return status, rules, err
+}
+
+// queueSignupRequest parks a signup in the approval queue. Signing up again
+// while a request is open is answered the same way, without a second row.
+func (s *AuthService) queueSignupRequest(ctx context.Context, input RegisterInput, passwordHash string) (*RegisterResult, error) {
+	err := storage.InTenantTx(ctx, s.pool, input.TenantID, func(q *db.Queries) error {
+		_, err := q.CreateSignupRequest(ctx, db.CreateSignupRequestParams{
+			TenantID:     pgtype.UUID{Bytes: input.TenantID, Valid: true},
+			Email:        input.Email,
+			FullName:     pgtype.Text{String: input.FullName, Valid: input.FullName != ""},
+			PasswordHash: passwordHash,
+			Method:       "approval",
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue signup: %w", err)
+	}
+
+	s.audit.Log(ctx, audit.EventSignupRequested, audit.LogParams{
+		TenantID: input.TenantID,
+		Metadata: map[string]interface{}{
+			"method":       "approval",
+			"email_domain": EmailDomain(input.Email),
+		},
+	})
+	return &RegisterResult{Status: SignupPendingApproval}, nil
+}
+
+// sendDomainSignupConfirmation mails the link that creates an auto-join
+// account. The link goes through the regular verification template: following
+// it is what proves the address belongs to the verified domain.
+func (s *AuthService) sendDomainSignupConfirmation(ctx context.Context, input RegisterInput, passwordHash string) (*RegisterResult, error) {
+	token, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	appURL := s.appURL(ctx, input.TenantID)
+
+	err = storage.InTenantTx(ctx, s.pool, input.TenantID, func(q *db.Queries) error {
+		err := q.DeletePendingDomainSignup(ctx, db.DeletePendingDomainSignupParams{
+			TenantID: pgtype.UUID{Bytes: input.TenantID, Valid: true},
+			Email:    input.Email,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateSignupRequest(ctx, db.CreateSignupRequestParams{
+			TenantID:         pgtype.UUID{Bytes: input.TenantID, Valid: true},
+			Email:            input.Email,
+			FullName:         pgtype.Text{String: input.FullName, Valid: input.FullName != ""},
+			PasswordHash:     passwordHash,
+			Method:           "domain",
+			ConfirmTokenHash: pgtype.Text{String: hashToken(token), Valid: true},
+			ExpiresAt:        pgtype.Timestamptz{Time: time.Now().Add(domainSignupLinkExpiry), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return s.mail.SendVerification(ctx, input.Email, token, appURL)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start domain signup: %w", err)
+	}
+	return &RegisterResult{Status: SignupConfirmationSent}, nil
+}
+
+// confirmDomainSignup redeems a domain auto-join link: the account is created
+// verified, with the tenant's signup role. Returns false when hashedToken is
+// not such a link. The link carries no tenant, hence WithoutRLS.
+func (s *AuthService) confirmDomainSignup(ctx context.Context, hashedToken string) (bool, error) {
+	var user db.CreateUserWithMembershipRow
+	var request db.SignupRequest
+	err := storage.WithoutRLS(ctx, s.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		var err error
+		request, err = q.ClaimDomainSignup(ctx, pgtype.Text{String: hashedToken, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		// The domain may have been removed since the link was sent
+		tenant, err := q.GetTenantByID(ctx, request.TenantID)
+		if err != nil {
+			return err
+		}
+		if _, err := q.GetAutoJoinDomain(ctx, db.GetAutoJoinDomainParams{TenantID: request.TenantID, Domain: EmailDomain(request.Email)}); err != nil {
+			return err
+		}
+
+		user, err = q.CreateUserWithMembership(ctx, db.CreateUserWithMembershipParams{
+			Email:        request.Email,
+			PasswordHash: pgtype.Text{String: request.PasswordHash, Valid: true},
+			FullName:     request.FullName,
+			TenantID:     request.TenantID,
+			Role:         tenant.Settings.Signup.Role(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.VerifyUserEmail(ctx, user.ID); err != nil {
+			return err
+		}
+		return q.SetSignupRequestUser(ctx, db.SetSignupRequestUserParams{ID: request.ID, UserID: user.ID})
+	})
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows) && !request.ID.Valid:
+		return false, nil
+	case errors.Is(err, pgx.ErrNoRows), errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return true, ErrInvalidVerificationToken
+	case err != nil:
+		return true, err
+	}
+
+	s.audit.Log(ctx, audit.EventUserCreatedDomain, audit.LogParams{
+		ActorID:  user.ID.Bytes,
+		TargetID: user.ID.Bytes,
+		TenantID: request.TenantID.Bytes,
+		Metadata: map[string]interface{}{
+			"method":       "domain_auto_join",
+			"email_domain": EmailDomain(request.Email),
+		},
+	})
+	return true, nil
+}
+
+// ListSignupRequests returns a page of the approval queue and the total for the filter.
+func (s *AuthService) ListSignupRequests(ctx context.Context, tenantID uuid.UUID, status string, limit, offset int32) ([]db.SignupRequest, int64, error) {
+	q := s.txQueries(ctx)
+	requests, err := q.ListSignupRequests(ctx, db.ListSignupRequestsParams{
+		TenantID:   pgtype.UUID{Bytes: tenantID, Valid: true},
+		Status:     status,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := q.CountSignupRequests(ctx, db.CountSignupRequestsParams{
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		Status:   status,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// ApproveSignupRequest creates the requested account with the tenant's signup role.
+func (s *AuthService) ApproveSignupRequest(ctx context.Context, tenantID, requestID, actorID uuid.UUID) (db.SignupRequest, error) {
+	var decided db.SignupRequest
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		request, err := q.GetPendingSignupRequest(ctx, db.GetPendingSignupRequestParams{
+			ID:       pgtype.UUID{Bytes: requestID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSignupRequestNotFound
+		}
+		if err != nil {
+			return err
+		}
+		tenant, err := q.GetTenantByID(ctx, request.TenantID)
+		if err != nil {
+			return err
+		}
+
+		user, err := q.CreateUserWithMembership(ctx, db.CreateUserWithMembershipParams{
+			Email:        request.Email,
+			PasswordHash: pgtype.Text{String: request.PasswordHash, Valid: true},
+			FullName:     request.FullName,
+			TenantID:     request.TenantID,
+			Role:         tenant.Settings.Signup.Role(),
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrSignupEmailTaken
+		}
+		if err != nil {
+			return err
+		}
+
+		decided, err = q.DecideSignupRequest(ctx, db.DecideSignupRequestParams{
+			ID:        request.ID,
+			TenantID:  request.TenantID,
+			Status:    SignupRequestApproved,
+			DecidedBy: pgtype.UUID{Bytes: actorID, Valid: true},
+			UserID:    user.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return db.SignupRequest{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventSignupApproved, audit.LogParams{
+		ActorID:  actorID,
+		TargetID: decided.UserID.Bytes,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"signup_request_id": requestID,
+		},
+	})
+	return decided, nil
+}
+
+// RejectSignupRequest closes a request without creating an account.
+func (s *AuthService) RejectSignupRequest(ctx context.Context, tenantID, requestID, actorID uuid.UUID) (db.SignupRequest, error) {
+	decided, err := s.txQueries(ctx).DecideSignupRequest(ctx, db.DecideSignupRequestParams{
+		ID:        pgtype.UUID{Bytes: requestID, Valid: true},
+		TenantID:  pgtype.UUID{Bytes: tenantID, Valid: true},
+		Status:    SignupRequestRejected,
+		DecidedBy: pgtype.UUID{Bytes: actorID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.SignupRequest{}, ErrSignupRequestNotFound
+	}
+	if err != nil {
+		return db.SignupRequest{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventSignupRejected, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"signup_request_id": requestID,
+		},
+	})
+	return decided, nil
+}
+
+// AddEmailDomain claims an email domain for auto-join. It stays inactive until
+// VerifyEmailDomain finds the TXT record (see DomainVerificationRecord).
+func (s *AuthService) AddEmailDomain(ctx context.Context, tenantID, actorID uuid.UUID, emailDomain string, autoJoin bool) (db.TenantEmailDomain, error) {
+	normalized, err := storage.ValidateTenantDomain(emailDomain, "")
+	if err != nil {
+		return db.TenantEmailDomain{}, fmt.Errorf("%w: %v", ErrInvalidEmailDomain, err)
+	}
+	token, err := GenerateSecureToken(24)
+	if err != nil {
+		return db.TenantEmailDomain{}, err
+	}
+
+	created, err := s.txQueries(ctx).CreateEmailDomain(ctx, db.CreateEmailDomainParams{
+		TenantID:          pgtype.UUID{Bytes: tenantID, Valid: true},
+		Domain:            normalized,
+		VerificationToken: token,
+		AutoJoin:          autoJoin,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return db.TenantEmailDomain{}, ErrEmailDomainExists
+	}
+	if err != nil {
+		return db.TenantEmailDomain{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventEmailDomainAdded, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"domain":    normalized,
+			"auto_join": autoJoin,
+		},
+	})
+	return created, nil
+}
+
+// ListEmailDomains returns the tenant's claimed email domains.
+func (s *AuthService) ListEmailDomains(ctx context.Context, tenantID uuid.UUID) ([]db.TenantEmailDomain, error) {
+	return s.txQueries(ctx).ListEmailDomains(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+}
+
+// VerifyEmailDomain checks DNS for the domain's TXT record and marks it
+// verified when found. A domain stays verified once proven.
+func (s *AuthService) VerifyEmailDomain(ctx context.Context, tenantID, domainID, actorID uuid.UUID) (db.TenantEmailDomain, error) {
+	q := s.txQueries(ctx)
+	params := db.GetEmailDomainParams{
+		ID:       pgtype.UUID{Bytes: domainID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	}
+	emailDomain, err := q.GetEmailDomain(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.TenantEmailDomain{}, ErrEmailDomainNotFound
+	}
+	if err != nil {
+		return db.TenantEmailDomain{}, err
+	}
+	if emailDomain.VerifiedAt.Valid {
+		return emailDomain, nil
+	}
+
+	found, err := checkDomainTXT(ctx, s.txtResolver(), emailDomain.Domain, emailDomain.VerificationToken)
+	if err != nil {
+		return db.TenantEmailDomain{}, fmt.Errorf("dns lookup failed: %w", err)
+	}
+	if !found {
+		// Recorded outside the request transaction, which rolls back on the error response
+		if err := s.queries.MarkEmailDomainChecked(ctx, db.MarkEmailDomainCheckedParams(params)); err != nil {
+			return db.TenantEmailDomain{}, err
+		}
+		return db.TenantEmailDomain{}, ErrDomainTXTNotFound
+	}
+
+	verified, err := q.MarkEmailDomainVerified(ctx, db.MarkEmailDomainVerifiedParams(params))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return db.TenantEmailDomain{}, ErrEmailDomainTaken
+	}
+	if err != nil {
+		return db.TenantEmailDomain{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventEmailDomainVerified, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"domain": verified.Domain,
+		},
+	})
+	return verified, nil
+}
+
+// DeleteEmailDomain removes a claimed domain; its addresses no longer auto-join.
+func (s *AuthService) DeleteEmailDomain(ctx context.Context, tenantID, domainID, actorID uuid.UUID) error {
+	rows, err := s.txQueries(ctx).DeleteEmailDomain(ctx, db.DeleteEmailDomainParams{
+		ID:       pgtype.UUID{Bytes: domainID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrEmailDomainNotFound
+	}
+
+	s.audit.Log(ctx, audit.EventEmailDomainRemoved, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"domain_id": domainID,
+		},
+	})
+	return nil
+}
+
+// txtResolver is the configured resolver, or the system one.
+func (s *AuthService) txtResolver() TXTResolver {
+	if s.config.TXTResolver != nil {
+		return s.config.TXTResolver
+	}
+	return net.DefaultResolver
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrTenantDomainNotFound = errors.New("domain not found")
+	ErrTenantDomainTaken    = errors.New("domain is verified by another tenant")
+)
+
+// VerifyTenantDomain checks DNS for the custom hostname's TXT record and marks
+// it verified when found. Only verified hostnames route to the tenant.
+func (s *AuthService) VerifyTenantDomain(ctx context.Context, tenantID, domainID, actorID uuid.UUID) (db.TenantDomain, error) {
+	q := s.txQueries(ctx)
+	params := db.GetTenantDomainParams{
+		ID:       pgtype.UUID{Bytes: domainID, Valid: true},
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+	}
+	tenantDomain, err := q.GetTenantDomain(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.TenantDomain{}, ErrTenantDomainNotFound
+	}
+	if err != nil {
+		return db.TenantDomain{}, err
+	}
+	if tenantDomain.VerifiedAt.Valid {
+		return tenantDomain, nil
+	}
+
+	found, err := checkDomainTXT(ctx, s.txtResolver(), tenantDomain.Domain, tenantDomain.VerificationToken)
+	if err != nil {
+		return db.TenantDomain{}, fmt.Errorf("dns lookup failed: %w", err)
+	}
+	if !found {
+		// Recorded outside the request transaction, which rolls back on the error response
+		if err := s.queries.MarkTenantDomainChecked(ctx, db.MarkTenantDomainCheckedParams(params)); err != nil {
+			return db.TenantDomain{}, err
+		}
+		return db.TenantDomain{}, ErrDomainTXTNotFound
+	}
+
+	verified, err := q.MarkTenantDomainVerified(ctx, db.MarkTenantDomainVerifiedParams(params))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return db.TenantDomain{}, ErrTenantDomainTaken
+	}
+	if err != nil {
+		return db.TenantDomain{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventTenantDomainVerified, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"domain": verified.Domain,
+		},
+	})
+	return verified, nil
+}
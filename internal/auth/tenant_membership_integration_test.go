@@ -0,0 +1,134 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func membershipCount(t *testing.T, pool *pgxpool.Pool, userID, tenantID uuid.UUID) int {
+	t.Helper()
+	var n int
+	require.NoError(t, pool.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM memberships WHERE user_id = $1 AND tenant_id = $2`, userID, tenantID).Scan(&n))
+	return n
+}
+
+func refreshTokenFamily(t *testing.T, pool *pgxpool.Pool, rawToken string) uuid.UUID {
+	t.Helper()
+	h := sha256.Sum256([]byte(rawToken))
+	var family uuid.UUID
+	require.NoError(t, pool.QueryRow(context.Background(),
+		`SELECT family_id FROM refresh_tokens WHERE token_hash = $1`, hex.EncodeToString(h[:])).Scan(&family))
+	return family
+}
+
+func TestAcceptInvitationAsMember_ClaimsOnce(t *testing.T) {
+	pool := integrationPool(t)
+	svc, _ := newIntegrationService(t, pool)
+	ctx := context.Background()
+	homeTenant := createTestTenant(t, pool)
+	otherTenant := createTestTenant(t, pool)
+	const email = "member@example.com"
+	userID := createTestUser(t, pool, homeTenant, email, "Password-123")
+
+	_, token, err := svc.CreateInvitation(ctx, email, otherTenant, "user", uuid.Nil)
+	require.NoError(t, err)
+
+	// Concurrent accepts: one claims the invitation, the rest find nothing left
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.AcceptInvitationAsMember(ctx, userID, homeTenant, token)
+		}()
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, err := range errs {
+		if err == nil {
+			accepted++
+		} else {
+			assert.ErrorIs(t, err, auth.ErrInvitationNotFound)
+		}
+	}
+	assert.Equal(t, 1, accepted)
+	assert.Equal(t, 1, membershipCount(t, pool, userID, otherTenant))
+
+	_, err = svc.AcceptInvitationAsMember(ctx, userID, homeTenant, token)
+	assert.ErrorIs(t, err, auth.ErrInvitationNotFound, "a claimed invitation cannot be used again")
+	assert.Equal(t, 1, membershipCount(t, pool, userID, otherTenant))
+
+	// A fresh invitation is refused once the user is a member
+	_, _, err = svc.CreateInvitation(ctx, email, otherTenant, "user", uuid.Nil)
+	assert.ErrorIs(t, err, auth.ErrAlreadyMember)
+}
+
+func TestAcceptInvitationAsMember_OtherAddress(t *testing.T) {
+	pool := integrationPool(t)
+	svc, _ := newIntegrationService(t, pool)
+	ctx := context.Background()
+	homeTenant := createTestTenant(t, pool)
+	otherTenant := createTestTenant(t, pool)
+	userID := createTestUser(t, pool, homeTenant, "member@example.com", "Password-123")
+
+	_, token, err := svc.CreateInvitation(ctx, "someone-else@example.com", otherTenant, "user", uuid.Nil)
+	require.NoError(t, err)
+
+	_, err = svc.AcceptInvitationAsMember(ctx, userID, homeTenant, token)
+	assert.ErrorIs(t, err, auth.ErrInvitationEmailMismatch)
+	assert.Zero(t, membershipCount(t, pool, userID, otherTenant))
+}
+
+func TestSwitchTenant(t *testing.T) {
+	pool := integrationPool(t)
+	svc, _ := newIntegrationService(t, pool)
+	ctx := context.Background()
+	homeTenant := createTestTenant(t, pool)
+	joinedTenant := createTestTenant(t, pool)
+	strangerTenant := createTestTenant(t, pool)
+	const email = "switch@example.com"
+	userID := createTestUser(t, pool, homeTenant, email, "Password-123")
+
+	_, token, err := svc.CreateInvitation(ctx, email, joinedTenant, "user", uuid.Nil)
+	require.NoError(t, err)
+	_, err = svc.AcceptInvitationAsMember(ctx, userID, homeTenant, token)
+	require.NoError(t, err)
+
+	session, err := svc.Login(ctx, auth.LoginInput{Email: email, Password: "Password-123", TenantID: homeTenant})
+	require.NoError(t, err)
+
+	t.Run("RefusesTenantsTheUserIsNotIn", func(t *testing.T) {
+		_, err := svc.SwitchTenant(ctx, userID, homeTenant, strangerTenant, session.RefreshToken, nil, "")
+		assert.ErrorIs(t, err, auth.ErrNotTenantMember)
+
+		// The session the user came from is left alone
+		session, err = svc.RefreshSession(ctx, session.RefreshToken, nil, "")
+		require.NoError(t, err)
+	})
+
+	t.Run("StartsANewFamily", func(t *testing.T) {
+		oldFamily := refreshTokenFamily(t, pool, session.RefreshToken)
+
+		switched, err := svc.SwitchTenant(ctx, userID, homeTenant, joinedTenant, session.RefreshToken, nil, "")
+		require.NoError(t, err)
+		assert.NotEqual(t, oldFamily, refreshTokenFamily(t, pool, switched.RefreshToken))
+
+		// The previous family is revoked; the new one refreshes within the joined tenant
+		_, err = svc.RefreshSession(ctx, session.RefreshToken, nil, "")
+		assert.Error(t, err)
+		_, err = svc.RefreshSession(ctx, switched.RefreshToken, nil, "")
+		assert.NoError(t, err)
+	})
+}
@@ -22,7 +22,7 @@ var (
 
 // TokenProvider defines the contract for generating and validating tokens.
 type TokenProvider interface {
-	GenerateAccessToken(userID uuid.UUID, tenantID uuid.UUID, role string) (string, error)
+	GenerateAccessToken(userID uuid.UUID, tenantID uuid.UUID, role string, permissions []string, emailVerified *bool) (string, error)
 	GeneratePreAuthToken(userID uuid.UUID) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	GetJWKS() (*JWKS, error) // New: Export public keys
@@ -33,10 +33,27 @@ type Claims struct {
 	UserID   uuid.UUID `json:"sub"`
 	TenantID uuid.UUID `json:"tid,omitempty"`
 	Role     string    `json:"role,omitempty"`
-	Scope    string    `json:"scope"` // "access" or "pre_auth"
+	// Permissions resolved from Role at issue time (nil on tokens issued before Phase 31).
+	Permissions []string `json:"perms"`
+	Scope       string   `json:"scope"` // "access", "pre_auth" or "platform"
+	// Actor is set on impersonation tokens: the platform operator acting as UserID (RFC 8693 "act").
+	Actor *ActorClaim `json:"act,omitempty"`
+	// EmailVerified is only present when the tenant has an email verification policy.
+	EmailVerified *bool `json:"email_verified,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaim identifies who is really behind an impersonated session.
+type ActorClaim struct {
+	Subject uuid.UUID `json:"sub"`
+}
+
+// Token lifetimes for the platform plane.
+const (
+	PlatformTokenDuration      = 30 * time.Minute
+	ImpersonationTokenDuration = 15 * time.Minute
+)
+
 // JWK represents a JSON Web Key.
 type JWK struct {
 	Kty string `json:"kty"`
@@ -91,12 +108,19 @@ func NewJWTProvider(secretKeyPEM string) *JWTProvider {
 }
 
 // GenerateAccessToken creates a signed JWT for the user.
-func (p *JWTProvider) GenerateAccessToken(userID uuid.UUID, tenantID uuid.UUID, role string) (string, error) {
+// permissions are the resolved permissions of role in tenantID; a nil
+// emailVerified leaves the email_verified claim out.
+func (p *JWTProvider) GenerateAccessToken(userID uuid.UUID, tenantID uuid.UUID, role string, permissions []string, emailVerified *bool) (string, error) {
+	if permissions == nil {
+		permissions = []string{} // Distinguish "no permissions" from legacy tokens
+	}
 	claims := Claims{
-		UserID:   userID,
-		TenantID: tenantID,
-		Role:     role,
-		Scope:    "access",
+		UserID:        userID,
+		TenantID:      tenantID,
+		Role:          role,
+		Permissions:   permissions,
+		Scope:         "access",
+		EmailVerified: emailVerified,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(p.tokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-1 * time.Minute)), // Fix clock skew
@@ -139,6 +163,78 @@ func (p *JWTProvider) GeneratePreAuthToken(userID uuid.UUID) (string, error) {
 	return signed, nil
 }
 
+// GeneratePlatformToken creates a token for a platform operator (/platform/v1).
+// It carries no tenant and is rejected by the tenant AuthMiddleware (scope check).
+func (p *JWTProvider) GeneratePlatformToken(adminID uuid.UUID) (string, error) {
+	claims := Claims{
+		UserID: adminID,
+		Scope:  "platform",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(PlatformTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "https://laventecareauthsystems.onrender.com",
+			Audience:  jwt.ClaimStrings{"platform"},
+		},
+	}
+	return p.sign(claims)
+}
+
+// GenerateImpersonationToken creates a short-lived access token for userID in tenantID
+// on behalf of a platform operator. The act claim keeps the real actor visible to
+// AuthMiddleware (and thus the audit log). No refresh token is ever issued for it.
+func (p *JWTProvider) GenerateImpersonationToken(userID, tenantID uuid.UUID, role string, permissions []string, actorID uuid.UUID) (string, error) {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	claims := Claims{
+		UserID:      userID,
+		TenantID:    tenantID,
+		Role:        role,
+		Permissions: permissions,
+		Scope:       "access",
+		Actor:       &ActorClaim{Subject: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ImpersonationTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "https://laventecareauthsystems.onrender.com",
+			Audience:  jwt.ClaimStrings{"convex"},
+		},
+	}
+	return p.sign(claims)
+}
+
+func (p *JWTProvider) sign(claims Claims) (string, error) {
+	return p.SignClaims(claims)
+}
+
+// SignClaims signs arbitrary claims with the token signing key (RS256, same kid),
+// e.g. audit checkpoints. Verify them with ParseClaims or the JWKS.
+func (p *JWTProvider) SignClaims(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	signed, err := token.SignedString(p.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, nil
+}
+
+// ParseClaims verifies a token from SignClaims and decodes it into claims.
+func (p *JWTProvider) ParseClaims(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return p.publicKey, nil
+	})
+	if err != nil || !token.Valid {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
 // ValidateToken parses and verifies the JWT.
 func (p *JWTProvider) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
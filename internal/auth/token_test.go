@@ -0,0 +1,40 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccessToken_EmailVerifiedClaim: the claim is only present when the
+// tenant has an email verification policy, and round-trips both values.
+func TestAccessToken_EmailVerifiedClaim(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	provider := auth.NewJWTProvider(string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})))
+	userID, tenantID := uuid.New(), uuid.New()
+
+	token, err := provider.GenerateAccessToken(userID, tenantID, "user", nil, nil)
+	require.NoError(t, err)
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+	require.NoError(t, err)
+	assert.NotContains(t, string(payload), "email_verified")
+
+	for _, verified := range []bool{true, false} {
+		token, err := provider.GenerateAccessToken(userID, tenantID, "user", nil, &verified)
+		require.NoError(t, err)
+		claims, err := provider.ValidateToken(token)
+		require.NoError(t, err)
+		require.NotNil(t, claims.EmailVerified)
+		assert.Equal(t, verified, *claims.EmailVerified)
+	}
+}
@@ -3,78 +3,227 @@ package auth
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/notify"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// emailChangeRevertWindow is how long the notice to the old address can undo a change.
+const emailChangeRevertWindow = 7 * 24 * time.Hour
+
+var (
+	ErrEmailTaken              = errors.New("email address already in use")
+	ErrInvalidEmailChangeToken = errors.New("invalid or expired email change link")
+)
+
 // RequestEmailChange initiates a secure email change flow.
-// RequestEmailChange initiates a secure email change flow.
-// Requires current password validation.
-func (s *AuthService) RequestEmailChange(ctx context.Context, userID uuid.UUID, tenantID uuid.UUID, newEmail string, password string) (string, error) {
+// Requires current password validation. The confirmation link goes to the new
+// address, a notice with a revert link to the current one; no token is returned.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID uuid.UUID, tenantID uuid.UUID, newEmail string, password string) error {
+	q := s.txQueries(ctx)
+
 	// 1. Verify User & Password
-	user, err := s.queries.GetUserByID(ctx, db.GetUserByIDParams{
+	user, err := q.GetUserInTenant(ctx, db.GetUserInTenantParams{
 		ID:       pgtype.UUID{Bytes: userID, Valid: true},
 		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
 	})
 	if err != nil {
-		return "", ErrUserNotFound
+		return ErrUserNotFound
 	}
 
 	if !user.PasswordHash.Valid {
-		return "", errors.New("user has no password")
+		return ErrInvalidCredentials
 	}
 	if err := s.passwordHasher.Compare(user.PasswordHash.String, password); err != nil {
-		return "", errors.New("invalid password")
+		return ErrInvalidCredentials
+	}
+
+	// 2. Emails are unique per home tenant, not the session tenant (checked again on confirmation)
+	if strings.EqualFold(user.Email, newEmail) {
+		return ErrEmailTaken
+	}
+	if err := checkEmailFree(ctx, q, user.TenantID, newEmail); err != nil {
+		return err
 	}
 
-	// 2. Generate Change Token
+	// 3. Generate Confirm & Revert Tokens
 	token, err := GenerateSecureToken(32)
 	if err != nil {
-		return "", err
+		return err
+	}
+	revertToken, err := GenerateSecureToken(32)
+	if err != nil {
+		return err
 	}
-	hash := hashToken(token)
 
-	// 3. Store Request
-	_, err = s.queries.CreateEmailChangeRequest(ctx, db.CreateEmailChangeRequestParams{
-		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
-		NewEmail:  newEmail,
-		TokenHash: hash,
-		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(1 * time.Hour), Valid: true}, // 1 hour expiry
+	// 4. Store Request (hashes only)
+	now := time.Now()
+	change, err := q.CreateEmailChangeRequest(ctx, db.CreateEmailChangeRequestParams{
+		UserID:          pgtype.UUID{Bytes: userID, Valid: true},
+		NewEmail:        newEmail,
+		TokenHash:       hashToken(token),
+		ExpiresAt:       pgtype.Timestamptz{Time: now.Add(1 * time.Hour), Valid: true}, // 1 hour expiry
+		OldEmail:        pgtype.Text{String: user.Email, Valid: true},
+		RevertTokenHash: pgtype.Text{String: hashToken(revertToken), Valid: true},
+		RevertExpiresAt: pgtype.Timestamptz{Time: now.Add(emailChangeRevertWindow), Valid: true},
 	})
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	// In real app: Send Email to NEW address with token
-	return token, nil
+	// 5. Notify both addresses
+	appURL := s.appURL(ctx, tenantID)
+	if err := s.mail.SendEmailChange(ctx, newEmail, token, appURL); err != nil {
+		return err
+	}
+	if err := s.mail.SendEmailChangeNotice(ctx, user.Email, revertToken, appURL); err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventEmailChangeRequested, audit.LogParams{
+		ActorID:  userID,
+		TargetID: userID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"change_id": uuid.UUID(change.ID.Bytes).String(),
+		},
+	})
+	return nil
 }
 
 // ConfirmEmailChange validates the token and updates the user's email.
-func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) error {
-	hash := hashToken(token)
+// Update, mark-used and session revocation commit together or not at all.
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, tenantID uuid.UUID, token string) error {
+	var change db.EmailChangeRequest
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		change, err = q.GetEmailChangeRequest(ctx, hashToken(token))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidEmailChangeToken
+		}
+		if err != nil {
+			return err
+		}
+		// The link must be used within a tenant the user belongs to, which
+		// includes tenants joined after signup
+		user, err := q.GetUserInTenant(ctx, db.GetUserInTenantParams{ID: change.UserID, TenantID: pgtype.UUID{Bytes: tenantID, Valid: true}})
+		if err != nil {
+			return ErrInvalidEmailChangeToken
+		}
+		if err := checkEmailFree(ctx, q, user.TenantID, change.NewEmail); err != nil {
+			return err
+		}
 
-	req, err := s.queries.GetEmailChangeRequest(ctx, hash)
+		// The token only ever reached the new inbox, so confirming verifies the address
+		err = q.UpdateUserEmail(ctx, db.UpdateUserEmailParams{
+			ID:              change.UserID,
+			Email:           change.NewEmail,
+			IsEmailVerified: true,
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrEmailTaken // Taken since the request (unique_user_email_per_tenant)
+		}
+		if err != nil {
+			return err
+		}
+		if err := q.MarkEmailChangeRequestUsed(ctx, change.ID); err != nil {
+			return err
+		}
+		return q.RevokeAllSessions(ctx, change.UserID)
+	})
 	if err != nil {
-		return errors.New("invalid or expired token")
+		return err
 	}
 
-	// Update User Email
-	// Transactionally speaking, we should do this together.
-	// For MVP, we do sequential updates.
-	err = s.queries.UpdateUserEmail(ctx, db.UpdateUserEmailParams{
-		ID:    req.UserID,
-		Email: req.NewEmail,
+	s.audit.Log(ctx, audit.EventEmailChangeConfirmed, audit.LogParams{
+		ActorID:  change.UserID.Bytes,
+		TargetID: change.UserID.Bytes,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"change_id":            uuid.UUID(change.ID.Bytes).String(),
+			"revoked_all_sessions": true,
+		},
+	})
+	return nil
+}
+
+// RevertEmailChange handles the link sent to the old address. Before
+// confirmation it cancels the request; afterwards it restores the old address.
+// Either way all sessions are revoked: the change may be an account takeover.
+func (s *AuthService) RevertEmailChange(ctx context.Context, tenantID uuid.UUID, revertToken string) error {
+	var change db.EmailChangeRequest
+	restored := false
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		change, err = q.GetEmailChangeRequestByRevertToken(ctx, pgtype.Text{String: hashToken(revertToken), Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidEmailChangeToken
+		}
+		if err != nil {
+			return err
+		}
+		user, err := q.GetUserInTenant(ctx, db.GetUserInTenantParams{ID: change.UserID, TenantID: pgtype.UUID{Bytes: tenantID, Valid: true}})
+		if err != nil {
+			return ErrInvalidEmailChangeToken
+		}
+
+		// Only restore while the address is still the one this request set
+		if change.Used && change.OldEmail.Valid && strings.EqualFold(user.Email, change.NewEmail) {
+			err = q.UpdateUserEmail(ctx, db.UpdateUserEmailParams{
+				ID:              change.UserID,
+				Email:           change.OldEmail.String,
+				IsEmailVerified: true, // The revert link reached the old inbox
+			})
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return ErrEmailTaken
+			}
+			if err != nil {
+				return err
+			}
+			restored = true
+		}
+		if err := q.MarkEmailChangeRequestReverted(ctx, change.ID); err != nil {
+			return err
+		}
+		return q.RevokeAllSessions(ctx, change.UserID)
 	})
 	if err != nil {
 		return err
 	}
 
-	// Mark Used
-	return s.queries.MarkEmailChangeRequestUsed(ctx, req.ID)
+	s.audit.Log(ctx, audit.EventEmailChangeReverted, audit.LogParams{
+		ActorID:  change.UserID.Bytes,
+		TargetID: change.UserID.Bytes,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"change_id":            uuid.UUID(change.ID.Bytes).String(),
+			"restored":             restored,
+			"revoked_all_sessions": true,
+		},
+	})
+	return nil
+}
+
+// checkEmailFree returns ErrEmailTaken when another user in homeTenant has email.
+func checkEmailFree(ctx context.Context, q *db.Queries, homeTenant pgtype.UUID, email string) error {
+	_, err := q.GetUserByEmail(ctx, db.GetUserByEmailParams{Email: email, TenantID: homeTenant})
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	return nil
 }
 
 // GetUserContext returns the user profile and role within the current tenant.
@@ -105,23 +254,42 @@ func (s *AuthService) ListTenantMembers(ctx context.Context, tenantID uuid.UUID)
 
 // UpdateMemberRole updates the role of a user in a tenant.
 func (s *AuthService) UpdateMemberRole(ctx context.Context, tenantID uuid.UUID, userID uuid.UUID, role string) error {
-	return s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
+	err := s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
 		return q.UpdateMemberRole(ctx, db.UpdateMemberRoleParams{
 			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
 			UserID:   pgtype.UUID{Bytes: userID, Valid: true},
 			Role:     role,
 		})
 	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventMemberRoleChanged, audit.LogParams{
+		TargetID: userID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{"role": role},
+	})
+	return nil
 }
 
 // RemoveMember removes a user from a tenant.
 func (s *AuthService) RemoveMember(ctx context.Context, tenantID uuid.UUID, userID uuid.UUID) error {
-	return s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
+	err := s.WithRLS(ctx, tenantID, func(q *db.Queries) error {
 		return q.RemoveMember(ctx, db.RemoveMemberParams{
 			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
 			UserID:   pgtype.UUID{Bytes: userID, Valid: true},
 		})
 	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventMemberRemoved, audit.LogParams{
+		TargetID: userID,
+		TenantID: tenantID,
+	})
+	return nil
 }
 
 // UpdateProfile updates the user's personal information.
@@ -132,10 +300,19 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID uuid.UUID, fullN
 	})
 }
 
+// UpdateLocale sets the language of the user's emails; an empty locale falls
+// back to the tenant's default.
+func (s *AuthService) UpdateLocale(ctx context.Context, userID uuid.UUID, locale string) error {
+	return s.queries.UpdateUserLocale(ctx, db.UpdateUserLocaleParams{
+		ID:     pgtype.UUID{Bytes: userID, Valid: true},
+		Locale: pgtype.Text{String: locale, Valid: locale != ""},
+	})
+}
+
 // ChangePassword updates the user's password and revokes all active sessions.
 func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, tenantID uuid.UUID, oldPassword, newPassword string) error {
-	// 1. Verify Old Password
-	user, err := s.queries.GetUserByID(ctx, db.GetUserByIDParams{
+	// 1. Verify Old Password (the session tenant may be one the user joined)
+	user, err := s.txQueries(ctx).GetUserInTenant(ctx, db.GetUserInTenantParams{
 		ID:       pgtype.UUID{Bytes: userID, Valid: true},
 		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
 	})
@@ -168,12 +345,8 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, tena
 
 	// 4. Nuclear Option: Revoke ALL Sessions
 	// This forces re-login on all devices, including the current one (frontend handles this by redirecting).
-	// We need `RevokeAllSessions` in `sessions.sql`.
-	// Wait, I haven't checked if `RevokeAllSessions` exists in `db.Queries`.
-	// Let me check `sessions.sql` content or `db` interface.
-	// I recall `sessions.sql` having `RevokeAllSessions`.
 	// AUDIT LOG
-	s.audit.Log(ctx, "user.password_change", audit.LogParams{
+	s.audit.Log(ctx, audit.EventPasswordChanged, audit.LogParams{
 		ActorID:  userID,
 		TargetID: userID,
 		// TenantID: Inherited from context or omitted if global user action?
@@ -183,5 +356,13 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, tena
 		},
 	})
 
-	return s.queries.RevokeAllSessions(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err := s.queries.RevokeAllSessions(ctx, pgtype.UUID{Bytes: userID, Valid: true}); err != nil {
+		return err
+	}
+
+	// 5. Tell the owner, with a link to lock the account if it wasn't them
+	s.notifySecurityEvent(ctx, s.txQueries(ctx), user, func(notice notify.SecurityNotice) error {
+		return s.mail.SendPasswordChanged(ctx, user.Email, notice)
+	})
+	return nil
 }
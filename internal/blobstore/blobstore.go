@@ -0,0 +1,197 @@
+// Package blobstore keeps opaque objects (archives) under slash-separated keys.
+// FS stores them on the local filesystem; object stores can implement Store
+// the same way.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps objects by key. Put replaces an existing object atomically: a
+// reader sees the old or the new content, never a partial write.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is one entry of a listing.
+type Object struct {
+	Key     string
+	ModTime time.Time
+}
+
+// Lister is implemented by stores that can enumerate their objects. Sweeps for
+// objects whose database row is gone need it; other callers only need Store.
+type Lister interface {
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// probeKey is written and removed by Probe; listings skip it like temp files.
+const probeKey = ".probe"
+
+// Probe checks at startup that the store accepts, returns and deletes an
+// object, so a missing or read-only volume fails the deploy instead of the
+// first request that needs it.
+func Probe(ctx context.Context, s Store) error {
+	if _, err := s.Put(ctx, probeKey, strings.NewReader("ok")); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	rc, err := s.Open(ctx, probeKey)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if string(data) != "ok" {
+		return errors.New("read: probe object changed")
+	}
+	return s.Delete(ctx, probeKey)
+}
+
+// ValidKey reports whether key is a clean relative path: "a/b.gz", not "/a",
+// "a/../b" or "a//b".
+func ValidKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") && path.Clean(key) == key &&
+		key != "." && key != ".." && !strings.HasPrefix(key, "../") && !strings.ContainsRune(key, '\\')
+}
+
+// FS stores objects as files below a directory.
+type FS struct {
+	dir string
+}
+
+func NewFS(dir string) *FS {
+	return &FS{dir: dir}
+}
+
+func (s *FS) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the object under a temporary name, syncs it and renames it into
+// place. Archives hold personal data: owner-only permissions.
+func (s *FS) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	dst, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".blob-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	n, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *FS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Delete removes the object; a missing object is not an error.
+func (s *FS) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the objects whose key starts with prefix. Temporary files of
+// an unfinished Put are not listed; a missing directory is an empty store.
+func (s *FS) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, ModTime: info.ModTime()})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return objects, err
+}
+
+// contextReader stops a long copy once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
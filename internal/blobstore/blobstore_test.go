@@ -0,0 +1,95 @@
+package blobstore_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := blobstore.NewFS(dir)
+
+	n, err := store.Put(ctx, "audit-logs/a/2025-01.ndjson.gz", strings.NewReader("first"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	_, err = store.Put(ctx, "audit-logs/a/2025-01.ndjson.gz", strings.NewReader("second"))
+	require.NoError(t, err)
+
+	rc, err := store.Open(ctx, "audit-logs/a/2025-01.ndjson.gz")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Stat(filepath.Join(dir, "audit-logs", "a", "2025-01.ndjson.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, store.Delete(ctx, "audit-logs/a/2025-01.ndjson.gz"))
+	require.NoError(t, store.Delete(ctx, "audit-logs/a/2025-01.ndjson.gz"))
+	_, err = store.Open(ctx, "audit-logs/a/2025-01.ndjson.gz")
+	assert.ErrorIs(t, err, blobstore.ErrNotFound)
+}
+
+func TestFS_InvalidKey(t *testing.T) {
+	store := blobstore.NewFS(t.TempDir())
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b", `a\b`} {
+		_, err := store.Put(context.Background(), key, strings.NewReader("x"))
+		assert.ErrorIs(t, err, blobstore.ErrInvalidKey, key)
+	}
+}
+
+func TestFS_List(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := blobstore.NewFS(dir)
+
+	objects, err := blobstore.NewFS(filepath.Join(dir, "missing")).List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	for _, key := range []string{"a.zip", "exports/b.zip", "other/c.gz"} {
+		_, err := store.Put(ctx, key, strings.NewReader("x"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".blob-123"), []byte("partial"), 0o600))
+
+	objects, err = store.List(ctx, "")
+	require.NoError(t, err)
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+		assert.False(t, o.ModTime.IsZero())
+	}
+	assert.ElementsMatch(t, []string{"a.zip", "exports/b.zip", "other/c.gz"}, keys)
+
+	objects, err = store.List(ctx, "exports/")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "exports/b.zip", objects[0].Key)
+}
+
+func TestProbe(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, blobstore.Probe(context.Background(), blobstore.NewFS(dir)))
+
+	// Leaves nothing behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// A path below a regular file can never hold objects
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	assert.Error(t, blobstore.Probe(context.Background(), blobstore.NewFS(filepath.Join(file, "store"))))
+}
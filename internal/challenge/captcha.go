@@ -0,0 +1,89 @@
+package challenge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrCaptchaFailed = errors.New("captcha verification failed")
+
+// CaptchaVerifier validates a CAPTCHA response token with its provider.
+// Implementations must be safe for concurrent use.
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, response, remoteIP string) error
+	// Provider and SiteKey are advertised to clients so they can render the widget.
+	Provider() string
+	SiteKey() string
+}
+
+// SiteVerifyCaptcha speaks the "siteverify" protocol shared by hCaptcha,
+// Cloudflare Turnstile and reCAPTCHA: form POST of secret+response, JSON {success}.
+type SiteVerifyCaptcha struct {
+	ProviderName string
+	VerifyURL    string
+	Secret       string
+	Key          string
+	Client       *http.Client
+}
+
+// NewSiteVerifyCaptcha creates a verifier with a short HTTP timeout.
+func NewSiteVerifyCaptcha(provider, verifyURL, secret, siteKey string) *SiteVerifyCaptcha {
+	return &SiteVerifyCaptcha{
+		ProviderName: provider,
+		VerifyURL:    verifyURL,
+		Secret:       secret,
+		Key:          siteKey,
+		Client:       &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *SiteVerifyCaptcha) Provider() string { return c.ProviderName }
+func (c *SiteVerifyCaptcha) SiteKey() string  { return c.Key }
+
+// Verify implements CaptchaVerifier.
+func (c *SiteVerifyCaptcha) Verify(ctx context.Context, response, remoteIP string) error {
+	if response == "" {
+		return ErrCaptchaFailed
+	}
+
+	form := url.Values{
+		"secret":   {c.Secret},
+		"response": {response},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.VerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha provider returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("captcha response decode: %w", err)
+	}
+	if !result.Success {
+		return ErrCaptchaFailed
+	}
+	return nil
+}
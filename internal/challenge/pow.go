@@ -0,0 +1,172 @@
+// Package challenge implements the adaptive bot challenge for credential endpoints:
+// a stateless HMAC-signed proof-of-work puzzle and a pluggable CAPTCHA verifier.
+package challenge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+)
+
+var (
+	ErrInvalidToken    = errors.New("invalid challenge token")
+	ErrExpired         = errors.New("challenge expired")
+	ErrInvalidSolution = errors.New("invalid challenge solution")
+	ErrReplayed        = errors.New("challenge already used")
+)
+
+const (
+	// AlgorithmSHA256 is the only supported puzzle: find a nonce such that
+	// SHA256(token ":" nonce) starts with Difficulty zero bits.
+	AlgorithmSHA256 = "sha256"
+
+	MinDifficulty     = 8
+	MaxDifficulty     = 24
+	DefaultDifficulty = 18
+)
+
+// Puzzle is the machine-readable proof-of-work challenge sent to clients.
+type Puzzle struct {
+	Algorithm  string    `json:"algorithm"`
+	Token      string    `json:"token"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type puzzleClaims struct {
+	ID         string `json:"id"`
+	Scope      string `json:"scope"`
+	Subject    string `json:"sub"` // SHA256 of the client IP the puzzle was issued to
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// ProofOfWork issues and verifies stateless puzzles.
+// The token carries its own parameters and is HMAC-signed, so any replica holding
+// the same secret can verify it. Single use is enforced through the shared
+// rate limit store, which is the only state involved.
+type ProofOfWork struct {
+	secret []byte
+	ttl    time.Duration
+	used   ratelimit.Store
+	now    func() time.Time
+}
+
+// NewProofOfWork creates a puzzle issuer. used may be nil to skip replay protection.
+func NewProofOfWork(secret []byte, ttl time.Duration, used ratelimit.Store) *ProofOfWork {
+	return &ProofOfWork{secret: secret, ttl: ttl, used: used, now: time.Now}
+}
+
+// Issue creates a new puzzle bound to scope (endpoint) and subject (client IP).
+func (p *ProofOfWork) Issue(scope, subject string, difficulty int) (Puzzle, error) {
+	difficulty = ClampDifficulty(difficulty)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Puzzle{}, err
+	}
+
+	expiresAt := p.now().Add(p.ttl)
+	claims := puzzleClaims{
+		ID:         hex.EncodeToString(id),
+		Scope:      scope,
+		Subject:    ratelimit.HashSubject(subject),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt.Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return Puzzle{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return Puzzle{
+		Algorithm:  AlgorithmSHA256,
+		Token:      encoded + "." + p.sign(encoded),
+		Difficulty: difficulty,
+		ExpiresAt:  time.Unix(claims.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// Verify checks signature, binding, expiry, the work itself and single use.
+func (p *ProofOfWork) Verify(ctx context.Context, token, nonce, scope, subject string) error {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(p.sign(encoded))) {
+		return ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	var claims puzzleClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ErrInvalidToken
+	}
+
+	if claims.Scope != scope || claims.Subject != ratelimit.HashSubject(subject) {
+		return ErrInvalidToken
+	}
+	now := p.now()
+	if now.Unix() > claims.ExpiresAt {
+		return ErrExpired
+	}
+
+	if nonce == "" || len(nonce) > 64 || LeadingZeroBits(token, nonce) < claims.Difficulty {
+		return ErrInvalidSolution
+	}
+
+	if p.used != nil {
+		// One bucket per puzzle ID, anchored at its expiry so every replica
+		// resolves the same bucket; it outlives the token by one TTL.
+		count, err := p.used.Increment(ctx, "challenge.used:"+claims.ID, time.Unix(claims.ExpiresAt, 0), p.ttl)
+		if err != nil {
+			return err
+		}
+		if count > 1 {
+			return ErrReplayed
+		}
+	}
+
+	return nil
+}
+
+func (p *ProofOfWork) sign(encoded string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// LeadingZeroBits returns the number of leading zero bits of SHA256(token ":" nonce).
+func LeadingZeroBits(token, nonce string) int {
+	sum := sha256.Sum256([]byte(token + ":" + nonce))
+	n := 0
+	for _, b := range sum {
+		if b == 0 {
+			n += 8
+			continue
+		}
+		n += bits.LeadingZeros8(b)
+		break
+	}
+	return n
+}
+
+// ClampDifficulty keeps tenant-configured difficulty within sane bounds.
+func ClampDifficulty(d int) int {
+	if d == 0 {
+		return DefaultDifficulty
+	}
+	return max(MinDifficulty, min(d, MaxDifficulty))
+}
@@ -0,0 +1,78 @@
+package challenge_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/challenge"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// solve brute-forces a nonce (difficulty is kept low in tests).
+func solve(t *testing.T, p challenge.Puzzle) string {
+	t.Helper()
+	for i := 0; i < 1<<20; i++ {
+		nonce := strconv.Itoa(i)
+		if challenge.LeadingZeroBits(p.Token, nonce) >= p.Difficulty {
+			return nonce
+		}
+	}
+	t.Fatal("no solution found")
+	return ""
+}
+
+func TestProofOfWork_SolveAndVerify(t *testing.T) {
+	pow := challenge.NewProofOfWork([]byte("secret"), time.Minute, ratelimit.NewMemoryStore(0))
+	ctx := t.Context()
+
+	puzzle, err := pow.Issue(challenge.ScopeLogin, "203.0.113.7", challenge.MinDifficulty)
+	require.NoError(t, err)
+	nonce := solve(t, puzzle)
+
+	require.NoError(t, pow.Verify(ctx, puzzle.Token, nonce, challenge.ScopeLogin, "203.0.113.7"))
+
+	// Single use
+	assert.ErrorIs(t, pow.Verify(ctx, puzzle.Token, nonce, challenge.ScopeLogin, "203.0.113.7"), challenge.ErrReplayed)
+}
+
+func TestProofOfWork_RejectsTamperingAndRebinding(t *testing.T) {
+	pow := challenge.NewProofOfWork([]byte("secret"), time.Minute, nil)
+	ctx := t.Context()
+
+	puzzle, err := pow.Issue(challenge.ScopeLogin, "203.0.113.7", challenge.MinDifficulty)
+	require.NoError(t, err)
+	nonce := solve(t, puzzle)
+
+	// Other IP or other endpoint
+	assert.ErrorIs(t, pow.Verify(ctx, puzzle.Token, nonce, challenge.ScopeLogin, "198.51.100.1"), challenge.ErrInvalidToken)
+	assert.ErrorIs(t, pow.Verify(ctx, puzzle.Token, nonce, challenge.ScopeRegister, "203.0.113.7"), challenge.ErrInvalidToken)
+
+	// Signed with another key
+	other := challenge.NewProofOfWork([]byte("other"), time.Minute, nil)
+	assert.ErrorIs(t, other.Verify(ctx, puzzle.Token, nonce, challenge.ScopeLogin, "203.0.113.7"), challenge.ErrInvalidToken)
+
+	// No work done
+	assert.ErrorIs(t, pow.Verify(ctx, puzzle.Token, "", challenge.ScopeLogin, "203.0.113.7"), challenge.ErrInvalidSolution)
+}
+
+func TestFailureTracker_CountsPerSubject(t *testing.T) {
+	tracker := challenge.NewFailureTracker(ratelimit.NewMemoryStore(0), time.Minute)
+	ctx := t.Context()
+
+	for i := range 3 {
+		n, err := tracker.RecordFailure(ctx, ratelimit.KeyEmail, "victim@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, i+1, n)
+	}
+
+	n, err := tracker.Failures(ctx, ratelimit.KeyEmail, "victim@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = tracker.Failures(ctx, ratelimit.KeyIP, "victim@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "IP and email counters are separate")
+}
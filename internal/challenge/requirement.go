@@ -0,0 +1,31 @@
+package challenge
+
+// Request headers carrying a challenge answer. Headers (not body fields) keep
+// the strict JSON decoders of the auth handlers untouched.
+const (
+	HeaderToken           = "X-Challenge-Token"
+	HeaderSolution        = "X-Challenge-Solution"
+	HeaderCaptchaResponse = "X-Captcha-Response"
+)
+
+// Error codes returned in the machine-readable 428 response.
+const (
+	CodeRequired = "challenge_required"
+	CodeFailed   = "challenge_failed"
+)
+
+// Requirement describes what the client must solve before retrying.
+type Requirement struct {
+	Type     string  `json:"type"` // "pow" or "captcha"
+	Scope    string  `json:"scope"`
+	PoW      *Puzzle `json:"pow,omitempty"`
+	Provider string  `json:"provider,omitempty"`
+	SiteKey  string  `json:"site_key,omitempty"`
+}
+
+// Response is the body of a 428 Precondition Required answer.
+type Response struct {
+	Error     string      `json:"error"`
+	Message   string      `json:"message"`
+	Challenge Requirement `json:"challenge"`
+}
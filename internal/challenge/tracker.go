@@ -0,0 +1,59 @@
+package challenge
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/ratelimit"
+)
+
+// Scopes protected by the challenge gate. A puzzle issued for one scope
+// cannot be spent on another.
+const (
+	ScopeLogin          = "auth.login"
+	ScopeRegister       = "auth.register"
+	ScopePasswordForgot = "auth.password.forgot"
+)
+
+// CountsEveryAttempt reports whether every request in scope counts towards the
+// threshold. Password-forgot always answers 200 (no enumeration), so there is
+// no failure status to observe; each attempt counts instead.
+func CountsEveryAttempt(scope string) bool {
+	return scope == ScopePasswordForgot
+}
+
+// FailureTracker counts failed auth attempts per IP and per email in the
+// shared rate limit store, so thresholds hold across replicas.
+type FailureTracker struct {
+	store  ratelimit.Store
+	window time.Duration
+	now    func() time.Time
+}
+
+func NewFailureTracker(store ratelimit.Store, window time.Duration) *FailureTracker {
+	return &FailureTracker{store: store, window: window, now: time.Now}
+}
+
+// RecordFailure adds one failure for subject (an IP or a normalised email)
+// and returns the failure count in the current window.
+func (t *FailureTracker) RecordFailure(ctx context.Context, key ratelimit.KeyType, subject string) (int, error) {
+	return t.store.Increment(ctx, t.bucketKey(key, subject), t.windowStart(), t.window)
+}
+
+// Failures returns the failure count for subject in the current window.
+func (t *FailureTracker) Failures(ctx context.Context, key ratelimit.KeyType, subject string) (int, error) {
+	return t.store.Count(ctx, t.bucketKey(key, subject), t.windowStart())
+}
+
+// ResetAt is when the current window ends and the counts start over.
+func (t *FailureTracker) ResetAt() time.Time {
+	return t.windowStart().Add(t.window)
+}
+
+func (t *FailureTracker) bucketKey(key ratelimit.KeyType, subject string) string {
+	return "challenge.failures:" + string(key) + ":" + ratelimit.HashSubject(subject)
+}
+
+func (t *FailureTracker) windowStart() time.Time {
+	return t.now().Truncate(t.window)
+}
@@ -3,24 +3,51 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config holds all application configuration.
 type Config struct {
-	AllowPublicRegistration bool
-	DatabaseURL             string
-	ConvexWebhookURL        string // URL to Convex gatekeeper endpoint
-	ConvexDeployKey         string // Deploy key for authentication
+	AllowPublicRegistration   bool
+	DatabaseURL               string
+	ConvexWebhookURL          string        // URL to Convex gatekeeper endpoint
+	ConvexDeployKey           string        // Deploy key for authentication
+	TenantDeletionGrace       time.Duration // Suspension period before an offboarding purge
+	OffboardingExportDir      string        // Where the worker writes tenant exports
+	DataExportDir             string        // Personal data archives; shared by worker and API
+	AccountDeletionCoolingOff time.Duration // DELETE /auth/account until the purge
+	AuditQueueSize            int           // Events buffered by the async audit writer
+	AuditBatchSize            int           // Events per COPY
+	AuditBackpressure         string        // Full queue: block, spool or drop
+	AuditSpoolPath            string        // Local fallback while the database is unreachable
+	AuditStdout               bool          // Also write audit events as JSON lines to stdout
+	AuditRetentionMinDays     int           // Compliance floor; tenants cannot choose less
+	AuditRetentionDefaultDays int           // Tenants without a retention choice, and platform events
+	AuditArchiveDir           string        // Blob store of archived audit log months
+	EmailLogRetentionDays     int           // email_logs rows older than this are deleted by the worker
 	// Add other app-level configs here
 }
 
 // Load reads configuration from environment variables.
 func Load() Config {
 	return Config{
-		AllowPublicRegistration: getEnvAsBool("ALLOW_PUBLIC_REGISTRATION", false),
-		DatabaseURL:             os.Getenv("DATABASE_URL"),
-		ConvexWebhookURL:        os.Getenv("CONVEX_WEBHOOK_URL"),
-		ConvexDeployKey:         os.Getenv("CONVEX_DEPLOY_KEY"),
+		AllowPublicRegistration:   getEnvAsBool("ALLOW_PUBLIC_REGISTRATION", false),
+		DatabaseURL:               os.Getenv("DATABASE_URL"),
+		ConvexWebhookURL:          os.Getenv("CONVEX_WEBHOOK_URL"),
+		ConvexDeployKey:           os.Getenv("CONVEX_DEPLOY_KEY"),
+		TenantDeletionGrace:       time.Duration(getEnvAsInt("TENANT_DELETION_GRACE_DAYS", 30)) * 24 * time.Hour,
+		OffboardingExportDir:      getEnvOrDefault("OFFBOARDING_EXPORT_DIR", "./data/offboarding"),
+		DataExportDir:             getEnvOrDefault("DATA_EXPORT_DIR", "./data/exports"),
+		AccountDeletionCoolingOff: time.Duration(getEnvAsInt("ACCOUNT_DELETION_COOLING_OFF_DAYS", 14)) * 24 * time.Hour,
+		AuditQueueSize:            getEnvAsInt("AUDIT_QUEUE_SIZE", 4096),
+		AuditBatchSize:            getEnvAsInt("AUDIT_BATCH_SIZE", 500),
+		AuditBackpressure:         getEnvOrDefault("AUDIT_BACKPRESSURE", "block"),
+		AuditSpoolPath:            getEnvOrDefault("AUDIT_SPOOL_PATH", "./data/audit/spool.ndjson"),
+		AuditStdout:               getEnvAsBool("AUDIT_STDOUT", true),
+		AuditRetentionMinDays:     getEnvAsInt("AUDIT_RETENTION_MIN_DAYS", 365),
+		AuditRetentionDefaultDays: getEnvAsInt("AUDIT_RETENTION_DEFAULT_DAYS", 730),
+		AuditArchiveDir:           getEnvOrDefault("AUDIT_ARCHIVE_DIR", "./data/audit-archive"),
+		EmailLogRetentionDays:     getEnvAsInt("EMAIL_LOG_RETENTION_DAYS", 90),
 	}
 }
 
@@ -36,3 +63,19 @@ func getEnvAsBool(name string, defaultVal bool) bool {
 	}
 	return val
 }
+
+// Helper to read integer env vars
+func getEnvAsInt(name string, defaultVal int) int {
+	val, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return defaultVal
+	}
+	return val
+}
+
+func getEnvOrDefault(name, defaultVal string) string {
+	if val := os.Getenv(name); val != "" {
+		return val
+	}
+	return defaultVal
+}
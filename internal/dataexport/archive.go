@@ -0,0 +1,104 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/google/uuid"
+)
+
+// Format identifies the archive layout (manifest.json "format").
+const Format = "laventecare.user-export.v1"
+
+// Manifest describes the archive. Every section of the collected data is a
+// separate JSON file next to it.
+type Manifest struct {
+	Format      string    `json:"format"`
+	ExportID    uuid.UUID `json:"export_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// archiveKey is where the archive of an export lives in the blob store;
+// derived from the ID only.
+func archiveKey(exportID uuid.UUID) string {
+	return exportID.String() + ".zip"
+}
+
+// WriteArchive stores data (a JSON object of sections) as a ZIP archive and
+// returns its key, SHA-256 and size. The archive is streamed into the store,
+// which only makes it visible once complete.
+func WriteArchive(ctx context.Context, store blobstore.Store, manifest Manifest, data []byte) (string, string, int64, error) {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return "", "", 0, err
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	manifest.Format = Format
+	manifest.Files = nil
+	for _, name := range names {
+		manifest.Files = append(manifest.Files, name+".json")
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		zw := zip.NewWriter(io.MultiWriter(pw, hash, counter))
+		err := writeJSON(zw, "manifest.json", manifest)
+		for _, name := range names {
+			if err != nil {
+				break
+			}
+			err = writeJSON(zw, name+".json", sections[name])
+		}
+		if err == nil {
+			err = zw.Close()
+		}
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	key := archiveKey(manifest.ExportID)
+	_, putErr := store.Put(ctx, key, pr)
+	pr.CloseWithError(putErr) // Unblocks the writer when Put gave up early
+	if err := <-done; err != nil {
+		return "", "", 0, err
+	}
+	if putErr != nil {
+		return "", "", 0, putErr
+	}
+	return key, hex.EncodeToString(hash.Sum(nil)), counter.n, nil
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+type countingWriter struct{ n int64 }
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,95 @@
+package dataexport_test
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/dataexport"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseLink(t *testing.T, link string) (string, string) {
+	t.Helper()
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestSigner(t *testing.T) {
+	signer := dataexport.NewSigner([]byte("test-secret"))
+	exportID := uuid.New()
+
+	link := signer.URL(exportID, time.Now().Add(dataexport.LinkTTL))
+	assert.True(t, strings.HasPrefix(link, "/api/v1/exports/"+exportID.String()+"/download?"))
+	expires, sig := parseLink(t, link)
+	assert.NoError(t, signer.Verify(exportID, expires, sig))
+
+	// Bound to the export ID, the expiry and the secret
+	assert.ErrorIs(t, signer.Verify(uuid.New(), expires, sig), dataexport.ErrInvalidLink)
+	assert.ErrorIs(t, signer.Verify(exportID, expires+"0", sig), dataexport.ErrInvalidLink)
+	assert.ErrorIs(t, dataexport.NewSigner([]byte("other")).Verify(exportID, expires, sig), dataexport.ErrInvalidLink)
+	assert.ErrorIs(t, signer.Verify(exportID, "soon", sig), dataexport.ErrInvalidLink)
+
+	expires, sig = parseLink(t, signer.URL(exportID, time.Now().Add(-time.Minute)))
+	assert.ErrorIs(t, signer.Verify(exportID, expires, sig), dataexport.ErrLinkExpired)
+}
+
+func TestWriteArchive(t *testing.T) {
+	dir := t.TempDir()
+	manifest := dataexport.Manifest{ExportID: uuid.New(), UserID: uuid.New(), TenantID: uuid.New(), GeneratedAt: time.Now().UTC()}
+	data := []byte(`{"profile":{"email":"jan@example.com"},"sessions":[],"linked_identities":[]}`)
+
+	key, sum, size, err := dataexport.WriteArchive(context.Background(), blobstore.NewFS(dir), manifest, data)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.ExportID.String()+".zip", key)
+	path := filepath.Join(dir, key)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	digest := sha256.Sum256(raw)
+	assert.Equal(t, hex.EncodeToString(digest[:]), sum)
+	assert.Equal(t, int64(len(raw)), size)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	zr, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+	}
+	require.Contains(t, files, "manifest.json")
+
+	var got dataexport.Manifest
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &got))
+	assert.Equal(t, dataexport.Format, got.Format)
+	assert.Equal(t, manifest.ExportID, got.ExportID)
+	assert.Equal(t, []string{"linked_identities.json", "profile.json", "sessions.json"}, got.Files)
+	assert.JSONEq(t, `{"email":"jan@example.com"}`, string(files["profile.json"]))
+
+	// Only the archive remains: no temp files left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,174 @@
+// Package dataexport implements personal data exports (GDPR Art. 15 right of
+// access, Art. 20 portability).
+//
+// The API queues a request (Service.Request), the worker builds a ZIP archive
+// (Runner) in a blob store shared with the API, and the archive is downloaded
+// through a signed link that expires after LinkTTL. Exports are tenant scoped:
+// a tenant only ever exports what it holds about its own member.
+package dataexport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Export statuses (data_exports.status).
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusReady      = "ready"
+	StatusFailed     = "failed"
+	StatusExpired    = "expired"
+)
+
+const (
+	// LinkTTL is the lifetime of a signed download link.
+	LinkTTL = 15 * time.Minute
+	// Retention is how long a finished archive stays downloadable.
+	Retention = 7 * 24 * time.Hour
+)
+
+var (
+	ErrNotAMember        = errors.New("user is not a member of the tenant")
+	ErrExportInProgress  = errors.New("an export is already in progress")
+	ErrInvalidLink       = errors.New("invalid download link")
+	ErrLinkExpired       = errors.New("download link expired")
+	ErrExportUnavailable = errors.New("export is not available")
+)
+
+// Service handles export requests and downloads for the API.
+type Service struct {
+	pool   *pgxpool.Pool
+	audit  *audit.AuditService
+	signer *Signer
+	store  blobstore.Store
+}
+
+func NewService(pool *pgxpool.Pool, audit *audit.AuditService, signer *Signer, store blobstore.Store) *Service {
+	return &Service{pool: pool, audit: audit, signer: signer, store: store}
+}
+
+// Request queues an export of userID's data. requestedBy is the member itself
+// or a tenant admin acting on their behalf.
+func (s *Service) Request(ctx context.Context, tenantID, userID, requestedBy uuid.UUID) (db.DataExport, error) {
+	var export db.DataExport
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+		uid := pgtype.UUID{Bytes: userID, Valid: true}
+
+		if _, err := q.GetMembership(ctx, db.GetMembershipParams{UserID: uid, TenantID: tid}); errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotAMember
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		export, err = q.CreateDataExport(ctx, db.CreateDataExportParams{
+			TenantID:    tid,
+			UserID:      uid,
+			RequestedBy: pgtype.UUID{Bytes: requestedBy, Valid: true},
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrExportInProgress
+		}
+		return err
+	})
+	if err != nil {
+		return db.DataExport{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventDataExportRequested, audit.LogParams{
+		ActorID:  requestedBy,
+		TargetID: userID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"export_id": uuid.UUID(export.ID.Bytes).String(),
+			"on_behalf": requestedBy != userID,
+		},
+	})
+	return export, nil
+}
+
+// List returns the most recent exports of a member.
+func (s *Service) List(ctx context.Context, tenantID, userID uuid.UUID) ([]db.DataExport, error) {
+	var exports []db.DataExport
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		exports, err = q.ListDataExportsByUser(ctx, db.ListDataExportsByUserParams{
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+			UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+		})
+		return err
+	})
+	return exports, err
+}
+
+// DownloadURL returns a fresh signed link for a ready export, or "" when there is
+// nothing to download. Links never outlive the archive.
+func (s *Service) DownloadURL(export db.DataExport) string {
+	if export.Status != StatusReady || !export.ExpiresAt.Valid {
+		return ""
+	}
+	expires := time.Now().Add(LinkTTL)
+	if export.ExpiresAt.Time.Before(expires) {
+		expires = export.ExpiresAt.Time
+	}
+	if !expires.After(time.Now()) {
+		return ""
+	}
+	return s.signer.URL(export.ID.Bytes, expires)
+}
+
+// Open verifies a signed link and opens the archive. The caller closes the reader.
+// The link is the credential, so there is no session or tenant context here.
+func (s *Service) Open(ctx context.Context, exportID uuid.UUID, expires, signature string) (io.ReadCloser, db.DataExport, error) {
+	if err := s.signer.Verify(exportID, expires, signature); err != nil {
+		return nil, db.DataExport{}, err
+	}
+
+	var export db.DataExport
+	err := storage.WithoutRLS(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		export, err = db.New(tx).GetDataExport(ctx, pgtype.UUID{Bytes: exportID, Valid: true})
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, db.DataExport{}, ErrExportUnavailable
+	}
+	if err != nil {
+		return nil, db.DataExport{}, err
+	}
+	if export.Status != StatusReady || !export.FilePath.Valid || time.Now().After(export.ExpiresAt.Time) {
+		return nil, db.DataExport{}, ErrExportUnavailable
+	}
+
+	f, err := s.store.Open(ctx, archiveKey(exportID))
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, db.DataExport{}, ErrExportUnavailable
+	}
+	if err != nil {
+		return nil, db.DataExport{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventDataExportDownloaded, audit.LogParams{
+		TargetID: export.UserID.Bytes,
+		TenantID: export.TenantID.Bytes,
+		Metadata: map[string]interface{}{
+			"export_id": exportID.String(),
+		},
+	})
+	return f, export, nil
+}
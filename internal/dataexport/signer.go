@@ -0,0 +1,58 @@
+package dataexport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DownloadPath is the public route serving archives. The signature is the only credential.
+const DownloadPath = "/api/v1/exports/%s/download"
+
+// Signer creates and checks download links. Links are stateless HMAC signatures
+// over the export ID and expiry, so every API replica must share the secret.
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret, now: time.Now}
+}
+
+// URL returns the relative download link for an export, valid until expires.
+func (s *Signer) URL(exportID uuid.UUID, expires time.Time) string {
+	exp := expires.Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(exp, 10))
+	q.Set("signature", s.sign(exportID, exp))
+	return fmt.Sprintf(DownloadPath, exportID) + "?" + q.Encode()
+}
+
+// Verify checks a link's signature and expiry.
+func (s *Signer) Verify(exportID uuid.UUID, expires, signature string) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidLink
+	}
+	// Signature first: an attacker learns nothing about expiry handling
+	if !hmac.Equal([]byte(signature), []byte(s.sign(exportID, exp))) {
+		return ErrInvalidLink
+	}
+	if s.now().Unix() > exp {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+func (s *Signer) sign(exportID uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s:%d", exportID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,163 @@
+package dataexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/blobstore"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Runner builds queued exports. Run it from cmd/worker; store must be the
+// same blob store the API serves downloads from.
+type Runner struct {
+	pool   *pgxpool.Pool
+	audit  *audit.AuditService
+	store  blobstore.Store
+	logger *slog.Logger
+}
+
+func NewRunner(pool *pgxpool.Pool, audit *audit.AuditService, store blobstore.Store, logger *slog.Logger) *Runner {
+	return &Runner{pool: pool, audit: audit, store: store, logger: logger}
+}
+
+// RunPending builds exports until the queue is empty. A failed export goes back
+// to the queue (FailDataExport) until its third attempt.
+func (r *Runner) RunPending(ctx context.Context) (int, error) {
+	built := 0
+	for {
+		var export db.DataExport
+		err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			var err error
+			export, err = db.New(tx).ClaimDataExport(ctx)
+			return err
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return built, nil
+		}
+		if err != nil {
+			return built, err
+		}
+
+		if err := r.build(ctx, export); err != nil {
+			r.logger.Error("DataExport: Build failed", "export_id", uuid.UUID(export.ID.Bytes), "attempt", export.Attempts, "error", err)
+			failErr := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+				return db.New(tx).FailDataExport(ctx, db.FailDataExportParams{
+					ID:        export.ID,
+					LastError: pgtype.Text{String: err.Error(), Valid: true},
+				})
+			})
+			if failErr != nil {
+				return built, failErr
+			}
+			if export.Attempts >= 3 {
+				continue // Now 'failed': move on to the rest of the queue
+			}
+			return built, nil // Back to 'pending': retry on the next tick, not in a hot loop
+		}
+		built++
+	}
+}
+
+func (r *Runner) build(ctx context.Context, export db.DataExport) error {
+	var data []byte
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		data, err = db.New(tx).CollectUserData(ctx, db.CollectUserDataParams{
+			ID:       export.UserID,
+			TenantID: export.TenantID,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("collect: %w", err)
+	}
+
+	key, sum, size, err := WriteArchive(ctx, r.store, Manifest{
+		ExportID:    export.ID.Bytes,
+		UserID:      export.UserID.Bytes,
+		TenantID:    export.TenantID.Bytes,
+		GeneratedAt: time.Now().UTC(),
+	}, data)
+	if err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+
+	err = storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		return db.New(tx).CompleteDataExport(ctx, db.CompleteDataExportParams{
+			ID:        export.ID,
+			FilePath:  pgtype.Text{String: key, Valid: true},
+			Sha256:    pgtype.Text{String: sum, Valid: true},
+			SizeBytes: pgtype.Int8{Int64: size, Valid: true},
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(Retention), Valid: true},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.audit.Log(ctx, audit.EventDataExportReady, audit.LogParams{
+		TargetID: export.UserID.Bytes,
+		TenantID: export.TenantID.Bytes,
+		Metadata: map[string]interface{}{
+			"export_id":  uuid.UUID(export.ID.Bytes).String(),
+			"sha256":     sum,
+			"size_bytes": size,
+		},
+	})
+	return nil
+}
+
+// CleanExpired marks archives past their download window as expired and removes
+// them from the store. Archives without a live row (member or tenant deleted,
+// rows gone by cascade) are removed once they are older than Retention, if the
+// store can list its objects.
+func (r *Runner) CleanExpired(ctx context.Context) (int, error) {
+	var expired []pgtype.UUID
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		expired, err = db.New(tx).ExpireDataExports(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, id := range expired {
+		if err := r.store.Delete(ctx, archiveKey(id.Bytes)); err != nil {
+			r.logger.Error("DataExport: Failed to remove archive", "export_id", uuid.UUID(id.Bytes), "error", err)
+			continue
+		}
+		removed++
+	}
+
+	lister, ok := r.store.(blobstore.Lister)
+	if !ok {
+		return removed, nil
+	}
+	objects, err := lister.List(ctx, "")
+	if err != nil {
+		return removed, err
+	}
+	cutoff := time.Now().Add(-Retention)
+	for _, o := range objects {
+		if !strings.HasSuffix(o.Key, ".zip") || o.ModTime.After(cutoff) {
+			continue
+		}
+		if err := r.store.Delete(ctx, o.Key); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -23,7 +23,113 @@ func (tb *TenantBranding) Scan(src interface{}) error {
 }
 
 type TenantSettings struct {
-	AllowRegistration bool `json:"allow_registration"`
+	AllowRegistration bool              `json:"allow_registration"`
+	Challenge         ChallengeSettings `json:"challenge"`
+	CORS              CORSSettings      `json:"cors"`
+	// EmailVerification is the login policy for unverified addresses (EmailVerification* consts).
+	EmailVerification string `json:"email_verification,omitempty"`
+	// DefaultLocale is the language of emails to members who chose none (nl or en; empty means nl).
+	DefaultLocale string `json:"default_locale,omitempty"`
+	// InvitationExpiryDays is how long invite links stay valid (0 means the default of 7 days).
+	InvitationExpiryDays int `json:"invitation_expiry_days,omitempty"`
+	// Signup holds the self-service signup rules; AllowRegistration mirrors whether signup is open at all.
+	Signup SignupSettings `json:"signup"`
+}
+
+// Self-service signup modes. An empty mode follows AllowRegistration, as before
+// signup rules existed (see TenantSettings.SignupRules).
+const (
+	SignupModeOpen     = "open"     // Anyone may create an account
+	SignupModeApproval = "approval" // Signups wait in the admin approval queue
+	SignupModeClosed   = "closed"   // Invitations and verified auto-join domains only
+)
+
+// DefaultSignupRole is given to self-service members when the tenant sets no role.
+const DefaultSignupRole = "user"
+
+// SignupSettings are the tenant's self-service signup rules. Blocked domains
+// are refused first; addresses on a verified auto-join domain (table
+// tenant_email_domains) are admitted whatever the mode.
+type SignupSettings struct {
+	Mode           string   `json:"mode,omitempty"`
+	AllowedDomains []string `json:"allowed_domains,omitempty"` // Non-empty: other domains are refused
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+	DefaultRole    string   `json:"default_role,omitempty"`
+}
+
+// SignupRules returns the tenant's signup rules. Tenants saved before signup
+// rules existed have no mode: allow_registration false makes them "closed".
+func (s TenantSettings) SignupRules() SignupSettings {
+	rules := s.Signup
+	if rules.Mode == "" && !s.AllowRegistration {
+		rules.Mode = SignupModeClosed
+	}
+	return rules
+}
+
+// EffectiveMode returns the signup mode; unset or unknown values mean "open".
+// Read the rules through TenantSettings.SignupRules so legacy tenants stay closed.
+func (s SignupSettings) EffectiveMode() string {
+	switch s.Mode {
+	case SignupModeApproval, SignupModeClosed:
+		return s.Mode
+	default:
+		return SignupModeOpen
+	}
+}
+
+// Role returns the role self-service members receive.
+func (s SignupSettings) Role() string {
+	if s.DefaultRole == "" {
+		return DefaultSignupRole
+	}
+	return s.DefaultRole
+}
+
+// Email verification policies. An empty value means "off".
+const (
+	EmailVerificationOff     = "off"
+	EmailVerificationWarn    = "warn"    // Login allowed; tokens and /auth/me expose email_verified
+	EmailVerificationEnforce = "enforce" // Login refused until the address is verified
+)
+
+// CORSSettings holds the per-tenant preflight policy. The allowed origins
+// themselves live in tenants.allowed_origins; empty fields fall back to the
+// system defaults of DynamicCorsMiddleware.
+type CORSSettings struct {
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+	AllowedMethods []string `json:"allowed_methods,omitempty"`
+	MaxAge         int      `json:"max_age,omitempty"` // Seconds browsers may cache a preflight
+}
+
+// Challenge modes for credential endpoints (login, register, password forgot)
+const (
+	ChallengeModeOff     = "off"
+	ChallengeModePoW     = "pow"
+	ChallengeModeCaptcha = "captcha"
+)
+
+// ChallengeSettings configureert de adaptieve bot-challenge per tenant.
+// Een lege Mode betekent "off" (bestaande tenants hebben dit veld nog niet).
+type ChallengeSettings struct {
+	Mode       string `json:"mode,omitempty"`
+	Threshold  int    `json:"threshold,omitempty"`  // Failures per IP/email before a challenge is demanded
+	Difficulty int    `json:"difficulty,omitempty"` // Proof-of-work leading zero bits
+}
+
+// Enabled reports whether the tenant demands challenges at all.
+func (c ChallengeSettings) Enabled() bool {
+	return c.Mode == ChallengeModePoW || c.Mode == ChallengeModeCaptcha
+}
+
+// EmailVerificationPolicy returns the effective policy; unset or unknown values mean "off".
+func (ts TenantSettings) EmailVerificationPolicy() string {
+	switch ts.EmailVerification {
+	case EmailVerificationWarn, EmailVerificationEnforce:
+		return ts.EmailVerification
+	default:
+		return EmailVerificationOff
+	}
 }
 
 func (ts *TenantSettings) Scan(src interface{}) error {
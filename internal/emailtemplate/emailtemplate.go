@@ -0,0 +1,339 @@
+// Package emailtemplate renders transactional emails.
+//
+// Every mailer.EmailTemplate has a default per locale, embedded in the binary
+// (templates/<locale>/<template>/): a subject and a plain-text body
+// (text/template), and an HTML body (html/template) that is rendered inside
+// the branded layout (templates/layout.html). Tenants can override each of the
+// three parts per locale (email_template_overrides). The layout itself is not
+// editable, so every email shows the tenant's name, logo and colour the same way.
+//
+// The locale follows the recipient (users.locale), then the tenant's
+// default_locale setting, then Dutch.
+package emailtemplate
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	_ "time/tzdata" // Europe/Amsterdam on hosts without zoneinfo
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+)
+
+// Supported locales.
+const (
+	LocaleDutch   = "nl"
+	LocaleEnglish = "en"
+	DefaultLocale = LocaleDutch
+)
+
+// Locales lists the supported locales, default first.
+var Locales = []string{LocaleDutch, LocaleEnglish}
+
+const (
+	// MaxSubjectLength bounds an overridden subject template.
+	MaxSubjectLength = 200
+	// MaxBodyLength bounds an overridden text or HTML body template.
+	MaxBodyLength = 64 << 10
+	// DefaultPrimaryColor is used when the tenant's branding has none or an invalid one.
+	DefaultPrimaryColor = "#2563eb"
+)
+
+var (
+	ErrUnknownTemplate  = errors.New("unknown email template")
+	ErrUnknownLocale    = errors.New("unsupported locale")
+	ErrInvalidTemplate  = errors.New("invalid email template")
+	ErrOverrideNotFound = errors.New("email template override not found")
+)
+
+//go:embed templates
+var files embed.FS
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{3}([0-9a-fA-F]{3})?$`)
+
+// timeZone is where dates in emails are shown; tenants are Dutch organisations.
+var timeZone = mustLoadLocation("Europe/Amsterdam")
+
+// ValidLocale reports whether locale is supported.
+func ValidLocale(locale string) bool {
+	return slices.Contains(Locales, locale)
+}
+
+// Templates returns every email template, sorted by name.
+func Templates() []mailer.EmailTemplate {
+	out := make([]mailer.EmailTemplate, 0, len(mailer.ValidTemplates))
+	for t := range mailer.ValidTemplates {
+		out = append(out, t)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// TenantLocale returns the tenant's default locale.
+func TenantLocale(tenant db.Tenant) string {
+	if ValidLocale(tenant.Settings.DefaultLocale) {
+		return tenant.Settings.DefaultLocale
+	}
+	return DefaultLocale
+}
+
+// Source holds the three editable parts of a template.
+type Source struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Override replaces parts of a Source; nil parts are left as they are.
+type Override struct {
+	Subject *string `json:"subject,omitempty"`
+	Text    *string `json:"text,omitempty"`
+	HTML    *string `json:"html,omitempty"`
+}
+
+// Apply returns src with the override's parts in place.
+func (o Override) Apply(src Source) Source {
+	if o.Subject != nil {
+		src.Subject = *o.Subject
+	}
+	if o.Text != nil {
+		src.Text = *o.Text
+	}
+	if o.HTML != nil {
+		src.HTML = *o.HTML
+	}
+	return src
+}
+
+// Parts returns the names of the parts the override sets.
+func (o Override) Parts() []string {
+	var parts []string
+	if o.Subject != nil {
+		parts = append(parts, "subject")
+	}
+	if o.Text != nil {
+		parts = append(parts, "text")
+	}
+	if o.HTML != nil {
+		parts = append(parts, "html")
+	}
+	return parts
+}
+
+func overrideOf(row db.EmailTemplateOverride) Override {
+	var o Override
+	if row.Subject.Valid {
+		o.Subject = &row.Subject.String
+	}
+	if row.TextBody.Valid {
+		o.Text = &row.TextBody.String
+	}
+	if row.HtmlBody.Valid {
+		o.HTML = &row.HtmlBody.String
+	}
+	return o
+}
+
+// Default returns the embedded source of a template in a locale.
+func Default(template mailer.EmailTemplate, locale string) (Source, error) {
+	if !mailer.ValidTemplates[template] {
+		return Source{}, ErrUnknownTemplate
+	}
+	if !ValidLocale(locale) {
+		return Source{}, ErrUnknownLocale
+	}
+	dir := path.Join("templates", locale, string(template))
+	var src Source
+	for name, part := range map[string]*string{"subject.tmpl": &src.Subject, "text.tmpl": &src.Text, "html.tmpl": &src.HTML} {
+		b, err := files.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return Source{}, fmt.Errorf("read default %s: %w", path.Join(dir, name), err)
+		}
+		*part = string(b)
+	}
+	src.Subject = strings.TrimSpace(src.Subject)
+	return src, nil
+}
+
+// Brand is the tenant as shown in emails.
+type Brand struct {
+	Name         string
+	LogoURL      string // https only; empty hides the logo
+	PrimaryColor string // #rgb or #rrggbb
+	AppURL       string
+}
+
+// NewBrand takes the tenant's name and branding, dropping a logo that is not
+// served over https and a colour that is not a hex code.
+func NewBrand(tenant db.Tenant) Brand {
+	b := Brand{Name: tenant.Name, PrimaryColor: DefaultPrimaryColor, AppURL: tenant.AppUrl}
+	if logo := tenant.Branding.LogoURL; logo != nil && strings.HasPrefix(*logo, "https://") {
+		b.LogoURL = *logo
+	}
+	if colorPattern.MatchString(tenant.Branding.PrimaryColor) {
+		b.PrimaryColor = tenant.Branding.PrimaryColor
+	}
+	return b
+}
+
+// Data is what templates see: {{.Data.link}}, {{.Tenant.Name}}, {{.Locale}}.
+type Data struct {
+	Data    map[string]any
+	Tenant  Brand
+	Locale  string
+	Subject string // Rendered subject, for the layout's <title>
+}
+
+// Render renders src for one email. Keys of the template's sample data that
+// the payload lacks render empty, so an older payload in the outbox still goes out.
+func Render(template mailer.EmailTemplate, src Source, data Data) (mailer.Message, error) {
+	values := make(map[string]any, len(data.Data))
+	for k := range Sample(template) {
+		values[k] = ""
+	}
+	for k, v := range data.Data {
+		values[k] = v
+	}
+	data.Data = values
+	return render(src, data, "missingkey=default")
+}
+
+// Validate checks that src parses and renders with the sample data of the
+// template, and that overridden parts stay within the size limits. Unknown
+// data keys are errors here, which catches typos in tenant overrides.
+func Validate(template mailer.EmailTemplate, locale string, src Source, brand Brand) (mailer.Message, error) {
+	if len(src.Subject) > MaxSubjectLength {
+		return mailer.Message{}, fmt.Errorf("%w: subject is longer than %d bytes", ErrInvalidTemplate, MaxSubjectLength)
+	}
+	if len(src.Text) > MaxBodyLength || len(src.HTML) > MaxBodyLength {
+		return mailer.Message{}, fmt.Errorf("%w: body is longer than %d bytes", ErrInvalidTemplate, MaxBodyLength)
+	}
+	if strings.TrimSpace(src.Subject) == "" || strings.TrimSpace(src.Text) == "" || strings.TrimSpace(src.HTML) == "" {
+		return mailer.Message{}, fmt.Errorf("%w: subject, text and html must not be empty", ErrInvalidTemplate)
+	}
+	msg, err := render(src, Data{Data: Sample(template), Tenant: brand, Locale: locale}, "missingkey=error")
+	if err != nil {
+		return mailer.Message{}, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return msg, nil
+}
+
+func render(src Source, data Data, missingKey string) (mailer.Message, error) {
+	funcs := funcMap(data.Locale)
+
+	subject, err := texttemplate.New("subject").Funcs(funcs).Option(missingKey).Parse(src.Subject)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	var buf bytes.Buffer
+	if err := subject.Execute(&buf, data); err != nil {
+		return mailer.Message{}, err
+	}
+	// One line: a subject becomes a header
+	data.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	text, err := texttemplate.New("text").Funcs(funcs).Option(missingKey).Parse(src.Text)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	buf.Reset()
+	if err := text.Execute(&buf, data); err != nil {
+		return mailer.Message{}, err
+	}
+	textBody := buf.String()
+
+	html, err := htmltemplate.New("layout.html").Funcs(funcs).Option(missingKey).ParseFS(files, "templates/layout.html")
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	if _, err := html.New("content").Parse(src.HTML); err != nil {
+		return mailer.Message{}, err
+	}
+	buf.Reset()
+	if err := html.ExecuteTemplate(&buf, "layout.html", data); err != nil {
+		return mailer.Message{}, err
+	}
+
+	return mailer.Message{Subject: data.Subject, Text: textBody, HTML: buf.String()}, nil
+}
+
+var monthsNL = [...]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"}
+
+// funcMap holds the helpers templates can use. Dates in payloads are RFC 3339
+// or YYYY-MM-DD strings; values that do not parse are shown as they are.
+func funcMap(locale string) map[string]any {
+	format := func(v any, withTime bool) string {
+		s := fmt.Sprint(v)
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, s); err != nil {
+				return s
+			}
+			withTime = false
+		}
+		if withTime {
+			t = t.In(timeZone)
+		}
+		var out string
+		if locale == LocaleDutch {
+			out = fmt.Sprintf("%d %s %d", t.Day(), monthsNL[t.Month()-1], t.Year())
+		} else {
+			out = t.Format("2 January 2006")
+		}
+		if withTime {
+			out += t.Format(" 15:04 MST")
+		}
+		return out
+	}
+	return map[string]any{
+		"date":     func(v any) string { return format(v, false) },
+		"datetime": func(v any) string { return format(v, true) },
+	}
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Sample returns example data for a template, with every key its emails carry.
+// Previews and override validation render with it.
+func Sample(template mailer.EmailTemplate) map[string]any {
+	security := map[string]any{
+		"time":   "2026-03-01T14:05:00Z",
+		"ip":     "203.0.113.7",
+		"device": "Firefox on Windows",
+		"link":   "https://app.example.nl/auth/security/lock?token=sample",
+	}
+	switch template {
+	case mailer.TemplateInviteUser:
+		return map[string]any{"link": "https://app.example.nl/invite?token=sample", "role": "user"}
+	case mailer.TemplatePasswordReset:
+		return map[string]any{"link": "https://app.example.nl/auth/reset?token=sample"}
+	case mailer.TemplateEmailVerification:
+		return map[string]any{"link": "https://app.example.nl/auth/verify?token=sample"}
+	case mailer.TemplateEmailChange:
+		return map[string]any{"link": "https://app.example.nl/auth/email/change/confirm?token=sample"}
+	case mailer.TemplateEmailChangeNotice:
+		return map[string]any{"link": "https://app.example.nl/auth/email/change/revert?token=sample"}
+	case mailer.TemplateAccountDeletion:
+		return map[string]any{"link": "https://app.example.nl/auth/account/cancel-deletion?token=sample", "purge_after": "2026-03-31"}
+	case mailer.TemplateAccountLocked:
+		security["locked_until"] = "2026-03-01T14:20:00Z"
+		return security
+	default: // password_changed, mfa_enabled, mfa_disabled
+		return security
+	}
+}
@@ -0,0 +1,125 @@
+package emailtemplate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/emailtemplate"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func brand() emailtemplate.Brand {
+	logo := "https://cdn.example.nl/logo.png"
+	return emailtemplate.NewBrand(db.Tenant{
+		Name:     "Zorggroep Noord",
+		Branding: domain.TenantBranding{LogoURL: &logo, PrimaryColor: "#0f766e"},
+	})
+}
+
+func TestDefaults_RenderInEveryLocale(t *testing.T) {
+	for _, tmpl := range emailtemplate.Templates() {
+		for _, locale := range emailtemplate.Locales {
+			src, err := emailtemplate.Default(tmpl, locale)
+			require.NoError(t, err, "%s/%s", tmpl, locale)
+
+			// Validate renders strictly: every key used must be in the sample data
+			msg, err := emailtemplate.Validate(tmpl, locale, src, brand())
+			require.NoError(t, err, "%s/%s", tmpl, locale)
+			assert.NotEmpty(t, msg.Subject)
+			assert.NotContains(t, msg.Subject, "\n")
+			assert.Contains(t, msg.Text, "Zorggroep Noord", "%s/%s", tmpl, locale)
+			assert.Contains(t, msg.HTML, `lang="`+locale+`"`)
+			assert.Contains(t, msg.HTML, "https://cdn.example.nl/logo.png")
+			assert.Contains(t, msg.HTML, "#0f766e")
+			assert.NotContains(t, msg.Text+msg.HTML, "<no value>", "%s/%s", tmpl, locale)
+		}
+	}
+}
+
+func TestDefault_Unknown(t *testing.T) {
+	_, err := emailtemplate.Default("../layout", emailtemplate.LocaleDutch)
+	assert.ErrorIs(t, err, emailtemplate.ErrUnknownTemplate)
+	_, err = emailtemplate.Default(mailer.TemplatePasswordReset, "de")
+	assert.ErrorIs(t, err, emailtemplate.ErrUnknownLocale)
+}
+
+func TestRender_LocalisesDatesAndEscapesHTML(t *testing.T) {
+	src, err := emailtemplate.Default(mailer.TemplateAccountDeletion, emailtemplate.LocaleDutch)
+	require.NoError(t, err)
+	msg, err := emailtemplate.Render(mailer.TemplateAccountDeletion, src, emailtemplate.Data{
+		Data: map[string]any{
+			"link":        "javascript:alert(1)",
+			"purge_after": "2026-03-31",
+		},
+		Tenant: emailtemplate.NewBrand(db.Tenant{Name: "<b>Acme</b>"}),
+		Locale: emailtemplate.LocaleDutch,
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, msg.Text, "31 maart 2026")
+	assert.Contains(t, msg.HTML, "31 maart 2026")
+	assert.Contains(t, msg.HTML, "&lt;b&gt;Acme&lt;/b&gt;")
+	assert.NotContains(t, msg.HTML, "<b>Acme</b>")
+	assert.NotContains(t, msg.HTML, `href="javascript:`)
+}
+
+func TestRender_MissingKeysRenderEmpty(t *testing.T) {
+	src, err := emailtemplate.Default(mailer.TemplatePasswordChanged, emailtemplate.LocaleEnglish)
+	require.NoError(t, err)
+	msg, err := emailtemplate.Render(mailer.TemplatePasswordChanged, src, emailtemplate.Data{
+		Data:   map[string]any{"time": "2026-03-01T14:05:00Z"},
+		Tenant: brand(),
+		Locale: emailtemplate.LocaleEnglish,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, msg.Text, "1 March 2026 15:05 CET")
+	assert.NotContains(t, msg.Text, "IP address")
+	assert.NotContains(t, msg.Text+msg.HTML, "<no value>")
+}
+
+func TestNewBrand_DropsUnsafeValues(t *testing.T) {
+	logo := "http://cdn.example.nl/logo.png"
+	b := emailtemplate.NewBrand(db.Tenant{
+		Name:     "Acme",
+		Branding: domain.TenantBranding{LogoURL: &logo, PrimaryColor: "red;background:url(x)"},
+	})
+	assert.Empty(t, b.LogoURL)
+	assert.Equal(t, emailtemplate.DefaultPrimaryColor, b.PrimaryColor)
+}
+
+func TestValidate_Overrides(t *testing.T) {
+	src, err := emailtemplate.Default(mailer.TemplateInviteUser, emailtemplate.LocaleDutch)
+	require.NoError(t, err)
+
+	subject := "Welkom bij {{.Tenant.Name}}\r\nBcc: x@example.com"
+	html := `<p>Klik <a href="{{.Data.link}}">hier</a></p>`
+	msg, err := emailtemplate.Validate(mailer.TemplateInviteUser, emailtemplate.LocaleDutch,
+		emailtemplate.Override{Subject: &subject, HTML: &html}.Apply(src), brand())
+	require.NoError(t, err)
+	assert.Equal(t, "Welkom bij Zorggroep Noord Bcc: x@example.com", msg.Subject)
+	assert.Contains(t, msg.HTML, `<a href="https://app.example.nl/invite?token=sample">hier</a>`)
+	assert.Contains(t, msg.HTML, "#0f766e") // Still inside the branded layout
+	assert.Contains(t, msg.Text, "Je bent uitgenodigd")
+
+	for _, bad := range []string{
+		"{{.Data.invite_link}}", // Typo: not a key of this template
+		"{{if}}",                // Does not parse
+		"{{template \"layout.html\" .}}",
+		strings.Repeat("x", emailtemplate.MaxSubjectLength+1),
+		"  ",
+	} {
+		_, err := emailtemplate.Validate(mailer.TemplateInviteUser, emailtemplate.LocaleDutch,
+			emailtemplate.Override{Subject: &bad}.Apply(src), brand())
+		assert.ErrorIs(t, err, emailtemplate.ErrInvalidTemplate, bad)
+	}
+}
+
+func TestTenantLocale(t *testing.T) {
+	assert.Equal(t, emailtemplate.LocaleDutch, emailtemplate.TenantLocale(db.Tenant{}))
+	assert.Equal(t, emailtemplate.LocaleEnglish, emailtemplate.TenantLocale(db.Tenant{Settings: domain.TenantSettings{DefaultLocale: "en"}}))
+	assert.Equal(t, emailtemplate.LocaleDutch, emailtemplate.TenantLocale(db.Tenant{Settings: domain.TenantSettings{DefaultLocale: "fr"}}))
+}
@@ -0,0 +1,375 @@
+package emailtemplate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Renderer implements mailer.Renderer for the email worker.
+type Renderer struct {
+	pool *pgxpool.Pool
+}
+
+func NewRenderer(pool *pgxpool.Pool) *Renderer {
+	return &Renderer{pool: pool}
+}
+
+// Render loads the tenant, the recipient's locale and the tenant's override,
+// and renders the payload. It runs outside tenant context: the recipient may
+// be a member whose user row lives in another tenant.
+func (r *Renderer) Render(ctx context.Context, payload mailer.EmailPayload) (mailer.Message, error) {
+	if !mailer.ValidTemplates[payload.Template] {
+		return mailer.Message{}, ErrUnknownTemplate
+	}
+	var (
+		tenant   db.Tenant
+		locale   string
+		override Override
+	)
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		tid := pgtype.UUID{Bytes: payload.TenantID, Valid: true}
+		var err error
+		if tenant, err = q.GetTenantByID(ctx, tid); err != nil {
+			return fmt.Errorf("load tenant: %w", err)
+		}
+
+		locale = TenantLocale(tenant)
+		chosen, err := q.GetRecipientLocale(ctx, db.GetRecipientLocaleParams{Email: payload.To, TenantID: tid})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("load recipient locale: %w", err)
+		}
+		if chosen.Valid && ValidLocale(chosen.String) {
+			locale = chosen.String
+		}
+
+		row, err := q.GetEmailTemplateOverride(ctx, db.GetEmailTemplateOverrideParams{
+			TenantID: tid,
+			Template: string(payload.Template),
+			Locale:   locale,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("load template override: %w", err)
+		}
+		override = overrideOf(row)
+		return nil
+	})
+	if err != nil {
+		return mailer.Message{}, err
+	}
+
+	src, err := Default(payload.Template, locale)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	return Render(payload.Template, override.Apply(src), Data{
+		Data:   payload.Data,
+		Tenant: NewBrand(tenant),
+		Locale: locale,
+	})
+}
+
+// Service manages a tenant's template overrides for the API.
+type Service struct {
+	pool  *pgxpool.Pool
+	audit *audit.AuditService
+}
+
+func NewService(pool *pgxpool.Pool, audit *audit.AuditService) *Service {
+	return &Service{pool: pool, audit: audit}
+}
+
+// Summary lists which locales of a template the tenant has overridden.
+type Summary struct {
+	Template   mailer.EmailTemplate `json:"template"`
+	Overridden []string             `json:"overridden_locales"`
+}
+
+// List returns every template with the locales the tenant overrides.
+func (s *Service) List(ctx context.Context, tenantID uuid.UUID) ([]Summary, error) {
+	var rows []db.EmailTemplateOverride
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		rows, err = q.ListEmailTemplateOverrides(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := make(map[string][]string)
+	for _, row := range rows {
+		overridden[row.Template] = append(overridden[row.Template], row.Locale)
+	}
+	summaries := make([]Summary, 0, len(mailer.ValidTemplates))
+	for _, t := range Templates() {
+		locales := overridden[string(t)]
+		if locales == nil {
+			locales = []string{}
+		}
+		summaries = append(summaries, Summary{Template: t, Overridden: locales})
+	}
+	return summaries, nil
+}
+
+// Detail is a template in one locale: the default and the tenant's override.
+type Detail struct {
+	Template  mailer.EmailTemplate `json:"template"`
+	Locale    string               `json:"locale"`
+	Default   Source               `json:"default"`
+	Override  *Override            `json:"override"` // nil: the default is used
+	UpdatedAt *time.Time           `json:"updated_at,omitempty"`
+	Variables []string             `json:"variables"` // Keys of .Data
+}
+
+// Get returns a template's default and override in a locale.
+func (s *Service) Get(ctx context.Context, tenantID uuid.UUID, template mailer.EmailTemplate, locale string) (Detail, error) {
+	src, err := Default(template, locale)
+	if err != nil {
+		return Detail{}, err
+	}
+	detail := Detail{Template: template, Locale: locale, Default: src, Variables: variables(template)}
+
+	err = storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		row, err := q.GetEmailTemplateOverride(ctx, db.GetEmailTemplateOverrideParams{
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+			Template: string(template),
+			Locale:   locale,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		override := overrideOf(row)
+		detail.Override = &override
+		detail.UpdatedAt = &row.UpdatedAt.Time
+		return nil
+	})
+	return detail, err
+}
+
+// Save stores the tenant's override of a template in a locale, replacing an
+// earlier one. Parts left nil or empty keep the default. The result must render
+// with the template's sample data.
+func (s *Service) Save(ctx context.Context, tenantID, actorID uuid.UUID, template mailer.EmailTemplate, locale string, o Override) (Detail, error) {
+	src, err := Default(template, locale)
+	if err != nil {
+		return Detail{}, err
+	}
+	o = o.normalize()
+	if o.Subject == nil && o.Text == nil && o.HTML == nil {
+		return Detail{}, fmt.Errorf("%w: set at least one of subject, text and html", ErrInvalidTemplate)
+	}
+
+	err = storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+		tenant, err := q.GetTenantByID(ctx, tid)
+		if err != nil {
+			return err
+		}
+		if _, err := Validate(template, locale, o.Apply(src), NewBrand(tenant)); err != nil {
+			return err
+		}
+		_, err = q.UpsertEmailTemplateOverride(ctx, db.UpsertEmailTemplateOverrideParams{
+			TenantID:  tid,
+			Template:  string(template),
+			Locale:    locale,
+			Subject:   textOf(o.Subject),
+			TextBody:  textOf(o.Text),
+			HtmlBody:  textOf(o.HTML),
+			UpdatedBy: pgtype.UUID{Bytes: actorID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return Detail{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventEmailTemplateUpdated, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"template": string(template),
+			"locale":   locale,
+			"parts":    o.Parts(),
+		},
+	})
+	return s.Get(ctx, tenantID, template, locale)
+}
+
+// Delete removes the tenant's override, so the default is used again.
+func (s *Service) Delete(ctx context.Context, tenantID, actorID uuid.UUID, template mailer.EmailTemplate, locale string) error {
+	if _, err := Default(template, locale); err != nil {
+		return err
+	}
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		n, err := q.DeleteEmailTemplateOverride(ctx, db.DeleteEmailTemplateOverrideParams{
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+			Template: string(template),
+			Locale:   locale,
+		})
+		if err == nil && n == 0 {
+			return ErrOverrideNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventEmailTemplateReset, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"template": string(template),
+			"locale":   locale,
+		},
+	})
+	return nil
+}
+
+// Preview renders a template with sample data and the tenant's branding. The
+// saved override applies, and draft parts on top of it, so an editor can see
+// a change before saving it. An empty locale means the tenant's default.
+func (s *Service) Preview(ctx context.Context, tenantID uuid.UUID, template mailer.EmailTemplate, locale string, draft Override) (mailer.Message, error) {
+	if !mailer.ValidTemplates[template] {
+		return mailer.Message{}, ErrUnknownTemplate
+	}
+	var (
+		tenant db.Tenant
+		saved  Override
+	)
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+		var err error
+		if tenant, err = q.GetTenantByID(ctx, tid); err != nil {
+			return err
+		}
+		if locale == "" {
+			locale = TenantLocale(tenant)
+		}
+		if !ValidLocale(locale) {
+			return ErrUnknownLocale
+		}
+		row, err := q.GetEmailTemplateOverride(ctx, db.GetEmailTemplateOverrideParams{
+			TenantID: tid,
+			Template: string(template),
+			Locale:   locale,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		saved = overrideOf(row)
+		return nil
+	})
+	if err != nil {
+		return mailer.Message{}, err
+	}
+
+	src, err := Default(template, locale)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	return Validate(template, locale, draft.normalize().Apply(saved.Apply(src)), NewBrand(tenant))
+}
+
+// normalize treats empty parts as unset.
+func (o Override) normalize() Override {
+	for _, part := range []**string{&o.Subject, &o.Text, &o.HTML} {
+		if *part != nil && strings.TrimSpace(**part) == "" {
+			*part = nil
+		}
+	}
+	return o
+}
+
+func textOf(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+// variables returns the data keys a template's emails carry, sorted.
+func variables(template mailer.EmailTemplate) []string {
+	sample := Sample(template)
+	keys := make([]string, 0, len(sample))
+	for k := range sample {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// DefaultLocale returns the locale of emails to members who chose none.
+func (s *Service) DefaultLocale(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	var locale string
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tenant, err := q.GetTenantByID(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		locale = TenantLocale(tenant)
+		return err
+	})
+	return locale, err
+}
+
+// SetDefaultLocale changes the tenant's default_locale setting.
+func (s *Service) SetDefaultLocale(ctx context.Context, tenantID, actorID uuid.UUID, locale string) error {
+	if !ValidLocale(locale) {
+		return ErrUnknownLocale
+	}
+	var previous string
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tenant, err := q.GetTenantByID(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		if err != nil {
+			return err
+		}
+		settings := tenant.Settings
+		previous = settings.DefaultLocale
+		settings.DefaultLocale = locale
+
+		// Update only settings, preserve other fields
+		_, err = q.UpdateTenantConfig(ctx, db.UpdateTenantConfigParams{
+			ID:             tenant.ID,
+			AllowedOrigins: tenant.AllowedOrigins,
+			RedirectUrls:   tenant.RedirectUrls,
+			Branding:       tenant.Branding,
+			Settings:       settings,
+			AppUrl:         tenant.AppUrl,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventEmailLocaleChanged, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"locale":          locale,
+			"previous_locale": previous,
+		},
+	})
+	return nil
+}
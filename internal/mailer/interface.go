@@ -28,6 +28,21 @@ type EmailProvider interface {
 	Send(ctx context.Context, payload EmailPayload) (providerMessageID string, err error)
 }
 
+// Message is a rendered email: a one-line subject and the plain-text and HTML
+// alternatives of the body.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Renderer turns a payload into the Message that is sent. The implementation
+// (emailtemplate.Renderer) applies the tenant's branding, template overrides
+// and the recipient's locale.
+type Renderer interface {
+	Render(ctx context.Context, payload EmailPayload) (Message, error)
+}
+
 // EmailPayload encapsulates all data required for sending an email.
 // ALL fields are validated in the Business Logic layer BEFORE calling Send().
 //
@@ -67,6 +82,9 @@ const (
 	TemplateMFADisabled       EmailTemplate = "mfa_disabled"
 	TemplateAccountLocked     EmailTemplate = "account_locked"
 	TemplatePasswordChanged   EmailTemplate = "password_changed"
+	TemplateAccountDeletion   EmailTemplate = "account_deletion"
+	TemplateEmailChange       EmailTemplate = "email_change"
+	TemplateEmailChangeNotice EmailTemplate = "email_change_notice"
 )
 
 // ValidTemplates is a set of allowed templates for runtime validation.
@@ -79,6 +97,9 @@ var ValidTemplates = map[EmailTemplate]bool{
 	TemplateMFADisabled:       true,
 	TemplateAccountLocked:     true,
 	TemplatePasswordChanged:   true,
+	TemplateAccountDeletion:   true,
+	TemplateEmailChange:       true,
+	TemplateEmailChangeNotice: true,
 }
 
 // SMTPConfig holds tenant-specific SMTP configuration.
@@ -126,6 +126,12 @@ func validatePublicIP(ip net.IP) error {
 	return nil
 }
 
+// ValidatePublicIP exposes the address check for outbound connections that
+// verify the resolved IP at dial time (net.Dialer.Control), such as audit sinks.
+func ValidatePublicIP(ip net.IP) error {
+	return validatePublicIP(ip)
+}
+
 // ValidateSMTPPort restricts to standard SMTP ports to prevent port scanning.
 // Non-standard ports could indicate:
 // - Port scanning attempts (e.g., testing if PostgreSQL is on 5432)
@@ -1,13 +1,18 @@
 package mailer
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/mail"
 	"net/smtp"
+	"net/textproto"
 	"strings"
 	"time"
 
@@ -24,12 +29,13 @@ import (
 // - Timeout isolation per email (prevents worker starvation)
 type SMTPProvider struct {
 	Config     SMTPConfig
-	KeyVersion int // For versioned decryption
+	KeyVersion int      // For versioned decryption
+	Renderer   Renderer // Subject and bodies (emailtemplate.Renderer)
 }
 
 // NewSMTPProvider creates a new SMTP provider with validation.
 // Returns error if configuration is invalid (SSRF check, invalid ports, etc.)
-func NewSMTPProvider(config SMTPConfig, keyVersion int) (*SMTPProvider, error) {
+func NewSMTPProvider(config SMTPConfig, keyVersion int, renderer Renderer) (*SMTPProvider, error) {
 	// Validate host and port (SSRF protection)
 	if err := ValidateSMTPConfig(config.Host, config.Port); err != nil {
 		return nil, fmt.Errorf("invalid SMTP configuration: %w", err)
@@ -43,6 +49,7 @@ func NewSMTPProvider(config SMTPConfig, keyVersion int) (*SMTPProvider, error) {
 	return &SMTPProvider{
 		Config:     config,
 		KeyVersion: keyVersion,
+		Renderer:   renderer,
 	}, nil
 }
 
@@ -95,8 +102,13 @@ func (p *SMTPProvider) Send(ctx context.Context, payload EmailPayload) (string,
 		return "", fmt.Errorf("SMTP configuration error")
 	}
 
-	// 4. Build email message (RFC 5322 format)
-	message, err := p.buildMessage(fromAddr, toAddr, payload)
+	// 4. Render the template and build the message (RFC 5322 format)
+	content, err := p.Renderer.Render(ctx, payload)
+	if err != nil {
+		logger.Error("Failed to render email template", "error", err)
+		return "", fmt.Errorf("failed to render email: %w", err)
+	}
+	message, err := p.buildMessage(fromAddr, toAddr, payload, content)
 	if err != nil {
 		return "", fmt.Errorf("failed to build email message: %w", err)
 	}
@@ -190,89 +202,54 @@ func (p *SMTPProvider) Send(ctx context.Context, payload EmailPayload) (string,
 	return messageID, nil
 }
 
-// buildMessage constructs an RFC 5322 compliant email message.
-// For now, uses plain text. In production, load HTML templates.
-func (p *SMTPProvider) buildMessage(from, to string, payload EmailPayload) ([]byte, error) {
-	// Generate Message-ID (helps with delivery tracking)
-	messageID := fmt.Sprintf("<%s@%s>", payload.RequestID, p.Config.Host)
-
-	// Build headers
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = to
-	headers["Subject"] = p.getSubject(payload.Template)
-	headers["Message-ID"] = messageID
-	headers["Date"] = time.Now().Format(time.RFC1123Z)
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/plain; charset=UTF-8"
-
-	// Construct message
-	var msg strings.Builder
-	for k, v := range headers {
-		msg.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
-	}
-	msg.WriteString("\r\n") // Blank line separates headers from body
-
-	// Body (for now, simple text - TODO: load HTML templates)
-	body := p.buildBody(payload)
-	msg.WriteString(body)
-
-	return []byte(msg.String()), nil
-}
-
-// getSubject returns the email subject based on template type.
-// TODO: Load from template files instead of hardcoding
-func (p *SMTPProvider) getSubject(template EmailTemplate) string {
-	subjects := map[EmailTemplate]string{
-		TemplateInviteUser:        "You've been invited",
-		TemplatePasswordReset:     "Reset your password",
-		TemplateEmailVerification: "Verify your email address",
-		TemplateMFAEnabled:        "Two-factor authentication enabled",
-		TemplateMFADisabled:       "Two-factor authentication disabled",
-		TemplateAccountLocked:     "Your account has been locked",
-		TemplatePasswordChanged:   "Your password was changed",
+// buildMessage constructs an RFC 5322 message: multipart/alternative with the
+// plain-text part first and the HTML part last, since mail clients show the
+// last alternative they support.
+func (p *SMTPProvider) buildMessage(from, to string, payload EmailPayload, content Message) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, text string }{
+		{"text/plain; charset=UTF-8", content.Text},
+		{"text/html; charset=UTF-8", content.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.text)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
 	}
-
-	if subject, ok := subjects[template]; ok {
-		return subject
+	if err := parts.Close(); err != nil {
+		return nil, err
 	}
-	return "Notification"
-}
 
-// buildBody constructs the email body from template data.
-// TODO: Use html/template for proper templating
-func (p *SMTPProvider) buildBody(payload EmailPayload) string {
-	// Simple text-based body for MVP
-	// In production, load HTML templates from templates/ directory
-	var body strings.Builder
-
-	body.WriteString("Hello,\n\n")
-
-	switch payload.Template {
-	case TemplateInviteUser:
-		role, _ := payload.Data["role"].(string)
-		link, _ := payload.Data["link"].(string)
-		body.WriteString(fmt.Sprintf("You've been invited as %s.\n\n", role))
-		body.WriteString(fmt.Sprintf("Click here to accept: %s\n\n", link))
-
-	case TemplatePasswordReset:
-		link, _ := payload.Data["link"].(string)
-		body.WriteString("You requested a password reset.\n\n")
-		body.WriteString(fmt.Sprintf("Reset your password: %s\n\n", link))
-		body.WriteString("This link expires in 1 hour.\n\n")
-
-	case TemplateEmailVerification:
-		link, _ := payload.Data["link"].(string)
-		body.WriteString("Please verify your email address.\n\n")
-		body.WriteString(fmt.Sprintf("Verify: %s\n\n", link))
-
-	default:
-		body.WriteString("This is a notification from the system.\n\n")
+	// Header injection: the subject is rendered from tenant templates
+	subject := strings.Join(strings.Fields(content.Subject), " ")
+
+	var msg bytes.Buffer
+	for _, h := range [][2]string{
+		{"From", from},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("UTF-8", subject)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", payload.RequestID, p.Config.Host)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=\"" + parts.Boundary() + "\""},
+	} {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
 	}
+	msg.WriteString("\r\n") // Blank line separates headers from body
+	msg.Write(body.Bytes())
 
-	body.WriteString("Thank you,\nThe Team")
-
-	return body.String()
+	return msg.Bytes(), nil
 }
 
 // sanitizeEmailAddress validates and sanitizes an email address.
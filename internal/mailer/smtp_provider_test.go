@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage_MultipartAlternative(t *testing.T) {
+	p := &SMTPProvider{Config: SMTPConfig{Host: "smtp.example.nl"}}
+	content := Message{
+		Subject: "Wachtwoord gewijzigd\r\nBcc: attacker@example.com",
+		Text:    "Hallo,\n\nJe wachtwoord is gewijzigd.\n",
+		HTML:    `<p style="color:#0f766e">Hallo, je wachtwoord is gewijzigd.</p>`,
+	}
+
+	raw, err := p.buildMessage("noreply@example.nl", "jan@example.nl", EmailPayload{RequestID: "req-1"}, content)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Empty(t, msg.Header.Get("Bcc"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Wachtwoord gewijzigd Bcc: attacker@example.com", subject)
+	assert.Equal(t, "<req-1@smtp.example.nl>", msg.Header.Get("Message-ID"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	// multipart.Reader decodes quoted-printable parts
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var types, bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		types = append(types, part.Header.Get("Content-Type"))
+		bodies = append(bodies, strings.ReplaceAll(string(body), "\r\n", "\n"))
+	}
+	assert.Equal(t, []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}, types)
+	assert.Equal(t, []string{content.Text, content.HTML}, bodies)
+}
@@ -3,12 +3,37 @@ package notify
 import (
 	"context"
 	"log/slog"
+	"time"
 )
 
 type EmailSender interface {
 	SendInvitation(ctx context.Context, to string, inviteURL string) error
 	SendPasswordReset(ctx context.Context, to string, token string, appURL string) error
 	SendVerification(ctx context.Context, to string, token string, appURL string) error
+	SendAccountDeletion(ctx context.Context, to string, token string, appURL string, purgeAfter time.Time) error
+	SendEmailChange(ctx context.Context, to string, token string, appURL string) error
+	SendEmailChangeNotice(ctx context.Context, to string, revertToken string, appURL string) error
+
+	// Security notifications: sent after the change, each with a "this wasn't me" link
+	SendPasswordChanged(ctx context.Context, to string, notice SecurityNotice) error
+	SendMFAEnabled(ctx context.Context, to string, notice SecurityNotice) error
+	SendAccountLocked(ctx context.Context, to string, notice SecurityNotice, lockedUntil time.Time) error
+}
+
+// SecurityNotice describes the event behind a security notification: when it
+// happened, from where, and the token of the link that locks the account.
+type SecurityNotice struct {
+	Time      time.Time
+	IP        string // Empty when unknown (background jobs)
+	Device    string // Readable summary of the user agent, e.g. "Firefox on Windows"
+	LockToken string
+	AppURL    string
+}
+
+// LockLink is the "this wasn't me" link; the frontend page posts its token to
+// POST /auth/security/lock.
+func (n SecurityNotice) LockLink() string {
+	return n.AppURL + "/auth/security/lock?token=" + n.LockToken
 }
 
 // DevMailer prints emails to stdout (safe for development).
@@ -46,3 +71,61 @@ func (m *DevMailer) SendVerification(ctx context.Context, to string, token strin
 	)
 	return nil
 }
+
+func (m *DevMailer) SendAccountDeletion(ctx context.Context, to string, token string, appURL string, purgeAfter time.Time) error {
+	link := appURL + "/auth/account/cancel-deletion?token=" + token
+	m.Logger.Info("📧 EMAIL SENT",
+		"to", to,
+		"type", "account_deletion",
+		"purge_after", purgeAfter,
+		"link", link,
+	)
+	return nil
+}
+
+func (m *DevMailer) SendEmailChange(ctx context.Context, to string, token string, appURL string) error {
+	link := appURL + "/auth/email/change/confirm?token=" + token
+	m.Logger.Info("📧 EMAIL SENT",
+		"to", to,
+		"type", "email_change",
+		"token", token,
+		"link", link,
+	)
+	return nil
+}
+
+func (m *DevMailer) SendEmailChangeNotice(ctx context.Context, to string, revertToken string, appURL string) error {
+	link := appURL + "/auth/email/change/revert?token=" + revertToken
+	m.Logger.Info("📧 EMAIL SENT",
+		"to", to,
+		"type", "email_change_notice",
+		"link", link,
+	)
+	return nil
+}
+
+func (m *DevMailer) SendPasswordChanged(ctx context.Context, to string, notice SecurityNotice) error {
+	m.logSecurityNotice(to, "password_changed", notice)
+	return nil
+}
+
+func (m *DevMailer) SendMFAEnabled(ctx context.Context, to string, notice SecurityNotice) error {
+	m.logSecurityNotice(to, "mfa_enabled", notice)
+	return nil
+}
+
+func (m *DevMailer) SendAccountLocked(ctx context.Context, to string, notice SecurityNotice, lockedUntil time.Time) error {
+	m.logSecurityNotice(to, "account_locked", notice, "locked_until", lockedUntil)
+	return nil
+}
+
+func (m *DevMailer) logSecurityNotice(to string, kind string, notice SecurityNotice, extra ...any) {
+	m.Logger.Info("📧 EMAIL SENT", append([]any{
+		"to", to,
+		"type", kind,
+		"time", notice.Time,
+		"ip", notice.IP,
+		"device", notice.Device,
+		"link", notice.LockLink(),
+	}, extra...)...)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/requestmeta"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -122,10 +124,158 @@ func (m *ProductionMailer) SendVerification(ctx context.Context, to string, toke
 	return nil
 }
 
-// generateRequestID extracts or generates a request ID for tracing.
-// In production, extract from Sentry context or generate UUID.
+// SendAccountDeletion enqueues the confirmation of a scheduled account deletion,
+// with the link that cancels it during the cooling-off period.
+func (m *ProductionMailer) SendAccountDeletion(ctx context.Context, to string, token string, appURL string, purgeAfter time.Time) error {
+	cancelLink := fmt.Sprintf("%s/auth/account/cancel-deletion?token=%s", appURL, token)
+
+	payload := mailer.EmailPayload{
+		To:       to,
+		TenantID: m.TenantID,
+		Template: mailer.TemplateAccountDeletion,
+		Data: map[string]any{
+			"link":        cancelLink,
+			"purge_after": purgeAfter.UTC().Format(time.DateOnly), // Localised by the template
+		},
+		RequestID: generateRequestID(ctx),
+	}
+
+	if err := mailer.EnqueueEmail(ctx, m.Pool, payload); err != nil {
+		m.Logger.Error("Failed to enqueue account deletion email",
+			"to_hash", mailer.HashRecipient(to),
+			"error", err,
+		)
+		return fmt.Errorf("failed to send account deletion: %w", err)
+	}
+
+	m.Logger.Info("Account deletion email enqueued",
+		"to_hash", mailer.HashRecipient(to),
+	)
+
+	return nil
+}
+
+// SendEmailChange enqueues the confirmation link for a new email address.
+// It goes to the new address only: confirming proves the member owns it.
+func (m *ProductionMailer) SendEmailChange(ctx context.Context, to string, token string, appURL string) error {
+	confirmLink := fmt.Sprintf("%s/auth/email/change/confirm?token=%s", appURL, token)
+
+	payload := mailer.EmailPayload{
+		To:       to,
+		TenantID: m.TenantID,
+		Template: mailer.TemplateEmailChange,
+		Data: map[string]any{
+			"link": confirmLink,
+		},
+		RequestID: generateRequestID(ctx),
+	}
+
+	if err := mailer.EnqueueEmail(ctx, m.Pool, payload); err != nil {
+		m.Logger.Error("Failed to enqueue email change confirmation",
+			"to_hash", mailer.HashRecipient(to),
+			"error", err,
+		)
+		return fmt.Errorf("failed to send email change confirmation: %w", err)
+	}
+
+	m.Logger.Info("Email change confirmation enqueued",
+		"to_hash", mailer.HashRecipient(to),
+	)
+
+	return nil
+}
+
+// SendEmailChangeNotice enqueues the notice to the current address, with the
+// link that cancels or reverts the change.
+func (m *ProductionMailer) SendEmailChangeNotice(ctx context.Context, to string, revertToken string, appURL string) error {
+	revertLink := fmt.Sprintf("%s/auth/email/change/revert?token=%s", appURL, revertToken)
+
+	payload := mailer.EmailPayload{
+		To:       to,
+		TenantID: m.TenantID,
+		Template: mailer.TemplateEmailChangeNotice,
+		Data: map[string]any{
+			"link": revertLink,
+		},
+		RequestID: generateRequestID(ctx),
+	}
+
+	if err := mailer.EnqueueEmail(ctx, m.Pool, payload); err != nil {
+		m.Logger.Error("Failed to enqueue email change notice",
+			"to_hash", mailer.HashRecipient(to),
+			"error", err,
+		)
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+
+	m.Logger.Info("Email change notice enqueued",
+		"to_hash", mailer.HashRecipient(to),
+	)
+
+	return nil
+}
+
+// SendPasswordChanged enqueues the notice that the password was changed or reset.
+func (m *ProductionMailer) SendPasswordChanged(ctx context.Context, to string, notice SecurityNotice) error {
+	return m.enqueueSecurityNotice(ctx, to, mailer.TemplatePasswordChanged, notice, nil)
+}
+
+// SendMFAEnabled enqueues the notice that two-factor authentication was turned on.
+func (m *ProductionMailer) SendMFAEnabled(ctx context.Context, to string, notice SecurityNotice) error {
+	return m.enqueueSecurityNotice(ctx, to, mailer.TemplateMFAEnabled, notice, nil)
+}
+
+// SendAccountLocked enqueues the notice that sign-in to the account is refused
+// after too many failed attempts; the template shows until when.
+func (m *ProductionMailer) SendAccountLocked(ctx context.Context, to string, notice SecurityNotice, lockedUntil time.Time) error {
+	return m.enqueueSecurityNotice(ctx, to, mailer.TemplateAccountLocked, notice, map[string]any{
+		"locked_until": lockedUntil.UTC().Format(time.RFC3339),
+	})
+}
+
+// enqueueSecurityNotice enqueues one of the security templates. Times travel
+// as RFC 3339 in UTC; the template localises them.
+func (m *ProductionMailer) enqueueSecurityNotice(ctx context.Context, to string, template mailer.EmailTemplate, notice SecurityNotice, extra map[string]any) error {
+	data := map[string]any{
+		"time":   notice.Time.UTC().Format(time.RFC3339),
+		"ip":     notice.IP,
+		"device": notice.Device,
+		"link":   notice.LockLink(),
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	payload := mailer.EmailPayload{
+		To:        to,
+		TenantID:  m.TenantID,
+		Template:  template,
+		Data:      data,
+		RequestID: generateRequestID(ctx),
+	}
+
+	if err := mailer.EnqueueEmail(ctx, m.Pool, payload); err != nil {
+		m.Logger.Error("Failed to enqueue security notification",
+			"template", template,
+			"to_hash", mailer.HashRecipient(to),
+			"error", err,
+		)
+		return fmt.Errorf("failed to send %s notification: %w", template, err)
+	}
+
+	m.Logger.Info("Security notification enqueued",
+		"template", template,
+		"to_hash", mailer.HashRecipient(to),
+	)
+
+	return nil
+}
+
+// generateRequestID returns the ID of the HTTP request that triggered the
+// email, so outbox rows can be traced back to it; background jobs get a fresh one.
 func generateRequestID(ctx context.Context) string {
-	// TODO: Extract from Sentry trace context when integrated
-	// For now, generate a simple UUID
+	if meta, ok := requestmeta.From(ctx); ok && meta.RequestID != "" {
+		return meta.RequestID
+	}
 	return uuid.New().String()
 }
@@ -0,0 +1,74 @@
+package offboarding
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportFormat identifies the layout of the export file.
+const ExportFormat = "laventecare.tenant-export.v1"
+
+// Export is the envelope written around the database snapshot.
+type Export struct {
+	Format     string          `json:"format"`
+	TenantID   uuid.UUID       `json:"tenant_id"`
+	Slug       string          `json:"slug"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// WriteExport stores the snapshot as gzipped JSON in dir and returns the file
+// path and its SHA-256. The file is written under a temporary name and renamed,
+// so a crash never leaves a truncated export behind a recorded checksum.
+func WriteExport(dir string, tenantID uuid.UUID, slug string, snapshot []byte) (string, string, error) {
+	// Exports contain personal data: owner-only permissions
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json.gz", tenantID, filepath.Base(slug)))
+	tmp, err := os.CreateTemp(dir, ".export-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(tmp, hash))
+	err = json.NewEncoder(gz).Encode(Export{
+		Format:     ExportFormat,
+		TenantID:   tenantID,
+		Slug:       slug,
+		ExportedAt: time.Now().UTC(),
+		Data:       snapshot,
+	})
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", "", err
+	}
+	return path, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,308 @@
+// Package offboarding executes scheduled tenant deletions.
+//
+// The platform plane only schedules a deletion (tenant suspended, grace period
+// starts). Once the grace period is over the janitor worker claims the job,
+// exports the tenant data, purges every table in dependency order and leaves a
+// tombstone in the audit log. Progress is persisted per batch in
+// tenant_offboarding, so a crashed worker resumes where it stopped.
+package offboarding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job statuses (tenant_offboarding.status).
+const (
+	StatusScheduled = "scheduled"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+)
+
+// StepExport is the first step: nothing is deleted before the export is on disk.
+const StepExport = "export"
+
+// StepRehomeUsers runs before any purge: members of the tenant who also belong
+// to another tenant move their home there and keep their account.
+const StepRehomeUsers = "rehome_users"
+
+// DefaultBatchSize bounds every purge transaction.
+const DefaultBatchSize = 500
+
+type purgeFunc func(ctx context.Context, q *db.Queries, tenantID pgtype.UUID, limit int32) (int64, error)
+
+// Step is one table of the purge.
+type Step struct {
+	Name  string
+	purge purgeFunc
+}
+
+// Steps lists the purge in dependency order: children before the rows they
+// reference (email_outbox -> email_logs, tokens/codes -> users, memberships -> users),
+// the tenants row last. Do not reorder without checking the foreign keys.
+// Re-homing comes first, so the user-scoped steps only see users who leave.
+var Steps = []Step{
+	{StepRehomeUsers, func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.RehomeTenantUsers(ctx, db.RehomeTenantUsersParams{TenantID: id, Limit: n})
+	}},
+	{"email_outbox", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantEmailOutbox(ctx, db.PurgeTenantEmailOutboxParams{TenantID: id, Limit: n})
+	}},
+	{"email_logs", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantEmailLogs(ctx, db.PurgeTenantEmailLogsParams{TenantID: id, Limit: n})
+	}},
+	{"refresh_tokens", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantRefreshTokens(ctx, db.PurgeTenantRefreshTokensParams{TenantID: id, Limit: n})
+	}},
+	{"verification_tokens", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantVerificationTokens(ctx, db.PurgeTenantVerificationTokensParams{TenantID: id, Limit: n})
+	}},
+	{"mfa_backup_codes", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantMfaBackupCodes(ctx, db.PurgeTenantMfaBackupCodesParams{TenantID: id, Limit: n})
+	}},
+	{"email_change_requests", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantEmailChangeRequests(ctx, db.PurgeTenantEmailChangeRequestsParams{TenantID: id, Limit: n})
+	}},
+	{"invitations", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantInvitations(ctx, db.PurgeTenantInvitationsParams{TenantID: id, Limit: n})
+	}},
+	{"iot_devices", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantIoTDevices(ctx, db.PurgeTenantIoTDevicesParams{TenantID: id, Limit: n})
+	}},
+	{"rate_limit_events", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantRateLimitEvents(ctx, db.PurgeTenantRateLimitEventsParams{TenantID: id, Limit: n})
+	}},
+	{"tenant_domains", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantDomains(ctx, db.PurgeTenantDomainsParams{TenantID: id, Limit: n})
+	}},
+	{"tenant_roles", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantRoles(ctx, db.PurgeTenantRolesParams{TenantID: id, Limit: n})
+	}},
+	{"memberships", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantMemberships(ctx, db.PurgeTenantMembershipsParams{TenantID: id, Limit: n})
+	}},
+	{"users", func(ctx context.Context, q *db.Queries, id pgtype.UUID, n int32) (int64, error) {
+		return q.PurgeTenantUsers(ctx, db.PurgeTenantUsersParams{TenantID: id, Limit: n})
+	}},
+	{"tenants", func(ctx context.Context, q *db.Queries, id pgtype.UUID, _ int32) (int64, error) {
+		return q.DeleteTenant(ctx, id)
+	}},
+}
+
+// StepNames returns the full step sequence as recorded in tenant_offboarding.step.
+func StepNames() []string {
+	names := []string{StepExport}
+	for _, s := range Steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// resumeIndex maps a recorded step to its position in StepNames. An empty or
+// unknown step starts from the beginning; the export is idempotent.
+func resumeIndex(step string) int {
+	for i, name := range StepNames() {
+		if name == step {
+			return i
+		}
+	}
+	return 0
+}
+
+// Runner processes due offboarding jobs. Run it from the janitor worker.
+type Runner struct {
+	pool      *pgxpool.Pool
+	audit     *audit.AuditService
+	exportDir string
+	batchSize int32
+	logger    *slog.Logger
+}
+
+func NewRunner(pool *pgxpool.Pool, audit *audit.AuditService, exportDir string, logger *slog.Logger) *Runner {
+	return &Runner{
+		pool:      pool,
+		audit:     audit,
+		exportDir: exportDir,
+		batchSize: DefaultBatchSize,
+		logger:    logger,
+	}
+}
+
+// RunDue claims and completes jobs until none are due. A failing job records
+// last_error and backs off; the next cycle resumes it at the recorded step.
+func (r *Runner) RunDue(ctx context.Context) (int, error) {
+	completed := 0
+	for {
+		var job db.TenantOffboarding
+		err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			var err error
+			job, err = db.New(tx).ClaimDueTenantOffboarding(ctx)
+			return err
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return completed, nil
+		}
+		if err != nil {
+			return completed, err
+		}
+
+		if err := r.run(ctx, job); err != nil {
+			r.logger.Error("Offboarding: Job failed, will resume next cycle",
+				"tenant_id", uuid.UUID(job.TenantID.Bytes), "step", job.Step, "attempt", job.Attempts, "error", err)
+			failErr := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+				return db.New(tx).FailTenantOffboarding(ctx, db.FailTenantOffboardingParams{
+					TenantID:  job.TenantID,
+					LastError: pgtype.Text{String: err.Error(), Valid: true},
+				})
+			})
+			if failErr != nil {
+				return completed, failErr
+			}
+			continue
+		}
+		completed++
+	}
+}
+
+// run executes the job from its recorded step onwards.
+func (r *Runner) run(ctx context.Context, job db.TenantOffboarding) error {
+	tenantID := uuid.UUID(job.TenantID.Bytes)
+	progress := map[string]int64{}
+	if len(job.Progress) > 0 {
+		if err := json.Unmarshal(job.Progress, &progress); err != nil {
+			return fmt.Errorf("decode progress: %w", err)
+		}
+	}
+
+	start := resumeIndex(job.Step)
+	r.logger.Info("Offboarding: Running", "tenant_id", tenantID, "slug", job.TenantSlug, "step", StepNames()[start])
+
+	if start == 0 {
+		if err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			return r.saveProgress(ctx, db.New(tx), job.TenantID, StepExport, progress)
+		}); err != nil {
+			return err
+		}
+		path, sum, err := r.export(ctx, job)
+		if err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		job.ExportPath = pgtype.Text{String: path, Valid: true}
+		job.ExportSha256 = pgtype.Text{String: sum, Valid: true}
+		start = 1
+	}
+
+	for _, step := range Steps[start-1:] {
+		if err := r.purge(ctx, job.TenantID, step, progress); err != nil {
+			return fmt.Errorf("purge %s: %w", step.Name, err)
+		}
+	}
+
+	if err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		return db.New(tx).CompleteTenantOffboarding(ctx, job.TenantID)
+	}); err != nil {
+		return err
+	}
+
+	// ✅ SECURE: Tombstone. What remains of the tenant is this record and its
+	// audit history (audit_logs.tenant_id has no foreign key).
+	deleted, rehomed := SplitProgress(progress)
+	r.audit.Log(ctx, audit.EventTenantOffboarded, audit.LogParams{
+		TargetID: tenantID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"slug":              job.TenantSlug,
+			"platform_admin_id": uuid.UUID(job.RequestedBy.Bytes).String(),
+			"requested_at":      job.RequestedAt.Time,
+			"export_sha256":     job.ExportSha256.String,
+			"deleted":           deleted,
+			"rehomed_users":     rehomed,
+		},
+	})
+	r.logger.Info("Offboarding: Completed", "tenant_id", tenantID, "slug", job.TenantSlug, "deleted", deleted, "rehomed_users", rehomed)
+	return nil
+}
+
+// SplitProgress separates the re-homed users from the per-table delete counts.
+func SplitProgress(progress map[string]int64) (deleted map[string]int64, rehomed int64) {
+	deleted = maps.Clone(progress)
+	rehomed = deleted[StepRehomeUsers]
+	delete(deleted, StepRehomeUsers)
+	return deleted, rehomed
+}
+
+// export writes the tenant snapshot to disk and records path and checksum.
+// Re-running it (crash before the first purge) overwrites the same file.
+func (r *Runner) export(ctx context.Context, job db.TenantOffboarding) (string, string, error) {
+	var snapshot []byte
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		snapshot, err = db.New(tx).ExportTenantSnapshot(ctx, job.TenantID)
+		return err
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	path, sum, err := WriteExport(r.exportDir, uuid.UUID(job.TenantID.Bytes), job.TenantSlug, snapshot)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		return db.New(tx).SetTenantOffboardingExport(ctx, db.SetTenantOffboardingExportParams{
+			TenantID:     job.TenantID,
+			ExportPath:   pgtype.Text{String: path, Valid: true},
+			ExportSha256: pgtype.Text{String: sum, Valid: true},
+		})
+	})
+	return path, sum, err
+}
+
+// purge deletes one table in batches. Each batch and its progress update commit
+// together, so the recorded counts are exact even across crashes.
+func (r *Runner) purge(ctx context.Context, tenantID pgtype.UUID, step Step, progress map[string]int64) error {
+	for {
+		var n int64
+		err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			q := db.New(tx)
+			var err error
+			if n, err = step.purge(ctx, q, tenantID, r.batchSize); err != nil {
+				return err
+			}
+			progress[step.Name] += n
+			return r.saveProgress(ctx, q, tenantID, step.Name, progress)
+		})
+		if err != nil {
+			progress[step.Name] -= n // rolled back
+			return err
+		}
+		if n < int64(r.batchSize) {
+			return nil
+		}
+	}
+}
+
+func (r *Runner) saveProgress(ctx context.Context, q *db.Queries, tenantID pgtype.UUID, step string, progress map[string]int64) error {
+	raw, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	return q.UpdateTenantOffboardingProgress(ctx, db.UpdateTenantOffboardingProgressParams{
+		TenantID: tenantID,
+		Step:     step,
+		Progress: raw,
+	})
+}
@@ -0,0 +1,83 @@
+package offboarding_test
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/offboarding"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepNames_DependencyOrder(t *testing.T) {
+	names := offboarding.StepNames()
+	require.NotEmpty(t, names)
+	assert.Equal(t, offboarding.StepExport, names[0], "nothing may be deleted before the export")
+	assert.Equal(t, "tenants", names[len(names)-1])
+
+	before := func(child, parent string) {
+		ci, pi := slices.Index(names, child), slices.Index(names, parent)
+		require.NotEqual(t, -1, ci, child)
+		require.NotEqual(t, -1, pi, parent)
+		assert.Less(t, ci, pi, "%s must be purged before %s", child, parent)
+	}
+	before("email_outbox", "email_logs")
+	for _, child := range []string{"refresh_tokens", "verification_tokens", "mfa_backup_codes", "email_change_requests", "memberships"} {
+		before(child, "users")
+	}
+	before("users", "tenants")
+
+	// Users with a membership elsewhere must be moved out before anything user-scoped runs
+	assert.Equal(t, offboarding.StepRehomeUsers, names[1])
+}
+
+func TestSplitProgress(t *testing.T) {
+	progress := map[string]int64{offboarding.StepRehomeUsers: 2, "memberships": 7, "users": 3}
+	deleted, rehomed := offboarding.SplitProgress(progress)
+	assert.Equal(t, int64(2), rehomed)
+	assert.Equal(t, map[string]int64{"memberships": 7, "users": 3}, deleted)
+	assert.Len(t, progress, 3, "the recorded progress is left alone")
+}
+
+func TestWriteExport(t *testing.T) {
+	dir := t.TempDir()
+	tenantID := uuid.New()
+	snapshot := []byte(`{"users":[{"email":"jan@example.com"}]}`)
+
+	path, sum, err := offboarding.WriteExport(dir, tenantID, "acme", snapshot)
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	digest := sha256.Sum256(raw)
+	assert.Equal(t, hex.EncodeToString(digest[:]), sum)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var export offboarding.Export
+	require.NoError(t, json.NewDecoder(gz).Decode(&export))
+	assert.Equal(t, offboarding.ExportFormat, export.Format)
+	assert.Equal(t, tenantID, export.TenantID)
+	assert.JSONEq(t, string(snapshot), string(export.Data))
+
+	// Re-running (crash before the purge) replaces the file, no temp files remain
+	_, _, err = offboarding.WriteExport(dir, tenantID, "acme", snapshot)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
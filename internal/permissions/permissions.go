@@ -0,0 +1,182 @@
+// Package permissions defines the fine-grained permission catalogue and
+// resolves a membership role (built-in or tenant-defined) to its permissions.
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Permission catalogue. Names are "<resource>:<action>" and end up in access tokens,
+// so keep them short and NEVER rename one (existing roles reference it).
+// tenants:create and tenants:delete moved to the platform plane; Resolve drops them.
+const (
+	UsersRead        = "users:read"
+	UsersInvite      = "users:invite"
+	UsersManage      = "users:manage" // change roles, remove members
+	UsersExport      = "users:export" // GDPR data export on a member's behalf
+	RolesManage      = "roles:manage"
+	MailConfigure    = "mail:configure"
+	MailStats        = "mail:stats"
+	AuditRead        = "audit:read"
+	AuditExport      = "audit:export"       // bulk export, SIEM sinks, retention
+	SecurityRead     = "security:read"      // rate limit hits
+	SecurityConfig   = "security:configure" // CORS, bot challenge, custom domains
+	WebhooksManage   = "webhooks:manage"
+	TenantsConfigure = "tenants:configure"
+)
+
+// Definition describes a permission for the admin UI.
+type Definition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Catalogue lists every known permission.
+var Catalogue = []Definition{
+	{UsersRead, "List members of the tenant"},
+	{UsersInvite, "Invite new members"},
+	{UsersManage, "Change member roles and remove members"},
+	{UsersExport, "Export a member's personal data"},
+	{RolesManage, "Create, edit and delete custom roles"},
+	{MailConfigure, "Manage the SMTP configuration, email templates and email language"},
+	{MailStats, "View email delivery statistics"},
+	{AuditRead, "Read the audit log"},
+	{AuditExport, "Export the audit log, manage SIEM sinks and set audit retention"},
+	{SecurityRead, "View rate limit hits"},
+	{SecurityConfig, "Manage CORS, bot challenge and custom domains"},
+	{WebhooksManage, "Manage webhooks and view their delivery log"},
+	{TenantsConfigure, "Manage tenant settings"},
+}
+
+// Built-in roles. They are resolved in code and cannot be redefined by tenants.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Builtin maps the built-in roles to their permissions. Editor and viewer have
+// no admin permissions, matching the former role-weight behaviour.
+var Builtin = map[string][]string{
+	RoleAdmin:  All(),
+	RoleEditor: {},
+	RoleViewer: {},
+}
+
+var (
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// All returns every permission in the catalogue.
+func All() []string {
+	all := make([]string, len(Catalogue))
+	for i, d := range Catalogue {
+		all[i] = d.Name
+	}
+	return all
+}
+
+// Known reports whether p is in the catalogue.
+func Known(p string) bool {
+	return slices.ContainsFunc(Catalogue, func(d Definition) bool { return d.Name == p })
+}
+
+// Validate rejects unknown permissions and returns a sorted, de-duplicated copy.
+func Validate(perms []string) ([]string, error) {
+	out := make([]string, 0, len(perms))
+	for _, p := range perms {
+		if !Known(p) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, p)
+		}
+		out = append(out, p)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// IsBuiltin reports whether role is resolved in code.
+func IsBuiltin(role string) bool {
+	_, ok := Builtin[role]
+	return ok
+}
+
+// HasAll reports whether granted contains every permission in required.
+func HasAll(granted []string, required ...string) bool {
+	for _, p := range required {
+		if !slices.Contains(granted, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// RoleLookup loads tenant-defined roles.
+type RoleLookup interface {
+	GetTenantRoleByName(ctx context.Context, arg db.GetTenantRoleByNameParams) (db.TenantRole, error)
+}
+
+// Resolver turns a (tenant, role) pair into permissions.
+type Resolver struct {
+	roles RoleLookup
+}
+
+func NewResolver(roles RoleLookup) *Resolver {
+	return &Resolver{roles: roles}
+}
+
+// Resolve returns the permissions of role in tenantID.
+// Unknown roles (e.g. the legacy default 'user') resolve to no permissions.
+func (r *Resolver) Resolve(ctx context.Context, tenantID uuid.UUID, role string) ([]string, error) {
+	if perms, ok := Builtin[role]; ok {
+		return slices.Clone(perms), nil
+	}
+	if r == nil || r.roles == nil || role == "" {
+		return []string{}, nil
+	}
+
+	custom, err := r.roles.GetTenantRoleByName(ctx, db.GetTenantRoleByNameParams{
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		Name:     role,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve role %q: %w", role, err)
+	}
+
+	// Drop permissions that were removed from the catalogue since the role was saved.
+	perms := make([]string, 0, len(custom.Permissions))
+	for _, p := range custom.Permissions {
+		if Known(p) {
+			perms = append(perms, p)
+		}
+	}
+	return perms, nil
+}
+
+// Exists reports whether role is built-in or defined for tenantID.
+func (r *Resolver) Exists(ctx context.Context, tenantID uuid.UUID, role string) (bool, error) {
+	if IsBuiltin(role) {
+		return true, nil
+	}
+	if r == nil || r.roles == nil {
+		return false, nil
+	}
+	_, err := r.roles.GetTenantRoleByName(ctx, db.GetTenantRoleByNameParams{
+		TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		Name:     role,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
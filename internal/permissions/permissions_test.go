@@ -0,0 +1,68 @@
+package permissions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRoles map[string][]string
+
+func (f fakeRoles) GetTenantRoleByName(ctx context.Context, arg db.GetTenantRoleByNameParams) (db.TenantRole, error) {
+	perms, ok := f[arg.Name]
+	if !ok {
+		return db.TenantRole{}, pgx.ErrNoRows
+	}
+	return db.TenantRole{Name: arg.Name, Permissions: perms}, nil
+}
+
+func TestValidate(t *testing.T) {
+	perms, err := permissions.Validate([]string{permissions.UsersRead, permissions.AuditRead, permissions.UsersRead})
+	require.NoError(t, err)
+	assert.Equal(t, []string{permissions.AuditRead, permissions.UsersRead}, perms)
+
+	_, err = permissions.Validate([]string{"users:*"})
+	assert.True(t, errors.Is(err, permissions.ErrUnknownPermission))
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	r := permissions.NewResolver(fakeRoles{"support": {permissions.UsersRead, "legacy:removed"}})
+
+	perms, err := r.Resolve(ctx, tenantID, permissions.RoleAdmin)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, permissions.All(), perms)
+
+	perms, err = r.Resolve(ctx, tenantID, permissions.RoleViewer)
+	require.NoError(t, err)
+	assert.Empty(t, perms)
+
+	// Permissions dropped from the catalogue are filtered out
+	perms, err = r.Resolve(ctx, tenantID, "support")
+	require.NoError(t, err)
+	assert.Equal(t, []string{permissions.UsersRead}, perms)
+
+	// Unknown roles (legacy 'user') resolve to nothing, not an error
+	perms, err = r.Resolve(ctx, tenantID, "user")
+	require.NoError(t, err)
+	assert.Empty(t, perms)
+
+	exists, err := r.Exists(ctx, tenantID, "user")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestHasAll(t *testing.T) {
+	granted := []string{permissions.UsersRead, permissions.UsersInvite}
+	assert.True(t, permissions.HasAll(granted, permissions.UsersRead))
+	assert.True(t, permissions.HasAll(granted))
+	assert.False(t, permissions.HasAll(granted, permissions.UsersRead, permissions.RolesManage))
+}
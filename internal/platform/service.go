@@ -0,0 +1,494 @@
+// Package platform implements the platform-operator plane (/platform/v1):
+// tenant lifecycle, impersonation and cross-tenant statistics.
+//
+// Operators are NOT tenant users. They live in platform_admins, log in with
+// password + TOTP, and every query this package runs goes through
+// storage.WithoutRLS, the only sanctioned way to read across tenants.
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auth"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/crypto"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/permissions"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email, password or mfa code")
+	ErrTenantNotFound     = errors.New("tenant not found")
+	ErrTenantExists       = errors.New("tenant slug already taken")
+	ErrSlugMismatch       = errors.New("confirmation slug does not match tenant")
+	ErrNotAMember         = errors.New("user is not a member of the tenant")
+	ErrReasonRequired     = errors.New("a reason is required")
+	ErrDeletionScheduled  = errors.New("tenant is scheduled for deletion")
+	ErrNoDeletion         = errors.New("tenant has no scheduled deletion")
+	ErrDeletionStarted    = errors.New("tenant deletion already started")
+)
+
+// TokenIssuer signs platform and impersonation tokens. Implemented by *auth.JWTProvider.
+type TokenIssuer interface {
+	GeneratePlatformToken(adminID uuid.UUID) (string, error)
+	GenerateImpersonationToken(userID, tenantID uuid.UUID, role string, permissions []string, actorID uuid.UUID) (string, error)
+}
+
+// Service orchestrates platform operations.
+type Service struct {
+	pool          *pgxpool.Pool
+	hasher        auth.PasswordHasher
+	mfa           *auth.MFAService
+	tokens        TokenIssuer
+	audit         *audit.AuditService
+	deletionGrace time.Duration // Between DELETE and the offboarding purge
+}
+
+func NewService(pool *pgxpool.Pool, hasher auth.PasswordHasher, mfa *auth.MFAService, tokens TokenIssuer, audit *audit.AuditService, deletionGrace time.Duration) *Service {
+	return &Service{
+		pool:          pool,
+		hasher:        hasher,
+		mfa:           mfa,
+		tokens:        tokens,
+		audit:         audit,
+		deletionGrace: deletionGrace,
+	}
+}
+
+// withoutRLS runs fn with cross-tenant visibility.
+func (s *Service) withoutRLS(ctx context.Context, fn func(q *db.Queries) error) error {
+	return storage.WithoutRLS(ctx, s.pool, func(tx pgx.Tx) error {
+		return fn(db.New(tx))
+	})
+}
+
+// LoginInput holds the operator credentials. MFA is not optional on this plane.
+type LoginInput struct {
+	Email    string
+	Password string
+	TOTPCode string
+	IP       net.IP
+}
+
+// Login verifies password AND TOTP and returns a platform-scoped token.
+func (s *Service) Login(ctx context.Context, input LoginInput) (string, error) {
+	var admin db.PlatformAdmin
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		var err error
+		admin, err = q.GetPlatformAdminByEmail(ctx, input.Email)
+		return err
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to load operator: %w", err)
+	}
+
+	// One generic error for unknown email, wrong password, wrong code or disabled operator
+	if err != nil || !admin.IsActive ||
+		s.hasher.Compare(admin.PasswordHash, input.Password) != nil ||
+		!s.mfa.ValidateCode(input.TOTPCode, admin.MfaSecret) {
+		s.audit.Log(ctx, audit.EventPlatformLoginFailed, audit.LogParams{
+			Metadata: map[string]interface{}{
+				"email": input.Email,
+				"ip":    input.IP.String(),
+			},
+		})
+		return "", ErrInvalidCredentials
+	}
+
+	adminID := uuid.UUID(admin.ID.Bytes)
+	token, err := s.tokens.GeneratePlatformToken(adminID)
+	if err != nil {
+		return "", fmt.Errorf("token generation failed: %w", err)
+	}
+
+	if err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		return q.TouchPlatformAdminLogin(ctx, admin.ID)
+	}); err != nil {
+		return "", fmt.Errorf("failed to record login: %w", err)
+	}
+
+	// Operators are not users (audit_logs.actor_id references users), so they go in metadata
+	s.audit.Log(ctx, audit.EventPlatformLoginSuccess, audit.LogParams{
+		Metadata: map[string]interface{}{
+			"platform_admin_id": adminID.String(),
+			"ip":                input.IP.String(),
+		},
+	})
+	return token, nil
+}
+
+// GetPlatformAdmin loads an operator (used by middleware.PlatformAuth on every request).
+func (s *Service) GetPlatformAdmin(ctx context.Context, id pgtype.UUID) (db.PlatformAdmin, error) {
+	var admin db.PlatformAdmin
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		var err error
+		admin, err = q.GetPlatformAdminByID(ctx, id)
+		return err
+	})
+	return admin, err
+}
+
+// ListTenants returns all tenants, newest first, with member and session counts.
+func (s *Service) ListTenants(ctx context.Context, limit, offset int32) ([]db.ListPlatformTenantsRow, error) {
+	var tenants []db.ListPlatformTenantsRow
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		var err error
+		tenants, err = q.ListPlatformTenants(ctx, db.ListPlatformTenantsParams{Limit: limit, Offset: offset})
+		return err
+	})
+	return tenants, err
+}
+
+// CreateTenantInput defines the input for creating a new tenant.
+type CreateTenantInput struct {
+	Name   string
+	Slug   string
+	AppURL string
+}
+
+// CreatedTenant is returned once: SecretKey is only stored as a bcrypt hash.
+type CreatedTenant struct {
+	Tenant    db.Tenant
+	SecretKey string
+}
+
+// CreateTenant creates a new tenant with a fresh secret key.
+// ✅ SECURE: The raw secret is returned to the operator once and stored hashed (bcrypt).
+func (s *Service) CreateTenant(ctx context.Context, adminID uuid.UUID, input CreateTenantInput) (*CreatedTenant, error) {
+	rawKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tenant secret: %w", err)
+	}
+	hashedKey, err := s.hasher.Hash(rawKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash secret: %w", err)
+	}
+
+	var tenant db.Tenant
+	err = s.withoutRLS(ctx, func(q *db.Queries) error {
+		tenant, err = q.CreateTenant(ctx, db.CreateTenantParams{
+			Name:           input.Name,
+			Slug:           input.Slug,
+			SecretKeyHash:  hashedKey,
+			AllowedOrigins: []string{},
+			RedirectUrls:   []string{},
+			Branding:       domain.TenantBranding{PrimaryColor: "#000000"},
+			Settings:       domain.TenantSettings{AllowRegistration: false},
+			AppUrl:         input.AppURL,
+		})
+		return err
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrTenantExists
+		}
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	s.audit.Log(ctx, audit.EventPlatformTenantCreated, audit.LogParams{
+		TargetID: tenant.ID.Bytes,
+		TenantID: tenant.ID.Bytes, // The new tenant is its own context here
+		Metadata: map[string]interface{}{
+			"platform_admin_id": adminID.String(),
+			"slug":              input.Slug,
+			"name":              input.Name,
+			"app_url":           input.AppURL,
+		},
+	})
+
+	return &CreatedTenant{Tenant: tenant, SecretKey: rawKey}, nil
+}
+
+// SuspendTenant deactivates a tenant: logins are refused, custom domains stop
+// resolving and every refresh token is revoked. Access tokens expire within 15 minutes.
+func (s *Service) SuspendTenant(ctx context.Context, adminID, tenantID uuid.UUID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrReasonRequired
+	}
+
+	var revoked int64
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		id := pgtype.UUID{Bytes: tenantID, Valid: true}
+		n, err := q.SetTenantActive(ctx, db.SetTenantActiveParams{ID: id, IsActive: false})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrTenantNotFound
+		}
+		revoked, err = q.RevokeTenantRefreshTokens(ctx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventPlatformTenantSuspended, audit.LogParams{
+		TargetID: tenantID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"platform_admin_id": adminID.String(),
+			"reason":            reason,
+			"sessions_revoked":  revoked,
+		},
+	})
+	return nil
+}
+
+// ResumeTenant reactivates a suspended tenant. Users have to log in again.
+// A tenant scheduled for deletion must have its deletion cancelled first.
+func (s *Service) ResumeTenant(ctx context.Context, adminID, tenantID uuid.UUID) error {
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		_, err := q.GetTenantOffboarding(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		if err == nil {
+			return ErrDeletionScheduled
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		n, err := q.SetTenantActive(ctx, db.SetTenantActiveParams{ID: pgtype.UUID{Bytes: tenantID, Valid: true}, IsActive: true})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrTenantNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventPlatformTenantResumed, audit.LogParams{
+		TargetID: tenantID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"platform_admin_id": adminID.String(),
+		},
+	})
+	return nil
+}
+
+// ScheduleTenantDeletion starts offboarding: the tenant is suspended now and
+// purged by the janitor worker (internal/offboarding) once the grace period is
+// over. confirmSlug must repeat the tenant slug (guards against a wrong ID).
+func (s *Service) ScheduleTenantDeletion(ctx context.Context, adminID, tenantID uuid.UUID, confirmSlug, reason string) (db.TenantOffboarding, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return db.TenantOffboarding{}, ErrReasonRequired
+	}
+
+	var job db.TenantOffboarding
+	var revoked int64
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		id := pgtype.UUID{Bytes: tenantID, Valid: true}
+		tenant, err := q.GetTenantByID(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTenantNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(tenant.Slug, confirmSlug) {
+			return ErrSlugMismatch
+		}
+
+		job, err = q.ScheduleTenantOffboarding(ctx, db.ScheduleTenantOffboardingParams{
+			TenantID:    id,
+			TenantSlug:  tenant.Slug,
+			TenantName:  tenant.Name,
+			RequestedBy: pgtype.UUID{Bytes: adminID, Valid: true},
+			Reason:      reason,
+			PurgeAfter:  pgtype.Timestamptz{Time: time.Now().Add(s.deletionGrace), Valid: true},
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDeletionScheduled
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.SetTenantActive(ctx, db.SetTenantActiveParams{ID: id, IsActive: false}); err != nil {
+			return err
+		}
+		revoked, err = q.RevokeTenantRefreshTokens(ctx, id)
+		return err
+	})
+	if err != nil {
+		return db.TenantOffboarding{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventPlatformDeletionScheduled, audit.LogParams{
+		TargetID: tenantID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"platform_admin_id": adminID.String(),
+			"slug":              job.TenantSlug,
+			"reason":            reason,
+			"purge_after":       job.PurgeAfter.Time,
+			"sessions_revoked":  revoked,
+		},
+	})
+	return job, nil
+}
+
+// CancelTenantDeletion stops a deletion during its grace period. The tenant stays
+// suspended until an operator resumes it.
+func (s *Service) CancelTenantDeletion(ctx context.Context, adminID, tenantID uuid.UUID) error {
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		id := pgtype.UUID{Bytes: tenantID, Valid: true}
+		n, err := q.CancelTenantOffboarding(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+		// Nothing cancelled: either never scheduled, or the worker already started
+		if _, err := q.GetTenantOffboarding(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoDeletion
+		} else if err != nil {
+			return err
+		}
+		return ErrDeletionStarted
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventPlatformDeletionCancelled, audit.LogParams{
+		TargetID: tenantID,
+		TenantID: tenantID,
+		Metadata: map[string]interface{}{
+			"platform_admin_id": adminID.String(),
+		},
+	})
+	return nil
+}
+
+// GetTenantDeletion returns the offboarding job of a tenant, including its progress.
+// Completed jobs remain readable after the tenant itself is gone.
+func (s *Service) GetTenantDeletion(ctx context.Context, tenantID uuid.UUID) (db.TenantOffboarding, error) {
+	var job db.TenantOffboarding
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		var err error
+		job, err = q.GetTenantOffboarding(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoDeletion
+		}
+		return err
+	})
+	return job, err
+}
+
+// ListTenantDeletions returns the most recent offboarding jobs.
+func (s *Service) ListTenantDeletions(ctx context.Context, limit int32) ([]db.TenantOffboarding, error) {
+	var jobs []db.TenantOffboarding
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		var err error
+		jobs, err = q.ListTenantOffboardings(ctx, limit)
+		return err
+	})
+	return jobs, err
+}
+
+// ImpersonationInput identifies who to act as and why.
+type ImpersonationInput struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+	Reason   string
+}
+
+// Impersonation is a short-lived, non-refreshable access token.
+type Impersonation struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	Role        string
+}
+
+// Impersonate issues an access token for a member of an active tenant. The token
+// carries an act claim, so every action taken with it is audited with the operator.
+func (s *Service) Impersonate(ctx context.Context, adminID uuid.UUID, input ImpersonationInput) (*Impersonation, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	var role string
+	var perms []string
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		tenant, err := q.GetTenantByID(ctx, pgtype.UUID{Bytes: input.TenantID, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTenantNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !tenant.IsActive {
+			return auth.ErrTenantSuspended
+		}
+
+		role, err = q.GetMembership(ctx, db.GetMembershipParams{
+			UserID:   pgtype.UUID{Bytes: input.UserID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: input.TenantID, Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotAMember
+		}
+		if err != nil {
+			return err
+		}
+
+		perms, err = permissions.NewResolver(q).Resolve(ctx, input.TenantID, role)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokens.GenerateImpersonationToken(input.UserID, input.TenantID, role, perms, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("token generation failed: %w", err)
+	}
+	expiresAt := time.Now().Add(auth.ImpersonationTokenDuration)
+
+	s.audit.Log(ctx, audit.EventPlatformImpersonate, audit.LogParams{
+		TargetID: input.UserID,
+		TenantID: input.TenantID,
+		Metadata: map[string]interface{}{
+			"platform_admin_id": adminID.String(),
+			"reason":            reason,
+			"role":              role,
+			"expires_at":        expiresAt.UTC().Format(time.RFC3339),
+		},
+	})
+
+	return &Impersonation{AccessToken: token, ExpiresAt: expiresAt, Role: role}, nil
+}
+
+// Stats returns platform-wide counters.
+func (s *Service) Stats(ctx context.Context) (db.GetPlatformStatsRow, error) {
+	var stats db.GetPlatformStatsRow
+	err := s.withoutRLS(ctx, func(q *db.Queries) error {
+		var err error
+		stats, err = q.GetPlatformStats(ctx)
+		return err
+	})
+	return stats, err
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryBucket struct {
+	windowStart time.Time
+	expiresAt   time.Time
+	hits        int
+}
+
+// MemoryStore keeps counters in process memory.
+// Counters are NOT shared between replicas; use PostgresStore in production.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryStore creates an in-memory store and starts a sweeper that evicts
+// buckets whose window has passed (instead of wiping every counter at once).
+func NewMemoryStore(sweepInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{buckets: make(map[string]*memoryBucket)}
+	if sweepInterval > 0 {
+		go s.sweepLoop(sweepInterval)
+	}
+	return s
+}
+
+// Increment implements Store.
+func (s *MemoryStore) Increment(_ context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok || !b.windowStart.Equal(windowStart) {
+		b = &memoryBucket{windowStart: windowStart, expiresAt: windowStart.Add(window)}
+		s.buckets[key] = b
+	}
+	b.hits++
+	return b.hits, nil
+}
+
+// Count implements Store.
+func (s *MemoryStore) Count(_ context.Context, key string, windowStart time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok || !b.windowStart.Equal(windowStart) {
+		return 0, nil
+	}
+	return b.hits, nil
+}
+
+// Sweep removes expired buckets. Exposed for tests; normally driven by sweepLoop.
+func (s *MemoryStore) Sweep(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, b := range s.buckets {
+		if !b.expiresAt.After(now) {
+			delete(s.buckets, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (s *MemoryStore) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.Sweep(now)
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import "time"
+
+// Route group names used by the router. Each group maps to one or more
+// policies in DefaultPolicies; a request must satisfy all of them.
+const (
+	GroupGlobal   = "global"
+	GroupLogin    = "auth.login"
+	GroupRegister = "auth.register"
+	GroupRecovery = "auth.recovery"
+	GroupMFA      = "auth.mfa"
+	GroupIoT      = "iot"
+	GroupSession  = "session"
+	GroupAdmin    = "admin"
+
+	GroupPlatformLogin = "platform.login"
+	GroupDataExport    = "data_export"
+)
+
+// DefaultPolicies is the declarative policy table.
+// Anti-Gravity Law: "Credential endpoints get the tightest budgets."
+// Email-keyed policies stop distributed credential stuffing against one account,
+// IP-keyed policies stop one host from spraying many accounts.
+var DefaultPolicies = map[string][]Policy{
+	GroupGlobal: {
+		{Name: "global.ip", Key: KeyIP, Limit: 1500, Window: time.Minute},
+	},
+	GroupLogin: {
+		{Name: "login.ip", Key: KeyIP, Limit: 20, Window: time.Minute},
+		{Name: "login.email", Key: KeyEmail, Limit: 10, Window: 15 * time.Minute, NotifyOwner: true},
+	},
+	GroupRegister: {
+		{Name: "register.ip", Key: KeyIP, Limit: 10, Window: time.Hour},
+	},
+	GroupRecovery: {
+		{Name: "recovery.ip", Key: KeyIP, Limit: 10, Window: 15 * time.Minute},
+		{Name: "recovery.email", Key: KeyEmail, Limit: 3, Window: 15 * time.Minute},
+	},
+	GroupMFA: {
+		{Name: "mfa.ip", Key: KeyIP, Limit: 10, Window: 5 * time.Minute},
+	},
+	GroupIoT: {
+		{Name: "iot.ip", Key: KeyIP, Limit: 600, Window: time.Minute},
+	},
+	GroupSession: {
+		{Name: "session.user", Key: KeyUser, Limit: 600, Window: time.Minute},
+	},
+	GroupAdmin: {
+		{Name: "admin.tenant", Key: KeyTenant, Limit: 1200, Window: time.Minute},
+	},
+	// Operators are few; anything beyond a handful of attempts is an attack.
+	GroupPlatformLogin: {
+		{Name: "platform.login.ip", Key: KeyIP, Limit: 5, Window: time.Minute},
+		{Name: "platform.login.email", Key: KeyEmail, Limit: 5, Window: 15 * time.Minute},
+	},
+	// Signed download links are unguessable; this only stops link-probing and hammering.
+	GroupDataExport: {
+		{Name: "data_export.ip", Key: KeyIP, Limit: 20, Window: time.Minute},
+	},
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PostgresStore shares counters between replicas via the rate_limit_buckets
+// table (UPSERT per request). Expired buckets are removed by the janitor worker.
+type PostgresStore struct {
+	queries *db.Queries
+}
+
+func NewPostgresStore(queries *db.Queries) *PostgresStore {
+	return &PostgresStore{queries: queries}
+}
+
+// Increment implements Store.
+func (s *PostgresStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	hits, err := s.queries.IncrementRateLimitBucket(ctx, db.IncrementRateLimitBucketParams{
+		BucketKey:   key,
+		WindowStart: pgtype.Timestamptz{Time: windowStart, Valid: true},
+		ExpiresAt:   pgtype.Timestamptz{Time: windowStart.Add(window), Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(hits), nil
+}
+
+// Count implements Store.
+func (s *PostgresStore) Count(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	hits, err := s.queries.GetRateLimitBucketHits(ctx, db.GetRateLimitBucketHitsParams{
+		BucketKey:   key,
+		WindowStart: pgtype.Timestamptz{Time: windowStart, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(hits), nil
+}
+
+// DBRecorder writes limit hits to rate_limit_events.
+type DBRecorder struct {
+	queries *db.Queries
+}
+
+func NewDBRecorder(queries *db.Queries) *DBRecorder {
+	return &DBRecorder{queries: queries}
+}
+
+// RecordHit implements Recorder.
+func (r *DBRecorder) RecordHit(ctx context.Context, hit Hit) error {
+	var ip *netip.Addr
+	if addr, ok := netip.AddrFromSlice(hit.IP); ok {
+		addr = addr.Unmap()
+		ip = &addr
+	}
+
+	return r.queries.CreateRateLimitEvent(ctx, db.CreateRateLimitEventParams{
+		TenantID:    pgtype.UUID{Bytes: hit.TenantID, Valid: hit.TenantID != uuid.Nil},
+		Policy:      hit.Policy,
+		KeyType:     string(hit.KeyType),
+		SubjectHash: hit.SubjectHash,
+		IpAddress:   ip,
+		Path:        hit.Path,
+	})
+}
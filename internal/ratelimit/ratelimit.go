@@ -0,0 +1,134 @@
+// Package ratelimit implements fixed-window rate limiting with pluggable
+// counter backends. The in-memory store is meant for single-node development;
+// the Postgres store shares counters between all API replicas.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KeyType selects which request attribute a policy counts against.
+type KeyType string
+
+const (
+	KeyIP     KeyType = "ip"
+	KeyEmail  KeyType = "email"
+	KeyTenant KeyType = "tenant"
+	KeyUser   KeyType = "user"
+)
+
+// Policy declares a single budget: at most Limit requests per Window,
+// counted per distinct value of Key.
+// NotifyOwner emails the account behind an email-keyed subject once the
+// policy starts rejecting it (login only).
+type Policy struct {
+	Name        string
+	Key         KeyType
+	Limit       int
+	Window      time.Duration
+	NotifyOwner bool
+}
+
+// Store is the counter backend.
+// Increment atomically adds one hit to the bucket identified by key for the
+// window starting at windowStart and returns the new hit count.
+// Count returns the current hit count without adding one (0 if the bucket is absent).
+type Store interface {
+	Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error)
+	Count(ctx context.Context, key string, windowStart time.Time) (int, error)
+}
+
+// Hit describes a request that exceeded a policy. It is handed to a Recorder
+// so tenant admins can see who is being throttled.
+type Hit struct {
+	Policy      string
+	KeyType     KeyType
+	SubjectHash string
+	TenantID    uuid.UUID // uuid.Nil for requests without tenant context
+	IP          net.IP
+	Path        string
+}
+
+// Recorder persists limit hits for the admin dashboard.
+type Recorder interface {
+	RecordHit(ctx context.Context, hit Hit) error
+}
+
+// Decision is the outcome of evaluating one policy for one subject.
+type Decision struct {
+	Policy    Policy
+	Allowed   bool
+	Count     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// RetryAfter returns how long the client should wait before retrying.
+func (d Decision) RetryAfter(now time.Time) time.Duration {
+	if d.ResetAt.Before(now) {
+		return 0
+	}
+	return d.ResetAt.Sub(now)
+}
+
+// FirstRejection reports whether this decision is the first rejection in the
+// current window. Only that one is recorded, so a flood of blocked requests
+// costs a single audit row instead of one write per request.
+func (d Decision) FirstRejection() bool {
+	return !d.Allowed && d.Count == d.Policy.Limit+1
+}
+
+// Limiter evaluates policies against a Store.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewLimiter creates a Limiter backed by the given store.
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow counts one request for subject under policy p.
+// The subject is hashed before it reaches the store so raw emails and IPs are
+// never persisted in the bucket table.
+func (l *Limiter) Allow(ctx context.Context, p Policy, subject string) (Decision, error) {
+	now := l.now()
+	windowStart := now.Truncate(p.Window)
+
+	count, err := l.store.Increment(ctx, BucketKey(p, subject), windowStart, p.Window)
+	if err != nil {
+		return Decision{}, fmt.Errorf("rate limit store: %w", err)
+	}
+
+	remaining := p.Limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return Decision{
+		Policy:    p,
+		Allowed:   count <= p.Limit,
+		Count:     count,
+		Remaining: remaining,
+		ResetAt:   windowStart.Add(p.Window),
+	}, nil
+}
+
+// BucketKey builds the storage key for a policy/subject pair.
+func BucketKey(p Policy, subject string) string {
+	return p.Name + ":" + string(p.Key) + ":" + HashSubject(subject)
+}
+
+// HashSubject pseudonymises a rate limit subject (IP, email, ID).
+func HashSubject(subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,51 @@
+// Package requestmeta carries per-request metadata (request ID, client IP,
+// user agent, actor, tenant) through the context.
+//
+// HTTP middleware fills it; packages that must not import the api layer
+// (audit, notify) read it. Values are copied on every With* call, so a
+// context derived further down never changes what its parent sees.
+package requestmeta
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/google/uuid"
+)
+
+// Metadata describes the request a context belongs to. Zero values mean unknown,
+// e.g. for background jobs in cmd/worker.
+type Metadata struct {
+	RequestID string
+	IP        netip.Addr
+	UserAgent string
+	ActorID   uuid.UUID // Authenticated user, set by AuthMiddleware
+	TenantID  uuid.UUID // Resolved (or token-derived) tenant
+}
+
+type metadataKey struct{}
+
+// With stores m in ctx, replacing any metadata already present.
+func With(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+// From returns the metadata of ctx; ok is false outside an HTTP request.
+func From(ctx context.Context) (Metadata, bool) {
+	m, ok := ctx.Value(metadataKey{}).(Metadata)
+	return m, ok
+}
+
+// WithActor records the authenticated user of the request.
+func WithActor(ctx context.Context, actorID uuid.UUID) context.Context {
+	m, _ := From(ctx)
+	m.ActorID = actorID
+	return With(ctx, m)
+}
+
+// WithTenant records the tenant the request operates on.
+func WithTenant(ctx context.Context, tenantID uuid.UUID) context.Context {
+	m, _ := From(ctx)
+	m.TenantID = tenantID
+	return With(ctx, m)
+}
@@ -2,14 +2,32 @@ package storage
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"slices"
 	"strings"
 )
 
+// Supported CORS origin patterns:
+//
+//	https://app.example.com      exact match
+//	https://*.example.com        exactly one subdomain label of example.com
+//	http://localhost:3000        development only (see MatchCORSOrigin)
+//	http://localhost:*           development only, any port
+const wildcardPrefix = "*."
+
+// AllowedCORSMethods is the set of methods a tenant may expose to browsers.
+var AllowedCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// MaxCORSMaxAge caps preflight caching (Chromium caps at 2h anyway).
+const MaxCORSMaxAge = 86400
+
 // ValidateCORSOrigins validates that CORS origins are secure.
 // Rejects wildcard (*) origins and enforces HTTPS-only (except localhost).
 //
 // ✅ SECURE: Prevents wildcard CORS misconfiguration
 // ✅ SECURE: Enforces TLS for production origins
+// ✅ SECURE: Subdomain wildcards only below a registrable domain (no https://*.com)
 //
 // This should be called when updating tenant configuration via admin endpoints.
 func ValidateCORSOrigins(origins []string) error {
@@ -19,16 +37,104 @@ func ValidateCORSOrigins(origins []string) error {
 			return errors.New("wildcard CORS origin not allowed")
 		}
 
+		// Additional validation: Ensure valid URL format
+		if origin == "" || strings.Contains(origin, " ") {
+			return errors.New("invalid origin format")
+		}
+
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || host == "" || strings.ContainsAny(host, "/?#@ ") {
+			return fmt.Errorf("invalid origin format: %s", origin)
+		}
+
+		if isLocalhostHost(host) {
+			// Localhost is allowed over http, but only ever matches in development.
+			if scheme != "http" && scheme != "https" {
+				return fmt.Errorf("invalid origin scheme: %s", origin)
+			}
+			continue
+		}
+
 		// ❌ REJECT: HTTP (except localhost for development)
-		if !strings.HasPrefix(origin, "https://") && !strings.HasPrefix(origin, "http://localhost") {
+		if scheme != "https" {
 			return errors.New("only HTTPS origins allowed (except http://localhost for development)")
 		}
 
-		// Additional validation: Ensure valid URL format
-		if origin == "" || strings.Contains(origin, " ") {
-			return errors.New("invalid origin format")
+		if strings.Contains(host, "*") {
+			suffix, isWildcard := strings.CutPrefix(host, wildcardPrefix)
+			// Wildcard only as the complete first label, below a domain with at least two labels
+			if !isWildcard || strings.Contains(suffix, "*") || strings.Count(hostname(suffix), ".") < 1 {
+				return fmt.Errorf("invalid wildcard origin (use https://*.example.com): %s", origin)
+			}
 		}
 	}
 
 	return nil
 }
+
+// ValidateCORSPolicy validates the per-tenant preflight settings.
+func ValidateCORSPolicy(headers, methods []string, maxAge int) error {
+	for _, m := range methods {
+		if !slices.Contains(AllowedCORSMethods, strings.ToUpper(m)) {
+			return fmt.Errorf("unsupported CORS method: %s", m)
+		}
+	}
+	for _, h := range headers {
+		if h == "" || h == "*" || strings.ContainsAny(h, " ,:\t\r\n") {
+			return fmt.Errorf("invalid CORS header: %q", h)
+		}
+	}
+	if maxAge < 0 || maxAge > MaxCORSMaxAge {
+		return fmt.Errorf("max_age must be between 0 and %d", MaxCORSMaxAge)
+	}
+	return nil
+}
+
+// MatchCORSOrigin reports whether a request Origin satisfies an allowed-origin pattern.
+// Localhost patterns only match when allowLocalhost is set (development environments).
+func MatchCORSOrigin(pattern, origin string, allowLocalhost bool) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "/"))
+	origin = strings.ToLower(origin)
+
+	pScheme, pHost, ok := strings.Cut(pattern, "://")
+	if !ok {
+		return false
+	}
+	oScheme, oHost, ok := strings.Cut(origin, "://")
+	if !ok || pScheme != oScheme {
+		return false
+	}
+
+	if isLocalhostHost(pHost) || isLocalhostHost(oHost) {
+		if !allowLocalhost {
+			return false
+		}
+		if p, isAnyPort := strings.CutSuffix(pHost, ":*"); isAnyPort {
+			return hostname(oHost) == p
+		}
+		return pHost == oHost
+	}
+
+	if suffix, isWildcard := strings.CutPrefix(pHost, wildcardPrefix); isWildcard {
+		label, found := strings.CutSuffix(oHost, "."+suffix)
+		return found && label != "" && !strings.ContainsAny(label, ".:")
+	}
+
+	return pHost == oHost
+}
+
+func isLocalhostHost(host string) bool {
+	switch hostname(strings.TrimSuffix(host, ":*")) {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// hostname strips an optional port from host (handles [::1]:3000).
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.Trim(host, "[]")
+}
@@ -0,0 +1,58 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCORSOrigins(t *testing.T) {
+	valid := [][]string{
+		{"https://app.example.com"},
+		{"https://*.example.com"},
+		{"https://*.example.co.uk:8443"},
+		{"http://localhost:3000", "http://localhost:*"},
+	}
+	for _, origins := range valid {
+		assert.NoError(t, storage.ValidateCORSOrigins(origins), origins)
+	}
+
+	invalid := [][]string{
+		{"*"},
+		{"http://app.example.com"},
+		{"https://*"},
+		{"https://*.com"},
+		{"https://a.*.example.com"},
+		{"https://*example.com"},
+		{"https://app.example.com/path"},
+	}
+	for _, origins := range invalid {
+		assert.Error(t, storage.ValidateCORSOrigins(origins), origins)
+	}
+}
+
+func TestMatchCORSOrigin(t *testing.T) {
+	cases := []struct {
+		pattern, origin string
+		allowLocalhost  bool
+		want            bool
+	}{
+		{"https://app.example.com", "https://app.example.com", false, true},
+		{"https://app.example.com", "https://APP.example.com", false, true},
+		{"https://app.example.com", "http://app.example.com", false, false},
+		{"https://*.example.com", "https://tenant.example.com", false, true},
+		{"https://*.example.com", "https://example.com", false, false},
+		{"https://*.example.com", "https://a.b.example.com", false, false},
+		{"https://*.example.com", "https://evil-example.com", false, false},
+		{"https://*.example.com", "https://tenant.example.com.evil.io", false, false},
+		{"http://localhost:3000", "http://localhost:3000", true, true},
+		{"http://localhost:3000", "http://localhost:3000", false, false},
+		{"http://localhost:*", "http://localhost:4321", true, true},
+		{"http://localhost:*", "http://localhost:4321", false, false},
+	}
+	for _, c := range cases {
+		got := storage.MatchCORSOrigin(c.pattern, c.origin, c.allowLocalhost)
+		assert.Equal(t, c.want, got, "%s vs %s (localhost=%v)", c.pattern, c.origin, c.allowLocalhost)
+	}
+}
@@ -12,8 +12,58 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countAuditLogsInRange = `-- name: CountAuditLogsInRange :one
+SELECT COUNT(*) FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = $1
+  AND timestamp >= $2 AND timestamp < $3
+`
+
+type CountAuditLogsInRangeParams struct {
+	ChainKey pgtype.UUID
+	Since    pgtype.Timestamptz
+	Until    pgtype.Timestamptz
+}
+
+func (q *Queries) CountAuditLogsInRange(ctx context.Context, arg CountAuditLogsInRangeParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAuditLogsInRange, arg.ChainKey, arg.Since, arg.Until)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnchainedAuditLogs = `-- name: CountUnchainedAuditLogs :one
+SELECT COUNT(*) FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = $1
+  AND seq IS NULL
+`
+
+// Rows from before the hash chain existed (migration 025).
+func (q *Queries) CountUnchainedAuditLogs(ctx context.Context, chainKey pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnchainedAuditLogs, chainKey)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAuditLog = `-- name: CreateAuditLog :exec
+WITH head AS (
+    INSERT INTO audit_chain_heads AS h (chain_key, last_seq, prev_hash, last_hash)
+    VALUES (
+        $1::UUID,
+        1,
+        decode(repeat('00', 32), 'hex'),
+        sha256(decode(repeat('00', 32), 'hex') || int8send(1::BIGINT) || $2::BYTEA)
+    )
+    ON CONFLICT (chain_key) DO UPDATE
+    SET last_seq = h.last_seq + 1,
+        prev_hash = h.last_hash,
+        last_hash = sha256(h.last_hash || int8send(h.last_seq + 1) || $2::BYTEA),
+        updated_at = NOW()
+    RETURNING last_seq, prev_hash, last_hash
+)
 INSERT INTO audit_logs (
+    id,
+    timestamp,
     actor_id, 
     session_id, 
     tenant_id, 
@@ -22,13 +72,34 @@ INSERT INTO audit_logs (
     metadata, 
     ip_address, 
     user_agent, 
-    request_id
-) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 )
+SELECT
+    $3::UUID,
+    $4::TIMESTAMPTZ,
+    $5::UUID,
+    $6::UUID,
+    $7::UUID,
+    $8::VARCHAR,
+    $9::UUID,
+    $10::JSONB,
+    $11::INET,
+    $12::TEXT,
+    $13::TEXT,
+    head.last_seq,
+    head.prev_hash,
+    head.last_hash
+FROM head
 `
 
 type CreateAuditLogParams struct {
+	ChainKey  pgtype.UUID
+	Payload   []byte
+	ID        pgtype.UUID
+	Timestamp pgtype.Timestamptz
 	ActorID   pgtype.UUID
 	SessionID pgtype.UUID
 	TenantID  pgtype.UUID
@@ -40,8 +111,16 @@ type CreateAuditLogParams struct {
 	RequestID pgtype.Text
 }
 
+// Appends the row to its tenant's hash chain: the head row is advanced (and
+// locked) and the row inserted in one statement. row_hash is
+// sha256(prev_hash || int8send(seq) || payload); payload is the canonical
+// encoding of the row built by audit.ChainRecord.
 func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
 	_, err := q.db.Exec(ctx, createAuditLog,
+		arg.ChainKey,
+		arg.Payload,
+		arg.ID,
+		arg.Timestamp,
 		arg.ActorID,
 		arg.SessionID,
 		arg.TenantID,
@@ -55,8 +134,133 @@ func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams)
 	return err
 }
 
+type CreateAuditLogsParams struct {
+	ID        pgtype.UUID
+	Timestamp pgtype.Timestamptz
+	ActorID   pgtype.UUID
+	SessionID pgtype.UUID
+	TenantID  pgtype.UUID
+	Action    string
+	TargetID  pgtype.UUID
+	Metadata  []byte
+	IpAddress *netip.Addr
+	UserAgent pgtype.Text
+	RequestID pgtype.Text
+	Seq       pgtype.Int8
+	PrevHash  []byte
+	RowHash   []byte
+}
+
+const listAuditChainRows = `-- name: ListAuditChainRows :many
+SELECT id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata, ip_address, user_agent, request_id, seq, prev_hash, row_hash FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = $1
+  AND seq > $2
+ORDER BY seq
+LIMIT $3
+`
+
+type ListAuditChainRowsParams struct {
+	ChainKey pgtype.UUID
+	AfterSeq int64
+	RowLimit int32
+}
+
+// One page of a hash chain in chain order, for control audit-verify.
+func (q *Queries) ListAuditChainRows(ctx context.Context, arg ListAuditChainRowsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditChainRows, arg.ChainKey, arg.AfterSeq, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Timestamp,
+			&i.ActorID,
+			&i.SessionID,
+			&i.TenantID,
+			&i.Action,
+			&i.TargetID,
+			&i.Metadata,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.RowHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogChainKeys = `-- name: ListAuditLogChainKeys :many
+SELECT DISTINCT COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID)::UUID
+FROM audit_logs
+WHERE timestamp >= $1 AND timestamp < $2
+`
+
+type ListAuditLogChainKeysParams struct {
+	Since pgtype.Timestamptz
+	Until pgtype.Timestamptz
+}
+
+// Chains with rows in [since, until).
+func (q *Queries) ListAuditLogChainKeys(ctx context.Context, arg ListAuditLogChainKeysParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listAuditLogChainKeys, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var column_1 pgtype.UUID
+		if err := rows.Scan(&column_1); err != nil {
+			return nil, err
+		}
+		items = append(items, column_1)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogIDs = `-- name: ListAuditLogIDs :many
+SELECT id FROM audit_logs
+WHERE id = ANY($1::UUID[])
+`
+
+// Which of the given rows exist; spool replay skips rows already written.
+func (q *Queries) ListAuditLogIDs(ctx context.Context, ids []pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listAuditLogIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAuditLogsByTenant = `-- name: ListAuditLogsByTenant :many
-SELECT id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata, ip_address, user_agent, request_id FROM audit_logs
+SELECT id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata, ip_address, user_agent, request_id, seq, prev_hash, row_hash FROM audit_logs
 WHERE tenant_id = $1
 ORDER BY timestamp DESC
 LIMIT $2 OFFSET $3
@@ -89,6 +293,9 @@ func (q *Queries) ListAuditLogsByTenant(ctx context.Context, arg ListAuditLogsBy
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.RequestID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.RowHash,
 		); err != nil {
 			return nil, err
 		}
@@ -101,7 +308,7 @@ func (q *Queries) ListAuditLogsByTenant(ctx context.Context, arg ListAuditLogsBy
 }
 
 const listAuditLogsByUser = `-- name: ListAuditLogsByUser :many
-SELECT id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata, ip_address, user_agent, request_id FROM audit_logs
+SELECT id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata, ip_address, user_agent, request_id, seq, prev_hash, row_hash FROM audit_logs
 WHERE actor_id = $1
 ORDER BY timestamp DESC
 LIMIT $2 OFFSET $3
@@ -134,6 +341,71 @@ func (q *Queries) ListAuditLogsByUser(ctx context.Context, arg ListAuditLogsByUs
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.RequestID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.RowHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogsForArchive = `-- name: ListAuditLogsForArchive :many
+SELECT id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata, ip_address, user_agent, request_id, seq, prev_hash, row_hash FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = $1
+  AND timestamp >= $2 AND timestamp < $3
+  AND (COALESCE(seq, 0), id) > ($4::BIGINT, $5::UUID)
+ORDER BY COALESCE(seq, 0), id
+LIMIT $6
+`
+
+type ListAuditLogsForArchiveParams struct {
+	ChainKey pgtype.UUID
+	Since    pgtype.Timestamptz
+	Until    pgtype.Timestamptz
+	AfterSeq int64
+	AfterID  pgtype.UUID
+	RowLimit int32
+}
+
+// One page of a chain within [since, until): unchained legacy rows first, then
+// chain order. Pass the (seq, id) of the last row read; seq 0 for unchained rows.
+func (q *Queries) ListAuditLogsForArchive(ctx context.Context, arg ListAuditLogsForArchiveParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditLogsForArchive,
+		arg.ChainKey,
+		arg.Since,
+		arg.Until,
+		arg.AfterSeq,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Timestamp,
+			&i.ActorID,
+			&i.SessionID,
+			&i.TenantID,
+			&i.Action,
+			&i.TargetID,
+			&i.Metadata,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.RowHash,
 		); err != nil {
 			return nil, err
 		}
@@ -7,24 +7,12 @@ package db
 
 import (
 	"context"
+	"net/netip"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const countAuditLogs = `-- name: CountAuditLogs :one
-SELECT COUNT(*) as total
-FROM audit_logs
-WHERE tenant_id = $1
-`
-
-func (q *Queries) CountAuditLogs(ctx context.Context, tenantID pgtype.UUID) (int64, error) {
-	row := q.db.QueryRow(ctx, countAuditLogs, tenantID)
-	var total int64
-	err := row.Scan(&total)
-	return total, err
-}
-
-const getAuditLogsByAction = `-- name: GetAuditLogsByAction :many
+const exportAuditLogs = `-- name: ExportAuditLogs :many
 SELECT 
     id,
     timestamp,
@@ -36,26 +24,40 @@ SELECT
     metadata,
     ip_address,
     user_agent,
-    request_id
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 FROM audit_logs
-WHERE tenant_id = $1 AND action = $2
-ORDER BY timestamp DESC
-LIMIT $3 OFFSET $4
+WHERE tenant_id = $1
+  AND timestamp >= $2
+  AND timestamp < $3
+  AND ($4::TEXT IS NULL OR action LIKE $4)
+  AND ($5::TIMESTAMPTZ IS NULL OR (timestamp, id) > ($5, $6::UUID))
+ORDER BY timestamp, id
+LIMIT $7
 `
 
-type GetAuditLogsByActionParams struct {
-	TenantID pgtype.UUID
-	Action   string
-	Limit    int32
-	Offset   int32
+type ExportAuditLogsParams struct {
+	TenantID      pgtype.UUID
+	Since         pgtype.Timestamptz
+	Until         pgtype.Timestamptz
+	ActionPattern pgtype.Text
+	CursorTs      pgtype.Timestamptz
+	CursorID      pgtype.UUID
+	RowLimit      int32
 }
 
-func (q *Queries) GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByActionParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByAction,
+// Oldest first after a keyset cursor, for the bulk export of a time range.
+func (q *Queries) ExportAuditLogs(ctx context.Context, arg ExportAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, exportAuditLogs,
 		arg.TenantID,
-		arg.Action,
-		arg.Limit,
-		arg.Offset,
+		arg.Since,
+		arg.Until,
+		arg.ActionPattern,
+		arg.CursorTs,
+		arg.CursorID,
+		arg.RowLimit,
 	)
 	if err != nil {
 		return nil, err
@@ -76,6 +78,9 @@ func (q *Queries) GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByAc
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.RequestID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.RowHash,
 		); err != nil {
 			return nil, err
 		}
@@ -87,7 +92,7 @@ func (q *Queries) GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByAc
 	return items, nil
 }
 
-const getAuditLogsByUser = `-- name: GetAuditLogsByUser :many
+const listUserActivity = `-- name: ListUserActivity :many
 SELECT 
     id,
     timestamp,
@@ -99,26 +104,43 @@ SELECT
     metadata,
     ip_address,
     user_agent,
-    request_id
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 FROM audit_logs
-WHERE tenant_id = $1 AND actor_id = $2
-ORDER BY timestamp DESC
-LIMIT $3 OFFSET $4
+WHERE tenant_id = $1
+  AND (actor_id = $2 OR target_id = $2)
+  AND ($3::TEXT IS NULL OR action LIKE $3)
+  AND ($4::TIMESTAMPTZ IS NULL OR timestamp >= $4)
+  AND ($5::TIMESTAMPTZ IS NULL OR timestamp < $5)
+  AND ($6::TIMESTAMPTZ IS NULL OR (timestamp, id) < ($6, $7::UUID))
+ORDER BY timestamp DESC, id DESC
+LIMIT $8
 `
 
-type GetAuditLogsByUserParams struct {
-	TenantID pgtype.UUID
-	ActorID  pgtype.UUID
-	Limit    int32
-	Offset   int32
+type ListUserActivityParams struct {
+	TenantID      pgtype.UUID
+	UserID        pgtype.UUID
+	ActionPattern pgtype.Text
+	Since         pgtype.Timestamptz
+	Until         pgtype.Timestamptz
+	CursorTs      pgtype.Timestamptz
+	CursorID      pgtype.UUID
+	RowLimit      int32
 }
 
-func (q *Queries) GetAuditLogsByUser(ctx context.Context, arg GetAuditLogsByUserParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByUser,
+// Events by or about one user (GET /auth/activity).
+func (q *Queries) ListUserActivity(ctx context.Context, arg ListUserActivityParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listUserActivity,
 		arg.TenantID,
-		arg.ActorID,
-		arg.Limit,
-		arg.Offset,
+		arg.UserID,
+		arg.ActionPattern,
+		arg.Since,
+		arg.Until,
+		arg.CursorTs,
+		arg.CursorID,
+		arg.RowLimit,
 	)
 	if err != nil {
 		return nil, err
@@ -139,6 +161,9 @@ func (q *Queries) GetAuditLogsByUser(ctx context.Context, arg GetAuditLogsByUser
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.RequestID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.RowHash,
 		); err != nil {
 			return nil, err
 		}
@@ -150,7 +175,7 @@ func (q *Queries) GetAuditLogsByUser(ctx context.Context, arg GetAuditLogsByUser
 	return items, nil
 }
 
-const listAuditLogs = `-- name: ListAuditLogs :many
+const searchAuditLogs = `-- name: SearchAuditLogs :many
 
 SELECT 
     id,
@@ -163,23 +188,56 @@ SELECT
     metadata,
     ip_address,
     user_agent,
-    request_id
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 FROM audit_logs
 WHERE tenant_id = $1
-ORDER BY timestamp DESC
-LIMIT $2 OFFSET $3
+  AND ($2::TEXT IS NULL OR action LIKE $2)
+  AND ($3::UUID IS NULL OR actor_id = $3)
+  AND ($4::UUID IS NULL OR target_id = $4)
+  AND ($5::TIMESTAMPTZ IS NULL OR timestamp >= $5)
+  AND ($6::TIMESTAMPTZ IS NULL OR timestamp < $6)
+  AND ($7::CIDR IS NULL OR ip_address <<= $7)
+  AND ($8::TEXT[] IS NULL OR metadata ?& $8)
+  AND ($9::TIMESTAMPTZ IS NULL OR (timestamp, id) < ($9, $10::UUID))
+ORDER BY timestamp DESC, id DESC
+LIMIT $11
 `
 
-type ListAuditLogsParams struct {
-	TenantID pgtype.UUID
-	Limit    int32
-	Offset   int32
+type SearchAuditLogsParams struct {
+	TenantID      pgtype.UUID
+	ActionPattern pgtype.Text
+	ActorID       pgtype.UUID
+	TargetID      pgtype.UUID
+	Since         pgtype.Timestamptz
+	Until         pgtype.Timestamptz
+	IpRange       *netip.Prefix
+	MetadataKeys  []string
+	CursorTs      pgtype.Timestamptz
+	CursorID      pgtype.UUID
+	RowLimit      int32
 }
 
 // Audit Logs Queries
 // Read-only queries for audit log API (writes handled by audit service)
-func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, listAuditLogs, arg.TenantID, arg.Limit, arg.Offset)
+// Newest first with a keyset cursor: pass the (timestamp, id) of the last row
+// of the previous page. Filters are optional; NULL disables them.
+func (q *Queries) SearchAuditLogs(ctx context.Context, arg SearchAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, searchAuditLogs,
+		arg.TenantID,
+		arg.ActionPattern,
+		arg.ActorID,
+		arg.TargetID,
+		arg.Since,
+		arg.Until,
+		arg.IpRange,
+		arg.MetadataKeys,
+		arg.CursorTs,
+		arg.CursorID,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -199,6 +257,9 @@ func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.RequestID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.RowHash,
 		); err != nil {
 			return nil, err
 		}
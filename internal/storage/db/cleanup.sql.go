@@ -10,11 +10,12 @@ import (
 )
 
 const cleanExpiredInvitations = `-- name: CleanExpiredInvitations :execrows
-DELETE FROM invitations 
-WHERE expires_at < NOW()
+DELETE FROM invitations
+WHERE COALESCE(accepted_at, revoked_at, expires_at) < NOW() - INTERVAL '30 days'
 `
 
-// Uitnodigingen die nooit geaccepteerd zijn.
+// Verlopen, geaccepteerde en ingetrokken uitnodigingen blijven 30 dagen
+// zichtbaar in het admin-overzicht (GET /admin/invitations).
 func (q *Queries) CleanExpiredInvitations(ctx context.Context) (int64, error) {
 	result, err := q.db.Exec(ctx, cleanExpiredInvitations)
 	if err != nil {
@@ -23,6 +24,20 @@ func (q *Queries) CleanExpiredInvitations(ctx context.Context) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
+const cleanExpiredRateLimitBuckets = `-- name: CleanExpiredRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE expires_at < NOW()
+`
+
+// Rate limit tellers waarvan het venster voorbij is.
+func (q *Queries) CleanExpiredRateLimitBuckets(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, cleanExpiredRateLimitBuckets)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const cleanExpiredRefreshTokens = `-- name: CleanExpiredRefreshTokens :execrows
 DELETE FROM refresh_tokens 
 WHERE expires_at < NOW() 
@@ -52,6 +67,75 @@ func (q *Queries) CleanExpiredVerificationTokens(ctx context.Context) (int64, er
 	return result.RowsAffected(), nil
 }
 
+const cleanOldEmailLogs = `-- name: CleanOldEmailLogs :execrows
+DELETE FROM email_logs
+WHERE id IN (
+    SELECT id FROM email_logs
+    WHERE created_at < NOW() - make_interval(days => $1::INT)
+    LIMIT $2
+)
+`
+
+type CleanOldEmailLogsParams struct {
+	RetentionDays int32
+	BatchSize     int32
+}
+
+// Bezorglogs ouder dan EMAIL_LOG_RETENTION_DAYS. Per batch, zodat de eerste
+// run op een grote tabel geen lange lock houdt; de janitor herhaalt tot klaar.
+func (q *Queries) CleanOldEmailLogs(ctx context.Context, arg CleanOldEmailLogsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, cleanOldEmailLogs, arg.RetentionDays, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cleanOldRateLimitEvents = `-- name: CleanOldRateLimitEvents :execrows
+DELETE FROM rate_limit_events
+WHERE created_at < NOW() - INTERVAL '30 days'
+`
+
+// Rate limit hits ouder dan 30 dagen (dashboard toont alleen recente data).
+func (q *Queries) CleanOldRateLimitEvents(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, cleanOldRateLimitEvents)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cleanOldWebhookDeliveries = `-- name: CleanOldWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status IN ('delivered', 'failed')
+  AND created_at < NOW() - INTERVAL '30 days'
+`
+
+// Afgeronde webhook-bezorgingen (delivered/failed) ouder dan 30 dagen.
+func (q *Queries) CleanOldWebhookDeliveries(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, cleanOldWebhookDeliveries)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cleanSignupRequests = `-- name: CleanSignupRequests :execrows
+DELETE FROM signup_requests
+WHERE (status = 'pending' AND expires_at < NOW())
+   OR (status <> 'pending' AND decided_at < NOW() - INTERVAL '30 days')
+`
+
+// Onbevestigde domein-aanmeldingen na verloop van de link; beslist verzoeken
+// blijven 30 dagen zichtbaar in de goedkeuringslijst.
+func (q *Queries) CleanSignupRequests(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, cleanSignupRequests)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const cleanUsedMfaCodes = `-- name: CleanUsedMfaCodes :execrows
 DELETE FROM mfa_backup_codes 
 WHERE used = TRUE AND used_at < NOW() - INTERVAL '7 days'
@@ -9,6 +9,53 @@ import (
 	"context"
 )
 
+// iteratorForCreateAuditLogs implements pgx.CopyFromSource.
+type iteratorForCreateAuditLogs struct {
+	rows                 []CreateAuditLogsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateAuditLogs) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateAuditLogs) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ID,
+		r.rows[0].Timestamp,
+		r.rows[0].ActorID,
+		r.rows[0].SessionID,
+		r.rows[0].TenantID,
+		r.rows[0].Action,
+		r.rows[0].TargetID,
+		r.rows[0].Metadata,
+		r.rows[0].IpAddress,
+		r.rows[0].UserAgent,
+		r.rows[0].RequestID,
+		r.rows[0].Seq,
+		r.rows[0].PrevHash,
+		r.rows[0].RowHash,
+	}, nil
+}
+
+func (r iteratorForCreateAuditLogs) Err() error {
+	return nil
+}
+
+// Batch insert of audit.AsyncWriter. The caller computes seq and the hashes
+// while holding the chain head (LockAuditChainHead) in the same transaction.
+func (q *Queries) CreateAuditLogs(ctx context.Context, arg []CreateAuditLogsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"audit_logs"}, []string{"id", "timestamp", "actor_id", "session_id", "tenant_id", "action", "target_id", "metadata", "ip_address", "user_agent", "request_id", "seq", "prev_hash", "row_hash"}, &iteratorForCreateAuditLogs{rows: arg})
+}
+
 // iteratorForCreateBackupCodes implements pgx.CopyFromSource.
 type iteratorForCreateBackupCodes struct {
 	rows                 []CreateBackupCodesParams
@@ -41,3 +88,49 @@ func (r iteratorForCreateBackupCodes) Err() error {
 func (q *Queries) CreateBackupCodes(ctx context.Context, arg []CreateBackupCodesParams) (int64, error) {
 	return q.db.CopyFrom(ctx, []string{"mfa_backup_codes"}, []string{"user_id", "code_hash"}, &iteratorForCreateBackupCodes{rows: arg})
 }
+
+// iteratorForCreateRestoredAuditLogs implements pgx.CopyFromSource.
+type iteratorForCreateRestoredAuditLogs struct {
+	rows                 []CreateRestoredAuditLogsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateRestoredAuditLogs) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateRestoredAuditLogs) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ArchiveID,
+		r.rows[0].ID,
+		r.rows[0].Timestamp,
+		r.rows[0].ActorID,
+		r.rows[0].SessionID,
+		r.rows[0].TenantID,
+		r.rows[0].Action,
+		r.rows[0].TargetID,
+		r.rows[0].Metadata,
+		r.rows[0].IpAddress,
+		r.rows[0].UserAgent,
+		r.rows[0].RequestID,
+		r.rows[0].Seq,
+		r.rows[0].PrevHash,
+		r.rows[0].RowHash,
+	}, nil
+}
+
+func (r iteratorForCreateRestoredAuditLogs) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateRestoredAuditLogs(ctx context.Context, arg []CreateRestoredAuditLogsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"audit_logs_restored"}, []string{"archive_id", "id", "timestamp", "actor_id", "session_id", "tenant_id", "action", "target_id", "metadata", "ip_address", "user_agent", "request_id", "seq", "prev_hash", "row_hash"}, &iteratorForCreateRestoredAuditLogs{rows: arg})
+}
@@ -13,17 +13,20 @@ import (
 
 const createEmailChangeRequest = `-- name: CreateEmailChangeRequest :one
 INSERT INTO email_change_requests (
-    user_id, new_email, token_hash, expires_at
+    user_id, new_email, token_hash, expires_at, old_email, revert_token_hash, revert_expires_at
 ) VALUES (
-    $1, $2, $3, $4
-) RETURNING id, user_id, new_email, token_hash, used, used_at, expires_at, created_at
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, user_id, new_email, token_hash, used, used_at, expires_at, created_at, old_email, revert_token_hash, revert_expires_at, reverted_at
 `
 
 type CreateEmailChangeRequestParams struct {
-	UserID    pgtype.UUID
-	NewEmail  string
-	TokenHash string
-	ExpiresAt pgtype.Timestamptz
+	UserID          pgtype.UUID
+	NewEmail        string
+	TokenHash       string
+	ExpiresAt       pgtype.Timestamptz
+	OldEmail        pgtype.Text
+	RevertTokenHash pgtype.Text
+	RevertExpiresAt pgtype.Timestamptz
 }
 
 func (q *Queries) CreateEmailChangeRequest(ctx context.Context, arg CreateEmailChangeRequestParams) (EmailChangeRequest, error) {
@@ -32,6 +35,9 @@ func (q *Queries) CreateEmailChangeRequest(ctx context.Context, arg CreateEmailC
 		arg.NewEmail,
 		arg.TokenHash,
 		arg.ExpiresAt,
+		arg.OldEmail,
+		arg.RevertTokenHash,
+		arg.RevertExpiresAt,
 	)
 	var i EmailChangeRequest
 	err := row.Scan(
@@ -43,16 +49,22 @@ func (q *Queries) CreateEmailChangeRequest(ctx context.Context, arg CreateEmailC
 		&i.UsedAt,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.OldEmail,
+		&i.RevertTokenHash,
+		&i.RevertExpiresAt,
+		&i.RevertedAt,
 	)
 	return i, err
 }
 
 const getEmailChangeRequest = `-- name: GetEmailChangeRequest :one
-SELECT id, user_id, new_email, token_hash, used, used_at, expires_at, created_at FROM email_change_requests
-WHERE token_hash = $1 AND expires_at > NOW() AND used = FALSE
+SELECT id, user_id, new_email, token_hash, used, used_at, expires_at, created_at, old_email, revert_token_hash, revert_expires_at, reverted_at FROM email_change_requests
+WHERE token_hash = $1 AND expires_at > NOW() AND used = FALSE AND reverted_at IS NULL
 LIMIT 1
+FOR UPDATE
 `
 
+// Locks the request: confirm and revert must not race.
 func (q *Queries) GetEmailChangeRequest(ctx context.Context, tokenHash string) (EmailChangeRequest, error) {
 	row := q.db.QueryRow(ctx, getEmailChangeRequest, tokenHash)
 	var i EmailChangeRequest
@@ -65,10 +77,52 @@ func (q *Queries) GetEmailChangeRequest(ctx context.Context, tokenHash string) (
 		&i.UsedAt,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.OldEmail,
+		&i.RevertTokenHash,
+		&i.RevertExpiresAt,
+		&i.RevertedAt,
 	)
 	return i, err
 }
 
+const getEmailChangeRequestByRevertToken = `-- name: GetEmailChangeRequestByRevertToken :one
+SELECT id, user_id, new_email, token_hash, used, used_at, expires_at, created_at, old_email, revert_token_hash, revert_expires_at, reverted_at FROM email_change_requests
+WHERE revert_token_hash = $1 AND revert_expires_at > NOW() AND reverted_at IS NULL
+LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetEmailChangeRequestByRevertToken(ctx context.Context, revertTokenHash pgtype.Text) (EmailChangeRequest, error) {
+	row := q.db.QueryRow(ctx, getEmailChangeRequestByRevertToken, revertTokenHash)
+	var i EmailChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.Used,
+		&i.UsedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.OldEmail,
+		&i.RevertTokenHash,
+		&i.RevertExpiresAt,
+		&i.RevertedAt,
+	)
+	return i, err
+}
+
+const markEmailChangeRequestReverted = `-- name: MarkEmailChangeRequestReverted :exec
+UPDATE email_change_requests
+SET reverted_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkEmailChangeRequestReverted(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markEmailChangeRequestReverted, id)
+	return err
+}
+
 const markEmailChangeRequestUsed = `-- name: MarkEmailChangeRequestUsed :exec
 UPDATE email_change_requests
 SET used = TRUE, used_at = NOW()
@@ -82,16 +136,17 @@ func (q *Queries) MarkEmailChangeRequestUsed(ctx context.Context, id pgtype.UUID
 
 const updateUserEmail = `-- name: UpdateUserEmail :exec
 UPDATE users
-SET email = $2, is_email_verified = TRUE, updated_at = NOW()
+SET email = $2, is_email_verified = $3, updated_at = NOW()
 WHERE id = $1
 `
 
 type UpdateUserEmailParams struct {
-	ID    pgtype.UUID
-	Email string
+	ID              pgtype.UUID
+	Email           string
+	IsEmailVerified bool
 }
 
 func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) error {
-	_, err := q.db.Exec(ctx, updateUserEmail, arg.ID, arg.Email)
+	_, err := q.db.Exec(ctx, updateUserEmail, arg.ID, arg.Email, arg.IsEmailVerified)
 	return err
 }
@@ -13,7 +13,7 @@ import (
 
 const acceptInvitation = `-- name: AcceptInvitation :exec
 UPDATE invitations
-SET accepted = TRUE
+SET accepted = TRUE, accepted_at = NOW()
 WHERE id = $1
 `
 
@@ -22,12 +22,65 @@ func (q *Queries) AcceptInvitation(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const claimInvitation = `-- name: ClaimInvitation :one
+UPDATE invitations
+SET accepted = TRUE, accepted_at = NOW()
+WHERE token_hash = $1 AND accepted = FALSE AND revoked_at IS NULL AND expires_at > NOW()
+RETURNING id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count
+`
+
+// Atomically marks an open invitation accepted; no row when it was already
+// used, revoked or has expired, so two concurrent accepts cannot both win.
+func (q *Queries) ClaimInvitation(ctx context.Context, tokenHash string) (Invitation, error) {
+	row := q.db.QueryRow(ctx, claimInvitation, tokenHash)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.TokenHash,
+		&i.TenantID,
+		&i.Role,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Accepted,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.LastSentAt,
+		&i.SendCount,
+	)
+	return i, err
+}
+
+const countInvitations = `-- name: CountInvitations :one
+SELECT COUNT(*) FROM invitations
+WHERE tenant_id = $1
+  AND ($2::text = '' OR $2::text = CASE
+        WHEN revoked_at IS NOT NULL THEN 'revoked'
+        WHEN accepted THEN 'accepted'
+        WHEN expires_at <= NOW() THEN 'expired'
+        ELSE 'pending'
+      END)
+`
+
+type CountInvitationsParams struct {
+	TenantID pgtype.UUID
+	Status   string
+}
+
+func (q *Queries) CountInvitations(ctx context.Context, arg CountInvitationsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countInvitations, arg.TenantID, arg.Status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createInvitation = `-- name: CreateInvitation :one
 INSERT INTO invitations (
-    email, token_hash, tenant_id, role, expires_at
+    email, token_hash, tenant_id, role, expires_at, invited_by
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, email, token_hash, tenant_id, role, expires_at, created_at, accepted
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count
 `
 
 type CreateInvitationParams struct {
@@ -36,6 +89,7 @@ type CreateInvitationParams struct {
 	TenantID  pgtype.UUID
 	Role      string
 	ExpiresAt pgtype.Timestamptz
+	InvitedBy pgtype.UUID
 }
 
 func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error) {
@@ -45,6 +99,7 @@ func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationPara
 		arg.TenantID,
 		arg.Role,
 		arg.ExpiresAt,
+		arg.InvitedBy,
 	)
 	var i Invitation
 	err := row.Scan(
@@ -56,6 +111,11 @@ func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationPara
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.Accepted,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.LastSentAt,
+		&i.SendCount,
 	)
 	return i, err
 }
@@ -70,8 +130,8 @@ func (q *Queries) DeleteInvitation(ctx context.Context, tokenHash string) error
 }
 
 const getInvitationByHash = `-- name: GetInvitationByHash :one
-SELECT id, email, token_hash, tenant_id, role, expires_at, created_at, accepted FROM invitations
-WHERE token_hash = $1 AND expires_at > NOW() AND accepted = FALSE
+SELECT id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count FROM invitations
+WHERE token_hash = $1 AND expires_at > NOW() AND accepted = FALSE AND revoked_at IS NULL
 LIMIT 1
 `
 
@@ -87,12 +147,50 @@ func (q *Queries) GetInvitationByHash(ctx context.Context, tokenHash string) (In
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.Accepted,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.LastSentAt,
+		&i.SendCount,
+	)
+	return i, err
+}
+
+const getOpenInvitationByEmail = `-- name: GetOpenInvitationByEmail :one
+SELECT id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count FROM invitations
+WHERE tenant_id = $1 AND email = $2 AND accepted = FALSE AND revoked_at IS NULL
+LIMIT 1
+`
+
+type GetOpenInvitationByEmailParams struct {
+	TenantID pgtype.UUID
+	Email    string
+}
+
+// Open = neither accepted nor revoked; it may have expired (then resend it).
+func (q *Queries) GetOpenInvitationByEmail(ctx context.Context, arg GetOpenInvitationByEmailParams) (Invitation, error) {
+	row := q.db.QueryRow(ctx, getOpenInvitationByEmail, arg.TenantID, arg.Email)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.TokenHash,
+		&i.TenantID,
+		&i.Role,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Accepted,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.LastSentAt,
+		&i.SendCount,
 	)
 	return i, err
 }
 
 const getPendingInvitationsByTenant = `-- name: GetPendingInvitationsByTenant :many
-SELECT id, email, token_hash, tenant_id, role, expires_at, created_at, accepted FROM invitations
+SELECT id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count FROM invitations
 WHERE tenant_id = $1 AND accepted = FALSE
 `
 
@@ -114,6 +212,11 @@ func (q *Queries) GetPendingInvitationsByTenant(ctx context.Context, tenantID pg
 			&i.ExpiresAt,
 			&i.CreatedAt,
 			&i.Accepted,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.RevokedAt,
+			&i.LastSentAt,
+			&i.SendCount,
 		); err != nil {
 			return nil, err
 		}
@@ -124,3 +227,158 @@ func (q *Queries) GetPendingInvitationsByTenant(ctx context.Context, tenantID pg
 	}
 	return items, nil
 }
+
+const isTenantMemberByEmail = `-- name: IsTenantMemberByEmail :one
+SELECT EXISTS (
+    SELECT 1 FROM memberships m
+    JOIN users u ON u.id = m.user_id
+    WHERE m.tenant_id = $1 AND u.email = $2
+)
+`
+
+type IsTenantMemberByEmailParams struct {
+	TenantID pgtype.UUID
+	Email    string
+}
+
+func (q *Queries) IsTenantMemberByEmail(ctx context.Context, arg IsTenantMemberByEmailParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isTenantMemberByEmail, arg.TenantID, arg.Email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listInvitations = `-- name: ListInvitations :many
+SELECT id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count FROM invitations
+WHERE tenant_id = $1
+  AND ($2::text = '' OR $2::text = CASE
+        WHEN revoked_at IS NOT NULL THEN 'revoked'
+        WHEN accepted THEN 'accepted'
+        WHEN expires_at <= NOW() THEN 'expired'
+        ELSE 'pending'
+      END)
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListInvitationsParams struct {
+	TenantID   pgtype.UUID
+	Status     string
+	PageLimit  int32
+	PageOffset int32
+}
+
+// status is ” (all), 'pending', 'accepted', 'expired' or 'revoked'; keep the
+// CASE in sync with CountInvitations and auth.InvitationStatus.
+func (q *Queries) ListInvitations(ctx context.Context, arg ListInvitationsParams) ([]Invitation, error) {
+	rows, err := q.db.Query(ctx, listInvitations,
+		arg.TenantID,
+		arg.Status,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invitation
+	for rows.Next() {
+		var i Invitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.TokenHash,
+			&i.TenantID,
+			&i.Role,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.Accepted,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.RevokedAt,
+			&i.LastSentAt,
+			&i.SendCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeInvitation = `-- name: RevokeInvitation :one
+UPDATE invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND tenant_id = $2 AND accepted = FALSE AND revoked_at IS NULL
+RETURNING id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count
+`
+
+type RevokeInvitationParams struct {
+	ID       pgtype.UUID
+	TenantID pgtype.UUID
+}
+
+func (q *Queries) RevokeInvitation(ctx context.Context, arg RevokeInvitationParams) (Invitation, error) {
+	row := q.db.QueryRow(ctx, revokeInvitation, arg.ID, arg.TenantID)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.TokenHash,
+		&i.TenantID,
+		&i.Role,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Accepted,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.LastSentAt,
+		&i.SendCount,
+	)
+	return i, err
+}
+
+const rotateInvitationToken = `-- name: RotateInvitationToken :one
+UPDATE invitations
+SET token_hash = $3, expires_at = $4, last_sent_at = NOW(), send_count = send_count + 1
+WHERE id = $1 AND tenant_id = $2 AND accepted = FALSE AND revoked_at IS NULL
+RETURNING id, email, token_hash, tenant_id, role, expires_at, created_at, accepted, invited_by, accepted_at, revoked_at, last_sent_at, send_count
+`
+
+type RotateInvitationTokenParams struct {
+	ID        pgtype.UUID
+	TenantID  pgtype.UUID
+	TokenHash string
+	ExpiresAt pgtype.Timestamptz
+}
+
+// Resend: the old link stops working, the expiry starts over.
+func (q *Queries) RotateInvitationToken(ctx context.Context, arg RotateInvitationTokenParams) (Invitation, error) {
+	row := q.db.QueryRow(ctx, rotateInvitationToken,
+		arg.ID,
+		arg.TenantID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.TokenHash,
+		&i.TenantID,
+		&i.Role,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Accepted,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.LastSentAt,
+		&i.SendCount,
+	)
+	return i, err
+}
@@ -133,6 +133,54 @@ func (q *Queries) ListTenantMembers(ctx context.Context, tenantID pgtype.UUID) (
 	return items, nil
 }
 
+const listUserTenants = `-- name: ListUserTenants :many
+SELECT
+    t.id,
+    t.name,
+    t.slug,
+    m.role,
+    m.created_at as joined_at
+FROM memberships m
+JOIN tenants t ON m.tenant_id = t.id
+WHERE m.user_id = $1 AND t.is_active = TRUE
+ORDER BY t.name
+`
+
+type ListUserTenantsRow struct {
+	ID       pgtype.UUID
+	Name     string
+	Slug     string
+	Role     string
+	JoinedAt pgtype.Timestamptz
+}
+
+// Every active tenant the user belongs to, for the tenant switcher.
+func (q *Queries) ListUserTenants(ctx context.Context, userID pgtype.UUID) ([]ListUserTenantsRow, error) {
+	rows, err := q.db.Query(ctx, listUserTenants, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserTenantsRow
+	for rows.Next() {
+		var i ListUserTenantsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Slug,
+			&i.Role,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const removeMember = `-- name: RemoveMember :exec
 DELETE FROM memberships
 WHERE user_id = $1 AND tenant_id = $2
@@ -13,6 +13,55 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// Self-service account deletions waiting for, or past, their cooling-off period.
+type AccountDeletion struct {
+	ID              pgtype.UUID
+	TenantID        pgtype.UUID
+	UserID          pgtype.UUID
+	CancelTokenHash string
+	Status          string
+	PurgeAfter      pgtype.Timestamptz
+	RequestedAt     pgtype.Timestamptz
+	CancelledAt     pgtype.Timestamptz
+	CompletedAt     pgtype.Timestamptz
+}
+
+// Audit log months archived to the blob store before their partition was dropped.
+type AuditArchive struct {
+	ID          pgtype.UUID
+	ChainKey    pgtype.UUID
+	Month       pgtype.Date
+	ObjectKey   string
+	Rows        int64
+	ChainedRows int64
+	FirstSeq    pgtype.Int8
+	LastSeq     pgtype.Int8
+	LastHash    []byte
+	Sha256      string
+	SizeBytes   int64
+	CreatedAt   pgtype.Timestamptz
+	DroppedAt   pgtype.Timestamptz
+}
+
+// Last sequence number and hash of each audit log hash chain.
+type AuditChainHead struct {
+	ChainKey  pgtype.UUID
+	LastSeq   int64
+	PrevHash  []byte
+	LastHash  []byte
+	UpdatedAt pgtype.Timestamptz
+}
+
+// Signed audit chain heads; anchors for control audit-verify.
+type AuditCheckpoint struct {
+	ID        pgtype.UUID
+	ChainKey  pgtype.UUID
+	Seq       int64
+	RowHash   []byte
+	Signature string
+	CreatedAt pgtype.Timestamptz
+}
+
 type AuditLog struct {
 	ID        pgtype.UUID
 	Timestamp pgtype.Timestamptz
@@ -25,17 +74,93 @@ type AuditLog struct {
 	IpAddress *netip.Addr
 	UserAgent pgtype.Text
 	RequestID pgtype.Text
+	Seq       pgtype.Int8
+	PrevHash  []byte
+	RowHash   []byte
+}
+
+// Archived audit rows loaded back by control audit-restore for investigation.
+type AuditLogsRestored struct {
+	ArchiveID  pgtype.UUID
+	RestoredAt pgtype.Timestamptz
+	ID         pgtype.UUID
+	Timestamp  pgtype.Timestamptz
+	ActorID    pgtype.UUID
+	SessionID  pgtype.UUID
+	TenantID   pgtype.UUID
+	Action     string
+	TargetID   pgtype.UUID
+	Metadata   []byte
+	IpAddress  *netip.Addr
+	UserAgent  pgtype.Text
+	RequestID  pgtype.Text
+	Seq        pgtype.Int8
+	PrevHash   []byte
+	RowHash    []byte
+}
+
+// Audit log retention chosen by the tenant; never below AUDIT_RETENTION_MIN_DAYS.
+type AuditRetentionPolicy struct {
+	TenantID      pgtype.UUID
+	RetentionDays int32
+	UpdatedBy     pgtype.UUID
+	UpdatedAt     pgtype.Timestamptz
+}
+
+// Per-tenant SIEM destinations for audit events, with their delivery cursor and status.
+type AuditSink struct {
+	ID                  pgtype.UUID
+	TenantID            pgtype.UUID
+	Name                string
+	Kind                string
+	Endpoint            string
+	UseTls              bool
+	SecretEncrypted     pgtype.Text
+	ActionFilter        pgtype.Text
+	Enabled             bool
+	LastSeq             int64
+	DeliveredCount      int64
+	ConsecutiveFailures int32
+	LastDeliveryAt      pgtype.Timestamptz
+	LastError           pgtype.Text
+	LastErrorAt         pgtype.Timestamptz
+	NextAttemptAt       pgtype.Timestamptz
+	CreatedBy           pgtype.UUID
+	CreatedAt           pgtype.Timestamptz
+	UpdatedAt           pgtype.Timestamptz
+}
+
+// Personal data export requests and the archives built by the worker.
+type DataExport struct {
+	ID          pgtype.UUID
+	TenantID    pgtype.UUID
+	UserID      pgtype.UUID
+	RequestedBy pgtype.UUID
+	Status      string
+	FilePath    pgtype.Text
+	Sha256      pgtype.Text
+	SizeBytes   pgtype.Int8
+	Attempts    int32
+	LastError   pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	StartedAt   pgtype.Timestamptz
+	CompletedAt pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
 }
 
 type EmailChangeRequest struct {
-	ID        pgtype.UUID
-	UserID    pgtype.UUID
-	NewEmail  string
-	TokenHash string
-	Used      bool
-	UsedAt    pgtype.Timestamptz
-	ExpiresAt pgtype.Timestamptz
-	CreatedAt pgtype.Timestamptz
+	ID              pgtype.UUID
+	UserID          pgtype.UUID
+	NewEmail        string
+	TokenHash       string
+	Used            bool
+	UsedAt          pgtype.Timestamptz
+	ExpiresAt       pgtype.Timestamptz
+	CreatedAt       pgtype.Timestamptz
+	OldEmail        pgtype.Text
+	RevertTokenHash pgtype.Text
+	RevertExpiresAt pgtype.Timestamptz
+	RevertedAt      pgtype.Timestamptz
 }
 
 // Audit trail for all email delivery attempts. Privacy-compliant: stores recipient hashes, not raw emails.
@@ -73,15 +198,34 @@ type EmailOutbox struct {
 	EmailLogID          pgtype.UUID
 }
 
-type Invitation struct {
+// Per-tenant subject and body overrides of the embedded email templates, per locale.
+type EmailTemplateOverride struct {
 	ID        pgtype.UUID
-	Email     string
-	TokenHash string
 	TenantID  pgtype.UUID
-	Role      string
-	ExpiresAt pgtype.Timestamptz
+	Template  string
+	Locale    string
+	Subject   pgtype.Text
+	TextBody  pgtype.Text
+	HtmlBody  pgtype.Text
+	UpdatedBy pgtype.UUID
 	CreatedAt pgtype.Timestamptz
-	Accepted  bool
+	UpdatedAt pgtype.Timestamptz
+}
+
+type Invitation struct {
+	ID         pgtype.UUID
+	Email      string
+	TokenHash  string
+	TenantID   pgtype.UUID
+	Role       string
+	ExpiresAt  pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	Accepted   bool
+	InvitedBy  pgtype.UUID
+	AcceptedAt pgtype.Timestamptz
+	RevokedAt  pgtype.Timestamptz
+	LastSentAt pgtype.Timestamptz
+	SendCount  int32
 }
 
 type IotDevice struct {
@@ -113,6 +257,39 @@ type MfaBackupCode struct {
 	CreatedAt pgtype.Timestamptz
 }
 
+// Platform operators for the /platform/v1 API. Separate from tenant users.
+type PlatformAdmin struct {
+	ID           pgtype.UUID
+	Email        string
+	PasswordHash string
+	MfaSecret    string
+	IsActive     bool
+	LastLoginAt  pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+}
+
+// Fixed-window rate limit counters shared by all API replicas. Swept by the janitor worker.
+type RateLimitBucket struct {
+	BucketKey   string
+	WindowStart pgtype.Timestamptz
+	Hits        int32
+	ExpiresAt   pgtype.Timestamptz
+}
+
+// Rate limit rejections (first per subject per window) for the admin dashboard.
+type RateLimitEvent struct {
+	ID       pgtype.UUID
+	TenantID pgtype.UUID
+	Policy   string
+	KeyType  string
+	// SHA256 of the limited subject (GDPR pseudonymization)
+	SubjectHash string
+	IpAddress   *netip.Addr
+	Path        string
+	CreatedAt   pgtype.Timestamptz
+}
+
 type RefreshToken struct {
 	ID            pgtype.UUID
 	UserID        pgtype.UUID
@@ -128,6 +305,23 @@ type RefreshToken struct {
 	RevokedAt     pgtype.Timestamptz
 }
 
+// Self-service signups waiting for admin approval or for the emailed domain confirmation.
+type SignupRequest struct {
+	ID               pgtype.UUID
+	TenantID         pgtype.UUID
+	Email            string
+	FullName         pgtype.Text
+	PasswordHash     string
+	Method           string
+	Status           string
+	ConfirmTokenHash pgtype.Text
+	ExpiresAt        pgtype.Timestamptz
+	UserID           pgtype.UUID
+	DecidedBy        pgtype.UUID
+	DecidedAt        pgtype.Timestamptz
+	CreatedAt        pgtype.Timestamptz
+}
+
 type Tenant struct {
 	ID             pgtype.UUID
 	Name           string
@@ -160,6 +354,62 @@ type Tenant struct {
 	SocialLinks []byte
 }
 
+// Custom hostnames mapped to tenants for tenant resolution by Host header.
+type TenantDomain struct {
+	ID                pgtype.UUID
+	TenantID          pgtype.UUID
+	Domain            string
+	CreatedAt         pgtype.Timestamptz
+	VerificationToken string
+	VerifiedAt        pgtype.Timestamptz
+	LastCheckedAt     pgtype.Timestamptz
+}
+
+// Email domains a tenant claims for automatic signup; verified through a DNS TXT record.
+type TenantEmailDomain struct {
+	ID                pgtype.UUID
+	TenantID          pgtype.UUID
+	Domain            string
+	VerificationToken string
+	AutoJoin          bool
+	VerifiedAt        pgtype.Timestamptz
+	LastCheckedAt     pgtype.Timestamptz
+	CreatedAt         pgtype.Timestamptz
+}
+
+// Scheduled and running tenant deletions. Survives the tenant as a record of the purge.
+type TenantOffboarding struct {
+	TenantID     pgtype.UUID
+	TenantSlug   string
+	TenantName   string
+	RequestedBy  pgtype.UUID
+	Reason       string
+	Status       string
+	Step         string
+	Progress     []byte
+	ExportPath   pgtype.Text
+	ExportSha256 pgtype.Text
+	Attempts     int32
+	LastError    pgtype.Text
+	PurgeAfter   pgtype.Timestamptz
+	LeasedUntil  pgtype.Timestamptz
+	RequestedAt  pgtype.Timestamptz
+	StartedAt    pgtype.Timestamptz
+	CompletedAt  pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+}
+
+// Tenant-defined roles composed of named permissions.
+type TenantRole struct {
+	ID          pgtype.UUID
+	TenantID    pgtype.UUID
+	Name        string
+	Description string
+	Permissions []string
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
 // Security barrier view that excludes mail_config. Use this for frontend/API queries.
 type TenantsSafe struct {
 	ID             pgtype.UUID
@@ -189,6 +439,8 @@ type User struct {
 	FailedLoginAttempts int32
 	LockedUntil         pgtype.Timestamptz
 	TenantID            pgtype.UUID
+	// Language of emails to this user (nl, en). NULL: tenant default.
+	Locale pgtype.Text
 }
 
 type VerificationToken struct {
@@ -200,3 +452,42 @@ type VerificationToken struct {
 	ExpiresAt pgtype.Timestamptz
 	CreatedAt pgtype.Timestamptz
 }
+
+// Webhook outbox and delivery log: one row per event and endpoint, retried with backoff.
+type WebhookDelivery struct {
+	ID                  pgtype.UUID
+	TenantID            pgtype.UUID
+	EndpointID          pgtype.UUID
+	EventID             pgtype.UUID
+	EventType           string
+	Payload             []byte
+	Status              string
+	AttemptCount        int32
+	MaxAttempts         int32
+	NextRetryAt         pgtype.Timestamptz
+	ProcessingStartedAt pgtype.Timestamptz
+	ResponseStatus      pgtype.Int4
+	ResponseBody        pgtype.Text
+	DurationMs          pgtype.Int4
+	LastError           pgtype.Text
+	LastAttemptAt       pgtype.Timestamptz
+	DeliveredAt         pgtype.Timestamptz
+	RedeliveryOf        pgtype.UUID
+	CreatedAt           pgtype.Timestamptz
+}
+
+// Per-tenant webhook subscriptions to audit events, with their queueing cursor.
+type WebhookEndpoint struct {
+	ID              pgtype.UUID
+	TenantID        pgtype.UUID
+	Url             string
+	Description     pgtype.Text
+	SecretEncrypted string
+	EventTypes      []string
+	Enabled         bool
+	LastSeq         int64
+	NextScanAt      pgtype.Timestamptz
+	CreatedBy       pgtype.UUID
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+}
@@ -247,6 +247,38 @@ func (q *Queries) ListShowcaseTenants(ctx context.Context) ([]ListShowcaseTenant
 	return items, nil
 }
 
+const listTenantCORSPolicies = `-- name: ListTenantCORSPolicies :many
+SELECT id, allowed_origins, settings FROM tenants
+WHERE is_active = TRUE
+`
+
+type ListTenantCORSPoliciesRow struct {
+	ID             pgtype.UUID
+	AllowedOrigins []string
+	Settings       domain.TenantSettings
+}
+
+// Loaded in bulk by the CORS policy cache (preflights carry no tenant header)
+func (q *Queries) ListTenantCORSPolicies(ctx context.Context) ([]ListTenantCORSPoliciesRow, error) {
+	rows, err := q.db.Query(ctx, listTenantCORSPolicies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTenantCORSPoliciesRow
+	for rows.Next() {
+		var i ListTenantCORSPoliciesRow
+		if err := rows.Scan(&i.ID, &i.AllowedOrigins, &i.Settings); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateTenantConfig = `-- name: UpdateTenantConfig :one
 UPDATE tenants
 SET 
@@ -12,6 +12,24 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countRecentVerificationTokens = `-- name: CountRecentVerificationTokens :one
+SELECT COUNT(*) FROM verification_tokens
+WHERE user_id = $1 AND type = $2 AND created_at > $3
+`
+
+type CountRecentVerificationTokensParams struct {
+	UserID    pgtype.UUID
+	Type      string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) CountRecentVerificationTokens(ctx context.Context, arg CountRecentVerificationTokensParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentVerificationTokens, arg.UserID, arg.Type, arg.CreatedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createRefreshToken = `-- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens (
     user_id, token_hash, parent_token_id, family_id, tenant_id, ip_address, user_agent, expires_at
@@ -145,8 +163,11 @@ func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (Refres
 const getVerificationToken = `-- name: GetVerificationToken :one
 SELECT id, user_id, token_hash, type, tenant_id, expires_at, created_at FROM verification_tokens
 WHERE token_hash = $1 LIMIT 1
+FOR UPDATE
 `
 
+// Locks the token inside a transaction: concurrent redemptions wait for the
+// first one to consume it.
 func (q *Queries) GetVerificationToken(ctx context.Context, tokenHash string) (VerificationToken, error) {
 	row := q.db.QueryRow(ctx, getVerificationToken, tokenHash)
 	var i VerificationToken
@@ -8,6 +8,7 @@ package db
 import (
 	"context"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -16,7 +17,7 @@ INSERT INTO users (
     email, password_hash, full_name, tenant_id, mfa_secret, mfa_enabled
 ) VALUES (
     $1, $2, $3, $4, $5, $6
-) RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id
+) RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale
 `
 
 type CreateUserParams struct {
@@ -51,6 +52,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.FailedLoginAttempts,
 		&i.LockedUntil,
 		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
@@ -67,8 +69,9 @@ new_membership AS (
     FROM new_user
     RETURNING user_id
 ),
-deleted_invite AS (
-    DELETE FROM invitations 
+accepted_invite AS (
+    UPDATE invitations
+    SET accepted = TRUE, accepted_at = NOW()
     WHERE token_hash = $5
 )
 SELECT id, email, created_at FROM new_user
@@ -112,7 +115,7 @@ WITH new_user AS (
         $5,
         $6
     )
-    RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id
+    RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale
 ),
 new_membership AS (
     INSERT INTO memberships (user_id, tenant_id, role)
@@ -120,7 +123,7 @@ new_membership AS (
     FROM new_user
     RETURNING user_id
 )
-SELECT id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id FROM new_user
+SELECT id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale FROM new_user
 `
 
 type CreateUserWithMembershipParams struct {
@@ -146,6 +149,7 @@ type CreateUserWithMembershipRow struct {
 	FailedLoginAttempts int32
 	LockedUntil         pgtype.Timestamptz
 	TenantID            pgtype.UUID
+	Locale              pgtype.Text
 }
 
 // Atomically creates a user and their default tenant membership
@@ -174,12 +178,13 @@ func (q *Queries) CreateUserWithMembership(ctx context.Context, arg CreateUserWi
 		&i.FailedLoginAttempts,
 		&i.LockedUntil,
 		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id FROM users
+SELECT id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale FROM users
 WHERE email = $1 AND tenant_id = $2 LIMIT 1
 `
 
@@ -204,12 +209,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, arg GetUserByEmailParams)
 		&i.FailedLoginAttempts,
 		&i.LockedUntil,
 		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id FROM users
+SELECT id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale FROM users
 WHERE id = $1 AND tenant_id = $2 LIMIT 1
 `
 
@@ -234,6 +240,7 @@ func (q *Queries) GetUserByID(ctx context.Context, arg GetUserByIDParams) (User,
 		&i.FailedLoginAttempts,
 		&i.LockedUntil,
 		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
@@ -243,9 +250,11 @@ SELECT
     u.id, 
     u.email, 
     u.full_name,
+    u.is_email_verified,
     m.role,
     t.id as tenant_id,
-    t.slug as tenant_slug
+    t.slug as tenant_slug,
+    t.settings as tenant_settings
 FROM users u
 JOIN memberships m ON u.id = m.user_id
 JOIN tenants t ON m.tenant_id = t.id
@@ -258,12 +267,14 @@ type GetUserContextParams struct {
 }
 
 type GetUserContextRow struct {
-	ID         pgtype.UUID
-	Email      string
-	FullName   pgtype.Text
-	Role       string
-	TenantID   pgtype.UUID
-	TenantSlug string
+	ID              pgtype.UUID
+	Email           string
+	FullName        pgtype.Text
+	IsEmailVerified bool
+	Role            string
+	TenantID        pgtype.UUID
+	TenantSlug      string
+	TenantSettings  domain.TenantSettings
 }
 
 func (q *Queries) GetUserContext(ctx context.Context, arg GetUserContextParams) (GetUserContextRow, error) {
@@ -273,9 +284,46 @@ func (q *Queries) GetUserContext(ctx context.Context, arg GetUserContextParams)
 		&i.ID,
 		&i.Email,
 		&i.FullName,
+		&i.IsEmailVerified,
 		&i.Role,
 		&i.TenantID,
 		&i.TenantSlug,
+		&i.TenantSettings,
+	)
+	return i, err
+}
+
+const getUserInTenant = `-- name: GetUserInTenant :one
+SELECT id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale FROM users
+WHERE id = $1 AND (tenant_id = $2 OR EXISTS (
+    SELECT 1 FROM memberships m WHERE m.user_id = users.id AND m.tenant_id = $2
+)) LIMIT 1
+`
+
+type GetUserInTenantParams struct {
+	ID       pgtype.UUID
+	TenantID pgtype.UUID
+}
+
+// Like GetUserByID, but also matches tenants the user joined through a
+// membership (accepted invitations), not only the home tenant.
+func (q *Queries) GetUserInTenant(ctx context.Context, arg GetUserInTenantParams) (User, error) {
+	row := q.db.QueryRow(ctx, getUserInTenant, arg.ID, arg.TenantID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.FullName,
+		&i.IsEmailVerified,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MfaSecret,
+		&i.MfaEnabled,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
@@ -318,11 +366,28 @@ func (q *Queries) ResetLoginAttempts(ctx context.Context, id pgtype.UUID) error
 	return err
 }
 
+const updateUserLocale = `-- name: UpdateUserLocale :exec
+UPDATE users
+SET locale = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserLocaleParams struct {
+	ID     pgtype.UUID
+	Locale pgtype.Text
+}
+
+// Language of the user's emails; NULL falls back to the tenant default.
+func (q *Queries) UpdateUserLocale(ctx context.Context, arg UpdateUserLocaleParams) error {
+	_, err := q.db.Exec(ctx, updateUserLocale, arg.ID, arg.Locale)
+	return err
+}
+
 const updateUserMFA = `-- name: UpdateUserMFA :one
 UPDATE users
 SET mfa_secret = $2, mfa_enabled = $3, updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id
+RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale
 `
 
 type UpdateUserMFAParams struct {
@@ -347,6 +412,7 @@ func (q *Queries) UpdateUserMFA(ctx context.Context, arg UpdateUserMFAParams) (U
 		&i.FailedLoginAttempts,
 		&i.LockedUntil,
 		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
@@ -355,7 +421,7 @@ const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users
 SET password_hash = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id
+RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale
 `
 
 type UpdateUserPasswordParams struct {
@@ -379,6 +445,7 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 		&i.FailedLoginAttempts,
 		&i.LockedUntil,
 		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
@@ -405,7 +472,7 @@ const verifyUserEmail = `-- name: VerifyUserEmail :one
 UPDATE users
 SET is_email_verified = TRUE, updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id
+RETURNING id, email, password_hash, full_name, is_email_verified, created_at, updated_at, mfa_secret, mfa_enabled, failed_login_attempts, locked_until, tenant_id, locale
 `
 
 func (q *Queries) VerifyUserEmail(ctx context.Context, id pgtype.UUID) (User, error) {
@@ -424,6 +491,7 @@ func (q *Queries) VerifyUserEmail(ctx context.Context, id pgtype.UUID) (User, er
 		&i.FailedLoginAttempts,
 		&i.LockedUntil,
 		&i.TenantID,
+		&i.Locale,
 	)
 	return i, err
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -117,3 +118,15 @@ func GetTx(ctx context.Context) pgx.Tx {
 	}
 	return tx
 }
+
+// InTenantTx runs fn in the request transaction set by TenantContext, or in a
+// new WithTenantContext transaction when there is none, so multi-step writes
+// stay atomic either way.
+func InTenantTx(ctx context.Context, pool *pgxpool.Pool, tenantID uuid.UUID, fn func(q *db.Queries) error) error {
+	if tx := GetTx(ctx); tx != nil {
+		return fn(db.New(tx))
+	}
+	return WithTenantContext(ctx, pool, tenantID, func(tx pgx.Tx) error {
+		return fn(db.New(tx))
+	})
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ValidateTenantDomain validates a custom hostname for the tenant resolver
+// and returns it in canonical (lowercase) form.
+//
+// ✅ SECURE: Rejects IPs, localhost, wildcards and schemes/paths
+// ✅ SECURE: Rejects hostnames below the platform base domain (subdomain routing owns those)
+func ValidateTenantDomain(domain, baseDomain string) (string, error) {
+	d := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if d == "" || len(d) > 253 {
+		return "", fmt.Errorf("invalid domain: %q", domain)
+	}
+	if net.ParseIP(d) != nil || isLocalhostHost(d) {
+		return "", fmt.Errorf("domain must be a public hostname: %s", d)
+	}
+
+	labels := strings.Split(d, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("domain must be fully qualified: %s", d)
+	}
+	for _, label := range labels {
+		if !validDNSLabel(label) {
+			return "", fmt.Errorf("invalid domain label %q in %s", label, d)
+		}
+	}
+
+	if base := strings.ToLower(strings.TrimPrefix(baseDomain, ".")); base != "" {
+		if d == base || strings.HasSuffix(d, "."+base) {
+			return "", fmt.Errorf("domain %s is reserved for subdomain routing", d)
+		}
+	}
+	return d, nil
+}
+
+func validDNSLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTenantDomain(t *testing.T) {
+	d, err := storage.ValidateTenantDomain(" Login.Acme.NL. ", "auth.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "login.acme.nl", d)
+
+	invalid := []string{
+		"", "localhost", "acme", "10.0.0.1", "*.acme.nl", "https://acme.nl",
+		"acme.nl/login", "-acme.nl", "acme.auth.example.com", "auth.example.com",
+	}
+	for _, domain := range invalid {
+		_, err := storage.ValidateTenantDomain(domain, "auth.example.com")
+		assert.Error(t, err, domain)
+	}
+}
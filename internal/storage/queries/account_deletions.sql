@@ -0,0 +1,48 @@
+-- Self-service account deletions. Scheduling and cancelling run under RLS; the
+-- worker claims due deletions and purges through storage.WithoutRLS.
+
+-- name: ScheduleAccountDeletion :one
+INSERT INTO account_deletions (tenant_id, user_id, cancel_token_hash, purge_after)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetScheduledAccountDeletion :one
+SELECT * FROM account_deletions
+WHERE user_id = $1 AND status = 'scheduled'
+LIMIT 1;
+
+-- name: CancelAccountDeletion :one
+-- Only during the cooling-off period: once due, the purge may already be running.
+UPDATE account_deletions
+SET status = 'cancelled', cancelled_at = NOW()
+WHERE cancel_token_hash = $1 AND status = 'scheduled' AND purge_after > NOW()
+RETURNING *;
+
+-- name: ClaimDueAccountDeletion :one
+-- Locks one due deletion for the caller's transaction; the purge commits with it.
+SELECT * FROM account_deletions
+WHERE status = 'scheduled' AND purge_after <= NOW()
+ORDER BY purge_after
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CompleteAccountDeletion :exec
+UPDATE account_deletions
+SET status = 'completed', completed_at = NOW()
+WHERE id = $1;
+
+-- name: PurgeUserMemberships :execrows
+DELETE FROM memberships WHERE user_id = $1;
+
+-- name: PurgeUserRefreshTokens :execrows
+DELETE FROM refresh_tokens WHERE user_id = $1;
+
+-- name: PurgeUserMfaBackupCodes :execrows
+DELETE FROM mfa_backup_codes WHERE user_id = $1;
+
+-- name: PurgeUserEmailChangeRequests :execrows
+DELETE FROM email_change_requests WHERE user_id = $1;
+
+-- name: PurgeUser :execrows
+-- Remaining user-keyed rows (verification tokens, data exports) go by cascade.
+DELETE FROM users WHERE id = $1;
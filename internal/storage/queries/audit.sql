@@ -1,5 +1,26 @@
 -- name: CreateAuditLog :exec
+-- Appends the row to its tenant's hash chain: the head row is advanced (and
+-- locked) and the row inserted in one statement. row_hash is
+-- sha256(prev_hash || int8send(seq) || payload); payload is the canonical
+-- encoding of the row built by audit.ChainRecord.
+WITH head AS (
+    INSERT INTO audit_chain_heads AS h (chain_key, last_seq, prev_hash, last_hash)
+    VALUES (
+        sqlc.arg(chain_key)::UUID,
+        1,
+        decode(repeat('00', 32), 'hex'),
+        sha256(decode(repeat('00', 32), 'hex') || int8send(1::BIGINT) || sqlc.arg(payload)::BYTEA)
+    )
+    ON CONFLICT (chain_key) DO UPDATE
+    SET last_seq = h.last_seq + 1,
+        prev_hash = h.last_hash,
+        last_hash = sha256(h.last_hash || int8send(h.last_seq + 1) || sqlc.arg(payload)::BYTEA),
+        updated_at = NOW()
+    RETURNING last_seq, prev_hash, last_hash
+)
 INSERT INTO audit_logs (
+    id,
+    timestamp,
     actor_id, 
     session_id, 
     tenant_id, 
@@ -8,11 +29,55 @@ INSERT INTO audit_logs (
     metadata, 
     ip_address, 
     user_agent, 
-    request_id
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
+)
+SELECT
+    sqlc.arg(id)::UUID,
+    sqlc.arg(timestamp)::TIMESTAMPTZ,
+    sqlc.narg(actor_id)::UUID,
+    sqlc.narg(session_id)::UUID,
+    sqlc.narg(tenant_id)::UUID,
+    sqlc.arg(action)::VARCHAR,
+    sqlc.narg(target_id)::UUID,
+    sqlc.arg(metadata)::JSONB,
+    sqlc.narg(ip_address)::INET,
+    sqlc.narg(user_agent)::TEXT,
+    sqlc.narg(request_id)::TEXT,
+    head.last_seq,
+    head.prev_hash,
+    head.last_hash
+FROM head;
+
+-- name: CreateAuditLogs :copyfrom
+-- Batch insert of audit.AsyncWriter. The caller computes seq and the hashes
+-- while holding the chain head (LockAuditChainHead) in the same transaction.
+INSERT INTO audit_logs (
+    id,
+    timestamp,
+    actor_id,
+    session_id,
+    tenant_id,
+    action,
+    target_id,
+    metadata,
+    ip_address,
+    user_agent,
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 );
 
+-- name: ListAuditLogIDs :many
+-- Which of the given rows exist; spool replay skips rows already written.
+SELECT id FROM audit_logs
+WHERE id = ANY(sqlc.arg(ids)::UUID[]);
+
 -- name: ListAuditLogsByTenant :many
 SELECT * FROM audit_logs
 WHERE tenant_id = $1
@@ -24,3 +89,38 @@ SELECT * FROM audit_logs
 WHERE actor_id = $1
 ORDER BY timestamp DESC
 LIMIT $2 OFFSET $3;
+
+-- name: ListAuditChainRows :many
+-- One page of a hash chain in chain order, for control audit-verify.
+SELECT * FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = sqlc.arg(chain_key)
+  AND seq > sqlc.arg(after_seq)
+ORDER BY seq
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountUnchainedAuditLogs :one
+-- Rows from before the hash chain existed (migration 025).
+SELECT COUNT(*) FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = $1
+  AND seq IS NULL;
+
+-- name: ListAuditLogChainKeys :many
+-- Chains with rows in [since, until).
+SELECT DISTINCT COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID)::UUID
+FROM audit_logs
+WHERE timestamp >= sqlc.arg(since) AND timestamp < sqlc.arg(until);
+
+-- name: CountAuditLogsInRange :one
+SELECT COUNT(*) FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = sqlc.arg(chain_key)
+  AND timestamp >= sqlc.arg(since) AND timestamp < sqlc.arg(until);
+
+-- name: ListAuditLogsForArchive :many
+-- One page of a chain within [since, until): unchained legacy rows first, then
+-- chain order. Pass the (seq, id) of the last row read; seq 0 for unchained rows.
+SELECT * FROM audit_logs
+WHERE COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID) = sqlc.arg(chain_key)
+  AND timestamp >= sqlc.arg(since) AND timestamp < sqlc.arg(until)
+  AND (COALESCE(seq, 0), id) > (sqlc.arg(after_seq)::BIGINT, sqlc.arg(after_id)::UUID)
+ORDER BY COALESCE(seq, 0), id
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,69 @@
+-- name: UpsertAuditArchive :one
+-- Records a written archive. A month whose partition is already dropped is
+-- never overwritten (no row returned).
+INSERT INTO audit_archives (
+    chain_key, month, object_key, rows, chained_rows, first_seq, last_seq, last_hash, sha256, size_bytes
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (chain_key, month) DO UPDATE
+SET object_key = EXCLUDED.object_key,
+    rows = EXCLUDED.rows,
+    chained_rows = EXCLUDED.chained_rows,
+    first_seq = EXCLUDED.first_seq,
+    last_seq = EXCLUDED.last_seq,
+    last_hash = EXCLUDED.last_hash,
+    sha256 = EXCLUDED.sha256,
+    size_bytes = EXCLUDED.size_bytes,
+    created_at = NOW()
+WHERE audit_archives.dropped_at IS NULL
+RETURNING *;
+
+-- name: ListAuditArchivesByMonth :many
+SELECT * FROM audit_archives
+WHERE month = $1;
+
+-- name: MarkAuditArchivesDropped :exec
+UPDATE audit_archives
+SET dropped_at = NOW()
+WHERE month = $1 AND dropped_at IS NULL;
+
+-- name: ListDroppedAuditArchives :many
+-- Archived parts of a chain that are gone from audit_logs, for the verifier.
+SELECT * FROM audit_archives
+WHERE chain_key = $1 AND dropped_at IS NOT NULL
+ORDER BY first_seq;
+
+-- name: ListAuditArchives :many
+SELECT * FROM audit_archives
+WHERE chain_key = $1
+ORDER BY month;
+
+-- name: GetAuditArchive :one
+SELECT * FROM audit_archives
+WHERE chain_key = $1 AND month = $2;
+
+-- name: DeleteRestoredAuditLogs :execrows
+DELETE FROM audit_logs_restored
+WHERE archive_id = $1;
+
+-- name: CreateRestoredAuditLogs :copyfrom
+INSERT INTO audit_logs_restored (
+    archive_id,
+    id,
+    timestamp,
+    actor_id,
+    session_id,
+    tenant_id,
+    action,
+    target_id,
+    metadata,
+    ip_address,
+    user_agent,
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+);
@@ -0,0 +1,30 @@
+-- name: ListAuditChainHeads :many
+SELECT * FROM audit_chain_heads
+ORDER BY chain_key;
+
+-- name: GetAuditChainHead :one
+SELECT * FROM audit_chain_heads
+WHERE chain_key = $1;
+
+-- name: ListAuditChainHeadsToCheckpoint :many
+-- Chains that grew since their last signed checkpoint.
+SELECT * FROM audit_chain_heads h
+WHERE h.last_seq > COALESCE((SELECT MAX(c.seq) FROM audit_checkpoints c WHERE c.chain_key = h.chain_key), 0)
+ORDER BY h.chain_key;
+
+-- name: LockAuditChainHead :one
+-- Locks the head of a chain until the end of the transaction, creating it at
+-- seq 0 for a new chain. Used by batch writers that compute hashes themselves.
+INSERT INTO audit_chain_heads AS h (chain_key, last_seq, prev_hash, last_hash)
+VALUES ($1, 0, decode(repeat('00', 32), 'hex'), decode(repeat('00', 32), 'hex'))
+ON CONFLICT (chain_key) DO UPDATE
+SET updated_at = NOW()
+RETURNING *;
+
+-- name: AdvanceAuditChainHead :exec
+UPDATE audit_chain_heads
+SET last_seq = $2,
+    prev_hash = $3,
+    last_hash = $4,
+    updated_at = NOW()
+WHERE chain_key = $1;
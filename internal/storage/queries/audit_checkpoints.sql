@@ -0,0 +1,9 @@
+-- name: CreateAuditCheckpoint :exec
+INSERT INTO audit_checkpoints (chain_key, seq, row_hash, signature)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (chain_key, seq) DO NOTHING;
+
+-- name: ListAuditCheckpoints :many
+SELECT * FROM audit_checkpoints
+WHERE chain_key = $1
+ORDER BY seq;
@@ -1,7 +1,9 @@
 -- Audit Logs Queries
 -- Read-only queries for audit log API (writes handled by audit service)
+-- Newest first with a keyset cursor: pass the (timestamp, id) of the last row
+-- of the previous page. Filters are optional; NULL disables them.
 
--- name: ListAuditLogs :many
+-- name: SearchAuditLogs :many
 SELECT 
     id,
     timestamp,
@@ -13,13 +15,25 @@ SELECT
     metadata,
     ip_address,
     user_agent,
-    request_id
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 FROM audit_logs
-WHERE tenant_id = $1
-ORDER BY timestamp DESC
-LIMIT $2 OFFSET $3;
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.narg(action_pattern)::TEXT IS NULL OR action LIKE sqlc.narg(action_pattern))
+  AND (sqlc.narg(actor_id)::UUID IS NULL OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(target_id)::UUID IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR timestamp >= sqlc.narg(since))
+  AND (sqlc.narg(until)::TIMESTAMPTZ IS NULL OR timestamp < sqlc.narg(until))
+  AND (sqlc.narg(ip_range)::CIDR IS NULL OR ip_address <<= sqlc.narg(ip_range))
+  AND (sqlc.narg(metadata_keys)::TEXT[] IS NULL OR metadata ?& sqlc.narg(metadata_keys))
+  AND (sqlc.narg(cursor_ts)::TIMESTAMPTZ IS NULL OR (timestamp, id) < (sqlc.narg(cursor_ts), sqlc.narg(cursor_id)::UUID))
+ORDER BY timestamp DESC, id DESC
+LIMIT sqlc.arg(row_limit);
 
--- name: GetAuditLogsByUser :many
+-- name: ListUserActivity :many
+-- Events by or about one user (GET /auth/activity).
 SELECT 
     id,
     timestamp,
@@ -31,13 +45,22 @@ SELECT
     metadata,
     ip_address,
     user_agent,
-    request_id
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 FROM audit_logs
-WHERE tenant_id = $1 AND actor_id = $2
-ORDER BY timestamp DESC
-LIMIT $3 OFFSET $4;
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (actor_id = sqlc.arg(user_id) OR target_id = sqlc.arg(user_id))
+  AND (sqlc.narg(action_pattern)::TEXT IS NULL OR action LIKE sqlc.narg(action_pattern))
+  AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR timestamp >= sqlc.narg(since))
+  AND (sqlc.narg(until)::TIMESTAMPTZ IS NULL OR timestamp < sqlc.narg(until))
+  AND (sqlc.narg(cursor_ts)::TIMESTAMPTZ IS NULL OR (timestamp, id) < (sqlc.narg(cursor_ts), sqlc.narg(cursor_id)::UUID))
+ORDER BY timestamp DESC, id DESC
+LIMIT sqlc.arg(row_limit);
 
--- name: GetAuditLogsByAction :many
+-- name: ExportAuditLogs :many
+-- Oldest first after a keyset cursor, for the bulk export of a time range.
 SELECT 
     id,
     timestamp,
@@ -49,13 +72,15 @@ SELECT
     metadata,
     ip_address,
     user_agent,
-    request_id
+    request_id,
+    seq,
+    prev_hash,
+    row_hash
 FROM audit_logs
-WHERE tenant_id = $1 AND action = $2
-ORDER BY timestamp DESC
-LIMIT $3 OFFSET $4;
-
--- name: CountAuditLogs :one
-SELECT COUNT(*) as total
-FROM audit_logs
-WHERE tenant_id = $1;
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND timestamp >= sqlc.arg(since)
+  AND timestamp < sqlc.arg(until)
+  AND (sqlc.narg(action_pattern)::TEXT IS NULL OR action LIKE sqlc.narg(action_pattern))
+  AND (sqlc.narg(cursor_ts)::TIMESTAMPTZ IS NULL OR (timestamp, id) > (sqlc.narg(cursor_ts), sqlc.narg(cursor_id)::UUID))
+ORDER BY timestamp, id
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,37 @@
+-- name: GetAuditRetentionPolicy :one
+SELECT * FROM audit_retention_policies
+WHERE tenant_id = $1;
+
+-- name: UpsertAuditRetentionPolicy :one
+INSERT INTO audit_retention_policies (tenant_id, retention_days, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (tenant_id) DO UPDATE
+SET retention_days = EXCLUDED.retention_days,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteAuditRetentionPolicy :execrows
+DELETE FROM audit_retention_policies
+WHERE tenant_id = $1;
+
+-- name: ListAuditRetentionPolicies :many
+-- Every tenant's choice, for the archiver.
+SELECT * FROM audit_retention_policies;
+
+-- name: CreateAuditLogPartition :one
+-- Creates the partition of the UTC month containing month, if missing.
+SELECT create_audit_log_partition(sqlc.arg(month)::DATE)::TEXT;
+
+-- name: ListAuditLogPartitions :many
+-- Monthly partitions (audit_logs_YYYY_MM), oldest first; not the default partition.
+SELECT c.relname::TEXT
+FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = 'audit_logs'::REGCLASS
+  AND c.relname ~ '^audit_logs_[0-9]{4}_[0-9]{2}$'
+ORDER BY c.relname;
+
+-- name: CountDefaultPartitionAuditLogs :one
+-- Rows that found no monthly partition.
+SELECT COUNT(*) FROM audit_logs_default;
@@ -0,0 +1,76 @@
+-- name: CreateAuditSink :one
+-- New sinks start at the current end of the chain; history is available
+-- through the bulk export.
+INSERT INTO audit_sinks (tenant_id, name, kind, endpoint, use_tls, secret_encrypted, action_filter, created_by, last_seq)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8,
+    COALESCE((SELECT h.last_seq FROM audit_chain_heads h WHERE h.chain_key = $1), 0)
+)
+RETURNING *;
+
+-- name: ListAuditSinks :many
+SELECT * FROM audit_sinks
+WHERE tenant_id = $1
+ORDER BY created_at;
+
+-- name: CountAuditSinks :one
+SELECT COUNT(*) FROM audit_sinks
+WHERE tenant_id = $1;
+
+-- name: GetAuditSink :one
+SELECT * FROM audit_sinks
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: SetAuditSinkEnabled :one
+-- Enabling also clears the backoff.
+UPDATE audit_sinks
+SET enabled = $3,
+    consecutive_failures = CASE WHEN $3 THEN 0 ELSE consecutive_failures END,
+    next_attempt_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: RetryAuditSink :execrows
+-- Skips the remaining backoff.
+UPDATE audit_sinks
+SET next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2 AND enabled;
+
+-- name: DeleteAuditSink :execrows
+DELETE FROM audit_sinks
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: ClaimDueAuditSinks :many
+-- Leases due sinks for five minutes, so concurrent workers skip them and a
+-- crashed worker's sinks become due again.
+UPDATE audit_sinks
+SET next_attempt_at = NOW() + INTERVAL '5 minutes'
+WHERE id IN (
+    SELECT s.id FROM audit_sinks s
+    WHERE s.enabled AND s.next_attempt_at <= NOW()
+    ORDER BY s.next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: AdvanceAuditSink :exec
+-- Moves the cursor after a successful delivery and releases the lease.
+UPDATE audit_sinks
+SET last_seq = $2,
+    delivered_count = delivered_count + $3,
+    last_delivery_at = CASE WHEN $3 > 0 THEN NOW() ELSE last_delivery_at END,
+    consecutive_failures = 0,
+    last_error = NULL,
+    next_attempt_at = NOW()
+WHERE id = $1;
+
+-- name: FailAuditSink :exec
+-- Backoff: 30s doubling per consecutive failure, capped at one hour.
+UPDATE audit_sinks
+SET consecutive_failures = consecutive_failures + 1,
+    last_error = $2,
+    last_error_at = NOW(),
+    next_attempt_at = NOW() + LEAST(POWER(2, LEAST(consecutive_failures, 7)) * INTERVAL '30 seconds', INTERVAL '1 hour')
+WHERE id = $1;
@@ -10,11 +10,45 @@ DELETE FROM verification_tokens
 WHERE expires_at < NOW();
 
 -- name: CleanExpiredInvitations :execrows
--- Uitnodigingen die nooit geaccepteerd zijn.
-DELETE FROM invitations 
-WHERE expires_at < NOW();
+-- Verlopen, geaccepteerde en ingetrokken uitnodigingen blijven 30 dagen
+-- zichtbaar in het admin-overzicht (GET /admin/invitations).
+DELETE FROM invitations
+WHERE COALESCE(accepted_at, revoked_at, expires_at) < NOW() - INTERVAL '30 days';
 
 -- name: CleanUsedMfaCodes :execrows
 -- Backup codes die al gebruikt zijn (bewaar ze kort voor audit, daarna weg).
 DELETE FROM mfa_backup_codes 
 WHERE used = TRUE AND used_at < NOW() - INTERVAL '7 days';
+
+-- name: CleanExpiredRateLimitBuckets :execrows
+-- Rate limit tellers waarvan het venster voorbij is.
+DELETE FROM rate_limit_buckets
+WHERE expires_at < NOW();
+
+-- name: CleanOldEmailLogs :execrows
+-- Bezorglogs ouder dan EMAIL_LOG_RETENTION_DAYS. Per batch, zodat de eerste
+-- run op een grote tabel geen lange lock houdt; de janitor herhaalt tot klaar.
+DELETE FROM email_logs
+WHERE id IN (
+    SELECT id FROM email_logs
+    WHERE created_at < NOW() - make_interval(days => sqlc.arg(retention_days)::INT)
+    LIMIT sqlc.arg(batch_size)
+);
+
+-- name: CleanOldRateLimitEvents :execrows
+-- Rate limit hits ouder dan 30 dagen (dashboard toont alleen recente data).
+DELETE FROM rate_limit_events
+WHERE created_at < NOW() - INTERVAL '30 days';
+
+-- name: CleanSignupRequests :execrows
+-- Onbevestigde domein-aanmeldingen na verloop van de link; beslist verzoeken
+-- blijven 30 dagen zichtbaar in de goedkeuringslijst.
+DELETE FROM signup_requests
+WHERE (status = 'pending' AND expires_at < NOW())
+   OR (status <> 'pending' AND decided_at < NOW() - INTERVAL '30 days');
+
+-- name: CleanOldWebhookDeliveries :execrows
+-- Afgeronde webhook-bezorgingen (delivered/failed) ouder dan 30 dagen.
+DELETE FROM webhook_deliveries
+WHERE status IN ('delivered', 'failed')
+  AND created_at < NOW() - INTERVAL '30 days';
@@ -0,0 +1,84 @@
+-- Personal data exports (GDPR Art. 15 / 20). Requests run under RLS; the worker
+-- and the signed download endpoint use storage.WithoutRLS.
+
+-- name: CreateDataExport :one
+INSERT INTO data_exports (tenant_id, user_id, requested_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListDataExportsByUser :many
+SELECT * FROM data_exports
+WHERE tenant_id = $1 AND user_id = $2
+ORDER BY created_at DESC
+LIMIT 10;
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimDataExport :one
+-- Oldest pending request, or one stuck in processing (crashed worker).
+UPDATE data_exports
+SET status = 'processing', started_at = NOW(), attempts = attempts + 1
+WHERE id = (
+    SELECT e.id FROM data_exports e
+    WHERE e.status = 'pending'
+       OR (e.status = 'processing' AND e.started_at < NOW() - INTERVAL '15 minutes')
+    ORDER BY e.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready', file_path = $2, sha256 = $3, size_bytes = $4,
+    completed_at = NOW(), expires_at = $5, last_error = NULL
+WHERE id = $1;
+
+-- name: FailDataExport :exec
+-- Back to the queue until the third attempt.
+UPDATE data_exports
+SET status = CASE WHEN attempts >= 3 THEN 'failed' ELSE 'pending' END,
+    last_error = $2
+WHERE id = $1;
+
+-- name: ExpireDataExports :many
+-- Archives past their download window; the caller removes the files.
+UPDATE data_exports
+SET status = 'expired', file_path = NULL
+WHERE status = 'ready' AND expires_at < NOW()
+RETURNING id;
+
+-- name: CollectUserData :one
+-- Everything stored about one member within one tenant. No secrets: password
+-- hashes, MFA secrets, backup code hashes and token hashes are never selected.
+SELECT json_build_object(
+    'profile', (SELECT json_build_object('id', u.id, 'email', u.email, 'full_name', u.full_name,
+                    'is_email_verified', u.is_email_verified, 'created_at', u.created_at, 'updated_at', u.updated_at)
+                FROM users u WHERE u.id = $1),
+    'memberships', COALESCE((SELECT json_agg(json_build_object('tenant', t.name, 'role', m.role, 'created_at', m.created_at))
+                FROM memberships m JOIN tenants t ON t.id = m.tenant_id
+                WHERE m.user_id = $1 AND m.tenant_id = $2), '[]'::json),
+    'sessions', COALESCE((SELECT json_agg(json_build_object('id', rt.id, 'ip_address', rt.ip_address, 'user_agent', rt.user_agent,
+                    'is_revoked', rt.is_revoked, 'created_at', rt.created_at, 'expires_at', rt.expires_at) ORDER BY rt.created_at)
+                FROM refresh_tokens rt WHERE rt.user_id = $1 AND rt.tenant_id = $2), '[]'::json),
+    'mfa', (SELECT json_build_object('enabled', u.mfa_enabled,
+                    'backup_codes_remaining', (SELECT COUNT(*) FROM mfa_backup_codes c WHERE c.user_id = u.id AND c.used = FALSE))
+                FROM users u WHERE u.id = $1),
+    'audit_logs', COALESCE((SELECT json_agg(json_build_object('timestamp', a.timestamp, 'action', a.action,
+                    'role', CASE WHEN a.actor_id = $1 THEN 'actor' ELSE 'target' END,
+                    'metadata', a.metadata, 'ip_address', a.ip_address, 'user_agent', a.user_agent) ORDER BY a.timestamp)
+                FROM audit_logs a WHERE a.tenant_id = $2 AND (a.actor_id = $1 OR a.target_id = $1)), '[]'::json),
+    -- Recipients are stored as SHA256(email) (see mailer.HashRecipient)
+    'email_logs', COALESCE((SELECT json_agg(json_build_object('template', e.template_type, 'status', e.status,
+                    'created_at', e.created_at, 'sent_at', e.sent_at) ORDER BY e.created_at)
+                FROM email_logs e, users u
+                WHERE u.id = $1 AND e.tenant_id = $2
+                  AND e.recipient_hash = encode(sha256(convert_to(u.email::text, 'UTF8')), 'hex')), '[]'::json),
+    'email_change_requests', COALESCE((SELECT json_agg(json_build_object('new_email', ecr.new_email, 'used', ecr.used,
+                    'created_at', ecr.created_at, 'used_at', ecr.used_at))
+                FROM email_change_requests ecr WHERE ecr.user_id = $1), '[]'::json),
+    -- No external identity providers are linked yet; kept so the format is stable.
+    'linked_identities', '[]'::json
+)::jsonb AS data;
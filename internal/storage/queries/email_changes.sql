@@ -1,21 +1,34 @@
 -- name: CreateEmailChangeRequest :one
 INSERT INTO email_change_requests (
-    user_id, new_email, token_hash, expires_at
+    user_id, new_email, token_hash, expires_at, old_email, revert_token_hash, revert_expires_at
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 -- name: GetEmailChangeRequest :one
+-- Locks the request: confirm and revert must not race.
 SELECT * FROM email_change_requests
-WHERE token_hash = $1 AND expires_at > NOW() AND used = FALSE
-LIMIT 1;
+WHERE token_hash = $1 AND expires_at > NOW() AND used = FALSE AND reverted_at IS NULL
+LIMIT 1
+FOR UPDATE;
+
+-- name: GetEmailChangeRequestByRevertToken :one
+SELECT * FROM email_change_requests
+WHERE revert_token_hash = $1 AND revert_expires_at > NOW() AND reverted_at IS NULL
+LIMIT 1
+FOR UPDATE;
 
 -- name: MarkEmailChangeRequestUsed :exec
 UPDATE email_change_requests
 SET used = TRUE, used_at = NOW()
 WHERE id = $1;
 
+-- name: MarkEmailChangeRequestReverted :exec
+UPDATE email_change_requests
+SET reverted_at = NOW()
+WHERE id = $1;
+
 -- name: UpdateUserEmail :exec
 UPDATE users
-SET email = $2, is_email_verified = TRUE, updated_at = NOW()
+SET email = $2, is_email_verified = $3, updated_at = NOW()
 WHERE id = $1;
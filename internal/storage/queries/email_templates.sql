@@ -0,0 +1,35 @@
+-- name: ListEmailTemplateOverrides :many
+SELECT * FROM email_template_overrides
+WHERE tenant_id = $1
+ORDER BY template, locale;
+
+-- name: GetEmailTemplateOverride :one
+SELECT * FROM email_template_overrides
+WHERE tenant_id = $1 AND template = $2 AND locale = $3;
+
+-- name: UpsertEmailTemplateOverride :one
+INSERT INTO email_template_overrides (tenant_id, template, locale, subject, text_body, html_body, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (tenant_id, template, locale) DO UPDATE
+SET subject = EXCLUDED.subject,
+    text_body = EXCLUDED.text_body,
+    html_body = EXCLUDED.html_body,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteEmailTemplateOverride :execrows
+DELETE FROM email_template_overrides
+WHERE tenant_id = $1 AND template = $2 AND locale = $3;
+
+-- name: GetRecipientLocale :one
+-- Language chosen by the recipient of an email to the tenant: its own user or
+-- a member from another home tenant. NULL when nobody chose one.
+SELECT u.locale FROM users u
+WHERE u.email = $1
+  AND u.locale IS NOT NULL
+  AND (u.tenant_id = $2 OR EXISTS (
+      SELECT 1 FROM memberships m WHERE m.user_id = u.id AND m.tenant_id = $2
+  ))
+ORDER BY (u.tenant_id = $2) DESC
+LIMIT 1;
@@ -1,18 +1,18 @@
 -- name: CreateInvitation :one
 INSERT INTO invitations (
-    email, token_hash, tenant_id, role, expires_at
+    email, token_hash, tenant_id, role, expires_at, invited_by
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetInvitationByHash :one
 SELECT * FROM invitations
-WHERE token_hash = $1 AND expires_at > NOW() AND accepted = FALSE
+WHERE token_hash = $1 AND expires_at > NOW() AND accepted = FALSE AND revoked_at IS NULL
 LIMIT 1;
 
 -- name: AcceptInvitation :exec
 UPDATE invitations
-SET accepted = TRUE
+SET accepted = TRUE, accepted_at = NOW()
 WHERE id = $1;
 
 -- name: GetPendingInvitationsByTenant :many
@@ -21,3 +21,61 @@ WHERE tenant_id = $1 AND accepted = FALSE;
 
 -- name: DeleteInvitation :exec
 DELETE FROM invitations WHERE token_hash = $1;
+
+-- name: ListInvitations :many
+-- status is '' (all), 'pending', 'accepted', 'expired' or 'revoked'; keep the
+-- CASE in sync with CountInvitations and auth.InvitationStatus.
+SELECT * FROM invitations
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.arg(status)::text = '' OR sqlc.arg(status)::text = CASE
+        WHEN revoked_at IS NOT NULL THEN 'revoked'
+        WHEN accepted THEN 'accepted'
+        WHEN expires_at <= NOW() THEN 'expired'
+        ELSE 'pending'
+      END)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountInvitations :one
+SELECT COUNT(*) FROM invitations
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.arg(status)::text = '' OR sqlc.arg(status)::text = CASE
+        WHEN revoked_at IS NOT NULL THEN 'revoked'
+        WHEN accepted THEN 'accepted'
+        WHEN expires_at <= NOW() THEN 'expired'
+        ELSE 'pending'
+      END);
+
+-- name: GetOpenInvitationByEmail :one
+-- Open = neither accepted nor revoked; it may have expired (then resend it).
+SELECT * FROM invitations
+WHERE tenant_id = $1 AND email = $2 AND accepted = FALSE AND revoked_at IS NULL
+LIMIT 1;
+
+-- name: RotateInvitationToken :one
+-- Resend: the old link stops working, the expiry starts over.
+UPDATE invitations
+SET token_hash = $3, expires_at = $4, last_sent_at = NOW(), send_count = send_count + 1
+WHERE id = $1 AND tenant_id = $2 AND accepted = FALSE AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeInvitation :one
+UPDATE invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND tenant_id = $2 AND accepted = FALSE AND revoked_at IS NULL
+RETURNING *;
+
+-- name: IsTenantMemberByEmail :one
+SELECT EXISTS (
+    SELECT 1 FROM memberships m
+    JOIN users u ON u.id = m.user_id
+    WHERE m.tenant_id = $1 AND u.email = $2
+);
+
+-- name: ClaimInvitation :one
+-- Atomically marks an open invitation accepted; no row when it was already
+-- used, revoked or has expired, so two concurrent accepts cannot both win.
+UPDATE invitations
+SET accepted = TRUE, accepted_at = NOW()
+WHERE token_hash = $1 AND accepted = FALSE AND revoked_at IS NULL AND expires_at > NOW()
+RETURNING *;
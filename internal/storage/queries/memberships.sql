@@ -33,3 +33,16 @@ WHERE user_id = $2 AND tenant_id = $3;
 -- name: RemoveMember :exec
 DELETE FROM memberships
 WHERE user_id = $1 AND tenant_id = $2;
+
+-- name: ListUserTenants :many
+-- Every active tenant the user belongs to, for the tenant switcher.
+SELECT
+    t.id,
+    t.name,
+    t.slug,
+    m.role,
+    m.created_at as joined_at
+FROM memberships m
+JOIN tenants t ON m.tenant_id = t.id
+WHERE m.user_id = $1 AND t.is_active = TRUE
+ORDER BY t.name;
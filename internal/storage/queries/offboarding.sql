@@ -0,0 +1,168 @@
+-- Tenant offboarding (platform plane + janitor). Every query here runs through storage.WithoutRLS.
+
+-- name: ScheduleTenantOffboarding :one
+INSERT INTO tenant_offboarding (tenant_id, tenant_slug, tenant_name, requested_by, reason, purge_after)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetTenantOffboarding :one
+SELECT * FROM tenant_offboarding
+WHERE tenant_id = $1 LIMIT 1;
+
+-- name: ListTenantOffboardings :many
+SELECT * FROM tenant_offboarding
+ORDER BY requested_at DESC
+LIMIT $1;
+
+-- name: CancelTenantOffboarding :execrows
+-- Only during the grace period: once the worker has started, the purge runs to the end.
+DELETE FROM tenant_offboarding
+WHERE tenant_id = $1 AND status = 'scheduled';
+
+-- name: ClaimDueTenantOffboarding :one
+-- Picks a job whose grace period is over, or a running job whose lease expired (crashed worker).
+UPDATE tenant_offboarding
+SET status = 'running',
+    started_at = COALESCE(started_at, NOW()),
+    leased_until = NOW() + INTERVAL '15 minutes',
+    attempts = attempts + 1
+WHERE tenant_id = (
+    SELECT o.tenant_id FROM tenant_offboarding o
+    WHERE o.purge_after <= NOW()
+      AND (o.status = 'scheduled'
+           OR (o.status = 'running' AND (o.leased_until IS NULL OR o.leased_until < NOW())))
+    ORDER BY o.purge_after
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateTenantOffboardingProgress :exec
+-- Every progress report extends the lease.
+UPDATE tenant_offboarding
+SET step = $2, progress = $3, leased_until = NOW() + INTERVAL '15 minutes'
+WHERE tenant_id = $1;
+
+-- name: SetTenantOffboardingExport :exec
+UPDATE tenant_offboarding
+SET export_path = $2, export_sha256 = $3
+WHERE tenant_id = $1;
+
+-- name: FailTenantOffboarding :exec
+-- Backs off until the next janitor cycle, which resumes at the recorded step.
+UPDATE tenant_offboarding
+SET last_error = $2, leased_until = NOW() + INTERVAL '30 minutes'
+WHERE tenant_id = $1;
+
+-- name: CompleteTenantOffboarding :exec
+UPDATE tenant_offboarding
+SET status = 'completed', step = 'done', completed_at = NOW(), leased_until = NULL, last_error = NULL
+WHERE tenant_id = $1;
+
+-- name: ExportTenantSnapshot :one
+-- Data export before the purge. Credentials (password hashes, MFA secrets,
+-- device secrets, token hashes) are never exported.
+SELECT json_build_object(
+    'tenant', (SELECT json_build_object('id', t.id, 'name', t.name, 'slug', t.slug, 'app_url', t.app_url, 'created_at', t.created_at)
+               FROM tenants t WHERE t.id = $1),
+    'users', COALESCE((SELECT json_agg(json_build_object('id', u.id, 'email', u.email, 'full_name', u.full_name,
+                  'is_email_verified', u.is_email_verified, 'mfa_enabled', u.mfa_enabled, 'created_at', u.created_at))
+               FROM users u WHERE u.tenant_id = $1), '[]'::json),
+    'memberships', COALESCE((SELECT json_agg(json_build_object('user_id', m.user_id, 'role', m.role, 'created_at', m.created_at))
+               FROM memberships m WHERE m.tenant_id = $1), '[]'::json),
+    'roles', COALESCE((SELECT json_agg(json_build_object('name', r.name, 'description', r.description, 'permissions', r.permissions))
+               FROM tenant_roles r WHERE r.tenant_id = $1), '[]'::json),
+    'domains', COALESCE((SELECT json_agg(d.domain) FROM tenant_domains d WHERE d.tenant_id = $1), '[]'::json),
+    'invitations', COALESCE((SELECT json_agg(json_build_object('email', i.email, 'role', i.role, 'accepted', i.accepted,
+                  'expires_at', i.expires_at, 'created_at', i.created_at))
+               FROM invitations i WHERE i.tenant_id = $1), '[]'::json),
+    'iot_devices', COALESCE((SELECT json_agg(json_build_object('device_id', dv.device_id, 'name', dv.name, 'is_active', dv.is_active,
+                  'last_seen_at', dv.last_seen_at, 'created_at', dv.created_at))
+               FROM iot_devices dv WHERE dv.tenant_id = $1), '[]'::json),
+    'email_logs', COALESCE((SELECT json_agg(json_build_object('template_type', e.template_type, 'status', e.status,
+                  'created_at', e.created_at, 'sent_at', e.sent_at))
+               FROM email_logs e WHERE e.tenant_id = $1), '[]'::json)
+)::jsonb AS snapshot;
+
+-- Purge batches, in dependency order (see offboarding.Steps). Each deletes at most
+-- $2 rows so a large tenant never holds one long transaction.
+
+-- name: RehomeTenantUsers :execrows
+-- Users homed in the tenant who are also members of another tenant move their
+-- home there (oldest membership first), so neither the purge nor the
+-- users.tenant_id cascade touches their account. Tenants where the address is
+-- already taken are skipped; a user with no other option is purged.
+UPDATE users u
+SET tenant_id = (SELECT m.tenant_id FROM memberships m
+                 WHERE m.user_id = u.id AND m.tenant_id <> $1
+                   AND NOT EXISTS (SELECT 1 FROM users o WHERE o.tenant_id = m.tenant_id AND o.email = u.email)
+                 ORDER BY m.created_at, m.tenant_id LIMIT 1),
+    updated_at = NOW()
+WHERE u.id IN (SELECT c.id FROM users c
+               WHERE c.tenant_id = $1
+                 AND EXISTS (SELECT 1 FROM memberships m
+                             WHERE m.user_id = c.id AND m.tenant_id <> $1
+                               AND NOT EXISTS (SELECT 1 FROM users o WHERE o.tenant_id = m.tenant_id AND o.email = c.email))
+               LIMIT $2);
+
+-- name: PurgeTenantEmailOutbox :execrows
+DELETE FROM email_outbox
+WHERE id IN (SELECT id FROM email_outbox WHERE tenant_id = $1 LIMIT $2);
+
+-- name: PurgeTenantEmailLogs :execrows
+DELETE FROM email_logs
+WHERE id IN (SELECT id FROM email_logs WHERE tenant_id = $1 LIMIT $2);
+
+-- name: PurgeTenantRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE id IN (SELECT rt.id FROM refresh_tokens rt
+             WHERE rt.tenant_id = $1 OR rt.user_id IN (SELECT u.id FROM users u WHERE u.tenant_id = $1)
+             LIMIT $2);
+
+-- name: PurgeTenantVerificationTokens :execrows
+DELETE FROM verification_tokens
+WHERE id IN (SELECT vt.id FROM verification_tokens vt
+             WHERE vt.tenant_id = $1 OR vt.user_id IN (SELECT u.id FROM users u WHERE u.tenant_id = $1)
+             LIMIT $2);
+
+-- name: PurgeTenantMfaBackupCodes :execrows
+DELETE FROM mfa_backup_codes
+WHERE id IN (SELECT c.id FROM mfa_backup_codes c
+             WHERE c.user_id IN (SELECT u.id FROM users u WHERE u.tenant_id = $1)
+             LIMIT $2);
+
+-- name: PurgeTenantEmailChangeRequests :execrows
+DELETE FROM email_change_requests
+WHERE id IN (SELECT ecr.id FROM email_change_requests ecr
+             WHERE ecr.user_id IN (SELECT u.id FROM users u WHERE u.tenant_id = $1)
+             LIMIT $2);
+
+-- name: PurgeTenantInvitations :execrows
+DELETE FROM invitations
+WHERE id IN (SELECT id FROM invitations WHERE tenant_id = $1 LIMIT $2);
+
+-- name: PurgeTenantIoTDevices :execrows
+DELETE FROM iot_devices
+WHERE id IN (SELECT id FROM iot_devices WHERE tenant_id = $1 LIMIT $2);
+
+-- name: PurgeTenantRateLimitEvents :execrows
+DELETE FROM rate_limit_events
+WHERE id IN (SELECT id FROM rate_limit_events WHERE tenant_id = $1 LIMIT $2);
+
+-- name: PurgeTenantDomains :execrows
+DELETE FROM tenant_domains
+WHERE id IN (SELECT id FROM tenant_domains WHERE tenant_id = $1 LIMIT $2);
+
+-- name: PurgeTenantRoles :execrows
+DELETE FROM tenant_roles
+WHERE id IN (SELECT id FROM tenant_roles WHERE tenant_id = $1 LIMIT $2);
+
+-- name: PurgeTenantMemberships :execrows
+DELETE FROM memberships
+WHERE id IN (SELECT m.id FROM memberships m
+             WHERE m.tenant_id = $1 OR m.user_id IN (SELECT u.id FROM users u WHERE u.tenant_id = $1)
+             LIMIT $2);
+
+-- name: PurgeTenantUsers :execrows
+DELETE FROM users
+WHERE id IN (SELECT id FROM users WHERE tenant_id = $1 LIMIT $2);
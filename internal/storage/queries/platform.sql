@@ -0,0 +1,55 @@
+-- Platform plane (/platform/v1). Every query here runs through storage.WithoutRLS.
+
+-- name: CreatePlatformAdmin :one
+INSERT INTO platform_admins (email, password_hash, mfa_secret)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPlatformAdminByEmail :one
+SELECT * FROM platform_admins
+WHERE email = $1 LIMIT 1;
+
+-- name: GetPlatformAdminByID :one
+SELECT * FROM platform_admins
+WHERE id = $1 LIMIT 1;
+
+-- name: TouchPlatformAdminLogin :exec
+UPDATE platform_admins
+SET last_login_at = NOW()
+WHERE id = $1;
+
+-- name: ListPlatformTenants :many
+SELECT
+    t.id, t.name, t.slug, t.app_url, t.is_active, t.created_at,
+    (SELECT COUNT(*) FROM memberships m WHERE m.tenant_id = t.id) AS member_count,
+    (SELECT COUNT(*) FROM refresh_tokens rt
+        WHERE rt.tenant_id = t.id AND rt.is_revoked = FALSE AND rt.expires_at > NOW()) AS active_sessions
+FROM tenants t
+ORDER BY t.created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: SetTenantActive :execrows
+UPDATE tenants
+SET is_active = $2
+WHERE id = $1;
+
+-- name: RevokeTenantRefreshTokens :execrows
+-- Suspension: every session of the tenant ends at its next refresh
+UPDATE refresh_tokens
+SET is_revoked = TRUE, revoked_at = NOW()
+WHERE tenant_id = $1 AND is_revoked = FALSE;
+
+-- name: DeleteTenant :execrows
+-- Memberships, users, tokens, domains, roles and mail data cascade (see FKs).
+DELETE FROM tenants
+WHERE id = $1;
+
+-- name: GetPlatformStats :one
+SELECT
+    (SELECT COUNT(*) FROM tenants) AS tenants_total,
+    (SELECT COUNT(*) FROM tenants WHERE is_active = TRUE) AS tenants_active,
+    (SELECT COUNT(*) FROM users) AS users_total,
+    (SELECT COUNT(*) FROM memberships) AS memberships_total,
+    (SELECT COUNT(*) FROM refresh_tokens WHERE is_revoked = FALSE AND expires_at > NOW()) AS active_sessions,
+    (SELECT COUNT(*) FROM audit_logs
+        WHERE action = 'auth.login.success' AND timestamp > NOW() - INTERVAL '24 hours') AS logins_24h;
@@ -0,0 +1,35 @@
+-- name: IncrementRateLimitBucket :one
+-- Atomically counts one hit in a fixed window (shared across API replicas)
+INSERT INTO rate_limit_buckets (bucket_key, window_start, hits, expires_at)
+VALUES ($1, $2, 1, $3)
+ON CONFLICT (bucket_key, window_start)
+DO UPDATE SET hits = rate_limit_buckets.hits + 1
+RETURNING hits;
+
+-- name: GetRateLimitBucketHits :one
+-- Reads a counter without incrementing it (adaptive challenge thresholds)
+SELECT hits FROM rate_limit_buckets
+WHERE bucket_key = $1 AND window_start = $2;
+
+-- name: CreateRateLimitEvent :exec
+INSERT INTO rate_limit_events (
+    tenant_id, policy, key_type, subject_hash, ip_address, path
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+-- name: ListRateLimitEvents :many
+-- Dashboard query: most recent limit hits for a tenant
+SELECT * FROM rate_limit_events
+WHERE tenant_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: GetRateLimitStats :many
+-- Dashboard query: limit hits per policy over the last 24 hours
+SELECT policy, key_type, COUNT(*) AS hit_count, MAX(created_at)::timestamptz AS last_hit_at
+FROM rate_limit_events
+WHERE tenant_id = $1
+  AND created_at > NOW() - INTERVAL '24 hours'
+GROUP BY policy, key_type
+ORDER BY hit_count DESC;
@@ -0,0 +1,49 @@
+-- name: CreateSignupRequest :one
+INSERT INTO signup_requests (
+    tenant_id, email, full_name, password_hash, method, confirm_token_hash, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: DeletePendingDomainSignup :exec
+-- Signing up again replaces an unconfirmed link instead of colliding with it.
+DELETE FROM signup_requests
+WHERE tenant_id = $1 AND email = $2 AND method = 'domain' AND status = 'pending';
+
+-- name: ListSignupRequests :many
+-- The admin approval queue; domain confirmations never need an admin.
+SELECT * FROM signup_requests
+WHERE tenant_id = sqlc.arg(tenant_id) AND method = 'approval'
+  AND (sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountSignupRequests :one
+SELECT COUNT(*) FROM signup_requests
+WHERE tenant_id = sqlc.arg(tenant_id) AND method = 'approval'
+  AND (sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text);
+
+-- name: GetPendingSignupRequest :one
+-- Locks the request so two admins cannot both approve it.
+SELECT * FROM signup_requests
+WHERE id = $1 AND tenant_id = $2 AND method = 'approval' AND status = 'pending'
+FOR UPDATE;
+
+-- name: DecideSignupRequest :one
+-- The stored password hash is only needed until the decision.
+UPDATE signup_requests
+SET status = $3, decided_by = $4, decided_at = NOW(), user_id = $5, password_hash = ''
+WHERE id = $1 AND tenant_id = $2 AND status = 'pending'
+RETURNING *;
+
+-- name: ClaimDomainSignup :one
+-- Redeems the emailed confirmation link; no row when it was used or has expired.
+UPDATE signup_requests
+SET status = 'approved', decided_at = NOW()
+WHERE confirm_token_hash = $1 AND method = 'domain' AND status = 'pending' AND expires_at > NOW()
+RETURNING *;
+
+-- name: SetSignupRequestUser :exec
+UPDATE signup_requests
+SET user_id = $2, password_hash = ''
+WHERE id = $1;
@@ -0,0 +1,36 @@
+-- name: GetTenantIDByDomain :one
+-- Resolver lookup: verified custom hostname -> active tenant
+SELECT td.tenant_id
+FROM tenant_domains td
+JOIN tenants t ON t.id = td.tenant_id
+WHERE td.domain = $1 AND td.verified_at IS NOT NULL AND t.is_active = TRUE
+LIMIT 1;
+
+-- name: ListTenantDomains :many
+SELECT * FROM tenant_domains
+WHERE tenant_id = $1
+ORDER BY domain ASC;
+
+-- name: GetTenantDomain :one
+SELECT * FROM tenant_domains
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: CreateTenantDomain :one
+INSERT INTO tenant_domains (tenant_id, domain, verification_token)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: MarkTenantDomainVerified :one
+UPDATE tenant_domains
+SET verified_at = COALESCE(verified_at, NOW()), last_checked_at = NOW()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: MarkTenantDomainChecked :exec
+UPDATE tenant_domains
+SET last_checked_at = NOW()
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: DeleteTenantDomain :execrows
+DELETE FROM tenant_domains
+WHERE id = $1 AND tenant_id = $2;
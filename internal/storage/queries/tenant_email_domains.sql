@@ -0,0 +1,33 @@
+-- name: CreateEmailDomain :one
+INSERT INTO tenant_email_domains (tenant_id, domain, verification_token, auto_join)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListEmailDomains :many
+SELECT * FROM tenant_email_domains
+WHERE tenant_id = $1
+ORDER BY domain ASC;
+
+-- name: GetEmailDomain :one
+SELECT * FROM tenant_email_domains
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: GetAutoJoinDomain :one
+-- Signup lookup: a proven domain of the tenant that admits its addresses automatically.
+SELECT * FROM tenant_email_domains
+WHERE tenant_id = $1 AND domain = $2 AND verified_at IS NOT NULL AND auto_join = TRUE;
+
+-- name: MarkEmailDomainVerified :one
+UPDATE tenant_email_domains
+SET verified_at = COALESCE(verified_at, NOW()), last_checked_at = NOW()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: MarkEmailDomainChecked :exec
+UPDATE tenant_email_domains
+SET last_checked_at = NOW()
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: DeleteEmailDomain :execrows
+DELETE FROM tenant_email_domains
+WHERE id = $1 AND tenant_id = $2;
@@ -0,0 +1,33 @@
+-- name: ListTenantRoles :many
+SELECT * FROM tenant_roles
+WHERE tenant_id = $1
+ORDER BY name ASC;
+
+-- name: GetTenantRoleByName :one
+SELECT * FROM tenant_roles
+WHERE tenant_id = $1 AND name = $2;
+
+-- name: GetTenantRoleForUpdate :one
+-- Locks the role: concurrent edits are checked against what they replace.
+SELECT * FROM tenant_roles
+WHERE id = $1 AND tenant_id = $2
+FOR UPDATE;
+
+-- name: CreateTenantRole :one
+INSERT INTO tenant_roles (tenant_id, name, description, permissions)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpdateTenantRole :one
+UPDATE tenant_roles
+SET description = $3, permissions = $4, updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: DeleteTenantRole :execrows
+DELETE FROM tenant_roles
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: CountMembersWithRole :one
+SELECT COUNT(*) FROM memberships
+WHERE tenant_id = $1 AND role = $2;
@@ -33,6 +33,11 @@ RETURNING *;
 SELECT allowed_origins, app_url FROM tenants
 WHERE id = $1;
 
+-- name: ListTenantCORSPolicies :many
+-- Loaded in bulk by the CORS policy cache (preflights carry no tenant header)
+SELECT id, allowed_origins, settings FROM tenants
+WHERE is_active = TRUE;
+
 -- name: ListShowcaseTenants :many
 SELECT name, slug, app_url, logo_url, description, category, tagline, tags, gallery_urls, social_links
 FROM tenants 
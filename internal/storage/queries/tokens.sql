@@ -55,9 +55,16 @@ INSERT INTO verification_tokens (
 ) RETURNING *;
 
 -- name: GetVerificationToken :one
+-- Locks the token inside a transaction: concurrent redemptions wait for the
+-- first one to consume it.
 SELECT * FROM verification_tokens
-WHERE token_hash = $1 LIMIT 1;
+WHERE token_hash = $1 LIMIT 1
+FOR UPDATE;
 
 -- name: DeleteVerificationToken :exec
 DELETE FROM verification_tokens
 WHERE id = $1;
+
+-- name: CountRecentVerificationTokens :one
+SELECT COUNT(*) FROM verification_tokens
+WHERE user_id = $1 AND type = $2 AND created_at > $3;
@@ -58,8 +58,9 @@ new_membership AS (
     FROM new_user
     RETURNING user_id
 ),
-deleted_invite AS (
-    DELETE FROM invitations 
+accepted_invite AS (
+    UPDATE invitations
+    SET accepted = TRUE, accepted_at = NOW()
     WHERE token_hash = sqlc.arg(token_hash)
 )
 SELECT id, email, created_at FROM new_user;
@@ -69,9 +70,11 @@ SELECT
     u.id, 
     u.email, 
     u.full_name,
+    u.is_email_verified,
     m.role,
     t.id as tenant_id,
-    t.slug as tenant_slug
+    t.slug as tenant_slug,
+    t.settings as tenant_settings
 FROM users u
 JOIN memberships m ON u.id = m.user_id
 JOIN tenants t ON m.tenant_id = t.id
@@ -84,6 +87,12 @@ SET
     updated_at = NOW()
 WHERE id = $2;
 
+-- name: UpdateUserLocale :exec
+-- Language of the user's emails; NULL falls back to the tenant default.
+UPDATE users
+SET locale = $2, updated_at = NOW()
+WHERE id = $1;
+
 -- name: CreateUserWithMembership :one
 -- Atomically creates a user and their default tenant membership
 -- Note: TenantID is now MANDATORY for the user itself.
@@ -108,3 +117,11 @@ new_membership AS (
 SELECT * FROM new_user;
 
 
+
+-- name: GetUserInTenant :one
+-- Like GetUserByID, but also matches tenants the user joined through a
+-- membership (accepted invitations), not only the home tenant.
+SELECT * FROM users
+WHERE id = $1 AND (tenant_id = $2 OR EXISTS (
+    SELECT 1 FROM memberships m WHERE m.user_id = users.id AND m.tenant_id = $2
+)) LIMIT 1;
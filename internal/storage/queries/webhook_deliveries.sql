@@ -0,0 +1,75 @@
+-- name: CreateWebhookDelivery :exec
+-- Queues an event for an endpoint; an event already queued is skipped.
+INSERT INTO webhook_deliveries (tenant_id, endpoint_id, event_id, event_type, payload)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (endpoint_id, event_id) WHERE redelivery_of IS NULL DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Worker query: due deliveries, plus those a crashed worker left in
+-- 'processing' for over five minutes. FOR UPDATE SKIP LOCKED keeps concurrent
+-- workers apart.
+UPDATE webhook_deliveries
+SET status = 'processing',
+    processing_started_at = NOW()
+WHERE id IN (
+    SELECT d.id FROM webhook_deliveries d
+    WHERE (d.status = 'pending' AND d.next_retry_at <= NOW())
+       OR (d.status = 'processing' AND d.processing_started_at < NOW() - INTERVAL '5 minutes')
+    ORDER BY d.created_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkWebhookDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered',
+    attempt_count = attempt_count + 1,
+    response_status = $2,
+    response_body = $3,
+    duration_ms = $4,
+    last_error = NULL,
+    last_attempt_at = NOW(),
+    delivered_at = NOW(),
+    next_retry_at = NULL
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- Schedules a retry (1 minute doubling per attempt), or gives up after max_attempts.
+UPDATE webhook_deliveries
+SET status = CASE
+        WHEN attempt_count + 1 >= max_attempts THEN 'failed'
+        ELSE 'pending'
+    END,
+    attempt_count = attempt_count + 1,
+    response_status = $2,
+    response_body = $3,
+    duration_ms = $4,
+    last_error = $5,
+    last_attempt_at = NOW(),
+    next_retry_at = CASE
+        WHEN attempt_count + 1 >= max_attempts THEN NULL
+        ELSE NOW() + (POWER(2, attempt_count) * INTERVAL '1 minute')
+    END
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+-- Delivery log of an endpoint, newest first. status is optional.
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg(endpoint_id)
+  AND tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.narg(status)::TEXT IS NULL OR status = sqlc.narg(status)::TEXT)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: RedeliverWebhook :one
+-- Queues the same payload again as a new delivery; the original keeps its log.
+INSERT INTO webhook_deliveries (tenant_id, endpoint_id, event_id, event_type, payload, redelivery_of)
+SELECT tenant_id, endpoint_id, event_id, event_type, payload, id
+FROM webhook_deliveries
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
@@ -0,0 +1,69 @@
+-- name: CreateWebhookEndpoint :one
+-- New endpoints start at the current end of the chain: only events after
+-- the subscription are delivered.
+INSERT INTO webhook_endpoints (tenant_id, url, description, secret_encrypted, event_types, created_by, last_seq)
+VALUES (
+    $1, $2, $3, $4, $5, $6,
+    COALESCE((SELECT h.last_seq FROM audit_chain_heads h WHERE h.chain_key = $1), 0)
+)
+RETURNING *;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE tenant_id = $1
+ORDER BY created_at;
+
+-- name: CountWebhookEndpoints :one
+SELECT COUNT(*) FROM webhook_endpoints
+WHERE tenant_id = $1;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = $3,
+    description = $4,
+    event_types = $5,
+    enabled = $6,
+    next_scan_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: RotateWebhookSecret :execrows
+UPDATE webhook_endpoints
+SET secret_encrypted = $3,
+    updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: DeleteWebhookEndpoint :execrows
+-- Queued deliveries go with it (ON DELETE CASCADE).
+DELETE FROM webhook_endpoints
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: ClaimWebhookEndpointsToScan :many
+-- Leases enabled endpoints for five minutes while their new events are
+-- queued, so concurrent workers skip them and a crashed worker's lease expires.
+UPDATE webhook_endpoints
+SET next_scan_at = NOW() + INTERVAL '5 minutes'
+WHERE id IN (
+    SELECT e.id FROM webhook_endpoints e
+    WHERE e.enabled AND e.next_scan_at <= NOW()
+    ORDER BY e.next_scan_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: AdvanceWebhookEndpoint :exec
+-- Moves the cursor past queued events; runs in the transaction that queued them.
+UPDATE webhook_endpoints
+SET last_seq = $2
+WHERE id = $1;
+
+-- name: ReleaseWebhookEndpoint :exec
+UPDATE webhook_endpoints
+SET next_scan_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,232 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/crypto"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var errEndpointDisabled = errors.New("endpoint is disabled")
+
+const (
+	// scanClaimLimit is the number of endpoints leased per Enqueue.
+	scanClaimLimit = 20
+	// scanPageSize is the number of chain rows read per transaction.
+	scanPageSize = 500
+	// maxPagesPerScan keeps one busy tenant from holding its lease too long;
+	// the rest is queued on the next tick.
+	maxPagesPerScan = 20
+	// deliveryClaimLimit is the number of deliveries attempted per DeliverDue.
+	deliveryClaimLimit = 50
+)
+
+// Runner moves events into the outbox and delivers it. Run it from cmd/worker.
+type Runner struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+	sender *Sender
+}
+
+func NewRunner(pool *pgxpool.Pool, logger *slog.Logger) *Runner {
+	return &Runner{pool: pool, logger: logger, sender: &Sender{}}
+}
+
+// Enqueue leases endpoints and queues a delivery for every subscribed event
+// past their cursor. It returns the number of deliveries queued.
+func (r *Runner) Enqueue(ctx context.Context) (int64, error) {
+	var endpoints []db.WebhookEndpoint
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		endpoints, err = db.New(tx).ClaimWebhookEndpointsToScan(ctx, scanClaimLimit)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("claim webhook endpoints: %w", err)
+	}
+
+	var total int64
+	for _, endpoint := range endpoints {
+		queued, err := r.scan(ctx, endpoint)
+		total += queued
+		if err != nil {
+			// The lease expires on its own; the cursor only moved past queued pages
+			r.logger.Error("Webhook: Failed to queue events",
+				"endpoint_id", uuid.UUID(endpoint.ID.Bytes),
+				"tenant_id", uuid.UUID(endpoint.TenantID.Bytes),
+				"error", err,
+			)
+			continue
+		}
+		err = storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			return db.New(tx).ReleaseWebhookEndpoint(ctx, endpoint.ID)
+		})
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// scan queues one endpoint's events page by page. Each page is queued and the
+// cursor moved in one transaction, so a crash never loses or repeats a page.
+func (r *Runner) scan(ctx context.Context, endpoint db.WebhookEndpoint) (int64, error) {
+	var queued int64
+	cursor := endpoint.LastSeq
+	for i := 0; i < maxPagesPerScan; i++ {
+		var rows int
+		err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+			q := db.New(tx)
+			page, err := q.ListAuditChainRows(ctx, db.ListAuditChainRowsParams{
+				ChainKey: endpoint.TenantID,
+				AfterSeq: cursor,
+				RowLimit: scanPageSize,
+			})
+			if err != nil {
+				return fmt.Errorf("read audit chain: %w", err)
+			}
+			rows = len(page)
+			if rows == 0 {
+				return nil
+			}
+			for _, row := range page {
+				if !slices.Contains(endpoint.EventTypes, row.Action) {
+					continue
+				}
+				body, err := json.Marshal(NewPayload(row))
+				if err != nil {
+					return fmt.Errorf("encode payload: %w", err)
+				}
+				err = q.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+					TenantID:   endpoint.TenantID,
+					EndpointID: endpoint.ID,
+					EventID:    row.ID,
+					EventType:  row.Action,
+					Payload:    body,
+				})
+				if err != nil {
+					return err
+				}
+				queued++
+			}
+			cursor = page[rows-1].Seq.Int64
+			return q.AdvanceWebhookEndpoint(ctx, db.AdvanceWebhookEndpointParams{
+				ID:      endpoint.ID,
+				LastSeq: cursor,
+			})
+		})
+		if err != nil {
+			return queued, err
+		}
+		if rows < scanPageSize {
+			break
+		}
+	}
+	return queued, nil
+}
+
+// DeliverDue attempts every due delivery once and records the outcome. It
+// returns the number delivered; failures are retried later by the database's
+// backoff (MarkWebhookDeliveryFailed).
+func (r *Runner) DeliverDue(ctx context.Context) (int, error) {
+	var deliveries []db.WebhookDelivery
+	err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		deliveries, err = db.New(tx).ClaimDueWebhookDeliveries(ctx, deliveryClaimLimit)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+
+	// Endpoints are shared by most deliveries in a claim
+	endpoints := make(map[uuid.UUID]db.WebhookEndpoint)
+	delivered := 0
+	for _, d := range deliveries {
+		endpointID := uuid.UUID(d.EndpointID.Bytes)
+		endpoint, ok := endpoints[endpointID]
+		if !ok {
+			err := storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+				var err error
+				endpoint, err = db.New(tx).GetWebhookEndpoint(ctx, db.GetWebhookEndpointParams{
+					ID:       d.EndpointID,
+					TenantID: d.TenantID,
+				})
+				return err
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue // Deleted since the claim; its deliveries went with it
+			}
+			if err != nil {
+				return delivered, fmt.Errorf("load webhook endpoint: %w", err)
+			}
+			endpoints[endpointID] = endpoint
+		}
+
+		result, err := r.attempt(ctx, endpoint, d)
+		if err != nil {
+			r.logger.Warn("Webhook: Delivery failed",
+				"delivery_id", uuid.UUID(d.ID.Bytes),
+				"endpoint_id", endpointID,
+				"tenant_id", uuid.UUID(d.TenantID.Bytes),
+				"event_type", d.EventType,
+				"attempt", d.AttemptCount+1,
+				"error", err,
+			)
+		}
+		if markErr := r.record(ctx, d.ID, result, err); markErr != nil {
+			return delivered, markErr
+		}
+		if err == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// attempt sends one delivery. A disabled endpoint fails it, so the delivery
+// stays in the log and can be redelivered once the endpoint is back.
+func (r *Runner) attempt(ctx context.Context, endpoint db.WebhookEndpoint, d db.WebhookDelivery) (Result, error) {
+	if !endpoint.Enabled {
+		return Result{}, errEndpointDisabled
+	}
+	secret, err := crypto.DecryptTenantSecret(endpoint.SecretEncrypted)
+	if err != nil {
+		return Result{}, fmt.Errorf("decrypt webhook secret: %w", err)
+	}
+	return r.sender.Send(ctx, endpoint.Url, []byte(secret), d.ID.Bytes, d.EventType, d.Payload)
+}
+
+func (r *Runner) record(ctx context.Context, id pgtype.UUID, result Result, sendErr error) error {
+	status := pgtype.Int4{Int32: int32(result.Status), Valid: result.Status != 0}
+	body := pgtype.Text{String: result.Body, Valid: result.Status != 0}
+	duration := pgtype.Int4{Int32: int32(result.Duration.Milliseconds()), Valid: result.Duration > 0}
+	return storage.WithoutRLS(ctx, r.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		if sendErr == nil {
+			return q.MarkWebhookDelivered(ctx, db.MarkWebhookDeliveredParams{
+				ID:             id,
+				ResponseStatus: status,
+				ResponseBody:   body,
+				DurationMs:     duration,
+			})
+		}
+		return q.MarkWebhookDeliveryFailed(ctx, db.MarkWebhookDeliveryFailedParams{
+			ID:             id,
+			ResponseStatus: status,
+			ResponseBody:   body,
+			DurationMs:     duration,
+			LastError:      pgtype.Text{String: sendErr.Error(), Valid: true},
+		})
+	})
+}
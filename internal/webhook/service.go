@@ -0,0 +1,317 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/crypto"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Delivery statuses (webhook_deliveries.status).
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusDelivered  = "delivered"
+	StatusFailed     = "failed"
+)
+
+// MaxDeliveryPage is the most deliveries one log request returns.
+const MaxDeliveryPage = 100
+
+// Service manages a tenant's endpoints and delivery log for the API.
+type Service struct {
+	pool  *pgxpool.Pool
+	audit *audit.AuditService
+}
+
+func NewService(pool *pgxpool.Pool, audit *audit.AuditService) *Service {
+	return &Service{pool: pool, audit: audit}
+}
+
+// CreateParams configures a new endpoint.
+type CreateParams struct {
+	URL         string
+	Description string
+	EventTypes  []string
+}
+
+// Create adds an endpoint that receives events from now on. It also returns
+// the signing secret; it is stored encrypted and never shown again.
+func (s *Service) Create(ctx context.Context, tenantID, actorID uuid.UUID, p CreateParams) (db.WebhookEndpoint, string, error) {
+	p.URL = strings.TrimSpace(p.URL)
+	p.Description = strings.TrimSpace(p.Description)
+	if err := ValidateURL(p.URL); err != nil {
+		return db.WebhookEndpoint{}, "", err
+	}
+	if len(p.Description) > 200 {
+		return db.WebhookEndpoint{}, "", fmt.Errorf("%w: description is too long", ErrInvalidEndpoint)
+	}
+	types, err := NormalizeEventTypes(p.EventTypes)
+	if err != nil {
+		return db.WebhookEndpoint{}, "", err
+	}
+	secret, encrypted, err := newSecret()
+	if err != nil {
+		return db.WebhookEndpoint{}, "", err
+	}
+
+	var endpoint db.WebhookEndpoint
+	err = storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+		count, err := q.CountWebhookEndpoints(ctx, tid)
+		if err != nil {
+			return err
+		}
+		if count >= MaxEndpointsPerTenant {
+			return ErrTooManyEndpoints
+		}
+		endpoint, err = q.CreateWebhookEndpoint(ctx, db.CreateWebhookEndpointParams{
+			TenantID:        tid,
+			Url:             p.URL,
+			Description:     pgtype.Text{String: p.Description, Valid: p.Description != ""},
+			SecretEncrypted: encrypted,
+			EventTypes:      types,
+			CreatedBy:       pgtype.UUID{Bytes: actorID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return db.WebhookEndpoint{}, "", err
+	}
+
+	s.audit.Log(ctx, audit.EventWebhookCreated, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		TargetID: endpoint.ID.Bytes,
+		Metadata: map[string]interface{}{
+			"url":         endpoint.Url,
+			"event_types": endpoint.EventTypes,
+		},
+	})
+	return endpoint, secret, nil
+}
+
+// List returns the tenant's endpoints, oldest first.
+func (s *Service) List(ctx context.Context, tenantID uuid.UUID) ([]db.WebhookEndpoint, error) {
+	var endpoints []db.WebhookEndpoint
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		var err error
+		endpoints, err = q.ListWebhookEndpoints(ctx, pgtype.UUID{Bytes: tenantID, Valid: true})
+		return err
+	})
+	return endpoints, err
+}
+
+// UpdateParams changes an endpoint; nil fields are left as they are.
+type UpdateParams struct {
+	URL         *string
+	Description *string
+	EventTypes  []string
+	Enabled     *bool
+}
+
+// Update changes an endpoint. A disabled endpoint keeps its cursor, so enabling
+// it again queues everything that happened in between.
+func (s *Service) Update(ctx context.Context, tenantID, actorID, endpointID uuid.UUID, p UpdateParams) (db.WebhookEndpoint, error) {
+	var endpoint db.WebhookEndpoint
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		current, err := q.GetWebhookEndpoint(ctx, db.GetWebhookEndpointParams{
+			ID:       pgtype.UUID{Bytes: endpointID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		arg := db.UpdateWebhookEndpointParams{
+			ID:          current.ID,
+			TenantID:    current.TenantID,
+			Url:         current.Url,
+			Description: current.Description,
+			EventTypes:  current.EventTypes,
+			Enabled:     current.Enabled,
+		}
+		if p.URL != nil {
+			arg.Url = strings.TrimSpace(*p.URL)
+			if err := ValidateURL(arg.Url); err != nil {
+				return err
+			}
+		}
+		if p.Description != nil {
+			description := strings.TrimSpace(*p.Description)
+			if len(description) > 200 {
+				return fmt.Errorf("%w: description is too long", ErrInvalidEndpoint)
+			}
+			arg.Description = pgtype.Text{String: description, Valid: description != ""}
+		}
+		if p.EventTypes != nil {
+			if arg.EventTypes, err = NormalizeEventTypes(p.EventTypes); err != nil {
+				return err
+			}
+		}
+		if p.Enabled != nil {
+			arg.Enabled = *p.Enabled
+		}
+
+		endpoint, err = q.UpdateWebhookEndpoint(ctx, arg)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.WebhookEndpoint{}, ErrEndpointNotFound
+	}
+	if err != nil {
+		return db.WebhookEndpoint{}, err
+	}
+
+	s.audit.Log(ctx, audit.EventWebhookUpdated, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		TargetID: endpointID,
+		Metadata: map[string]interface{}{
+			"url":         endpoint.Url,
+			"event_types": endpoint.EventTypes,
+			"enabled":     endpoint.Enabled,
+		},
+	})
+	return endpoint, nil
+}
+
+// RotateSecret replaces the signing secret and returns the new one. Deliveries
+// from then on, retries included, are signed with it.
+func (s *Service) RotateSecret(ctx context.Context, tenantID, actorID, endpointID uuid.UUID) (string, error) {
+	secret, encrypted, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+	err = storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		n, err := q.RotateWebhookSecret(ctx, db.RotateWebhookSecretParams{
+			ID:              pgtype.UUID{Bytes: endpointID, Valid: true},
+			TenantID:        pgtype.UUID{Bytes: tenantID, Valid: true},
+			SecretEncrypted: encrypted,
+		})
+		if err == nil && n == 0 {
+			return ErrEndpointNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.audit.Log(ctx, audit.EventWebhookRotated, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		TargetID: endpointID,
+	})
+	return secret, nil
+}
+
+// Delete removes an endpoint together with its delivery log.
+func (s *Service) Delete(ctx context.Context, tenantID, actorID, endpointID uuid.UUID) error {
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		n, err := q.DeleteWebhookEndpoint(ctx, db.DeleteWebhookEndpointParams{
+			ID:       pgtype.UUID{Bytes: endpointID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		})
+		if err == nil && n == 0 {
+			return ErrEndpointNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.EventWebhookDeleted, audit.LogParams{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		TargetID: endpointID,
+	})
+	return nil
+}
+
+// Deliveries returns an endpoint's delivery log, newest first. status is
+// optional; limit is clamped to MaxDeliveryPage.
+func (s *Service) Deliveries(ctx context.Context, tenantID, endpointID uuid.UUID, status string, limit int) ([]db.WebhookDelivery, error) {
+	switch status {
+	case "", StatusPending, StatusProcessing, StatusDelivered, StatusFailed:
+	default:
+		return nil, fmt.Errorf("%w: unknown delivery status %q", ErrInvalidEndpoint, status)
+	}
+	if limit <= 0 || limit > MaxDeliveryPage {
+		limit = MaxDeliveryPage
+	}
+
+	var deliveries []db.WebhookDelivery
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		tid := pgtype.UUID{Bytes: tenantID, Valid: true}
+		_, err := q.GetWebhookEndpoint(ctx, db.GetWebhookEndpointParams{
+			ID:       pgtype.UUID{Bytes: endpointID, Valid: true},
+			TenantID: tid,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEndpointNotFound
+		}
+		if err != nil {
+			return err
+		}
+		deliveries, err = q.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+			EndpointID: pgtype.UUID{Bytes: endpointID, Valid: true},
+			TenantID:   tid,
+			Status:     pgtype.Text{String: status, Valid: status != ""},
+			RowLimit:   int32(limit),
+		})
+		return err
+	})
+	return deliveries, err
+}
+
+// Redeliver queues the payload of an earlier delivery to the endpoint again,
+// whatever its outcome. The new delivery gets its own id and log entry.
+func (s *Service) Redeliver(ctx context.Context, tenantID, endpointID, deliveryID uuid.UUID) (db.WebhookDelivery, error) {
+	var delivery db.WebhookDelivery
+	err := storage.InTenantTx(ctx, s.pool, tenantID, func(q *db.Queries) error {
+		arg := db.GetWebhookDeliveryParams{
+			ID:       pgtype.UUID{Bytes: deliveryID, Valid: true},
+			TenantID: pgtype.UUID{Bytes: tenantID, Valid: true},
+		}
+		original, err := q.GetWebhookDelivery(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if original.EndpointID.Bytes != endpointID {
+			return pgx.ErrNoRows
+		}
+		delivery, err = q.RedeliverWebhook(ctx, db.RedeliverWebhookParams(arg))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.WebhookDelivery{}, ErrDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// newSecret returns a fresh signing secret and its encrypted form.
+func newSecret() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	secret := hex.EncodeToString(raw)
+	encrypted, err := crypto.EncryptTenantSecret(secret)
+	if err != nil {
+		return "", "", fmt.Errorf("encrypt webhook secret: %w", err)
+	}
+	return secret, encrypted, nil
+}
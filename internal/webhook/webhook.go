@@ -0,0 +1,221 @@
+// Package webhook notifies tenant applications of security events.
+//
+// Tenant admins subscribe HTTPS endpoints to event types from the audit
+// catalogue (Service). The worker (Runner) reads each tenant's hash chain from
+// the endpoint's cursor and queues one delivery per matching event in
+// webhook_deliveries, an outbox like email_outbox: every delivery is retried
+// with exponential backoff until it is accepted or runs out of attempts, and
+// its outcome stays in the delivery log, where an admin can redeliver it.
+//
+// Requests are signed like audit sink webhooks (auditsink.Sign), so a receiver
+// can verify both with the same code.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/auditsink"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/mailer"
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
+	"github.com/google/uuid"
+)
+
+// Request headers besides auditsink.HeaderTimestamp and auditsink.HeaderSignature.
+const (
+	HeaderEvent    = "X-LaventeCare-Event"
+	HeaderDelivery = "X-LaventeCare-Delivery" // Changes on redelivery; the payload id does not
+)
+
+const (
+	// MaxEndpointsPerTenant bounds the fan-out of one tenant's events.
+	MaxEndpointsPerTenant = 10
+	// MaxResponseBody is how much of a receiver's response the log keeps.
+	MaxResponseBody = 1024
+	// deliveryTimeout bounds one attempt (connect, write, response).
+	deliveryTimeout = 10 * time.Second
+)
+
+var (
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+	ErrTooManyEndpoints = errors.New("tenant has the maximum number of webhook endpoints")
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrBlockedTarget    = errors.New("security violation: connection to private network blocked")
+	ErrUnexpectedStatus = errors.New("webhook receiver did not accept the event")
+)
+
+// Payload is the JSON body of a delivery. It carries what an application needs
+// to react, not the full audit row: IP address and user agent stay out.
+type Payload struct {
+	ID         uuid.UUID       `json:"id"` // Audit event id; use it to deduplicate
+	Type       string          `json:"type"`
+	Severity   audit.Severity  `json:"severity"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	TenantID   uuid.UUID       `json:"tenant_id"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	TargetID   *uuid.UUID      `json:"target_id,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty"`
+}
+
+func NewPayload(row db.AuditLog) Payload {
+	p := Payload{
+		ID:         row.ID.Bytes,
+		Type:       row.Action,
+		Severity:   audit.SeverityOf(row.Action),
+		OccurredAt: row.Timestamp.Time.UTC(),
+		TenantID:   row.TenantID.Bytes,
+	}
+	if row.ActorID.Valid {
+		id := uuid.UUID(row.ActorID.Bytes)
+		p.ActorID = &id
+	}
+	if row.TargetID.Valid {
+		id := uuid.UUID(row.TargetID.Bytes)
+		p.TargetID = &id
+	}
+	if len(row.Metadata) > 0 {
+		p.Metadata = json.RawMessage(row.Metadata)
+	}
+	return p
+}
+
+// ValidateURL checks an endpoint URL: https, no credentials, and a host that
+// does not resolve to a private network (mailer.ValidateSMTPHost). The sender
+// checks the address again at connect time.
+func ValidateURL(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an https URL", ErrInvalidEndpoint)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: url must not contain credentials", ErrInvalidEndpoint)
+	}
+	if len(endpoint) > 2048 {
+		return fmt.Errorf("%w: url is too long", ErrInvalidEndpoint)
+	}
+	if err := mailer.ValidateSMTPHost(u.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEndpoint, err)
+	}
+	return nil
+}
+
+// NormalizeEventTypes checks that every type is in the audit catalogue and
+// returns them sorted without duplicates.
+func NormalizeEventTypes(types []string) ([]string, error) {
+	if len(types) == 0 {
+		return nil, fmt.Errorf("%w: subscribe to at least one event type", ErrInvalidEndpoint)
+	}
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		if _, ok := audit.Lookup(audit.Event(t)); !ok {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidEndpoint, t)
+		}
+		out = append(out, t)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// Result is the outcome of one attempt, as recorded in the delivery log.
+// Status is 0 when no response was received.
+type Result struct {
+	Status   int
+	Body     string
+	Duration time.Duration
+}
+
+// Sender POSTs signed payloads. The zero value refuses private networks.
+type Sender struct {
+	Dialer    *net.Dialer // nil: refuse private networks (guardedDialer)
+	TLSConfig *tls.Config // Base TLS config; nil uses the system roots
+}
+
+// Send delivers one payload. Any 2xx response accepts it; redirects are not
+// followed and count as a failure.
+func (s *Sender) Send(ctx context.Context, endpoint string, secret []byte, deliveryID uuid.UUID, eventType string, body []byte) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LaventeCare-Webhook/1")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID.String())
+	req.Header.Set(auditsink.HeaderTimestamp, ts)
+	req.Header.Set(auditsink.HeaderSignature, auditsink.Sign(secret, ts, body))
+
+	start := time.Now()
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return Result{Duration: time.Since(start)}, fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	head, _ := io.ReadAll(io.LimitReader(resp.Body, MaxResponseBody))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	// The log stores the body as TEXT: keep it valid UTF-8 without NUL bytes
+	text := strings.ToValidUTF8(strings.ReplaceAll(string(head), "\x00", ""), "\uFFFD")
+	result := Result{Status: resp.StatusCode, Body: text, Duration: time.Since(start)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("%w: status %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+	return result, nil
+}
+
+func (s *Sender) client() *http.Client {
+	dialer := s.Dialer
+	if dialer == nil {
+		dialer = guardedDialer()
+	}
+	cfg := &tls.Config{}
+	if s.TLSConfig != nil {
+		cfg = s.TLSConfig.Clone()
+	}
+	cfg.MinVersion = tls.VersionTLS12
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:     dialer.DialContext,
+			TLSClientConfig: cfg,
+			Proxy:           nil, // A proxy would bypass the dial-time address check
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// guardedDialer checks the resolved IP at connect time, so a DNS record that
+// changes after the endpoint was saved cannot point deliveries inward.
+func guardedDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || mailer.ValidatePublicIP(ip) != nil {
+				return ErrBlockedTarget
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,5 @@
+-- Migration 015 Rollback: Drop rate limit tables
+
+DROP POLICY IF EXISTS rate_limit_events_tenant_isolation ON rate_limit_events;
+DROP TABLE IF EXISTS rate_limit_events;
+DROP TABLE IF EXISTS rate_limit_buckets;
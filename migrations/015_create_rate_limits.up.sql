@@ -0,0 +1,39 @@
+-- Migration 015: Distributed Rate Limiting
+-- Purpose: Shared fixed-window counters so every API replica enforces the same budget,
+--          plus a log of limit hits for the tenant admin dashboard.
+
+-- Counters are ephemeral: UNLOGGED skips WAL (fast, lost on crash - acceptable for rate limits)
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    bucket_key VARCHAR(255) NOT NULL,      -- "<policy>:<key_type>:<sha256(subject)>"
+    window_start TIMESTAMPTZ NOT NULL,
+    hits INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (bucket_key, window_start)
+);
+
+CREATE INDEX idx_rate_limit_buckets_expires_at ON rate_limit_buckets(expires_at);
+
+-- Limit hits (one row per subject per window, the first rejected request)
+CREATE TABLE rate_limit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE, -- NULL = no tenant context
+    policy VARCHAR(100) NOT NULL,
+    key_type VARCHAR(20) NOT NULL CHECK (key_type IN ('ip', 'email', 'tenant', 'user')),
+    subject_hash VARCHAR(64) NOT NULL,     -- Privacy: SHA256 of IP/email/ID
+    ip_address INET,
+    path TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_rate_limit_events_tenant_created ON rate_limit_events(tenant_id, created_at DESC);
+CREATE INDEX idx_rate_limit_events_created_at ON rate_limit_events(created_at);
+
+-- Row Level Security (Strict Tenant Isolation)
+ALTER TABLE rate_limit_events ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY rate_limit_events_tenant_isolation ON rate_limit_events
+    USING (tenant_id::text = current_setting('app.current_tenant', true));
+
+COMMENT ON TABLE rate_limit_buckets IS 'Fixed-window rate limit counters shared by all API replicas. Swept by the janitor worker.';
+COMMENT ON TABLE rate_limit_events IS 'Rate limit rejections (first per subject per window) for the admin dashboard.';
+COMMENT ON COLUMN rate_limit_events.subject_hash IS 'SHA256 of the limited subject (GDPR pseudonymization)';
@@ -0,0 +1,3 @@
+-- Migration 016 Rollback: Drop tenant_domains table
+
+DROP TABLE IF EXISTS tenant_domains;
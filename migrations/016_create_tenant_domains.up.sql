@@ -0,0 +1,18 @@
+-- Migration 016: Tenant Domains (Custom Domain -> Tenant Resolution)
+-- Purpose: Frontends can identify their tenant by hostname instead of sending a raw UUID.
+-- Security: No RLS - the resolver must look up the tenant BEFORE a tenant context exists.
+--           Rows are only writable through the admin API (tenant scoped in the handler).
+
+CREATE TABLE tenant_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    domain VARCHAR(253) NOT NULL,  -- Lowercase hostname without port, e.g. 'login.acme.nl'
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT tenant_domains_domain_lowercase CHECK (domain = LOWER(domain))
+);
+
+-- One hostname can only ever point to one tenant
+CREATE UNIQUE INDEX idx_tenant_domains_domain ON tenant_domains(domain);
+CREATE INDEX idx_tenant_domains_tenant_id ON tenant_domains(tenant_id);
+
+COMMENT ON TABLE tenant_domains IS 'Custom hostnames mapped to tenants for tenant resolution by Host header.';
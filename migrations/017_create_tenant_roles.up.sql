@@ -0,0 +1,28 @@
+-- Migration 017: Tenant Roles (Fine-Grained Permissions)
+-- Purpose: Tenants define custom roles ("billing", "support") as a set of named
+--          permissions (users:read, mail:configure, ...). memberships.role refers to
+--          either a built-in role (admin/editor/viewer, defined in code) or a row here.
+-- Tokens carry the resolved permissions; changes apply on the next token refresh.
+
+CREATE TABLE tenant_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT tenant_roles_name_format CHECK (name ~ '^[a-z][a-z0-9_-]{1,49}$'),
+    -- Built-in role names are reserved (resolved in code)
+    CONSTRAINT tenant_roles_name_reserved CHECK (name NOT IN ('admin', 'editor', 'viewer', 'user'))
+);
+
+CREATE UNIQUE INDEX idx_tenant_roles_tenant_name ON tenant_roles(tenant_id, name);
+
+-- RLS: Same isolation as memberships
+ALTER TABLE tenant_roles ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_tenant_roles ON tenant_roles
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+COMMENT ON TABLE tenant_roles IS 'Tenant-defined roles composed of named permissions.';
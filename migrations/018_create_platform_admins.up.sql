@@ -0,0 +1,24 @@
+-- Migration 018: Platform Operators
+-- Purpose: Identities for the /platform/v1 plane (tenant lifecycle, impersonation,
+--          cross-tenant stats). Deliberately separate from users/memberships: a tenant
+--          admin can never become a platform operator by editing their own tenant.
+-- MFA is mandatory; the TOTP secret is set when the operator is created (cmd/control).
+
+CREATE TABLE platform_admins (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email CITEXT NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    mfa_secret TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_login_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_platform_admins
+BEFORE UPDATE ON platform_admins
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- No RLS: not tenant data. Only reached through storage.WithoutRLS by the platform service.
+
+COMMENT ON TABLE platform_admins IS 'Platform operators for the /platform/v1 API. Separate from tenant users.';
@@ -0,0 +1,39 @@
+-- Migration 019: Tenant Offboarding
+-- Purpose: Tenant deletion is a job, not a single DELETE. The platform plane schedules it
+--          (tenant suspended, grace period starts), the janitor worker exports the tenant
+--          data and purges it table by table. One row per tenant tracks progress so a
+--          crashed worker resumes at the step it was in.
+-- No FK on tenant_id: the row outlives the tenant as the offboarding record.
+
+CREATE TABLE tenant_offboarding (
+    tenant_id UUID PRIMARY KEY,
+    tenant_slug CITEXT NOT NULL,
+    tenant_name VARCHAR(255) NOT NULL,
+    requested_by UUID NOT NULL,            -- platform_admins.id (no FK: operators can be removed)
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'running', 'completed')),
+    step VARCHAR(50) NOT NULL DEFAULT '',  -- Last step started; earlier steps are done
+    progress JSONB NOT NULL DEFAULT '{}',  -- Rows deleted per table
+    export_path TEXT,
+    export_sha256 VARCHAR(64),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    purge_after TIMESTAMPTZ NOT NULL,      -- End of the grace period
+    leased_until TIMESTAMPTZ,              -- Worker lease; an expired lease means the worker died
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_tenant_offboarding_due ON tenant_offboarding(purge_after)
+    WHERE status IN ('scheduled', 'running');
+
+CREATE TRIGGER set_timestamp_tenant_offboarding
+BEFORE UPDATE ON tenant_offboarding
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- No RLS: platform data. Read by the platform service and the janitor via storage.WithoutRLS.
+
+COMMENT ON TABLE tenant_offboarding IS 'Scheduled and running tenant deletions. Survives the tenant as a record of the purge.';
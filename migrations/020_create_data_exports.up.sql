@@ -0,0 +1,39 @@
+-- Migration 020: Personal Data Exports (GDPR Art. 15 / 20)
+-- Purpose: A member (or a tenant admin on their behalf) requests a copy of their data.
+--          The API only queues the request; cmd/worker builds the ZIP archive and the
+--          API serves it through a signed, short-lived download link.
+-- Exports are tenant scoped: each tenant is the controller of its members' data.
+
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL, -- The member or an admin
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'ready', 'failed', 'expired')),
+    file_path TEXT,
+    sha256 VARCHAR(64),
+    size_bytes BIGINT,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ                 -- Archive is deleted from disk after this
+);
+
+-- One open request per member per tenant
+CREATE UNIQUE INDEX idx_data_exports_open ON data_exports(tenant_id, user_id)
+    WHERE status IN ('pending', 'processing');
+CREATE INDEX idx_data_exports_user ON data_exports(tenant_id, user_id, created_at DESC);
+CREATE INDEX idx_data_exports_queue ON data_exports(created_at)
+    WHERE status IN ('pending', 'processing');
+
+-- RLS: Same isolation as memberships. The worker and the download endpoint
+-- (signed link, no tenant context) read through storage.WithoutRLS.
+ALTER TABLE data_exports ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_data_exports ON data_exports
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+COMMENT ON TABLE data_exports IS 'Personal data export requests and the archives built by the worker.';
@@ -0,0 +1,5 @@
+-- NOT VALID: actor IDs of users deleted in the meantime no longer resolve
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_actor_id_fkey
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL NOT VALID;
+
+DROP TABLE IF EXISTS account_deletions;
@@ -0,0 +1,36 @@
+-- Migration 021: Self-Service Account Deletion
+-- Purpose: DELETE /auth/account closes the account at once (sessions revoked, login
+--          refused) and schedules the purge after a cooling-off period. The member can
+--          cancel through the emailed link until then; cmd/worker purges afterwards.
+
+CREATE TABLE account_deletions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,                 -- No FK: the row outlives the user as a pseudonymous record
+    cancel_token_hash VARCHAR(255) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'cancelled', 'completed')),
+    purge_after TIMESTAMPTZ NOT NULL,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    cancelled_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+-- One pending deletion per user; also the lookup used by login
+CREATE UNIQUE INDEX idx_account_deletions_scheduled ON account_deletions(user_id)
+    WHERE status = 'scheduled';
+CREATE INDEX idx_account_deletions_due ON account_deletions(purge_after)
+    WHERE status = 'scheduled';
+
+-- RLS: Requests and cancellations run in tenant context; the worker purges
+-- through storage.WithoutRLS.
+ALTER TABLE account_deletions ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_account_deletions ON account_deletions
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+COMMENT ON TABLE account_deletions IS 'Self-service account deletions waiting for, or past, their cooling-off period.';
+
+-- Audit entries keep the actor ID after the user row is deleted. With no user
+-- behind it the UUID is a pseudonym; ON DELETE SET NULL would erase the trail.
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_actor_id_fkey;
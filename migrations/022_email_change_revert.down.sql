@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_email_change_requests_user_id;
+
+ALTER TABLE email_change_requests
+    DROP COLUMN IF EXISTS reverted_at,
+    DROP COLUMN IF EXISTS revert_expires_at,
+    DROP COLUMN IF EXISTS revert_token_hash,
+    DROP COLUMN IF EXISTS old_email;
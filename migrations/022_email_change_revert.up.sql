@@ -0,0 +1,12 @@
+-- Migration 022: Email Change Notifications & Revert
+-- Purpose: The confirmation link goes to the new address; the old address gets a
+--          notice with a revert link. Reverting before confirmation cancels the
+--          change, afterwards it restores the old address (account takeover defence).
+
+ALTER TABLE email_change_requests
+    ADD COLUMN old_email CITEXT,
+    ADD COLUMN revert_token_hash VARCHAR(255) UNIQUE,
+    ADD COLUMN revert_expires_at TIMESTAMPTZ,  -- Outlives expires_at: the owner may only notice later
+    ADD COLUMN reverted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_email_change_requests_user_id ON email_change_requests(user_id);
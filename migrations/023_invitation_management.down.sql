@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_invitations_tenant_created;
+DROP INDEX IF EXISTS idx_invitations_open_email;
+
+ALTER TABLE invitations
+    DROP COLUMN IF EXISTS send_count,
+    DROP COLUMN IF EXISTS last_sent_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS accepted_at,
+    DROP COLUMN IF EXISTS invited_by;
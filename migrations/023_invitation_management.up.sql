@@ -0,0 +1,30 @@
+-- Migration 023: Invitation Management
+-- Purpose: Admins list, resend and revoke invitations. Accepted invitations are
+--          kept (instead of deleted) so the list shows who joined, and revoked
+--          ones stay visible as history.
+
+ALTER TABLE invitations
+    ADD COLUMN invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN accepted_at TIMESTAMPTZ,
+    ADD COLUMN revoked_at TIMESTAMPTZ,
+    ADD COLUMN last_sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN send_count INT NOT NULL DEFAULT 1;
+
+-- One open invitation per address and tenant: a second invite is a resend.
+-- Older duplicates from before this migration are revoked first.
+UPDATE invitations i
+SET revoked_at = NOW()
+WHERE i.accepted = FALSE
+  AND EXISTS (
+    SELECT 1 FROM invitations newer
+    WHERE newer.tenant_id = i.tenant_id
+      AND newer.email = i.email
+      AND newer.accepted = FALSE
+      AND (newer.created_at, newer.id) > (i.created_at, i.id)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_open_email
+    ON invitations(tenant_id, email)
+    WHERE accepted = FALSE AND revoked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_invitations_tenant_created ON invitations(tenant_id, created_at DESC);
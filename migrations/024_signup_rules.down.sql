@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS signup_requests;
+DROP TABLE IF EXISTS tenant_email_domains;
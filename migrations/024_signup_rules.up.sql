@@ -0,0 +1,56 @@
+-- Migration 024: Signup Rules
+-- Purpose: Self-service signup per tenant. Addresses on an email domain the tenant has
+--          proven through a DNS TXT record join automatically once they confirm the
+--          emailed link; other signups are open, refused or wait in an approval queue
+--          (tenants.settings.signup).
+
+CREATE TABLE tenant_email_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    domain CITEXT NOT NULL,
+    verification_token VARCHAR(64) NOT NULL, -- Published in DNS, not a secret
+    auto_join BOOLEAN NOT NULL DEFAULT TRUE,
+    verified_at TIMESTAMPTZ,
+    last_checked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, domain)
+);
+
+-- Several tenants may claim a domain, only one can prove it
+CREATE UNIQUE INDEX idx_tenant_email_domains_verified ON tenant_email_domains(domain)
+    WHERE verified_at IS NOT NULL;
+
+CREATE TABLE signup_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    email CITEXT NOT NULL,
+    full_name TEXT,
+    password_hash TEXT NOT NULL,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('approval', 'domain')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    confirm_token_hash VARCHAR(255) UNIQUE,  -- domain: emailed confirmation link
+    expires_at TIMESTAMPTZ,                  -- domain: link lifetime
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- Account created on approval
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One open request per address and tenant
+CREATE UNIQUE INDEX idx_signup_requests_open ON signup_requests(tenant_id, email)
+    WHERE status = 'pending';
+CREATE INDEX idx_signup_requests_tenant ON signup_requests(tenant_id, status, created_at DESC);
+
+-- RLS: Admin screens run in tenant context; the confirmation link carries no
+-- tenant and is redeemed through storage.WithoutRLS.
+ALTER TABLE tenant_email_domains ENABLE ROW LEVEL SECURITY;
+ALTER TABLE signup_requests ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_tenant_email_domains ON tenant_email_domains
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+CREATE POLICY tenant_isolation_signup_requests ON signup_requests
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+COMMENT ON TABLE tenant_email_domains IS 'Email domains a tenant claims for automatic signup; verified through a DNS TXT record.';
+COMMENT ON TABLE signup_requests IS 'Self-service signups waiting for admin approval or for the emailed domain confirmation.';
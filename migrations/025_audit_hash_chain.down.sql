@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS audit_checkpoints;
+DROP TABLE IF EXISTS audit_chain_heads;
+
+DROP INDEX IF EXISTS idx_audit_logs_chain;
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_chained;
+ALTER TABLE audit_logs
+    DROP COLUMN IF EXISTS row_hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,49 @@
+-- Migration 025: Tamper-Evident Audit Log
+-- Purpose: Every audit row links to the previous row of the same tenant through
+--          a SHA-256 hash, so editing or deleting a row breaks the chain. The
+--          worker periodically signs the chain heads (audit_checkpoints), which
+--          anchors the chain against a rewrite by someone with database access
+--          but without the JWT signing key. `control audit-verify` walks it.
+--
+-- Chains are per tenant (platform events without tenant share the nil UUID
+-- chain), so writers only contend on their own tenant's head row.
+
+ALTER TABLE audit_logs
+    ADD COLUMN seq BIGINT,
+    ADD COLUMN prev_hash BYTEA,
+    ADD COLUMN row_hash BYTEA;
+
+-- Rows written before this migration stay unchained; every new row must be chained.
+ALTER TABLE audit_logs
+    ADD CONSTRAINT audit_logs_chained CHECK (seq IS NOT NULL AND prev_hash IS NOT NULL AND row_hash IS NOT NULL) NOT VALID;
+
+CREATE UNIQUE INDEX idx_audit_logs_chain
+    ON audit_logs ((COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID)), seq)
+    WHERE seq IS NOT NULL;
+
+-- Current end of each chain. CreateAuditLog advances it and inserts the row in
+-- one statement; the row lock on the head orders writes within a tenant only.
+CREATE TABLE audit_chain_heads (
+    chain_key UUID PRIMARY KEY, -- tenant_id, or the nil UUID for platform events
+    last_seq BIGINT NOT NULL,
+    prev_hash BYTEA NOT NULL, -- Hash before last_hash, returned to the insert
+    last_hash BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE audit_checkpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chain_key UUID NOT NULL,
+    seq BIGINT NOT NULL,
+    row_hash BYTEA NOT NULL,
+    signature TEXT NOT NULL, -- RS256 JWT over chain, seq and hash (JWT signing key)
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (chain_key, seq)
+);
+
+-- Like audit_logs itself: append-only.
+REVOKE UPDATE, DELETE ON audit_checkpoints FROM PUBLIC;
+
+-- No RLS: platform data, only read by the worker and cmd/control.
+COMMENT ON TABLE audit_chain_heads IS 'Last sequence number and hash of each audit log hash chain.';
+COMMENT ON TABLE audit_checkpoints IS 'Signed audit chain heads; anchors for control audit-verify.';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_audit_logs_metadata;
+DROP INDEX IF EXISTS idx_audit_logs_ip;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_action;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_target;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_actor;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_keyset;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_id ON audit_logs(tenant_id);
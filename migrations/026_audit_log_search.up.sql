@@ -0,0 +1,22 @@
+-- Migration 026: Audit Log Search
+-- Purpose: GET /admin/audit-logs filters (action prefix, actor, target, time
+--          range, IP, metadata keys) and GET /auth/activity page with a keyset
+--          cursor on (timestamp, id) instead of OFFSET, newest first.
+
+-- Keyset order per tenant; replaces the single-column tenant index
+CREATE INDEX idx_audit_logs_tenant_keyset ON audit_logs (tenant_id, timestamp DESC, id DESC);
+DROP INDEX IF EXISTS idx_audit_logs_tenant_id;
+
+-- Actor/target filters, and a user's own activity (actor OR target)
+CREATE INDEX idx_audit_logs_tenant_actor ON audit_logs (tenant_id, actor_id, timestamp DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_target ON audit_logs (tenant_id, target_id, timestamp DESC, id DESC);
+DROP INDEX IF EXISTS idx_audit_logs_actor_id;
+
+-- Prefix match (action LIKE 'auth.%') needs pattern ops under a non-C collation
+CREATE INDEX idx_audit_logs_tenant_action ON audit_logs (tenant_id, action varchar_pattern_ops);
+
+-- IP or CIDR containment (ip_address <<= '10.0.0.0/8')
+CREATE INDEX idx_audit_logs_ip ON audit_logs USING GIST (ip_address inet_ops);
+
+-- Metadata key existence (metadata ?& ARRAY['key'])
+CREATE INDEX idx_audit_logs_metadata ON audit_logs USING GIN (metadata);
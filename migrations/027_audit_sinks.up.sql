@@ -0,0 +1,43 @@
+-- Migration 027: Audit Sinks
+-- Purpose: Stream a tenant's audit events to its SIEM: RFC 5424 syslog over
+--          TCP/TLS or signed HTTPS webhooks. The worker delivers in chain order
+--          (audit_logs.seq, migration 025) from a durable cursor, with backoff
+--          on failure; the delivery status is shown to tenant admins.
+
+CREATE TABLE audit_sinks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('syslog', 'webhook')),
+    endpoint TEXT NOT NULL,                -- syslog: host:port; webhook: https URL
+    use_tls BOOLEAN NOT NULL DEFAULT TRUE, -- syslog only: TLS (RFC 5425) or plain TCP (RFC 6587)
+    secret_encrypted TEXT,                 -- webhook: HMAC key (crypto.EncryptTenantSecret)
+    action_filter VARCHAR(255),            -- Exact action, or a prefix with a trailing '*'
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Durable cursor: seq of the last delivered event in the tenant's chain
+    last_seq BIGINT NOT NULL DEFAULT 0,
+
+    -- Delivery status
+    delivered_count BIGINT NOT NULL DEFAULT 0,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    last_delivery_at TIMESTAMPTZ,
+    last_error TEXT,
+    last_error_at TIMESTAMPTZ,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- Also the worker's lease
+
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_sinks_tenant ON audit_sinks(tenant_id);
+CREATE INDEX idx_audit_sinks_due ON audit_sinks(next_attempt_at) WHERE enabled;
+
+-- RLS: Admin screens run in tenant context; the worker uses storage.WithoutRLS.
+ALTER TABLE audit_sinks ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_audit_sinks ON audit_sinks
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+COMMENT ON TABLE audit_sinks IS 'Per-tenant SIEM destinations for audit events, with their delivery cursor and status.';
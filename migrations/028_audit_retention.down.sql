@@ -0,0 +1,61 @@
+DROP TABLE IF EXISTS audit_logs_restored;
+DROP TABLE IF EXISTS audit_archives;
+DROP TABLE IF EXISTS audit_retention_policies;
+
+-- Back to a plain table. Rows of dropped partitions only exist in the archives.
+ALTER TABLE audit_logs RENAME TO audit_logs_partitioned;
+ALTER TABLE audit_logs_partitioned DROP CONSTRAINT audit_logs_pkey;
+DROP INDEX IF EXISTS idx_audit_logs_timestamp;
+DROP INDEX IF EXISTS idx_audit_logs_action;
+DROP INDEX IF EXISTS idx_audit_logs_chain;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_keyset;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_actor;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_target;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_action;
+DROP INDEX IF EXISTS idx_audit_logs_ip;
+DROP INDEX IF EXISTS idx_audit_logs_metadata;
+
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_id UUID,
+    session_id UUID,
+    tenant_id UUID,
+    action VARCHAR(255) NOT NULL,
+    target_id UUID,
+    metadata JSONB DEFAULT '{}'::JSONB,
+    ip_address INET,
+    user_agent TEXT,
+    request_id TEXT,
+    seq BIGINT,
+    prev_hash BYTEA,
+    row_hash BYTEA
+);
+
+INSERT INTO audit_logs SELECT * FROM audit_logs_partitioned;
+DROP TABLE audit_logs_partitioned;
+DROP FUNCTION IF EXISTS create_audit_log_partition(DATE);
+
+ALTER TABLE audit_logs
+    ADD CONSTRAINT audit_logs_chained CHECK (seq IS NOT NULL AND prev_hash IS NOT NULL AND row_hash IS NOT NULL) NOT VALID;
+
+CREATE INDEX idx_audit_logs_timestamp ON audit_logs (timestamp DESC);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action);
+CREATE UNIQUE INDEX idx_audit_logs_chain
+    ON audit_logs ((COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID)), seq)
+    WHERE seq IS NOT NULL;
+CREATE INDEX idx_audit_logs_tenant_keyset ON audit_logs (tenant_id, timestamp DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_actor ON audit_logs (tenant_id, actor_id, timestamp DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_target ON audit_logs (tenant_id, target_id, timestamp DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_action ON audit_logs (tenant_id, action varchar_pattern_ops);
+CREATE INDEX idx_audit_logs_ip ON audit_logs USING GIST (ip_address inet_ops);
+CREATE INDEX idx_audit_logs_metadata ON audit_logs USING GIN (metadata);
+
+REVOKE UPDATE, DELETE ON audit_logs FROM PUBLIC;
+REVOKE UPDATE, DELETE ON audit_logs FROM "user";
+
+ALTER TABLE audit_logs ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_audit_logs_read ON audit_logs
+    FOR SELECT
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
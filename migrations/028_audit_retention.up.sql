@@ -0,0 +1,207 @@
+-- Migration 028: Audit Log Retention & Archival
+-- Purpose: audit_logs is partitioned by month (UTC). Once every tenant with
+--          rows in a month is past its retention, the worker archives the month
+--          to gzipped NDJSON on the blob store (one file per hash chain,
+--          audit_archives) and drops the partition. Tenants choose their
+--          retention (audit_retention_policies); the platform minimum
+--          (AUDIT_RETENTION_MIN_DAYS) always applies on top.
+--          `control audit-restore` loads an archive into audit_logs_restored.
+--
+-- Dropping a partition is the only way rows leave audit_logs: UPDATE and
+-- DELETE stay revoked, on the parent and on every partition.
+
+-- 1. Move the existing table aside. Index names are schema-wide, so its
+--    indexes go now; the table itself goes after the copy.
+ALTER TABLE audit_logs RENAME TO audit_logs_unpartitioned;
+ALTER TABLE audit_logs_unpartitioned DROP CONSTRAINT audit_logs_pkey;
+DROP INDEX IF EXISTS idx_audit_logs_timestamp;
+DROP INDEX IF EXISTS idx_audit_logs_action;
+DROP INDEX IF EXISTS idx_audit_logs_chain;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_keyset;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_actor;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_target;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_action;
+DROP INDEX IF EXISTS idx_audit_logs_ip;
+DROP INDEX IF EXISTS idx_audit_logs_metadata;
+
+-- 2. Same columns in the same order (queries select them positionally).
+--    Unique indexes must contain the partition key: the primary key becomes
+--    (id, timestamp), and seq is no longer unique per chain in the index. The
+--    chain head row lock (CreateAuditLog, LockAuditChainHead) already hands
+--    out every seq once, and the verifier reports duplicates as broken links.
+CREATE TABLE audit_logs (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_id UUID,
+    session_id UUID,
+    tenant_id UUID,
+    action VARCHAR(255) NOT NULL,
+    target_id UUID,
+    metadata JSONB DEFAULT '{}'::JSONB,
+    ip_address INET,
+    user_agent TEXT,
+    request_id TEXT,
+    seq BIGINT,
+    prev_hash BYTEA,
+    row_hash BYTEA,
+    PRIMARY KEY (id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+-- Partitioned tables do not take NOT VALID constraints: exempt the unchained
+-- rows from before migration 025 by time instead.
+DO $$
+DECLARE
+    legacy_until TIMESTAMPTZ;
+BEGIN
+    SELECT MAX(timestamp) INTO legacy_until FROM audit_logs_unpartitioned WHERE seq IS NULL;
+    EXECUTE format(
+        'ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_chained CHECK ((seq IS NOT NULL AND prev_hash IS NOT NULL AND row_hash IS NOT NULL) OR timestamp <= %L)',
+        COALESCE(legacy_until, '-infinity'::TIMESTAMPTZ));
+END $$;
+
+CREATE INDEX idx_audit_logs_timestamp ON audit_logs (timestamp DESC);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX idx_audit_logs_chain
+    ON audit_logs ((COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::UUID)), seq)
+    WHERE seq IS NOT NULL;
+CREATE INDEX idx_audit_logs_tenant_keyset ON audit_logs (tenant_id, timestamp DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_actor ON audit_logs (tenant_id, actor_id, timestamp DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_target ON audit_logs (tenant_id, target_id, timestamp DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_action ON audit_logs (tenant_id, action varchar_pattern_ops);
+CREATE INDEX idx_audit_logs_ip ON audit_logs USING GIST (ip_address inet_ops);
+CREATE INDEX idx_audit_logs_metadata ON audit_logs USING GIN (metadata);
+
+REVOKE UPDATE, DELETE ON audit_logs FROM PUBLIC;
+REVOKE UPDATE, DELETE ON audit_logs FROM "user";
+
+ALTER TABLE audit_logs ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_audit_logs_read ON audit_logs
+    FOR SELECT
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+-- 3. Rows without a month partition land here instead of failing the insert
+--    (worker down for months, or a spooled event of an already dropped month).
+--    The worker logs a warning while it holds rows.
+CREATE TABLE audit_logs_default PARTITION OF audit_logs DEFAULT;
+REVOKE UPDATE, DELETE ON audit_logs_default FROM PUBLIC;
+
+-- 4. Monthly partitions are named audit_logs_YYYY_MM and cover the UTC month.
+--    Idempotent; the worker keeps the next months created. Rows of the month
+--    already in the default partition are routed into the new partition.
+CREATE FUNCTION create_audit_log_partition(month DATE) RETURNS TEXT
+LANGUAGE plpgsql AS $$
+DECLARE
+    lower_bound TIMESTAMPTZ := date_trunc('month', month)::TIMESTAMP AT TIME ZONE 'UTC';
+    upper_bound TIMESTAMPTZ := (date_trunc('month', month) + INTERVAL '1 month')::TIMESTAMP AT TIME ZONE 'UTC';
+    partition_name TEXT := 'audit_logs_' || to_char(month, 'YYYY_MM');
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN partition_name;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM audit_logs_default WHERE timestamp >= lower_bound AND timestamp < upper_bound) THEN
+        -- Rows may not be deleted, so swap the default partition for an empty
+        -- one and insert its rows again through the parent.
+        ALTER TABLE audit_logs DETACH PARTITION audit_logs_default;
+        ALTER TABLE audit_logs_default RENAME TO audit_logs_default_old;
+        CREATE TABLE audit_logs_default PARTITION OF audit_logs DEFAULT;
+        REVOKE UPDATE, DELETE ON audit_logs_default FROM PUBLIC;
+        EXECUTE format('CREATE TABLE %I PARTITION OF audit_logs FOR VALUES FROM (%L) TO (%L)',
+            partition_name, lower_bound, upper_bound);
+        INSERT INTO audit_logs SELECT * FROM audit_logs_default_old;
+        DROP TABLE audit_logs_default_old;
+    ELSE
+        EXECUTE format('CREATE TABLE %I PARTITION OF audit_logs FOR VALUES FROM (%L) TO (%L)',
+            partition_name, lower_bound, upper_bound);
+    END IF;
+    EXECUTE format('REVOKE UPDATE, DELETE ON %I FROM PUBLIC', partition_name);
+    RETURN partition_name;
+END $$;
+
+-- 5. Partitions for the existing rows and the coming months, then the copy.
+DO $$
+DECLARE
+    m DATE;
+BEGIN
+    FOR m IN
+        SELECT DISTINCT date_trunc('month', timestamp AT TIME ZONE 'UTC')::DATE FROM audit_logs_unpartitioned
+        UNION
+        SELECT (date_trunc('month', NOW() AT TIME ZONE 'UTC') + make_interval(months => n))::DATE
+        FROM generate_series(0, 3) AS n
+    LOOP
+        PERFORM create_audit_log_partition(m);
+    END LOOP;
+END $$;
+
+INSERT INTO audit_logs (
+    id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata,
+    ip_address, user_agent, request_id, seq, prev_hash, row_hash
+)
+SELECT
+    id, timestamp, actor_id, session_id, tenant_id, action, target_id, metadata,
+    ip_address, user_agent, request_id, seq, prev_hash, row_hash
+FROM audit_logs_unpartitioned;
+
+DROP TABLE audit_logs_unpartitioned;
+
+-- 6. Retention per tenant. No row: AUDIT_RETENTION_DEFAULT_DAYS.
+CREATE TABLE audit_retention_policies (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    retention_days INT NOT NULL CHECK (retention_days > 0),
+    updated_by UUID, -- Admin; no foreign key, like audit_logs.actor_id
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE audit_retention_policies ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_audit_retention_policies ON audit_retention_policies
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+-- 7. One row per archived chain and month. Written before the partition is
+--    dropped (dropped_at), so a crash in between re-archives the same month.
+CREATE TABLE audit_archives (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chain_key UUID NOT NULL, -- tenant_id, or the nil UUID for platform events
+    month DATE NOT NULL, -- First day of the archived UTC month
+    object_key TEXT NOT NULL, -- Blob store key of the .ndjson.gz file
+    rows BIGINT NOT NULL,
+    chained_rows BIGINT NOT NULL, -- Rows with a seq (the rest predate migration 025)
+    first_seq BIGINT,
+    last_seq BIGINT,
+    last_hash BYTEA, -- row_hash at last_seq; the verifier links the next row to it
+    sha256 TEXT NOT NULL, -- Of the compressed file
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dropped_at TIMESTAMPTZ, -- When the partition was dropped: only the archive is left
+    UNIQUE (chain_key, month)
+);
+
+-- 8. Archives loaded back for an investigation (control audit-restore). Not
+--    part of any chain and never read by the API.
+CREATE TABLE audit_logs_restored (
+    archive_id UUID NOT NULL REFERENCES audit_archives(id) ON DELETE CASCADE,
+    restored_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    id UUID NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    actor_id UUID,
+    session_id UUID,
+    tenant_id UUID,
+    action VARCHAR(255) NOT NULL,
+    target_id UUID,
+    metadata JSONB,
+    ip_address INET,
+    user_agent TEXT,
+    request_id TEXT,
+    seq BIGINT,
+    prev_hash BYTEA,
+    row_hash BYTEA,
+    PRIMARY KEY (archive_id, id)
+);
+
+CREATE INDEX idx_audit_logs_restored_tenant ON audit_logs_restored (tenant_id, timestamp DESC);
+
+-- No RLS on archives and restores: platform data, only read by the worker and cmd/control.
+COMMENT ON TABLE audit_retention_policies IS 'Audit log retention chosen by the tenant; never below AUDIT_RETENTION_MIN_DAYS.';
+COMMENT ON TABLE audit_archives IS 'Audit log months archived to the blob store before their partition was dropped.';
+COMMENT ON TABLE audit_logs_restored IS 'Archived audit rows loaded back by control audit-restore for investigation.';
@@ -0,0 +1,89 @@
+-- Migration 029: Security Event Webhooks
+-- Purpose: Let tenant applications react to security events (registration,
+--          email change, member removal, MFA) without polling. The worker
+--          reads the tenant's audit chain from a cursor per endpoint and queues
+--          one delivery per subscribed event (webhook_deliveries), which it
+--          POSTs with an HMAC signature and retries with exponential backoff,
+--          like email_outbox. Deliveries double as the admin's delivery log.
+
+CREATE TABLE webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,                       -- https only; SSRF-checked on save and on every connect
+    description VARCHAR(200),
+    secret_encrypted TEXT NOT NULL,          -- HMAC key (crypto.EncryptTenantSecret)
+    event_types TEXT[] NOT NULL CHECK (cardinality(event_types) > 0), -- audit catalogue names
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Durable cursor: seq of the last event in the tenant's chain that was queued
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    next_scan_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- The worker's lease while queueing
+
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_endpoints_tenant ON webhook_endpoints(tenant_id);
+CREATE INDEX idx_webhook_endpoints_scan ON webhook_endpoints(next_scan_at) WHERE enabled;
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,                  -- audit_logs.id; stable across redeliveries
+    event_type VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,                  -- Body as sent; signed again on every attempt
+
+    -- Status machine (worker picks 'pending', marks 'processing' → 'delivered'/'failed')
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN (
+        'pending',
+        'processing',
+        'delivered',
+        'failed'
+    )),
+
+    -- Retry logic (exponential backoff: 1m, 2m, 4m ... 64m; about two hours in total)
+    attempt_count INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_retry_at TIMESTAMPTZ DEFAULT NOW(),
+    processing_started_at TIMESTAMPTZ,
+
+    -- Outcome of the latest attempt, for the delivery log
+    response_status INT,
+    response_body TEXT,                      -- First 1 KB
+    duration_ms INT,
+    last_error TEXT,
+    last_attempt_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+
+    redelivery_of UUID,                      -- Delivery this one repeats; no FK, the janitor trims old rows
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One queued delivery per event and endpoint; redeliveries are new rows
+CREATE UNIQUE INDEX idx_webhook_deliveries_event
+    ON webhook_deliveries(endpoint_id, event_id)
+    WHERE redelivery_of IS NULL;
+
+-- Worker queue (FOR UPDATE SKIP LOCKED)
+CREATE INDEX idx_webhook_deliveries_queue
+    ON webhook_deliveries(status, next_retry_at, created_at)
+    WHERE status IN ('pending', 'processing');
+
+-- Delivery log per endpoint, newest first
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_tenant ON webhook_deliveries(tenant_id);
+
+-- RLS: Admin screens run in tenant context; the worker uses storage.WithoutRLS.
+ALTER TABLE webhook_endpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_webhook_endpoints ON webhook_endpoints
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+CREATE POLICY tenant_isolation_webhook_deliveries ON webhook_deliveries
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+COMMENT ON TABLE webhook_endpoints IS 'Per-tenant webhook subscriptions to audit events, with their queueing cursor.';
+COMMENT ON TABLE webhook_deliveries IS 'Webhook outbox and delivery log: one row per event and endpoint, retried with backoff.';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS email_template_overrides;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,34 @@
+-- Migration 030: Email Templates & Locale
+-- Purpose: Emails are rendered from html/template defaults embedded in the
+--          binary (internal/emailtemplate), as multipart text + HTML with the
+--          tenant's branding. Tenants can override subject and bodies per
+--          template and locale (email_template_overrides). The locale follows
+--          the recipient (users.locale), then the tenant's default_locale
+--          setting, then Dutch.
+
+-- 1. Recipient language. NULL: the tenant's default.
+ALTER TABLE users ADD COLUMN locale VARCHAR(5) CHECK (locale IN ('nl', 'en'));
+
+-- 2. Per-tenant template overrides. A missing part (NULL) keeps the default.
+CREATE TABLE email_template_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    template VARCHAR(50) NOT NULL,           -- mailer.EmailTemplate
+    locale VARCHAR(5) NOT NULL CHECK (locale IN ('nl', 'en')),
+    subject TEXT,                            -- text/template
+    text_body TEXT,                          -- text/template
+    html_body TEXT,                          -- html/template, rendered inside the branded layout
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_email_template_override UNIQUE (tenant_id, template, locale)
+);
+
+-- RLS: Admin screens run in tenant context; the email worker uses storage.WithoutRLS.
+ALTER TABLE email_template_overrides ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_email_template_overrides ON email_template_overrides
+    USING (tenant_id = NULLIF(current_setting('app.current_tenant', TRUE), '')::UUID);
+
+COMMENT ON COLUMN users.locale IS 'Language of emails to this user (nl, en). NULL: tenant default.';
+COMMENT ON TABLE email_template_overrides IS 'Per-tenant subject and body overrides of the embedded email templates, per locale.';
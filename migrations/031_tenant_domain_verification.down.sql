@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_tenant_domains_verified;
+DELETE FROM tenant_domains WHERE verified_at IS NULL;
+ALTER TABLE tenant_domains DROP CONSTRAINT IF EXISTS tenant_domains_tenant_domain_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_domains_domain ON tenant_domains(domain);
+ALTER TABLE tenant_domains
+    DROP COLUMN IF EXISTS last_checked_at,
+    DROP COLUMN IF EXISTS verified_at,
+    DROP COLUMN IF EXISTS verification_token;
@@ -0,0 +1,22 @@
+-- Migration 031: Tenant Domain Verification
+-- Purpose: A custom hostname only routes to its tenant once the tenant proved
+--          control of it with a DNS TXT record, like email domains (024).
+--          Several tenants may claim a hostname, only one can verify it, so an
+--          unverified claim cannot block the real owner.
+
+ALTER TABLE tenant_domains
+    ADD COLUMN verification_token VARCHAR(64), -- Published in DNS, not a secret
+    ADD COLUMN verified_at TIMESTAMPTZ,
+    ADD COLUMN last_checked_at TIMESTAMPTZ;
+
+-- Hostnames mapped before this migration keep routing.
+UPDATE tenant_domains
+SET verification_token = replace(gen_random_uuid()::text, '-', ''),
+    verified_at = created_at;
+
+ALTER TABLE tenant_domains ALTER COLUMN verification_token SET NOT NULL;
+
+DROP INDEX idx_tenant_domains_domain;
+ALTER TABLE tenant_domains ADD CONSTRAINT tenant_domains_tenant_domain_key UNIQUE (tenant_id, domain);
+CREATE UNIQUE INDEX idx_tenant_domains_verified ON tenant_domains(domain)
+    WHERE verified_at IS NOT NULL;
@@ -2,10 +2,11 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 	"os"
 
+	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/audit"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/domain"
 	"github.com/Jeffreasy/LaventeCareAuthSystems/internal/storage/db"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -61,6 +62,7 @@ func main() {
 	defer pool.Close()
 
 	queries := db.New(pool)
+	auditLogger := audit.NewAuditService(slog.Default(), audit.NewDBSink(queries, slog.Default()))
 	log.Println("✅ Connected to Database")
 
 	// 3. Hash Password (Once, reused)
@@ -132,25 +134,20 @@ func main() {
 		log.Printf("   ✅ Admin User Created: %s (Role: admin)", t.Email)
 
 		// D. AUDIT LOG (Compliance)
-		// We must manually insert the audit log since we bypassed the Service Layer
-		metadata := fmt.Sprintf(`{"method":"bootstrap_script", "slug":"%s", "admin_email":"%s"}`, t.Slug, t.Email)
-
-		err = queries.CreateAuditLog(ctx, db.CreateAuditLogParams{
-			ActorID: user.ID, // The user themselves "created" it effectively, or system.
-			// Actually, system created it. But let's attribute to the new user for traceability if they log inside.
-			// Or better: Use uuid.Nil for System?
-			// Let's use the User ID so they see it in their log.
-			SessionID: pgtype.UUID{Valid: false},
-			TenantID:  tenant.ID,
-			Action:    "tenant.bootstrap",
-			TargetID:  tenant.ID,
-			Metadata:  []byte(metadata),
+		// We bypassed the Service Layer, so log explicitly; the audit logger
+		// appends the row to the tenant's hash chain.
+		// Attributed to the new admin so they see it in their log.
+		auditLogger.Log(ctx, audit.EventTenantBootstrap, audit.LogParams{
+			ActorID:  user.ID.Bytes,
+			TenantID: tenant.ID.Bytes,
+			TargetID: tenant.ID.Bytes,
+			Metadata: map[string]interface{}{
+				"method":      "bootstrap_script",
+				"slug":        t.Slug,
+				"admin_email": t.Email,
+			},
 		})
-		if err != nil {
-			log.Printf("   ⚠️ Failed to write audit log: %v", err)
-		} else {
-			log.Printf("   ✅ Audit Log Written")
-		}
+		log.Printf("   ✅ Audit Log Written")
 	}
 
 	log.Println("🏁 Bootstrap Complete.")